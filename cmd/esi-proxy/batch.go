@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/client"
+)
+
+// maxBatchEndpoints caps the endpoints of one /esi/batch request.
+const maxBatchEndpoints = 100
+
+// esiBatcher is the part of *client.Client used by the batch handler.
+type esiBatcher interface {
+	GetMany(ctx context.Context, endpoints []string) []client.ManyResult
+}
+
+// batchRequest is the body of POST /esi/batch.
+type batchRequest struct {
+	Endpoints []string `json:"endpoints"`
+}
+
+// batchItem is the result of one endpoint in the /esi/batch response.
+type batchItem struct {
+	Endpoint string           `json:"endpoint"`
+	Status   int              `json:"status"`
+	Body     json.RawMessage  `json:"body,omitempty"`
+	Error    string           `json:"error,omitempty"`
+	Code     client.ErrorCode `json:"code,omitempty"`
+}
+
+// batchHandler serves POST /esi/batch: the listed endpoints are fetched
+// through GetMany (shared concurrency and error budget) and returned as a
+// JSON array in request order with a status per item. The batch itself
+// succeeds even if items fail.
+func batchHandler(esiClient esiBatcher, projections projections, stats *proxyStats) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req batchRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("invalid batch request: %v", err), http.StatusBadRequest)
+			return
+		}
+		if len(req.Endpoints) == 0 || len(req.Endpoints) > maxBatchEndpoints {
+			http.Error(w, fmt.Sprintf("batch must contain 1 to %d endpoints", maxBatchEndpoints), http.StatusBadRequest)
+			return
+		}
+		for _, endpoint := range req.Endpoints {
+			if !strings.HasPrefix(endpoint, "/") {
+				http.Error(w, fmt.Sprintf("invalid endpoint %q: must start with /", endpoint), http.StatusBadRequest)
+				return
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+		defer cancel()
+
+		results := esiClient.GetMany(ctx, req.Endpoints)
+		items := make([]batchItem, len(results))
+		for i, result := range results {
+			items[i] = batchResultItem(result, projections)
+			path, _, _ := strings.Cut(result.Endpoint, "?")
+			stats.record(requestTenant(r), path, items[i].Status, result.Header.Get(client.CacheStatusHeader), int64(len(items[i].Body)))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(items); err != nil {
+			log.Printf("[%s] Failed to write response: %v", requestID(r), err)
+		}
+	}
+}
+
+// batchResultItem converts a GetMany result into a response item. Failed
+// requests are reported with status 502, the error message and code; ESI
+// error statuses keep the ESI body and get a code as well.
+func batchResultItem(result client.ManyResult, projections projections) batchItem {
+	item := batchItem{Endpoint: result.Endpoint, Status: result.StatusCode}
+	if result.Err != nil {
+		item.Status = http.StatusBadGateway
+		item.Error = result.Err.Error()
+		item.Code = client.ErrorCodeOf(result.Err)
+		return item
+	}
+	if result.StatusCode >= 400 {
+		item.Code = client.StatusErrorCode(result.StatusCode)
+	}
+
+	body := result.Body
+	if result.StatusCode == http.StatusOK {
+		projected, _, err := projections.apply(result.Endpoint, body)
+		if err != nil {
+			item.Status = http.StatusBadGateway
+			item.Error = err.Error()
+			item.Code = client.ErrorCodeUnknown
+			return item
+		}
+		body = projected
+	}
+
+	if json.Valid(body) {
+		item.Body = body
+	} else if len(body) > 0 {
+		item.Error = "response is not JSON"
+	}
+	return item
+}
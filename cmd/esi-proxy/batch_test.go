@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/client"
+)
+
+// fakeBatcher answers GetMany from canned results keyed by endpoint.
+type fakeBatcher map[string]client.ManyResult
+
+func (f fakeBatcher) GetMany(ctx context.Context, endpoints []string) []client.ManyResult {
+	results := make([]client.ManyResult, len(endpoints))
+	for i, endpoint := range endpoints {
+		results[i] = f[endpoint]
+		results[i].Endpoint = endpoint
+	}
+	return results
+}
+
+func TestBatchHandler(t *testing.T) {
+	esi := fakeBatcher{
+		"/v1/status/":                  {StatusCode: http.StatusOK, Body: []byte(`{"players":1}`)},
+		"/v1/markets/10000002/orders/": {StatusCode: http.StatusOK, Body: []byte(`[{"order_id":1,"price":2}]`)},
+		"/v1/bad/":                     {StatusCode: http.StatusNotFound, Body: []byte(`{"error":"not found"}`)},
+		"/v1/down/":                    {Err: errors.New("connection refused")},
+	}
+	handler := batchHandler(esi, projections{"/v1/markets/{id}/orders/": {"price"}}, newProxyStats())
+
+	body := `{"endpoints":["/v1/status/","/v1/markets/10000002/orders/","/v1/bad/","/v1/down/"]}`
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("POST", "/esi/batch", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+
+	var items []batchItem
+	if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	want := []batchItem{
+		{Endpoint: "/v1/status/", Status: http.StatusOK, Body: json.RawMessage(`{"players":1}`)},
+		{Endpoint: "/v1/markets/10000002/orders/", Status: http.StatusOK, Body: json.RawMessage(`[{"price":2}]`)},
+		{Endpoint: "/v1/bad/", Status: http.StatusNotFound, Body: json.RawMessage(`{"error":"not found"}`), Code: client.ErrorCodeNotFound},
+		{Endpoint: "/v1/down/", Status: http.StatusBadGateway, Error: "connection refused", Code: client.ErrorCodeUnknown},
+	}
+	if len(items) != len(want) {
+		t.Fatalf("got %d items, want %d", len(items), len(want))
+	}
+	for i := range want {
+		got := items[i]
+		if got.Endpoint != want[i].Endpoint || got.Status != want[i].Status || string(got.Body) != string(want[i].Body) || got.Error != want[i].Error || got.Code != want[i].Code {
+			t.Errorf("items[%d] = %+v, want %+v", i, got, want[i])
+		}
+	}
+}
+
+func TestBatchHandler_InvalidRequests(t *testing.T) {
+	handler := batchHandler(fakeBatcher{}, nil, newProxyStats())
+
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		want   int
+	}{
+		{"GET", "GET", "", http.StatusMethodNotAllowed},
+		{"invalid json", "POST", `{`, http.StatusBadRequest},
+		{"empty", "POST", `{"endpoints":[]}`, http.StatusBadRequest},
+		{"relative path", "POST", `{"endpoints":["v1/status/"]}`, http.StatusBadRequest},
+		{"too many", "POST", `{"endpoints":[` + strings.Repeat(`"/v1/status/",`, maxBatchEndpoints) + `"/v1/status/"]}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler(w, httptest.NewRequest(tt.method, "/esi/batch", strings.NewReader(tt.body)))
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+	"github.com/Sternrassler/eve-esi-client/pkg/client"
+)
+
+// upstreamTimeout bounds a proxied ESI request.
+const upstreamTimeout = 30 * time.Second
+
+// upstreamFlight is a public ESI request shared by the downstream requests
+// that asked for the same endpoint while it was in flight.
+type upstreamFlight struct {
+	done chan struct{}
+	resp *http.Response // Body in body
+	body []byte
+	err  error
+}
+
+// coalescer issues one upstream request for identical public downstream
+// requests in flight and fans the response out to all of them. The client
+// coalesces cacheable GETs as well, but only while the request that started
+// them is live: when that downstream caller disconnects, everyone waiting on
+// it starts over. A proxy flight runs detached from its callers, so each of
+// them only gives up on its own context. Private requests (with an access
+// token) are passed through.
+type coalescer struct {
+	esiClient esiGetter
+
+	mu      sync.Mutex
+	pending map[string]*upstreamFlight
+}
+
+func newCoalescer(esiClient esiGetter) *coalescer {
+	return &coalescer{esiClient: esiClient, pending: make(map[string]*upstreamFlight)}
+}
+
+// Get implements esiGetter.
+func (c *coalescer) Get(ctx context.Context, endpoint string) (*http.Response, error) {
+	if client.AccessTokenFromContext(ctx) != "" {
+		return c.esiClient.Get(ctx, endpoint)
+	}
+
+	c.mu.Lock()
+	fl, ok := c.pending[endpoint]
+	if !ok {
+		fl = &upstreamFlight{done: make(chan struct{})}
+		c.pending[endpoint] = fl
+		// The request ID and fail-fast mode of the first caller apply
+		go c.fetch(context.WithoutCancel(ctx), endpoint, fl)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-fl.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if fl.err != nil {
+		return nil, fl.err
+	}
+
+	if ok {
+		route, _, _ := strings.Cut(endpoint, "?")
+		proxyCoalescedRequestsTotal.WithLabelValues(client.TenantFromContext(ctx), cache.EndpointPattern(route)).Inc()
+	}
+	resp := *fl.resp
+	resp.Header = fl.resp.Header.Clone()
+	resp.Body = io.NopCloser(bytes.NewReader(fl.body))
+	return &resp, nil
+}
+
+// fetch performs the upstream request of fl and publishes its result.
+func (c *coalescer) fetch(ctx context.Context, endpoint string, fl *upstreamFlight) {
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, endpoint)
+		c.mu.Unlock()
+		close(fl.done)
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, upstreamTimeout)
+	defer cancel()
+
+	resp, err := c.esiClient.Get(ctx, endpoint)
+	if err != nil {
+		fl.err = err
+		return
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fl.err = fmt.Errorf("read response body: %w", err)
+		return
+	}
+	fl.resp, fl.body = resp, body
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/client"
+)
+
+// blockingESI answers every request once release is closed.
+type blockingESI struct {
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func (b *blockingESI) Get(ctx context.Context, endpoint string) (*http.Response, error) {
+	b.calls.Add(1)
+	<-b.release
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(`{"players":21500}`)),
+	}, nil
+}
+
+func TestCoalescer_FansOutOneUpstreamRequest(t *testing.T) {
+	esi := &blockingESI{release: make(chan struct{})}
+	c := newCoalescer(esi)
+
+	// The first caller goes away; the others still get the response
+	first, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := c.Get(first, "/v1/status/")
+		firstErr <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	const waiters = 50
+	var wg sync.WaitGroup
+	bodies := make([]string, waiters)
+	for i := range waiters {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := c.Get(context.Background(), "/v1/status/")
+			if err != nil {
+				t.Errorf("Get() error = %v", err)
+				return
+			}
+			body, _ := io.ReadAll(resp.Body)
+			bodies[i] = string(body)
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	if err := <-firstErr; err != context.Canceled {
+		t.Errorf("cancelled caller: Get() error = %v, want context.Canceled", err)
+	}
+	close(esi.release)
+	wg.Wait()
+
+	if n := esi.calls.Load(); n != 1 {
+		t.Errorf("upstream requests = %d, want 1", n)
+	}
+	for i, body := range bodies {
+		if body != `{"players":21500}` {
+			t.Fatalf("waiter %d: body = %q", i, body)
+		}
+	}
+
+	// Later requests start a new flight
+	if _, err := c.Get(context.Background(), "/v1/status/"); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if n := esi.calls.Load(); n != 2 {
+		t.Errorf("upstream requests = %d, want 2", n)
+	}
+}
+
+func TestCoalescer_PrivatePassThrough(t *testing.T) {
+	esi := &blockingESI{release: make(chan struct{})}
+	close(esi.release)
+	c := newCoalescer(esi)
+
+	ctx := client.WithAccessToken(context.Background(), "token")
+	for range 2 {
+		if _, err := c.Get(ctx, "/v1/characters/90000001/wallet/"); err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+	}
+	if n := esi.calls.Load(); n != 2 {
+		t.Errorf("upstream requests = %d, want 2 for private requests", n)
+	}
+	if len(c.pending) != 0 {
+		t.Errorf("private request left a flight: %v", c.pending)
+	}
+}
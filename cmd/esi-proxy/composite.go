@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+	"github.com/Sternrassler/eve-esi-client/pkg/client"
+)
+
+// compositeStore is the part of *cache.Manager used to cache composites.
+type compositeStore interface {
+	Get(ctx context.Context, key cache.CacheKey) (*cache.CacheEntry, error)
+	Set(ctx context.Context, key cache.CacheKey, entry *cache.CacheEntry) error
+}
+
+// compositePart is the fetched response of one part of a composite.
+type compositePart struct {
+	name    string
+	body    json.RawMessage
+	expires time.Time
+	err     error
+}
+
+// compositeHandler serves the configured composite endpoints. Each part is
+// fetched through the ESI client (rate limiting, conditional requests and
+// retries apply as usual) and the aggregate is cached until the earliest
+// part expires, so a composite is never fresher than its oldest input.
+func compositeHandler(esiClient esiGetter, store compositeStore, composites []compositeConfig, projections projections, messages errorMessages, stats *proxyStats) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		composite, params, ok := matchComposite(composites, r.URL.Path)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+		defer cancel()
+
+		key := cache.CacheKey{Endpoint: r.URL.Path}
+		if entry, err := store.Get(ctx, key); err == nil {
+			status, n := writeComposite(w, r, entry.Data, entry.Expires, client.CacheStatusHit)
+			stats.record(requestTenant(r), r.URL.Path, status, client.CacheStatusHit, n)
+			return
+		}
+
+		parts := fetchCompositeParts(ctx, esiClient, composite, params, projections)
+
+		aggregate := make(map[string]json.RawMessage, len(parts))
+		expires := time.Time{}
+		for _, part := range parts {
+			if part.err != nil {
+				stats.record(requestTenant(r), r.URL.Path, http.StatusBadGateway, "", 0)
+				writeError(w, r, http.StatusBadGateway, messages, fmt.Errorf("composite part %q failed: %w", part.name, part.err))
+				return
+			}
+			aggregate[part.name] = part.body
+			if expires.IsZero() || part.expires.Before(expires) {
+				expires = part.expires
+			}
+		}
+
+		data, err := json.Marshal(aggregate)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("encode composite: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		entry := &cache.CacheEntry{
+			Data:       data,
+			Expires:    expires,
+			StatusCode: http.StatusOK,
+			CachedAt:   time.Now(),
+		}
+		if err := store.Set(ctx, key, entry); err != nil {
+			log.Printf("[%s] Failed to cache composite %s: %v", requestID(r), r.URL.Path, err)
+		}
+
+		status, n := writeComposite(w, r, data, expires, client.CacheStatusMiss)
+		stats.record(requestTenant(r), r.URL.Path, status, client.CacheStatusMiss, n)
+	}
+}
+
+// fetchCompositeParts fetches all parts of a composite concurrently.
+func fetchCompositeParts(ctx context.Context, esiClient esiGetter, composite compositeConfig, params map[string]string, projections projections) []compositePart {
+	parts := make([]compositePart, 0, len(composite.Parts))
+	for name := range composite.Parts {
+		parts = append(parts, compositePart{name: name})
+	}
+
+	var wg sync.WaitGroup
+	for i := range parts {
+		wg.Add(1)
+		go func(part *compositePart) {
+			defer wg.Done()
+			route := expandRoute(composite.Parts[part.name], params)
+			part.body, part.expires, part.err = fetchCompositePart(ctx, esiClient, route, projections)
+		}(&parts[i])
+	}
+	wg.Wait()
+
+	return parts
+}
+
+// fetchCompositePart GETs one ESI route and returns its (projected) JSON body
+// and expiry. Responses without a valid Expires header expire immediately,
+// which keeps the composite out of the cache.
+func fetchCompositePart(ctx context.Context, esiClient esiGetter, route string, projections projections) (json.RawMessage, time.Time, error) {
+	resp, err := esiClient.Get(ctx, route)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, time.Time{}, &statusError{route: route, status: resp.StatusCode}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("%s: read body: %w", route, err)
+	}
+	if !json.Valid(body) {
+		return nil, time.Time{}, fmt.Errorf("%s: response is not JSON", route)
+	}
+	body, _, err = projections.apply(route, body)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("%s: %w", route, err)
+	}
+
+	expires := time.Now()
+	if t, err := http.ParseTime(resp.Header.Get("Expires")); err == nil {
+		expires = t
+	}
+	return body, expires, nil
+}
+
+// writeComposite writes a composite response with a strong ETag, or 304 if
+// the request's If-None-Match matches it. It returns the status code and the
+// body bytes written.
+func writeComposite(w http.ResponseWriter, r *http.Request, data []byte, expires time.Time, cacheStatus string) (int, int64) {
+	etag := strongETag(data)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Expires", expires.UTC().Format(http.TimeFormat))
+	w.Header().Set(client.CacheStatusHeader, cacheStatus)
+
+	if notModified(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return http.StatusNotModified, 0
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	n, err := w.Write(data)
+	if err != nil {
+		log.Printf("[%s] Failed to write response: %v", requestID(r), err)
+	}
+	return http.StatusOK, int64(n)
+}
+
+// matchComposite finds the composite whose path template matches path and
+// returns the placeholder values.
+func matchComposite(composites []compositeConfig, path string) (compositeConfig, map[string]string, bool) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for _, composite := range composites {
+		template := strings.Split(strings.Trim(composite.Path, "/"), "/")
+		if len(template) != len(segments) {
+			continue
+		}
+
+		params := make(map[string]string)
+		matched := true
+		for i, segment := range template {
+			if name, ok := placeholder(segment); ok && segments[i] != "" {
+				params[name] = segments[i]
+				continue
+			}
+			if segment != segments[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return composite, params, true
+		}
+	}
+	return compositeConfig{}, nil, false
+}
+
+// expandRoute replaces {name} placeholders in an ESI route.
+func expandRoute(route string, params map[string]string) string {
+	for name, value := range params {
+		route = strings.ReplaceAll(route, "{"+name+"}", url.PathEscape(value))
+	}
+	return route
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+	"github.com/Sternrassler/eve-esi-client/pkg/client"
+)
+
+// memStore is an in-memory compositeStore.
+type memStore map[string]*cache.CacheEntry
+
+func (m memStore) Get(ctx context.Context, key cache.CacheKey) (*cache.CacheEntry, error) {
+	entry, ok := m[key.String()]
+	if !ok || entry.IsExpired() {
+		return nil, cache.ErrCacheMiss
+	}
+	return entry, nil
+}
+
+func (m memStore) Set(ctx context.Context, key cache.CacheKey, entry *cache.CacheEntry) error {
+	m[key.String()] = entry
+	return nil
+}
+
+func TestCompositeHandler(t *testing.T) {
+	soon := time.Now().Add(5 * time.Minute).Truncate(time.Second)
+	later := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	esi := &fakeESI{responses: map[string]fakeResponse{
+		"/v1/markets/10000002/orders/":             {status: http.StatusOK, body: `[{"order_id":1}]`, expires: soon},
+		"/v1/markets/10000002/history/?type_id=34": {status: http.StatusOK, body: `[{"volume":5}]`, expires: later},
+	}}
+	composites := []compositeConfig{{
+		Path: "/composite/market-summary/{region}",
+		Parts: map[string]string{
+			"orders":  "/v1/markets/{region}/orders/",
+			"history": "/v1/markets/{region}/history/?type_id=34",
+		},
+	}}
+	store := memStore{}
+	handler := compositeHandler(esi, store, composites, nil, nil, newProxyStats())
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/composite/market-summary/10000002", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+
+	var got map[string]json.RawMessage
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode composite: %v", err)
+	}
+	if string(got["orders"]) != `[{"order_id":1}]` || string(got["history"]) != `[{"volume":5}]` {
+		t.Errorf("composite = %s", w.Body.String())
+	}
+
+	// Cached with the minimum TTL of the parts
+	entry, err := store.Get(context.Background(), cache.CacheKey{Endpoint: "/composite/market-summary/10000002"})
+	if err != nil {
+		t.Fatalf("composite not cached: %v", err)
+	}
+	if !entry.Expires.Equal(soon) {
+		t.Errorf("composite expires = %v, want %v", entry.Expires, soon)
+	}
+	if got := w.Header().Get("Expires"); got != soon.UTC().Format(http.TimeFormat) {
+		t.Errorf("Expires header = %q", got)
+	}
+
+	// Second request is served from the cache without touching ESI
+	requests := len(esi.requested)
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/composite/market-summary/10000002", nil))
+	if len(esi.requested) != requests {
+		t.Errorf("cached composite fetched %d parts again", len(esi.requested)-requests)
+	}
+	if got := w.Header().Get(client.CacheStatusHeader); got != client.CacheStatusHit {
+		t.Errorf("%s = %q, want %q", client.CacheStatusHeader, got, client.CacheStatusHit)
+	}
+
+	// A failing part fails the composite and nothing is cached
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/composite/market-summary/10000043", nil))
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("status with failing part = %d, want %d", w.Code, http.StatusBadGateway)
+	}
+	if len(store) != 1 {
+		t.Errorf("store has %d entries, want 1", len(store))
+	}
+
+	// Unknown composites
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/composite/unknown/1", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status for unknown composite = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestLoadProxyConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr bool
+	}{
+		{"valid", `{"composites":[{"path":"/composite/a/{region}","parts":{"x":"/v1/markets/{region}/orders/"}}]}`, false},
+		{"path outside /composite/", `{"composites":[{"path":"/a/{region}","parts":{"x":"/v1/status/"}}]}`, true},
+		{"no parts", `{"composites":[{"path":"/composite/a"}]}`, true},
+		{"undefined placeholder", `{"composites":[{"path":"/composite/a","parts":{"x":"/v1/markets/{region}/orders/"}}]}`, true},
+		{"invalid json", `{`, true},
+		{"error message", `{"error_messages":{"UPSTREAM_DOWN":"ESI is down"}}`, false},
+		{"unknown error code", `{"error_messages":{"DOWN":"ESI is down"}}`, true},
+		{"tenants", `{"tenants":[{"name":"alliance-a","api_keys":["0123456789abcdef"]},{"name":"alliance-b","api_keys":["fedcba9876543210"]}]}`, false},
+		{"invalid tenant name", `{"tenants":[{"name":"Alliance A","api_keys":["0123456789abcdef"]}]}`, true},
+		{"tenant without keys", `{"tenants":[{"name":"alliance-a"}]}`, true},
+		{"short api key", `{"tenants":[{"name":"alliance-a","api_keys":["secret"]}]}`, true},
+		{"shared api key", `{"tenants":[{"name":"alliance-a","api_keys":["0123456789abcdef"]},{"name":"alliance-b","api_keys":["0123456789abcdef"]}]}`, true},
+		{"duplicate tenant", `{"tenants":[{"name":"alliance-a","api_keys":["0123456789abcdef"]},{"name":"alliance-a","api_keys":["fedcba9876543210"]}]}`, true},
+		{"min refresh intervals", `{"min_refresh_intervals":{"markets/":"5m","universe/":"1h"}}`, false},
+		{"invalid refresh interval", `{"min_refresh_intervals":{"markets/":"5"}}`, true},
+		{"capture", `{"capture":{"sample_rate":0.01,"routes":["markets/"],"max_entries":500}}`, false},
+		{"canary", `{"canary_sample_rate":0.001}`, false},
+		{"privacy", `{"privacy":{"routes":["characters/"],"mode":"hash","salt":"s3cret"}}`, false},
+		{"unknown privacy mode", `{"privacy":{"routes":["characters/"],"mode":"anonymize"}}`, true},
+		{"latest versions", `{"latest_versions":{"markets/":"v1"}}`, false},
+		{"adaptive ttl", `{"adaptive_ttl":{"enabled":true,"min":"2m","max":"6h"}}`, false},
+		{"fallback ttls", `{"default_ttl":"2m","fallback_ttls":{"status/":"30s","universe/":"24h"}}`, false},
+		{"invalid fallback ttl", `{"fallback_ttls":{"status/":"30"}}`, true},
+		{"invalid adaptive ttl", `{"adaptive_ttl":{"enabled":true,"max":"6"}}`, true},
+		{"slos", `{"slos":{"markets/":{"latency":"2s","target":0.95,"window":"1h"}}}`, false},
+		{"invalid slo latency", `{"slos":{"markets/":{"latency":"2","target":0.95}}}`, true},
+		{"backoff strategies", `{"backoff_strategies":{"server":"full_jitter","rate_limit":"decorrelated"}}`, false},
+		{"unknown backoff strategy", `{"backoff_strategies":{"server":"linear"}}`, true},
+		{"warm connections", `{"warm_connections":8,"warm_connection_interval":"45s"}`, false},
+		{"invalid warm connection interval", `{"warm_connections":8,"warm_connection_interval":"45"}`, true},
+		{"validator index", `{"validator_index_size":50000}`, false},
+		{"split body storage", `{"split_body_size":16384}`, false},
+		{"last modified routes", `{"last_modified_routes":["markets/"]}`, false},
+		{"cache replica", `{"cache_replica":{"max_lag":"2s","miss_fallback":true}}`, false},
+		{"invalid cache replica lag", `{"cache_replica":{"max_lag":"soon"}}`, true},
+		{"challenge backoff", `{"challenge_backoff":"10m"}`, false},
+		{"incident mode", `{"incident":{"status_url":"https://esi.evetech.net/status.json?version=latest","poll_interval":"1m","max_concurrency":2}}`, false},
+		{"invalid incident poll interval", `{"incident":{"status_url":"https://esi.evetech.net/status.json","poll_interval":"often"}}`, true},
+		{"max in-flight bytes", `{"max_inflight_bytes":67108864}`, false},
+		{"stuck request threshold", `{"stuck_request_threshold":"1m"}`, false},
+		{"timeouts", `{"timeouts":{"request":"15s","page":"1m","admin":"30m"}}`, false},
+		{"invalid timeout", `{"timeouts":{"admin":"long"}}`, true},
+		{"sso", `{"sso":{"client_id":"0123456789abcdef","jwks_url":"https://login.eveonline.com/oauth/jwks"}}`, false},
+		{"warmup", `{"warmup":{"endpoints":["/v1/markets/prices/","/v1/status/"],"redis_key":"esi:warmup","concurrency":4}}`, false},
+		{"warmup endpoint without slash", `{"warmup":{"endpoints":["v1/status/"]}}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "proxy.json")
+			if err := os.WriteFile(path, []byte(tt.config), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := loadProxyConfig(path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("loadProxyConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCompositeHandler_ConditionalGET(t *testing.T) {
+	esi := &fakeESI{responses: map[string]fakeResponse{
+		"/v1/status/": {status: http.StatusOK, body: `{"players":1}`, expires: time.Now().Add(time.Minute)},
+	}}
+	composites := []compositeConfig{{Path: "/composite/status", Parts: map[string]string{"status": "/v1/status/"}}}
+	handler := compositeHandler(esi, memStore{}, composites, nil, nil, newProxyStats())
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/composite/status", nil))
+	etag := w.Header().Get("ETag")
+	if etag == "" || etag[0] != '"' {
+		t.Fatalf("ETag = %q, want a strong validator", etag)
+	}
+
+	req := httptest.NewRequest("GET", "/composite/status", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	handler(w, req)
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("revalidation = %d with %d bytes, want 304 without body", w.Code, w.Body.Len())
+	}
+}
@@ -0,0 +1,376 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/client"
+)
+
+// proxyConfig is the optional JSON configuration file named by PROXY_CONFIG.
+type proxyConfig struct {
+	// Composites are downstream endpoints that aggregate several ESI routes
+	Composites []compositeConfig `json:"composites"`
+
+	// Projections limit the JSON fields returned for a route
+	Projections projections `json:"projections"`
+
+	// ErrorMessages override the user-facing message of proxy errors by code
+	ErrorMessages errorMessages `json:"error_messages"`
+
+	// Tenants require an API key per request and isolate private cache
+	// entries per tenant (optional)
+	Tenants tenants `json:"tenants"`
+
+	// SSO verifies the access tokens of private requests (optional)
+	SSO ssoConfig `json:"sso"`
+
+	// MinRefreshIntervals collapse polls of a route prefix onto the cached
+	// value until the interval has passed (see Config.MinRefreshIntervals)
+	MinRefreshIntervals routeDurations `json:"min_refresh_intervals"`
+
+	// DefaultTTL and FallbackTTLs expire responses without Expires header
+	// (see Config.DefaultTTL)
+	DefaultTTL   duration       `json:"default_ttl"`
+	FallbackTTLs routeDurations `json:"fallback_ttls"`
+
+	// LatestVersions canonicalizes /latest/ routes to the version they
+	// resolve to in cache keys (see Config.LatestVersions)
+	LatestVersions map[string]string `json:"latest_versions"`
+
+	// Capture enables debug capture of ESI exchanges (see Config.CaptureSampleRate)
+	Capture captureConfig `json:"capture"`
+
+	// CanarySampleRate compares that fraction of cache hits with a live
+	// fetch (see Config.CanarySampleRate)
+	CanarySampleRate float64 `json:"canary_sample_rate"`
+
+	// Privacy hides IDs of private routes in metric labels and logs (see
+	// Config.PrivateRoutes)
+	Privacy privacyConfig `json:"privacy"`
+
+	// AdaptiveTTL learns TTLs for responses without Expires header (see
+	// Config.AdaptiveTTL)
+	AdaptiveTTL adaptiveTTLConfig `json:"adaptive_ttl"`
+
+	// SLOs are latency and availability objectives by route prefix (see
+	// Config.SLOs)
+	SLOs map[string]sloConfig `json:"slos"`
+
+	// BackoffStrategies select the retry backoff per error class (see
+	// Config.BackoffStrategies)
+	BackoffStrategies backoffStrategies `json:"backoff_strategies"`
+
+	// WarmConnections keeps that many connections to ESI open, re-opened
+	// after WarmConnectionInterval idle (see Config.WarmConnections)
+	WarmConnections        int      `json:"warm_connections"`
+	WarmConnectionInterval duration `json:"warm_connection_interval"`
+
+	// ValidatorIndexSize keeps the validators of that many entries in
+	// memory (see Config.ValidatorIndexSize)
+	ValidatorIndexSize int `json:"validator_index_size"`
+
+	// SplitBodySize stores bodies of at least that many bytes apart from
+	// their metadata (see Config.SplitBodySize)
+	SplitBodySize int `json:"split_body_size"`
+
+	// CacheReplica tunes reads from REDIS_REPLICA_URL (see
+	// Config.CacheReplicaMaxLag)
+	CacheReplica cacheReplicaConfig `json:"cache_replica"`
+
+	// LastModifiedRoutes revalidate with If-Modified-Since instead of the
+	// ETag (see Config.LastModifiedRoutes)
+	LastModifiedRoutes []string `json:"last_modified_routes"`
+
+	// ChallengeBackoff pauses ESI requests after a Cloudflare challenge
+	// (see Config.ChallengeBackoff)
+	ChallengeBackoff duration `json:"challenge_backoff"`
+
+	// Incident polls a status feed and enters incident mode while it
+	// declares an incident (see Config.IncidentStatusURL)
+	Incident incidentConfig `json:"incident"`
+
+	// MaxInFlightBytes caps the response bytes buffered in memory (see
+	// Config.MaxInFlightBytes)
+	MaxInFlightBytes int64 `json:"max_inflight_bytes"`
+
+	// StuckRequestThreshold logs requests running longer (see
+	// Config.StuckRequestThreshold)
+	StuckRequestThreshold duration `json:"stuck_request_threshold"`
+
+	// Timeouts are the default deadlines by operation type (see
+	// Config.RequestTimeout)
+	Timeouts timeoutsConfig `json:"timeouts"`
+
+	// Warmup lists endpoints fetched with low priority at startup
+	Warmup warmupConfig `json:"warmup"`
+}
+
+// sloConfig is the objective of one route prefix, e.g.
+//
+//	{"markets/": {"latency": "2s", "target": 0.95, "window": "1h"}}
+type sloConfig struct {
+	Latency duration `json:"latency"`
+	Target  float64  `json:"target"`
+	Window  duration `json:"window"`
+}
+
+// clientSLOs converts the SLOs to the client configuration.
+func (c *proxyConfig) clientSLOs() map[string]client.SLO {
+	if len(c.SLOs) == 0 {
+		return nil
+	}
+	slos := make(map[string]client.SLO, len(c.SLOs))
+	for route, slo := range c.SLOs {
+		slos[route] = client.SLO{
+			Latency: time.Duration(slo.Latency),
+			Target:  slo.Target,
+			Window:  time.Duration(slo.Window),
+		}
+	}
+	return slos
+}
+
+// adaptiveTTLConfig enables adaptive TTLs with optional bounds, e.g.
+//
+//	{"enabled": true, "min": "2m", "max": "6h"}
+type adaptiveTTLConfig struct {
+	Enabled bool     `json:"enabled"`
+	Min     duration `json:"min"`
+	Max     duration `json:"max"`
+}
+
+// duration is a time.Duration written like "5m".
+type duration time.Duration
+
+// UnmarshalJSON parses the duration.
+func (d *duration) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(raw)
+	if err != nil {
+		return err
+	}
+	*d = duration(parsed)
+	return nil
+}
+
+// captureConfig selects the requests recorded in debug capture mode, e.g.
+//
+//	{"sample_rate": 0.01, "routes": ["markets/"], "max_entries": 500}
+type captureConfig struct {
+	SampleRate float64  `json:"sample_rate"`
+	Routes     []string `json:"routes"`
+	MaxEntries int      `json:"max_entries"`
+}
+
+// cacheReplicaConfig controls the stale reads tolerated from the cache read
+// replica, e.g. {"max_lag": "2s", "miss_fallback": true}.
+type cacheReplicaConfig struct {
+	MaxLag       duration `json:"max_lag"`
+	MissFallback bool     `json:"miss_fallback"`
+}
+
+// incidentConfig selects the status feed driving incident mode, e.g.
+//
+//	{"status_url": "https://esi.evetech.net/status.json?version=latest", "poll_interval": "1m", "max_concurrency": 2}
+type incidentConfig struct {
+	StatusURL      string   `json:"status_url"`
+	PollInterval   duration `json:"poll_interval"`
+	MaxConcurrency int      `json:"max_concurrency"`
+}
+
+// ssoConfig selects how access tokens are verified, e.g.
+//
+//	{"client_id": "..."}
+//
+// ClientID restricts private requests to tokens issued to the application;
+// JWKSURL overrides the EVE SSO signing keys (see sso.DefaultJWKSURL).
+type ssoConfig struct {
+	ClientID string `json:"client_id"`
+	JWKSURL  string `json:"jwks_url"`
+}
+
+// timeoutsConfig sets the default deadlines of calls without deadline, e.g.
+//
+//	{"request": "15s", "page": "1m", "admin": "30m"}
+type timeoutsConfig struct {
+	Request duration `json:"request"`
+	Page    duration `json:"page"`
+	Admin   duration `json:"admin"`
+}
+
+// privacyConfig selects the routes reported without IDs, e.g.
+//
+//	{"routes": ["characters/"], "mode": "hash", "salt": "..."}
+//
+// Mode is "redact" (default), "hash" or "omit" (see client.PrivacyMode).
+type privacyConfig struct {
+	Routes []string    `json:"routes"`
+	Mode   privacyMode `json:"mode"`
+	Salt   string      `json:"salt"`
+}
+
+// privacyMode is a client.PrivacyMode written by name.
+type privacyMode client.PrivacyMode
+
+// UnmarshalJSON parses the mode.
+func (m *privacyMode) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	for _, mode := range []client.PrivacyMode{client.PrivacyRedact, client.PrivacyHash, client.PrivacyOmit} {
+		if raw == mode.String() {
+			*m = privacyMode(mode)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown privacy mode %q (want redact, hash or omit)", raw)
+}
+
+// backoffStrategies maps retried error classes to backoff strategies by
+// name, e.g.
+//
+//	{"server": "full_jitter", "rate_limit": "decorrelated", "network": "fibonacci"}
+type backoffStrategies map[client.ErrorClass]client.BackoffStrategy
+
+// backoffStrategyNames are the names of the built-in strategies.
+var backoffStrategyNames = map[string]client.BackoffStrategy{
+	"exponential":  client.ExponentialJitter,
+	"full_jitter":  client.ExponentialFullJitter,
+	"decorrelated": client.DecorrelatedJitter,
+	"fibonacci":    client.FibonacciBackoff,
+	"fixed":        client.FixedBackoff,
+}
+
+// UnmarshalJSON parses the strategy names.
+func (b *backoffStrategies) UnmarshalJSON(data []byte) error {
+	var raw map[string]string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	strategies := make(backoffStrategies, len(raw))
+	for class, name := range raw {
+		strategy, ok := backoffStrategyNames[name]
+		if !ok {
+			return fmt.Errorf("error class %q: unknown backoff strategy %q", class, name)
+		}
+		strategies[client.ErrorClass(class)] = strategy
+	}
+	*b = strategies
+	return nil
+}
+
+// routeDurations maps route prefixes to durations written like "5m", e.g.
+//
+//	{"markets/": "5m", "universe/": "1h"}
+type routeDurations map[string]time.Duration
+
+// UnmarshalJSON parses the durations.
+func (r *routeDurations) UnmarshalJSON(data []byte) error {
+	var raw map[string]string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	durations := make(routeDurations, len(raw))
+	for route, value := range raw {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("route %q: %w", route, err)
+		}
+		durations[route] = d
+	}
+	*r = durations
+	return nil
+}
+
+// compositeConfig defines one composite endpoint, e.g.
+//
+//	{
+//	  "path": "/composite/market-summary/{region}",
+//	  "parts": {
+//	    "orders":  "/v1/markets/{region}/orders/",
+//	    "history": "/v1/markets/{region}/history/?type_id=34"
+//	  }
+//	}
+//
+// The response is a JSON object with one field per part holding the ESI
+// response body. Placeholders in part routes are filled from the path.
+type compositeConfig struct {
+	Path  string            `json:"path"`
+	Parts map[string]string `json:"parts"`
+}
+
+// loadProxyConfig reads and validates the configuration file at path.
+func loadProxyConfig(path string) (*proxyConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read proxy config: %w", err)
+	}
+
+	var cfg proxyConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse proxy config: %w", err)
+	}
+
+	for _, composite := range cfg.Composites {
+		if err := composite.validate(); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.Projections.validate(); err != nil {
+		return nil, err
+	}
+	if err := cfg.ErrorMessages.validate(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Tenants.validate(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Warmup.validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// validate checks that the path is under /composite/ and that every
+// placeholder used by a part is defined by the path.
+func (c compositeConfig) validate() error {
+	if !strings.HasPrefix(c.Path, "/composite/") {
+		return fmt.Errorf("composite %q: path must start with /composite/", c.Path)
+	}
+	if len(c.Parts) == 0 {
+		return fmt.Errorf("composite %q: no parts", c.Path)
+	}
+
+	params := make(map[string]bool)
+	for _, segment := range strings.Split(strings.Trim(c.Path, "/"), "/") {
+		if name, ok := placeholder(segment); ok {
+			params[name] = true
+		}
+	}
+	for name, route := range c.Parts {
+		for _, segment := range strings.FieldsFunc(route, func(r rune) bool { return r == '/' || r == '?' || r == '&' || r == '=' }) {
+			if param, ok := placeholder(segment); ok && !params[param] {
+				return fmt.Errorf("composite %q: part %q uses undefined placeholder {%s}", c.Path, name, param)
+			}
+		}
+	}
+	return nil
+}
+
+// placeholder returns the name of a {name} path segment.
+func placeholder(segment string) (string, bool) {
+	if len(segment) > 2 && segment[0] == '{' && segment[len(segment)-1] == '}' {
+		return segment[1 : len(segment)-1], true
+	}
+	return "", false
+}
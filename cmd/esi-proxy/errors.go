@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/client"
+)
+
+// errorMessages are user-facing messages by error code. Codes missing from
+// the "error_messages" section of PROXY_CONFIG use defaultErrorMessages.
+type errorMessages map[client.ErrorCode]string
+
+// defaultErrorMessages are shown when no message is configured for a code.
+var defaultErrorMessages = errorMessages{
+	client.ErrorCodeRateLimited:    "Too many requests to the EVE Online API, please try again shortly.",
+	client.ErrorCodeUpstreamDown:   "The EVE Online API is currently unavailable.",
+	client.ErrorCodeUnauthorized:   "Not authorized to access this EVE Online data.",
+	client.ErrorCodeNotFound:       "The requested EVE Online data does not exist.",
+	client.ErrorCodeRetryExhausted: "The EVE Online API did not respond in time, please try again later.",
+	client.ErrorCodeUnknown:        "The request to the EVE Online API failed.",
+}
+
+// validate rejects messages for unknown error codes (typos would silently
+// fall back to the defaults).
+func (m errorMessages) validate() error {
+	for code := range m {
+		if _, ok := defaultErrorMessages[code]; !ok {
+			return fmt.Errorf("error_messages: unknown error code %q", code)
+		}
+	}
+	return nil
+}
+
+// message returns the user-facing message for code.
+func (m errorMessages) message(code client.ErrorCode) string {
+	if msg, ok := m[code]; ok {
+		return msg
+	}
+	return defaultErrorMessages[code]
+}
+
+// errorResponse is the JSON body of errors generated by the proxy.
+type errorResponse struct {
+	Code      client.ErrorCode `json:"code"`
+	Message   string           `json:"message"`
+	Detail    string           `json:"detail,omitempty"`
+	RequestID string           `json:"request_id,omitempty"`
+
+	// ESIRequestID is ESI's X-ESI-Request-ID of the failed call, for
+	// escalating to CCP
+	ESIRequestID string `json:"esi_request_id,omitempty"`
+}
+
+// statusError reports an unexpected ESI status for a route.
+type statusError struct {
+	route  string
+	status int
+}
+
+// Error implements the error interface.
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s: status %d", e.route, e.status)
+}
+
+// errorCode returns the error code for err.
+func errorCode(err error) client.ErrorCode {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return client.StatusErrorCode(statusErr.status)
+	}
+	return client.ErrorCodeOf(err)
+}
+
+// upstreamErrorStatus returns the status for a failed ESI request: 429 with
+// Retry-After when the client rejected it because the error budget is low,
+// 502 otherwise.
+func upstreamErrorStatus(w http.ResponseWriter, err error) int {
+	var bpErr *client.BackpressureError
+	if !errors.As(err, &bpErr) {
+		return http.StatusBadGateway
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(bpErr.RetryAfter.Round(time.Second)/time.Second)))
+	return http.StatusTooManyRequests
+}
+
+// writeError writes err as a JSON error response with its error code, the
+// configured message for the code, the error text as detail and the request
+// ID to quote in support tickets. The error is logged with the request ID.
+func writeError(w http.ResponseWriter, r *http.Request, status int, messages errorMessages, err error) {
+	code := errorCode(err)
+	log.Printf("[%s] %s %s: %s: %v", requestID(r), r.Method, r.URL.Path, code, err)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	body := errorResponse{
+		Code:      code,
+		Message:   messages.message(code),
+		Detail:    err.Error(),
+		RequestID: requestID(r),
+	}
+	var esiErr *client.ESIError
+	if errors.As(err, &esiErr) {
+		body.ESIRequestID = esiErr.RequestID
+	}
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("[%s] Failed to write response: %v", requestID(r), err)
+	}
+}
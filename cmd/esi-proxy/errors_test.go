@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/client"
+)
+
+// getterFunc adapts a function to esiGetter.
+type getterFunc func(ctx context.Context, endpoint string) (*http.Response, error)
+
+func (f getterFunc) Get(ctx context.Context, endpoint string) (*http.Response, error) {
+	return f(ctx, endpoint)
+}
+
+func TestESIProxyHandler_ErrorCodes(t *testing.T) {
+	messages := errorMessages{client.ErrorCodeRateLimited: "Slow down"}
+
+	tests := []struct {
+		name             string
+		err              error
+		wantCode         client.ErrorCode
+		wantMessage      string
+		wantESIRequestID string
+	}{
+		{"configured message", fmt.Errorf("request blocked: %w", client.ErrRateLimited), client.ErrorCodeRateLimited, "Slow down", ""},
+		{"default message", &client.ESIError{StatusCode: 502, ErrorClass: client.ErrorClassServer}, client.ErrorCodeUpstreamDown, defaultErrorMessages[client.ErrorCodeUpstreamDown], ""},
+		{"downtime", &client.ESIError{StatusCode: 503, ErrorClass: client.ErrorClassServer, Err: client.ErrDowntime}, client.ErrorCodeUpstreamDown, defaultErrorMessages[client.ErrorCodeUpstreamDown], ""},
+		{"ESI request ID", fmt.Errorf("retries exhausted: %w", &client.ESIError{StatusCode: 500, ErrorClass: client.ErrorClassServer, RequestID: "9f3c4e1a"}), client.ErrorCodeUpstreamDown, defaultErrorMessages[client.ErrorCodeUpstreamDown], "9f3c4e1a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			esi := getterFunc(func(ctx context.Context, endpoint string) (*http.Response, error) {
+				return nil, tt.err
+			})
+			handler := esiProxyHandler(esi, nil, messages, newProxyStats())
+
+			w := httptest.NewRecorder()
+			handler(w, httptest.NewRequest("GET", "/esi/v1/status/", nil))
+			if w.Code != http.StatusBadGateway {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusBadGateway)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", ct)
+			}
+
+			var body errorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if body.Code != tt.wantCode || body.Message != tt.wantMessage || body.Detail == "" {
+				t.Errorf("body = %+v, want code %s, message %q and detail", body, tt.wantCode, tt.wantMessage)
+			}
+			if body.ESIRequestID != tt.wantESIRequestID {
+				t.Errorf("esi_request_id = %q, want %q", body.ESIRequestID, tt.wantESIRequestID)
+			}
+		})
+	}
+}
+
+func TestCompositeHandler_ErrorCode(t *testing.T) {
+	esi := &fakeESI{responses: map[string]fakeResponse{
+		"/v5/characters/1/": {status: http.StatusNotFound, body: `{"error":"not found"}`},
+	}}
+	composites := []compositeConfig{{
+		Path:  "/composite/character/{id}",
+		Parts: map[string]string{"character": "/v5/characters/{id}/"},
+	}}
+	handler := compositeHandler(esi, memStore{}, composites, nil, nil, newProxyStats())
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/composite/character/1", nil))
+
+	var body errorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode response: %v (status %d)", err, w.Code)
+	}
+	if body.Code != client.ErrorCodeNotFound {
+		t.Errorf("code = %q, want %q", body.Code, client.ErrorCodeNotFound)
+	}
+}
+
+func TestESIProxyHandler_Backpressure(t *testing.T) {
+	esi := getterFunc(func(ctx context.Context, endpoint string) (*http.Response, error) {
+		return nil, &client.BackpressureError{RetryAfter: 42 * time.Second}
+	})
+	handler := esiProxyHandler(esi, nil, nil, newProxyStats())
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/esi/v1/status/", nil))
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	if got := w.Header().Get("Retry-After"); got != "42" {
+		t.Errorf("Retry-After = %q, want 42", got)
+	}
+
+	var body errorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if body.Code != client.ErrorCodeRateLimited {
+		t.Errorf("code = %s, want %s", body.Code, client.ErrorCodeRateLimited)
+	}
+}
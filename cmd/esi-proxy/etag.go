@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+	"github.com/cespare/xxhash/v2"
+)
+
+// strongETag returns a strong validator for a response body generated by the
+// proxy (composites, projections), where ESI's own ETag does not describe the
+// bytes sent downstream.
+func strongETag(body []byte) string {
+	return fmt.Sprintf(`"%016x"`, xxhash.Sum64(body))
+}
+
+// notModified reports whether the request's If-None-Match header matches
+// etag. If-None-Match uses weak comparison (RFC 9110 13.1.2), so a W/ prefix
+// on either side is ignored.
+func notModified(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" || etag == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || cache.WeakETagMatch(candidate, etag) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNotModified(t *testing.T) {
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		etag        string
+		want        bool
+	}{
+		{"no header", "", `"a"`, false},
+		{"match", `"a"`, `"a"`, true},
+		{"list", `"b", "a"`, `"a"`, true},
+		{"weak candidate", `W/"a"`, `"a"`, true},
+		{"weak etag", `"a"`, `W/"a"`, true},
+		{"both weak", `"b", W/"a"`, `W/"a"`, true},
+		{"weak mismatch", `W/"b"`, `W/"a"`, false},
+		{"wildcard", `*`, `"a"`, true},
+		{"mismatch", `"b"`, `"a"`, false},
+		{"no etag", `"a"`, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			if tt.ifNoneMatch != "" {
+				r.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			if got := notModified(r, tt.etag); got != tt.want {
+				t.Errorf("notModified(%q, %q) = %v, want %v", tt.ifNoneMatch, tt.etag, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+	"github.com/Sternrassler/eve-esi-client/pkg/client"
+)
+
+const (
+	// inspectPreviewLines limits the body preview of --inspect-cache.
+	inspectPreviewLines = 40
+
+	// diffMaxLines limits the changed lines shown per side by --diff-cache.
+	diffMaxLines = 40
+)
+
+// runInspectCache prints the cached entry of endpoint and returns the
+// process exit code.
+func runInspectCache(esiClient *client.Client, endpoint string) int {
+	key, entry, err := esiClient.InspectCache(context.Background(), endpoint)
+	if errors.Is(err, client.ErrCacheMiss) {
+		fmt.Printf("Key: %s\nNot cached\n", key)
+		return 1
+	}
+	if err != nil {
+		log.Printf("Inspect failed: %v", err)
+		return 1
+	}
+
+	writeCacheEntry(os.Stdout, key, entry, time.Now())
+	fmt.Println()
+	fmt.Println(bodyPreview(entry.Data, inspectPreviewLines))
+	return 0
+}
+
+// runDiffCache compares the cached entry of endpoint with a live fetch and
+// returns the process exit code: 0 if equal, 2 if the bodies differ.
+func runDiffCache(esiClient *client.Client, endpoint string) int {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	diff, err := esiClient.DiffCache(ctx, endpoint)
+	if err != nil {
+		log.Printf("Diff failed: %v", err)
+		return 1
+	}
+
+	writeCacheDiff(os.Stdout, diff, time.Now())
+	if !diff.Equal {
+		return 2
+	}
+	return 0
+}
+
+// writeCacheEntry writes the metadata and headers of a cache entry.
+func writeCacheEntry(w io.Writer, key string, entry *cache.CacheEntry, now time.Time) {
+	fmt.Fprintf(w, "Key:           %s\n", key)
+	fmt.Fprintf(w, "Status:        %d\n", entry.StatusCode)
+	fmt.Fprintf(w, "Cached at:     %s\n", relativeTime(entry.CachedAt, now))
+	if !entry.ValidatedAt.IsZero() {
+		fmt.Fprintf(w, "Validated at:  %s\n", relativeTime(entry.ValidatedAt, now))
+	}
+	fmt.Fprintf(w, "Expires:       %s\n", relativeTime(entry.Expires, now))
+	if entry.ETag != "" {
+		fmt.Fprintf(w, "ETag:          %s\n", entry.ETag)
+	}
+	if !entry.LastModified.IsZero() {
+		fmt.Fprintf(w, "Last-Modified: %s\n", entry.LastModified.UTC().Format(time.RFC3339))
+	}
+	fmt.Fprintf(w, "Size:          %d bytes\n", len(entry.Data))
+	if entry.Preloaded {
+		fmt.Fprintf(w, "Preloaded:     true (not yet confirmed by ESI)\n")
+	}
+
+	if len(entry.Headers) > 0 {
+		fmt.Fprintln(w, "Headers:")
+		names := make([]string, 0, len(entry.Headers))
+		for name := range entry.Headers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(w, "  %s: %s\n", name, strings.Join(entry.Headers[name], ", "))
+		}
+	}
+}
+
+// writeCacheDiff writes the cached entry, the live response metadata and the
+// changed lines of the (pretty-printed) bodies.
+func writeCacheDiff(w io.Writer, diff *client.CacheDiff, now time.Time) {
+	if diff.Cached == nil {
+		fmt.Fprintf(w, "Key:           %s\nNot cached before the live fetch\n", diff.Key)
+	} else {
+		writeCacheEntry(w, diff.Key, diff.Cached, now)
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Live status:   %d\n", diff.LiveStatus)
+	for _, name := range []string{"ETag", "Last-Modified", "Expires"} {
+		if value := diff.LiveHeader.Get(name); value != "" {
+			fmt.Fprintf(w, "Live %-9s %s\n", name+":", value)
+		}
+	}
+	fmt.Fprintf(w, "Live size:     %d bytes\n", len(diff.LiveBody))
+	fmt.Fprintln(w)
+
+	switch {
+	case diff.Cached == nil:
+		return
+	case diff.Equal:
+		fmt.Fprintln(w, "Bodies are equal")
+		return
+	}
+
+	fmt.Fprintln(w, "Bodies differ (- cached, + live):")
+	for _, line := range lineDiff(prettyLines(diff.Cached.Data), prettyLines(diff.LiveBody), diffMaxLines) {
+		fmt.Fprintln(w, line)
+	}
+}
+
+// relativeTime formats t with its distance to now, e.g.
+// "2026-01-01T12:00:00Z (5m0s ago)".
+func relativeTime(t, now time.Time) string {
+	d := t.Sub(now).Round(time.Second)
+	if d < 0 {
+		return fmt.Sprintf("%s (%s ago)", t.UTC().Format(time.RFC3339), -d)
+	}
+	return fmt.Sprintf("%s (in %s)", t.UTC().Format(time.RFC3339), d)
+}
+
+// prettyLines returns data split into lines, indented first if it is JSON.
+func prettyLines(data []byte) []string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err == nil {
+		data = buf.Bytes()
+	}
+	return strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+}
+
+// bodyPreview returns the first maxLines lines of the pretty-printed body.
+func bodyPreview(data []byte, maxLines int) string {
+	lines := prettyLines(data)
+	if len(lines) > maxLines {
+		lines = append(lines[:maxLines], fmt.Sprintf("... (%d more lines)", len(lines)-maxLines))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// lineDiff returns the lines between the common prefix and suffix of a and b,
+// removed ones prefixed "-" and added ones "+", at most maxLines per side.
+// It is no minimal diff, but points at the changed region of ESI bodies,
+// which keep their order between versions.
+func lineDiff(a, b []string, maxLines int) []string {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	out := []string{fmt.Sprintf("@@ line %d @@", prefix+1)}
+	for _, side := range []struct {
+		sign  string
+		lines []string
+	}{
+		{"-", a[prefix : len(a)-suffix]},
+		{"+", b[prefix : len(b)-suffix]},
+	} {
+		for i, line := range side.lines {
+			if i == maxLines {
+				out = append(out, fmt.Sprintf("%s ... (%d more lines)", side.sign, len(side.lines)-maxLines))
+				break
+			}
+			out = append(out, side.sign+line)
+		}
+	}
+	return out
+}
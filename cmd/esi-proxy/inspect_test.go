@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+	"github.com/Sternrassler/eve-esi-client/pkg/client"
+)
+
+func TestLineDiff(t *testing.T) {
+	a := []string{"[", "  1,", "  2,", "  3", "]"}
+	b := []string{"[", "  1,", "  5,", "  6,", "  3", "]"}
+
+	got := lineDiff(a, b, 10)
+	want := []string{"@@ line 3 @@", "-  2,", "+  5,", "+  6,"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("lineDiff() = %q, want %q", got, want)
+	}
+
+	got = lineDiff(a, b, 1)
+	want = []string{"@@ line 3 @@", "-  2,", "+  5,", "+ ... (1 more lines)"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("lineDiff() limited = %q, want %q", got, want)
+	}
+}
+
+func TestBodyPreview(t *testing.T) {
+	if got, want := bodyPreview([]byte(`{"a":1,"b":2}`), 10), "{\n  \"a\": 1,\n  \"b\": 2\n}"; got != want {
+		t.Errorf("bodyPreview() = %q, want %q", got, want)
+	}
+	if got, want := bodyPreview([]byte("not json"), 10), "not json"; got != want {
+		t.Errorf("bodyPreview() = %q, want %q", got, want)
+	}
+	if got := bodyPreview([]byte(`[1,2,3,4]`), 2); !strings.HasSuffix(got, "... (4 more lines)") {
+		t.Errorf("bodyPreview() = %q, want truncation note", got)
+	}
+}
+
+func TestWriteCacheDiff(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	cached := &cache.CacheEntry{
+		Data:       []byte(`{"players":1}`),
+		ETag:       `"abc"`,
+		StatusCode: http.StatusOK,
+		Headers:    http.Header{"Content-Type": {"application/json"}},
+		CachedAt:   now.Add(-10 * time.Minute),
+		Expires:    now.Add(-5 * time.Minute),
+	}
+	diff := &client.CacheDiff{
+		Key:        "esi:v1/status",
+		Cached:     cached,
+		LiveStatus: http.StatusOK,
+		LiveHeader: http.Header{"Etag": {`"def"`}},
+		LiveBody:   []byte(`{"players":2}`),
+	}
+
+	var buf bytes.Buffer
+	writeCacheDiff(&buf, diff, now)
+	out := buf.String()
+	for _, want := range []string{
+		"Key:           esi:v1/status",
+		"Cached at:     2026-01-01T11:50:00Z (10m0s ago)",
+		"Expires:       2026-01-01T11:55:00Z (5m0s ago)",
+		"ETag:          \"abc\"",
+		"Content-Type: application/json",
+		"Live ETag:     \"def\"",
+		"-  \"players\": 1",
+		"+  \"players\": 2",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output misses %q:\n%s", want, out)
+		}
+	}
+
+	diff.Equal = true
+	buf.Reset()
+	writeCacheDiff(&buf, diff, now)
+	if !strings.Contains(buf.String(), "Bodies are equal") {
+		t.Errorf("output of equal bodies:\n%s", buf.String())
+	}
+}
@@ -1,29 +1,154 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
 	"github.com/Sternrassler/eve-esi-client/pkg/client"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/redis/go-redis/v9"
 )
 
 func main() {
+	check := flag.Bool("check", false, "Run the startup self-check and exit (non-zero on failure)")
+	exportState := flag.String("export-state", "", "Export rate limit state to `file` and exit (blue-green migration)")
+	exportCache := flag.Bool("export-cache", false, "Include cache entries in --export-state")
+	importState := flag.String("import-state", "", "Import state from `file` into REDIS_URL and exit")
+	replicateTo := flag.String("replicate-cache-to", "", "Copy cache entries to the Redis at `addr` and exit (warm standby)")
+	replicatePrefixes := flag.String("replicate-prefixes", "", "Comma-separated endpoint `prefixes` for --replicate-cache-to (default: all)")
+	dumpCache := flag.String("dump-cache", "", "Write live cache entries as JSON lines to `file` and exit (analytics, --preload-cache)")
+	dumpPrefix := flag.String("dump-prefix", "", "Endpoint `prefix` for --dump-cache, e.g. /v1/markets/ (default: all)")
+	migrateKeys := flag.Bool("migrate-keys", false, "Move cache entries stored under legacy key formats to the current keys and exit")
+	migrateDryRun := flag.Bool("migrate-dry-run", false, "Only count the legacy entries for --migrate-keys")
+	preloadCache := flag.String("preload-cache", "", "Load a cache dump (JSON lines) from `file` into REDIS_URL and exit")
+	preloadTTL := flag.Duration("preload-ttl", 15*time.Minute, "Lifetime of entries loaded by --preload-cache")
+	preloadMaxAge := flag.Duration("preload-max-age", 0, "Skip dump records that expired longer than `duration` ago (0 = load all)")
+	dumpCaptures := flag.String("dump-captures", "", "Write debug captures (see \"capture\" in PROXY_CONFIG) as JSON lines to `file` and exit")
+	inspectCache := flag.String("inspect-cache", "", "Print the cached entry of `endpoint` (e.g. /v1/markets/prices/) and exit")
+	diffCache := flag.String("diff-cache", "", "Compare the cached entry of `endpoint` with a forced live fetch and exit (2 if they differ)")
+	memoryUsage := flag.String("memory-usage", "", "Print the Redis memory used per endpoint family below `prefix` (/ for all) and exit")
+	memoryDepth := flag.Int("memory-depth", 2, "Endpoint path segments per family for --memory-usage, e.g. 2 for /v1/markets/")
+	memorySample := flag.Int("memory-sample", 1, "Measure every `n`th key for --memory-usage and extrapolate (large caches)")
+	flag.Parse()
+
 	// Configuration from environment
 	redisURL := getEnv("REDIS_URL", "localhost:6379")
+	redisReplicaURL := os.Getenv("REDIS_REPLICA_URL")
 	port := getEnv("PORT", "8080")
 	userAgent := getEnv("USER_AGENT", "eve-esi-client/0.1.0")
 
+	proxyCfg := &proxyConfig{}
+	if path := os.Getenv("PROXY_CONFIG"); path != "" {
+		cfg, err := loadProxyConfig(path)
+		if err != nil {
+			log.Fatalf("Failed to load proxy config: %v", err)
+		}
+		proxyCfg = cfg
+	}
+
 	// Setup Redis
 	redisClient := redis.NewClient(&redis.Options{
 		Addr: redisURL,
 	})
 
+	// Create ESI client
+	clientCfg := client.DefaultConfig(redisClient, userAgent)
+	if redisReplicaURL != "" {
+		replica := redis.NewClient(&redis.Options{Addr: redisReplicaURL})
+		defer replica.Close()
+		clientCfg.CacheReplica = replica
+		clientCfg.CacheReplicaMaxLag = time.Duration(proxyCfg.CacheReplica.MaxLag)
+		clientCfg.CacheReplicaMissFallback = proxyCfg.CacheReplica.MissFallback
+	}
+	clientCfg.MinRefreshIntervals = proxyCfg.MinRefreshIntervals
+	clientCfg.LatestVersions = proxyCfg.LatestVersions
+	clientCfg.CaptureSampleRate = proxyCfg.Capture.SampleRate
+	clientCfg.CaptureRoutes = proxyCfg.Capture.Routes
+	clientCfg.CaptureMaxEntries = proxyCfg.Capture.MaxEntries
+	clientCfg.CanarySampleRate = proxyCfg.CanarySampleRate
+	clientCfg.PrivateRoutes = proxyCfg.Privacy.Routes
+	clientCfg.PrivacyMode = client.PrivacyMode(proxyCfg.Privacy.Mode)
+	clientCfg.PrivacySalt = proxyCfg.Privacy.Salt
+	clientCfg.BackoffStrategies = proxyCfg.BackoffStrategies
+	clientCfg.WarmConnections = proxyCfg.WarmConnections
+	clientCfg.WarmInterval = time.Duration(proxyCfg.WarmConnectionInterval)
+	clientCfg.ValidatorIndexSize = proxyCfg.ValidatorIndexSize
+	clientCfg.SplitBodySize = proxyCfg.SplitBodySize
+	clientCfg.LastModifiedRoutes = proxyCfg.LastModifiedRoutes
+	clientCfg.ChallengeBackoff = time.Duration(proxyCfg.ChallengeBackoff)
+	clientCfg.IncidentStatusURL = proxyCfg.Incident.StatusURL
+	clientCfg.IncidentPollInterval = time.Duration(proxyCfg.Incident.PollInterval)
+	clientCfg.IncidentMaxConcurrency = proxyCfg.Incident.MaxConcurrency
+	clientCfg.MaxInFlightBytes = proxyCfg.MaxInFlightBytes
+	clientCfg.StuckRequestThreshold = time.Duration(proxyCfg.StuckRequestThreshold)
+	clientCfg.RequestTimeout = time.Duration(proxyCfg.Timeouts.Request)
+	clientCfg.PageTimeout = time.Duration(proxyCfg.Timeouts.Page)
+	clientCfg.AdminTimeout = time.Duration(proxyCfg.Timeouts.Admin)
+	clientCfg.DefaultTTL = time.Duration(proxyCfg.DefaultTTL)
+	clientCfg.FallbackTTLs = proxyCfg.FallbackTTLs
+	clientCfg.AdaptiveTTL = proxyCfg.AdaptiveTTL.Enabled
+	clientCfg.AdaptiveTTLMin = time.Duration(proxyCfg.AdaptiveTTL.Min)
+	clientCfg.AdaptiveTTLMax = time.Duration(proxyCfg.AdaptiveTTL.Max)
+	clientCfg.SLOs = proxyCfg.clientSLOs()
+	esiClient, err := client.New(clientCfg)
+	if err != nil {
+		log.Fatalf("Failed to create ESI client: %v", err)
+	}
+	defer esiClient.Close()
+
+	// Self-check mode for CI/CD smoke tests
+	if *check {
+		os.Exit(runSelfCheck(esiClient))
+	}
+
+	// Admin operations for moving to a new Redis instance
+	if *exportState != "" {
+		os.Exit(runExportState(esiClient, *exportState, *exportCache))
+	}
+	if *importState != "" {
+		os.Exit(runImportState(esiClient, *importState))
+	}
+	if *replicateTo != "" {
+		os.Exit(runReplicateCache(esiClient, *replicateTo, *replicatePrefixes))
+	}
+	if *migrateKeys {
+		os.Exit(runMigrateKeys(esiClient, cache.MigrateKeysOptions{DryRun: *migrateDryRun}))
+	}
+	if *dumpCache != "" {
+		os.Exit(runDumpCache(esiClient, *dumpCache, *dumpPrefix))
+	}
+	if *dumpCaptures != "" {
+		os.Exit(runDumpCaptures(esiClient, *dumpCaptures))
+	}
+	if *preloadCache != "" {
+		os.Exit(runPreloadCache(esiClient, *preloadCache, cache.PreloadOptions{TTL: *preloadTTL, MaxAge: *preloadMaxAge}))
+	}
+
+	// Debugging of stale-data complaints
+	if *inspectCache != "" {
+		os.Exit(runInspectCache(esiClient, *inspectCache))
+	}
+	if *diffCache != "" {
+		os.Exit(runDiffCache(esiClient, *diffCache))
+	}
+
+	// Sizing of compression and TTL policies
+	if *memoryUsage != "" {
+		os.Exit(runMemoryUsage(esiClient, *memoryUsage, cache.MemoryUsageOptions{Depth: *memoryDepth, SampleEvery: *memorySample}))
+	}
+
 	// Ping Redis
 	ctx := context.Background()
 	if err := redisClient.Ping(ctx).Err(); err != nil {
@@ -31,18 +156,27 @@ func main() {
 	}
 	log.Printf("Connected to Redis at %s", redisURL)
 
-	// Create ESI client
-	esiClient, err := client.New(client.DefaultConfig(redisClient, userAgent))
-	if err != nil {
-		log.Fatalf("Failed to create ESI client: %v", err)
+	// Warm the cache in the background, behind downstream traffic
+	if proxyCfg.Warmup.enabled() {
+		go runWarmup(ctx, redisClient, esiClient, proxyCfg.Warmup)
 	}
-	defer esiClient.Close()
 
 	// HTTP Server
 	http.HandleFunc("/health", healthHandler)
 	http.HandleFunc("/ready", readyHandler(redisClient, esiClient))
-	http.Handle("/metrics", promhttp.Handler())
-	http.HandleFunc("/esi/", esiProxyHandler(esiClient))
+	// OpenMetrics exposes trace exemplars (requires Prometheus exemplar storage)
+	http.Handle("/metrics", promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{EnableOpenMetrics: true}))
+	stats := newProxyStats()
+	http.HandleFunc("/statsz", statszHandler(esiClient, stats))
+	http.HandleFunc("/statsz/memory", memoryHandler(esiClient))
+	tenants, messages := proxyCfg.Tenants, proxyCfg.ErrorMessages
+	upstream := newCoalescer(esiClient)
+	verifier := newTokenVerifier(proxyCfg.SSO)
+	http.HandleFunc("/esi/", requireTenant(tenants, messages, verifyAccessToken(verifier, messages, esiProxyHandler(upstream, proxyCfg.Projections, messages, stats))))
+	http.HandleFunc("/esi/batch", requireTenant(tenants, messages, batchHandler(esiClient, proxyCfg.Projections, stats)))
+	if len(proxyCfg.Composites) > 0 {
+		http.HandleFunc("/composite/", requireTenant(tenants, messages, compositeHandler(upstream, esiClient.GetCache(), proxyCfg.Composites, proxyCfg.Projections, messages, stats)))
+	}
 
 	addr := ":" + port
 	log.Printf("Starting ESI proxy server on %s", addr)
@@ -51,13 +185,199 @@ func main() {
 	log.Printf("  - Health:  http://localhost%s/health", addr)
 	log.Printf("  - Ready:   http://localhost%s/ready", addr)
 	log.Printf("  - Metrics: http://localhost%s/metrics", addr)
+	log.Printf("  - Stats:   http://localhost%s/statsz", addr)
+	log.Printf("  - Memory:  http://localhost%s/statsz/memory?prefix=/v1/markets/", addr)
 	log.Printf("  - Proxy:   http://localhost%s/esi/...", addr)
+	log.Printf("  - Batch:   POST http://localhost%s/esi/batch", addr)
+	for _, composite := range proxyCfg.Composites {
+		log.Printf("  - Composite: http://localhost%s%s", addr, composite.Path)
+	}
+	if len(tenants) > 0 {
+		log.Printf("Multi-tenant mode: %d tenants, %s required", len(tenants), apiKeyHeader)
+	}
 
-	if err := http.ListenAndServe(addr, nil); err != nil {
+	if err := http.ListenAndServe(addr, withRequestID(http.DefaultServeMux)); err != nil {
 		log.Fatalf("Server failed: %v", err)
 	}
 }
 
+// runSelfCheck prints the self-check report and returns the process exit code.
+func runSelfCheck(esiClient *client.Client) int {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	report := esiClient.SelfCheck(ctx)
+	fmt.Print(report)
+	if !report.OK {
+		return 1
+	}
+	return 0
+}
+
+// runExportState writes the client state to path and returns the process exit code.
+func runExportState(esiClient *client.Client, path string, includeCache bool) int {
+	export, err := esiClient.ExportState(context.Background(), includeCache)
+	if err != nil {
+		log.Printf("Export failed: %v", err)
+		return 1
+	}
+
+	data, err := json.Marshal(export)
+	if err != nil {
+		log.Printf("Export failed: %v", err)
+		return 1
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		log.Printf("Export failed: %v", err)
+		return 1
+	}
+
+	log.Printf("Exported state to %s (rate limit: %t, cache entries: %d)", path, export.RateLimit != nil, len(export.Cache))
+	return 0
+}
+
+// runImportState loads the client state from path and returns the process exit code.
+func runImportState(esiClient *client.Client, path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Printf("Import failed: %v", err)
+		return 1
+	}
+
+	var export client.StateExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		log.Printf("Import failed: invalid state file: %v", err)
+		return 1
+	}
+
+	result, err := esiClient.ImportState(context.Background(), &export)
+	if err != nil {
+		log.Printf("Import failed: %v", err)
+		return 1
+	}
+
+	log.Printf("Imported state from %s (rate limit: %t, cache entries: %d)", path, result.RateLimit, result.CacheEntries)
+	return 0
+}
+
+// runReplicateCache copies cache entries to the Redis at addr and returns the process exit code.
+func runReplicateCache(esiClient *client.Client, addr, prefixes string) int {
+	dst := redis.NewClient(&redis.Options{Addr: addr})
+	defer dst.Close()
+
+	opts := cache.ReplicateOptions{}
+	if prefixes != "" {
+		opts.EndpointPrefixes = strings.Split(prefixes, ",")
+	}
+
+	result, err := esiClient.ReplicateCache(context.Background(), dst, opts)
+	if err != nil {
+		log.Printf("Replication failed: %v", err)
+		return 1
+	}
+
+	log.Printf("Replicated cache to %s (copied: %d, skipped: %d, bytes: %d)", addr, result.Copied, result.Skipped, result.Bytes)
+	return 0
+}
+
+// runMigrateKeys moves legacy cache entries to the current key format and
+// returns the process exit code.
+func runMigrateKeys(esiClient *client.Client, opts cache.MigrateKeysOptions) int {
+	result, err := esiClient.GetCache().MigrateKeys(context.Background(), opts)
+	if err != nil {
+		log.Printf("Key migration failed: %v", err)
+		return 1
+	}
+
+	if opts.DryRun {
+		log.Printf("Found %d legacy cache entries (scanned: %d)", result.Migrated, result.Scanned)
+		return 0
+	}
+	log.Printf("Migrated cache keys (scanned: %d, migrated: %d, superseded: %d)", result.Scanned, result.Migrated, result.Superseded)
+	return 0
+}
+
+// runDumpCache writes the cache entries matching prefix to path and returns the process exit code.
+func runDumpCache(esiClient *client.Client, path, prefix string) int {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		log.Printf("Dump failed: %v", err)
+		return 1
+	}
+	w := bufio.NewWriter(f)
+
+	exported, err := esiClient.GetCache().Export(context.Background(), prefix, w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		log.Printf("Dump failed: %v", err)
+		return 1
+	}
+
+	log.Printf("Dumped %d cache entries to %s", exported, path)
+	return 0
+}
+
+// runDumpCaptures writes the debug captures to path as JSON lines, newest
+// first, and returns the process exit code.
+func runDumpCaptures(esiClient *client.Client, path string) int {
+	captures, err := esiClient.Captures(context.Background())
+	if err != nil {
+		log.Printf("Dump failed: %v", err)
+		return 1
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		log.Printf("Dump failed: %v", err)
+		return 1
+	}
+	w := bufio.NewWriter(f)
+
+	enc := json.NewEncoder(w)
+	for _, capture := range captures {
+		if err = enc.Encode(capture); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		log.Printf("Dump failed: %v", err)
+		return 1
+	}
+
+	log.Printf("Dumped %d debug captures to %s", len(captures), path)
+	return 0
+}
+
+// runPreloadCache loads the cache dump at path and returns the process exit code.
+func runPreloadCache(esiClient *client.Client, path string, opts cache.PreloadOptions) int {
+	f, err := os.Open(path)
+	if err != nil {
+		log.Printf("Preload failed: %v", err)
+		return 1
+	}
+	defer f.Close()
+
+	result, err := esiClient.GetCache().Preload(context.Background(), f, opts)
+	if err != nil {
+		log.Printf("Preload failed after %d entries: %v", result.Loaded, err)
+		return 1
+	}
+
+	log.Printf("Preloaded cache from %s (loaded: %d, skipped: %d, ttl: %s)", path, result.Loaded, result.Skipped, opts.TTL)
+	return 0
+}
+
 func healthHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	fmt.Fprintf(w, "OK")
@@ -75,43 +395,108 @@ func readyHandler(redisClient *redis.Client, esiClient *client.Client) http.Hand
 			return
 		}
 
+		// Take the instance out of rotation while the client is critical;
+		// degraded instances still serve (from cache where possible)
+		health := esiClient.Health(ctx)
+		switch health.Status {
+		case client.HealthCritical:
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, health)
+			return
+		case client.HealthDegraded:
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, health)
+			return
+		}
+
 		// All checks passed
 		w.WriteHeader(http.StatusOK)
 		fmt.Fprintf(w, "OK")
 	}
 }
 
-func esiProxyHandler(esiClient *client.Client) http.HandlerFunc {
+// esiGetter is the part of *client.Client used by the proxy handler.
+type esiGetter interface {
+	Get(ctx context.Context, endpoint string) (*http.Response, error)
+}
+
+func esiProxyHandler(esiClient esiGetter, projections projections, messages errorMessages, stats *proxyStats) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Extract ESI endpoint from request path
 		// Example: /esi/v4/markets/10000002/orders/ -> /v4/markets/10000002/orders/
-		endpoint := r.URL.Path[4:] // Remove "/esi" prefix
+		endpoint := strings.TrimPrefix(r.URL.Path, "/esi")
 
-		// Proxy request to ESI
-		ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+		// Proxy request to ESI, with the caller's token for private routes.
+		// Requests fail fast with 429 instead of queuing while the error
+		// budget is low.
+		ctx, cancel := context.WithTimeout(client.WithFailFast(r.Context()), upstreamTimeout)
 		defer cancel()
 
-		resp, err := esiClient.Get(ctx, endpoint)
+		ctx, err := privateContext(ctx, r)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("ESI request failed: %v", err), http.StatusBadGateway)
+			writeError(w, r, http.StatusUnauthorized, messages, fmt.Errorf("%v: %w", err, &statusError{route: endpoint, status: http.StatusUnauthorized}))
+			return
+		}
+
+		target := endpoint
+		if r.URL.RawQuery != "" {
+			target += "?" + r.URL.RawQuery
+		}
+
+		resp, err := esiClient.Get(ctx, target)
+		if err != nil {
+			status := upstreamErrorStatus(w, err)
+			stats.record(requestTenant(r), endpoint, status, "", 0)
+			writeError(w, r, status, messages, fmt.Errorf("ESI request failed: %w", err))
 			return
 		}
 		defer resp.Body.Close()
 
+		var projectedBody []byte
+		projected := false
+		if resp.StatusCode == http.StatusOK {
+			projectedBody, projected, err = projectBody(projections, endpoint, resp.Body)
+			if err != nil {
+				stats.record(requestTenant(r), endpoint, http.StatusBadGateway, "", 0)
+				writeError(w, r, http.StatusBadGateway, messages, fmt.Errorf("ESI request failed: %w", err))
+				return
+			}
+		}
+
 		// Copy response headers
 		for key, values := range resp.Header {
 			for _, value := range values {
 				w.Header().Add(key, value)
 			}
 		}
+		if projected {
+			// The upstream length and validator describe the full body
+			w.Header().Del("Content-Length")
+			w.Header().Set("ETag", strongETag(projectedBody))
+		}
+
+		// Downstream revalidation against the proxy's own validator
+		cacheStatus := resp.Header.Get(client.CacheStatusHeader)
+		if resp.StatusCode == http.StatusOK && notModified(r, w.Header().Get("ETag")) {
+			w.Header().Del("Content-Length")
+			w.WriteHeader(http.StatusNotModified)
+			stats.record(requestTenant(r), endpoint, http.StatusNotModified, cacheStatus, 0)
+			return
+		}
 
 		// Copy status code
 		w.WriteHeader(resp.StatusCode)
 
 		// Copy body
-		if _, err := w.Write([]byte("TODO: Copy response body")); err != nil {
-			log.Printf("Failed to write response: %v", err)
+		var body io.Reader = resp.Body
+		if projected {
+			body = bytes.NewReader(projectedBody)
+		}
+		n, err := io.Copy(w, body)
+		if err != nil {
+			log.Printf("[%s] Failed to write response: %v", requestID(r), err)
 		}
+		stats.record(requestTenant(r), endpoint, resp.StatusCode, cacheStatus, n)
 	}
 }
 
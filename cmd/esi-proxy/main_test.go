@@ -175,7 +175,7 @@ func TestESIProxyHandler_Integration(t *testing.T) {
 	}
 	defer esiClient.Close()
 
-	handler := esiProxyHandler(esiClient)
+	handler := esiProxyHandler(esiClient, nil, nil, newProxyStats())
 
 	t.Run("invalid_endpoint", func(t *testing.T) {
 		req := httptest.NewRequest("GET", "/esi/invalid", nil)
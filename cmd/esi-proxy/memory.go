@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+	"github.com/Sternrassler/eve-esi-client/pkg/client"
+)
+
+// runMemoryUsage prints the Redis memory used per endpoint family and
+// returns the process exit code.
+func runMemoryUsage(esiClient *client.Client, prefix string, opts cache.MemoryUsageOptions) int {
+	usage, err := esiClient.GetCache().MemoryUsage(context.Background(), prefix, opts)
+	if err != nil {
+		log.Printf("Memory usage failed: %v", err)
+		return 1
+	}
+
+	writeMemoryUsage(os.Stdout, usage)
+	return 0
+}
+
+// memoryHandler serves the Redis memory used per endpoint family as a
+// plain-text table. Query parameters: prefix (endpoint prefix, default all),
+// depth (path segments per family) and sample (measure every Nth key). It
+// scans the keyspace, so it is meant for operators, not for scraping.
+func memoryHandler(esiClient *client.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		var opts cache.MemoryUsageOptions
+		for name, target := range map[string]*int{"depth": &opts.Depth, "sample": &opts.SampleEvery} {
+			value := query.Get(name)
+			if value == "" {
+				continue
+			}
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				http.Error(w, fmt.Sprintf("invalid %s %q", name, value), http.StatusBadRequest)
+				return
+			}
+			*target = n
+		}
+
+		usage, err := esiClient.GetCache().MemoryUsage(r.Context(), query.Get("prefix"), opts)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		writeMemoryUsage(w, usage)
+	}
+}
+
+// writeMemoryUsage prints the memory table, largest families first, with a
+// total row.
+func writeMemoryUsage(w io.Writer, usage []cache.FamilyUsage) {
+	var keys int
+	var total int64
+	for _, u := range usage {
+		keys += u.Keys
+		total += u.Bytes
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FAMILY\tKEYS\tSAMPLED\tBYTES\tAVG BYTES\tSHARE")
+	for _, u := range usage {
+		avg, share := int64(0), 0.0
+		if u.Keys > 0 {
+			avg = u.Bytes / int64(u.Keys)
+		}
+		if total > 0 {
+			share = float64(u.Bytes) / float64(total) * 100
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%.1f%%\n", u.Family, u.Keys, u.Sampled, u.Bytes, avg, share)
+	}
+	fmt.Fprintf(tw, "TOTAL\t%d\t\t%d\t\t\n", keys, total)
+	tw.Flush()
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+)
+
+func TestWriteMemoryUsage(t *testing.T) {
+	var buf bytes.Buffer
+	writeMemoryUsage(&buf, []cache.FamilyUsage{
+		{Family: "/v1/markets/", Keys: 4, Sampled: 4, Bytes: 3000},
+		{Family: "/v3/universe/", Keys: 10, Sampled: 5, Bytes: 1000},
+	})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("got %d lines, want header, 2 families and total:\n%s", len(lines), buf.String())
+	}
+	for i, want := range [][]string{
+		{"FAMILY", "KEYS", "SAMPLED", "BYTES"},
+		{"/v1/markets/", "4", "4", "3000", "750", "75.0%"},
+		{"/v3/universe/", "10", "5", "1000", "100", "25.0%"},
+		{"TOTAL", "14", "4000"},
+	} {
+		if fields := strings.Fields(lines[i]); !strings.HasPrefix(strings.Join(fields, " "), strings.Join(want, " ")) {
+			t.Errorf("line %d = %q, want fields %v", i, lines[i], want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+)
+
+// projections maps route patterns to the JSON fields kept in responses, e.g.
+//
+//	"projections": {
+//	  "/v1/markets/{id}/orders/": ["order_id", "price", "volume_remain"]
+//	}
+//
+// Route patterns are endpoint paths with numeric segments replaced by {id}
+// (see cache.EndpointPattern). Projections apply to object responses and to
+// arrays of objects; other responses are returned unchanged.
+type projections map[string][]string
+
+// validate rejects routes that can never match and empty field lists.
+func (p projections) validate() error {
+	for route, fields := range p {
+		if pattern := cache.EndpointPattern(route); pattern != route {
+			return fmt.Errorf("projection %q: route must be a pattern like %q", route, pattern)
+		}
+		if len(fields) == 0 {
+			return fmt.Errorf("projection %q: no fields", route)
+		}
+	}
+	return nil
+}
+
+// apply projects body to the fields configured for endpoint (path with
+// optional query string). It reports whether body was changed.
+func (p projections) apply(endpoint string, body []byte) ([]byte, bool, error) {
+	path, _, _ := strings.Cut(endpoint, "?")
+	fields, ok := p[cache.EndpointPattern(path)]
+	if !ok {
+		return body, false, nil
+	}
+
+	// UseNumber keeps large IDs exact
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, false, fmt.Errorf("decode response for projection: %w", err)
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		value = projectObject(v, fields)
+	case []any:
+		for i, element := range v {
+			if object, ok := element.(map[string]any); ok {
+				v[i] = projectObject(object, fields)
+			}
+		}
+	default:
+		return body, false, nil
+	}
+
+	projected, err := json.Marshal(value)
+	if err != nil {
+		return nil, false, fmt.Errorf("encode projected response: %w", err)
+	}
+	return projected, true, nil
+}
+
+// projectObject returns the subset of object with the given fields.
+func projectObject(object map[string]any, fields []string) map[string]any {
+	projected := make(map[string]any, len(fields))
+	for _, field := range fields {
+		if value, ok := object[field]; ok {
+			projected[field] = value
+		}
+	}
+	return projected
+}
+
+// projectBody reads body and applies the projection for endpoint. Without a
+// projection for the route it returns false and leaves body unread, so it can
+// be streamed unchanged.
+func projectBody(p projections, endpoint string, body io.Reader) ([]byte, bool, error) {
+	path, _, _ := strings.Cut(endpoint, "?")
+	if _, ok := p[cache.EndpointPattern(path)]; !ok {
+		return nil, false, nil
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, false, fmt.Errorf("read response body: %w", err)
+	}
+	projected, _, err := p.apply(endpoint, data)
+	if err != nil {
+		return nil, false, err
+	}
+	return projected, true, nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestProjections_Apply(t *testing.T) {
+	p := projections{
+		"/v1/markets/{id}/orders/": {"order_id", "price", "volume_remain"},
+		"/v5/characters/{id}/":     {"name"},
+	}
+
+	tests := []struct {
+		name        string
+		endpoint    string
+		body        string
+		want        string
+		wantChanged bool
+	}{
+		{
+			name:        "array of objects",
+			endpoint:    "/v1/markets/10000002/orders/?order_type=all",
+			body:        `[{"order_id":6543210987,"price":5.5,"volume_remain":10,"location_id":60003760,"is_buy_order":false}]`,
+			want:        `[{"order_id":6543210987,"price":5.5,"volume_remain":10}]`,
+			wantChanged: true,
+		},
+		{
+			name:        "object",
+			endpoint:    "/v5/characters/90000001/",
+			body:        `{"name":"Pilot","corporation_id":1000001,"birthday":"2010-01-01T00:00:00Z"}`,
+			want:        `{"name":"Pilot"}`,
+			wantChanged: true,
+		},
+		{
+			name:     "no projection for route",
+			endpoint: "/v1/status/",
+			body:     `{"players":1,"server_version":"1"}`,
+			want:     `{"players":1,"server_version":"1"}`,
+		},
+		{
+			name:     "scalar response",
+			endpoint: "/v5/characters/90000001/",
+			body:     `42`,
+			want:     `42`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed, err := p.apply(tt.endpoint, []byte(tt.body))
+			if err != nil {
+				t.Fatalf("apply() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("apply() = %s, want %s", got, tt.want)
+			}
+			if changed != tt.wantChanged {
+				t.Errorf("apply() changed = %v, want %v", changed, tt.wantChanged)
+			}
+		})
+	}
+}
+
+func TestProjections_Validate(t *testing.T) {
+	if err := (projections{"/v1/markets/10000002/orders/": {"price"}}).validate(); err == nil {
+		t.Error("validate() accepted a concrete path instead of a route pattern")
+	}
+	if err := (projections{"/v1/markets/{id}/orders/": nil}).validate(); err == nil {
+		t.Error("validate() accepted an empty field list")
+	}
+	if err := (projections{"/v1/markets/{id}/orders/": {"price"}}).validate(); err != nil {
+		t.Errorf("validate() error = %v", err)
+	}
+}
+
+func TestESIProxyHandler_Projection(t *testing.T) {
+	esi := &fakeESI{responses: map[string]fakeResponse{
+		"/v1/markets/10000002/orders/": {status: http.StatusOK, body: `[{"order_id":1,"price":2,"range":"region"}]`},
+	}}
+	handler := esiProxyHandler(esi, projections{"/v1/markets/{id}/orders/": {"order_id", "price"}}, nil, newProxyStats())
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/esi/v1/markets/10000002/orders/", nil))
+
+	body, _ := io.ReadAll(w.Result().Body)
+	if got := strings.TrimSpace(string(body)); got != `[{"order_id":1,"price":2}]` {
+		t.Errorf("body = %s", got)
+	}
+}
+
+func TestESIProxyHandler_ProjectionETag(t *testing.T) {
+	esi := &fakeESI{responses: map[string]fakeResponse{
+		"/v1/markets/10000002/orders/": {status: http.StatusOK, body: `[{"order_id":1,"price":2,"range":"region"}]`},
+	}}
+	handler := esiProxyHandler(esi, projections{"/v1/markets/{id}/orders/": {"order_id", "price"}}, nil, newProxyStats())
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/esi/v1/markets/10000002/orders/", nil))
+	if got, want := w.Header().Get("ETag"), strongETag([]byte(`[{"order_id":1,"price":2}]`)); got != want {
+		t.Fatalf("ETag = %q, want %q (hash of the projected body)", got, want)
+	}
+
+	req := httptest.NewRequest("GET", "/esi/v1/markets/10000002/orders/", nil)
+	req.Header.Set("If-None-Match", `W/"other", `+w.Header().Get("ETag"))
+	w = httptest.NewRecorder()
+	handler(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusNotModified)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/client"
+)
+
+// maxRequestIDLength caps caller-supplied request IDs.
+const maxRequestIDLength = 128
+
+// withRequestID gives every request an ID for support ticket correlation:
+// the caller's X-Request-ID if it is a sane token, otherwise a new one. The
+// ID is returned in the response header and carried in the request context,
+// so the ESI client logs it and sends it to ESI.
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(client.RequestIDHeader)
+		if !validRequestID(id) {
+			id = client.NewRequestID()
+		}
+
+		w.Header().Set(client.RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(client.WithRequestID(r.Context(), id)))
+	})
+}
+
+// validRequestID reports whether id is a non-empty token of printable ASCII
+// characters without spaces, short enough to log.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// requestID returns the request ID assigned by withRequestID.
+func requestID(r *http.Request) string {
+	return client.RequestIDFromContext(r.Context())
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/client"
+)
+
+func TestWithRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{"caller ID kept", "ticket-42", true},
+		{"missing ID generated", "", false},
+		{"ID with spaces replaced", "a b", false},
+		{"overlong ID replaced", strings.Repeat("x", maxRequestIDLength+1), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var upstream string
+			esi := getterFunc(func(ctx context.Context, endpoint string) (*http.Response, error) {
+				upstream = client.RequestIDFromContext(ctx)
+				return nil, errors.New("connection refused")
+			})
+			handler := withRequestID(esiProxyHandler(esi, nil, nil, newProxyStats()))
+
+			req := httptest.NewRequest("GET", "/esi/v1/status/", nil)
+			if tt.incoming != "" {
+				req.Header.Set(client.RequestIDHeader, tt.incoming)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			id := w.Header().Get(client.RequestIDHeader)
+			if tt.keep && id != tt.incoming {
+				t.Errorf("%s = %q, want %q", client.RequestIDHeader, id, tt.incoming)
+			}
+			if !tt.keep && (id == tt.incoming || !validRequestID(id)) {
+				t.Errorf("%s = %q, want a new ID", client.RequestIDHeader, id)
+			}
+			if upstream != id {
+				t.Errorf("request ID passed to client = %q, want %q", upstream, id)
+			}
+
+			var body errorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if body.RequestID != id {
+				t.Errorf("error body request_id = %q, want %q", body.RequestID, id)
+			}
+		})
+	}
+}
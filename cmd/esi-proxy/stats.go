@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+	"github.com/Sternrassler/eve-esi-client/pkg/client"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Proxy metrics describe downstream traffic (clients of the proxy), as
+// opposed to the esi_* library metrics that describe traffic to ESI.
+var (
+	// proxyRequestsTotal counts downstream requests by tenant, route and status code
+	proxyRequestsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "esi_proxy_requests_total",
+			Help: "Total number of downstream proxy requests by tenant, route and status code",
+		},
+		[]string{"tenant", "route", "status"},
+	)
+
+	// proxyCacheResponsesTotal counts downstream responses by tenant, route and cache status
+	proxyCacheResponsesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "esi_proxy_cache_responses_total",
+			Help: "Total number of proxy responses by tenant, route and cache status (hit, stale, miss, bypass)",
+		},
+		[]string{"tenant", "route", "cache"},
+	)
+
+	// proxyResponseBytesTotal counts response body bytes by tenant, route and source
+	proxyResponseBytesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "esi_proxy_response_bytes_total",
+			Help: "Total response body bytes served by the proxy by tenant, route and source (cache, upstream)",
+		},
+		[]string{"tenant", "route", "source"},
+	)
+
+	// proxyCoalescedRequestsTotal counts downstream requests answered by an
+	// identical upstream request already in flight
+	proxyCoalescedRequestsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "esi_proxy_coalesced_requests_total",
+			Help: "Total number of downstream requests answered by an identical upstream request in flight by tenant and route",
+		},
+		[]string{"tenant", "route"},
+	)
+)
+
+// routeStats holds the per-route counters shown on /statsz.
+type routeStats struct {
+	requests      int64
+	errors        int64
+	hits          int64
+	misses        int64
+	cacheBytes    int64
+	upstreamBytes int64
+}
+
+// proxyStats aggregates downstream traffic per route for /statsz. The same
+// numbers are exported as Prometheus metrics by record.
+type proxyStats struct {
+	mu     sync.Mutex
+	since  time.Time
+	routes map[string]*routeStats
+}
+
+func newProxyStats() *proxyStats {
+	return &proxyStats{
+		since:  time.Now(),
+		routes: make(map[string]*routeStats),
+	}
+}
+
+// record accounts one downstream response of tenant ("" without tenants).
+// cacheStatus is the value of client.CacheStatusHeader (empty if the
+// upstream request failed).
+func (s *proxyStats) record(tenant, endpoint string, status int, cacheStatus string, bytes int64) {
+	route := cache.EndpointPattern(endpoint)
+	source := "upstream"
+	if cacheStatus == client.CacheStatusHit || cacheStatus == client.CacheStatusStale {
+		source = "cache"
+	}
+
+	proxyRequestsTotal.WithLabelValues(tenant, route, strconv.Itoa(status)).Inc()
+	if cacheStatus != "" {
+		proxyCacheResponsesTotal.WithLabelValues(tenant, route, cacheLabel(cacheStatus)).Inc()
+		proxyResponseBytesTotal.WithLabelValues(tenant, route, source).Add(float64(bytes))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rs, ok := s.routes[route]
+	if !ok {
+		rs = &routeStats{}
+		s.routes[route] = rs
+	}
+	rs.requests++
+	if cacheStatus == "" || status >= http.StatusInternalServerError {
+		rs.errors++
+	}
+	switch cacheStatus {
+	case client.CacheStatusHit, client.CacheStatusStale:
+		rs.hits++
+		rs.cacheBytes += bytes
+	case client.CacheStatusMiss:
+		rs.misses++
+		rs.upstreamBytes += bytes
+	case client.CacheStatusBypass:
+		rs.upstreamBytes += bytes
+	}
+}
+
+// cacheLabel maps a cache status header value to a metric label value.
+func cacheLabel(cacheStatus string) string {
+	switch cacheStatus {
+	case client.CacheStatusHit:
+		return "hit"
+	case client.CacheStatusMiss:
+		return "miss"
+	case client.CacheStatusStale:
+		return "stale"
+	default:
+		return "bypass"
+	}
+}
+
+// statszHandler renders a plain-text summary of proxy and client activity
+// for quick checks without Prometheus.
+func statszHandler(esiClient *client.Client, stats *proxyStats) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+		if esiClient != nil {
+			cs := esiClient.Stats()
+			fmt.Fprintf(w, "Client (since %s)\n", cs.Since.Format(time.RFC3339))
+			fmt.Fprintf(w, "  requests: %d  cache hits: %d  not modified: %d  retries: %d  blocked: %d  errors: %d  avg latency: %s\n\n",
+				cs.Requests, cs.CacheHits, cs.NotModified, cs.Retries, cs.Blocked, cs.Errors, cs.AverageLatency)
+		}
+
+		stats.write(w)
+	}
+}
+
+// write prints the per-route table, busiest routes first.
+func (s *proxyStats) write(w io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	routes := make([]string, 0, len(s.routes))
+	for route := range s.routes {
+		routes = append(routes, route)
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		a, b := s.routes[routes[i]], s.routes[routes[j]]
+		if a.requests != b.requests {
+			return a.requests > b.requests
+		}
+		return routes[i] < routes[j]
+	})
+
+	fmt.Fprintf(w, "Proxy routes (since %s)\n", s.since.Format(time.RFC3339))
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ROUTE\tREQUESTS\tERRORS\tHIT RATIO\tCACHE BYTES\tUPSTREAM BYTES")
+	for _, route := range routes {
+		rs := s.routes[route]
+		ratio := "-"
+		if cached := rs.hits + rs.misses; cached > 0 {
+			ratio = fmt.Sprintf("%.1f%%", float64(rs.hits)/float64(cached)*100)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%d\t%d\n", route, rs.requests, rs.errors, ratio, rs.cacheBytes, rs.upstreamBytes)
+	}
+	tw.Flush()
+}
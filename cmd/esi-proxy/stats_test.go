@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/client"
+)
+
+// fakeESI returns canned responses keyed by endpoint.
+type fakeESI struct {
+	mu        sync.Mutex
+	responses map[string]fakeResponse
+	requested []string
+}
+
+type fakeResponse struct {
+	status      int
+	body        string
+	cacheStatus string
+	expires     time.Time
+}
+
+func (f *fakeESI) Get(ctx context.Context, endpoint string) (*http.Response, error) {
+	f.mu.Lock()
+	f.requested = append(f.requested, endpoint)
+	f.mu.Unlock()
+
+	r, ok := f.responses[endpoint]
+	if !ok {
+		return nil, errors.New("connection refused")
+	}
+	header := http.Header{}
+	header.Set(client.CacheStatusHeader, r.cacheStatus)
+	if !r.expires.IsZero() {
+		header.Set("Expires", r.expires.UTC().Format(http.TimeFormat))
+	}
+	return &http.Response{
+		StatusCode: r.status,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(r.body)),
+	}, nil
+}
+
+func TestESIProxyHandler_RecordsStats(t *testing.T) {
+	esi := &fakeESI{responses: map[string]fakeResponse{
+		"/v1/markets/10000002/history/?type_id=34": {status: http.StatusOK, body: `[{"volume":1}]`, cacheStatus: client.CacheStatusHit},
+		"/v1/markets/10000043/history/?type_id=34": {status: http.StatusOK, body: `[]`, cacheStatus: client.CacheStatusMiss},
+		"/v1/status/": {status: http.StatusOK, body: `{"players":1}`, cacheStatus: client.CacheStatusMiss},
+	}}
+	stats := newProxyStats()
+	handler := esiProxyHandler(esi, nil, nil, stats)
+
+	for _, path := range []string{
+		"/esi/v1/markets/10000002/history/?type_id=34",
+		"/esi/v1/markets/10000043/history/?type_id=34",
+		"/esi/v1/status/",
+		"/esi/v1/missing/",
+	} {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest("GET", path, nil))
+
+		want, ok := esi.responses[strings.TrimPrefix(path, "/esi")]
+		if !ok {
+			if w.Code != http.StatusBadGateway {
+				t.Errorf("%s: status = %d, want %d", path, w.Code, http.StatusBadGateway)
+			}
+			continue
+		}
+		if w.Body.String() != want.body {
+			t.Errorf("%s: body = %q, want %q", path, w.Body.String(), want.body)
+		}
+	}
+
+	if got := esi.requested[0]; got != "/v1/markets/10000002/history/?type_id=34" {
+		t.Errorf("query string not forwarded: requested %q", got)
+	}
+
+	history := stats.routes["/v1/markets/{id}/history/"]
+	if history == nil {
+		t.Fatalf("no stats for market history route, have %v", stats.routes)
+	}
+	if history.requests != 2 || history.hits != 1 || history.misses != 1 {
+		t.Errorf("history stats = %+v, want 2 requests, 1 hit, 1 miss", *history)
+	}
+	if history.cacheBytes != int64(len(`[{"volume":1}]`)) || history.upstreamBytes != int64(len(`[]`)) {
+		t.Errorf("history bytes = cache %d / upstream %d", history.cacheBytes, history.upstreamBytes)
+	}
+	if missing := stats.routes["/v1/missing/"]; missing == nil || missing.errors != 1 {
+		t.Errorf("failed request not counted as error: %+v", missing)
+	}
+}
+
+func TestStatszHandler(t *testing.T) {
+	stats := newProxyStats()
+	stats.record("", "/v1/status/", http.StatusOK, client.CacheStatusHit, 100)
+	stats.record("", "/v1/status/", http.StatusOK, client.CacheStatusHit, 100)
+	stats.record("", "/v1/status/", http.StatusOK, client.CacheStatusHit, 100)
+	stats.record("", "/v1/status/", http.StatusOK, client.CacheStatusMiss, 100)
+
+	w := httptest.NewRecorder()
+	statszHandler(nil, stats)(w, httptest.NewRequest("GET", "/statsz", nil))
+
+	body := w.Body.String()
+	if !strings.Contains(body, "/v1/status/") || !strings.Contains(body, "75.0%") {
+		t.Errorf("statsz output missing route hit ratio:\n%s", body)
+	}
+}
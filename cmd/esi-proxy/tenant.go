@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/client"
+	"github.com/Sternrassler/eve-esi-client/pkg/sso"
+)
+
+// Headers of the multi-tenant proxy.
+const (
+	// apiKeyHeader carries the API key identifying the tenant
+	apiKeyHeader = "X-API-Key"
+
+	// characterIDHeader and ownerHashHeader may name the character (and its
+	// SSO owner hash) an authenticated request is made for; they must match
+	// the access token, whose claims scope the private cache entries
+	characterIDHeader = "X-Character-ID"
+	ownerHashHeader   = "X-Owner-Hash"
+)
+
+// tenantNamePattern restricts tenant names to characters safe in Redis keys
+// and metric labels.
+var tenantNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// tenantConfig defines one tenant of the "tenants" section, e.g.
+//
+//	{"name": "alliance-a", "api_keys": ["..."]}
+//
+// Several keys per tenant allow rotating them without downtime.
+type tenantConfig struct {
+	Name    string   `json:"name"`
+	APIKeys []string `json:"api_keys"`
+}
+
+// tenants maps API keys to tenant names. Without tenants the proxy is
+// single-tenant and requires no API key.
+type tenants []tenantConfig
+
+// validate checks tenant names and that every API key is unique.
+func (t tenants) validate() error {
+	names := make(map[string]bool)
+	keys := make(map[string]bool)
+	for _, tenant := range t {
+		if !tenantNamePattern.MatchString(tenant.Name) {
+			return fmt.Errorf("tenant %q: name must match %s", tenant.Name, tenantNamePattern)
+		}
+		if names[tenant.Name] {
+			return fmt.Errorf("tenant %q: defined twice", tenant.Name)
+		}
+		names[tenant.Name] = true
+
+		if len(tenant.APIKeys) == 0 {
+			return fmt.Errorf("tenant %q: no api_keys", tenant.Name)
+		}
+		for _, key := range tenant.APIKeys {
+			if len(key) < 16 {
+				return fmt.Errorf("tenant %q: api key shorter than 16 characters", tenant.Name)
+			}
+			if keys[key] {
+				return fmt.Errorf("tenant %q: api key used by several tenants", tenant.Name)
+			}
+			keys[key] = true
+		}
+	}
+	return nil
+}
+
+// lookup returns the tenant of apiKey. Keys are compared in constant time.
+func (t tenants) lookup(apiKey string) (string, bool) {
+	tenant, found := "", false
+	for _, tc := range t {
+		for _, key := range tc.APIKeys {
+			if subtle.ConstantTimeCompare([]byte(apiKey), []byte(key)) == 1 {
+				tenant, found = tc.Name, true
+			}
+		}
+	}
+	return tenant, found
+}
+
+// requireTenant authenticates requests by API key when tenants are
+// configured and carries the tenant in the request context: private cache
+// entries are kept per tenant and proxy metrics are labeled with it.
+func requireTenant(t tenants, messages errorMessages, next http.HandlerFunc) http.HandlerFunc {
+	if len(t) == 0 {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenant, ok := t.lookup(r.Header.Get(apiKeyHeader))
+		if !ok {
+			writeError(w, r, http.StatusUnauthorized, messages, &statusError{route: r.URL.Path, status: http.StatusUnauthorized})
+			return
+		}
+		next(w, r.WithContext(client.WithTenant(r.Context(), tenant)))
+	}
+}
+
+// requestTenant returns the tenant assigned by requireTenant ("" without
+// tenants).
+func requestTenant(r *http.Request) string {
+	return client.TenantFromContext(r.Context())
+}
+
+// tokenVerifier checks access tokens (implemented by *sso.Verifier).
+type tokenVerifier interface {
+	Verify(ctx context.Context, token string) (sso.Claims, error)
+}
+
+// newTokenVerifier returns the verifier of the "sso" section.
+func newTokenVerifier(cfg ssoConfig) *sso.Verifier {
+	verifier := sso.NewVerifier()
+	verifier.ClientID = cfg.ClientID
+	if cfg.JWKSURL != "" {
+		verifier.JWKSURL = cfg.JWKSURL
+	}
+	return verifier
+}
+
+// claimsKey is the context key of the verified claims of a request's
+// access token.
+type claimsKey struct{}
+
+// bearerToken returns the Bearer token of r, "" if none.
+func bearerToken(r *http.Request) string {
+	token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return token
+}
+
+// verifyAccessToken verifies the Bearer token of private requests and
+// carries its claims in the request context for privateContext. The
+// character scope of private cache entries comes from the verified token,
+// never from the caller: invalid or expired tokens are rejected with 401,
+// X-Character-ID and X-Owner-Hash headers disagreeing with the token with
+// 403.
+func verifyAccessToken(v tokenVerifier, messages errorMessages, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := bearerToken(r)
+		if token == "" {
+			next(w, r)
+			return
+		}
+
+		claims, err := v.Verify(r.Context(), token)
+		if err != nil {
+			status := http.StatusUnauthorized
+			if !errors.Is(err, sso.ErrInvalidToken) {
+				status = http.StatusBadGateway // Signing keys unavailable
+			}
+			writeError(w, r, status, messages, fmt.Errorf("%v: %w", err, &statusError{route: r.URL.Path, status: status}))
+			return
+		}
+
+		if err := checkScopeHeaders(r, claims); err != nil {
+			writeError(w, r, http.StatusForbidden, messages, fmt.Errorf("%v: %w", err, &statusError{route: r.URL.Path, status: http.StatusForbidden}))
+			return
+		}
+		next(w, r.WithContext(context.WithValue(r.Context(), claimsKey{}, claims)))
+	}
+}
+
+// checkScopeHeaders returns an error if the X-Character-ID or X-Owner-Hash
+// header of r names another character than claims.
+func checkScopeHeaders(r *http.Request, claims sso.Claims) error {
+	if header := r.Header.Get(characterIDHeader); header != "" {
+		characterID, err := strconv.ParseInt(header, 10, 64)
+		if err != nil || characterID != claims.CharacterID {
+			return fmt.Errorf("%s %q does not match the access token", characterIDHeader, header)
+		}
+	}
+	if header := r.Header.Get(ownerHashHeader); header != "" && header != claims.OwnerHash {
+		return fmt.Errorf("%s does not match the access token", ownerHashHeader)
+	}
+	return nil
+}
+
+// privateContext forwards the caller's access token and the character scope
+// verified by verifyAccessToken to the ESI client. Requests without Bearer
+// token stay public and share the cache across tenants.
+func privateContext(ctx context.Context, r *http.Request) (context.Context, error) {
+	token := bearerToken(r)
+	if token == "" {
+		return ctx, nil
+	}
+	claims, ok := r.Context().Value(claimsKey{}).(sso.Claims)
+	if !ok {
+		return ctx, errors.New("access token not verified")
+	}
+
+	ctx = client.WithAccessToken(ctx, token)
+	ctx = client.WithCharacterID(ctx, claims.CharacterID)
+	return client.WithOwnerHash(ctx, claims.OwnerHash), nil
+}
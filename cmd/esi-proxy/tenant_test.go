@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/client"
+	"github.com/Sternrassler/eve-esi-client/pkg/sso"
+)
+
+var testTenants = tenants{
+	{Name: "alliance-a", APIKeys: []string{"key-a-0123456789", "key-a-rotated-0123"}},
+	{Name: "alliance-b", APIKeys: []string{"key-b-0123456789"}},
+}
+
+func TestRequireTenant(t *testing.T) {
+	tests := []struct {
+		name       string
+		apiKey     string
+		wantStatus int
+		wantTenant string
+	}{
+		{"first tenant", "key-a-0123456789", http.StatusOK, "alliance-a"},
+		{"rotated key", "key-a-rotated-0123", http.StatusOK, "alliance-a"},
+		{"second tenant", "key-b-0123456789", http.StatusOK, "alliance-b"},
+		{"unknown key", "key-c-0123456789", http.StatusUnauthorized, ""},
+		{"missing key", "", http.StatusUnauthorized, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tenant string
+			handler := requireTenant(testTenants, nil, func(w http.ResponseWriter, r *http.Request) {
+				tenant = requestTenant(r)
+			})
+
+			r := httptest.NewRequest("GET", "/esi/v1/status/", nil)
+			if tt.apiKey != "" {
+				r.Header.Set(apiKeyHeader, tt.apiKey)
+			}
+			w := httptest.NewRecorder()
+			handler(w, r)
+
+			if w.Code != tt.wantStatus || tenant != tt.wantTenant {
+				t.Fatalf("status = %d, tenant = %q; want %d, %q", w.Code, tenant, tt.wantStatus, tt.wantTenant)
+			}
+			if w.Code == http.StatusUnauthorized {
+				var body errorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Code != client.ErrorCodeUnauthorized {
+					t.Errorf("body = %s, want %s error", w.Body.String(), client.ErrorCodeUnauthorized)
+				}
+			}
+		})
+	}
+}
+
+func TestRequireTenant_SingleTenant(t *testing.T) {
+	called := false
+	handler := requireTenant(nil, nil, func(w http.ResponseWriter, r *http.Request) {
+		called = requestTenant(r) == ""
+	})
+
+	handler(httptest.NewRecorder(), httptest.NewRequest("GET", "/esi/v1/status/", nil))
+	if !called {
+		t.Error("request without tenants was not passed through without tenant")
+	}
+}
+
+// fakeVerifier accepts the token "valid" of character 90000001.
+type fakeVerifier struct{}
+
+func (fakeVerifier) Verify(ctx context.Context, token string) (sso.Claims, error) {
+	switch token {
+	case "valid":
+		return sso.Claims{CharacterID: 90000001, OwnerHash: "owner"}, nil
+	case "keys-down":
+		return sso.Claims{}, errors.New("fetch JWKS: status 503")
+	}
+	return sso.Claims{}, fmt.Errorf("%w: expired", sso.ErrInvalidToken)
+}
+
+func TestESIProxyHandler_PrivateRoute(t *testing.T) {
+	type scope struct {
+		token, tenant, ownerHash string
+		characterID              int64
+	}
+	tests := []struct {
+		name       string
+		header     map[string]string
+		wantStatus int
+		want       scope
+	}{
+		{
+			name:       "public",
+			header:     map[string]string{apiKeyHeader: "key-a-0123456789"},
+			wantStatus: http.StatusOK,
+			want:       scope{tenant: "alliance-a"},
+		},
+		{
+			name: "scope from the token",
+			header: map[string]string{
+				apiKeyHeader:    "key-b-0123456789",
+				"Authorization": "Bearer valid",
+			},
+			wantStatus: http.StatusOK,
+			want:       scope{token: "valid", tenant: "alliance-b", characterID: 90000001, ownerHash: "owner"},
+		},
+		{
+			name: "matching scope headers",
+			header: map[string]string{
+				apiKeyHeader:      "key-b-0123456789",
+				"Authorization":   "Bearer valid",
+				characterIDHeader: "90000001",
+				ownerHashHeader:   "owner",
+			},
+			wantStatus: http.StatusOK,
+			want:       scope{token: "valid", tenant: "alliance-b", characterID: 90000001, ownerHash: "owner"},
+		},
+		{
+			name: "other character",
+			header: map[string]string{
+				apiKeyHeader:      "key-b-0123456789",
+				"Authorization":   "Bearer valid",
+				characterIDHeader: "90000002",
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "other owner",
+			header: map[string]string{
+				apiKeyHeader:    "key-b-0123456789",
+				"Authorization": "Bearer valid",
+				ownerHashHeader: "previous-owner",
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "invalid character ID",
+			header: map[string]string{
+				apiKeyHeader:      "key-b-0123456789",
+				"Authorization":   "Bearer valid",
+				characterIDHeader: "abc",
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "invalid token",
+			header: map[string]string{
+				apiKeyHeader:      "key-b-0123456789",
+				"Authorization":   "Bearer forged",
+				characterIDHeader: "90000001",
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "signing keys unavailable",
+			header: map[string]string{
+				apiKeyHeader:    "key-b-0123456789",
+				"Authorization": "Bearer keys-down",
+			},
+			wantStatus: http.StatusBadGateway,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got scope
+			esi := getterFunc(func(ctx context.Context, endpoint string) (*http.Response, error) {
+				got = scope{
+					token:       client.AccessTokenFromContext(ctx),
+					tenant:      client.TenantFromContext(ctx),
+					ownerHash:   client.OwnerHashFromContext(ctx),
+					characterID: client.CharacterIDFromContext(ctx),
+				}
+				return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(`{}`))}, nil
+			})
+			handler := requireTenant(testTenants, nil, verifyAccessToken(fakeVerifier{}, nil, esiProxyHandler(esi, nil, nil, newProxyStats())))
+
+			r := httptest.NewRequest("GET", "/esi/v1/characters/90000001/wallet/", nil)
+			for name, value := range tt.header {
+				r.Header.Set(name, value)
+			}
+			w := httptest.NewRecorder()
+			handler(w, r)
+
+			if w.Code != tt.wantStatus || got != tt.want {
+				t.Errorf("status = %d, scope = %+v; want %d, %+v", w.Code, got, tt.wantStatus, tt.want)
+			}
+		})
+	}
+}
+
+func TestESIProxyHandler_UnverifiedToken(t *testing.T) {
+	called := false
+	esi := getterFunc(func(ctx context.Context, endpoint string) (*http.Response, error) {
+		called = true
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(`{}`))}, nil
+	})
+
+	// Without verifyAccessToken a token is never trusted
+	r := httptest.NewRequest("GET", "/esi/v1/characters/90000001/wallet/", nil)
+	r.Header.Set("Authorization", "Bearer valid")
+	r.Header.Set(characterIDHeader, "90000001")
+	w := httptest.NewRecorder()
+	esiProxyHandler(esi, nil, nil, newProxyStats())(w, r)
+
+	if w.Code != http.StatusUnauthorized || called {
+		t.Errorf("status = %d, ESI called: %t; want 401 without ESI call", w.Code, called)
+	}
+}
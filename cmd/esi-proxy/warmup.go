@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/client"
+	"github.com/Sternrassler/eve-esi-client/pkg/pool"
+	"github.com/redis/go-redis/v9"
+)
+
+// defaultWarmupConcurrency is the number of warm-up fetches in flight.
+const defaultWarmupConcurrency = 2
+
+// warmupConfig lists endpoints fetched at startup, so the first downstream
+// requests after a deploy hit a warm cache, e.g.
+//
+//	{"endpoints": ["/v1/markets/prices/", "/v1/status/"], "redis_key": "esi:warmup", "concurrency": 2}
+//
+// Endpoints come first, in order; then the members of the Redis sorted set
+// RedisKey, highest score first (ZADD esi:warmup 10 /v1/markets/prices/).
+type warmupConfig struct {
+	Endpoints   []string `json:"endpoints"`
+	RedisKey    string   `json:"redis_key"`
+	Concurrency int      `json:"concurrency"`
+}
+
+// enabled reports whether there is anything to warm up.
+func (w warmupConfig) enabled() bool {
+	return len(w.Endpoints) > 0 || w.RedisKey != ""
+}
+
+// validate checks the configured endpoints.
+func (w warmupConfig) validate() error {
+	for _, endpoint := range w.Endpoints {
+		if !strings.HasPrefix(endpoint, "/") {
+			return fmt.Errorf("warmup: endpoint %q must start with /", endpoint)
+		}
+	}
+	if w.Concurrency < 0 {
+		return fmt.Errorf("warmup: concurrency must not be negative (got %d)", w.Concurrency)
+	}
+	return nil
+}
+
+// warmupList returns the endpoints to warm up in priority order without
+// duplicates. Invalid Redis members are skipped.
+func warmupList(ctx context.Context, redisClient *redis.Client, cfg warmupConfig) ([]string, error) {
+	endpoints := cfg.Endpoints
+	if cfg.RedisKey != "" {
+		members, err := redisClient.ZRevRange(ctx, cfg.RedisKey, 0, -1).Result()
+		if err != nil {
+			return dedupe(endpoints), fmt.Errorf("read %s: %w", cfg.RedisKey, err)
+		}
+		for _, member := range members {
+			if strings.HasPrefix(member, "/") {
+				endpoints = append(endpoints, member)
+			}
+		}
+	}
+	return dedupe(endpoints), nil
+}
+
+// dedupe returns endpoints without repetitions, keeping the first position.
+func dedupe(endpoints []string) []string {
+	seen := make(map[string]bool, len(endpoints))
+	unique := make([]string, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		if !seen[endpoint] {
+			seen[endpoint] = true
+			unique = append(unique, endpoint)
+		}
+	}
+	return unique
+}
+
+// endpointGetter fetches an ESI endpoint (implemented by *client.Client).
+type endpointGetter interface {
+	Get(ctx context.Context, endpoint string) (*http.Response, error)
+}
+
+// warmupResult summarizes a warm-up.
+type warmupResult struct {
+	Warmed   int
+	Failed   int
+	Duration time.Duration
+}
+
+// warmCache fetches endpoints with low priority (see client.WithPriority),
+// so they go through the rate limiter behind downstream traffic and land in
+// the cache. At most concurrency fetches run at once, started in list order.
+func warmCache(ctx context.Context, getter endpointGetter, endpoints []string, concurrency int) warmupResult {
+	if concurrency <= 0 {
+		concurrency = defaultWarmupConcurrency
+	}
+	ctx = client.WithPriority(ctx, client.PriorityLow)
+	start := time.Now()
+
+	results := pool.Run(ctx, len(endpoints), pool.Config{Workers: concurrency}, func(ctx context.Context, i int) (struct{}, error) {
+		err := warmEndpoint(ctx, getter, endpoints[i])
+		if err != nil {
+			log.Printf("Warm-up of %s failed: %v", endpoints[i], err)
+		}
+		return struct{}{}, err
+	})
+
+	var result warmupResult
+	for _, r := range results {
+		switch {
+		case r.Attempts == 0:
+			// Not started before ctx was done
+		case r.Err != nil:
+			result.Failed++
+		default:
+			result.Warmed++
+		}
+	}
+
+	result.Duration = time.Since(start)
+	return result
+}
+
+// warmEndpoint fetches endpoint and discards the body once cached.
+func warmEndpoint(ctx context.Context, getter endpointGetter, endpoint string) error {
+	resp, err := getter.Get(ctx, endpoint)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+		return err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// runWarmup loads the warm-up list and warms the cache, logging a summary.
+func runWarmup(ctx context.Context, redisClient *redis.Client, esiClient *client.Client, cfg warmupConfig) {
+	endpoints, err := warmupList(ctx, redisClient, cfg)
+	if err != nil {
+		log.Printf("Warm-up list incomplete: %v", err)
+	}
+	if len(endpoints) == 0 {
+		return
+	}
+
+	log.Printf("Warming cache with %d endpoints", len(endpoints))
+	result := warmCache(ctx, esiClient, endpoints, cfg.Concurrency)
+	log.Printf("Cache warm-up done in %s (warmed: %d, failed: %d)", result.Duration.Round(time.Millisecond), result.Warmed, result.Failed)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/client"
+)
+
+// recordingGetter answers warm-up fetches and records their order and
+// priority.
+type recordingGetter struct {
+	mu         sync.Mutex
+	endpoints  []string
+	priorities []client.Priority
+}
+
+func (g *recordingGetter) Get(ctx context.Context, endpoint string) (*http.Response, error) {
+	g.mu.Lock()
+	g.endpoints = append(g.endpoints, endpoint)
+	g.priorities = append(g.priorities, client.PriorityFromContext(ctx))
+	g.mu.Unlock()
+
+	switch endpoint {
+	case "/v1/broken/":
+		return nil, errors.New("boom")
+	case "/v1/missing/":
+		return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader(`{}`))}, nil
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`[]`))}, nil
+}
+
+func TestWarmCache(t *testing.T) {
+	getter := &recordingGetter{}
+	endpoints := []string{"/v1/markets/prices/", "/v1/broken/", "/v1/status/", "/v1/missing/"}
+
+	result := warmCache(context.Background(), getter, endpoints, 1)
+	if result.Warmed != 2 || result.Failed != 2 {
+		t.Errorf("warmCache() = %+v, want 2 warmed, 2 failed", result)
+	}
+	if !reflect.DeepEqual(getter.endpoints, endpoints) {
+		t.Errorf("fetched %v, want list order %v", getter.endpoints, endpoints)
+	}
+	for _, p := range getter.priorities {
+		if p != client.PriorityLow {
+			t.Errorf("priority = %s, want low", p)
+		}
+	}
+}
+
+func TestWarmCache_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	getter := &recordingGetter{}
+	result := warmCache(ctx, getter, []string{"/v1/status/", "/v1/markets/prices/"}, 2)
+	if result.Warmed+result.Failed != 0 || len(getter.endpoints) != 0 {
+		t.Errorf("warmCache() after cancel = %+v, fetched %v, want nothing", result, getter.endpoints)
+	}
+}
+
+func TestDedupe(t *testing.T) {
+	got := dedupe([]string{"/v1/status/", "/v1/markets/prices/", "/v1/status/"})
+	want := []string{"/v1/status/", "/v1/markets/prices/"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("dedupe() = %v, want %v", got, want)
+	}
+}
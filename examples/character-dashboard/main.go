@@ -0,0 +1,166 @@
+// Package main shows authenticated ESI requests cached per character: it
+// verifies an EVE SSO access token, scopes the client's requests with the
+// token's character and owner hash, and prints a small dashboard of the
+// character's wallet, skill queue and location.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/client"
+	"github.com/Sternrassler/eve-esi-client/pkg/sso"
+	"github.com/redis/go-redis/v9"
+)
+
+// panel is one dashboard section backed by an ESI route.
+type panel struct {
+	title  string
+	scope  string // SSO scope the route requires
+	route  string // Format with the character ID
+	render func(data []byte) (string, error)
+}
+
+// panels are the dashboard sections, in display order.
+var panels = []panel{
+	{
+		title: "Wallet",
+		scope: "esi-wallet.read_character_wallet.v1",
+		route: "/v1/characters/%d/wallet/",
+		render: func(data []byte) (string, error) {
+			var balance float64
+			if err := json.Unmarshal(data, &balance); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%.2f ISK", balance), nil
+		},
+	},
+	{
+		title: "Skill queue",
+		scope: "esi-skills.read_skillqueue.v1",
+		route: "/v2/characters/%d/skillqueue/",
+		render: func(data []byte) (string, error) {
+			var queue []struct {
+				FinishDate time.Time `json:"finish_date"`
+			}
+			if err := json.Unmarshal(data, &queue); err != nil {
+				return "", err
+			}
+			if len(queue) == 0 {
+				return "empty", nil
+			}
+			last := queue[len(queue)-1]
+			return fmt.Sprintf("%d skills, done %s", len(queue), last.FinishDate.Format(time.RFC1123)), nil
+		},
+	},
+	{
+		title: "Location",
+		scope: "esi-location.read_location.v1",
+		route: "/v2/characters/%d/location/",
+		render: func(data []byte) (string, error) {
+			var location struct {
+				SolarSystemID int64 `json:"solar_system_id"`
+				StationID     int64 `json:"station_id"`
+			}
+			if err := json.Unmarshal(data, &location); err != nil {
+				return "", err
+			}
+			if location.StationID != 0 {
+				return fmt.Sprintf("docked in station %d (system %d)", location.StationID, location.SolarSystemID), nil
+			}
+			return fmt.Sprintf("in space, system %d", location.SolarSystemID), nil
+		},
+	},
+}
+
+func main() {
+	token := os.Getenv("ESI_ACCESS_TOKEN")
+	if token == "" {
+		log.Fatal("ESI_ACCESS_TOKEN is required (an EVE SSO access token)")
+	}
+	ctx := context.Background()
+
+	// 1. Verify the token: the cache scope must come from its claims
+	verifier := sso.NewVerifier()
+	verifier.ClientID = os.Getenv("ESI_CLIENT_ID") // Optional
+	claims, err := verifier.Verify(ctx, token)
+	if err != nil {
+		log.Fatalf("Access token rejected: %v", err)
+	}
+
+	// 2. Client with StrictAuthCache: unscoped private requests fail
+	redisClient := redis.NewClient(&redis.Options{Addr: getEnv("REDIS_URL", "localhost:6379")})
+	defer redisClient.Close()
+
+	cfg := client.DefaultConfig(redisClient, "EVE-ESI-Character-Dashboard/1.0.0 (your-email@example.com)")
+	cfg.StrictAuthCache = true
+	esiClient, err := client.New(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create ESI client: %v", err)
+	}
+	defer esiClient.Close()
+
+	// 3. Scope every request to the character and its current owner
+	ctx = client.WithAccessToken(ctx, token)
+	ctx = client.WithCharacterID(ctx, claims.CharacterID)
+	ctx = client.WithOwnerHash(ctx, claims.OwnerHash)
+
+	fmt.Printf("%s (%d), token valid until %s\n\n", claims.CharacterName, claims.CharacterID, claims.ExpiresAt.Format(time.RFC1123))
+	for _, p := range panels {
+		fmt.Printf("%-12s %s\n", p.title+":", renderPanel(ctx, esiClient, claims, p))
+	}
+}
+
+// renderPanel fetches and renders one panel, or explains why it is empty.
+func renderPanel(ctx context.Context, esiClient *client.Client, claims sso.Claims, p panel) string {
+	if !slices.Contains(claims.Scopes, p.scope) {
+		return "token lacks scope " + p.scope
+	}
+
+	data, status, err := fetch(ctx, esiClient, fmt.Sprintf(p.route, claims.CharacterID))
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	text, err := p.render(data)
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	return fmt.Sprintf("%s [cache %s]", text, status)
+}
+
+// fetch GETs a private route and returns the body and cache status.
+func fetch(ctx context.Context, esiClient *client.Client, route string) ([]byte, string, error) {
+	resp, err := esiClient.Get(ctx, route)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusForbidden:
+		return nil, "", fmt.Errorf("forbidden (token revoked or scope missing)")
+	default:
+		return nil, "", fmt.Errorf("ESI returned status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, resp.Header.Get(client.CacheStatusHeader), nil
+}
+
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
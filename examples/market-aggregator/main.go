@@ -0,0 +1,229 @@
+// Package main is a reference service computing Jita best prices for a
+// watchlist. It shows the recommended wiring of the ESI client: Redis-backed
+// client (or a shared esi-proxy), paginated region crawl, cache warming at
+// startup and periodic refresh.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/client"
+	"github.com/Sternrassler/eve-esi-client/pkg/market"
+	"github.com/Sternrassler/eve-esi-client/pkg/pagination"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	theForgeRegionID = 10000002
+	jitaStationID    = 60003760 // Jita IV - Moon 4 - Caldari Navy Assembly Plant
+)
+
+// defaultWatchlist: Tritanium, Pyerite, Mexallon, Isogen, PLEX.
+const defaultWatchlist = "34,35,36,37,44992"
+
+// aggregator keeps the latest best prices of the watchlist.
+type aggregator struct {
+	fetcher   pagination.PageFetcher
+	regionID  int
+	stationID int64
+	watchlist []int
+
+	mu        sync.RWMutex
+	prices    map[int]Price
+	updatedAt time.Time
+	lastErr   error
+}
+
+// refresh crawls the region's order book and recomputes the prices. On a
+// partial crawl the previous prices are kept.
+func (a *aggregator) refresh(ctx context.Context) error {
+	ctx = client.WithRequestID(ctx, client.NewRequestID())
+
+	snapshots, err := market.CrawlRegions(ctx, []int{a.regionID}, market.CrawlOptions{
+		Fetcher:        a.fetcher,
+		MaxConcurrency: 5,
+	})
+	if err == nil {
+		err = snapshots[0].Err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.lastErr = err
+	if err != nil {
+		return fmt.Errorf("crawl region %d: %w", a.regionID, err)
+	}
+	a.prices = bestPrices(snapshots[0].Orders, a.stationID, a.watchlist)
+	a.updatedAt = snapshots[0].FetchedAt
+	log.Printf("Refreshed %d prices from %d orders (%d pages) in %s",
+		len(a.prices), len(snapshots[0].Orders), snapshots[0].Pages, snapshots[0].Duration.Round(time.Millisecond))
+	return nil
+}
+
+// run refreshes the prices every interval until ctx is done.
+func (a *aggregator) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := a.refresh(ctx); err != nil {
+				log.Printf("Refresh failed, serving previous prices: %v", err)
+			}
+		}
+	}
+}
+
+// pricesResponse is the body of GET /prices.
+type pricesResponse struct {
+	StationID int64     `json:"station_id"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Stale     bool      `json:"stale"` // Last refresh failed
+	Prices    []Price   `json:"prices"`
+}
+
+// pricesHandler serves the best prices of the watchlist, or of the type IDs
+// given as ?type_id=34,35.
+func (a *aggregator) pricesHandler(w http.ResponseWriter, r *http.Request) {
+	typeIDs := a.watchlist
+	if q := r.URL.Query().Get("type_id"); q != "" {
+		ids, err := parseTypeIDs(q)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		typeIDs = ids
+	}
+
+	a.mu.RLock()
+	resp := pricesResponse{
+		StationID: a.stationID,
+		UpdatedAt: a.updatedAt,
+		Stale:     a.lastErr != nil,
+		Prices:    make([]Price, 0, len(typeIDs)),
+	}
+	for _, typeID := range typeIDs {
+		if p, ok := a.prices[typeID]; ok {
+			resp.Prices = append(resp.Prices, p)
+		}
+	}
+	a.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
+
+func main() {
+	watchlist, err := parseTypeIDs(getEnv("WATCHLIST", defaultWatchlist))
+	if err != nil {
+		log.Fatalf("Invalid WATCHLIST: %v", err)
+	}
+	interval, err := time.ParseDuration(getEnv("REFRESH_INTERVAL", "5m"))
+	if err != nil {
+		log.Fatalf("Invalid REFRESH_INTERVAL: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// 1. Page source: a shared esi-proxy if configured, otherwise an own client
+	var fetcher pagination.PageFetcher
+	if proxyURL := os.Getenv("ESI_PROXY_URL"); proxyURL != "" {
+		fetcher = &proxyFetcher{baseURL: proxyURL, httpClient: &http.Client{Timeout: 60 * time.Second}}
+		log.Printf("Fetching through esi-proxy at %s", proxyURL)
+	} else {
+		redisClient := redis.NewClient(&redis.Options{Addr: getEnv("REDIS_URL", "localhost:6379")})
+		defer redisClient.Close()
+		if err := redisClient.Ping(ctx).Err(); err != nil {
+			log.Fatalf("Failed to connect to Redis: %v", err)
+		}
+
+		cfg := client.DefaultConfig(redisClient, getEnv("ESI_USER_AGENT", "EVE-ESI-Market-Aggregator/1.0.0 (your-email@example.com)"))
+		esiClient, err := client.New(cfg)
+		if err != nil {
+			log.Fatalf("Failed to create ESI client: %v", err)
+		}
+		defer esiClient.Close()
+		fetcher = esiClient
+	}
+
+	agg := &aggregator{
+		fetcher:   fetcher,
+		regionID:  theForgeRegionID,
+		stationID: jitaStationID,
+		watchlist: watchlist,
+	}
+
+	// 2. Cache warming: crawl once before accepting requests, so the first
+	// caller is not the one paying for a cold region crawl
+	log.Printf("Warming cache for region %d (%d watched types)...", agg.regionID, len(watchlist))
+	if err := agg.refresh(ctx); err != nil {
+		log.Fatalf("Cache warming failed: %v", err)
+	}
+	go agg.run(ctx, interval)
+
+	// 3. Serve
+	mux := http.NewServeMux()
+	mux.HandleFunc("/prices", agg.pricesHandler)
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	server := &http.Server{Addr: ":" + getEnv("PORT", "8081"), Handler: mux}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("Serving Jita prices on http://localhost%s/prices", server.Addr)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Fatalf("Server failed: %v", err)
+	}
+}
+
+// parseTypeIDs parses a comma-separated list of type IDs.
+func parseTypeIDs(s string) ([]int, error) {
+	var ids []int
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		id, err := strconv.Atoi(field)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid type ID %q", field)
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no type IDs given")
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
+
+// getEnv retrieves an environment variable or returns a default value.
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/market"
+)
+
+func TestBestPrices(t *testing.T) {
+	orders := []market.Order{
+		{TypeID: 34, LocationID: jitaStationID, Price: 5.0, IsBuyOrder: true, VolumeRemain: 100},
+		{TypeID: 34, LocationID: jitaStationID, Price: 5.5, IsBuyOrder: true, VolumeRemain: 10},
+		{TypeID: 34, LocationID: jitaStationID, Price: 5.5, IsBuyOrder: true, VolumeRemain: 20},
+		{TypeID: 34, LocationID: jitaStationID, Price: 6.0, VolumeRemain: 50},
+		{TypeID: 34, LocationID: jitaStationID, Price: 5.8, VolumeRemain: 70},
+		{TypeID: 34, LocationID: 60008494, Price: 1.0, VolumeRemain: 999},    // Amarr, ignored
+		{TypeID: 99, LocationID: jitaStationID, Price: 1.0, VolumeRemain: 1}, // not watched
+	}
+
+	prices := bestPrices(orders, jitaStationID, []int{34, 35})
+
+	want := map[int]Price{
+		34: {TypeID: 34, BestBuy: 5.5, BuyVolume: 30, BestSell: 5.8, SellVolume: 70, Orders: 5},
+		35: {TypeID: 35},
+	}
+	if len(prices) != len(want) {
+		t.Fatalf("got %d prices, want %d", len(prices), len(want))
+	}
+	for typeID, w := range want {
+		if got := prices[typeID]; got != w {
+			t.Errorf("prices[%d] = %+v, want %+v", typeID, got, w)
+		}
+	}
+}
+
+// fakeProxy serves a two-page order book for The Forge like esi-proxy does.
+func fakeProxy(t *testing.T) *httptest.Server {
+	t.Helper()
+	pages := map[string][]market.Order{
+		"1": {{OrderID: 1, TypeID: 34, LocationID: jitaStationID, Price: 4.9, VolumeRemain: 10}},
+		"2": {{OrderID: 2, TypeID: 34, LocationID: jitaStationID, Price: 4.2, IsBuyOrder: true, VolumeRemain: 5}},
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != fmt.Sprintf("/esi/v1/markets/%d/orders/", theForgeRegionID) {
+			http.NotFound(w, r)
+			return
+		}
+		orders, ok := pages[r.URL.Query().Get("page")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("X-Pages", strconv.Itoa(len(pages)))
+		_ = json.NewEncoder(w).Encode(orders)
+	}))
+}
+
+func TestAggregator_ThroughProxy(t *testing.T) {
+	proxy := fakeProxy(t)
+	defer proxy.Close()
+
+	agg := &aggregator{
+		fetcher:   &proxyFetcher{baseURL: proxy.URL, httpClient: proxy.Client()},
+		regionID:  theForgeRegionID,
+		stationID: jitaStationID,
+		watchlist: []int{34},
+	}
+	if err := agg.refresh(context.Background()); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	agg.pricesHandler(rec, httptest.NewRequest(http.MethodGet, "/prices", nil))
+
+	var resp pricesResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	want := Price{TypeID: 34, BestBuy: 4.2, BuyVolume: 5, BestSell: 4.9, SellVolume: 10, Orders: 2}
+	if resp.Stale || len(resp.Prices) != 1 || resp.Prices[0] != want {
+		t.Errorf("response = %+v, want fresh prices [%+v]", resp, want)
+	}
+}
+
+func TestAggregator_KeepsPricesOnFailure(t *testing.T) {
+	proxy := fakeProxy(t)
+
+	agg := &aggregator{
+		fetcher:   &proxyFetcher{baseURL: proxy.URL, httpClient: proxy.Client()},
+		regionID:  theForgeRegionID,
+		stationID: jitaStationID,
+		watchlist: []int{34},
+	}
+	if err := agg.refresh(context.Background()); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+
+	proxy.Close()
+	if err := agg.refresh(context.Background()); err == nil {
+		t.Fatal("refresh with proxy down succeeded, want error")
+	}
+
+	rec := httptest.NewRecorder()
+	agg.pricesHandler(rec, httptest.NewRequest(http.MethodGet, "/prices?type_id=34", nil))
+
+	var resp pricesResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if !resp.Stale || len(resp.Prices) != 1 {
+		t.Errorf("response = %+v, want stale previous prices", resp)
+	}
+}
+
+func TestParseTypeIDs(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []int
+		wantErr bool
+	}{
+		{"35, 34,,44992", []int{34, 35, 44992}, false},
+		{"34,abc", nil, true},
+		{"-1", nil, true},
+		{"", nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseTypeIDs(tt.in)
+		if (err != nil) != tt.wantErr || fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("parseTypeIDs(%q) = %v, %v; want %v (error: %v)", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"github.com/Sternrassler/eve-esi-client/pkg/market"
+)
+
+// Price is the best buy and sell price of a type at one station.
+type Price struct {
+	TypeID     int     `json:"type_id"`
+	BestBuy    float64 `json:"best_buy"`    // Highest buy order (0 = none)
+	BestSell   float64 `json:"best_sell"`   // Lowest sell order (0 = none)
+	BuyVolume  int     `json:"buy_volume"`  // Volume remaining at BestBuy
+	SellVolume int     `json:"sell_volume"` // Volume remaining at BestSell
+	Orders     int     `json:"orders"`      // Orders of the type at the station
+}
+
+// bestPrices computes the best prices of the watchlist types from the orders
+// located at stationID. Every watchlist type gets an entry, with zero prices
+// if it has no orders at the station.
+func bestPrices(orders []market.Order, stationID int64, watchlist []int) map[int]Price {
+	prices := make(map[int]Price, len(watchlist))
+	for _, typeID := range watchlist {
+		prices[typeID] = Price{TypeID: typeID}
+	}
+
+	for _, order := range orders {
+		if order.LocationID != stationID {
+			continue
+		}
+		p, ok := prices[order.TypeID]
+		if !ok {
+			continue
+		}
+		p.Orders++
+
+		switch {
+		case order.IsBuyOrder && order.Price > p.BestBuy:
+			p.BestBuy, p.BuyVolume = order.Price, order.VolumeRemain
+		case order.IsBuyOrder && order.Price == p.BestBuy:
+			p.BuyVolume += order.VolumeRemain
+		case !order.IsBuyOrder && (p.BestSell == 0 || order.Price < p.BestSell):
+			p.BestSell, p.SellVolume = order.Price, order.VolumeRemain
+		case !order.IsBuyOrder && order.Price == p.BestSell:
+			p.SellVolume += order.VolumeRemain
+		}
+		prices[order.TypeID] = p
+	}
+
+	return prices
+}
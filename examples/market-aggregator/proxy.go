@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/client"
+)
+
+// proxyFetcher implements pagination.PageFetcher on top of a running
+// esi-proxy, so several services share one rate limit and one cache.
+type proxyFetcher struct {
+	baseURL    string // e.g. http://localhost:8080
+	httpClient *http.Client
+}
+
+// FetchPage fetches one page through the proxy's /esi/ route.
+func (f *proxyFetcher) FetchPage(ctx context.Context, endpoint string, pageNum int) ([]byte, int, error) {
+	url := fmt.Sprintf("%s/esi%s?page=%d", strings.TrimSuffix(f.baseURL, "/"), endpoint, pageNum)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("create request: %w", err)
+	}
+	if id := client.RequestIDFromContext(ctx); id != "" {
+		req.Header.Set(client.RequestIDHeader, id)
+	}
+
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("proxy request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("read proxy response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		// Proxy errors are JSON with a stable code, see the README
+		return nil, 0, fmt.Errorf("proxy returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	totalPages := 1
+	if xPages := resp.Header.Get("X-Pages"); xPages != "" {
+		if n, err := strconv.Atoi(xPages); err == nil {
+			totalPages = n
+		}
+	}
+	return data, totalPages, nil
+}
@@ -0,0 +1,127 @@
+package testutil
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// StatusErrorLimited is ESI's status for requests discarded because the
+// error limit is exhausted.
+const StatusErrorLimited = 420
+
+// ErrorBudget simulates ESI's error limit for a MockESI: every response
+// carries X-ESI-Error-Limit-Remain and X-ESI-Error-Limit-Reset, each 4xx or
+// 5xx response costs one error, and once the budget is used up all requests
+// are discarded with 420 until the window ends and the budget refills.
+type ErrorBudget struct {
+	mu        sync.Mutex
+	limit     int
+	window    time.Duration
+	remain    int
+	resetAt   time.Time // Zero until the first request opens a window
+	discarded int
+}
+
+// NewErrorBudget returns a budget of limit errors per window.
+func NewErrorBudget(limit int, window time.Duration) *ErrorBudget {
+	return &ErrorBudget{limit: limit, window: window, remain: limit}
+}
+
+// Spend deducts n errors, like errors of other applications sharing the IP.
+func (b *ErrorBudget) Spend(n int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.roll()
+	b.remain = max(b.remain-n, 0)
+}
+
+// Remaining returns the errors left in the current window.
+func (b *ErrorBudget) Remaining() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.roll()
+	return b.remain
+}
+
+// ResetAt returns the end of the current window.
+func (b *ErrorBudget) ResetAt() time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.roll()
+	return b.resetAt
+}
+
+// Discarded returns the number of requests answered with 420.
+func (b *ErrorBudget) Discarded() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.discarded
+}
+
+// roll opens a new window with a full budget once the current one ended.
+func (b *ErrorBudget) roll() {
+	if now := time.Now(); !now.Before(b.resetAt) {
+		b.remain = b.limit
+		b.resetAt = now.Add(b.window)
+	}
+}
+
+// admit reports whether a request may be served and, if not, answers it
+// with 420.
+func (b *ErrorBudget) admit(w http.ResponseWriter) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.roll()
+	if b.remain > 0 {
+		return true
+	}
+	b.discarded++
+	b.setHeaders(w.Header())
+	w.WriteHeader(StatusErrorLimited)
+	_, _ = w.Write([]byte(`{"error": "This software has exceeded the error limit for ESI."}`))
+	return false
+}
+
+// record charges a response status and sets the budget headers.
+func (b *ErrorBudget) record(header http.Header, status int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.roll()
+	if status >= 400 {
+		b.remain = max(b.remain-1, 0)
+	}
+	b.setHeaders(header)
+}
+
+// setHeaders sets the error limit headers of the current window.
+func (b *ErrorBudget) setHeaders(header http.Header) {
+	reset := int(math.Ceil(time.Until(b.resetAt).Seconds()))
+	header.Set("X-ESI-Error-Limit-Remain", strconv.Itoa(b.remain))
+	header.Set("X-ESI-Error-Limit-Reset", strconv.Itoa(reset))
+}
+
+// budgetWriter charges the response status to the budget when the handler
+// writes it, overriding error limit headers set by the handler.
+type budgetWriter struct {
+	http.ResponseWriter
+	budget  *ErrorBudget
+	written bool
+}
+
+func (w *budgetWriter) WriteHeader(status int) {
+	if !w.written {
+		w.written = true
+		w.budget.record(w.Header(), status)
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *budgetWriter) Write(p []byte) (int, error) {
+	if !w.written {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(p)
+}
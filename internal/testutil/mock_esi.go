@@ -22,6 +22,7 @@ type MockESI struct {
 	server   *httptest.Server
 	mu       sync.RWMutex
 	handlers map[string]func(w http.ResponseWriter, r *http.Request)
+	budget   *ErrorBudget
 
 	// Tracking
 	RequestCount      int
@@ -49,8 +50,17 @@ func NewMockESI() *MockESI {
 		// Check for custom handler
 		mock.mu.RLock()
 		handler, exists := mock.handlers[r.URL.Path]
+		budget := mock.budget
 		mock.mu.RUnlock()
 
+		// Simulated error limit
+		if budget != nil {
+			if !budget.admit(w) {
+				return
+			}
+			w = &budgetWriter{ResponseWriter: w, budget: budget}
+		}
+
 		if exists {
 			handler(w, r)
 			return
@@ -110,6 +120,39 @@ func (m *MockESI) SetResponse(path string, resp MockESIResponse) {
 	})
 }
 
+// SetSequence configures scripted responses for a path: each request gets
+// the next response, the last one repeats.
+func (m *MockESI) SetSequence(path string, responses ...MockESIResponse) {
+	var mu sync.Mutex
+	next := 0
+	m.SetHandler(path, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		resp := responses[min(next, len(responses)-1)]
+		next++
+		mu.Unlock()
+
+		if resp.Delay > 0 {
+			time.Sleep(resp.Delay)
+		}
+		for key, value := range resp.Headers {
+			w.Header().Set(key, value)
+		}
+		w.WriteHeader(resp.StatusCode)
+		if resp.Body != "" {
+			_, _ = w.Write([]byte(resp.Body))
+		}
+	})
+}
+
+// SetErrorBudget simulates ESI's error limit with budget for all paths
+// (nil disables it). Responses then carry the budget's error limit headers
+// instead of those set by handlers.
+func (m *MockESI) SetErrorBudget(budget *ErrorBudget) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.budget = budget
+}
+
 // SetMarketOrdersResponse configures a typical market orders endpoint response.
 func (m *MockESI) SetMarketOrdersResponse(regionID int, resp MockESIResponse) {
 	path := fmt.Sprintf("/v1/markets/%d/orders/", regionID)
@@ -0,0 +1,521 @@
+// Command dashgen generates the Grafana dashboard and Prometheus alert rules
+// in docs/monitoring from the metric definitions in pkg/. It parses the
+// promauto.New* calls, so every metric gets a dashboard panel and alert rules
+// can only reference metrics that exist.
+//
+// Run via go generate in pkg/metrics:
+//
+//	go generate ./pkg/metrics
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Metric is a Prometheus metric defined via promauto.
+type Metric struct {
+	Name    string
+	Help    string
+	Type    string // counter, gauge, histogram or summary
+	Labels  []string
+	Package string
+}
+
+func main() {
+	root := flag.String("root", ".", "Repository root")
+	dashboardPath := flag.String("dashboard", "docs/monitoring/grafana-dashboard.json", "Dashboard output `file`, relative to root")
+	alertsPath := flag.String("alerts", "docs/monitoring/prometheus-alerts.yml", "Alert rules output `file`, relative to root")
+	flag.Parse()
+
+	metrics, err := ParseMetrics(filepath.Join(*root, "pkg"))
+	if err != nil {
+		log.Fatalf("parse metrics: %v", err)
+	}
+
+	dashboard, err := Dashboard(metrics)
+	if err != nil {
+		log.Fatalf("generate dashboard: %v", err)
+	}
+	alerts, err := AlertRules(metrics)
+	if err != nil {
+		log.Fatalf("generate alert rules: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(*root, *dashboardPath), dashboard, 0o644); err != nil {
+		log.Fatalf("write dashboard: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(*root, *alertsPath), alerts, 0o644); err != nil {
+		log.Fatalf("write alert rules: %v", err)
+	}
+}
+
+// metricTypes maps promauto constructors to metric types.
+var metricTypes = map[string]string{
+	"NewCounter":      "counter",
+	"NewCounterVec":   "counter",
+	"NewGauge":        "gauge",
+	"NewGaugeVec":     "gauge",
+	"NewHistogram":    "histogram",
+	"NewHistogramVec": "histogram",
+	"NewSummary":      "summary",
+	"NewSummaryVec":   "summary",
+}
+
+// ParseMetrics collects the promauto metric definitions of all non-test Go
+// files below dir, sorted by package and name.
+func ParseMetrics(dir string) ([]Metric, error) {
+	var metrics []Metric
+	fset := token.NewFileSet()
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return err
+		}
+
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return err
+		}
+
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			pkg, ok := sel.X.(*ast.Ident)
+			if !ok || pkg.Name != "promauto" {
+				return true
+			}
+			typ, ok := metricTypes[sel.Sel.Name]
+			if !ok || len(call.Args) == 0 {
+				return true
+			}
+
+			metric := Metric{Type: typ, Package: file.Name.Name}
+			if opts, ok := call.Args[0].(*ast.CompositeLit); ok {
+				for _, elt := range opts.Elts {
+					kv, ok := elt.(*ast.KeyValueExpr)
+					if !ok {
+						continue
+					}
+					switch fmt.Sprint(kv.Key) {
+					case "Name":
+						metric.Name = stringValue(kv.Value)
+					case "Help":
+						metric.Help = stringValue(kv.Value)
+					}
+				}
+			}
+			if len(call.Args) > 1 {
+				if labels, ok := call.Args[1].(*ast.CompositeLit); ok {
+					for _, elt := range labels.Elts {
+						metric.Labels = append(metric.Labels, stringValue(elt))
+					}
+				}
+			}
+
+			if metric.Name != "" {
+				metrics = append(metrics, metric)
+			}
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(metrics, func(i, j int) bool {
+		if metrics[i].Package != metrics[j].Package {
+			return metrics[i].Package < metrics[j].Package
+		}
+		return metrics[i].Name < metrics[j].Name
+	})
+	return metrics, nil
+}
+
+// stringValue evaluates a string literal or a concatenation of literals.
+func stringValue(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		s, err := strconv.Unquote(e.Value)
+		if err != nil {
+			return ""
+		}
+		return s
+	case *ast.BinaryExpr:
+		if e.Op == token.ADD {
+			return stringValue(e.X) + stringValue(e.Y)
+		}
+	}
+	return ""
+}
+
+// Query returns the PromQL expression plotted for a metric.
+func (m Metric) Query() string {
+	sum := "sum"
+	if len(m.Labels) > 0 {
+		sum = "sum by (" + strings.Join(m.Labels, ", ") + ") "
+	}
+
+	switch m.Type {
+	case "counter":
+		return fmt.Sprintf("%s(rate(%s[5m]))", sum, m.Name)
+	case "histogram":
+		return fmt.Sprintf("histogram_quantile(0.95, sum by (%s) (rate(%s_bucket[5m])))", strings.Join(append([]string{"le"}, m.Labels...), ", "), m.Name)
+	case "summary":
+		return fmt.Sprintf("%s(rate(%s_sum[5m])) / %s(rate(%s_count[5m]))", sum, m.Name, sum, m.Name)
+	default:
+		return fmt.Sprintf("%s(%s)", sum, m.Name)
+	}
+}
+
+// Legend returns the Grafana legend format for a metric.
+func (m Metric) Legend() string {
+	if len(m.Labels) == 0 {
+		return m.Name
+	}
+	parts := make([]string, len(m.Labels))
+	for i, label := range m.Labels {
+		parts[i] = "{{" + label + "}}"
+	}
+	return strings.Join(parts, " ")
+}
+
+// overviewPanel is a hand-written panel combining several metrics.
+type overviewPanel struct {
+	Title   string
+	Expr    string
+	Unit    string
+	Metrics []string // Metrics the expression depends on
+}
+
+// overview is the first dashboard row.
+var overview = []overviewPanel{
+	{
+		Title:   "Cache Hit Rate",
+		Expr:    "sum(rate(esi_cache_hits_total[5m])) / (sum(rate(esi_cache_hits_total[5m])) + sum(rate(esi_cache_misses_total[5m])))",
+		Unit:    "percentunit",
+		Metrics: []string{"esi_cache_hits_total", "esi_cache_misses_total"},
+	},
+	{
+		Title:   "Error Budget",
+		Expr:    "esi_errors_remaining",
+		Unit:    "short",
+		Metrics: []string{"esi_errors_remaining"},
+	},
+	{
+		Title:   "Minutes Until Critical (forecast)",
+		Expr:    "esi_error_budget_minutes_to_critical",
+		Unit:    "m",
+		Metrics: []string{"esi_error_budget_minutes_to_critical"},
+	},
+	{
+		Title:   "Retry Exhaustion Rate",
+		Expr:    "sum by (error_class) (rate(esi_retry_exhausted_total[5m]))",
+		Unit:    "reqps",
+		Metrics: []string{"esi_retry_exhausted_total"},
+	},
+}
+
+// Dashboard renders the Grafana dashboard (import API envelope): an overview
+// row followed by one row per package with a panel per metric.
+func Dashboard(metrics []Metric) ([]byte, error) {
+	known := metricNames(metrics)
+
+	var panels []map[string]any
+	y := 0
+	addRow := func(title string) {
+		panels = append(panels, map[string]any{
+			"id":        len(panels) + 1,
+			"type":      "row",
+			"title":     title,
+			"collapsed": false,
+			"gridPos":   gridPos(0, y, 24, 1),
+		})
+		y++
+	}
+	col := 0
+	addPanel := func(title, description, expr, legend, unit string) {
+		panels = append(panels, map[string]any{
+			"id":          len(panels) + 1,
+			"type":        "timeseries",
+			"title":       title,
+			"description": description,
+			"datasource":  map[string]string{"type": "prometheus", "uid": "${datasource}"},
+			"targets":     []map[string]string{{"expr": expr, "legendFormat": legend, "refId": "A"}},
+			"fieldConfig": map[string]any{"defaults": map[string]string{"unit": unit}},
+			"gridPos":     gridPos(col*12, y, 12, 8),
+		})
+		if col++; col == 2 {
+			col = 0
+			y += 8
+		}
+	}
+	endRow := func() {
+		if col != 0 {
+			col = 0
+			y += 8
+		}
+	}
+
+	addRow("Overview")
+	for _, panel := range overview {
+		if err := requireMetrics(known, panel.Title, panel.Metrics); err != nil {
+			return nil, err
+		}
+		addPanel(panel.Title, "", panel.Expr, "", panel.Unit)
+	}
+	endRow()
+
+	pkg := ""
+	for _, m := range metrics {
+		if m.Package != pkg {
+			endRow()
+			pkg = m.Package
+			addRow("Package " + pkg)
+		}
+		addPanel(m.Name, m.Help, m.Query(), m.Legend(), metricUnit(m))
+	}
+
+	dashboard := map[string]any{
+		"dashboard": map[string]any{
+			"uid":           "eve-esi-client",
+			"title":         "EVE ESI Client Monitoring",
+			"tags":          []string{"eve-online", "esi", "monitoring"},
+			"timezone":      "browser",
+			"schemaVersion": 39,
+			"refresh":       "30s",
+			"time":          map[string]string{"from": "now-6h", "to": "now"},
+			"templating": map[string]any{
+				"list": []map[string]any{{
+					"name":  "datasource",
+					"type":  "datasource",
+					"query": "prometheus",
+				}},
+			},
+			"panels": panels,
+		},
+		"overwrite": true,
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(dashboard); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// metricUnit picks the Grafana unit of a metric panel.
+func metricUnit(m Metric) string {
+	switch {
+	case strings.HasSuffix(m.Name, "_seconds"):
+		return "s"
+	case strings.HasSuffix(m.Name, "_bytes"):
+		return "bytes"
+	case m.Type == "counter":
+		return "ops"
+	default:
+		return "short"
+	}
+}
+
+func gridPos(x, y, w, h int) map[string]int {
+	return map[string]int{"x": x, "y": y, "w": w, "h": h}
+}
+
+// alertRule is a Prometheus alerting rule.
+type alertRule struct {
+	Name        string
+	Expr        string
+	For         string
+	Severity    string
+	Summary     string
+	Description string
+	Metrics     []string // Metrics the expression depends on
+}
+
+// alertRules are the recommended alerts (see docs/monitoring.md).
+var alertRules = []alertRule{
+	{
+		Name:        "ESIErrorLimitCritical",
+		Expr:        "esi_errors_remaining < 5",
+		For:         "1m",
+		Severity:    "critical",
+		Summary:     "ESI error limit critical",
+		Description: "Only {{ $value }} errors remaining, requests are being blocked to prevent an IP ban",
+		Metrics:     []string{"esi_errors_remaining"},
+	},
+	{
+		Name:        "ESIErrorBudgetExhaustionForecast",
+		Expr:        "esi_error_budget_minutes_to_critical < 0.5",
+		For:         "10s",
+		Severity:    "critical",
+		Summary:     "ESI error limit will become critical within this window",
+		Description: "Critical threshold forecast in {{ $value | humanize }} minutes at the current error rate",
+		Metrics:     []string{"esi_error_budget_minutes_to_critical"},
+	},
+	{
+		Name:        "ESIRequestsBlocked",
+		Expr:        "increase(esi_rate_limit_blocks_total[5m]) > 0",
+		For:         "0m",
+		Severity:    "critical",
+		Summary:     "ESI requests blocked by the rate limiter",
+		Description: "{{ $value }} requests blocked in the last 5 minutes",
+		Metrics:     []string{"esi_rate_limit_blocks_total"},
+	},
+	{
+		Name:        "ESIErrorLimitWarning",
+		Expr:        "esi_errors_remaining < 20",
+		For:         "5m",
+		Severity:    "warning",
+		Summary:     "ESI error limit warning",
+		Description: "Only {{ $value }} errors remaining, requests are being throttled",
+		Metrics:     []string{"esi_errors_remaining"},
+	},
+	{
+		Name:        "ESILowCacheHitRate",
+		Expr:        "sum(rate(esi_cache_hits_total[10m])) / (sum(rate(esi_cache_hits_total[10m])) + sum(rate(esi_cache_misses_total[10m]))) < 0.4",
+		For:         "10m",
+		Severity:    "warning",
+		Summary:     "Low ESI cache hit rate",
+		Description: "Cache hit rate is {{ $value | humanizePercentage }}",
+		Metrics:     []string{"esi_cache_hits_total", "esi_cache_misses_total"},
+	},
+	{
+		Name:        "ESIRetryExhaustion",
+		Expr:        "sum(rate(esi_retry_exhausted_total[5m])) > 0.01",
+		For:         "5m",
+		Severity:    "warning",
+		Summary:     "ESI requests exhausting retries",
+		Description: "{{ $value | humanize }} requests/sec fail after all retries",
+		Metrics:     []string{"esi_retry_exhausted_total"},
+	},
+	{
+		Name:        "ESISLOFastBurn",
+		Expr:        `max by (route) (esi_slo_burn_rate{window="5m"}) > 6 and max by (route) (esi_slo_burn_rate{window="1h"}) > 6`,
+		For:         "2m",
+		Severity:    "critical",
+		Summary:     "ESI SLO error budget burning fast",
+		Description: "SLO for {{ $labels.route }} burns its error budget {{ $value | humanize }}x faster than sustainable",
+		Metrics:     []string{"esi_slo_burn_rate"},
+	},
+	{
+		Name:        "ESISLOSlowBurn",
+		Expr:        `max by (route) (esi_slo_burn_rate{window="1h"}) > 1`,
+		For:         "30m",
+		Severity:    "warning",
+		Summary:     "ESI SLO error budget burning",
+		Description: "SLO for {{ $labels.route }} burns its error budget {{ $value | humanize }}x faster than sustainable",
+		Metrics:     []string{"esi_slo_burn_rate"},
+	},
+	{
+		Name:        "ESICanaryMismatch",
+		Expr:        `sum by (endpoint) (increase(esi_canary_checks_total{hit="revalidated",result="mismatch"}[1h])) > 0`,
+		For:         "0m",
+		Severity:    "warning",
+		Summary:     "ESI cache served replaced data",
+		Description: "Canary found {{ $value | humanize }} cache hits of {{ $labels.endpoint }} confirmed by 304 that differ from the live response",
+		Metrics:     []string{"esi_canary_checks_total"},
+	},
+	{
+		Name:        "ESICloudflareChallenge",
+		Expr:        `increase(esi_challenge_responses_total[5m]) > 0`,
+		For:         "0m",
+		Severity:    "critical",
+		Summary:     "Cloudflare challenges instead of ESI responses",
+		Description: "{{ $value | humanize }} Cloudflare challenge or HTML responses in 5m, ESI requests are paused (see ChallengeBackoff)",
+		Metrics:     []string{"esi_challenge_responses_total"},
+	},
+	{
+		Name:        "ESIIncidentMode",
+		Expr:        `max(esi_incident_mode) > 0`,
+		For:         "0m",
+		Severity:    "warning",
+		Summary:     "ESI incident declared, client in incident mode",
+		Description: "The status feed declares an ESI incident; requests run with reduced concurrency, without retries and from stale cache",
+		Metrics:     []string{"esi_incident_mode"},
+	},
+	{
+		Name:        "ESIIncidentFeedDown",
+		Expr:        `sum(increase(esi_incident_polls_total{result="error"}[15m])) > 0 unless sum(increase(esi_incident_polls_total{result!="error"}[15m])) > 0`,
+		For:         "5m",
+		Severity:    "warning",
+		Summary:     "ESI status feed unreachable",
+		Description: "No status feed poll succeeded in 15m; incident mode cannot follow ESI incidents",
+		Metrics:     []string{"esi_incident_polls_total"},
+	},
+	{
+		Name:        "ESIStuckRequests",
+		Expr:        `sum by (phase) (increase(esi_stuck_requests_total[10m])) > 0`,
+		For:         "0m",
+		Severity:    "warning",
+		Summary:     "ESI requests hanging",
+		Description: "{{ $value | humanize }} requests ran longer than StuckRequestThreshold in phase {{ $labels.phase }}; see the \"Request still running\" logs",
+		Metrics:     []string{"esi_stuck_requests_total"},
+	},
+}
+
+// AlertRules renders the Prometheus rule file.
+func AlertRules(metrics []Metric) ([]byte, error) {
+	known := metricNames(metrics)
+
+	var buf bytes.Buffer
+	buf.WriteString("# Code generated by internal/tools/dashgen. DO NOT EDIT.\n")
+	buf.WriteString("groups:\n")
+	buf.WriteString("  - name: esi_client\n")
+	buf.WriteString("    rules:\n")
+	for _, rule := range alertRules {
+		if err := requireMetrics(known, rule.Name, rule.Metrics); err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&buf, "      - alert: %s\n", rule.Name)
+		fmt.Fprintf(&buf, "        expr: %s\n", rule.Expr)
+		fmt.Fprintf(&buf, "        for: %s\n", rule.For)
+		buf.WriteString("        labels:\n")
+		fmt.Fprintf(&buf, "          severity: %s\n", rule.Severity)
+		buf.WriteString("          component: esi-client\n")
+		buf.WriteString("        annotations:\n")
+		fmt.Fprintf(&buf, "          summary: %s\n", strconv.Quote(rule.Summary))
+		fmt.Fprintf(&buf, "          description: %s\n", strconv.Quote(rule.Description))
+	}
+	return buf.Bytes(), nil
+}
+
+func metricNames(metrics []Metric) map[string]bool {
+	names := make(map[string]bool, len(metrics))
+	for _, m := range metrics {
+		names[m.Name] = true
+	}
+	return names
+}
+
+// requireMetrics fails if a hand-written query references an unknown metric.
+func requireMetrics(known map[string]bool, owner string, metrics []string) error {
+	for _, name := range metrics {
+		if !known[name] {
+			return fmt.Errorf("%s references unknown metric %s", owner, name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const repoRoot = "../../.."
+
+// TestGeneratedFilesUpToDate fails when a metric was added or changed without
+// running go generate ./pkg/metrics.
+func TestGeneratedFilesUpToDate(t *testing.T) {
+	metrics, err := ParseMetrics(filepath.Join(repoRoot, "pkg"))
+	if err != nil {
+		t.Fatalf("ParseMetrics() error = %v", err)
+	}
+
+	dashboard, err := Dashboard(metrics)
+	if err != nil {
+		t.Fatalf("Dashboard() error = %v", err)
+	}
+	alerts, err := AlertRules(metrics)
+	if err != nil {
+		t.Fatalf("AlertRules() error = %v", err)
+	}
+
+	for path, want := range map[string][]byte{
+		"docs/monitoring/grafana-dashboard.json": dashboard,
+		"docs/monitoring/prometheus-alerts.yml":  alerts,
+	} {
+		got, err := os.ReadFile(filepath.Join(repoRoot, path))
+		if err != nil {
+			t.Fatalf("read %s: %v", path, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s is out of date, run go generate ./pkg/metrics", path)
+		}
+	}
+}
+
+func TestParseMetrics(t *testing.T) {
+	metrics, err := ParseMetrics(filepath.Join(repoRoot, "pkg"))
+	if err != nil {
+		t.Fatalf("ParseMetrics() error = %v", err)
+	}
+
+	found := false
+	for _, m := range metrics {
+		if m.Name == "esi_requests_total" {
+			found = true
+			if m.Type != "counter" || m.Package != "client" || len(m.Labels) != 2 {
+				t.Errorf("esi_requests_total = %+v", m)
+			}
+			if got, want := m.Query(), "sum by (endpoint, status) (rate(esi_requests_total[5m]))"; got != want {
+				t.Errorf("Query() = %s, want %s", got, want)
+			}
+		}
+	}
+	if !found {
+		t.Error("esi_requests_total not found")
+	}
+}
+
+func TestAlertRules_UnknownMetric(t *testing.T) {
+	if _, err := AlertRules([]Metric{{Name: "esi_errors_remaining"}}); err == nil {
+		t.Error("AlertRules() error = nil, want unknown metric error")
+	}
+}
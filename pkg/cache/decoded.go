@@ -0,0 +1,157 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// decodedLayer is an in-memory cache of decoded Go values, keyed by cache key
+// and target type. It sits in front of the Redis layer and is invalidated
+// whenever the byte-level entry for the same key is written or deleted.
+type decodedLayer struct {
+	mu         sync.Mutex
+	entries    map[string]map[reflect.Type]decodedValue
+	size       int
+	maxEntries int
+}
+
+type decodedValue struct {
+	value   any
+	expires time.Time
+}
+
+func newDecodedLayer(maxEntries int) *decodedLayer {
+	return &decodedLayer{
+		entries:    make(map[string]map[reflect.Type]decodedValue),
+		maxEntries: maxEntries,
+	}
+}
+
+func (l *decodedLayer) get(key string, typ reflect.Type) (any, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	v, ok := l.entries[key][typ]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(v.expires) {
+		l.remove(key, typ)
+		return nil, false
+	}
+	return v.value, true
+}
+
+func (l *decodedLayer) set(key string, typ reflect.Type, value any, expires time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	byType, ok := l.entries[key]
+	if !ok {
+		byType = make(map[reflect.Type]decodedValue)
+		l.entries[key] = byType
+	}
+	if _, exists := byType[typ]; !exists {
+		if l.size >= l.maxEntries {
+			l.evict()
+		}
+		l.size++
+	}
+	byType[typ] = decodedValue{value: value, expires: expires}
+}
+
+// invalidate drops all decoded values for key.
+func (l *decodedLayer) invalidate(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.size -= len(l.entries[key])
+	delete(l.entries, key)
+}
+
+// remove drops a single value. Caller must hold mu.
+func (l *decodedLayer) remove(key string, typ reflect.Type) {
+	byType := l.entries[key]
+	if _, ok := byType[typ]; !ok {
+		return
+	}
+	delete(byType, typ)
+	l.size--
+	if len(byType) == 0 {
+		delete(l.entries, key)
+	}
+}
+
+// evict frees one slot, preferring expired values. Caller must hold mu.
+func (l *decodedLayer) evict() {
+	now := time.Now()
+	for key, byType := range l.entries {
+		for typ, v := range byType {
+			if now.After(v.expires) {
+				l.remove(key, typ)
+				return
+			}
+		}
+	}
+	// No expired value - drop an arbitrary one (map order is random)
+	for key, byType := range l.entries {
+		for typ := range byType {
+			l.remove(key, typ)
+			return
+		}
+	}
+}
+
+// EnableDecodedCache turns on the in-memory layer for GetDecoded, holding at
+// most maxEntries decoded values. Must be called before the manager is used.
+func (m *Manager) EnableDecodedCache(maxEntries int) {
+	if maxEntries <= 0 {
+		m.decoded = nil
+		return
+	}
+	m.decoded = newDecodedLayer(maxEntries)
+}
+
+// invalidateDecoded drops decoded values for key after the byte-level entry changed.
+func (m *Manager) invalidateDecoded(key string) {
+	if m.decoded != nil {
+		m.decoded.invalidate(key)
+	}
+}
+
+// GetDecoded returns the cached entry for key decoded into T.
+//
+// With EnableDecodedCache the decoded value is kept in memory until the entry
+// expires, so hot lookups skip Redis and JSON decoding entirely. Values are
+// shared between callers and must be treated as read-only.
+// Returns ErrCacheMiss like Get.
+func GetDecoded[T any](ctx context.Context, m *Manager, key CacheKey) (T, error) {
+	var zero T
+	cacheKey := key.String()
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+
+	if m.decoded != nil {
+		if v, ok := m.decoded.get(cacheKey, typ); ok {
+			CacheHits.WithLabelValues("memory").Inc()
+			return v.(T), nil
+		}
+	}
+
+	entry, err := m.Get(ctx, key)
+	if err != nil {
+		return zero, err
+	}
+
+	var value T
+	if err := m.codec.Unmarshal(entry.Data, &value); err != nil {
+		return zero, fmt.Errorf("decode cached data: %w", err)
+	}
+
+	if m.decoded != nil {
+		m.decoded.set(cacheKey, typ, value, entry.Expires)
+	}
+	return value, nil
+}
@@ -0,0 +1,132 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type typeName struct {
+	TypeID int    `json:"type_id"`
+	Name   string `json:"name"`
+}
+
+func TestGetDecoded(t *testing.T) {
+	client := setupTestRedis(t)
+	manager := NewManager(client)
+	manager.EnableDecodedCache(10)
+	ctx := context.Background()
+
+	key := CacheKey{Endpoint: "/v3/universe/types/34/"}
+	entry := &CacheEntry{
+		Data:       []byte(`{"type_id":34,"name":"Tritanium"}`),
+		Expires:    time.Now().Add(time.Hour),
+		StatusCode: http.StatusOK,
+	}
+	if err := manager.Set(ctx, key, entry); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	got, err := GetDecoded[typeName](ctx, manager, key)
+	if err != nil {
+		t.Fatalf("GetDecoded() error = %v", err)
+	}
+	if got.Name != "Tritanium" {
+		t.Errorf("GetDecoded() = %+v", got)
+	}
+
+	// Second lookup is served from memory even if Redis lost the key
+	if err := client.Del(ctx, key.String()).Err(); err != nil {
+		t.Fatalf("redis del: %v", err)
+	}
+	if _, err := GetDecoded[typeName](ctx, manager, key); err != nil {
+		t.Errorf("GetDecoded() from memory error = %v", err)
+	}
+
+	// Writing the byte-level entry invalidates the decoded value
+	entry.Data = []byte(`{"type_id":34,"name":"Tritanium II"}`)
+	if err := manager.Set(ctx, key, entry); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if got, _ := GetDecoded[typeName](ctx, manager, key); got.Name != "Tritanium II" {
+		t.Errorf("GetDecoded() after Set = %+v, want updated value", got)
+	}
+
+	// Delete invalidates as well
+	if err := manager.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := GetDecoded[typeName](ctx, manager, key); err != ErrCacheMiss {
+		t.Errorf("GetDecoded() after Delete error = %v, want ErrCacheMiss", err)
+	}
+}
+
+func TestGetDecoded_Disabled(t *testing.T) {
+	client := setupTestRedis(t)
+	manager := NewManager(client)
+	ctx := context.Background()
+
+	key := CacheKey{Endpoint: "/v3/universe/types/34/"}
+	if _, err := GetDecoded[typeName](ctx, manager, key); err != ErrCacheMiss {
+		t.Errorf("GetDecoded() error = %v, want ErrCacheMiss", err)
+	}
+}
+
+func TestDecodedLayer(t *testing.T) {
+	typ := reflect.TypeOf(typeName{})
+	other := reflect.TypeOf(map[string]any{})
+
+	tests := []struct {
+		name    string
+		run     func(l *decodedLayer)
+		wantKey string
+		wantTyp reflect.Type
+		wantHit bool
+	}{
+		{
+			name:    "expired value is a miss",
+			run:     func(l *decodedLayer) { l.set("a", typ, 1, time.Now().Add(-time.Second)) },
+			wantKey: "a", wantTyp: typ, wantHit: false,
+		},
+		{
+			name: "types are cached separately",
+			run: func(l *decodedLayer) {
+				l.set("a", typ, 1, time.Now().Add(time.Hour))
+			},
+			wantKey: "a", wantTyp: other, wantHit: false,
+		},
+		{
+			name: "invalidate drops all types",
+			run: func(l *decodedLayer) {
+				l.set("a", typ, 1, time.Now().Add(time.Hour))
+				l.set("a", other, 2, time.Now().Add(time.Hour))
+				l.invalidate("a")
+			},
+			wantKey: "a", wantTyp: other, wantHit: false,
+		},
+		{
+			name: "full layer evicts expired values first",
+			run: func(l *decodedLayer) {
+				l.set("old", typ, 1, time.Now().Add(-time.Second))
+				l.set("keep", typ, 2, time.Now().Add(time.Hour))
+				l.set("new", typ, 3, time.Now().Add(time.Hour))
+			},
+			wantKey: "keep", wantTyp: typ, wantHit: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newDecodedLayer(2)
+			tt.run(l)
+			if _, ok := l.get(tt.wantKey, tt.wantTyp); ok != tt.wantHit {
+				t.Errorf("get(%q) hit = %v, want %v", tt.wantKey, ok, tt.wantHit)
+			}
+			if l.size > l.maxEntries {
+				t.Errorf("size = %d exceeds maxEntries %d", l.size, l.maxEntries)
+			}
+		})
+	}
+}
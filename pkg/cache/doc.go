@@ -52,15 +52,81 @@
 //		// Make request - ESI will return 304 if not modified
 //	}
 //
+// An entry whose body reappears under a new ETag, as after patch-day
+// rollbacks, keeps the earlier ETags as ETagAliases (see InheritETagAliases).
+// If-None-Match lists them all, and the ETag a 304 confirms becomes the ETag
+// of the entry again (see UpdateTTLWithETag).
+//
+// # Decoded Lookups
+//
+//	// Optional in-memory layer for decoded values (keyed by cache key + type)
+//	manager.EnableDecodedCache(10000)
+//
+//	// Hot lookups skip Redis and JSON decoding until the entry expires
+//	info, err := cache.GetDecoded[TypeInfo](ctx, manager, key)
+//
+// Decoded values are invalidated whenever the byte-level entry is set or deleted.
+//
+// # Validator Index
+//
+//	// Keep ETag and Last-Modified of read or written entries in memory
+//	manager.EnableValidatorIndex(50000)
+//
+//	if v, ok := manager.Validators(key); ok && v.Conditional() {
+//		cache.AddValidatorHeaders(req, v)
+//		// Read the entry only if ESI answers 304, and check v.Matches(entry)
+//	}
+//
+// Validators older than ValidatorReloadAge are reloaded from Redis in the
+// background, so the index follows entries written by other instances.
+//
+// # Split Storage
+//
+//	// Store bodies of 16 KiB and more apart from their metadata
+//	manager.EnableSplitStorage(16 << 10)
+//
+//	// Metadata only, the body stays in Redis
+//	meta, err := manager.GetMeta(ctx, key)
+//
+// Split bodies live under RedisKeyBodyPrefix and share the TTL of their
+// envelope; UpdateTTL extends both without transferring the body. A body
+// missing from Redis makes the entry a miss.
+//
+// # Read Replica
+//
+//	// Reads from the replica, writes to the primary
+//	manager.SetReadReplica(replicaClient, cache.ReplicaOptions{MaxLag: 2 * time.Second})
+//
+// Keys the manager wrote within MaxLag are read from the primary; with
+// MissFallback replica misses are retried there as well.
+//
 // # Metrics
 //
 // The cache manager exports Prometheus metrics:
 //
-//   - esi_cache_hits_total{layer="redis"} - Cache hits
+//   - esi_cache_hits_total{layer="redis"|"memory"} - Cache hits
 //   - esi_cache_misses_total - Cache misses
 //   - esi_cache_size_bytes{layer="redis"} - Cache size
+//   - esi_cache_entries{layer="redis"} - Live entries (with WatchExpirations)
+//   - esi_cache_expirations_total{endpoint} - Entries expired by Redis
+//   - esi_cache_tracked_bytes - Cache size tracked for the memory limit
+//   - esi_cache_evictions_total{policy} - Entries evicted by the memory limit
 //   - esi_304_responses_total - Conditional request successes
+//   - esi_etag_alias_confirmations_total - 304s confirming an ETag alias
 //   - esi_cache_errors_total{operation} - Cache operation errors
+//   - esi_cache_corruption_total - Corrupted entries detected and deleted
+//   - esi_cache_migrations_total{from_version} - Entries upgraded from an older format
+//   - esi_cache_key_migrations_total{migration,mode} - Entries moved from a legacy key format
+//
+// # Entry Versioning
+//
+// Serialized entries carry a format version (EntryVersion). Older entries are
+// migrated on read and written back, entries from a newer version are treated
+// as a miss, so format changes never require flushing Redis.
+//
+// Changes of the key format are registered in KeyMigrations. With
+// EnableLegacyKeys, a miss falls back to the legacy key and renames the entry;
+// MigrateKeys moves all legacy entries at once.
 //
 // # ESI Compliance
 //
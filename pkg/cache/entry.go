@@ -5,16 +5,30 @@ package cache
 import (
 	"net/http"
 	"time"
+
+	"github.com/cespare/xxhash/v2"
 )
 
 // CacheEntry represents a cached ESI response.
 type CacheEntry struct {
+	// Version is the envelope format version (see EntryVersion)
+	Version int `json:"version"`
+
 	// Data is the response body
 	Data []byte `json:"data"`
 
+	// BodySize is the size of the body stored under a separate key (see
+	// EnableSplitStorage); Data is then empty in the stored envelope.
+	// Zero for entries stored whole.
+	BodySize int `json:"body_size,omitempty"`
+
 	// ETag for conditional requests (If-None-Match)
 	ETag string `json:"etag"`
 
+	// ETagAliases are earlier ETags ESI served for the same body, newest
+	// first, sent along in If-None-Match (see InheritETagAliases)
+	ETagAliases []string `json:"etag_aliases,omitempty"`
+
 	// Expires is when the cache entry becomes stale (from ESI expires header)
 	Expires time.Time `json:"expires"`
 
@@ -29,6 +43,22 @@ type CacheEntry struct {
 
 	// CachedAt is when we cached this response
 	CachedAt time.Time `json:"cached_at"`
+
+	// ValidatedAt is when ESI last confirmed the data with 304 Not Modified
+	// (zero if never revalidated)
+	ValidatedAt time.Time `json:"validated_at,omitempty"`
+
+	// ChangedAt is when the data was first seen with its current ETag.
+	// Only tracked for adaptive TTLs (zero otherwise).
+	ChangedAt time.Time `json:"changed_at,omitempty"`
+
+	// Checksum is the xxhash of Data, set on write and verified on read.
+	// Zero for entries written before checksums were introduced.
+	Checksum uint64 `json:"checksum,omitempty"`
+
+	// Preloaded marks entries imported from a dump (see Preload). Expires is
+	// synthetic and the data may be outdated until ESI confirms it.
+	Preloaded bool `json:"preloaded,omitempty"`
 }
 
 // IsExpired returns true if the cache entry has expired.
@@ -36,6 +66,20 @@ func (e *CacheEntry) IsExpired() bool {
 	return time.Now().After(e.Expires)
 }
 
+// IsStale reports whether the entry may only be served as stale data:
+// it has expired or was preloaded and not yet confirmed by ESI.
+func (e *CacheEntry) IsStale() bool {
+	return e.Preloaded || e.IsExpired()
+}
+
+// RefreshedAt returns when the data was last fetched or confirmed by ESI.
+func (e *CacheEntry) RefreshedAt() time.Time {
+	if e.ValidatedAt.After(e.CachedAt) {
+		return e.ValidatedAt
+	}
+	return e.CachedAt
+}
+
 // TTL returns the time until expiration.
 // Returns 0 if already expired.
 func (e *CacheEntry) TTL() time.Duration {
@@ -45,3 +89,14 @@ func (e *CacheEntry) TTL() time.Duration {
 	}
 	return ttl
 }
+
+// computeChecksum returns the xxhash of the entry data.
+func (e *CacheEntry) computeChecksum() uint64 {
+	return xxhash.Sum64(e.Data)
+}
+
+// Verify reports whether Data matches the stored checksum.
+// Entries without a checksum are accepted.
+func (e *CacheEntry) Verify() bool {
+	return e.Checksum == 0 || e.Checksum == e.computeChecksum()
+}
@@ -79,3 +79,27 @@ func TestCacheEntry_TTL(t *testing.T) {
 		})
 	}
 }
+
+func TestCacheEntry_Verify(t *testing.T) {
+	data := []byte(`{"type_id":34}`)
+	valid := &CacheEntry{Data: data}
+	valid.Checksum = valid.computeChecksum()
+
+	tests := []struct {
+		name  string
+		entry *CacheEntry
+		want  bool
+	}{
+		{"matching checksum", valid, true},
+		{"no checksum (legacy entry)", &CacheEntry{Data: data}, true},
+		{"tampered data", &CacheEntry{Data: []byte(`{"type_id":35}`), Checksum: valid.Checksum}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.entry.Verify(); got != tt.want {
+				t.Errorf("Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+)
+
+// MaxETagAliases bounds CacheEntry.ETagAliases.
+const MaxETagAliases = 4
+
+// ETags returns the ETag of the entry followed by its aliases, nil without
+// ETag.
+func (e *CacheEntry) ETags() []string {
+	if e.ETag == "" {
+		return nil
+	}
+	return append([]string{e.ETag}, e.ETagAliases...)
+}
+
+// WeakETagMatch reports whether two ETags match under weak comparison (RFC
+// 9110 8.8.3.2): their opaque tags are equal, whether or not either carries
+// the W/ prefix. Some ESI routes, and CDNs compressing responses, mark the
+// validator of the same representation weak on one response and not on the
+// next.
+func WeakETagMatch(a, b string) bool {
+	return a != "" && b != "" && strings.TrimPrefix(a, "W/") == strings.TrimPrefix(b, "W/")
+}
+
+// InheritETagAliases records the ETag of prev, and its aliases, as aliases
+// of entry if both carry the same body, leaving out those weakly matching
+// the ETag of entry. ESI serves unchanged data under a new ETag after
+// patch-day rollbacks and while its backends disagree; with the aliases sent
+// in If-None-Match, a 304 for any of them still confirms the entry. prev may
+// be nil; entries without checksum are compared by body.
+func InheritETagAliases(entry, prev *CacheEntry) {
+	if prev == nil || prev.ETag == "" || entry.ETag == "" {
+		return
+	}
+	switch {
+	case prev.Checksum != 0:
+		if prev.Checksum != entry.computeChecksum() {
+			return
+		}
+	case prev.Data == nil || !bytes.Equal(prev.Data, entry.Data):
+		return
+	}
+
+	aliases := make([]string, 0, MaxETagAliases)
+	for _, etag := range prev.ETags() {
+		known := slices.ContainsFunc(aliases, func(alias string) bool { return WeakETagMatch(alias, etag) })
+		if !known && !WeakETagMatch(etag, entry.ETag) && len(aliases) < MaxETagAliases {
+			aliases = append(aliases, etag)
+		}
+	}
+	if len(aliases) > 0 {
+		entry.ETagAliases = aliases
+	}
+}
+
+// PromoteETag makes etag, an alias confirmed by a 304, the ETag of the
+// entry, keeping the former ETag as newest alias, and updates the ETag
+// header replayed on hits. It reports false if etag does not weakly match an
+// alias.
+func (e *CacheEntry) PromoteETag(etag string) bool {
+	i := slices.IndexFunc(e.ETagAliases, func(alias string) bool { return WeakETagMatch(alias, etag) })
+	if i < 0 {
+		return false
+	}
+
+	aliases := append([]string{e.ETag}, slices.Delete(slices.Clone(e.ETagAliases), i, i+1)...)
+	e.ETag = etag
+	e.ETagAliases = aliases
+	if e.Headers != nil && e.Headers.Get("ETag") != "" {
+		e.Headers = e.Headers.Clone()
+		e.Headers.Set("ETag", etag)
+	}
+	return true
+}
+
+// ifNoneMatch renders etags as an If-None-Match list.
+func ifNoneMatch(etags []string) string {
+	return strings.Join(etags, ", ")
+}
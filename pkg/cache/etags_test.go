@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestWeakETagMatch(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{`"a"`, `"a"`, true},
+		{`W/"a"`, `"a"`, true},
+		{`"a"`, `W/"a"`, true},
+		{`W/"a"`, `W/"a"`, true},
+		{`W/"a"`, `W/"b"`, false},
+		{"", "", false},
+		{`"a"`, "", false},
+	}
+
+	for _, tt := range tests {
+		if got := WeakETagMatch(tt.a, tt.b); got != tt.want {
+			t.Errorf("WeakETagMatch(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestInheritETagAliases(t *testing.T) {
+	body := []byte(`{"players":21500}`)
+	prev := &CacheEntry{ETag: `"b"`, ETagAliases: []string{`"a"`, `"c"`}, Data: body}
+	prev.Checksum = prev.computeChecksum()
+
+	entry := &CacheEntry{ETag: `"c"`, Data: body}
+	InheritETagAliases(entry, prev)
+	if want := []string{`"b"`, `"a"`}; !slices.Equal(entry.ETagAliases, want) {
+		t.Errorf("ETagAliases = %q, want %q", entry.ETagAliases, want)
+	}
+
+	changed := &CacheEntry{ETag: `"d"`, Data: []byte(`{"players":21501}`)}
+	InheritETagAliases(changed, prev)
+	if changed.ETagAliases != nil {
+		t.Errorf("ETagAliases = %q for a changed body", changed.ETagAliases)
+	}
+
+	// Without checksum the bodies are compared; the aliases are capped
+	many := &CacheEntry{ETag: `"0"`, ETagAliases: []string{`"1"`, `"2"`, `"3"`, `"4"`}, Data: body}
+	entry = &CacheEntry{ETag: `"5"`, Data: body}
+	InheritETagAliases(entry, many)
+	if want := []string{`"0"`, `"1"`, `"2"`, `"3"`}; !slices.Equal(entry.ETagAliases, want) {
+		t.Errorf("ETagAliases = %q, want %q", entry.ETagAliases, want)
+	}
+
+	// The same ETag marked weak is no alias
+	weak := &CacheEntry{ETag: `W/"b"`, Data: body}
+	InheritETagAliases(weak, prev)
+	if want := []string{`"a"`, `"c"`}; !slices.Equal(weak.ETagAliases, want) {
+		t.Errorf("ETagAliases = %q, want %q", weak.ETagAliases, want)
+	}
+
+	InheritETagAliases(entry, nil) // No-op
+}
+
+func TestPromoteETag(t *testing.T) {
+	headers := http.Header{"Etag": {`"b"`}}
+	entry := &CacheEntry{ETag: `"b"`, ETagAliases: []string{`"a"`, `"c"`}, Headers: headers}
+
+	if entry.PromoteETag(`W/"d"`) {
+		t.Error("PromoteETag() = true for an unknown ETag")
+	}
+	if !entry.PromoteETag(`"c"`) {
+		t.Fatal("PromoteETag() = false for an alias")
+	}
+	if entry.ETag != `"c"` || !slices.Equal(entry.ETagAliases, []string{`"b"`, `"a"`}) {
+		t.Errorf("after PromoteETag() ETag = %q, aliases = %q", entry.ETag, entry.ETagAliases)
+	}
+	if got := entry.Headers.Get("ETag"); got != `"c"` {
+		t.Errorf("ETag header = %q, want %q", got, `"c"`)
+	}
+	if got := headers.Get("ETag"); got != `"b"` {
+		t.Errorf("PromoteETag() modified the shared headers: %q", got)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/status/", nil)
+	AddValidatorHeaders(req, ValidatorsOf(entry))
+	if got, want := req.Header.Get("If-None-Match"), `"c", "b", "a"`; got != want {
+		t.Errorf("If-None-Match = %q, want %q", got, want)
+	}
+}
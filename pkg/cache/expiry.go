@@ -0,0 +1,177 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// expiryTracker remembers size and endpoint of the entries this process
+// wrote, so Redis expiry notifications (which only carry the key) can be
+// turned into accurate size, entry and per-endpoint expiry metrics.
+// Only entries written by this process are tracked; summing the metrics of
+// all instances yields the totals for the shared Redis.
+type expiryTracker struct {
+	enabled atomic.Bool
+
+	mu      sync.Mutex
+	entries map[string]trackedEntry
+}
+
+type trackedEntry struct {
+	endpoint string
+	size     int
+}
+
+func newExpiryTracker() *expiryTracker {
+	return &expiryTracker{entries: make(map[string]trackedEntry)}
+}
+
+// track records a written entry. Returns the size of the entry it replaced
+// and whether there was one.
+func (t *expiryTracker) track(key, endpoint string, size int) (previous int, replaced bool) {
+	if !t.enabled.Load() {
+		return 0, false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	old, replaced := t.entries[key]
+	t.entries[key] = trackedEntry{endpoint: endpoint, size: size}
+	return old.size, replaced
+}
+
+// untrack forgets a deleted or expired entry.
+func (t *expiryTracker) untrack(key string) (trackedEntry, bool) {
+	if !t.enabled.Load() {
+		return trackedEntry{}, false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, ok := t.entries[key]
+	if ok {
+		delete(t.entries, key)
+	}
+	return entry, ok
+}
+
+// recordWrite updates size and entry metrics for a written entry.
+func (m *Manager) recordWrite(cacheKey, endpoint string, size int) {
+	if !m.expiry.enabled.Load() {
+		CacheSize.WithLabelValues("redis").Add(float64(size))
+		return
+	}
+
+	previous, replaced := m.expiry.track(cacheKey, endpoint, size)
+	if replaced {
+		CacheSize.WithLabelValues("redis").Sub(float64(previous))
+	} else {
+		CacheEntries.WithLabelValues("redis").Inc()
+	}
+	CacheSize.WithLabelValues("redis").Add(float64(size))
+}
+
+// recordRemoval updates size and entry metrics for a removed entry.
+// Reports whether the entry was tracked.
+func (m *Manager) recordRemoval(cacheKey string, expired bool) bool {
+	entry, ok := m.expiry.untrack(cacheKey)
+	if !ok {
+		return false
+	}
+
+	CacheSize.WithLabelValues("redis").Sub(float64(entry.size))
+	CacheEntries.WithLabelValues("redis").Dec()
+	if expired {
+		CacheExpirations.WithLabelValues(entry.endpoint).Inc()
+	}
+	return true
+}
+
+// WatchExpirations subscribes to Redis keyspace expiry notifications and
+// keeps esi_cache_size_bytes / esi_cache_entries accurate as entries expire,
+// counting expirations per endpoint in esi_cache_expirations_total.
+// It blocks until ctx is cancelled.
+//
+// Expired events must be enabled in Redis (notify-keyspace-events containing
+// "E" and "x"). WatchExpirations tries to enable them via CONFIG SET; on
+// managed Redis where CONFIG is disabled they must be configured upfront.
+func (m *Manager) WatchExpirations(ctx context.Context) error {
+	m.enableExpiredEvents(ctx)
+
+	channel := fmt.Sprintf("__keyevent@%d__:expired", m.redis.Options().DB)
+	pubsub := m.redis.Subscribe(ctx, channel)
+	defer pubsub.Close()
+
+	// Wait for the subscription to be confirmed
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return fmt.Errorf("subscribe %s: %w", channel, err)
+	}
+
+	m.expiry.enabled.Store(true)
+	defer m.expiry.enabled.Store(false)
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-messages:
+			if !ok {
+				return fmt.Errorf("subscription %s closed", channel)
+			}
+			if strings.HasPrefix(msg.Payload, "esi:") {
+				m.recordRemoval(msg.Payload, true)
+			}
+		}
+	}
+}
+
+// enableExpiredEvents adds the flags for expired key events to the Redis
+// notification config, keeping any flags already set. Errors are ignored
+// because CONFIG is often disabled on managed Redis.
+func (m *Manager) enableExpiredEvents(ctx context.Context) {
+	current, err := m.redis.ConfigGet(ctx, "notify-keyspace-events").Result()
+	if err != nil {
+		return
+	}
+
+	flags := current["notify-keyspace-events"]
+	updated := flags
+	if !strings.Contains(updated, "E") {
+		updated += "E"
+	}
+	// "A" is an alias for all event classes including expired
+	if !strings.Contains(updated, "x") && !strings.Contains(updated, "A") {
+		updated += "x"
+	}
+	if updated != flags {
+		_ = m.redis.ConfigSet(ctx, "notify-keyspace-events", updated).Err()
+	}
+}
+
+// endpointFromKey extracts the endpoint pattern from a cache key string
+// ("esi:<endpoint>:<params>...").
+func endpointFromKey(cacheKey string) string {
+	parts := strings.SplitN(cacheKey, ":", 3)
+	if len(parts) < 2 {
+		return "/"
+	}
+	return EndpointPattern(parts[1])
+}
+
+// EndpointPattern replaces numeric path segments with {id} to keep the
+// cardinality of per-endpoint metrics bounded.
+func EndpointPattern(endpoint string) string {
+	segments := strings.Split(strings.Trim(endpoint, "/"), "/")
+	for i, segment := range segments {
+		if segment != "" && strings.Trim(segment, "0123456789") == "" {
+			segments[i] = "{id}"
+		}
+	}
+	return "/" + strings.Join(segments, "/") + "/"
+}
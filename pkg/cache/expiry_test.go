@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// gaugeValue returns the current value of a Prometheus gauge.
+func gaugeValue(t *testing.T, g prometheus.Gauge) float64 {
+	t.Helper()
+	var m dto.Metric
+	if err := g.Write(&m); err != nil {
+		t.Fatalf("read gauge: %v", err)
+	}
+	return m.GetGauge().GetValue()
+}
+
+func TestEndpointPattern(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		want     string
+	}{
+		{"/v1/markets/10000002/orders/", "/v1/markets/{id}/orders/"},
+		{"v3/universe/types/34", "/v3/universe/types/{id}/"},
+		{"/v2/status/", "/v2/status/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.endpoint, func(t *testing.T) {
+			if got := EndpointPattern(tt.endpoint); got != tt.want {
+				t.Errorf("EndpointPattern(%q) = %q, want %q", tt.endpoint, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEndpointFromKey(t *testing.T) {
+	key := CacheKey{Endpoint: "/v1/markets/10000002/orders/", QueryParams: map[string][]string{"page": {"2"}}}
+	if got := endpointFromKey(key.String()); got != "/v1/markets/{id}/orders/" {
+		t.Errorf("endpointFromKey() = %q", got)
+	}
+}
+
+func TestManager_ExpiryTracking(t *testing.T) {
+	client := setupTestRedis(t)
+	manager := NewManager(client)
+	manager.expiry.enabled.Store(true)
+	ctx := context.Background()
+
+	size := CacheSize.WithLabelValues("redis")
+	entries := CacheEntries.WithLabelValues("redis")
+	expirations := CacheExpirations.WithLabelValues("/v3/universe/types/{id}/")
+	sizeBefore, entriesBefore := gaugeValue(t, size), gaugeValue(t, entries)
+	expiredBefore := counterValue(t, expirations)
+
+	keys := []CacheKey{
+		{Endpoint: "/v3/universe/types/34/"},
+		{Endpoint: "/v3/universe/types/35/"},
+	}
+	for _, key := range keys {
+		entry := &CacheEntry{Data: []byte(`{}`), Expires: time.Now().Add(time.Hour), StatusCode: http.StatusOK}
+		if err := manager.Set(ctx, key, entry); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+	}
+	// Overwriting an entry must not count it twice
+	entry := &CacheEntry{Data: []byte(`{}`), Expires: time.Now().Add(time.Hour), StatusCode: http.StatusOK}
+	if err := manager.Set(ctx, keys[0], entry); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	if got := gaugeValue(t, entries) - entriesBefore; got != 2 {
+		t.Errorf("entries after Set = %v, want 2", got)
+	}
+	if gaugeValue(t, size) <= sizeBefore {
+		t.Error("size should grow after Set")
+	}
+
+	// One entry deleted, one expired by Redis
+	if err := manager.Delete(ctx, keys[0]); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if !manager.recordRemoval(keys[1].String(), true) {
+		t.Error("recordRemoval() should find the tracked entry")
+	}
+	if manager.recordRemoval("esi:unknown", true) {
+		t.Error("recordRemoval() should ignore untracked keys")
+	}
+
+	if got := gaugeValue(t, entries) - entriesBefore; got != 0 {
+		t.Errorf("entries after removal = %v, want 0", got)
+	}
+	if got := gaugeValue(t, size) - sizeBefore; got != 0 {
+		t.Errorf("size after removal = %v, want 0", got)
+	}
+	if got := counterValue(t, expirations) - expiredBefore; got != 1 {
+		t.Errorf("expirations = %v, want 1", got)
+	}
+}
+
+func TestManager_WatchExpirations_StopsOnCancel(t *testing.T) {
+	client := setupTestRedis(t)
+	manager := NewManager(client)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- manager.WatchExpirations(ctx) }()
+
+	// Tracking becomes active once the subscription is confirmed
+	deadline := time.Now().Add(2 * time.Second)
+	for !manager.expiry.enabled.Load() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !manager.expiry.enabled.Load() {
+		t.Fatal("expiry tracking not enabled after subscribing")
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("WatchExpirations() error = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("WatchExpirations() did not return after cancel")
+	}
+	if manager.expiry.enabled.Load() {
+		t.Error("expiry tracking should be disabled after the watcher stopped")
+	}
+}
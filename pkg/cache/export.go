@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Export streams live cache entries whose endpoint starts with prefix (e.g.
+// "/v1/markets/", empty for all) to w as JSON lines of DumpRecord, so
+// analytics jobs can consume ESI data without issuing their own requests.
+// The output can be loaded again with Preload. Expired, unconfirmed
+// preloaded and corrupted entries are skipped, as are bodies that are not
+// JSON. Keys keep their scope (char=, tenant=), so private entries can be
+// told apart. Returns the number of exported entries.
+func (m *Manager) Export(ctx context.Context, prefix string, w io.Writer) (int, error) {
+	enc := json.NewEncoder(w)
+	exported := 0
+
+	iter := m.redis.Scan(ctx, 0, "esi:"+strings.TrimLeft(prefix, "/")+"*", manifestScanCount).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		if !isCacheKey(key) {
+			continue
+		}
+
+		data, err := m.redis.Get(ctx, key).Bytes()
+		if err == redis.Nil {
+			continue // expired meanwhile
+		}
+		var replyErr redis.Error
+		if errors.As(err, &replyErr) {
+			continue // not a string
+		}
+		if err != nil {
+			return exported, fmt.Errorf("redis get %s: %w", key, err)
+		}
+
+		var entry CacheEntry
+		if err := m.codec.Unmarshal(data, &entry); err != nil || entry.Expires.IsZero() || entry.Version > EntryVersion {
+			continue
+		}
+		if entry.BodySize > 0 && !entry.IsStale() {
+			if err := m.loadBody(ctx, m.redis, key, &entry); errors.Is(err, ErrCacheMiss) || errors.Is(err, ErrInvalidEntry) {
+				continue
+			} else if err != nil {
+				return exported, err
+			}
+		}
+		if _, err := migrateEntry(&entry); err != nil || !entry.Verify() || entry.IsStale() || !json.Valid(entry.Data) {
+			continue
+		}
+
+		record := DumpRecord{
+			Key:     key,
+			Headers: m.headers.Apply(entry.Headers),
+			Body:    entry.Data,
+			Expires: entry.Expires,
+		}
+		if err := enc.Encode(record); err != nil {
+			return exported, fmt.Errorf("write dump record: %w", err)
+		}
+		exported++
+	}
+	if err := iter.Err(); err != nil {
+		return exported, fmt.Errorf("redis scan: %w", err)
+	}
+
+	return exported, nil
+}
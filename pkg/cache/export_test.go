@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestManager_Export(t *testing.T) {
+	client := setupTestRedis(t)
+	manager := NewManager(client)
+	ctx := context.Background()
+
+	set := func(endpoint, body string) CacheKey {
+		t.Helper()
+		key := CacheKey{Endpoint: endpoint}
+		entry := &CacheEntry{
+			Data:       []byte(body),
+			ETag:       `"v1"`,
+			Expires:    time.Now().Add(time.Hour),
+			StatusCode: http.StatusOK,
+			Headers:    http.Header{"Etag": {`"v1"`}, "X-Pages": {"2"}},
+		}
+		if err := manager.Set(ctx, key, entry); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+		return key
+	}
+	orders := set("/v1/markets/10000002/orders/", `[{"order_id":1}]`)
+	set("/v1/markets/prices/", `[{"type_id":34}]`)
+	set("/v3/universe/types/34/", `{"type_id":34}`)
+	set("/v1/markets/10000043/orders/", `not json`)
+	if err := client.Set(ctx, "esi:rate_limit:errors_remaining", 100, 0).Err(); err != nil {
+		t.Fatalf("redis set: %v", err)
+	}
+
+	var buf bytes.Buffer
+	exported, err := manager.Export(ctx, "/v1/markets/", &buf)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if exported != 2 {
+		t.Errorf("Export() = %d, want 2 (other prefixes and non-JSON bodies skipped)", exported)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != exported {
+		t.Fatalf("got %d lines, want %d", len(lines), exported)
+	}
+	records := make(map[string]DumpRecord)
+	for _, line := range lines {
+		var record DumpRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid line %q: %v", line, err)
+		}
+		records[record.Key] = record
+	}
+	record, ok := records[orders.String()]
+	if !ok {
+		t.Fatalf("records = %v, want %s", records, orders)
+	}
+	if string(record.Body) != `[{"order_id":1}]` || record.Headers.Get("X-Pages") != "2" || record.Expires.IsZero() {
+		t.Errorf("record = %+v", record)
+	}
+
+	// The dump round-trips through Preload
+	if err := client.FlushDB(ctx).Err(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	result, err := manager.Preload(ctx, &buf, PreloadOptions{})
+	if err != nil || result.Loaded != exported {
+		t.Errorf("Preload() = %+v, %v; want %d loaded", result, err, exported)
+	}
+
+	// Unconfirmed preloaded entries are not exported again
+	var again bytes.Buffer
+	if n, err := manager.Export(ctx, "", &again); err != nil || n != 0 {
+		t.Errorf("Export() after Preload = %d, %v; want 0", n, err)
+	}
+}
@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"net/http"
+	"net/textproto"
+)
+
+// DefaultCachedHeaders are the response headers kept in cache entries when no
+// allowlist is configured. Hop-by-hop and per-response headers (Set-Cookie,
+// CF-Ray, Connection, ...) would otherwise be replayed to every downstream.
+var DefaultCachedHeaders = []string{"Content-Type", "ETag", "Expires", "Last-Modified", "X-Pages"}
+
+// AllHeaders as the only Allow entry keeps all headers except Deny.
+const AllHeaders = "*"
+
+// HeaderFilter selects the response headers stored in cache entries and
+// replayed from them. The zero value keeps DefaultCachedHeaders.
+type HeaderFilter struct {
+	// Allow lists the headers to keep (default: DefaultCachedHeaders,
+	// AllHeaders = all)
+	Allow []string
+
+	// Deny lists headers to drop even if allowed
+	Deny []string
+}
+
+// SetHeaderFilter sets the filter applied to entry headers on Set and on
+// read, so entries written before the filter changed are not replayed
+// verbatim either. Must be called before the manager is used.
+func (m *Manager) SetHeaderFilter(f HeaderFilter) {
+	m.headers = f
+}
+
+// Apply returns the headers of h passing the filter. h is not modified.
+func (f HeaderFilter) Apply(h http.Header) http.Header {
+	if h == nil {
+		return nil
+	}
+
+	allow := f.Allow
+	if len(allow) == 0 {
+		allow = DefaultCachedHeaders
+	}
+
+	var filtered http.Header
+	if len(allow) == 1 && allow[0] == AllHeaders {
+		filtered = h.Clone()
+	} else {
+		filtered = make(http.Header, len(allow))
+		for _, name := range allow {
+			name = textproto.CanonicalMIMEHeaderKey(name)
+			if values, ok := h[name]; ok {
+				filtered[name] = append([]string(nil), values...)
+			}
+		}
+	}
+
+	for _, name := range f.Deny {
+		filtered.Del(name)
+	}
+	return filtered
+}
@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func upstreamHeaders() http.Header {
+	return http.Header{
+		"Content-Type":  {"application/json; charset=UTF-8"},
+		"Etag":          {`"abc"`},
+		"Expires":       {"Mon, 01 Jan 2024 12:05:00 GMT"},
+		"Last-Modified": {"Mon, 01 Jan 2024 12:00:00 GMT"},
+		"X-Pages":       {"3"},
+		"Set-Cookie":    {"session=secret"},
+		"Cf-Ray":        {"8a1b2c3d4e5f-FRA"},
+		"Connection":    {"keep-alive"},
+	}
+}
+
+func TestHeaderFilter_Apply(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter HeaderFilter
+		want   []string
+	}{
+		{
+			name:   "default allowlist",
+			filter: HeaderFilter{},
+			want:   []string{"Content-Type", "Etag", "Expires", "Last-Modified", "X-Pages"},
+		},
+		{
+			name:   "custom allowlist is canonicalized",
+			filter: HeaderFilter{Allow: []string{"etag", "x-pages", "x-missing"}},
+			want:   []string{"Etag", "X-Pages"},
+		},
+		{
+			name:   "all headers with denylist",
+			filter: HeaderFilter{Allow: []string{AllHeaders}, Deny: []string{"set-cookie", "Connection"}},
+			want:   []string{"Content-Type", "Etag", "Expires", "Last-Modified", "X-Pages", "Cf-Ray"},
+		},
+		{
+			name:   "denylist overrides allowlist",
+			filter: HeaderFilter{Deny: []string{"X-Pages"}},
+			want:   []string{"Content-Type", "Etag", "Expires", "Last-Modified"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := upstreamHeaders()
+			got := tt.filter.Apply(h)
+
+			if len(got) != len(tt.want) {
+				t.Errorf("Apply() kept %v, want %v", got, tt.want)
+			}
+			for _, name := range tt.want {
+				if !reflect.DeepEqual(got.Values(name), h.Values(name)) {
+					t.Errorf("Apply()[%s] = %v, want %v", name, got.Values(name), h.Values(name))
+				}
+			}
+			if !reflect.DeepEqual(h, upstreamHeaders()) {
+				t.Error("Apply() modified its input")
+			}
+		})
+	}
+
+	if got := (HeaderFilter{}).Apply(nil); got != nil {
+		t.Errorf("Apply(nil) = %v, want nil", got)
+	}
+}
+
+func TestManager_HeaderFilter(t *testing.T) {
+	client := setupTestRedis(t)
+	manager := NewManager(client)
+	ctx := context.Background()
+
+	key := CacheKey{Endpoint: "/v1/headers/"}
+	if err := manager.Set(ctx, key, &CacheEntry{
+		Data:       []byte(`[]`),
+		Expires:    time.Now().Add(time.Minute),
+		StatusCode: 200,
+		Headers:    upstreamHeaders(),
+	}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	// Stored without the per-response headers
+	var stored CacheEntry
+	if err := json.Unmarshal([]byte(client.Get(ctx, key.String()).Val()), &stored); err != nil {
+		t.Fatalf("decode stored entry: %v", err)
+	}
+	if stored.Headers.Get("Set-Cookie") != "" || stored.Headers.Get("X-Pages") != "3" {
+		t.Errorf("stored headers = %v, want default allowlist only", stored.Headers)
+	}
+
+	// Entries written before the filter was tightened are filtered on read
+	manager.SetHeaderFilter(HeaderFilter{Deny: []string{"X-Pages"}})
+	entry, err := manager.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if entry.Headers.Get("X-Pages") != "" || entry.Headers.Get("ETag") != `"abc"` {
+		t.Errorf("Get() headers = %v, want X-Pages denied", entry.Headers)
+	}
+}
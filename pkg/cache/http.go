@@ -10,6 +10,7 @@ import (
 
 const (
 	// DefaultTTL is the fallback TTL when no expires header is present
+	// (see Manager.SetFallbackTTL to change it)
 	DefaultTTL = 5 * time.Minute
 )
 
@@ -17,6 +18,13 @@ const (
 // It parses expires and last-modified headers and reads the response body.
 // The response body is restored after reading.
 func ResponseToEntry(resp *http.Response) (*CacheEntry, error) {
+	return ResponseToEntryWithTTL(resp, DefaultTTL)
+}
+
+// ResponseToEntryWithTTL converts an HTTP response like ResponseToEntry, but
+// expires entries without (valid) expires header after fallback instead of
+// DefaultTTL.
+func ResponseToEntryWithTTL(resp *http.Response, fallback time.Duration) (*CacheEntry, error) {
 	if resp == nil {
 		return nil, fmt.Errorf("response cannot be nil")
 	}
@@ -40,7 +48,7 @@ func ResponseToEntry(resp *http.Response) (*CacheEntry, error) {
 	}
 
 	// Parse Expires header (MUST respect per ESI documentation)
-	entry.Expires = parseExpires(resp.Header)
+	entry.Expires = parseExpires(resp.Header, fallback)
 
 	// Parse Last-Modified header
 	if lastModStr := resp.Header.Get("Last-Modified"); lastModStr != "" {
@@ -53,18 +61,18 @@ func ResponseToEntry(resp *http.Response) (*CacheEntry, error) {
 }
 
 // parseExpires parses the Expires header from HTTP headers.
-// Returns the parsed expiration time, or current time + DefaultTTL if parsing fails.
-func parseExpires(headers http.Header) time.Time {
+// Returns the parsed expiration time, or current time + fallback if parsing fails.
+func parseExpires(headers http.Header, fallback time.Duration) time.Time {
 	expiresStr := headers.Get("Expires")
 	if expiresStr == "" {
-		// No expires header - use default TTL
-		return time.Now().Add(DefaultTTL)
+		// No expires header - use fallback TTL
+		return time.Now().Add(fallback)
 	}
 
 	expires, err := http.ParseTime(expiresStr)
 	if err != nil {
-		// Failed to parse expires header - use default TTL
-		return time.Now().Add(DefaultTTL)
+		// Failed to parse expires header - use fallback TTL
+		return time.Now().Add(fallback)
 	}
 
 	// Validate that TTL is not negative
@@ -92,13 +100,7 @@ func AddConditionalHeaders(req *http.Request, entry *CacheEntry) {
 	if entry == nil || req == nil {
 		return
 	}
-
-	// Prefer ETag over Last-Modified (more accurate)
-	if entry.ETag != "" {
-		req.Header.Set("If-None-Match", entry.ETag)
-	} else if !entry.LastModified.IsZero() {
-		req.Header.Set("If-Modified-Since", entry.LastModified.Format(http.TimeFormat))
-	}
+	AddValidatorHeaders(req, ValidatorsOf(entry))
 }
 
 // EntryToResponse converts a cache entry back to an HTTP response.
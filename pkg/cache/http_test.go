@@ -87,6 +87,34 @@ func TestResponseToEntry(t *testing.T) {
 	}
 }
 
+func TestResponseToEntryWithTTL(t *testing.T) {
+	tests := []struct {
+		name    string
+		expires string
+		want    time.Duration
+	}{
+		{"no expires header", "", time.Hour},
+		{"invalid expires header", "soon", time.Hour},
+		{"expires header wins", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: 200, Header: http.Header{}, Body: io.NopCloser(bytes.NewReader(nil))}
+			if tt.expires != "" {
+				resp.Header.Set("Expires", tt.expires)
+			}
+			entry, err := ResponseToEntryWithTTL(resp, time.Hour)
+			if err != nil {
+				t.Fatalf("ResponseToEntryWithTTL() error = %v", err)
+			}
+			if ttl := entry.TTL(); ttl < tt.want-2*time.Second || ttl > tt.want {
+				t.Errorf("TTL() = %s, want %s", ttl, tt.want)
+			}
+		})
+	}
+}
+
 func TestParseExpires(t *testing.T) {
 	now := time.Now().UTC()
 	futureTime := now.Add(1 * time.Hour)
@@ -132,7 +160,7 @@ func TestParseExpires(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := parseExpires(tt.headers)
+			got := parseExpires(tt.headers, DefaultTTL)
 
 			if tt.expectFuture && got.Before(now) {
 				t.Errorf("parseExpires() = %v, expected time in the future", got)
@@ -1,10 +1,15 @@
 package cache
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"net/url"
+	"slices"
 	"sort"
 	"strings"
+
+	"github.com/cespare/xxhash/v2"
 )
 
 // CacheKey represents a unique identifier for a cached ESI response.
@@ -12,6 +17,11 @@ type CacheKey struct {
 	// Endpoint is the ESI endpoint path (e.g., "/v4/markets/{region_id}/orders/")
 	Endpoint string
 
+	// Method is the HTTP method. Empty and HEAD mean GET, so a HEAD shares
+	// the entry of the GET. Only other methods are part of the key, which
+	// keeps GET keys unchanged.
+	Method string
+
 	// PathParams are the path parameters (e.g., {"region_id": "10000002"})
 	PathParams map[string]string
 
@@ -20,23 +30,49 @@ type CacheKey struct {
 
 	// CharacterID is the character ID for authenticated endpoints (0 for public)
 	CharacterID int64
+
+	// OwnerHash is the SSO owner hash of the character (CharacterOwnerHash
+	// claim). It changes when a character is transferred to another account,
+	// so the new owner never reads entries cached for the previous one.
+	OwnerHash string
+
+	// BodyHash identifies the request body of cacheable POSTs (see HashBody)
+	BodyHash string
+
+	// Tenant isolates private entries of one tenant of a shared cache (e.g.
+	// one alliance served by a multi-tenant proxy). It prefixes the key, so
+	// a tenant's entries can be replicated or flushed by prefix.
+	Tenant string
 }
 
 // String generates a deterministic cache key string.
-// Format: esi:endpoint:param1=val1:param2=val2:query1=val1:char=123456
+// Format: esi:[tenant=name:]endpoint[:METHOD]:param1=val1:param2=val2:query1=val1:body=hash:char=123456:owner=hash
+//
+// The method is a bare segment, so no parameter can produce it.
 //
 // Example:
 //
 //	esi:/v4/markets/10000002/orders/:order_type=all:char=0
+//	esi:v3/universe/names:POST:body=0123456789abcdef
 func (k CacheKey) String() string {
 	parts := []string{"esi"}
 
+	// Add tenant first, so keys of a tenant share a prefix
+	if k.Tenant != "" {
+		parts = append(parts, "tenant="+k.Tenant)
+	}
+
 	// Add endpoint (normalize path)
 	endpoint := strings.Trim(k.Endpoint, "/")
 	if endpoint != "" {
 		parts = append(parts, endpoint)
 	}
 
+	// Add method unless GET, so GET and POST to one path never collide
+	if method := k.NormalizedMethod(); method != http.MethodGet {
+		parts = append(parts, method)
+	}
+
 	// Add path params (sorted for determinism)
 	if len(k.PathParams) > 0 {
 		pathKeys := make([]string, 0, len(k.PathParams))
@@ -63,10 +99,69 @@ func (k CacheKey) String() string {
 		}
 	}
 
+	// Add body hash for cacheable POSTs
+	if k.BodyHash != "" {
+		parts = append(parts, "body="+k.BodyHash)
+	}
+
 	// Add character ID if authenticated
 	if k.CharacterID > 0 {
 		parts = append(parts, fmt.Sprintf("char=%d", k.CharacterID))
 	}
 
+	// Add owner hash, hashed again to keep it out of Redis key listings
+	if k.OwnerHash != "" {
+		parts = append(parts, fmt.Sprintf("owner=%016x", xxhash.Sum64String(k.OwnerHash)))
+	}
+
 	return strings.Join(parts, ":")
 }
+
+// NormalizedMethod returns the upper-case method of the key, GET for empty
+// and HEAD.
+func (k CacheKey) NormalizedMethod() string {
+	method := strings.ToUpper(k.Method)
+	if method == "" || method == http.MethodHead {
+		return http.MethodGet
+	}
+	return method
+}
+
+// HashBody returns a hash of a JSON request body for CacheKey.BodyHash.
+// Bodies are normalized first so equivalent requests share a key: ID and
+// name lists are sorted and deduplicated, other JSON is re-encoded compactly
+// with sorted object keys. Non-JSON bodies are hashed as is.
+func HashBody(body []byte) string {
+	return fmt.Sprintf("%016x", xxhash.Sum64(normalizeBody(body)))
+}
+
+// normalizeBody returns the canonical form of a JSON request body.
+func normalizeBody(body []byte) []byte {
+	var ids []int64
+	if err := json.Unmarshal(body, &ids); err == nil {
+		slices.Sort(ids)
+		return marshalOr(slices.Compact(ids), body)
+	}
+
+	var names []string
+	if err := json.Unmarshal(body, &names); err == nil {
+		slices.Sort(names)
+		return marshalOr(slices.Compact(names), body)
+	}
+
+	var value any
+	if err := json.Unmarshal(body, &value); err == nil {
+		return marshalOr(value, body)
+	}
+
+	return body
+}
+
+// mustMarshal encodes v, falling back to raw if encoding fails.
+func marshalOr(v any, raw []byte) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return raw
+	}
+	return data
+}
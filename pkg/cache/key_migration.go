@@ -0,0 +1,174 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultLegacyKeyGrace is a suggested EnableLegacyKeys grace period: long
+// enough for entries with long lifetimes to be read once after an upgrade.
+const DefaultLegacyKeyGrace = 7 * 24 * time.Hour
+
+// KeyMigration describes a change of the cache key format, so entries stored
+// under the previous format survive an upgrade instead of being refetched.
+// Like entry migrations (see EntryVersion), register one in KeyMigrations
+// whenever CacheKey.String changes for existing entries.
+type KeyMigration struct {
+	// Name identifies the migration in metrics and logs.
+	Name string
+
+	// Legacy returns the key an entry for key was stored under before the
+	// change, "" if the change does not affect key. Used to dual-read during
+	// the grace period (see EnableLegacyKeys).
+	Legacy func(key CacheKey) string
+
+	// Rewrite returns the current form of a stored key, false if it is not a
+	// legacy key of this migration. Used by MigrateKeys.
+	Rewrite func(stored string) (string, bool)
+}
+
+// KeyMigrations are the key format changes legacy entries are migrated
+// across, oldest first.
+var KeyMigrations = []KeyMigration{
+	methodKeyMigration,
+}
+
+// methodKeyMigration moves cached POSTs to keys including the method. Before
+// the method was part of the key, POSTs were the only cached non-GET
+// requests, identified by their body hash.
+var methodKeyMigration = KeyMigration{
+	Name: "method",
+	Legacy: func(key CacheKey) string {
+		if key.NormalizedMethod() != http.MethodPost {
+			return ""
+		}
+		key.Method = ""
+		return key.String()
+	},
+	Rewrite: func(stored string) (string, bool) {
+		prefix := "esi:"
+		rest, ok := strings.CutPrefix(stored, prefix)
+		if !ok {
+			return "", false
+		}
+		if strings.HasPrefix(rest, "tenant=") {
+			tenant, after, _ := strings.Cut(rest, ":")
+			prefix, rest = prefix+tenant+":", after
+		}
+
+		segments := strings.Split(rest, ":")
+		if len(segments) < 2 || !strings.Contains(segments[0], "/") || !strings.Contains(segments[1], "=") {
+			return "", false // No parameters, or already carries a method
+		}
+		for _, segment := range segments[1:] {
+			if strings.HasPrefix(segment, "body=") {
+				return prefix + segments[0] + ":" + http.MethodPost + ":" + strings.Join(segments[1:], ":"), true
+			}
+		}
+		return "", false
+	},
+}
+
+// EnableLegacyKeys makes Get and GetStale fall back to the keys of
+// KeyMigrations for grace after the call: a legacy entry found on a miss is
+// renamed to the current key, keeping its TTL. Combine with MigrateKeys to
+// move all entries at once. Must be called before the manager is used.
+func (m *Manager) EnableLegacyKeys(grace time.Duration) {
+	if grace <= 0 {
+		m.legacyUntil = time.Time{}
+		return
+	}
+	m.legacyUntil = time.Now().Add(grace)
+}
+
+// adoptLegacyKey renames a legacy entry of key to cacheKey and reports
+// whether one was found. Memory limit bookkeeping keeps the legacy key until
+// the entry is written again.
+func (m *Manager) adoptLegacyKey(ctx context.Context, key CacheKey, cacheKey string) bool {
+	if m.legacyUntil.IsZero() || time.Now().After(m.legacyUntil) {
+		return false
+	}
+
+	for _, migration := range KeyMigrations {
+		legacy := migration.Legacy(key)
+		if legacy == "" || legacy == cacheKey {
+			continue
+		}
+		// Fails with "no such key" if there is no legacy entry
+		renamed, err := m.redis.RenameNX(ctx, legacy, cacheKey).Result()
+		if err != nil {
+			continue
+		}
+		if renamed {
+			// Fails harmlessly for entries stored whole
+			_ = m.redis.RenameNX(ctx, bodyKey(legacy), bodyKey(cacheKey)).Err()
+			CacheKeyMigrations.WithLabelValues(migration.Name, "read").Inc()
+		}
+		return true
+	}
+	return false
+}
+
+// MigrateKeysOptions configures MigrateKeys.
+type MigrateKeysOptions struct {
+	// DryRun counts the legacy entries without renaming them.
+	DryRun bool
+}
+
+// MigrateKeysResult summarizes a MigrateKeys run.
+type MigrateKeysResult struct {
+	Scanned    int // Cache entries inspected
+	Migrated   int // Legacy entries renamed to the current key
+	Superseded int // Legacy entries deleted because the current key existed
+}
+
+// MigrateKeys renames all legacy entries (see KeyMigrations) to their current
+// keys, keeping their TTL, e.g. once after an upgrade that changed the key
+// format. A legacy entry whose current key already exists is deleted; the
+// current one is newer. Memory limit bookkeeping is not updated.
+func (m *Manager) MigrateKeys(ctx context.Context, opts MigrateKeysOptions) (MigrateKeysResult, error) {
+	var result MigrateKeysResult
+
+	iter := m.redis.Scan(ctx, 0, "esi:*", manifestScanCount).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		if !isCacheKey(key) {
+			continue
+		}
+		result.Scanned++
+
+		for _, migration := range KeyMigrations {
+			current, ok := migration.Rewrite(key)
+			if !ok {
+				continue
+			}
+			if opts.DryRun {
+				result.Migrated++
+				break
+			}
+
+			renamed, err := m.redis.RenameNX(ctx, key, current).Result()
+			if err != nil {
+				break // Expired meanwhile
+			}
+			if renamed {
+				_ = m.redis.RenameNX(ctx, bodyKey(key), bodyKey(current)).Err()
+				result.Migrated++
+				CacheKeyMigrations.WithLabelValues(migration.Name, "scan").Inc()
+			} else {
+				if err := m.redis.Del(ctx, key, bodyKey(key)).Err(); err != nil {
+					return result, fmt.Errorf("redis del: %w", err)
+				}
+				result.Superseded++
+			}
+			break
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return result, fmt.Errorf("redis scan: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestMethodKeyMigration(t *testing.T) {
+	keys := []CacheKey{
+		{Endpoint: "/v3/universe/names/", Method: http.MethodPost, BodyHash: "0123456789abcdef"},
+		{Endpoint: "/v1/characters/affiliation/", Method: "post", BodyHash: "abc", Tenant: "acme"},
+		{Endpoint: "/v2/universe/ids/", Method: http.MethodPost, BodyHash: "abc", QueryParams: url.Values{"language": {"en"}}},
+	}
+	for _, key := range keys {
+		legacy := methodKeyMigration.Legacy(key)
+		if legacy == "" || legacy == key.String() {
+			t.Errorf("Legacy(%s) = %q, want the key without method", key, legacy)
+			continue
+		}
+		if current, ok := methodKeyMigration.Rewrite(legacy); !ok || current != key.String() {
+			t.Errorf("Rewrite(%q) = %q, %v, want %q", legacy, current, ok, key.String())
+		}
+	}
+
+	// GET keys never changed
+	if legacy := methodKeyMigration.Legacy(CacheKey{Endpoint: "/v1/markets/prices/"}); legacy != "" {
+		t.Errorf("Legacy(GET) = %q, want empty", legacy)
+	}
+
+	for _, stored := range []string{
+		"esi:v1/markets/prices",
+		"esi:v1/markets/10000002/orders:order_type=all",
+		"esi:v3/universe/names:POST:body=abc",
+		"esi:tenant=acme:v1/characters/affiliation:POST:body=abc",
+		"esi:rate_limit:errors_remaining",
+	} {
+		if current, ok := methodKeyMigration.Rewrite(stored); ok {
+			t.Errorf("Rewrite(%q) = %q, want no legacy key", stored, current)
+		}
+	}
+}
+
+func TestManager_LegacyKeys(t *testing.T) {
+	client := setupTestRedis(t)
+	manager := NewManager(client)
+	ctx := context.Background()
+
+	key := CacheKey{Endpoint: "/v3/universe/names/", Method: http.MethodPost, BodyHash: "abc"}
+	entry := &CacheEntry{
+		Data:       []byte(`[{"id":34}]`),
+		Expires:    time.Now().Add(time.Hour),
+		StatusCode: http.StatusOK,
+	}
+	// Store under the legacy key, as an older release did
+	legacy := key
+	legacy.Method = ""
+	if err := manager.Set(ctx, legacy, entry); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	if _, err := manager.Get(ctx, key); err != ErrCacheMiss {
+		t.Fatalf("Get() without legacy keys error = %v, want ErrCacheMiss", err)
+	}
+
+	manager.EnableLegacyKeys(time.Hour)
+	got, err := manager.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get() with legacy keys error = %v", err)
+	}
+	if string(got.Data) != string(entry.Data) {
+		t.Errorf("Data = %s, want %s", got.Data, entry.Data)
+	}
+	if n, _ := client.Exists(ctx, legacy.String()).Result(); n != 0 {
+		t.Error("legacy key still exists after the entry was adopted")
+	}
+	if ttl := client.TTL(ctx, key.String()).Val(); ttl <= 0 || ttl > time.Hour {
+		t.Errorf("TTL = %s, want the legacy entry's TTL", ttl)
+	}
+}
+
+func TestManager_MigrateKeys(t *testing.T) {
+	client := setupTestRedis(t)
+	manager := NewManager(client)
+	ctx := context.Background()
+
+	set := func(key CacheKey, body string) {
+		t.Helper()
+		entry := &CacheEntry{Data: []byte(body), Expires: time.Now().Add(time.Hour), StatusCode: http.StatusOK}
+		if err := manager.Set(ctx, key, entry); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+	}
+	names := CacheKey{Endpoint: "/v3/universe/names/", BodyHash: "abc"}
+	ids := CacheKey{Endpoint: "/v2/universe/ids/", BodyHash: "def"}
+	set(names, `"legacy names"`)
+	set(ids, `"legacy ids"`)
+	set(CacheKey{Endpoint: "/v2/universe/ids/", Method: http.MethodPost, BodyHash: "def"}, `"current ids"`)
+	set(CacheKey{Endpoint: "/v1/markets/prices/"}, `[]`)
+
+	result, err := manager.MigrateKeys(ctx, MigrateKeysOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("MigrateKeys(DryRun) error = %v", err)
+	}
+	if result.Scanned != 4 || result.Migrated != 2 {
+		t.Errorf("MigrateKeys(DryRun) = %+v, want 4 scanned, 2 legacy", result)
+	}
+
+	result, err = manager.MigrateKeys(ctx, MigrateKeysOptions{})
+	if err != nil {
+		t.Fatalf("MigrateKeys() error = %v", err)
+	}
+	if result.Migrated != 1 || result.Superseded != 1 {
+		t.Errorf("MigrateKeys() = %+v, want 1 migrated, 1 superseded", result)
+	}
+
+	names.Method = http.MethodPost
+	if got, err := manager.Get(ctx, names); err != nil || string(got.Data) != `"legacy names"` {
+		t.Errorf("Get(migrated) = %v, %v, want the legacy entry", got, err)
+	}
+	ids.Method = http.MethodPost
+	if got, err := manager.Get(ctx, ids); err != nil || string(got.Data) != `"current ids"` {
+		t.Errorf("Get(superseded) = %v, %v, want the current entry", got, err)
+	}
+}
@@ -55,6 +55,74 @@ func TestCacheKey_String(t *testing.T) {
 			},
 			want: "esi:v4/characters/{character_id}/assets:char=123456789",
 		},
+		{
+			name: "authenticated endpoint with owner hash",
+			key: CacheKey{
+				Endpoint:    "/v4/characters/{character_id}/assets/",
+				CharacterID: 123456789,
+				OwnerHash:   "XM4D...Hash=",
+			},
+			want: "esi:v4/characters/{character_id}/assets:char=123456789:owner=d6d4addac56c3cb9",
+		},
+		{
+			name: "tenant prefix",
+			key: CacheKey{
+				Endpoint:    "/v4/characters/{character_id}/assets/",
+				CharacterID: 123456789,
+				Tenant:      "alliance-a",
+			},
+			want: "esi:tenant=alliance-a:v4/characters/{character_id}/assets:char=123456789",
+		},
+		{
+			name: "cacheable POST with body hash",
+			key: CacheKey{
+				Endpoint: "/v3/universe/names/",
+				BodyHash: "0123456789abcdef",
+			},
+			want: "esi:v3/universe/names:body=0123456789abcdef",
+		},
+		{
+			name: "POST with body hash",
+			key: CacheKey{
+				Endpoint: "/v3/universe/names/",
+				Method:   "POST",
+				BodyHash: "0123456789abcdef",
+			},
+			want: "esi:v3/universe/names:POST:body=0123456789abcdef",
+		},
+		{
+			name: "lower-case method",
+			key: CacheKey{
+				Endpoint: "/v3/universe/names/",
+				Method:   "post",
+			},
+			want: "esi:v3/universe/names:POST",
+		},
+		{
+			name: "GET method omitted",
+			key: CacheKey{
+				Endpoint: "/v1/universe/types/",
+				Method:   "GET",
+			},
+			want: "esi:v1/universe/types",
+		},
+		{
+			name: "HEAD shares the GET key",
+			key: CacheKey{
+				Endpoint: "/v1/universe/types/",
+				Method:   "HEAD",
+			},
+			want: "esi:v1/universe/types",
+		},
+		{
+			name: "tenant and POST",
+			key: CacheKey{
+				Tenant:   "alliance-a",
+				Endpoint: "/v3/universe/names/",
+				Method:   "POST",
+			},
+			want: "esi:tenant=alliance-a:v3/universe/names:POST",
+		},
 		{
 			name: "complex key with all params",
 			key: CacheKey{
@@ -121,3 +189,76 @@ func TestCacheKey_Determinism(t *testing.T) {
 		}
 	}
 }
+
+func TestHashBody(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		same bool
+	}{
+		{"id order", `[3, 1, 2]`, `[1,2,3]`, true},
+		{"duplicate ids", `[1, 1, 2]`, `[2, 1]`, true},
+		{"different ids", `[1, 2]`, `[1, 3]`, false},
+		{"name order", `["Jita", "Amarr"]`, `["Amarr","Jita"]`, true},
+		{"object key order", `{"b": 1, "a": [2]}`, `{"a":[2],"b":1}`, true},
+		{"non-json", `abc`, `abd`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := HashBody([]byte(tt.a)), HashBody([]byte(tt.b))
+			if (a == b) != tt.same {
+				t.Errorf("HashBody(%s) = %s, HashBody(%s) = %s, want same = %v", tt.a, a, tt.b, b, tt.same)
+			}
+		})
+	}
+}
+
+// TestCacheKey_MethodCollisions guards against requests with different
+// methods sharing an entry.
+func TestCacheKey_MethodCollisions(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b CacheKey
+	}{
+		{
+			name: "GET and POST to the same path",
+			a:    CacheKey{Endpoint: "/v3/universe/names/"},
+			b:    CacheKey{Endpoint: "/v3/universe/names/", Method: "POST"},
+		},
+		{
+			name: "POST and PUT to the same path",
+			a:    CacheKey{Endpoint: "/v1/characters/1/contacts/", Method: "POST"},
+			b:    CacheKey{Endpoint: "/v1/characters/1/contacts/", Method: "PUT"},
+		},
+		{
+			name: "GET with method query parameter and POST",
+			a:    CacheKey{Endpoint: "/v3/universe/names/", QueryParams: url.Values{"POST": {""}}},
+			b:    CacheKey{Endpoint: "/v3/universe/names/", Method: "POST"},
+		},
+		{
+			name: "GET with id query and POST bulk element",
+			a:    CacheKey{Endpoint: "/v3/universe/names/", QueryParams: url.Values{"id": {"1"}}},
+			b:    CacheKey{Endpoint: "/v3/universe/names/", Method: "POST", QueryParams: url.Values{"id": {"1"}}},
+		},
+		{
+			name: "GET and POST with body hash",
+			a:    CacheKey{Endpoint: "/v3/universe/names/", BodyHash: "0123456789abcdef"},
+			b:    CacheKey{Endpoint: "/v3/universe/names/", Method: "POST", BodyHash: "0123456789abcdef"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.a.String() == tt.b.String() {
+				t.Errorf("keys collide: %s", tt.a.String())
+			}
+		})
+	}
+
+	get := CacheKey{Endpoint: "/v1/universe/types/"}
+	head := CacheKey{Endpoint: "/v1/universe/types/", Method: "HEAD"}
+	if get.String() != head.String() {
+		t.Errorf("HEAD key %s, want GET key %s", head.String(), get.String())
+	}
+}
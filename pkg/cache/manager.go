@@ -2,11 +2,11 @@ package cache
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
 
+	"github.com/Sternrassler/eve-esi-client/pkg/codec"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -20,55 +20,162 @@ var (
 
 // Manager handles caching operations with Redis backend.
 type Manager struct {
-	redis *redis.Client
+	redis      *redis.Client
+	codec      codec.Codec
+	decoded    *decodedLayer   // optional, see EnableDecodedCache
+	validators *validatorIndex // optional, see EnableValidatorIndex
+	expiry     *expiryTracker
+	limit      *memoryLimit  // optional, see EnableMemoryLimit
+	replica    *readReplica  // optional, see SetReadReplica
+	stale      time.Duration // optional, see EnableStaleRetention
+	splitAt    int           // optional, see EnableSplitStorage
+	headers    HeaderFilter  // see SetHeaderFilter
+	ttl        time.Duration // optional, see SetFallbackTTL
+
+	legacyUntil time.Time // optional, see EnableLegacyKeys
 }
 
 // NewManager creates a new cache manager with Redis backend.
 func NewManager(redisClient *redis.Client) *Manager {
+	return NewManagerWithCodec(redisClient, codec.Std)
+}
+
+// NewManagerWithCodec creates a cache manager that encodes entries with c
+// instead of encoding/json. A nil codec falls back to codec.Std.
+// Entries must be read with a codec compatible with the one that wrote them.
+func NewManagerWithCodec(redisClient *redis.Client, c codec.Codec) *Manager {
 	if redisClient == nil {
 		panic("redis client cannot be nil")
 	}
 	return &Manager{
-		redis: redisClient,
+		redis:  redisClient,
+		codec:  codec.OrStd(c),
+		expiry: newExpiryTracker(),
+	}
+}
+
+// SetFallbackTTL sets the TTL reported by FallbackTTL for responses without
+// expires header (0 restores DefaultTTL). Must be called before the manager
+// is used.
+func (m *Manager) SetFallbackTTL(d time.Duration) {
+	m.ttl = max(d, 0)
+}
+
+// FallbackTTL returns the TTL for responses without expires header, for use
+// with ResponseToEntryWithTTL.
+func (m *Manager) FallbackTTL() time.Duration {
+	if m.ttl == 0 {
+		return DefaultTTL
 	}
+	return m.ttl
 }
 
 // Get retrieves a cache entry by key.
 // Returns ErrCacheMiss if the key doesn't exist or entry is expired.
 func (m *Manager) Get(ctx context.Context, key CacheKey) (*CacheEntry, error) {
+	return m.get(ctx, key, false)
+}
+
+// get implements Get and GetStale.
+func (m *Manager) get(ctx context.Context, key CacheKey, allowStale bool) (*CacheEntry, error) {
 	cacheKey := key.String()
 
-	// Get data from Redis
-	data, err := m.redis.Get(ctx, cacheKey).Bytes()
+	entry, src, migrated, err := m.getEnvelope(ctx, key, cacheKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if expired (retained entries stay until Redis drops them)
+	if entry.IsExpired() && !allowStale {
+		if m.stale <= 0 {
+			_ = m.Delete(ctx, key)
+		}
+		CacheMisses.Inc()
+		return nil, ErrCacheMiss
+	}
+
+	// Read the body stored apart from the envelope
+	if entry.BodySize > 0 {
+		if err := m.loadBody(ctx, src, cacheKey, entry); err != nil {
+			if errors.Is(err, ErrCacheMiss) {
+				CacheMisses.Inc()
+				return nil, err
+			}
+			CacheErrors.WithLabelValues("get").Inc()
+			if errors.Is(err, ErrInvalidEntry) {
+				m.dropCorrupted(ctx, key)
+			}
+			return nil, err
+		}
+	}
+
+	// Verify checksum - never hand corrupted data to callers
+	if !entry.Verify() {
+		m.dropCorrupted(ctx, key)
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrInvalidEntry)
+	}
+
+	// Entries may predate the current header filter
+	entry.Headers = m.headers.Apply(entry.Headers)
+
+	// Persist migrated entry so the upgrade happens only once
+	if migrated {
+		_ = m.Set(ctx, key, entry)
+	}
+
+	// Cache hit
+	CacheHits.WithLabelValues("redis").Inc()
+	m.touch(ctx, cacheKey)
+	if !entry.IsExpired() {
+		m.indexValidators(cacheKey, entry)
+	}
+
+	return entry, nil
+}
+
+// getEnvelope reads and decodes the stored envelope of key, upgrading it to
+// EntryVersion. Data is empty if the body is stored split (BodySize > 0).
+// The result includes the Redis the envelope was read from (the primary or
+// the read replica) and reports whether the entry was migrated and must be
+// written back.
+func (m *Manager) getEnvelope(ctx context.Context, key CacheKey, cacheKey string) (*CacheEntry, *redis.Client, bool, error) {
+	// Get data from Redis (legacy entries are adopted on the primary)
+	data, src, err := m.read(ctx, cacheKey)
+	if err == redis.Nil && m.adoptLegacyKey(ctx, key, cacheKey) {
+		data, err = m.redis.Get(ctx, cacheKey).Bytes()
+		src = m.redis
+	}
 	if err != nil {
 		if err == redis.Nil {
 			CacheMisses.Inc()
-			return nil, ErrCacheMiss
+			return nil, nil, false, ErrCacheMiss
 		}
 		CacheErrors.WithLabelValues("get").Inc()
-		return nil, fmt.Errorf("redis get: %w", err)
+		return nil, nil, false, fmt.Errorf("redis get: %w", err)
 	}
 
 	// Unmarshal entry
 	var entry CacheEntry
-	if err := json.Unmarshal(data, &entry); err != nil {
+	if err := m.codec.Unmarshal(data, &entry); err != nil {
 		CacheErrors.WithLabelValues("get").Inc()
-		return nil, fmt.Errorf("%w: %v", ErrInvalidEntry, err)
+		m.dropCorrupted(ctx, key)
+		return nil, nil, false, fmt.Errorf("%w: %v", ErrInvalidEntry, err)
 	}
 
-	// Check if expired
-	if entry.IsExpired() {
-		// Delete expired entry
-		_ = m.Delete(ctx, key)
+	// Entries from a newer deployment (rolling upgrade) are left alone
+	if entry.Version > EntryVersion {
 		CacheMisses.Inc()
-		return nil, ErrCacheMiss
+		return nil, nil, false, ErrCacheMiss
 	}
 
-	// Cache hit
-	CacheHits.WithLabelValues("redis").Inc()
-	CacheSize.WithLabelValues("redis").Add(float64(len(data)))
+	// Upgrade entries written by older versions
+	migrated, err := migrateEntry(&entry)
+	if err != nil {
+		m.dropCorrupted(ctx, key)
+		return nil, nil, false, fmt.Errorf("%w: %v", ErrInvalidEntry, err)
+	}
 
-	return &entry, nil
+	return &entry, src, migrated, nil
 }
 
 // Set stores a cache entry with TTL based on the entry's Expires field.
@@ -78,58 +185,166 @@ func (m *Manager) Set(ctx context.Context, key CacheKey, entry *CacheEntry) erro
 		return fmt.Errorf("cache entry cannot be nil")
 	}
 
-	cacheKey := key.String()
+	_, err := m.store(ctx, key.String(), EndpointPattern(key.Endpoint), entry, false)
+	return err
+}
+
+// store implements Set for a cache key string. With ifAbsent an existing
+// entry is kept; the result reports whether entry was written.
+func (m *Manager) store(ctx context.Context, cacheKey, endpoint string, entry *CacheEntry, ifAbsent bool) (bool, error) {
+	m.invalidateDecoded(cacheKey)
 
 	// Calculate TTL
 	ttl := entry.TTL()
 	if ttl <= 0 {
 		// Already expired, don't cache
-		return nil
+		return false, nil
 	}
 
-	// Marshal entry
-	data, err := json.Marshal(entry)
+	// Stamp version and checksum, then marshal entry (without a body
+	// stored split)
+	entry.Headers = m.headers.Apply(entry.Headers)
+	entry.Version = EntryVersion
+	entry.Checksum = entry.computeChecksum()
+	envelope, body := m.splitEnvelope(entry)
+	data, err := m.codec.Marshal(envelope)
 	if err != nil {
 		CacheErrors.WithLabelValues("set").Inc()
-		return fmt.Errorf("marshal cache entry: %w", err)
+		return false, fmt.Errorf("marshal cache entry: %w", err)
 	}
 
-	// Store in Redis with TTL
-	if err := m.redis.Set(ctx, cacheKey, data, ttl).Err(); err != nil {
+	// Store in Redis with TTL, plus the stale retention window
+	if err := m.write(ctx, cacheKey, data, body, ttl+m.stale, ifAbsent); err != nil {
+		if err == errNotWritten {
+			return false, nil
+		}
 		CacheErrors.WithLabelValues("set").Inc()
-		return fmt.Errorf("redis set: %w", err)
+		return false, err
+	}
+	size := len(data) + len(body)
+
+	// Update cache size metrics
+	m.recordWrite(cacheKey, endpoint, size)
+	m.indexValidators(cacheKey, entry)
+
+	// Enforce memory limit
+	if m.limit != nil {
+		if err := m.trackSize(ctx, cacheKey, size, entry.Expires); err != nil {
+			CacheErrors.WithLabelValues("set").Inc()
+			return true, err
+		}
+	}
+
+	return true, nil
+}
+
+// errNotWritten reports that write kept an existing entry (ifAbsent).
+var errNotWritten = errors.New("entry exists")
+
+// write stores the envelope data of cacheKey and its body, if stored split.
+// Without split storage enabled a single SET is issued; otherwise the body
+// key is replaced or removed along with the envelope. With ifAbsent an
+// existing entry is kept and errNotWritten returned.
+func (m *Manager) write(ctx context.Context, cacheKey string, data, body []byte, ttl time.Duration, ifAbsent bool) error {
+	m.markWritten(cacheKey)
+
+	if ifAbsent {
+		written, err := m.redis.SetNX(ctx, cacheKey, data, ttl).Result()
+		if err != nil {
+			return fmt.Errorf("redis setnx: %w", err)
+		}
+		if !written {
+			return errNotWritten
+		}
+		// Readers miss until the body follows
+		if body != nil {
+			if err := m.redis.Set(ctx, bodyKey(cacheKey), body, ttl).Err(); err != nil {
+				return fmt.Errorf("redis set body: %w", err)
+			}
+		}
+		return nil
 	}
 
-	// Update cache size metric
-	CacheSize.WithLabelValues("redis").Add(float64(len(data)))
+	if m.splitAt <= 0 && body == nil {
+		if err := m.redis.Set(ctx, cacheKey, data, ttl).Err(); err != nil {
+			return fmt.Errorf("redis set: %w", err)
+		}
+		return nil
+	}
 
+	// Body first, so readers never see the new envelope with the old body
+	if _, err := m.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if body != nil {
+			pipe.Set(ctx, bodyKey(cacheKey), body, ttl)
+		} else {
+			pipe.Del(ctx, bodyKey(cacheKey))
+		}
+		pipe.Set(ctx, cacheKey, data, ttl)
+		return nil
+	}); err != nil {
+		return fmt.Errorf("redis set: %w", err)
+	}
 	return nil
 }
 
 // Delete removes a cache entry.
 func (m *Manager) Delete(ctx context.Context, key CacheKey) error {
 	cacheKey := key.String()
+	m.invalidateDecoded(cacheKey)
+	m.forgetValidators(cacheKey)
+	m.markWritten(cacheKey)
 
-	if err := m.redis.Del(ctx, cacheKey).Err(); err != nil {
+	if err := m.redis.Del(ctx, cacheKey, bodyKey(cacheKey)).Err(); err != nil {
 		CacheErrors.WithLabelValues("delete").Inc()
 		return fmt.Errorf("redis del: %w", err)
 	}
+	m.recordRemoval(cacheKey, false)
+	m.forgetSize(ctx, cacheKey)
 
 	return nil
 }
 
+// dropCorrupted deletes a corrupted entry so the next request refetches it.
+func (m *Manager) dropCorrupted(ctx context.Context, key CacheKey) {
+	CacheCorruption.Inc()
+	_ = m.Delete(ctx, key)
+}
+
 // UpdateTTL updates the TTL of an existing cache entry.
 // This is useful when receiving a 304 Not Modified response with a new expires header.
+// The body of an entry stored split is not transferred.
 func (m *Manager) UpdateTTL(ctx context.Context, key CacheKey, newExpires time.Time) error {
+	return m.UpdateTTLWithETag(ctx, key, newExpires, "")
+}
+
+// UpdateTTLWithETag updates the TTL like UpdateTTL after a 304 that
+// confirmed etag. If etag is one of the entry's ETagAliases, it becomes the
+// entry's ETag (see CacheEntry.PromoteETag).
+func (m *Manager) UpdateTTLWithETag(ctx context.Context, key CacheKey, newExpires time.Time, etag string) error {
 	// Get existing entry
-	entry, err := m.Get(ctx, key)
+	entry, err := m.GetMeta(ctx, key)
 	if err != nil {
 		return err
 	}
+	if entry.BodySize == 0 {
+		// Stored whole - rewrite it with its body
+		if entry, err = m.Get(ctx, key); err != nil {
+			return err
+		}
+	}
 
-	// Update expires time
+	// Update expires time; ESI confirmed the data, so it is no longer a
+	// preloaded guess
 	entry.Expires = newExpires
+	entry.ValidatedAt = time.Now()
+	entry.Preloaded = false
+	if etag != "" && !WeakETagMatch(etag, entry.ETag) {
+		entry.PromoteETag(etag)
+	}
 
 	// Re-save with new TTL
+	if entry.BodySize > 0 {
+		return m.updateSplitTTL(ctx, key, entry)
+	}
 	return m.Set(ctx, key, entry)
 }
@@ -2,13 +2,35 @@ package cache
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/Sternrassler/eve-esi-client/pkg/codec"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/redis/go-redis/v9"
 )
 
+// countingCodec wraps codec.Std and counts calls.
+type countingCodec struct {
+	codec.Codec
+	marshals, unmarshals int
+}
+
+func (c *countingCodec) Marshal(v any) ([]byte, error) {
+	c.marshals++
+	return c.Codec.Marshal(v)
+}
+
+func (c *countingCodec) Unmarshal(data []byte, v any) error {
+	c.unmarshals++
+	return c.Codec.Unmarshal(data, v)
+}
+
 // setupTestRedis creates a test Redis client for testing.
 // For unit tests, we use miniredis (in-memory). For integration tests,
 // we would use testcontainers-go with a real Redis instance.
@@ -63,6 +85,65 @@ func TestNewManager_Panic(t *testing.T) {
 	NewManager(nil)
 }
 
+func TestNewManagerWithCodec(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+	defer client.Close()
+
+	if m := NewManagerWithCodec(client, nil); m.codec != codec.Std {
+		t.Error("nil codec should fall back to codec.Std")
+	}
+}
+
+func TestManager_FallbackTTL(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+	defer client.Close()
+
+	tests := []struct {
+		name string
+		set  time.Duration
+		want time.Duration
+	}{
+		{"default", 0, DefaultTTL},
+		{"custom", time.Hour, time.Hour},
+		{"negative", -time.Hour, DefaultTTL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := NewManager(client)
+			manager.SetFallbackTTL(tt.set)
+			if got := manager.FallbackTTL(); got != tt.want {
+				t.Errorf("FallbackTTL() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManager_CustomCodec(t *testing.T) {
+	client := setupTestRedis(t)
+	c := &countingCodec{Codec: codec.Std}
+	manager := NewManagerWithCodec(client, c)
+	ctx := context.Background()
+
+	key := CacheKey{Endpoint: "/v1/universe/types/34/"}
+	entry := &CacheEntry{
+		Data:       []byte(`{"type_id":34}`),
+		Expires:    time.Now().Add(time.Hour),
+		StatusCode: http.StatusOK,
+	}
+
+	if err := manager.Set(ctx, key, entry); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if _, err := manager.Get(ctx, key); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	if c.marshals != 1 || c.unmarshals != 1 {
+		t.Errorf("codec calls = %d marshal / %d unmarshal, want 1/1", c.marshals, c.unmarshals)
+	}
+}
+
 func TestManager_SetAndGet(t *testing.T) {
 	client := setupTestRedis(t)
 	manager := NewManager(client)
@@ -120,6 +201,124 @@ func TestManager_Get_CacheMiss(t *testing.T) {
 	}
 }
 
+// counterValue returns the current value of a Prometheus counter.
+func counterValue(t *testing.T, c prometheus.Counter) float64 {
+	t.Helper()
+	var m dto.Metric
+	if err := c.Write(&m); err != nil {
+		t.Fatalf("read counter: %v", err)
+	}
+	return m.GetCounter().GetValue()
+}
+
+func TestManager_Get_CorruptedEntry(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(raw string) string
+	}{
+		{
+			name: "checksum mismatch",
+			corrupt: func(raw string) string {
+				// base64 of the data field changes, checksum stays
+				return strings.Replace(raw, `"data":"`, `"data":"AAAA`, 1)
+			},
+		},
+		{
+			name:    "undecodable envelope",
+			corrupt: func(raw string) string { return raw[:len(raw)/2] },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := setupTestRedis(t)
+			manager := NewManager(client)
+			ctx := context.Background()
+
+			key := CacheKey{Endpoint: "/v1/universe/types/34/"}
+			entry := &CacheEntry{
+				Data:       []byte(`{"type_id":34}`),
+				Expires:    time.Now().Add(time.Hour),
+				StatusCode: http.StatusOK,
+			}
+			if err := manager.Set(ctx, key, entry); err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+
+			raw, err := client.Get(ctx, key.String()).Result()
+			if err != nil {
+				t.Fatalf("redis get: %v", err)
+			}
+			if err := client.Set(ctx, key.String(), tt.corrupt(raw), time.Hour).Err(); err != nil {
+				t.Fatalf("redis set: %v", err)
+			}
+
+			before := counterValue(t, CacheCorruption)
+			if _, err := manager.Get(ctx, key); !errors.Is(err, ErrInvalidEntry) {
+				t.Fatalf("Get() error = %v, want ErrInvalidEntry", err)
+			}
+			if got := counterValue(t, CacheCorruption) - before; got != 1 {
+				t.Errorf("esi_cache_corruption_total increased by %v, want 1", got)
+			}
+
+			// Corrupted entry is gone, next read is a plain miss
+			if _, err := manager.Get(ctx, key); err != ErrCacheMiss {
+				t.Errorf("Get() after corruption error = %v, want ErrCacheMiss", err)
+			}
+		})
+	}
+}
+
+func TestManager_Get_EntryVersions(t *testing.T) {
+	expires := time.Now().Add(time.Hour).UTC().Format(time.RFC3339Nano)
+	legacy := `{"data":"eyJ0eXBlX2lkIjozNH0=","etag":"\"abc\"","expires":"` + expires + `","status_code":200}`
+	future := `{"version":99,"data":"eyJ0eXBlX2lkIjozNH0=","expires":"` + expires + `","status_code":200}`
+
+	tests := []struct {
+		name        string
+		raw         string
+		wantErr     error
+		wantVersion int
+	}{
+		{"legacy entry is migrated", legacy, nil, EntryVersion},
+		{"newer entry is a miss and kept", future, ErrCacheMiss, 99},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := setupTestRedis(t)
+			manager := NewManager(client)
+			ctx := context.Background()
+			key := CacheKey{Endpoint: "/v1/universe/types/34/"}
+
+			if err := client.Set(ctx, key.String(), tt.raw, time.Hour).Err(); err != nil {
+				t.Fatalf("redis set: %v", err)
+			}
+
+			entry, err := manager.Get(ctx, key)
+			if err != tt.wantErr {
+				t.Fatalf("Get() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && string(entry.Data) != `{"type_id":34}` {
+				t.Errorf("Get() data = %s", entry.Data)
+			}
+
+			// Stored entry was rewritten (migration) or left untouched (newer)
+			raw, err := client.Get(ctx, key.String()).Bytes()
+			if err != nil {
+				t.Fatalf("redis get: %v", err)
+			}
+			var stored CacheEntry
+			if err := json.Unmarshal(raw, &stored); err != nil {
+				t.Fatalf("unmarshal stored entry: %v", err)
+			}
+			if stored.Version != tt.wantVersion {
+				t.Errorf("stored version = %d, want %d", stored.Version, tt.wantVersion)
+			}
+		})
+	}
+}
+
 func TestManager_Get_ExpiredEntry(t *testing.T) {
 	client := setupTestRedis(t)
 	manager := NewManager(client)
@@ -220,6 +419,11 @@ func TestManager_UpdateTTL(t *testing.T) {
 		t.Errorf("Expires time not updated correctly: got %v, want %v (diff: %v)",
 			retrieved.Expires, newExpires, diff)
 	}
+
+	// The 304 counts as a refresh
+	if time.Since(retrieved.RefreshedAt()) > time.Second {
+		t.Errorf("RefreshedAt() = %v, want about now", retrieved.RefreshedAt())
+	}
 }
 
 func TestManager_Set_NilEntry(t *testing.T) {
@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// manifestScanCount is the SCAN batch size used when building a manifest.
+const manifestScanCount = 500
+
+// ManifestEntry describes one cache entry for export to another Redis
+// instance. Raw holds the serialized envelope and Body the body of entries
+// stored split (see EnableSplitStorage); both are only set when data was
+// requested.
+type ManifestEntry struct {
+	Key     string    `json:"key"`
+	ETag    string    `json:"etag,omitempty"`
+	Expires time.Time `json:"expires"`
+	Raw     []byte    `json:"raw,omitempty"`
+	Body    []byte    `json:"body,omitempty"`
+}
+
+// ExportManifest lists all live cache entries. With includeData the
+// serialized entries are included so ImportManifest can warm a new Redis
+// instance; without, the manifest only describes what is cached.
+// Non-cache keys sharing the "esi:" prefix (rate limit state, quotas, the
+// request journal) are skipped because they do not decode as a CacheEntry.
+// Bodies stored split are only read with includeData.
+func (m *Manager) ExportManifest(ctx context.Context, includeData bool) ([]ManifestEntry, error) {
+	var manifest []ManifestEntry
+
+	iter := m.redis.Scan(ctx, 0, "esi:*", manifestScanCount).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		if strings.HasPrefix(key, RedisKeyMetaPrefix) || strings.HasPrefix(key, RedisKeyBodyPrefix) {
+			continue
+		}
+
+		data, err := m.redis.Get(ctx, key).Bytes()
+		if err == redis.Nil {
+			continue // expired meanwhile
+		}
+		var replyErr redis.Error
+		if errors.As(err, &replyErr) {
+			continue // not a string (e.g. the client's request journal)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("redis get %s: %w", key, err)
+		}
+
+		var entry CacheEntry
+		if err := m.codec.Unmarshal(data, &entry); err != nil || entry.Expires.IsZero() {
+			continue
+		}
+		if entry.IsExpired() {
+			continue
+		}
+
+		item := ManifestEntry{Key: key, ETag: entry.ETag, Expires: entry.Expires}
+		if includeData {
+			item.Raw = data
+			if entry.BodySize > 0 {
+				body, err := m.redis.Get(ctx, bodyKey(key)).Bytes()
+				if err == redis.Nil {
+					continue // body expired or evicted
+				}
+				if err != nil {
+					return nil, fmt.Errorf("redis get %s: %w", bodyKey(key), err)
+				}
+				item.Body = body
+			}
+		}
+		manifest = append(manifest, item)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("redis scan: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// ImportManifest writes exported entries with their remaining TTL.
+// Entries without data or already expired are skipped. Returns the number of
+// imported entries.
+func (m *Manager) ImportManifest(ctx context.Context, manifest []ManifestEntry) (int, error) {
+	imported := 0
+	for _, item := range manifest {
+		ttl := time.Until(item.Expires)
+		if len(item.Raw) == 0 || ttl <= 0 {
+			continue
+		}
+
+		if err := m.write(ctx, item.Key, item.Raw, item.Body, ttl, false); err != nil {
+			CacheErrors.WithLabelValues("set").Inc()
+			return imported, fmt.Errorf("import %s: %w", item.Key, err)
+		}
+		size := len(item.Raw) + len(item.Body)
+		m.invalidateDecoded(item.Key)
+		m.recordWrite(item.Key, endpointFromKey(item.Key), size)
+		if m.limit != nil {
+			if err := m.trackSize(ctx, item.Key, size, item.Expires); err != nil {
+				return imported, err
+			}
+		}
+		imported++
+	}
+	return imported, nil
+}
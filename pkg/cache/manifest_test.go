@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestManager_ExportImportManifest(t *testing.T) {
+	client := setupTestRedis(t)
+	manager := NewManager(client)
+	ctx := context.Background()
+
+	key := CacheKey{Endpoint: "/v1/markets/10000002/orders/"}
+	entry := &CacheEntry{
+		Data:       []byte(`[{"order_id":1}]`),
+		ETag:       `"abc"`,
+		Expires:    time.Now().Add(time.Hour),
+		StatusCode: http.StatusOK,
+	}
+	if err := manager.Set(ctx, key, entry); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	// Non-cache keys with the same prefix are ignored
+	if err := client.Set(ctx, "esi:rate_limit:errors_remaining", 100, 0).Err(); err != nil {
+		t.Fatalf("redis set: %v", err)
+	}
+	if err := client.HSet(ctx, "esi:journal", "key", "{}").Err(); err != nil {
+		t.Fatalf("redis hset: %v", err)
+	}
+
+	withoutData, err := manager.ExportManifest(ctx, false)
+	if err != nil {
+		t.Fatalf("ExportManifest() error = %v", err)
+	}
+	if len(withoutData) != 1 || withoutData[0].Key != key.String() || withoutData[0].ETag != `"abc"` || withoutData[0].Raw != nil {
+		t.Fatalf("ExportManifest(false) = %+v", withoutData)
+	}
+
+	manifest, err := manager.ExportManifest(ctx, true)
+	if err != nil {
+		t.Fatalf("ExportManifest() error = %v", err)
+	}
+
+	// Import into an empty Redis; expired and data-less items are skipped
+	if err := client.FlushDB(ctx).Err(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	manifest = append(manifest,
+		ManifestEntry{Key: "esi:expired", Expires: time.Now().Add(-time.Minute), Raw: []byte("{}")},
+		ManifestEntry{Key: "esi:no-data", Expires: time.Now().Add(time.Hour)},
+	)
+
+	imported, err := manager.ImportManifest(ctx, manifest)
+	if err != nil {
+		t.Fatalf("ImportManifest() error = %v", err)
+	}
+	if imported != 1 {
+		t.Errorf("ImportManifest() imported = %d, want 1", imported)
+	}
+
+	got, err := manager.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get() after import error = %v", err)
+	}
+	if string(got.Data) != string(entry.Data) {
+		t.Errorf("Get() data = %s, want %s", got.Data, entry.Data)
+	}
+	if ttl := client.TTL(ctx, key.String()).Val(); ttl <= 0 || ttl > time.Hour {
+		t.Errorf("imported TTL = %v, want remaining lifetime", ttl)
+	}
+}
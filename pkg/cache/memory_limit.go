@@ -0,0 +1,179 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis keys for the memory limit bookkeeping. They share the "esi:" prefix
+// but live under RedisKeyMetaPrefix, which cache scans skip.
+const (
+	RedisKeyMetaPrefix    = "esi:cache_meta:"
+	redisKeyEntrySizes    = RedisKeyMetaPrefix + "sizes"
+	redisKeyTotalBytes    = RedisKeyMetaPrefix + "total_bytes"
+	redisKeyExpiresIndex  = RedisKeyMetaPrefix + "by_expires"
+	redisKeyLastReadIndex = RedisKeyMetaPrefix + "by_last_read"
+)
+
+// EvictionPolicy selects which entries are trimmed first when the cache
+// exceeds its memory limit.
+type EvictionPolicy string
+
+const (
+	// EvictSoonestExpiring drops entries closest to expiry first (default).
+	// They lose the least remaining value.
+	EvictSoonestExpiring EvictionPolicy = "expires"
+
+	// EvictLeastRecentlyRead drops entries that were not read for the longest time.
+	EvictLeastRecentlyRead EvictionPolicy = "lru"
+)
+
+// trimTargetRatio is the fill level trimming stops at, below the limit so
+// not every write over the limit triggers another trim.
+const trimTargetRatio = 0.9
+
+// trimBatchSize is the number of candidates fetched per trim round.
+const trimBatchSize = 50
+
+// memoryLimit holds the configured cache memory cap.
+type memoryLimit struct {
+	maxBytes int64
+	policy   EvictionPolicy
+}
+
+// trackScript records the size of a written entry and returns the new total.
+var trackScript = redis.NewScript(`
+local old = tonumber(redis.call('HGET', KEYS[1], ARGV[1]) or '0')
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[2])
+redis.call('ZADD', KEYS[3], ARGV[3], ARGV[1])
+redis.call('ZADD', KEYS[4], ARGV[4], ARGV[1])
+return redis.call('INCRBY', KEYS[2], tonumber(ARGV[2]) - old)
+`)
+
+// forgetScript removes an entry from the bookkeeping.
+var forgetScript = redis.NewScript(`
+local size = tonumber(redis.call('HGET', KEYS[1], ARGV[1]) or '0')
+redis.call('HDEL', KEYS[1], ARGV[1])
+redis.call('ZREM', KEYS[3], ARGV[1])
+redis.call('ZREM', KEYS[4], ARGV[1])
+return redis.call('DECRBY', KEYS[2], size)
+`)
+
+// trimScript first forgets entries Redis already expired, then deletes
+// entries in policy order until the total is at or below the target.
+// Evicted entries are deleted with their body key (ARGV[5] prefix). Returns
+// the total followed by the evicted keys.
+var trimScript = redis.NewScript(`
+local function forget(key)
+  local size = tonumber(redis.call('HGET', KEYS[1], key) or '0')
+  redis.call('HDEL', KEYS[1], key)
+  redis.call('ZREM', KEYS[3], key)
+  redis.call('ZREM', KEYS[4], key)
+  return tonumber(redis.call('DECRBY', KEYS[2], size))
+end
+
+for _, key in ipairs(redis.call('ZRANGEBYSCORE', KEYS[3], '-inf', ARGV[2])) do
+  forget(key)
+end
+
+local index = KEYS[3]
+if ARGV[3] == 'lru' then index = KEYS[4] end
+
+local target = tonumber(ARGV[1])
+local total = tonumber(redis.call('GET', KEYS[2]) or '0')
+local result = {}
+while total > target do
+  local batch = redis.call('ZRANGE', index, 0, tonumber(ARGV[4]) - 1)
+  if #batch == 0 then break end
+  for _, key in ipairs(batch) do
+    redis.call('DEL', key, ARGV[5] .. key)
+    total = forget(key)
+    table.insert(result, key)
+    if total <= target then break end
+  end
+end
+table.insert(result, 1, total)
+return result
+`)
+
+var memoryLimitKeys = []string{redisKeyEntrySizes, redisKeyTotalBytes, redisKeyExpiresIndex, redisKeyLastReadIndex}
+
+// EnableMemoryLimit caps the total size of cache entries at maxBytes
+// (approximate, tracked in Redis and shared by all instances). When a write
+// exceeds the cap, entries are deleted in policy order until the cache is
+// back under 90% of it, so a runaway crawl cannot evict other tenants' data
+// from a shared Redis. Must be called before the manager is used.
+func (m *Manager) EnableMemoryLimit(maxBytes int64, policy EvictionPolicy) {
+	if maxBytes <= 0 {
+		m.limit = nil
+		return
+	}
+	if policy == "" {
+		policy = EvictSoonestExpiring
+	}
+	m.limit = &memoryLimit{maxBytes: maxBytes, policy: policy}
+}
+
+// trackSize records a written entry and trims the cache if it is over the limit.
+func (m *Manager) trackSize(ctx context.Context, cacheKey string, size int, expires time.Time) error {
+	total, err := trackScript.Run(ctx, m.redis, memoryLimitKeys, cacheKey, size, expires.Unix(), time.Now().UnixMilli()).Int64()
+	if err != nil {
+		return fmt.Errorf("track cache size: %w", err)
+	}
+	CacheTrackedBytes.Set(float64(total))
+
+	if total <= m.limit.maxBytes {
+		return nil
+	}
+	return m.trim(ctx)
+}
+
+// trim deletes entries until the cache is below the trim target.
+func (m *Manager) trim(ctx context.Context) error {
+	target := int64(float64(m.limit.maxBytes) * trimTargetRatio)
+	now := time.Now().Unix()
+
+	result, err := trimScript.Run(ctx, m.redis, memoryLimitKeys, target, now, string(m.limit.policy), trimBatchSize, RedisKeyBodyPrefix).Slice()
+	if err != nil {
+		return fmt.Errorf("trim cache: %w", err)
+	}
+	if len(result) == 0 {
+		return nil
+	}
+
+	if total, ok := result[0].(int64); ok {
+		CacheTrackedBytes.Set(float64(total))
+	}
+	for _, item := range result[1:] {
+		key, ok := item.(string)
+		if !ok {
+			continue
+		}
+		CacheEvictions.WithLabelValues(string(m.limit.policy)).Inc()
+		m.invalidateDecoded(key)
+		m.markWritten(key)
+		m.recordRemoval(key, false)
+	}
+	return nil
+}
+
+// touch records a read for least-recently-read eviction.
+func (m *Manager) touch(ctx context.Context, cacheKey string) {
+	if m.limit == nil || m.limit.policy != EvictLeastRecentlyRead {
+		return
+	}
+	_ = m.redis.ZAddXX(ctx, redisKeyLastReadIndex, redis.Z{Score: float64(time.Now().UnixMilli()), Member: cacheKey}).Err()
+}
+
+// forgetSize removes a deleted entry from the bookkeeping.
+func (m *Manager) forgetSize(ctx context.Context, cacheKey string) {
+	if m.limit == nil {
+		return
+	}
+	if total, err := forgetScript.Run(ctx, m.redis, memoryLimitKeys, cacheKey).Int64(); err == nil {
+		CacheTrackedBytes.Set(float64(total))
+	}
+}
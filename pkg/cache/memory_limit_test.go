@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestManager_MemoryLimit(t *testing.T) {
+	payload := []byte(strings.Repeat("x", 1000))
+
+	tests := []struct {
+		name    string
+		policy  EvictionPolicy
+		read    int // entry read after writing, protected under LRU
+		evicted []int
+	}{
+		{"soonest expiring first", EvictSoonestExpiring, 0, []int{0, 1}},
+		{"least recently read first", EvictLeastRecentlyRead, 0, []int{1, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := setupTestRedis(t)
+			manager := NewManager(client)
+			ctx := context.Background()
+
+			keys := make([]CacheKey, 5)
+			for i := range keys {
+				keys[i] = CacheKey{Endpoint: fmt.Sprintf("/v3/universe/types/%d/", i)}
+			}
+
+			// Measure the serialized size of one entry
+			manager.EnableMemoryLimit(1<<30, tt.policy)
+			if err := manager.Set(ctx, keys[0], &CacheEntry{Data: payload, Expires: time.Now().Add(time.Hour), StatusCode: http.StatusOK}); err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+			entrySize := client.StrLen(ctx, keys[0].String()).Val()
+
+			// Room for four entries; the fifth write trims back to 90% (three entries)
+			manager.EnableMemoryLimit(entrySize*44/10, tt.policy)
+			for i := 1; i < 4; i++ {
+				// Later entries expire later
+				entry := &CacheEntry{Data: payload, Expires: time.Now().Add(time.Duration(i+1) * time.Hour), StatusCode: http.StatusOK}
+				if err := manager.Set(ctx, keys[i], entry); err != nil {
+					t.Fatalf("Set() error = %v", err)
+				}
+				// Distinct read timestamps (millisecond resolution)
+				time.Sleep(2 * time.Millisecond)
+			}
+			if _, err := manager.Get(ctx, keys[tt.read]); err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			entry := &CacheEntry{Data: payload, Expires: time.Now().Add(10 * time.Hour), StatusCode: http.StatusOK}
+			if err := manager.Set(ctx, keys[4], entry); err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+
+			var evicted []int
+			for i, key := range keys {
+				if client.Exists(ctx, key.String()).Val() == 0 {
+					evicted = append(evicted, i)
+				}
+			}
+			if fmt.Sprint(evicted) != fmt.Sprint(tt.evicted) {
+				t.Errorf("evicted entries = %v, want %v", evicted, tt.evicted)
+			}
+
+			var remaining int64
+			for _, key := range keys {
+				remaining += client.StrLen(ctx, key.String()).Val()
+			}
+			if total, _ := client.Get(ctx, redisKeyTotalBytes).Int64(); total != remaining {
+				t.Errorf("tracked bytes = %d, want %d", total, remaining)
+			}
+		})
+	}
+}
+
+func TestManager_MemoryLimit_DeleteForgetsSize(t *testing.T) {
+	client := setupTestRedis(t)
+	manager := NewManager(client)
+	manager.EnableMemoryLimit(1<<20, EvictSoonestExpiring)
+	ctx := context.Background()
+
+	key := CacheKey{Endpoint: "/v3/universe/types/34/"}
+	if err := manager.Set(ctx, key, &CacheEntry{Data: []byte(`{}`), Expires: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := manager.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	if total, _ := client.Get(ctx, redisKeyTotalBytes).Int64(); total != 0 {
+		t.Errorf("tracked bytes after Delete = %d, want 0", total)
+	}
+	if n := client.HLen(ctx, redisKeyEntrySizes).Val(); n != 0 {
+		t.Errorf("size entries after Delete = %d, want 0", n)
+	}
+}
@@ -0,0 +1,172 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// defaultFamilyDepth is the number of endpoint path segments forming a
+	// family, e.g. "/v1/markets/".
+	defaultFamilyDepth = 2
+
+	// memoryUsageBatch is the number of MEMORY USAGE calls per round trip.
+	memoryUsageBatch = 100
+)
+
+// MemoryUsageOptions configures MemoryUsage.
+type MemoryUsageOptions struct {
+	// Depth is the number of endpoint path segments that form a family,
+	// e.g. 2 for "/v1/markets/", 3 for "/v1/markets/10000002/" (default: 2).
+	Depth int
+
+	// SampleEvery measures every Nth key of a family and extrapolates to
+	// all of its keys, for large caches (default: 1, measure every key).
+	SampleEvery int
+}
+
+// FamilyUsage is the Redis memory used by the cache entries of one endpoint
+// family.
+type FamilyUsage struct {
+	Family  string // Endpoint prefix, e.g. "/v1/markets/"; tenant entries as "tenant=name:/v1/markets/"
+	Keys    int    // Cache entries of the family
+	Sampled int    // Entries measured with MEMORY USAGE
+	Bytes   int64  // Estimated bytes of all entries, including Redis overhead
+}
+
+// MemoryUsage reports the Redis memory used by cache entries whose endpoint
+// starts with prefix (e.g. "/v1/markets/", empty for all), grouped by
+// endpoint family and sorted by size, largest first. Sizes come from Redis
+// MEMORY USAGE and include key and allocator overhead, so they show where
+// compression or shorter TTLs would pay off. It scans the keyspace; meant
+// for operators, not for frequent polling.
+func (m *Manager) MemoryUsage(ctx context.Context, prefix string, opts MemoryUsageOptions) ([]FamilyUsage, error) {
+	if opts.Depth <= 0 {
+		opts.Depth = defaultFamilyDepth
+	}
+	if opts.SampleEvery <= 0 {
+		opts.SampleEvery = 1
+	}
+
+	families := make(map[string]*FamilyUsage)
+	sampledBytes := make(map[string]int64)
+	var batch []string
+	measure := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		sizes, err := m.memoryUsage(ctx, batch)
+		if err != nil {
+			return err
+		}
+		for i, key := range batch {
+			if sizes[i] < 0 {
+				continue // expired meanwhile
+			}
+			family := keyFamily(key, opts.Depth)
+			families[family].Sampled++
+			sampledBytes[family] += sizes[i]
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	iter := m.redis.Scan(ctx, 0, "esi:"+strings.TrimLeft(prefix, "/")+"*", manifestScanCount).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		if !isCacheKey(key) {
+			continue
+		}
+
+		family := keyFamily(key, opts.Depth)
+		usage, ok := families[family]
+		if !ok {
+			usage = &FamilyUsage{Family: family}
+			families[family] = usage
+		}
+		usage.Keys++
+		if (usage.Keys-1)%opts.SampleEvery != 0 {
+			continue
+		}
+
+		batch = append(batch, key)
+		if len(batch) == memoryUsageBatch {
+			if err := measure(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("redis scan: %w", err)
+	}
+	if err := measure(); err != nil {
+		return nil, err
+	}
+
+	result := make([]FamilyUsage, 0, len(families))
+	for family, usage := range families {
+		if usage.Sampled > 0 {
+			usage.Bytes = sampledBytes[family] * int64(usage.Keys) / int64(usage.Sampled)
+		}
+		result = append(result, *usage)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Bytes != result[j].Bytes {
+			return result[i].Bytes > result[j].Bytes
+		}
+		return result[i].Family < result[j].Family
+	})
+	return result, nil
+}
+
+// memoryUsage returns the MEMORY USAGE of keys, including bodies stored
+// split, in one round trip, -1 for keys that no longer exist.
+func (m *Manager) memoryUsage(ctx context.Context, keys []string) ([]int64, error) {
+	pipe := m.redis.Pipeline()
+	cmds := make([]*redis.IntCmd, len(keys))
+	bodies := make([]*redis.IntCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.MemoryUsage(ctx, key)
+		bodies[i] = pipe.MemoryUsage(ctx, bodyKey(key))
+	}
+	// Missing keys reply nil; handled per command below
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("redis memory usage: %w", err)
+	}
+
+	sizes := make([]int64, len(keys))
+	for i, cmd := range cmds {
+		size, err := cmd.Result()
+		if err != nil {
+			size = -1
+		} else if body, err := bodies[i].Result(); err == nil {
+			size += body
+		}
+		sizes[i] = size
+	}
+	return sizes, nil
+}
+
+// keyFamily returns the endpoint family of a cache key: the first depth
+// segments of its endpoint, prefixed by the tenant scope if any.
+func keyFamily(key string, depth int) string {
+	rest := strings.TrimPrefix(key, "esi:")
+	scope := ""
+	if strings.HasPrefix(rest, "tenant=") {
+		var tenant string
+		tenant, rest, _ = strings.Cut(rest, ":")
+		scope = tenant + ":"
+	}
+	endpoint, _, _ := strings.Cut(rest, ":")
+
+	segments := strings.Split(strings.Trim(endpoint, "/"), "/")
+	if len(segments) > depth {
+		segments = segments[:depth]
+	}
+	return scope + "/" + strings.Join(segments, "/") + "/"
+}
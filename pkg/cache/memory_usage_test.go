@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestKeyFamily(t *testing.T) {
+	tests := []struct {
+		key   string
+		depth int
+		want  string
+	}{
+		{"esi:v1/markets/10000002/orders:order_type=all", 2, "/v1/markets/"},
+		{"esi:v1/markets/10000002/orders:order_type=all", 3, "/v1/markets/10000002/"},
+		{"esi:v1/status", 2, "/v1/status/"},
+		{"esi:v1/status", 5, "/v1/status/"},
+		{"esi:v1/universe/names:POST:body=abc", 2, "/v1/universe/"},
+		{"esi:tenant=acme:v1/markets/prices", 2, "tenant=acme:/v1/markets/"},
+	}
+	for _, tt := range tests {
+		if got := keyFamily(tt.key, tt.depth); got != tt.want {
+			t.Errorf("keyFamily(%q, %d) = %q, want %q", tt.key, tt.depth, got, tt.want)
+		}
+	}
+}
+
+func TestManager_MemoryUsage(t *testing.T) {
+	client := setupTestRedis(t)
+	manager := NewManager(client)
+	ctx := context.Background()
+
+	set := func(endpoint string, size int) {
+		t.Helper()
+		entry := &CacheEntry{
+			Data:       make([]byte, size),
+			Expires:    time.Now().Add(time.Hour),
+			StatusCode: http.StatusOK,
+		}
+		if err := manager.Set(ctx, CacheKey{Endpoint: endpoint}, entry); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+	}
+	set("/v1/markets/10000002/orders/", 64*1024)
+	set("/v1/markets/10000043/orders/", 64*1024)
+	set("/v3/universe/types/34/", 100)
+	if err := client.Set(ctx, "esi:rate_limit:errors_remaining", 100, 0).Err(); err != nil {
+		t.Fatalf("redis set: %v", err)
+	}
+
+	usage, err := manager.MemoryUsage(ctx, "", MemoryUsageOptions{})
+	if err != nil {
+		t.Fatalf("MemoryUsage() error = %v", err)
+	}
+	if len(usage) != 2 {
+		t.Fatalf("MemoryUsage() returned %d families, want 2: %+v", len(usage), usage)
+	}
+	if usage[0].Family != "/v1/markets/" || usage[0].Keys != 2 || usage[0].Sampled != 2 {
+		t.Errorf("largest family = %+v, want /v1/markets/ with 2 keys sampled", usage[0])
+	}
+	if usage[0].Bytes < 2*64*1024 || usage[0].Bytes <= usage[1].Bytes {
+		t.Errorf("Bytes = %d, %d, want at least the body sizes, largest first", usage[0].Bytes, usage[1].Bytes)
+	}
+
+	// Sampling every second key extrapolates to all keys of the family
+	usage, err = manager.MemoryUsage(ctx, "/v1/markets/", MemoryUsageOptions{SampleEvery: 2})
+	if err != nil {
+		t.Fatalf("MemoryUsage() error = %v", err)
+	}
+	if len(usage) != 1 || usage[0].Keys != 2 || usage[0].Sampled != 1 || usage[0].Bytes < 2*64*1024 {
+		t.Errorf("sampled MemoryUsage() = %+v, want 2 keys, 1 sampled, extrapolated bytes", usage)
+	}
+}
@@ -6,13 +6,13 @@ import (
 )
 
 var (
-	// CacheHits tracks cache hits by layer (redis)
+	// CacheHits tracks cache hits by layer (redis, memory)
 	CacheHits = promauto.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "esi_cache_hits_total",
 			Help: "Total number of ESI cache hits",
 		},
-		[]string{"layer"}, // "redis"
+		[]string{"layer"}, // "redis", "memory"
 	)
 
 	// CacheMisses tracks cache misses
@@ -48,6 +48,15 @@ var (
 		},
 	)
 
+	// ETagAliasConfirmations tracks 304 responses that confirmed an ETag
+	// alias instead of the entry's ETag (see InheritETagAliases)
+	ETagAliasConfirmations = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "esi_etag_alias_confirmations_total",
+			Help: "Total number of 304 responses confirming an earlier ETag of the cached body",
+		},
+	)
+
 	// CacheErrors tracks cache operation errors
 	CacheErrors = promauto.NewCounterVec(
 		prometheus.CounterOpts{
@@ -56,4 +65,77 @@ var (
 		},
 		[]string{"operation"}, // "get", "set", "delete"
 	)
+
+	// CacheCorruption tracks entries dropped because they failed to decode
+	// or their checksum did not match
+	CacheCorruption = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "esi_cache_corruption_total",
+			Help: "Total number of corrupted ESI cache entries detected and deleted",
+		},
+	)
+
+	// CacheMigrations tracks entries upgraded from an older envelope version
+	CacheMigrations = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "esi_cache_migrations_total",
+			Help: "Total number of cache entries migrated from an older envelope version",
+		},
+		[]string{"from_version"},
+	)
+
+	// CacheKeyMigrations tracks entries moved from a legacy key format
+	CacheKeyMigrations = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "esi_cache_key_migrations_total",
+			Help: "Total number of cache entries moved from a legacy key format by migration and mode (read, scan)",
+		},
+		[]string{"migration", "mode"},
+	)
+
+	// CacheEntries tracks the number of live entries written by this process
+	// (only maintained while WatchExpirations runs)
+	CacheEntries = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "esi_cache_entries",
+			Help: "Current number of ESI cache entries written by this instance",
+		},
+		[]string{"layer"}, // "redis"
+	)
+
+	// CacheExpirations tracks entries expired by Redis per endpoint pattern
+	CacheExpirations = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "esi_cache_expirations_total",
+			Help: "Total number of ESI cache entries expired by Redis",
+		},
+		[]string{"endpoint"},
+	)
+
+	// CacheTrackedBytes tracks the cache size as accounted by the memory limit
+	CacheTrackedBytes = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "esi_cache_tracked_bytes",
+			Help: "Approximate total size of ESI cache entries tracked for the memory limit",
+		},
+	)
+
+	// CacheReplicaReads tracks cache reads with a read replica configured by
+	// where they were served from
+	CacheReplicaReads = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "esi_cache_replica_reads_total",
+			Help: "Total number of ESI cache reads with a read replica by result (replica, recent_write, miss_fallback, error_fallback)",
+		},
+		[]string{"result"},
+	)
+
+	// CacheEvictions tracks entries deleted to stay under the memory limit
+	CacheEvictions = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "esi_cache_evictions_total",
+			Help: "Total number of ESI cache entries evicted to stay under the memory limit",
+		},
+		[]string{"policy"}, // "expires", "lru"
+	)
 )
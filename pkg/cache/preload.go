@@ -0,0 +1,119 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// defaultPreloadTTL is used when PreloadOptions.TTL is unset.
+const defaultPreloadTTL = 15 * time.Minute
+
+// DumpRecord is one line of a cache dump file (JSON lines). Body holds the
+// response body as JSON, Expires the expiry ESI announced for it.
+type DumpRecord struct {
+	Key     string          `json:"key"`
+	Headers http.Header     `json:"headers,omitempty"`
+	Body    json.RawMessage `json:"body"`
+	Expires time.Time       `json:"expires"`
+}
+
+// PreloadOptions configures Preload.
+type PreloadOptions struct {
+	// TTL is the synthetic lifetime of preloaded entries (default: 15m).
+	// Requests revalidate them with ESI in the meantime, so the TTL only
+	// bounds how long an unconfirmed dump can be served.
+	TTL time.Duration
+
+	// MaxAge skips records whose original Expires is further in the past
+	// (0 = load all). Keeps a forgotten dump from warming with week-old data.
+	MaxAge time.Duration
+
+	// Overwrite replaces existing entries. By default entries already in the
+	// cache are newer than the dump and kept.
+	Overwrite bool
+}
+
+// PreloadResult summarizes what Preload wrote.
+type PreloadResult struct {
+	Loaded  int // Entries written
+	Skipped int // Records skipped (too old, not a cache key, already cached)
+}
+
+// Preload imports a cache dump (JSON lines of DumpRecord) so a new
+// environment starts warm without fetching everything from ESI. Preloaded
+// entries expire after opts.TTL and are flagged as Preloaded: their data is
+// of unknown age, so it is served as stale until ESI confirms it with a 304
+// or replaces it. Reading stops at the first malformed line.
+func (m *Manager) Preload(ctx context.Context, r io.Reader, opts PreloadOptions) (PreloadResult, error) {
+	var result PreloadResult
+
+	ttl := opts.TTL
+	if ttl <= 0 {
+		ttl = defaultPreloadTTL
+	}
+
+	dec := json.NewDecoder(r)
+	for line := 1; ; line++ {
+		var record DumpRecord
+		if err := dec.Decode(&record); errors.Is(err, io.EOF) {
+			return result, nil
+		} else if err != nil {
+			return result, fmt.Errorf("decode dump record %d: %w", line, err)
+		}
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		now := time.Now()
+		if !isCacheKey(record.Key) || (opts.MaxAge > 0 && record.Expires.Before(now.Add(-opts.MaxAge))) {
+			result.Skipped++
+			continue
+		}
+
+		if record.Headers == nil {
+			record.Headers = http.Header{}
+		}
+		entry := &CacheEntry{
+			Data:       record.Body,
+			ETag:       record.Headers.Get("ETag"),
+			Expires:    now.Add(ttl),
+			StatusCode: http.StatusOK,
+			Headers:    record.Headers,
+			CachedAt:   now,
+			Preloaded:  true,
+		}
+		if lastModified, err := http.ParseTime(record.Headers.Get("Last-Modified")); err == nil {
+			entry.LastModified = lastModified
+		}
+
+		written, err := m.store(ctx, record.Key, endpointFromKey(record.Key), entry, !opts.Overwrite)
+		if err != nil {
+			return result, fmt.Errorf("preload %s: %w", record.Key, err)
+		}
+		if written {
+			result.Loaded++
+		} else {
+			result.Skipped++
+		}
+	}
+}
+
+// isCacheKey reports whether key has the format of CacheKey.String(), which
+// rules out the client's other "esi:" keys (rate limit, quotas, journal).
+func isCacheKey(key string) bool {
+	rest, ok := strings.CutPrefix(key, "esi:")
+	if !ok {
+		return false
+	}
+	if strings.HasPrefix(rest, "tenant=") {
+		_, rest, _ = strings.Cut(rest, ":")
+	}
+	endpoint, _, _ := strings.Cut(rest, ":")
+	return strings.Contains(endpoint, "/")
+}
@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestManager_Preload(t *testing.T) {
+	client := setupTestRedis(t)
+	manager := NewManager(client)
+	ctx := context.Background()
+
+	existing := CacheKey{Endpoint: "/v1/markets/prices/"}
+	if err := manager.Set(ctx, existing, &CacheEntry{Data: []byte(`"live"`), Expires: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	orders := CacheKey{Endpoint: "/v1/markets/10000002/orders/", QueryParams: url.Values{"page": {"1"}}}
+	expired := time.Now().Add(-12 * time.Hour).UTC().Format(time.RFC3339)
+	dump := strings.Join([]string{
+		fmt.Sprintf(`{"key":%q,"headers":{"Etag":["\"v1\""],"X-Pages":["3"]},"body":[{"order_id":1}],"expires":%q}`, orders.String(), expired),
+		fmt.Sprintf(`{"key":%q,"body":"dump","expires":%q}`, existing.String(), expired),
+		fmt.Sprintf(`{"key":"esi:v1/universe/types/","body":[],"expires":%q}`, time.Now().Add(-30*24*time.Hour).UTC().Format(time.RFC3339)),
+		fmt.Sprintf(`{"key":"esi:rate_limit:errors_remaining","body":1,"expires":%q}`, expired),
+	}, "\n")
+
+	result, err := manager.Preload(ctx, strings.NewReader(dump), PreloadOptions{TTL: time.Minute, MaxAge: 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("Preload() error = %v", err)
+	}
+	if result.Loaded != 1 || result.Skipped != 3 {
+		t.Errorf("Preload() = %+v, want 1 loaded (existing, too old and non-cache records skipped)", result)
+	}
+
+	entry, err := manager.Get(ctx, orders)
+	if err != nil {
+		t.Fatalf("Get() preloaded entry error = %v", err)
+	}
+	if !entry.Preloaded || !entry.IsStale() {
+		t.Error("preloaded entry not flagged as stale")
+	}
+	if string(entry.Data) != `[{"order_id":1}]` || entry.ETag != `"v1"` || entry.Headers.Get("X-Pages") != "3" {
+		t.Errorf("preloaded entry = %+v", entry)
+	}
+	if ttl := entry.TTL(); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("TTL() = %s, want synthetic TTL <= 1m", ttl)
+	}
+
+	if live, err := manager.Get(ctx, existing); err != nil || string(live.Data) != `"live"` {
+		t.Errorf("existing entry = %v, %v; want kept", live, err)
+	}
+
+	// ESI confirming the data clears the flag
+	if err := manager.UpdateTTL(ctx, orders, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("UpdateTTL() error = %v", err)
+	}
+	if entry, _ := manager.Get(ctx, orders); entry == nil || entry.IsStale() {
+		t.Errorf("revalidated entry = %+v, want not stale", entry)
+	}
+}
+
+func TestManager_Preload_Overwrite(t *testing.T) {
+	manager := NewManager(setupTestRedis(t))
+	ctx := context.Background()
+
+	key := CacheKey{Endpoint: "/v1/markets/prices/"}
+	if err := manager.Set(ctx, key, &CacheEntry{Data: []byte(`"live"`), Expires: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	dump := fmt.Sprintf(`{"key":%q,"body":"dump","expires":"2024-01-01T00:00:00Z"}`, key.String())
+	if _, err := manager.Preload(ctx, strings.NewReader(dump), PreloadOptions{Overwrite: true}); err != nil {
+		t.Fatalf("Preload() error = %v", err)
+	}
+
+	if entry, err := manager.Get(ctx, key); err != nil || string(entry.Data) != `"dump"` {
+		t.Errorf("entry = %v, %v; want overwritten by the dump", entry, err)
+	}
+}
+
+func TestManager_Preload_Malformed(t *testing.T) {
+	manager := NewManager(setupTestRedis(t))
+
+	dump := `{"key":"esi:v1/status/","body":{},"expires":"2024-01-01T00:00:00Z"}` + "\n{not json"
+	result, err := manager.Preload(context.Background(), strings.NewReader(dump), PreloadOptions{})
+	if err == nil || !strings.Contains(err.Error(), "record 2") {
+		t.Errorf("Preload() error = %v, want decode error for record 2", err)
+	}
+	if result.Loaded != 1 {
+		t.Errorf("Loaded = %d, want 1 (records before the error are kept)", result.Loaded)
+	}
+}
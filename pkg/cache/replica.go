@@ -0,0 +1,128 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultReplicaMaxLag is the read-your-writes window used when
+// ReplicaOptions.MaxLag is unset.
+const DefaultReplicaMaxLag = time.Second
+
+// ReplicaOptions configures reads from a Redis replica (see SetReadReplica).
+type ReplicaOptions struct {
+	// MaxLag is the replication lag tolerated: for this long after the
+	// manager wrote or deleted a key, reads of it go to the primary, so a
+	// write is never followed by a stale read from this instance
+	// (default: DefaultReplicaMaxLag). Writes of other instances may still be
+	// read stale for the actual lag of the replica.
+	MaxLag time.Duration
+
+	// MissFallback retries misses of the replica on the primary, so entries
+	// written by other instances are found before they have replicated, at
+	// the cost of a second round trip for every real miss.
+	MissFallback bool
+}
+
+// readReplica routes cache reads to a replica of the primary Redis.
+type readReplica struct {
+	client       *redis.Client
+	maxLag       time.Duration
+	missFallback bool
+
+	mu        sync.Mutex
+	written   map[string]time.Time // Cache key -> last write or delete by this manager
+	lastSweep time.Time
+}
+
+// replicaSweepSize is the number of tracked writes from which expired ones
+// are swept.
+const replicaSweepSize = 1024
+
+// SetReadReplica sends the reads of Get, GetStale, GetMeta and the validator
+// index to replica, while writes, deletes and maintenance (scans, manifests,
+// migrations) stay on the primary. Replica errors fall back to the primary.
+// A nil replica reads from the primary. Must be called before the manager is
+// used.
+func (m *Manager) SetReadReplica(replica *redis.Client, opts ReplicaOptions) {
+	if replica == nil {
+		m.replica = nil
+		return
+	}
+	if opts.MaxLag <= 0 {
+		opts.MaxLag = DefaultReplicaMaxLag
+	}
+	m.replica = &readReplica{
+		client:       replica,
+		maxLag:       opts.MaxLag,
+		missFallback: opts.MissFallback,
+		written:      make(map[string]time.Time),
+	}
+}
+
+// markWritten records a write or delete of cacheKey by this manager.
+func (r *readReplica) markWritten(cacheKey string) {
+	now := time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.written[cacheKey] = now
+	if len(r.written) >= replicaSweepSize && now.Sub(r.lastSweep) >= r.maxLag {
+		for key, at := range r.written {
+			if now.Sub(at) >= r.maxLag {
+				delete(r.written, key)
+			}
+		}
+		r.lastSweep = now
+	}
+}
+
+// recentlyWritten reports whether cacheKey was written or deleted within
+// the tolerated lag.
+func (r *readReplica) recentlyWritten(cacheKey string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	at, ok := r.written[cacheKey]
+	return ok && time.Since(at) < r.maxLag
+}
+
+// markWritten records a write or delete of cacheKey for replica routing.
+func (m *Manager) markWritten(cacheKey string) {
+	if m.replica != nil {
+		m.replica.markWritten(cacheKey)
+	}
+}
+
+// read GETs the envelope of cacheKey from the replica if the entry was not
+// written recently, else from the primary. It returns the Redis the
+// envelope was read from, so a body stored split is read from the same one.
+func (m *Manager) read(ctx context.Context, cacheKey string) ([]byte, *redis.Client, error) {
+	if m.replica == nil {
+		data, err := m.redis.Get(ctx, cacheKey).Bytes()
+		return data, m.redis, err
+	}
+	if m.replica.recentlyWritten(cacheKey) {
+		CacheReplicaReads.WithLabelValues("recent_write").Inc()
+		data, err := m.redis.Get(ctx, cacheKey).Bytes()
+		return data, m.redis, err
+	}
+
+	data, err := m.replica.client.Get(ctx, cacheKey).Bytes()
+	switch {
+	case err == nil:
+		CacheReplicaReads.WithLabelValues("replica").Inc()
+		return data, m.replica.client, nil
+	case err == redis.Nil && !m.replica.missFallback:
+		CacheReplicaReads.WithLabelValues("replica").Inc()
+		return nil, m.replica.client, err
+	case err == redis.Nil:
+		CacheReplicaReads.WithLabelValues("miss_fallback").Inc()
+	default:
+		CacheReplicaReads.WithLabelValues("error_fallback").Inc()
+	}
+	data, err = m.redis.Get(ctx, cacheKey).Bytes()
+	return data, m.redis, err
+}
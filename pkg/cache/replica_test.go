@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestReadReplica_RecentlyWritten(t *testing.T) {
+	r := &readReplica{maxLag: 50 * time.Millisecond, written: make(map[string]time.Time)}
+	if r.recentlyWritten("esi:status") {
+		t.Error("recentlyWritten() = true before any write")
+	}
+
+	r.markWritten("esi:status")
+	if !r.recentlyWritten("esi:status") {
+		t.Error("recentlyWritten() = false right after a write")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if r.recentlyWritten("esi:status") {
+		t.Error("recentlyWritten() = true after MaxLag")
+	}
+}
+
+func TestReadReplica_Sweep(t *testing.T) {
+	r := &readReplica{maxLag: time.Millisecond, written: make(map[string]time.Time)}
+	old := time.Now().Add(-time.Second)
+	for i := range replicaSweepSize {
+		r.written[fmt.Sprintf("esi:v1/universe/types/%d", i)] = old
+	}
+
+	r.markWritten("esi:status")
+	if len(r.written) != 1 {
+		t.Errorf("%d tracked writes after sweep, want 1", len(r.written))
+	}
+}
+
+func TestSetReadReplica_Defaults(t *testing.T) {
+	m := &Manager{}
+	m.SetReadReplica(redis.NewClient(&redis.Options{Addr: "localhost:0"}), ReplicaOptions{})
+	if m.replica == nil || m.replica.maxLag != DefaultReplicaMaxLag {
+		t.Fatalf("replica = %+v, want MaxLag %v", m.replica, DefaultReplicaMaxLag)
+	}
+	m.replica.client.Close()
+
+	m.SetReadReplica(nil, ReplicaOptions{})
+	if m.replica != nil {
+		t.Error("SetReadReplica(nil) kept the replica")
+	}
+}
+
+func TestManager_ReadReplica(t *testing.T) {
+	primary := setupTestRedis(t)
+	ctx := context.Background()
+
+	// Another DB stands in for a replica that has not caught up
+	replicaOpts := *primary.Options()
+	replicaOpts.DB = 14
+	replica := redis.NewClient(&replicaOpts)
+	defer replica.Close()
+	replica.FlushDB(ctx)
+	defer replica.FlushDB(context.Background())
+
+	m := NewManager(primary)
+	m.SetReadReplica(replica, ReplicaOptions{MaxLag: 50 * time.Millisecond})
+	key := CacheKey{Endpoint: "/v1/status/"}
+	entry := &CacheEntry{Data: []byte(`{"players":1}`), Expires: time.Now().Add(time.Minute), CachedAt: time.Now(), StatusCode: http.StatusOK}
+	if err := m.Set(ctx, key, entry); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	// Own writes are read from the primary within MaxLag
+	if _, err := m.Get(ctx, key); err != nil {
+		t.Errorf("Get() right after Set error = %v", err)
+	}
+
+	// Afterwards reads go to the replica, which misses
+	time.Sleep(60 * time.Millisecond)
+	if _, err := m.Get(ctx, key); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Get() from lagging replica error = %v, want ErrCacheMiss", err)
+	}
+
+	// ... unless misses fall back to the primary
+	m.SetReadReplica(replica, ReplicaOptions{MaxLag: 50 * time.Millisecond, MissFallback: true})
+	if _, err := m.Get(ctx, key); err != nil {
+		t.Errorf("Get() with MissFallback error = %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ReplicateOptions configures Replicate.
+type ReplicateOptions struct {
+	// EndpointPrefixes selects entries by endpoint, e.g. "/v1/markets/".
+	// Empty copies all cache entries.
+	EndpointPrefixes []string
+
+	// MinTTL skips entries expiring sooner than this; they would be gone
+	// before a failover could use them (default: 0, copy everything live).
+	MinTTL time.Duration
+
+	// BatchSize is the number of keys read and written per round trip (default: 100).
+	BatchSize int
+}
+
+// ReplicateResult summarizes a Replicate run.
+type ReplicateResult struct {
+	Copied  int   // Entries written to the target
+	Skipped int   // Entries expired or below MinTTL
+	Bytes   int64 // Total size of copied entries
+}
+
+// ReplicateTo copies cache entries to dst with their remaining TTL, e.g. to
+// keep a warm standby before a regional failover so the switch does not
+// trigger an ESI request storm. Entries are copied as stored (version,
+// checksum and codec unchanged); memory limit bookkeeping in dst is not
+// updated. Bodies stored split are copied along with their envelope. Like
+// ExportManifest, non-cache keys sharing the "esi:" prefix are skipped.
+func (m *Manager) ReplicateTo(ctx context.Context, dst *redis.Client, opts ReplicateOptions) (ReplicateResult, error) {
+	var result ReplicateResult
+
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+
+	patterns := []string{"esi:*"}
+	if len(opts.EndpointPrefixes) > 0 {
+		patterns = patterns[:0]
+		for _, prefix := range opts.EndpointPrefixes {
+			patterns = append(patterns, "esi:"+strings.TrimLeft(prefix, "/")+"*")
+		}
+	}
+
+	for _, pattern := range patterns {
+		var batch []string
+		iter := m.redis.Scan(ctx, 0, pattern, int64(opts.BatchSize)).Iterator()
+		for iter.Next(ctx) {
+			key := iter.Val()
+			if strings.HasPrefix(key, RedisKeyMetaPrefix) || strings.HasPrefix(key, RedisKeyBodyPrefix) {
+				continue
+			}
+			batch = append(batch, key)
+			if len(batch) == opts.BatchSize {
+				if err := m.replicateBatch(ctx, dst, batch, opts.MinTTL, &result); err != nil {
+					return result, err
+				}
+				batch = batch[:0]
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return result, fmt.Errorf("redis scan: %w", err)
+		}
+		if err := m.replicateBatch(ctx, dst, batch, opts.MinTTL, &result); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// replicateBatch copies one batch of keys with their remaining TTL.
+func (m *Manager) replicateBatch(ctx context.Context, dst *redis.Client, keys []string, minTTL time.Duration, result *ReplicateResult) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	// Read values, bodies stored split and remaining TTLs in one round trip
+	read := m.redis.Pipeline()
+	values := make([]*redis.StringCmd, len(keys))
+	bodies := make([]*redis.StringCmd, len(keys))
+	ttls := make([]*redis.DurationCmd, len(keys))
+	for i, key := range keys {
+		values[i] = read.Get(ctx, key)
+		bodies[i] = read.Get(ctx, bodyKey(key))
+		ttls[i] = read.PTTL(ctx, key)
+	}
+	// Per-key replies (missing keys, WRONGTYPE) are handled below
+	var replyErr redis.Error
+	if _, err := read.Exec(ctx); err != nil && !errors.As(err, &replyErr) {
+		return fmt.Errorf("read batch: %w", err)
+	}
+
+	write := dst.Pipeline()
+	queued := 0
+	for i, key := range keys {
+		data, err := values[i].Bytes()
+		ttl := ttls[i].Val()
+		if err != nil {
+			continue // expired meanwhile or not a string
+		}
+
+		var entry CacheEntry
+		if err := m.codec.Unmarshal(data, &entry); err != nil || entry.Expires.IsZero() {
+			continue
+		}
+		if ttl <= 0 || ttl < minTTL {
+			result.Skipped++
+			continue
+		}
+
+		if entry.BodySize > 0 {
+			body, err := bodies[i].Bytes()
+			if err != nil {
+				continue // body expired or evicted
+			}
+			write.Set(ctx, bodyKey(key), body, ttl)
+			result.Bytes += int64(len(body))
+		}
+		write.Set(ctx, key, data, ttl)
+		result.Bytes += int64(len(data))
+		queued++
+	}
+	if queued == 0 {
+		return nil
+	}
+	if _, err := write.Exec(ctx); err != nil {
+		return fmt.Errorf("write batch: %w", err)
+	}
+	result.Copied += queued
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestManager_ReplicateTo(t *testing.T) {
+	client := setupTestRedis(t)
+	manager := NewManager(client)
+	ctx := context.Background()
+
+	dst := redis.NewClient(&redis.Options{Addr: "localhost:6379", DB: 14})
+	if err := dst.FlushDB(ctx).Err(); err != nil {
+		t.Fatalf("flush target: %v", err)
+	}
+	t.Cleanup(func() {
+		dst.FlushDB(context.Background())
+		dst.Close()
+	})
+
+	set := func(endpoint string, ttl time.Duration) CacheKey {
+		key := CacheKey{Endpoint: endpoint}
+		entry := &CacheEntry{
+			Data:       []byte(`{"ok":true}`),
+			Expires:    time.Now().Add(ttl),
+			StatusCode: http.StatusOK,
+		}
+		if err := manager.Set(ctx, key, entry); err != nil {
+			t.Fatalf("Set(%s) error = %v", endpoint, err)
+		}
+		return key
+	}
+	orders := set("/v1/markets/10000002/orders/", time.Hour)
+	short := set("/v1/markets/10000043/orders/", 2*time.Second)
+	other := set("/v4/characters/123/", time.Hour)
+	// Non-cache keys with the same prefix are ignored
+	if err := client.Set(ctx, "esi:rate_limit:errors_remaining", 100, time.Minute).Err(); err != nil {
+		t.Fatalf("redis set: %v", err)
+	}
+
+	result, err := manager.ReplicateTo(ctx, dst, ReplicateOptions{
+		EndpointPrefixes: []string{"/v1/markets/"},
+		MinTTL:           time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("ReplicateTo() error = %v", err)
+	}
+	if result.Copied != 1 || result.Skipped != 1 || result.Bytes == 0 {
+		t.Errorf("ReplicateTo() = %+v, want 1 copied, 1 skipped", result)
+	}
+
+	ttl, err := dst.PTTL(ctx, orders.String()).Result()
+	if err != nil {
+		t.Fatalf("target pttl: %v", err)
+	}
+	if ttl < 59*time.Minute || ttl > time.Hour {
+		t.Errorf("target TTL = %v, want about 1h", ttl)
+	}
+
+	got, err := NewManager(dst).Get(ctx, orders)
+	if err != nil {
+		t.Fatalf("Get() from target error = %v", err)
+	}
+	if string(got.Data) != `{"ok":true}` {
+		t.Errorf("Get() from target data = %s", got.Data)
+	}
+
+	for _, key := range []string{short.String(), other.String(), "esi:rate_limit:errors_remaining"} {
+		if n := dst.Exists(ctx, key).Val(); n != 0 {
+			t.Errorf("%s replicated, want skipped", key)
+		}
+	}
+
+	// Without prefixes all cache entries are copied
+	result, err = manager.ReplicateTo(ctx, dst, ReplicateOptions{BatchSize: 1})
+	if err != nil {
+		t.Fatalf("ReplicateTo() error = %v", err)
+	}
+	if result.Copied != 3 {
+		t.Errorf("ReplicateTo() copied = %d, want 3", result.Copied)
+	}
+}
@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisKeyBodyPrefix prefixes the body keys of entries stored split (see
+// EnableSplitStorage): the body of "esi:..." lives under
+// RedisKeyBodyPrefix+"esi:...". Cache scans skip these keys.
+const RedisKeyBodyPrefix = "esi:cache_body:"
+
+// bodyKey returns the key holding the separately stored body of cacheKey.
+func bodyKey(cacheKey string) string {
+	return RedisKeyBodyPrefix + cacheKey
+}
+
+// EnableSplitStorage stores bodies of at least minBodySize bytes under a
+// separate key, so metadata-only operations (GetMeta, UpdateTTL, manifests)
+// don't transfer them; entries with smaller bodies are stored whole to save
+// the second round trip on reads. minBodySize <= 0 stores all entries whole.
+// Split entries written by other instances are read either way. Must be
+// called before the manager is used.
+func (m *Manager) EnableSplitStorage(minBodySize int) {
+	m.splitAt = max(minBodySize, 0)
+}
+
+// GetMeta retrieves a cache entry like Get, but without its body: Data is
+// nil. Bodies stored split are not read from Redis at all, so checking the
+// validators or expiry of an entry doesn't transfer the body.
+func (m *Manager) GetMeta(ctx context.Context, key CacheKey) (*CacheEntry, error) {
+	cacheKey := key.String()
+	entry, _, _, err := m.getEnvelope(ctx, key, cacheKey)
+	if err != nil {
+		return nil, err
+	}
+	if entry.IsExpired() {
+		CacheMisses.Inc()
+		return nil, ErrCacheMiss
+	}
+
+	entry.Data = nil
+	entry.Headers = m.headers.Apply(entry.Headers)
+	m.indexValidators(cacheKey, entry)
+	return entry, nil
+}
+
+// loadBody reads the separately stored body of entry from src, the Redis
+// its envelope came from, into Data. A body evicted or expired before its
+// envelope is reported as ErrCacheMiss.
+func (m *Manager) loadBody(ctx context.Context, src *redis.Client, cacheKey string, entry *CacheEntry) error {
+	data, err := src.Get(ctx, bodyKey(cacheKey)).Bytes()
+	if err == redis.Nil {
+		return ErrCacheMiss
+	}
+	if err != nil {
+		return fmt.Errorf("redis get body: %w", err)
+	}
+	if len(data) != entry.BodySize {
+		return fmt.Errorf("%w: body size %d, want %d", ErrInvalidEntry, len(data), entry.BodySize)
+	}
+	entry.Data = data
+	return nil
+}
+
+// splitEnvelope returns the envelope to store for entry and the body to
+// store separately, nil if the entry is stored whole.
+func (m *Manager) splitEnvelope(entry *CacheEntry) (*CacheEntry, []byte) {
+	if m.splitAt <= 0 || len(entry.Data) < m.splitAt {
+		entry.BodySize = 0
+		return entry, nil
+	}
+	meta := *entry
+	meta.Data = nil
+	meta.BodySize = len(entry.Data)
+	return &meta, entry.Data
+}
+
+// updateSplitTTL rewrites the envelope of a split entry and moves the expiry
+// of its body along, without transferring the body.
+func (m *Manager) updateSplitTTL(ctx context.Context, key CacheKey, entry *CacheEntry) error {
+	cacheKey := key.String()
+	m.invalidateDecoded(cacheKey)
+
+	ttl := entry.TTL()
+	if ttl <= 0 {
+		return nil
+	}
+
+	entry.Headers = m.headers.Apply(entry.Headers)
+	entry.Version = EntryVersion
+	data, err := m.codec.Marshal(entry)
+	if err != nil {
+		CacheErrors.WithLabelValues("set").Inc()
+		return fmt.Errorf("marshal cache entry: %w", err)
+	}
+
+	m.markWritten(cacheKey)
+	var expire *redis.BoolCmd
+	if _, err := m.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, cacheKey, data, ttl+m.stale)
+		expire = pipe.PExpire(ctx, bodyKey(cacheKey), ttl+m.stale)
+		return nil
+	}); err != nil {
+		CacheErrors.WithLabelValues("set").Inc()
+		return fmt.Errorf("redis set: %w", err)
+	}
+	if !expire.Val() {
+		// Body gone meanwhile - drop the envelope too, so the next read refetches
+		return m.Delete(ctx, key)
+	}
+
+	size := len(data) + entry.BodySize
+	m.recordWrite(cacheKey, EndpointPattern(key.Endpoint), size)
+	m.indexValidators(cacheKey, entry)
+	if m.limit != nil {
+		if err := m.trackSize(ctx, cacheKey, size, entry.Expires); err != nil {
+			CacheErrors.WithLabelValues("set").Inc()
+			return err
+		}
+	}
+	return nil
+}
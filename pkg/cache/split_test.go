@@ -0,0 +1,115 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSplitEnvelope(t *testing.T) {
+	m := &Manager{splitAt: 4}
+
+	small := &CacheEntry{Data: []byte("abc"), BodySize: 7}
+	if envelope, body := m.splitEnvelope(small); envelope != small || body != nil || small.BodySize != 0 {
+		t.Errorf("small body split: envelope %p, body %q, BodySize %d", envelope, body, small.BodySize)
+	}
+
+	large := &CacheEntry{Data: []byte("abcdef"), ETag: `"x"`}
+	envelope, body := m.splitEnvelope(large)
+	if string(body) != "abcdef" || envelope.Data != nil || envelope.BodySize != 6 || envelope.ETag != `"x"` {
+		t.Errorf("large body: envelope %+v, body %q", envelope, body)
+	}
+	if string(large.Data) != "abcdef" {
+		t.Error("splitEnvelope() modified the entry's data")
+	}
+
+	m.splitAt = 0
+	if _, body := m.splitEnvelope(large); body != nil {
+		t.Error("body split with split storage off")
+	}
+}
+
+func TestManager_SplitStorage(t *testing.T) {
+	redisClient := setupTestRedis(t)
+	ctx := context.Background()
+
+	m := NewManager(redisClient)
+	m.EnableSplitStorage(16)
+	key := CacheKey{Endpoint: "/v1/markets/10000002/orders/"}
+	body := bytes.Repeat([]byte("x"), 64)
+	entry := &CacheEntry{Data: body, ETag: `"v1"`, Expires: time.Now().Add(time.Minute), CachedAt: time.Now(), StatusCode: http.StatusOK}
+	if err := m.Set(ctx, key, entry); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	stored, err := redisClient.Get(ctx, bodyKey(key.String())).Bytes()
+	if err != nil || !bytes.Equal(stored, body) {
+		t.Fatalf("body key = %q, %v", stored, err)
+	}
+
+	got, err := m.Get(ctx, key)
+	if err != nil || !bytes.Equal(got.Data, body) {
+		t.Fatalf("Get() = %v, %v", got, err)
+	}
+
+	meta, err := m.GetMeta(ctx, key)
+	if err != nil || meta.Data != nil || meta.BodySize != len(body) || meta.ETag != `"v1"` {
+		t.Fatalf("GetMeta() = %+v, %v", meta, err)
+	}
+
+	// UpdateTTL moves the body's expiry along without rewriting it
+	newExpires := time.Now().Add(time.Hour)
+	if err := m.UpdateTTL(ctx, key, newExpires); err != nil {
+		t.Fatalf("UpdateTTL() error = %v", err)
+	}
+	if ttl := redisClient.PTTL(ctx, bodyKey(key.String())).Val(); ttl < 59*time.Minute {
+		t.Errorf("body TTL after UpdateTTL = %v, want ~1h", ttl)
+	}
+	if got, err := m.Get(ctx, key); err != nil || !bytes.Equal(got.Data, body) || got.ValidatedAt.IsZero() {
+		t.Errorf("Get() after UpdateTTL = %+v, %v", got, err)
+	}
+
+	// Rewriting the entry small stores it whole and drops the body key
+	entry.Data = []byte("{}")
+	if err := m.Set(ctx, key, entry); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if n := redisClient.Exists(ctx, bodyKey(key.String())).Val(); n != 0 {
+		t.Error("body key kept after the entry was stored whole")
+	}
+
+	if err := m.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+}
+
+func TestManager_SplitStorage_MissingBody(t *testing.T) {
+	redisClient := setupTestRedis(t)
+	ctx := context.Background()
+
+	m := NewManager(redisClient)
+	m.EnableSplitStorage(1)
+	key := CacheKey{Endpoint: "/v1/universe/types/34/"}
+	entry := &CacheEntry{Data: []byte(`{"type_id":34}`), Expires: time.Now().Add(time.Minute), CachedAt: time.Now(), StatusCode: http.StatusOK}
+	if err := m.Set(ctx, key, entry); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	// Redis evicted the body but not the envelope
+	redisClient.Del(ctx, bodyKey(key.String()))
+	if _, err := m.Get(ctx, key); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Get() without body error = %v, want ErrCacheMiss", err)
+	}
+
+	// Entries stored whole are read by managers with split storage enabled
+	whole := NewManager(redisClient)
+	if err := whole.Set(ctx, key, entry); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if got, err := m.Get(ctx, key); err != nil || string(got.Data) != `{"type_id":34}` {
+		t.Errorf("Get() of whole entry = %v, %v", got, err)
+	}
+}
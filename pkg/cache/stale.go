@@ -0,0 +1,21 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// EnableStaleRetention keeps entries in Redis for d after they expire, so
+// they can still be read with GetStale when ESI is unavailable. Get keeps
+// treating expired entries as misses. Must be called before the manager is
+// used.
+func (m *Manager) EnableStaleRetention(d time.Duration) {
+	m.stale = max(d, 0)
+}
+
+// GetStale retrieves a cache entry like Get, but also returns entries that
+// have expired within the stale retention window (see EnableStaleRetention).
+// Callers must check entry.IsExpired() and mark stale data as such.
+func (m *Manager) GetStale(ctx context.Context, key CacheKey) (*CacheEntry, error) {
+	return m.get(ctx, key, true)
+}
@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestManager_StaleRetention(t *testing.T) {
+	client := setupTestRedis(t)
+	manager := NewManager(client)
+	manager.EnableStaleRetention(time.Minute)
+	ctx := context.Background()
+
+	key := CacheKey{Endpoint: "/v1/stale/"}
+	entry := &CacheEntry{
+		Data:       []byte(`{"players":1}`),
+		Expires:    time.Now().Add(200 * time.Millisecond),
+		StatusCode: 200,
+	}
+	if err := manager.Set(ctx, key, entry); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	if ttl := client.PTTL(ctx, key.String()).Val(); ttl < time.Minute {
+		t.Errorf("Redis TTL = %s, want entry TTL plus retention (> 1m)", ttl)
+	}
+
+	time.Sleep(300 * time.Millisecond)
+
+	// Get treats the entry as expired, but keeps it for GetStale
+	if _, err := manager.Get(ctx, key); err != ErrCacheMiss {
+		t.Fatalf("Get() error = %v, want ErrCacheMiss", err)
+	}
+	stale, err := manager.GetStale(ctx, key)
+	if err != nil {
+		t.Fatalf("GetStale() error = %v", err)
+	}
+	if !stale.IsExpired() || string(stale.Data) != `{"players":1}` {
+		t.Errorf("GetStale() = %+v, want the expired entry", stale)
+	}
+}
+
+func TestManager_GetStale_WithoutRetention(t *testing.T) {
+	client := setupTestRedis(t)
+	manager := NewManager(client)
+	ctx := context.Background()
+
+	key := CacheKey{Endpoint: "/v1/fresh/"}
+	entry := &CacheEntry{Data: []byte(`{}`), Expires: time.Now().Add(time.Minute), StatusCode: 200}
+	if err := manager.Set(ctx, key, entry); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	// Fresh entries are returned like Get
+	if _, err := manager.GetStale(ctx, key); err != nil {
+		t.Errorf("GetStale() error = %v", err)
+	}
+	if ttl := client.PTTL(ctx, key.String()).Val(); ttl > time.Minute {
+		t.Errorf("Redis TTL = %s, want entry TTL without retention", ttl)
+	}
+}
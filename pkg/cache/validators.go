@@ -0,0 +1,211 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ValidatorReloadAge is how old an indexed validator may get before
+// Validators reloads it from Redis in the background, picking up entries
+// rewritten by other instances.
+const ValidatorReloadAge = 30 * time.Second
+
+// validatorReloadTimeout bounds a background reload.
+const validatorReloadTimeout = 5 * time.Second
+
+// Validators are the conditional request validators of a cache entry.
+type Validators struct {
+	ETag         string
+	ETagAliases  []string // See CacheEntry.ETagAliases
+	LastModified time.Time
+	Expires      time.Time
+}
+
+// ValidatorsOf returns the validators of entry.
+func ValidatorsOf(entry *CacheEntry) Validators {
+	return Validators{ETag: entry.ETag, ETagAliases: entry.ETagAliases, LastModified: entry.LastModified, Expires: entry.Expires}
+}
+
+// Conditional reports whether a conditional request can be made (see
+// ShouldMakeConditionalRequest).
+func (v Validators) Conditional() bool {
+	return v.ETag != "" || !v.LastModified.IsZero()
+}
+
+// Matches reports whether entry still carries these validators.
+func (v Validators) Matches(entry *CacheEntry) bool {
+	return entry != nil && entry.ETag == v.ETag && entry.LastModified.Equal(v.LastModified)
+}
+
+// AddValidatorHeaders adds If-None-Match (ETag and its aliases) or
+// If-Modified-Since headers for v to req.
+func AddValidatorHeaders(req *http.Request, v Validators) {
+	// Prefer ETag over Last-Modified (more accurate)
+	if v.ETag != "" {
+		req.Header.Set("If-None-Match", ifNoneMatch(append([]string{v.ETag}, v.ETagAliases...)))
+	} else if !v.LastModified.IsZero() {
+		req.Header.Set("If-Modified-Since", v.LastModified.Format(http.TimeFormat))
+	}
+}
+
+// validatorIndex is an in-memory map of cache key to validators, so the
+// conditional request decision doesn't need a Redis GET of the whole entry.
+type validatorIndex struct {
+	mu         sync.Mutex
+	entries    map[string]indexedValidators
+	maxEntries int
+	reloading  map[string]bool
+}
+
+type indexedValidators struct {
+	Validators
+	loadedAt time.Time
+}
+
+func newValidatorIndex(maxEntries int) *validatorIndex {
+	return &validatorIndex{
+		entries:    make(map[string]indexedValidators),
+		maxEntries: maxEntries,
+		reloading:  make(map[string]bool),
+	}
+}
+
+// get returns the unexpired validators of key and when they were loaded.
+func (x *validatorIndex) get(key string) (indexedValidators, bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	v, ok := x.entries[key]
+	if !ok {
+		return indexedValidators{}, false
+	}
+	if !time.Now().Before(v.Expires) {
+		delete(x.entries, key)
+		return indexedValidators{}, false
+	}
+	return v, true
+}
+
+func (x *validatorIndex) set(key string, v Validators) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if _, exists := x.entries[key]; !exists && len(x.entries) >= x.maxEntries {
+		x.evict()
+	}
+	x.entries[key] = indexedValidators{Validators: v, loadedAt: time.Now()}
+}
+
+func (x *validatorIndex) invalidate(key string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	delete(x.entries, key)
+}
+
+// startReload marks key as reloading; false if a reload already runs.
+func (x *validatorIndex) startReload(key string) bool {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.reloading[key] {
+		return false
+	}
+	x.reloading[key] = true
+	return true
+}
+
+func (x *validatorIndex) finishReload(key string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	delete(x.reloading, key)
+}
+
+// evict frees one slot, preferring expired validators. Caller must hold mu.
+func (x *validatorIndex) evict() {
+	now := time.Now()
+	for key, v := range x.entries {
+		if !now.Before(v.Expires) {
+			delete(x.entries, key)
+			return
+		}
+	}
+	// No expired validators - drop arbitrary ones (map order is random)
+	for key := range x.entries {
+		delete(x.entries, key)
+		return
+	}
+}
+
+// EnableValidatorIndex keeps the validators of at most maxEntries entries
+// in memory for Validators. Must be called before the manager is used.
+func (m *Manager) EnableValidatorIndex(maxEntries int) {
+	if maxEntries <= 0 {
+		m.validators = nil
+		return
+	}
+	m.validators = newValidatorIndex(maxEntries)
+}
+
+// Validators returns the validators of the unexpired entry for key from
+// memory, without contacting Redis. ok is false if the index is off or has
+// no validators for key; callers then read the entry with Get, which
+// indexes it. Validators older than ValidatorReloadAge are reloaded from
+// Redis in the background.
+//
+// The index may lag behind Redis (other instances, eviction): callers must
+// check the entry with Validators.Matches before serving it for a 304.
+func (m *Manager) Validators(key CacheKey) (v Validators, ok bool) {
+	if m.validators == nil {
+		return Validators{}, false
+	}
+	cacheKey := key.String()
+	indexed, ok := m.validators.get(cacheKey)
+	if !ok {
+		return Validators{}, false
+	}
+	if time.Since(indexed.loadedAt) >= ValidatorReloadAge && m.validators.startReload(cacheKey) {
+		go m.reloadValidators(cacheKey)
+	}
+	return indexed.Validators, true
+}
+
+// reloadValidators refreshes the indexed validators of cacheKey from Redis.
+func (m *Manager) reloadValidators(cacheKey string) {
+	defer m.validators.finishReload(cacheKey)
+
+	ctx, cancel := context.WithTimeout(context.Background(), validatorReloadTimeout)
+	defer cancel()
+
+	data, _, err := m.read(ctx, cacheKey)
+	if err == redis.Nil {
+		m.validators.invalidate(cacheKey)
+		return
+	}
+	if err != nil {
+		return // Keep the validators until the next attempt
+	}
+
+	var entry CacheEntry
+	if err := m.codec.Unmarshal(data, &entry); err != nil || entry.IsExpired() {
+		m.validators.invalidate(cacheKey)
+		return
+	}
+	m.validators.set(cacheKey, ValidatorsOf(&entry))
+}
+
+// indexValidators records the validators of an entry read or written.
+func (m *Manager) indexValidators(cacheKey string, entry *CacheEntry) {
+	if m.validators != nil {
+		m.validators.set(cacheKey, ValidatorsOf(entry))
+	}
+}
+
+// forgetValidators drops the validators of a deleted entry.
+func (m *Manager) forgetValidators(cacheKey string) {
+	if m.validators != nil {
+		m.validators.invalidate(cacheKey)
+	}
+}
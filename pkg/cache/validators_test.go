@@ -0,0 +1,170 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestValidatorIndex_Expiry(t *testing.T) {
+	x := newValidatorIndex(10)
+	x.set("fresh", Validators{ETag: `"a"`, Expires: time.Now().Add(time.Minute)})
+	x.set("expired", Validators{ETag: `"b"`, Expires: time.Now().Add(-time.Second)})
+
+	if v, ok := x.get("fresh"); !ok || v.ETag != `"a"` {
+		t.Errorf("get(fresh) = %+v, %v", v, ok)
+	}
+	if _, ok := x.get("expired"); ok {
+		t.Error("get(expired) found expired validators")
+	}
+	if len(x.entries) != 1 {
+		t.Errorf("expired validators not dropped: %d entries", len(x.entries))
+	}
+
+	x.invalidate("fresh")
+	if _, ok := x.get("fresh"); ok {
+		t.Error("get() found invalidated validators")
+	}
+}
+
+func TestValidatorIndex_EvictsAtCapacity(t *testing.T) {
+	x := newValidatorIndex(2)
+	x.set("expired", Validators{Expires: time.Now().Add(-time.Second)})
+	x.set("a", Validators{Expires: time.Now().Add(time.Minute)})
+	x.set("b", Validators{Expires: time.Now().Add(time.Minute)})
+
+	if len(x.entries) != 2 {
+		t.Fatalf("index holds %d entries, want 2", len(x.entries))
+	}
+	if _, ok := x.entries["expired"]; ok {
+		t.Error("expired validators were not evicted first")
+	}
+
+	x.set("c", Validators{Expires: time.Now().Add(time.Minute)})
+	if len(x.entries) != 2 {
+		t.Errorf("index holds %d entries, want 2", len(x.entries))
+	}
+}
+
+func TestValidatorIndex_SingleReload(t *testing.T) {
+	x := newValidatorIndex(1)
+	if !x.startReload("k") {
+		t.Fatal("first startReload() = false")
+	}
+	if x.startReload("k") {
+		t.Error("second startReload() = true while a reload runs")
+	}
+	x.finishReload("k")
+	if !x.startReload("k") {
+		t.Error("startReload() = false after finishReload")
+	}
+}
+
+func TestValidators_MatchesAndHeaders(t *testing.T) {
+	lastModified := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	entry := &CacheEntry{ETag: `"abc"`, LastModified: lastModified, Expires: time.Now().Add(time.Minute)}
+	v := ValidatorsOf(entry)
+
+	if !v.Matches(entry) {
+		t.Error("Matches() = false for the entry itself")
+	}
+	if v.Matches(&CacheEntry{ETag: `"def"`, LastModified: lastModified}) {
+		t.Error("Matches() = true for a changed ETag")
+	}
+	if v.Matches(nil) {
+		t.Error("Matches(nil) = true")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/status/", nil)
+	AddValidatorHeaders(req, v)
+	if got := req.Header.Get("If-None-Match"); got != `"abc"` {
+		t.Errorf("If-None-Match = %q, want %q", got, `"abc"`)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/status/", nil)
+	AddValidatorHeaders(req, Validators{LastModified: lastModified})
+	if got := req.Header.Get("If-Modified-Since"); got != lastModified.Format(http.TimeFormat) {
+		t.Errorf("If-Modified-Since = %q", got)
+	}
+	if (Validators{}).Conditional() {
+		t.Error("empty Validators are conditional")
+	}
+}
+
+func TestManager_ValidatorIndex(t *testing.T) {
+	redisClient := setupTestRedis(t)
+	ctx := context.Background()
+
+	m := NewManager(redisClient)
+	key := CacheKey{Endpoint: "/v1/markets/prices/"}
+	entry := &CacheEntry{Data: []byte(`[]`), ETag: `"v1"`, Expires: time.Now().Add(time.Minute), CachedAt: time.Now(), StatusCode: http.StatusOK}
+	if err := m.Set(ctx, key, entry); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if _, ok := m.Validators(key); ok {
+		t.Error("Validators() found validators with the index off")
+	}
+
+	m.EnableValidatorIndex(100)
+	if _, ok := m.Validators(key); ok {
+		t.Error("Validators() found validators before the entry was read or written")
+	}
+	if _, err := m.Get(ctx, key); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if v, ok := m.Validators(key); !ok || v.ETag != `"v1"` {
+		t.Errorf("Validators() after Get = %+v, %v", v, ok)
+	}
+
+	entry.ETag = `"v2"`
+	if err := m.Set(ctx, key, entry); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if v, _ := m.Validators(key); v.ETag != `"v2"` {
+		t.Errorf("Validators() after Set = %q, want %q", v.ETag, `"v2"`)
+	}
+
+	if err := m.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, ok := m.Validators(key); ok {
+		t.Error("Validators() found validators of a deleted entry")
+	}
+}
+
+func TestManager_ReloadValidators(t *testing.T) {
+	redisClient := setupTestRedis(t)
+	ctx := context.Background()
+
+	m := NewManager(redisClient)
+	m.EnableValidatorIndex(100)
+	key := CacheKey{Endpoint: "/v1/status/"}
+	entry := &CacheEntry{Data: []byte(`{}`), ETag: `"v1"`, Expires: time.Now().Add(time.Minute), CachedAt: time.Now(), StatusCode: http.StatusOK}
+	if err := m.Set(ctx, key, entry); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	// Another instance replaces the entry
+	other := NewManager(redisClient)
+	entry.ETag = `"v2"`
+	if err := other.Set(ctx, key, entry); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	m.validators.startReload(key.String())
+	m.reloadValidators(key.String())
+	if v, _ := m.Validators(key); v.ETag != `"v2"` {
+		t.Errorf("Validators() after reload = %q, want %q", v.ETag, `"v2"`)
+	}
+
+	if err := other.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	m.validators.startReload(key.String())
+	m.reloadValidators(key.String())
+	if _, ok := m.Validators(key); ok {
+		t.Error("Validators() found validators of an entry deleted elsewhere")
+	}
+}
@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"fmt"
+)
+
+// EntryVersion is the current format version of serialized cache entries.
+//
+// Version history:
+//   - 1: original envelope (no version field, no checksum)
+//   - 2: adds Checksum
+//   - 3: adds BodySize (body may be stored under a separate key)
+//
+// Bump this constant and register a migration from the previous version
+// whenever the envelope format changes.
+const EntryVersion = 3
+
+// migrations upgrade a decoded entry from the key version to the next one.
+var migrations = map[int]func(*CacheEntry) error{
+	1: migrateV1,
+	2: migrateV2,
+}
+
+// migrateEntry upgrades entry to EntryVersion in place.
+// Reports whether the entry was changed and must be written back.
+func migrateEntry(entry *CacheEntry) (bool, error) {
+	if entry.Version == 0 {
+		// Entries written before versioning was introduced
+		entry.Version = 1
+	}
+
+	from := entry.Version
+	for entry.Version < EntryVersion {
+		migrate, ok := migrations[entry.Version]
+		if !ok {
+			return false, fmt.Errorf("no migration from entry version %d", entry.Version)
+		}
+		if err := migrate(entry); err != nil {
+			return false, fmt.Errorf("migrate entry version %d: %w", entry.Version, err)
+		}
+		entry.Version++
+	}
+
+	if from < EntryVersion {
+		CacheMigrations.WithLabelValues(fmt.Sprintf("%d", from)).Inc()
+		return true, nil
+	}
+	return false, nil
+}
+
+// migrateV1 adds the checksum missing from version 1 entries.
+func migrateV1(entry *CacheEntry) error {
+	entry.Checksum = entry.computeChecksum()
+	return nil
+}
+
+// migrateV2 has nothing to do: version 2 entries are stored whole. The bump
+// keeps deployments that can't read split bodies from misreading them.
+func migrateV2(entry *CacheEntry) error {
+	return nil
+}
@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"testing"
+)
+
+func TestMigrateEntry(t *testing.T) {
+	data := []byte(`{"type_id":34}`)
+
+	tests := []struct {
+		name         string
+		entry        CacheEntry
+		wantMigrated bool
+	}{
+		{"unversioned legacy entry", CacheEntry{Data: data}, true},
+		{"version 1", CacheEntry{Version: 1, Data: data}, true},
+		{"current version", CacheEntry{Version: EntryVersion, Data: data}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := tt.entry
+			migrated, err := migrateEntry(&entry)
+			if err != nil {
+				t.Fatalf("migrateEntry() error = %v", err)
+			}
+			if migrated != tt.wantMigrated {
+				t.Errorf("migrateEntry() migrated = %v, want %v", migrated, tt.wantMigrated)
+			}
+			if entry.Version != EntryVersion {
+				t.Errorf("Version = %d, want %d", entry.Version, EntryVersion)
+			}
+			if tt.wantMigrated && entry.Checksum != entry.computeChecksum() {
+				t.Error("migrated entry should carry a valid checksum")
+			}
+		})
+	}
+}
+
+func TestMigrateEntry_MissingMigration(t *testing.T) {
+	saved := migrations[1]
+	delete(migrations, 1)
+	defer func() { migrations[1] = saved }()
+
+	entry := CacheEntry{Version: 1}
+	if _, err := migrateEntry(&entry); err == nil {
+		t.Error("migrateEntry() expected error for missing migration")
+	}
+}
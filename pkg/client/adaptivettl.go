@@ -0,0 +1,71 @@
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+)
+
+const (
+	// defaultAdaptiveTTLMin and defaultAdaptiveTTLMax bound learned TTLs if
+	// Config.AdaptiveTTLMin or Config.AdaptiveTTLMax is 0.
+	defaultAdaptiveTTLMin = time.Minute
+	defaultAdaptiveTTLMax = time.Hour
+
+	// adaptiveTTLFactor is the share of the time the data has stayed
+	// unchanged that it is trusted for: data unchanged for an hour is
+	// cached for 15 minutes.
+	adaptiveTTLFactor = 0.25
+)
+
+// validateAdaptiveTTL checks the adaptive TTL bounds.
+func validateAdaptiveTTL(cfg Config) error {
+	if cfg.AdaptiveTTLMin < 0 || cfg.AdaptiveTTLMax < 0 {
+		return fmt.Errorf("adaptive_ttl bounds must not be negative (got min %s, max %s)", cfg.AdaptiveTTLMin, cfg.AdaptiveTTLMax)
+	}
+	minTTL, maxTTL := adaptiveTTLBounds(cfg)
+	if minTTL > maxTTL {
+		return fmt.Errorf("adaptive_ttl_min must not exceed adaptive_ttl_max (got %s > %s)", minTTL, maxTTL)
+	}
+	return nil
+}
+
+// adaptiveTTLBounds returns the configured bounds with defaults applied.
+func adaptiveTTLBounds(cfg Config) (minTTL, maxTTL time.Duration) {
+	minTTL, maxTTL = cfg.AdaptiveTTLMin, cfg.AdaptiveTTLMax
+	if minTTL == 0 {
+		minTTL = defaultAdaptiveTTLMin
+	}
+	if maxTTL == 0 {
+		maxTTL = defaultAdaptiveTTLMax
+	}
+	return minTTL, maxTTL
+}
+
+// learnTTL sets the expiry of a fresh entry without Expires header from
+// how long its data has stayed unchanged. prev is the entry it replaces
+// (nil if none); an unchanged ETag carries the change time over.
+func (c *Client) learnTTL(entry, prev *cache.CacheEntry) {
+	entry.ChangedAt = entry.CachedAt
+	if prev != nil && cache.WeakETagMatch(entry.ETag, prev.ETag) {
+		entry.ChangedAt = prev.ChangedAt
+		if entry.ChangedAt.IsZero() {
+			entry.ChangedAt = prev.CachedAt
+		}
+	}
+	entry.Expires = c.adaptiveExpires(entry.ChangedAt, entry.CachedAt)
+}
+
+// adaptiveExpires returns the expiry for data unchanged since changedAt
+// (cachedAt if unknown), bounded by the configured minimum and maximum.
+func (c *Client) adaptiveExpires(changedAt, cachedAt time.Time) time.Time {
+	if changedAt.IsZero() {
+		changedAt = cachedAt
+	}
+	minTTL, maxTTL := adaptiveTTLBounds(c.config)
+
+	now := time.Now()
+	ttl := time.Duration(float64(now.Sub(changedAt)) * adaptiveTTLFactor)
+	return now.Add(min(max(ttl, minTTL), maxTTL))
+}
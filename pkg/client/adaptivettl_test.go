@@ -0,0 +1,162 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+)
+
+func TestAdaptiveExpires(t *testing.T) {
+	c := &Client{config: Config{AdaptiveTTLMin: 2 * time.Minute, AdaptiveTTLMax: time.Hour}}
+
+	tests := []struct {
+		name      string
+		unchanged time.Duration
+		wantTTL   time.Duration
+	}{
+		{"just changed", 0, 2 * time.Minute},
+		{"unchanged for an hour", time.Hour, 15 * time.Minute},
+		{"unchanged for a day", 24 * time.Hour, time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expires := c.adaptiveExpires(time.Now().Add(-tt.unchanged), time.Time{})
+			if ttl := time.Until(expires); ttl < tt.wantTTL-time.Second || ttl > tt.wantTTL+time.Second {
+				t.Errorf("TTL = %s, want %s", ttl, tt.wantTTL)
+			}
+		})
+	}
+}
+
+func TestLearnTTL(t *testing.T) {
+	c := &Client{}
+	now := time.Now()
+	changed := now.Add(-4 * time.Hour)
+
+	tests := []struct {
+		name          string
+		prev          *cache.CacheEntry
+		wantChangedAt time.Time
+	}{
+		{"first fetch", nil, now},
+		{"same etag", &cache.CacheEntry{ETag: `"a"`, ChangedAt: changed, CachedAt: now.Add(-time.Minute)}, changed},
+		{"same etag without change time", &cache.CacheEntry{ETag: `"a"`, CachedAt: changed}, changed},
+		{"same etag marked weak", &cache.CacheEntry{ETag: `W/"a"`, ChangedAt: changed}, changed},
+		{"new etag", &cache.CacheEntry{ETag: `"b"`, ChangedAt: changed}, now},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := &cache.CacheEntry{ETag: `"a"`, CachedAt: now}
+			c.learnTTL(entry, tt.prev)
+			if !entry.ChangedAt.Equal(tt.wantChangedAt) {
+				t.Errorf("ChangedAt = %s, want %s", entry.ChangedAt, tt.wantChangedAt)
+			}
+			if want := c.adaptiveExpires(tt.wantChangedAt, now); entry.Expires.Sub(want).Abs() > time.Second {
+				t.Errorf("Expires = %s, want %s", entry.Expires, want)
+			}
+		})
+	}
+}
+
+func TestNew_AdaptiveTTLValidation(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	tests := []struct {
+		name     string
+		min, max time.Duration
+		wantErr  bool
+	}{
+		{"defaults", 0, 0, false},
+		{"valid", time.Minute, 6 * time.Hour, false},
+		{"negative", -time.Minute, 0, true},
+		{"min above max", 2 * time.Hour, time.Hour, true},
+		{"min above default max", 2 * time.Hour, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig(redisClient, "TestApp/1.0.0")
+			cfg.AdaptiveTTL = true
+			cfg.AdaptiveTTLMin, cfg.AdaptiveTTLMax = tt.min, tt.max
+			client, err := New(cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if client != nil {
+				client.Close()
+			}
+		})
+	}
+}
+
+func TestDo_AdaptiveTTL(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-ESI-Error-Limit-Remain", "100")
+		w.Header().Set("X-ESI-Error-Limit-Reset", "60")
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte(`[1,2,3]`))
+	}))
+	defer server.Close()
+
+	cfg := DefaultConfig(redisClient, "TestApp/1.0.0")
+	cfg.AdaptiveTTL = true
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+	client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+
+	ctx := context.Background()
+	endpoint := "/v1/universe/factions/"
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, esiBaseURL+endpoint, nil)
+	key, _, err := client.requestCacheKey(req, false)
+	if err != nil {
+		t.Fatalf("requestCacheKey() error = %v", err)
+	}
+
+	get := func() *cache.CacheEntry {
+		t.Helper()
+		resp, err := client.Get(ctx, endpoint)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		resp.Body.Close()
+		entry, err := client.cache.Get(ctx, key)
+		if err != nil {
+			t.Fatalf("cache.Get() error = %v", err)
+		}
+		return entry
+	}
+
+	// New data starts at the minimum TTL instead of cache.DefaultTTL
+	entry := get()
+	if ttl := entry.TTL(); ttl > defaultAdaptiveTTLMin {
+		t.Errorf("TTL after first fetch = %s, want <= %s", ttl, defaultAdaptiveTTLMin)
+	}
+
+	// Data confirmed unchanged for two hours is kept for half an hour
+	entry.ChangedAt = time.Now().Add(-2 * time.Hour)
+	if err := client.cache.Set(ctx, key, entry); err != nil {
+		t.Fatalf("cache.Set() error = %v", err)
+	}
+	entry = get()
+	if ttl := entry.TTL(); ttl < 29*time.Minute || ttl > 30*time.Minute+time.Second {
+		t.Errorf("TTL after revalidation = %s, want 30m", ttl)
+	}
+	if entry.ChangedAt.After(time.Now().Add(-time.Hour)) {
+		t.Errorf("ChangedAt = %s, want kept from before the revalidation", entry.ChangedAt)
+	}
+}
@@ -0,0 +1,107 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+	"github.com/Sternrassler/eve-esi-client/pkg/ratelimit"
+	"github.com/redis/go-redis/v9"
+)
+
+// StateExport is a snapshot of the shared Redis state used to move a
+// deployment to a new Redis instance (blue-green deploys) without starting
+// blind (rate limit) or cold (cache).
+type StateExport struct {
+	ExportedAt time.Time                 `json:"exported_at"`
+	RateLimit  *ratelimit.RateLimitState `json:"rate_limit,omitempty"`
+	Cache      []cache.ManifestEntry     `json:"cache,omitempty"`
+}
+
+// ImportResult summarizes what ImportState wrote.
+type ImportResult struct {
+	RateLimit    bool // Rate limit state imported (false if missing or its window already reset)
+	CacheEntries int  // Cache entries imported
+}
+
+// ExportState exports the rate limit state and, with includeCache, all live
+// cache entries including their data.
+func (c *Client) ExportState(ctx context.Context, includeCache bool) (*StateExport, error) {
+	ctx, cancel := c.withDefaultDeadline(ctx, operationAdmin)
+	defer cancel()
+
+	export := &StateExport{ExportedAt: time.Now()}
+
+	state, err := c.rateLimiter.ExportState(ctx)
+	if err != nil {
+		return nil, err
+	}
+	export.RateLimit = state
+
+	if includeCache {
+		manifest, err := c.cache.ExportManifest(ctx, true)
+		if err != nil {
+			return nil, fmt.Errorf("export cache manifest: %w", err)
+		}
+		export.Cache = manifest
+	}
+
+	c.logger.Info().
+		Bool("rate_limit", state != nil).
+		Int("cache_entries", len(export.Cache)).
+		Msg("Exported client state")
+
+	return export, nil
+}
+
+// ImportState writes an export into the client's Redis. Stale parts (reset
+// rate limit window, expired cache entries) are skipped.
+func (c *Client) ImportState(ctx context.Context, export *StateExport) (ImportResult, error) {
+	var result ImportResult
+	if export == nil {
+		return result, nil
+	}
+	ctx, cancel := c.withDefaultDeadline(ctx, operationAdmin)
+	defer cancel()
+
+	imported, err := c.rateLimiter.ImportState(ctx, export.RateLimit)
+	if err != nil {
+		return result, err
+	}
+	result.RateLimit = imported
+
+	result.CacheEntries, err = c.cache.ImportManifest(ctx, export.Cache)
+	if err != nil {
+		return result, fmt.Errorf("import cache manifest: %w", err)
+	}
+
+	c.logger.Info().
+		Bool("rate_limit", result.RateLimit).
+		Int("cache_entries", result.CacheEntries).
+		Msg("Imported client state")
+
+	return result, nil
+}
+
+// ReplicateCache copies live cache entries to a standby Redis with their
+// remaining TTL (warm standby before a regional failover). The rate limit
+// state is not copied; it belongs to the deployment's own outbound IP.
+func (c *Client) ReplicateCache(ctx context.Context, dst *redis.Client, opts cache.ReplicateOptions) (cache.ReplicateResult, error) {
+	ctx, cancel := c.withDefaultDeadline(ctx, operationAdmin)
+	defer cancel()
+
+	result, err := c.cache.ReplicateTo(ctx, dst, opts)
+	if err != nil {
+		return result, fmt.Errorf("replicate cache: %w", err)
+	}
+
+	c.logger.Info().
+		Strs("prefixes", opts.EndpointPrefixes).
+		Int("copied", result.Copied).
+		Int("skipped", result.Skipped).
+		Int64("bytes", result.Bytes).
+		Msg("Replicated cache entries")
+
+	return result, nil
+}
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestExportImportState(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-ESI-Error-Limit-Remain", "77")
+		w.Header().Set("X-ESI-Error-Limit-Reset", "40")
+		w.Header().Set("Expires", time.Now().Add(5*time.Minute).Format(http.TimeFormat))
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"players": 30000}`))
+	}))
+	defer server.Close()
+
+	client, err := New(DefaultConfig(redisClient, "TestApp/1.0.0"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	req, _ := http.NewRequest("GET", server.URL+"/v2/status/", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	ctx := context.Background()
+	export, err := client.ExportState(ctx, true)
+	if err != nil {
+		t.Fatalf("ExportState() error = %v", err)
+	}
+	if export.RateLimit == nil || export.RateLimit.ErrorsRemaining != 77 {
+		t.Fatalf("exported rate limit = %+v, want 77 errors remaining", export.RateLimit)
+	}
+	if len(export.Cache) != 1 {
+		t.Fatalf("exported %d cache entries, want 1", len(export.Cache))
+	}
+
+	// Round-trip through JSON like the esi-proxy admin flags
+	data, err := json.Marshal(export)
+	if err != nil {
+		t.Fatalf("marshal export: %v", err)
+	}
+	var decoded StateExport
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal export: %v", err)
+	}
+
+	// Simulate the new, empty Redis instance
+	if err := redisClient.FlushDB(ctx).Err(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+
+	result, err := client.ImportState(ctx, &decoded)
+	if err != nil {
+		t.Fatalf("ImportState() error = %v", err)
+	}
+	if !result.RateLimit || result.CacheEntries != 1 {
+		t.Errorf("ImportState() = %+v, want rate limit and 1 cache entry", result)
+	}
+
+	state, err := client.rateLimiter.GetState(ctx)
+	if err != nil {
+		t.Fatalf("GetState() error = %v", err)
+	}
+	if state.ErrorsRemaining != 77 {
+		t.Errorf("ErrorsRemaining after import = %d, want 77", state.ErrorsRemaining)
+	}
+}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+)
+
+// characterIDKey is the context key of the character a request is made for.
+type characterIDKey struct{}
+
+// WithCharacterID returns a context scoping authenticated requests to
+// characterID: their responses are cached under keys of that character only.
+func WithCharacterID(ctx context.Context, characterID int64) context.Context {
+	return context.WithValue(ctx, characterIDKey{}, characterID)
+}
+
+// CharacterIDFromContext returns the character ID of ctx, or 0 if none is set.
+func CharacterIDFromContext(ctx context.Context) int64 {
+	id, _ := ctx.Value(characterIDKey{}).(int64)
+	return id
+}
+
+// ownerHashKey is the context key of the SSO owner hash of a request.
+type ownerHashKey struct{}
+
+// WithOwnerHash returns a context scoping authenticated requests to the SSO
+// owner hash (the CharacterOwnerHash claim of the access token). Set it next
+// to WithCharacterID: a character transferred to another account gets a new
+// owner hash, so the new owner never reads the previous owner's cached data.
+func WithOwnerHash(ctx context.Context, ownerHash string) context.Context {
+	return context.WithValue(ctx, ownerHashKey{}, ownerHash)
+}
+
+// OwnerHashFromContext returns the owner hash of ctx, or "" if none is set.
+func OwnerHashFromContext(ctx context.Context) string {
+	hash, _ := ctx.Value(ownerHashKey{}).(string)
+	return hash
+}
+
+// tenantKey is the context key of the tenant a request is made for.
+type tenantKey struct{}
+
+// WithTenant returns a context placing cached responses of authenticated
+// requests in the private key space of tenant (see cache.CacheKey.Tenant).
+// Public responses stay shared between tenants.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant of ctx, or "" if none is set.
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+// accessTokenKey is the context key of the access token of a request.
+type accessTokenKey struct{}
+
+// WithAccessToken returns a context whose requests are sent with token as
+// Bearer authorization, unless the request sets Authorization itself. This
+// lets callers that only have Get (pagination, proxies) make authenticated
+// requests.
+func WithAccessToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, accessTokenKey{}, token)
+}
+
+// AccessTokenFromContext returns the access token of ctx, or "" if none is set.
+func AccessTokenFromContext(ctx context.Context) string {
+	token, _ := ctx.Value(accessTokenKey{}).(string)
+	return token
+}
+
+// scopeAuthenticated adds the character scope (character ID and owner hash)
+// to the cache key of a cacheable request carrying Authorization. Without a
+// scope the response would land under the public key and be served to every
+// account sharing the Redis, so the request bypasses the cache, or fails with
+// StrictAuthCache.
+func (c *Client) scopeAuthenticated(req *http.Request, key cache.CacheKey) (cache.CacheKey, bool, error) {
+	if req.Header.Get("Authorization") == "" {
+		return key, true, nil
+	}
+
+	key.CharacterID = CharacterIDFromContext(req.Context())
+	key.OwnerHash = OwnerHashFromContext(req.Context())
+	key.Tenant = TenantFromContext(req.Context())
+	if key.CharacterID > 0 || key.OwnerHash != "" {
+		return key, true, nil
+	}
+
+	if c.config.StrictAuthCache {
+		return key, false, fmt.Errorf("%s: %w", req.URL.Path, ErrUnscopedAuthCache)
+	}
+	logger := requestLogger(req.Context(), c.logger)
+	logger.Warn().
+		Str("endpoint", c.reportedEndpoint(req.Context(), req.URL.Path)).
+		Msg("Authenticated request without character scope, bypassing cache")
+	return key, false, nil
+}
@@ -0,0 +1,205 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDo_AuthenticatedCaching(t *testing.T) {
+	tests := []struct {
+		name        string
+		strict      bool
+		characterID int64
+		wantHits    int64 // Revalidations of a cached entry for two identical requests
+		wantErr     error
+	}{
+		{"scoped requests are cached", false, 90000001, 1, nil},
+		{"unscoped requests bypass the cache", false, 0, 0, nil},
+		{"unscoped requests fail in strict mode", true, 0, 0, ErrUnscopedAuthCache},
+		{"scoped requests are cached in strict mode", true, 90000001, 1, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			redisClient := setupTestRedis(t)
+
+			var hits atomic.Int64
+			server := httptest.NewServer(revalidatingHandler(&hits, `{"balance":42}`))
+			defer server.Close()
+
+			cfg := DefaultConfig(redisClient, "TestApp/1.0.0")
+			cfg.StrictAuthCache = tt.strict
+			client, err := New(cfg)
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+			client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+
+			ctx := context.Background()
+			if tt.characterID > 0 {
+				ctx = WithCharacterID(ctx, tt.characterID)
+			}
+			for range 2 {
+				req, _ := http.NewRequestWithContext(ctx, http.MethodGet, esiBaseURL+"/v1/characters/90000001/wallet/", nil)
+				req.Header.Set("Authorization", "Bearer token")
+				resp, err := client.Do(req)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Do() error = %v, want %v", err, tt.wantErr)
+				}
+				if err == nil {
+					resp.Body.Close()
+				}
+			}
+
+			if n := hits.Load(); n != tt.wantHits {
+				t.Errorf("revalidations = %d, want %d", n, tt.wantHits)
+			}
+		})
+	}
+}
+
+func TestDo_AuthenticatedCacheIsolation(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	var hits atomic.Int64
+	server := httptest.NewServer(revalidatingHandler(&hits, `[]`))
+	defer server.Close()
+
+	client, err := New(DefaultConfig(redisClient, "TestApp/1.0.0"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+
+	// Public, character A and character B must not share entries
+	for _, characterID := range []int64{0, 90000001, 90000002} {
+		ctx := context.Background()
+		req, _ := http.NewRequestWithContext(WithCharacterID(ctx, characterID), http.MethodGet, esiBaseURL+"/v1/markets/prices/", nil)
+		if characterID > 0 {
+			req.Header.Set("Authorization", "Bearer token")
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Do() error = %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	if n := hits.Load(); n != 0 {
+		t.Errorf("revalidations = %d, want 0 (no entry shared between scopes)", n)
+	}
+}
+
+func TestDo_OwnerHashScoping(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	var hits atomic.Int64
+	server := httptest.NewServer(revalidatingHandler(&hits, `{"balance":42}`))
+	defer server.Close()
+
+	cfg := DefaultConfig(redisClient, "TestApp/1.0.0")
+	cfg.StrictAuthCache = true
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+
+	get := func(ownerHash string) {
+		t.Helper()
+		ctx := WithOwnerHash(WithCharacterID(context.Background(), 90000001), ownerHash)
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, esiBaseURL+"/v1/characters/90000001/wallet/", nil)
+		req.Header.Set("Authorization", "Bearer token")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Do() error = %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	get("owner-a")
+	get("owner-a")
+	if n := hits.Load(); n != 1 {
+		t.Fatalf("revalidations = %d, want 1 (same owner shares the entry)", n)
+	}
+
+	// Same character after an account transfer
+	get("owner-b")
+	if n := hits.Load(); n != 1 {
+		t.Errorf("revalidations = %d, want 1 (new owner must not reuse the entry)", n)
+	}
+}
+
+// revalidatingHandler serves body with an ETag and answers matching
+// conditional requests with 304, counting them in hits.
+func revalidatingHandler(hits *atomic.Int64, body string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-ESI-Error-Limit-Remain", "100")
+		w.Header().Set("X-ESI-Error-Limit-Reset", "60")
+		w.Header().Set("Expires", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			hits.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte(body))
+	})
+}
+
+func TestDo_TenantScoping(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	var hits atomic.Int64
+	var auth atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth.Store(r.Header.Get("Authorization"))
+		revalidatingHandler(&hits, `[]`).ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	client, err := New(DefaultConfig(redisClient, "TestApp/1.0.0"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+
+	get := func(tenant, token, endpoint string) {
+		t.Helper()
+		ctx := WithTenant(WithCharacterID(context.Background(), 90000001), tenant)
+		if token != "" {
+			ctx = WithAccessToken(ctx, token)
+		}
+		resp, err := client.Get(ctx, endpoint)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	// Private: the same character in two tenants does not share entries
+	get("alliance-a", "token-a", "/v1/characters/90000001/assets/")
+	if got := auth.Load(); got != "Bearer token-a" {
+		t.Errorf("Authorization = %q, want the context's access token", got)
+	}
+	get("alliance-b", "token-b", "/v1/characters/90000001/assets/")
+	if n := hits.Load(); n != 0 {
+		t.Errorf("revalidations = %d, want 0 (private entries are per tenant)", n)
+	}
+	get("alliance-a", "token-a", "/v1/characters/90000001/assets/")
+	if n := hits.Load(); n != 1 {
+		t.Errorf("revalidations = %d, want 1 (tenant reuses its own entry)", n)
+	}
+
+	// Public: shared between tenants
+	get("alliance-a", "", "/v1/markets/prices/")
+	get("alliance-b", "", "/v1/markets/prices/")
+	if n := hits.Load(); n != 2 {
+		t.Errorf("revalidations = %d, want 2 (public entries are shared)", n)
+	}
+}
@@ -0,0 +1,111 @@
+package client
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// BackoffStrategy computes the wait before a retry from the retry
+// configuration of the error class (see RetryConfigForErrorClass). Select
+// strategies per error class with Config.BackoffStrategies.
+type BackoffStrategy interface {
+	// Backoff returns the wait before retry number retry (1 for the first
+	// retry); prev is the previous wait, 0 before the first retry.
+	Backoff(cfg RetryConfig, retry int, prev time.Duration) time.Duration
+}
+
+// BackoffFunc adapts a function to BackoffStrategy.
+type BackoffFunc func(cfg RetryConfig, retry int, prev time.Duration) time.Duration
+
+// Backoff implements BackoffStrategy.
+func (f BackoffFunc) Backoff(cfg RetryConfig, retry int, prev time.Duration) time.Duration {
+	return f(cfg, retry, prev)
+}
+
+// Built-in backoff strategies.
+var (
+	// ExponentialJitter waits InitialBackoff * BackoffMultiplier^(retry-1),
+	// capped at MaxBackoff, ±20% (default).
+	ExponentialJitter BackoffStrategy = BackoffFunc(exponentialJitter)
+
+	// ExponentialFullJitter waits a random duration between 0 and the
+	// exponential backoff. It spreads clients that failed at the same moment
+	// over the whole interval instead of ±20% around it.
+	ExponentialFullJitter BackoffStrategy = BackoffFunc(exponentialFullJitter)
+
+	// DecorrelatedJitter waits a random duration between InitialBackoff and
+	// three times the previous wait, capped at MaxBackoff, so waits grow
+	// roughly exponentially without moving in lockstep across clients.
+	DecorrelatedJitter BackoffStrategy = BackoffFunc(decorrelatedJitter)
+
+	// FibonacciBackoff waits InitialBackoff times the Fibonacci number of the
+	// retry (1, 1, 2, 3, 5, ...), capped at MaxBackoff: slower growth than
+	// exponential for classes that retry often.
+	FibonacciBackoff BackoffStrategy = BackoffFunc(fibonacciBackoff)
+
+	// FixedBackoff always waits InitialBackoff.
+	FixedBackoff BackoffStrategy = BackoffFunc(func(cfg RetryConfig, _ int, _ time.Duration) time.Duration {
+		return cfg.InitialBackoff
+	})
+)
+
+// validateBackoffStrategies checks Config.BackoffStrategies.
+func validateBackoffStrategies(cfg Config) error {
+	for class, strategy := range cfg.BackoffStrategies {
+		switch class {
+		case ErrorClassServer, ErrorClassRateLimit, ErrorClassNetwork:
+		default:
+			return fmt.Errorf("backoff_strategies: error class %q is not retried", class)
+		}
+		if strategy == nil {
+			return fmt.Errorf("backoff_strategies: nil strategy for %q", class)
+		}
+	}
+	return nil
+}
+
+// backoffStrategy returns the strategy for class, ExponentialJitter unless
+// strategies selects another.
+func backoffStrategy(strategies map[ErrorClass]BackoffStrategy, class ErrorClass) BackoffStrategy {
+	if strategy := strategies[class]; strategy != nil {
+		return strategy
+	}
+	return ExponentialJitter
+}
+
+// exponentialBackoff returns InitialBackoff * BackoffMultiplier^(retry-1),
+// capped at MaxBackoff.
+func exponentialBackoff(cfg RetryConfig, retry int) time.Duration {
+	backoff := float64(cfg.InitialBackoff) * math.Pow(cfg.BackoffMultiplier, float64(retry-1))
+	if backoff > float64(cfg.MaxBackoff) {
+		return cfg.MaxBackoff
+	}
+	return time.Duration(backoff)
+}
+
+func exponentialJitter(cfg RetryConfig, retry int, _ time.Duration) time.Duration {
+	return time.Duration(float64(exponentialBackoff(cfg, retry)) * (0.8 + rand.Float64()*0.4))
+}
+
+func exponentialFullJitter(cfg RetryConfig, retry int, _ time.Duration) time.Duration {
+	return time.Duration(rand.Float64() * float64(exponentialBackoff(cfg, retry)))
+}
+
+func decorrelatedJitter(cfg RetryConfig, _ int, prev time.Duration) time.Duration {
+	upper := max(3*prev, cfg.InitialBackoff)
+	backoff := cfg.InitialBackoff + time.Duration(rand.Float64()*float64(upper-cfg.InitialBackoff))
+	return min(backoff, cfg.MaxBackoff)
+}
+
+func fibonacciBackoff(cfg RetryConfig, retry int, _ time.Duration) time.Duration {
+	a, b := 1, 1
+	for i := 1; i < retry; i++ {
+		a, b = b, a+b
+		if time.Duration(a)*cfg.InitialBackoff >= cfg.MaxBackoff {
+			return cfg.MaxBackoff
+		}
+	}
+	return min(time.Duration(a)*cfg.InitialBackoff, cfg.MaxBackoff)
+}
@@ -0,0 +1,116 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// testRetryConfig grows from 100ms by factor 2 up to 1s.
+var testRetryConfig = RetryConfig{
+	MaxAttempts:       5,
+	InitialBackoff:    100 * time.Millisecond,
+	MaxBackoff:        time.Second,
+	BackoffMultiplier: 2,
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second}
+	for i, w := range want {
+		if got := exponentialBackoff(testRetryConfig, i+1); got != w {
+			t.Errorf("exponentialBackoff(retry %d) = %v, want %v", i+1, got, w)
+		}
+	}
+}
+
+func TestBackoffStrategies_Range(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy BackoffStrategy
+		retry    int
+		prev     time.Duration
+		min, max time.Duration
+	}{
+		{"exponential jitter first", ExponentialJitter, 1, 0, 80 * time.Millisecond, 120 * time.Millisecond},
+		{"exponential jitter third", ExponentialJitter, 3, 0, 320 * time.Millisecond, 480 * time.Millisecond},
+		{"full jitter third", ExponentialFullJitter, 3, 0, 0, 400 * time.Millisecond},
+		{"full jitter capped", ExponentialFullJitter, 10, 0, 0, time.Second},
+		{"decorrelated first", DecorrelatedJitter, 1, 0, 100 * time.Millisecond, 100 * time.Millisecond},
+		{"decorrelated grows from prev", DecorrelatedJitter, 2, 200 * time.Millisecond, 100 * time.Millisecond, 600 * time.Millisecond},
+		{"decorrelated capped", DecorrelatedJitter, 5, 900 * time.Millisecond, 100 * time.Millisecond, time.Second},
+		{"fixed", FixedBackoff, 4, 300 * time.Millisecond, 100 * time.Millisecond, 100 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for range 100 {
+				got := tt.strategy.Backoff(testRetryConfig, tt.retry, tt.prev)
+				if got < tt.min || got > tt.max {
+					t.Fatalf("Backoff() = %v, want within [%v, %v]", got, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestFibonacciBackoff(t *testing.T) {
+	want := []time.Duration{100, 100, 200, 300, 500, 800, 1000, 1000}
+	for i, w := range want {
+		if got := FibonacciBackoff.Backoff(testRetryConfig, i+1, 0); got != w*time.Millisecond {
+			t.Errorf("FibonacciBackoff(retry %d) = %v, want %v", i+1, got, w*time.Millisecond)
+		}
+	}
+}
+
+func TestValidateBackoffStrategies(t *testing.T) {
+	tests := []struct {
+		name       string
+		strategies map[ErrorClass]BackoffStrategy
+		wantErr    bool
+	}{
+		{"none", nil, false},
+		{"per class", map[ErrorClass]BackoffStrategy{ErrorClassServer: ExponentialFullJitter, ErrorClassRateLimit: DecorrelatedJitter}, false},
+		{"client errors are not retried", map[ErrorClass]BackoffStrategy{ErrorClassClient: FixedBackoff}, true},
+		{"nil strategy", map[ErrorClass]BackoffStrategy{ErrorClassNetwork: nil}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateBackoffStrategies(Config{BackoffStrategies: tt.strategies}); (err != nil) != tt.wantErr {
+				t.Errorf("validateBackoffStrategies() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRetryWithBackoff_Strategy(t *testing.T) {
+	var retries []int
+	var prevs []time.Duration
+	strategy := BackoffFunc(func(cfg RetryConfig, retry int, prev time.Duration) time.Duration {
+		retries = append(retries, retry)
+		prevs = append(prevs, prev)
+		return time.Duration(retry) * time.Millisecond
+	})
+
+	calls := 0
+	fn := func() error {
+		calls++
+		return errors.New("server error")
+	}
+	strategies := map[ErrorClass]BackoffStrategy{ErrorClassServer: strategy}
+
+	start := time.Now()
+	_ = retryWithBackoff(context.Background(), strategies, fn, func(error) ErrorClass { return ErrorClassServer })
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("retries took %v, the strategy's waits were not used", elapsed)
+	}
+
+	if calls != 3 {
+		t.Fatalf("calls = %d, want 3", calls)
+	}
+	if len(retries) != 2 || retries[0] != 1 || retries[1] != 2 {
+		t.Errorf("strategy called for retries %v, want [1 2]", retries)
+	}
+	if len(prevs) != 2 || prevs[0] != 0 || prevs[1] != time.Millisecond {
+		t.Errorf("strategy got previous waits %v, want [0 1ms]", prevs)
+	}
+}
@@ -0,0 +1,80 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+)
+
+// failFastKey is the context key of WithFailFast.
+type failFastKey struct{}
+
+// WithFailFast returns a context whose requests never wait for the rate
+// limiter: while the error budget is in warning or critical state, they are
+// answered from the cache (marked stale if expired) or fail immediately
+// with a BackpressureError instead of being throttled. Meant for proxies
+// that should make backpressure explicit to their callers.
+func WithFailFast(ctx context.Context) context.Context {
+	return context.WithValue(ctx, failFastKey{}, true)
+}
+
+// failFast reports whether ctx was created by WithFailFast.
+func failFast(ctx context.Context) bool {
+	v, _ := ctx.Value(failFastKey{}).(bool)
+	return v
+}
+
+// BackpressureError is returned for WithFailFast requests that would have
+// waited for the rate limiter and have no cached entry. It wraps
+// ErrRateLimited.
+type BackpressureError struct {
+	// RetryAfter is the time until the error limit window resets.
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface.
+func (e *BackpressureError) Error() string {
+	return fmt.Sprintf("error budget low, retry after %s: %v", e.RetryAfter, ErrRateLimited)
+}
+
+// Unwrap implements error unwrapping for errors.Is/As.
+func (e *BackpressureError) Unwrap() error {
+	return ErrRateLimited
+}
+
+// backpressure answers a WithFailFast request while the error budget is low.
+// handled is false if the request may proceed normally.
+func (c *Client) backpressure(ctx context.Context, key cache.CacheKey, cacheable bool) (resp *http.Response, err error, handled bool) {
+	state, err := c.rateLimiter.GetState(ctx)
+	if err != nil || (!state.NeedsThrottling() && !state.NeedsCriticalBlock()) {
+		return nil, nil, false
+	}
+
+	endpoint := c.reportedEndpoint(ctx, key.Endpoint)
+	if cacheable {
+		if entry, err := c.cache.GetStale(ctx, key); err == nil {
+			status := CacheStatusHit
+			if entry.IsStale() {
+				status = CacheStatusStale
+				esiStaleResponsesTotal.WithLabelValues(staleReasonBlocked).Inc()
+			}
+			resp := c.cacheEntryToResponse(entry)
+			resp.Header.Set(CacheStatusHeader, status)
+			return resp, nil, true
+		}
+	}
+
+	retryAfter := max(state.TimeUntilReset().Round(time.Second), time.Second)
+	logger := requestLogger(ctx, c.logger)
+	logger.Warn().
+		Str("endpoint", endpoint).
+		Int("errors_remaining", state.ErrorsRemaining).
+		Dur("retry_after", retryAfter).
+		Msg("Rejecting uncached request while error budget is low")
+	esiRequestsTotal.WithLabelValues(endpoint, "rate_limited").Inc()
+	c.stats.blocked.Add(1)
+	return nil, &BackpressureError{RetryAfter: retryAfter}, true
+}
@@ -0,0 +1,71 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDo_FailFastBackpressure(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	var calls atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		// Below ErrorThresholdWarning: later requests would be throttled
+		w.Header().Set("X-ESI-Error-Limit-Remain", "10")
+		w.Header().Set("X-ESI-Error-Limit-Reset", "30")
+		w.Header().Set("Expires", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+		w.Write([]byte(`{"players":1}`))
+	}))
+	defer server.Close()
+
+	client, err := New(DefaultConfig(redisClient, "TestApp/1.0.0"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+
+	resp, err := client.Get(context.Background(), "/v1/status/")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+
+	ctx := WithFailFast(context.Background())
+	before := calls.Load()
+
+	// Cached entries are served without waiting for the rate limiter
+	resp, err = client.Get(ctx, "/v1/status/")
+	if err != nil {
+		t.Fatalf("Get() cached error = %v", err)
+	}
+	resp.Body.Close()
+	if got := resp.Header.Get(CacheStatusHeader); got != CacheStatusHit {
+		t.Errorf("%s = %q, want %q", CacheStatusHeader, got, CacheStatusHit)
+	}
+
+	// Uncached requests are rejected with the time until the window resets
+	start := time.Now()
+	_, err = client.Get(ctx, "/v1/universe/types/")
+	var bpErr *BackpressureError
+	if !errors.As(err, &bpErr) {
+		t.Fatalf("Get() uncached error = %v, want BackpressureError", err)
+	}
+	if !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Get() error = %v, want ErrRateLimited", err)
+	}
+	if bpErr.RetryAfter < time.Second || bpErr.RetryAfter > 30*time.Second {
+		t.Errorf("RetryAfter = %s, want 1s..30s", bpErr.RetryAfter)
+	}
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Errorf("rejection took %s, want no throttling delay", elapsed)
+	}
+	if calls.Load() != before {
+		t.Error("fail-fast request sent to ESI while the error budget is low")
+	}
+}
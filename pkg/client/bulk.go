@@ -0,0 +1,237 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+)
+
+const (
+	// defaultBulkBatchSize is the ESI limit for IDs per bulk POST.
+	defaultBulkBatchSize = 1000
+
+	// defaultBulkTTL is used when a bulk response carries no Expires header.
+	defaultBulkTTL = time.Hour
+)
+
+// defaultCacheablePosts are the ESI POST endpoints whose response depends only
+// on the request body (lists of IDs or names).
+var defaultCacheablePosts = []string{"universe/names", "universe/ids", "characters/affiliation"}
+
+// BulkRequest describes a call to an ESI bulk POST endpoint such as
+// POST /v3/universe/names/, which takes a JSON array of IDs and returns one
+// element per ID.
+type BulkRequest struct {
+	Endpoint  string        // e.g. "/v3/universe/names/"
+	IDs       []int64       // IDs to resolve (duplicates are ignored)
+	IDField   string        // Element field holding the ID (default: "id")
+	BatchSize int           // Max IDs per POST (default: 1000)
+	TTL       time.Duration // Element TTL without Expires header (default: 1h)
+}
+
+// PostBulk resolves IDs via a bulk POST endpoint. Although the method is
+// POST, the results are cacheable per ID: each response element is cached
+// under its own key, so IDs resolved by one call are reused by any later
+// call regardless of how the IDs were batched. Only IDs missing from the
+// cache are sent to ESI.
+//
+// The result maps each ID to its response element. IDs ESI did not return
+// are absent.
+func (c *Client) PostBulk(ctx context.Context, bulk BulkRequest) (map[int64]json.RawMessage, error) {
+	if bulk.IDField == "" {
+		bulk.IDField = "id"
+	}
+	if bulk.BatchSize <= 0 {
+		bulk.BatchSize = defaultBulkBatchSize
+	}
+	if bulk.TTL <= 0 {
+		bulk.TTL = defaultBulkTTL
+	}
+
+	results := make(map[int64]json.RawMessage, len(bulk.IDs))
+	var missing []int64
+	seen := make(map[int64]bool, len(bulk.IDs))
+	for _, id := range bulk.IDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		entry, err := c.cache.Get(ctx, bulkElementKey(bulk.Endpoint, id))
+		if err != nil {
+			if err != cache.ErrCacheMiss {
+				c.logger.Warn().Err(err).Str("endpoint", c.reportedEndpoint(ctx, bulk.Endpoint)).Msg("Cache get error")
+			}
+			missing = append(missing, id)
+			continue
+		}
+		results[id] = entry.Data
+	}
+
+	c.logger.Debug().
+		Str("endpoint", c.reportedEndpoint(ctx, bulk.Endpoint)).
+		Int("ids", len(seen)).
+		Int("cached", len(results)).
+		Msg("Bulk request")
+
+	for start := 0; start < len(missing); start += bulk.BatchSize {
+		end := min(start+bulk.BatchSize, len(missing))
+		if err := c.postBulkBatch(ctx, bulk, missing[start:end], results); err != nil {
+			return results, err
+		}
+	}
+
+	return results, nil
+}
+
+// postBulkBatch POSTs one batch of IDs and caches each returned element.
+func (c *Client) postBulkBatch(ctx context.Context, bulk BulkRequest, ids []int64, results map[int64]json.RawMessage) error {
+	ctx, cancel := c.withDefaultDeadline(ctx, operationRequest)
+	defer cancel()
+
+	body, err := json.Marshal(ids)
+	if err != nil {
+		return fmt.Errorf("encode ids: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, esiBaseURL+bulk.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req, false)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &ESIError{
+			StatusCode: resp.StatusCode,
+			ErrorClass: c.classifyError(resp, nil),
+			Message:    resp.Status,
+			RequestID:  resp.Header.Get(ESIRequestIDHeader),
+		}
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response body: %w", err)
+	}
+
+	var elements []json.RawMessage
+	if err := json.Unmarshal(data, &elements); err != nil {
+		return fmt.Errorf("decode bulk response: %w", err)
+	}
+
+	expires := time.Now().Add(bulk.TTL)
+	if header := resp.Header.Get("Expires"); header != "" {
+		if t, err := http.ParseTime(header); err == nil {
+			expires = t
+		}
+	}
+
+	for _, element := range elements {
+		id, err := elementID(element, bulk.IDField)
+		if err != nil {
+			c.logger.Warn().Err(err).Str("endpoint", c.reportedEndpoint(ctx, bulk.Endpoint)).Msg("Skipping bulk element")
+			continue
+		}
+		results[id] = element
+
+		entry := &cache.CacheEntry{
+			Data:       element,
+			Expires:    expires,
+			StatusCode: http.StatusOK,
+			CachedAt:   time.Now(),
+		}
+		key := bulkElementKey(bulk.Endpoint, id)
+		if entry.TTL() <= 0 || !c.cacheAllowed(ctx, key, resp, element) {
+			continue
+		}
+		if err := c.cache.Set(ctx, key, entry); err != nil {
+			c.logger.Warn().Err(err).Msg("Failed to cache bulk element")
+		}
+	}
+
+	return nil
+}
+
+// bulkElementKey is the cache key of a single bulk response element. It is
+// a POST key, so a GET with ?id= to the same path never reads it.
+func bulkElementKey(endpoint string, id int64) cache.CacheKey {
+	return cache.CacheKey{
+		Endpoint:    endpoint,
+		Method:      http.MethodPost,
+		QueryParams: url.Values{"id": {strconv.FormatInt(id, 10)}},
+	}
+}
+
+// elementID extracts the numeric ID field from a bulk response element.
+func elementID(element json.RawMessage, field string) (int64, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(element, &fields); err != nil {
+		return 0, fmt.Errorf("decode element: %w", err)
+	}
+
+	raw, ok := fields[field]
+	if !ok {
+		return 0, fmt.Errorf("element has no %q field", field)
+	}
+
+	var id int64
+	if err := json.Unmarshal(raw, &id); err != nil {
+		return 0, fmt.Errorf("decode %q field: %w", field, err)
+	}
+	return id, nil
+}
+
+// isCacheablePost reports whether POSTs to endpoint are cached by body.
+func (c *Client) isCacheablePost(endpoint string) bool {
+	segments := strings.Split(strings.Trim(endpoint, "/"), "/")
+	if len(segments) > 1 && isVersionSegment(segments[0]) {
+		segments = segments[1:]
+	}
+	route := strings.Join(segments, "/")
+
+	return slices.Contains(defaultCacheablePosts, route) || slices.Contains(c.config.CacheablePosts, route)
+}
+
+// requestBody returns a copy of the request body, leaving req.Body readable
+// (and rewindable for retries).
+func requestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("read request body: %w", err)
+		}
+		defer body.Close()
+		return io.ReadAll(body)
+	}
+
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("read request body: %w", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	return data, nil
+}
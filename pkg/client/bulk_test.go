@@ -0,0 +1,222 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPostBulk_CachesPerElement(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	var posted [][]int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("method = %s, want POST", r.Method)
+		}
+		var ids []int64
+		if err := json.NewDecoder(r.Body).Decode(&ids); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		posted = append(posted, ids)
+
+		var names []map[string]any
+		for _, id := range ids {
+			names = append(names, map[string]any{"id": id, "name": fmt.Sprintf("name-%d", id), "category": "character"})
+		}
+		w.Header().Set("X-ESI-Error-Limit-Remain", "100")
+		w.Header().Set("X-ESI-Error-Limit-Reset", "60")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(names)
+	}))
+	defer server.Close()
+
+	client, err := New(DefaultConfig(redisClient, "TestApp/1.0.0"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+
+	ctx := context.Background()
+	first, err := client.PostBulk(ctx, BulkRequest{Endpoint: "/v3/universe/names/", IDs: []int64{1, 2}})
+	if err != nil {
+		t.Fatalf("PostBulk() error = %v", err)
+	}
+	if len(first) != 2 {
+		t.Fatalf("PostBulk() returned %d elements, want 2", len(first))
+	}
+
+	// Differently batched call: only the unknown ID goes to ESI
+	second, err := client.PostBulk(ctx, BulkRequest{Endpoint: "/v3/universe/names/", IDs: []int64{2, 3, 3}, BatchSize: 1})
+	if err != nil {
+		t.Fatalf("PostBulk() error = %v", err)
+	}
+	if len(second) != 2 {
+		t.Fatalf("PostBulk() returned %d elements, want 2", len(second))
+	}
+
+	want := [][]int64{{1, 2}, {3}}
+	if !reflect.DeepEqual(posted, want) {
+		t.Errorf("posted ids = %v, want %v", posted, want)
+	}
+
+	var name struct{ Name string }
+	if err := json.Unmarshal(second[2], &name); err != nil || name.Name != "name-2" {
+		t.Errorf("element 2 = %s, want name-2", second[2])
+	}
+}
+
+func TestDo_RetryRewindsBody(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		w.Header().Set("X-ESI-Error-Limit-Remain", "100")
+		w.Header().Set("X-ESI-Error-Limit-Reset", "60")
+		if len(bodies) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	client, err := New(DefaultConfig(redisClient, "TestApp/1.0.0"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+
+	if _, err := client.PostBulk(context.Background(), BulkRequest{Endpoint: "/v3/universe/names/", IDs: []int64{42}}); err != nil {
+		t.Fatalf("PostBulk() error = %v", err)
+	}
+	// POSTs are not cached as a whole; the retry must resend the body
+	if len(bodies) != 2 || bodies[1] != "[42]" {
+		t.Errorf("request bodies = %q, want the body resent on retry", bodies)
+	}
+}
+
+func TestDo_CacheablePostByBody(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	var requests, notModified int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("X-ESI-Error-Limit-Remain", "100")
+		w.Header().Set("X-ESI-Error-Limit-Reset", "60")
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Expires", time.Now().Add(time.Hour).Format(http.TimeFormat))
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`[{"character_id":1,"corporation_id":1000001}]`))
+	}))
+	defer server.Close()
+
+	cfg := DefaultConfig(redisClient, "TestApp/1.0.0")
+	cfg.CacheablePosts = []string{"custom/lookup"}
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+
+	post := func(endpoint, body string) string {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPost, "https://esi.evetech.net"+endpoint, strings.NewReader(body))
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Do(%s) error = %v", endpoint, err)
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return string(data)
+	}
+
+	// Same IDs in another order are answered by a conditional request
+	first := post("/v2/characters/affiliation/", `[2, 1]`)
+	second := post("/v2/characters/affiliation/", `[1,2]`)
+	if first != second {
+		t.Errorf("cached body = %s, want %s", second, first)
+	}
+	if requests != 2 || notModified != 1 {
+		t.Errorf("requests = %d (304: %d), want 2 (304: 1)", requests, notModified)
+	}
+
+	// Endpoints from Config.CacheablePosts are cached too
+	post("/v1/custom/lookup/", `[1]`)
+	post("/v1/custom/lookup/", `[1]`)
+	if notModified != 2 {
+		t.Errorf("304 responses = %d, want 2", notModified)
+	}
+
+	// Other POSTs are never cached
+	post("/v1/other/", `[1]`)
+	post("/v1/other/", `[1]`)
+	if notModified != 2 {
+		t.Errorf("304 responses = %d, want 2 (uncached POST)", notModified)
+	}
+}
+
+func TestPostBulk_ElementsNotServedToGET(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	var gets, posts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-ESI-Error-Limit-Remain", "100")
+		w.Header().Set("X-ESI-Error-Limit-Reset", "60")
+		w.Header().Set("Expires", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+		if r.Method == http.MethodGet {
+			gets++
+			w.Write([]byte(`{"method":"GET"}`))
+			return
+		}
+		posts++
+		w.Write([]byte(`[{"id":1,"name":"name-1","category":"character"}]`))
+	}))
+	defer server.Close()
+
+	client, err := New(DefaultConfig(redisClient, "TestApp/1.0.0"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+
+	ctx := context.Background()
+	if _, err := client.PostBulk(ctx, BulkRequest{Endpoint: "/v3/universe/names/", IDs: []int64{1}}); err != nil {
+		t.Fatalf("PostBulk() error = %v", err)
+	}
+
+	// Same path and ?id= as the cached element, but a GET
+	resp, err := client.Get(ctx, "/v3/universe/names/?id=1")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if gets != 1 || string(body) != `{"method":"GET"}` {
+		t.Errorf("GET served %q after %d ESI GETs, want the GET response from ESI", body, gets)
+	}
+
+	// ...and the GET response did not replace the cached element
+	elements, err := client.PostBulk(ctx, BulkRequest{Endpoint: "/v3/universe/names/", IDs: []int64{1}})
+	if err != nil {
+		t.Fatalf("PostBulk() error = %v", err)
+	}
+	if posts != 1 || !strings.Contains(string(elements[1]), "name-1") {
+		t.Errorf("element = %s after %d POSTs, want the cached element", elements[1], posts)
+	}
+}
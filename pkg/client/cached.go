@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+)
+
+// GetCached answers a GET to an ESI endpoint purely from the cache: no
+// request to ESI, no rate limit check, no quota. Fresh entries are marked
+// HIT, expired entries kept for MaxStale and preloaded ones STALE. Without
+// an entry it returns ErrCacheMiss, so UIs can render instantly and trigger
+// a refresh with Get separately.
+//
+// The cache key is scoped like Get's, so the context needs the same access
+// token, character and tenant as the request that filled the cache.
+func (c *Client) GetCached(ctx context.Context, endpoint string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, esiBaseURL+endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	if token := AccessTokenFromContext(ctx); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	key, cacheable, err := c.requestCacheKey(req, false)
+	if err != nil {
+		return nil, err
+	}
+	if !cacheable {
+		return nil, ErrCacheMiss
+	}
+
+	entry, err := c.cache.GetStale(ctx, key)
+	if errors.Is(err, cache.ErrCacheMiss) || errors.Is(err, cache.ErrInvalidEntry) {
+		return nil, ErrCacheMiss
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cache lookup: %w", err)
+	}
+
+	status := CacheStatusHit
+	if entry.IsStale() {
+		status = CacheStatusStale
+	}
+	resp := c.cacheEntryToResponse(entry)
+	resp.Header.Set(CacheStatusHeader, status)
+	return resp, nil
+}
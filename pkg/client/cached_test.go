@@ -0,0 +1,75 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetCached(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("X-ESI-Error-Limit-Remain", "100")
+		w.Header().Set("X-ESI-Error-Limit-Reset", "60")
+		w.Header().Set("Expires", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+		w.Write([]byte(`{"players":1}`))
+	}))
+	defer server.Close()
+
+	client, err := New(DefaultConfig(redisClient, "TestApp/1.0.0"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+	client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+
+	ctx := context.Background()
+	if _, err := client.GetCached(ctx, "/v1/status/"); !errors.Is(err, ErrCacheMiss) {
+		t.Fatalf("GetCached() before Get error = %v, want ErrCacheMiss", err)
+	}
+
+	resp, err := client.Get(ctx, "/v1/status/")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+
+	// Critical error budget: Get would be blocked, GetCached still answers
+	headers := http.Header{}
+	headers.Set("X-ESI-Error-Limit-Remain", "1")
+	headers.Set("X-ESI-Error-Limit-Reset", "60")
+	if err := client.rateLimiter.UpdateFromHeaders(ctx, headers); err != nil {
+		t.Fatalf("UpdateFromHeaders() error = %v", err)
+	}
+
+	before := requests.Load()
+	resp, err = client.GetCached(ctx, "/v1/status/")
+	if err != nil {
+		t.Fatalf("GetCached() error = %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != `{"players":1}` {
+		t.Errorf("body = %q, want the cached body", body)
+	}
+	if got := resp.Header.Get(CacheStatusHeader); got != CacheStatusHit {
+		t.Errorf("%s = %q, want %q", CacheStatusHeader, got, CacheStatusHit)
+	}
+	if requests.Load() != before {
+		t.Error("GetCached() sent a request to ESI")
+	}
+
+	// Entries are scoped like Get's: another character has no entry
+	charCtx := WithCharacterID(WithAccessToken(ctx, "token"), 90000001)
+	if _, err := client.GetCached(charCtx, "/v1/status/"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("GetCached() for a character error = %v, want ErrCacheMiss", err)
+	}
+}
@@ -0,0 +1,39 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var esiCacheFilteredTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "esi_cache_filtered_total",
+	Help: "Total number of responses Config.CacheFilter excluded from the cache by endpoint",
+}, []string{"endpoint"})
+
+// cacheAllowed consults Config.CacheFilter before data, the body of resp, is
+// cached under key. The filter sees a copy of resp whose body it may read.
+func (c *Client) cacheAllowed(ctx context.Context, key cache.CacheKey, resp *http.Response, data []byte) bool {
+	if c.config.CacheFilter == nil {
+		return true
+	}
+
+	view := *resp
+	view.Header = resp.Header.Clone()
+	view.Body = io.NopCloser(bytes.NewReader(data))
+	view.ContentLength = int64(len(data))
+	if c.config.CacheFilter(key, &view) {
+		return true
+	}
+
+	endpoint := c.reportedEndpoint(ctx, key.Endpoint)
+	esiCacheFilteredTotal.WithLabelValues(endpoint).Inc()
+	logger := requestLogger(ctx, c.logger)
+	logger.Debug().Str("endpoint", endpoint).Msg("Response excluded from cache by CacheFilter")
+	return false
+}
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+)
+
+// skipEmptyArrays is a CacheFilter keeping empty JSON arrays out of the cache.
+func skipEmptyArrays(key cache.CacheKey, resp *http.Response) bool {
+	body, err := io.ReadAll(resp.Body)
+	return err != nil || strings.TrimSpace(string(body)) != "[]"
+}
+
+func TestCacheAllowed(t *testing.T) {
+	resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{"Content-Type": {"application/json"}}, Body: io.NopCloser(strings.NewReader("[]"))}
+	key := cache.CacheKey{Endpoint: "/v1/universe/structures/"}
+
+	c := &Client{}
+	if !c.cacheAllowed(context.Background(), key, resp, []byte("[]")) {
+		t.Error("cacheAllowed() = false without CacheFilter")
+	}
+
+	c.config.CacheFilter = skipEmptyArrays
+	if c.cacheAllowed(context.Background(), key, resp, []byte("[]")) {
+		t.Error("cacheAllowed() = true for a vetoed response")
+	}
+	if !c.cacheAllowed(context.Background(), key, resp, []byte("[1035466617946]")) {
+		t.Error("cacheAllowed() = false for an allowed response")
+	}
+
+	// The filter reads a copy; the caller's body is untouched
+	if body, _ := io.ReadAll(resp.Body); string(body) != "[]" {
+		t.Errorf("caller's body after cacheAllowed() = %q", body)
+	}
+}
+
+func TestDo_CacheFilter(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-ESI-Error-Limit-Remain", "100")
+		w.Header().Set("X-ESI-Error-Limit-Reset", "60")
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Expires", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+		_, _ = w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+
+	cfg := DefaultConfig(redisClient, "TestApp/1.0.0")
+	cfg.CacheFilter = skipEmptyArrays
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+
+	req, _ := http.NewRequest(http.MethodGet, "https://esi.evetech.net/v1/universe/structures/", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "[]" {
+		t.Errorf("body = %q, want []", body)
+	}
+
+	if _, err := client.cache.Get(context.Background(), cache.CacheKey{Endpoint: "/v1/universe/structures/"}); err != cache.ErrCacheMiss {
+		t.Errorf("cache.Get() error = %v, want ErrCacheMiss for a vetoed response", err)
+	}
+}
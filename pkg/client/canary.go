@@ -0,0 +1,125 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var esiCanaryChecksTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "esi_canary_checks_total",
+	Help: "Cache hits compared with a forced live fetch by endpoint pattern, hit type (revalidated, suppressed) and result (match, mismatch, error)",
+}, []string{"endpoint", "hit", "result"})
+
+// Kinds of cache hits checked by canaries (esi_canary_checks_total label).
+const (
+	canaryHitRevalidated = "revalidated" // ESI answered 304 to a conditional request
+	canaryHitSuppressed  = "suppressed"  // Served within the minimum refresh interval
+)
+
+// Results of canary checks (esi_canary_checks_total label).
+const (
+	canaryResultMatch    = "match"
+	canaryResultMismatch = "mismatch"
+	canaryResultError    = "error"
+)
+
+// canaryTimeout bounds a canary's live fetch.
+const canaryTimeout = 30 * time.Second
+
+// validateCanary checks Config.CanarySampleRate.
+func validateCanary(cfg Config) error {
+	if cfg.CanarySampleRate < 0 || cfg.CanarySampleRate > 1 {
+		return fmt.Errorf("canary_sample_rate must be between 0 and 1 (got %v)", cfg.CanarySampleRate)
+	}
+	return nil
+}
+
+// startCanaries creates the context canaries run in until Close.
+func (c *Client) startCanaries() {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.canaryCtx = ctx
+	c.onShutdown("canaries", func(context.Context) { cancel() })
+}
+
+// maybeCanary checks a sampled cache hit of req against a forced live fetch
+// in the background. Only public GETs are checked, one at a time; hits
+// sampled while a canary runs are skipped.
+func (c *Client) maybeCanary(ctx context.Context, req *http.Request, entry *cache.CacheEntry, hit string) {
+	if c.canaryCtx == nil || rand.Float64() >= c.config.CanarySampleRate {
+		return
+	}
+	if req.Method != http.MethodGet || req.Header.Get("Authorization") != "" || forceRefresh(ctx) {
+		return
+	}
+	if !c.canaryBusy.CompareAndSwap(false, true) {
+		return
+	}
+
+	endpoint, pattern := req.URL.RequestURI(), cache.EndpointPattern(req.URL.Path)
+	if c.reportedEndpoint(ctx, endpoint) == privateEndpoint {
+		pattern = privateEndpoint
+	}
+	go func() {
+		defer c.canaryBusy.Store(false)
+		c.runCanary(context.WithoutCancel(ctx), endpoint, pattern, entry, hit)
+	}()
+}
+
+// runCanary fetches endpoint with WithForceRefresh and low priority and
+// compares the body with the cached one. The live response replaces the
+// cached entry. A mismatch means a cache hit served data ESI had replaced.
+func (c *Client) runCanary(ctx context.Context, endpoint, pattern string, cached *cache.CacheEntry, hit string) {
+	ctx, cancel := context.WithTimeout(WithPriority(WithForceRefresh(ctx), PriorityLow), canaryTimeout)
+	defer cancel()
+	stop := context.AfterFunc(c.canaryCtx, cancel)
+	defer stop()
+
+	logger := requestLogger(ctx, c.logger)
+
+	body, header, err := c.canaryFetch(ctx, endpoint)
+	if err != nil {
+		esiCanaryChecksTotal.WithLabelValues(pattern, hit, canaryResultError).Inc()
+		logger.Debug().Err(err).Str("endpoint", c.reportedEndpoint(ctx, endpoint)).Msg("Canary fetch failed")
+		return
+	}
+
+	if sameBody(cached.Data, body) {
+		esiCanaryChecksTotal.WithLabelValues(pattern, hit, canaryResultMatch).Inc()
+		return
+	}
+	esiCanaryChecksTotal.WithLabelValues(pattern, hit, canaryResultMismatch).Inc()
+	logger.Warn().
+		Str("endpoint", c.reportedEndpoint(ctx, endpoint)).
+		Str("hit", hit).
+		Str("cached_etag", cached.ETag).
+		Str("live_etag", header.Get("ETag")).
+		Time("cached_at", cached.CachedAt).
+		Time("expires", cached.Expires).
+		Msg("Canary mismatch - cache hit differs from live response")
+}
+
+// canaryFetch returns the body and header of a successful live fetch.
+func (c *Client) canaryFetch(ctx context.Context, endpoint string) ([]byte, http.Header, error) {
+	resp, err := c.Get(ctx, endpoint)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read response body: %w", err)
+	}
+	return body, resp.Header, nil
+}
@@ -0,0 +1,98 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestValidateCanary(t *testing.T) {
+	for rate, wantErr := range map[float64]bool{0: false, 0.01: false, 1: false, -0.1: true, 1.5: true} {
+		if err := validateCanary(Config{CanarySampleRate: rate}); (err != nil) != wantErr {
+			t.Errorf("validateCanary(%v) error = %v, wantErr %v", rate, err, wantErr)
+		}
+	}
+}
+
+// canaryCount returns esi_canary_checks_total for the labels.
+func canaryCount(t *testing.T, endpoint, hit, result string) float64 {
+	t.Helper()
+	var m dto.Metric
+	if err := esiCanaryChecksTotal.WithLabelValues(endpoint, hit, result).Write(&m); err != nil {
+		t.Fatalf("read counter: %v", err)
+	}
+	return m.GetCounter().GetValue()
+}
+
+func TestDo_CanaryDetectsStaleValidator(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	// ESI keeps answering 304 for "v1" although the body changed: a
+	// validator bug only a full download reveals
+	var body atomic.Value
+	body.Store(`{"players":1}`)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-ESI-Error-Limit-Remain", "100")
+		w.Header().Set("X-ESI-Error-Limit-Reset", "60")
+		w.Header().Set("Expires", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte(body.Load().(string)))
+	}))
+	defer server.Close()
+
+	cfg := DefaultConfig(redisClient, "TestApp/1.0.0")
+	cfg.CanarySampleRate = 1
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+	client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+
+	get := func() {
+		t.Helper()
+		resp, err := client.Get(context.Background(), "/v1/status/")
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	waitForCanary := func() {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for client.canaryBusy.Load() {
+			if time.Now().After(deadline) {
+				t.Fatal("canary did not finish")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	match := canaryCount(t, "/v1/status/", canaryHitRevalidated, canaryResultMatch)
+	mismatch := canaryCount(t, "/v1/status/", canaryHitRevalidated, canaryResultMismatch)
+
+	get() // Miss, cached
+	get() // 304 hit, canary matches
+	waitForCanary()
+	if got := canaryCount(t, "/v1/status/", canaryHitRevalidated, canaryResultMatch) - match; got != 1 {
+		t.Errorf("matching canaries = %v, want 1", got)
+	}
+
+	body.Store(`{"players":2}`)
+	get() // 304 hit with the old body, canary finds the new one
+	waitForCanary()
+	if got := canaryCount(t, "/v1/status/", canaryHitRevalidated, canaryResultMismatch) - mismatch; got != 1 {
+		t.Errorf("mismatching canaries = %v, want 1", got)
+	}
+}
@@ -0,0 +1,227 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// capturesKey is the Redis list holding debug captures, newest first. Like
+// the journal it lives on Config.Redis, not the cache Redis.
+const capturesKey = "esi:captures"
+
+const (
+	// defaultCaptureMaxEntries is the capture list length if
+	// Config.CaptureMaxEntries is 0.
+	defaultCaptureMaxEntries = 1000
+
+	// captureMaxAge is how long captures are kept after the last one.
+	captureMaxAge = 24 * time.Hour
+
+	// captureMaxBody is the number of body bytes captured per request and
+	// response; longer bodies are truncated.
+	captureMaxBody = 1 << 20
+)
+
+// redacted replaces secrets in captured headers and query strings.
+const redacted = "REDACTED"
+
+// redactedHeaders are request and response headers whose values are never
+// captured.
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// redactedParams are query parameters whose values are never captured
+// (ESI accepts the access token as ?token=).
+var redactedParams = []string{"token"}
+
+// Capture is one request/response exchange with ESI recorded in debug
+// capture mode (see Config.CaptureSampleRate). Secrets are redacted.
+type Capture struct {
+	Time           time.Time     `json:"time"`
+	RequestID      string        `json:"request_id,omitempty"` // Caller's request ID (WithRequestID)
+	Attempt        int           `json:"attempt"`              // 1 for the first attempt, higher for retries
+	Method         string        `json:"method"`
+	URL            string        `json:"url"`
+	RequestHeader  http.Header   `json:"request_header,omitempty"`
+	RequestBody    string        `json:"request_body,omitempty"`
+	Status         int           `json:"status,omitempty"` // 0 if no response was received
+	ResponseHeader http.Header   `json:"response_header,omitempty"`
+	ResponseBody   string        `json:"response_body,omitempty"`
+	Truncated      bool          `json:"truncated,omitempty"` // A body exceeded the capture limit
+	Error          string        `json:"error,omitempty"`     // Transport error
+	Duration       time.Duration `json:"duration"`
+}
+
+// validateCapture checks the debug capture settings.
+func validateCapture(cfg Config) error {
+	if cfg.CaptureSampleRate < 0 || cfg.CaptureSampleRate > 1 {
+		return fmt.Errorf("capture_sample_rate must be between 0 and 1 (got %v)", cfg.CaptureSampleRate)
+	}
+	if cfg.CaptureMaxEntries < 0 {
+		return fmt.Errorf("capture_max_entries must not be negative (got %d)", cfg.CaptureMaxEntries)
+	}
+	return nil
+}
+
+// shouldCapture decides whether the exchanges of a request to endpoint are
+// captured: always for CaptureRoutes, otherwise sampled.
+func (c *Client) shouldCapture(endpoint string) bool {
+	if len(c.config.CaptureRoutes) > 0 {
+		segments := strings.Split(strings.Trim(endpoint, "/"), "/")
+		if len(segments) > 1 && isVersionSegment(segments[0]) {
+			segments = segments[1:]
+		}
+		route := strings.Join(segments, "/") + "/"
+		if slices.ContainsFunc(c.config.CaptureRoutes, func(prefix string) bool {
+			return strings.HasPrefix(route, strings.TrimLeft(prefix, "/"))
+		}) {
+			return true
+		}
+	}
+	return c.config.CaptureSampleRate > 0 && rand.Float64() < c.config.CaptureSampleRate
+}
+
+// capture records one attempt of req. The response body is buffered up to
+// captureMaxBody and stays readable for the caller. Failures to store the
+// capture are logged and never affect the request.
+func (c *Client) capture(ctx context.Context, req *http.Request, attempt int, resp *http.Response, reqErr error, duration time.Duration) {
+	record := Capture{
+		Time:          time.Now(),
+		RequestID:     RequestIDFromContext(ctx),
+		Attempt:       attempt,
+		Method:        req.Method,
+		URL:           redactURL(req),
+		RequestHeader: redactHeader(req.Header),
+		Duration:      duration,
+	}
+	if record.Method == "" {
+		record.Method = http.MethodGet
+	}
+
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			data, truncated := readCaptured(body)
+			body.Close()
+			record.RequestBody, record.Truncated = string(data), truncated
+		}
+	}
+
+	if reqErr != nil {
+		record.Error = reqErr.Error()
+	}
+	if resp != nil {
+		record.Status = resp.StatusCode
+		record.ResponseHeader = redactHeader(resp.Header)
+		if resp.Body != nil {
+			data, truncated := readCaptured(resp.Body)
+			resp.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(data), resp.Body), resp.Body}
+			record.ResponseBody = string(data)
+			record.Truncated = record.Truncated || truncated
+		}
+	}
+
+	if err := c.storeCapture(context.WithoutCancel(ctx), record); err != nil {
+		logger := requestLogger(ctx, c.logger)
+		logger.Warn().Err(err).Str("endpoint", c.reportedEndpoint(ctx, req.URL.Path)).Msg("Failed to store debug capture")
+	}
+}
+
+// readCaptured reads up to captureMaxBody bytes of r. truncated reports
+// whether r holds more.
+func readCaptured(r io.Reader) (data []byte, truncated bool) {
+	data, _ = io.ReadAll(io.LimitReader(r, captureMaxBody+1))
+	if len(data) > captureMaxBody {
+		return data[:captureMaxBody], true
+	}
+	return data, false
+}
+
+// storeCapture prepends record to the capture list and trims it.
+func (c *Client) storeCapture(ctx context.Context, record Capture) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("marshal capture: %w", err)
+	}
+
+	maxEntries := c.config.CaptureMaxEntries
+	if maxEntries == 0 {
+		maxEntries = defaultCaptureMaxEntries
+	}
+
+	pipe := c.redis.TxPipeline()
+	pipe.LPush(ctx, capturesKey, data)
+	pipe.LTrim(ctx, capturesKey, 0, int64(maxEntries-1))
+	pipe.Expire(ctx, capturesKey, captureMaxAge)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("write capture: %w", err)
+	}
+	return nil
+}
+
+// Captures returns the stored debug captures, newest first.
+func (c *Client) Captures(ctx context.Context) ([]Capture, error) {
+	values, err := c.redis.LRange(ctx, capturesKey, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("read captures: %w", err)
+	}
+
+	captures := make([]Capture, 0, len(values))
+	for _, value := range values {
+		var record Capture
+		if err := json.Unmarshal([]byte(value), &record); err != nil {
+			c.logger.Warn().Err(err).Msg("Skipping invalid debug capture")
+			continue
+		}
+		captures = append(captures, record)
+	}
+	return captures, nil
+}
+
+// ClearCaptures deletes all stored debug captures.
+func (c *Client) ClearCaptures(ctx context.Context) error {
+	if err := c.redis.Del(ctx, capturesKey).Err(); err != nil {
+		return fmt.Errorf("clear captures: %w", err)
+	}
+	return nil
+}
+
+// redactHeader returns a copy of h with secret values replaced.
+func redactHeader(h http.Header) http.Header {
+	if h == nil {
+		return nil
+	}
+	clone := h.Clone()
+	for _, name := range redactedHeaders {
+		if clone.Get(name) != "" {
+			clone.Set(name, redacted)
+		}
+	}
+	return clone
+}
+
+// redactURL returns the request URL with secret query parameters replaced.
+func redactURL(req *http.Request) string {
+	u := *req.URL
+	query := u.Query()
+	changed := false
+	for _, name := range redactedParams {
+		if query.Has(name) {
+			query.Set(name, redacted)
+			changed = true
+		}
+	}
+	if changed {
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDo_Capture(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-ESI-Error-Limit-Remain", "100")
+		w.Header().Set("X-ESI-Error-Limit-Reset", "60")
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Write([]byte(`{"players":1}`))
+	}))
+	defer server.Close()
+
+	cfg := DefaultConfig(redisClient, "TestApp/1.0.0")
+	cfg.CaptureRoutes = []string{"status/"}
+	cfg.CaptureMaxEntries = 2
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+	client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+
+	get := func(endpoint string) {
+		t.Helper()
+		req, _ := http.NewRequestWithContext(WithRequestID(context.Background(), "req-1"), http.MethodGet, "https://esi.evetech.net"+endpoint, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Do(%s) error = %v", endpoint, err)
+		}
+		defer resp.Body.Close()
+		if body, _ := io.ReadAll(resp.Body); string(body) != `{"players":1}` {
+			t.Errorf("body = %q, want the full response", body)
+		}
+	}
+
+	get("/v1/status/?token=secret")
+	get("/v1/universe/types/") // not captured (sample rate 0)
+
+	captures, err := client.Captures(context.Background())
+	if err != nil {
+		t.Fatalf("Captures() error = %v", err)
+	}
+	if len(captures) != 1 {
+		t.Fatalf("captures = %d, want 1", len(captures))
+	}
+	got := captures[0]
+	if got.Status != http.StatusOK || got.ResponseBody != `{"players":1}` || got.RequestID != "req-1" || got.Attempt != 1 {
+		t.Errorf("capture = %+v, want 200 with body, request ID and attempt 1", got)
+	}
+	if strings.Contains(got.URL, "secret") || !strings.Contains(got.URL, "token="+redacted) {
+		t.Errorf("URL = %q, want token redacted", got.URL)
+	}
+	if got.RequestHeader.Get("Authorization") != redacted || got.ResponseHeader.Get("Set-Cookie") != redacted {
+		t.Errorf("headers not redacted: request %v, response %v", got.RequestHeader, got.ResponseHeader)
+	}
+
+	// Only the newest CaptureMaxEntries are kept
+	get("/v1/status/")
+	get("/v1/status/")
+	if captures, _ := client.Captures(context.Background()); len(captures) != 2 {
+		t.Errorf("captures = %d, want 2", len(captures))
+	}
+
+	if err := client.ClearCaptures(context.Background()); err != nil {
+		t.Fatalf("ClearCaptures() error = %v", err)
+	}
+	if captures, _ := client.Captures(context.Background()); len(captures) != 0 {
+		t.Errorf("captures after clear = %d, want 0", len(captures))
+	}
+}
+
+func TestReadCaptured_Truncates(t *testing.T) {
+	data, truncated := readCaptured(strings.NewReader(strings.Repeat("x", captureMaxBody+10)))
+	if len(data) != captureMaxBody || !truncated {
+		t.Errorf("readCaptured() = %d bytes, truncated %v, want %d, true", len(data), truncated, captureMaxBody)
+	}
+}
+
+func TestNew_CaptureValidation(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	for _, rate := range []float64{-0.1, 1.5} {
+		cfg := DefaultConfig(redisClient, "TestApp/1.0.0")
+		cfg.CaptureSampleRate = rate
+		if _, err := New(cfg); err == nil {
+			t.Errorf("New() with capture_sample_rate %v: expected error", rate)
+		}
+	}
+}
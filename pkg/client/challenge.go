@@ -0,0 +1,94 @@
+package client
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// defaultChallengeBackoff is how long no requests are sent to ESI after a
+// Cloudflare challenge when Config.ChallengeBackoff is unset. Challenges
+// appear during incidents and attacks and last minutes, not seconds.
+const defaultChallengeBackoff = 5 * time.Minute
+
+// challengeSniffSize is the number of body bytes inspected when a response
+// has no Content-Type.
+const challengeSniffSize = 512
+
+// esiChallengeResponsesTotal counts Cloudflare challenge and other HTML
+// responses received instead of ESI JSON.
+var esiChallengeResponsesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "esi_challenge_responses_total",
+	Help: "Total number of Cloudflare challenge or HTML responses received instead of ESI JSON by status",
+}, []string{"status"})
+
+// validateChallengeBackoff checks Config.ChallengeBackoff.
+func validateChallengeBackoff(cfg Config) error {
+	if cfg.ChallengeBackoff < 0 {
+		return fmt.Errorf("challenge_backoff must not be negative (got %s)", cfg.ChallengeBackoff)
+	}
+	return nil
+}
+
+// isChallenge reports whether resp is a Cloudflare challenge or another
+// HTML page instead of an ESI response. ESI answers JSON (or nothing) on
+// every route, so HTML is never a valid payload. Responses without
+// Content-Type are sniffed; resp.Body still yields the whole body afterwards.
+func isChallenge(resp *http.Response) bool {
+	if strings.EqualFold(resp.Header.Get("Cf-Mitigated"), "challenge") {
+		return true
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		if resp.Body == nil || resp.Body == http.NoBody {
+			return false
+		}
+		buffered := bufio.NewReaderSize(resp.Body, challengeSniffSize)
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{buffered, resp.Body}
+		head, _ := buffered.Peek(challengeSniffSize)
+		contentType = http.DetectContentType(head)
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "text/html" || mediaType == "application/xhtml+xml")
+}
+
+// beginChallengeBackoff stops requests to ESI for Config.ChallengeBackoff
+// and logs one error per backoff, the alert event for operators.
+func (c *Client) beginChallengeBackoff(endpoint string, status int) time.Time {
+	backoff := c.config.ChallengeBackoff
+	if backoff == 0 {
+		backoff = defaultChallengeBackoff
+	}
+	until := time.Now().Add(backoff)
+	if c.challenge.begin(until) {
+		c.logger.Error().
+			Str("endpoint", endpoint).
+			Int("status", status).
+			Time("until", until).
+			Msg("Cloudflare challenge instead of ESI response - pausing ESI requests")
+	}
+	return until
+}
+
+// challengeError is the error of requests answered with, or held back
+// because of, a Cloudflare challenge.
+func challengeError(status int, until time.Time) *ESIError {
+	return &ESIError{
+		StatusCode: status,
+		ErrorClass: ErrorClassChallenge,
+		Message:    fmt.Sprintf("Cloudflare challenge, ESI requests paused until %s", until.UTC().Format(time.RFC3339)),
+		Err:        ErrChallenge,
+	}
+}
@@ -0,0 +1,89 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIsChallenge(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		body   string
+		want   bool
+	}{
+		{"cf-mitigated", http.Header{"Cf-Mitigated": {"challenge"}, "Content-Type": {"application/json"}}, `{}`, true},
+		{"html", http.Header{"Content-Type": {"text/html; charset=UTF-8"}}, `<html></html>`, true},
+		{"xhtml", http.Header{"Content-Type": {"application/xhtml+xml"}}, `<html></html>`, true},
+		{"json", http.Header{"Content-Type": {"application/json; charset=UTF-8"}}, `{"error":"not found"}`, false},
+		{"sniffed html", http.Header{}, `<!DOCTYPE html><html><head><title>Just a moment...</title></head></html>`, true},
+		{"sniffed json", http.Header{}, `{"players":1}`, false},
+		{"empty", http.Header{}, ``, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: http.StatusForbidden, Header: tt.header, Body: io.NopCloser(strings.NewReader(tt.body))}
+			if got := isChallenge(resp); got != tt.want {
+				t.Errorf("isChallenge() = %v, want %v", got, tt.want)
+			}
+			if body, _ := io.ReadAll(resp.Body); string(body) != tt.body {
+				t.Errorf("body after isChallenge() = %q, want %q", body, tt.body)
+			}
+		})
+	}
+}
+
+func TestValidateChallengeBackoff(t *testing.T) {
+	if err := validateChallengeBackoff(Config{}); err != nil {
+		t.Errorf("default backoff error = %v", err)
+	}
+	if err := validateChallengeBackoff(Config{ChallengeBackoff: -time.Second}); err == nil {
+		t.Error("negative backoff accepted")
+	}
+}
+
+func TestDo_ChallengePausesRequests(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+		w.Header().Set("Cf-Mitigated", "challenge")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`<!DOCTYPE html><title>Just a moment...</title>`))
+	}))
+	defer server.Close()
+
+	cfg := DefaultConfig(redisClient, "TestApp/1.0.0")
+	cfg.ChallengeBackoff = time.Minute
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+
+	for i := 0; i < 3; i++ {
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, "https://esi.evetech.net/v1/status/", nil)
+		_, err := client.Do(req)
+		if !errors.Is(err, ErrChallenge) {
+			t.Fatalf("Do() #%d error = %v, want ErrChallenge", i, err)
+		}
+		if code := ErrorCodeOf(err); code != ErrorCodeUpstreamDown {
+			t.Errorf("ErrorCodeOf() = %q, want %q", code, ErrorCodeUpstreamDown)
+		}
+	}
+
+	// Neither retried nor repeated during the backoff
+	if n := requests.Load(); n != 1 {
+		t.Errorf("server received %d requests, want 1", n)
+	}
+}
@@ -4,12 +4,15 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+	"github.com/Sternrassler/eve-esi-client/pkg/codec"
 	"github.com/Sternrassler/eve-esi-client/pkg/ratelimit"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
@@ -27,15 +30,20 @@ var (
 
 	esiRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
 		Name:    "esi_request_duration_seconds",
-		Help:    "ESI request duration in seconds by endpoint",
+		Help:    "ESI request duration in seconds by endpoint and upstream status class (2xx, 304, 4xx, 5xx, error)",
 		Buckets: []float64{0.1, 0.5, 1, 2, 5, 10},
-	}, []string{"endpoint"})
+	}, []string{"endpoint", "status_class"})
 
 	esiErrorsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "esi_errors_total",
 		Help: "Total ESI errors by class",
 	}, []string{"class"})
 
+	esiNetworkErrorsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "esi_network_errors_total",
+		Help: "Total ESI network errors by subclass (dns, connect_timeout, connect, tls, read_timeout, other)",
+	}, []string{"subclass"})
+
 	esiRetriesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "esi_retries_total",
 		Help: "Total number of retry attempts by error class",
@@ -51,6 +59,42 @@ var (
 		Name: "esi_retry_exhausted_total",
 		Help: "Total number of times retry attempts were exhausted by error class",
 	}, []string{"error_class"})
+
+	esiRetryDeadlineSkipsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "esi_retry_deadline_skips_total",
+		Help: "Total number of retries skipped because the backoff exceeded the remaining context deadline",
+	}, []string{"error_class"})
+
+	esiInteractiveRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "esi_interactive_requests_total",
+		Help: "Total uncached interactive ESI requests (fleets, ui) by group and status",
+	}, []string{"group", "status"})
+
+	esiHedgedRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "esi_hedged_requests_total",
+		Help: "Total hedged interactive requests by group and winning attempt",
+	}, []string{"group", "winner"})
+
+	esiInteractiveRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "esi_interactive_request_duration_seconds",
+		Help:    "Interactive ESI request duration in seconds by group",
+		Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 5},
+	}, []string{"group"})
+)
+
+// esiBaseURL is the ESI API host all endpoint paths are resolved against.
+const esiBaseURL = "https://esi.evetech.net"
+
+// CacheStatusHeader reports on every response returned by Do how the cache
+// was involved, so proxies and callers can account for cache traffic.
+const CacheStatusHeader = "X-ESI-Client-Cache"
+
+// Values of CacheStatusHeader.
+const (
+	CacheStatusHit    = "HIT"    // Body served from cache (revalidated with 304)
+	CacheStatusMiss   = "MISS"   // Body downloaded from ESI and cached if possible
+	CacheStatusBypass = "BYPASS" // Not cacheable (interactive endpoint, plain POST)
+	CacheStatusStale  = "STALE"  // Expired or preloaded entry served because ESI is unavailable
 )
 
 // ErrorClass represents a classification of HTTP errors.
@@ -68,6 +112,10 @@ const (
 
 	// ErrorClassNetwork represents network/timeout errors.
 	ErrorClassNetwork ErrorClass = "network"
+
+	// ErrorClassChallenge represents Cloudflare challenges and other HTML
+	// pages returned instead of ESI JSON.
+	ErrorClassChallenge ErrorClass = "challenge"
 )
 
 // Client is the main ESI client.
@@ -76,8 +124,28 @@ type Client struct {
 	redis       *redis.Client
 	rateLimiter *ratelimit.Tracker
 	cache       *cache.Manager
+	quotas      []*ratelimit.Quota
 	config      Config
 	logger      zerolog.Logger
+	hedges      hedgeTracker
+	stats       clientStats
+	downtime    downtimeState
+	challenge   downtimeState // ESI requests paused after a Cloudflare challenge
+	incident    incidentState
+	inFlight    *byteBudget // nil without MaxInFlightBytes
+	watch       requestWatch
+	shutdown    shutdownState
+	warm        warmPool
+	prefetch    prefetcher
+	flights     flights
+	load        loadCounters
+	slos        []*sloTracker
+
+	canaryCtx  context.Context // cancelled by Close; nil while canaries are off
+	canaryBusy atomic.Bool     // a canary is running
+
+	cacheRedis     *redis.Client // Redis for cache data (may be redis)
+	ownsCacheRedis bool          // cacheRedis was created from CacheRedisDB and is closed by Close
 }
 
 // Config holds the client configuration.
@@ -85,6 +153,22 @@ type Config struct {
 	// Redis client for caching and rate limit state
 	Redis *redis.Client
 
+	// Separate Redis for cache data (optional), so rate limit state can live on
+	// a persistent instance (AOF) while the cache uses a volatile one with its
+	// own eviction policy. CacheRedisDB instead selects another logical DB on
+	// the Redis connection. Default: cache shares Redis.
+	CacheRedis   *redis.Client
+	CacheRedisDB int
+
+	// Read replica of the cache Redis (optional): cache reads go to it,
+	// writes and deletes to the primary. Entries this client wrote within
+	// CacheReplicaMaxLag are read from the primary (default:
+	// cache.DefaultReplicaMaxLag); with CacheReplicaMissFallback replica
+	// misses are retried on the primary. Not closed by Close.
+	CacheReplica             *redis.Client
+	CacheReplicaMaxLag       time.Duration
+	CacheReplicaMissFallback bool
+
 	// User-Agent header (REQUIRED by ESI)
 	// Format: "AppName/Version (contact@example.com)"
 	UserAgent string
@@ -93,16 +177,237 @@ type Config struct {
 	RateLimit      int // Requests per second
 	ErrorThreshold int // Stop requests when errors remaining < threshold
 
+	// Soft quotas (e.g. hourly/daily) shared by the whole deployment via Redis
+	Quotas []ratelimit.QuotaConfig
+
 	// Concurrency
 	MaxConcurrency int // Max parallel requests
 
+	// Smooths request emission across all callers, so parallel pagination
+	// workers don't send their first requests in one burst (optional)
+	Limiter Limiter
+
+	// Rejects requests early with a ShedError based on their priority (see
+	// WithPriority), the error limit state, and the requests in flight and
+	// queued, e.g. PriorityShedder (optional)
+	LoadShedder LoadShedder
+
 	// Caching
 	MemoryCacheTTL time.Duration // In-memory cache TTL
 	RespectExpires bool          // Honor ESI expires header (MUST be true)
+	MaxStale       time.Duration // Keep expired entries this long to serve them during ESI downtime (0 = off)
+	Codec          codec.Codec   // JSON implementation for cache entries (default: encoding/json)
+
+	// Read cache entries stored under legacy key formats (cache.KeyMigrations)
+	// this long after start, moving them to the current key, so upgrades
+	// that change the key format don't refetch the whole cache (0 = off)
+	LegacyKeyGrace time.Duration
+
+	// Keep the ETag and Last-Modified of up to ValidatorIndexSize entries in
+	// memory, so conditional requests are made without reading the whole
+	// entry from Redis first; the entry is read only if ESI answers 304
+	// (0 = off)
+	ValidatorIndexSize int
+
+	// Store response bodies of at least SplitBodySize bytes under a separate
+	// Redis key from their metadata, so TTL updates after a 304 don't
+	// rewrite multi-hundred-KB bodies (0 = store entries whole)
+	SplitBodySize int
+
+	// Conditional requests to LastModifiedRoutes (prefixes matched like
+	// "markets/") send If-Modified-Since instead of If-None-Match, and pages
+	// without Last-Modified borrow page 1's: for route families whose pages
+	// carry page-scoped ETags but share Last-Modified
+	LastModifiedRoutes []string
+
+	// Response headers stored with cache entries and replayed on hits
+	// (default: cache.DefaultCachedHeaders)
+	CacheHeaders cache.HeaderFilter
+
+	// Consulted before a fresh response is cached; returning false keeps it
+	// out of the cache, e.g. empty arrays of endpoints not yet populated
+	// after downtime that would otherwise stick until Expires. The response
+	// body may be read. Called concurrently (optional)
+	CacheFilter func(key cache.CacheKey, resp *http.Response) bool
+
+	// Fail requests carrying Authorization without a character scope (see
+	// WithCharacterID) instead of bypassing the cache for them
+	StrictAuthCache bool
+
+	// Serve cached entries (expired ones up to MaxStale, marked stale) instead
+	// of failing when ESI keeps erroring or the rate limiter blocks requests
+	ServeStaleOnError bool
+
+	// Pause all ESI requests this long after a Cloudflare challenge or other
+	// HTML page arrives instead of ESI JSON (default: 5m). Such responses are
+	// never cached nor retried.
+	ChallengeBackoff time.Duration
+
+	// Poll this status feed (ESIStatusURL or a Statuspage status.json) every
+	// IncidentPollInterval (default: 1m) and enter incident mode while it
+	// declares an incident: at most IncidentMaxConcurrency (default: 2)
+	// requests to ESI at once, no retries, and cached entries served on
+	// errors as with ServeStaleOnError (optional)
+	IncidentStatusURL      string
+	IncidentPollInterval   time.Duration
+	IncidentMaxConcurrency int
+
+	// Additional POST endpoints whose responses are cached by request body,
+	// matched like "universe/names" without version prefix. Always cached:
+	// universe/names, universe/ids, characters/affiliation.
+	CacheablePosts []string
+
+	// Minimum time between ESI refreshes by route prefix, matched without
+	// version like "markets/" (longest prefix wins). Polls of a cached entry
+	// fetched or revalidated more recently are answered from the cache
+	// without contacting ESI, e.g. for consumers polling faster than useful.
+	MinRefreshIntervals map[string]time.Duration
+
+	// Version /latest/ currently resolves to by route prefix, matched like
+	// "markets/" (longest prefix wins), e.g. {"markets/": "v1"}. Responses
+	// fetched via /latest/ and the pinned version share one cache entry;
+	// requests still go to the path the caller used. Default: /latest/ is
+	// cached separately.
+	LatestVersions map[string]string
+
+	// TTL for responses without Expires header (default: cache.DefaultTTL)
+	// and overrides by route prefix, matched without version like
+	// "universe/" (longest prefix wins), e.g. a minute for "status/" and a
+	// day for static universe data.
+	DefaultTTL   time.Duration
+	FallbackTTLs map[string]time.Duration
+
+	// Learn TTLs for responses without Expires header instead of using
+	// DefaultTTL (routes in FallbackTTLs keep their fixed TTL): entries
+	// whose ETag stays unchanged are kept longer, entries that change often
+	// are revalidated sooner. The learned TTL is bounded by AdaptiveTTLMin
+	// (default: 1m) and AdaptiveTTLMax (default: 1h). Default: off.
+	AdaptiveTTL    bool
+	AdaptiveTTLMin time.Duration
+	AdaptiveTTLMax time.Duration
+
+	// Approximate cap on total cache bytes in Redis (0 = unlimited) and which
+	// entries are trimmed first when it is exceeded (default: soonest expiring)
+	MaxCacheBytes       int64
+	CacheEvictionPolicy cache.EvictionPolicy
+
+	// Subscribe to Redis expiry notifications for accurate cache size/entry
+	// metrics and per-endpoint expiry counts (optional)
+	WatchCacheExpirations bool
+
+	// Latency and availability objectives by route prefix, matched without
+	// version like "markets/" (longest prefix wins). The client counts good,
+	// slow and failed requests and exports compliance and burn rates (see
+	// SLOStatus). Default: none.
+	SLOs map[string]SLO
+
+	// Reject every request that could change in-game state (anything but
+	// GET, HEAD and the universe/names-style lookup POSTs), e.g. for
+	// analytics deployments holding tokens with write scopes
+	ReadOnly bool
+
+	// Deadlines for calls whose context has none, by operation type: single
+	// requests (Do, Get, PostBulk batches, GetMany elements), pages of
+	// paginated fetches and GetStream up to the response headers, and admin
+	// operations (state export and import, cache replication, inspection
+	// and diff, journal replay). Zero uses the default (15s, 1m, 10m),
+	// negative sets no deadline.
+	RequestTimeout time.Duration
+	PageTimeout    time.Duration
+	AdminTimeout   time.Duration
+
+	// Interactive endpoints (/fleets/, /ui/) - never cached
+	InteractiveTimeout time.Duration // Deadline for a single interactive call
+	HedgeRequests      bool          // Hedge interactive GETs after P95 latency (only while error budget is healthy)
+	HedgeDelay         time.Duration // Hedge delay until enough latency samples exist for a P95
 
 	// Retry
 	MaxRetries     int
 	InitialBackoff time.Duration
+	RetryBudget    int // Retries shared by all requests of one GetMany or paginated fetch (0 = unlimited, see RetryBudget)
+
+	// Backoff strategy per retried error class (server, rate_limit,
+	// network), e.g. ExponentialFullJitter or DecorrelatedJitter. Default:
+	// ExponentialJitter for every class.
+	BackoffStrategies map[ErrorClass]BackoffStrategy
+
+	// Transport
+	IPVersion     IPVersion // Force IPv4 or IPv6 (default: dual stack)
+	LocalAddress  string    // Local source IP for outgoing connections (egress selection)
+	ProxyURL      string    // http://, https://, socks5:// or socks5h:// proxy (default: HTTP(S)_PROXY env)
+	ProxyUsername string    // Proxy auth user (overrides credentials in ProxyURL)
+	ProxyPassword string    // Proxy auth password
+
+	// DNS: pin esi.evetech.net to fixed IPs (TLS SNI keeps the host name),
+	// or cache lookups and reuse the last answer while the resolver fails
+	StaticAddresses []string
+	DNSCacheTTL     time.Duration
+
+	// Ordered ESI hosts or mirrors (default: esi.evetech.net only). GET and
+	// HEAD requests failing with a network error or 5xx move on to the next
+	// upstream serving the route; a failed upstream is skipped for
+	// UpstreamCooldown (default: 30s).
+	Upstreams        []Upstream
+	UpstreamCooldown time.Duration
+
+	// Warm pool: open WarmConnections connections to ESI at startup and
+	// again after the client was idle for WarmInterval (default: 60s), so
+	// the first burst of a crawl doesn't wait for TLS handshakes. Default:
+	// off.
+	WarmConnections int
+	WarmInterval    time.Duration
+
+	// Debug capture: store full request/response pairs with ESI (secrets
+	// redacted) in Redis for CaptureSampleRate (0..1) of all requests and
+	// every request to CaptureRoutes (prefixes matched like "markets/"),
+	// keeping the newest CaptureMaxEntries (default: 1000). Read them with
+	// Captures. Default: off.
+	CaptureSampleRate float64
+	CaptureRoutes     []string
+	CaptureMaxEntries int
+
+	// Canary: for CanarySampleRate (0..1) of cache hits of public GETs,
+	// fetch the endpoint again in the background with a full download and
+	// compare the bodies (metric esi_canary_checks_total), to detect stale
+	// data served because of TTL or validator bugs. Each check costs a full
+	// ESI request. Default: off.
+	CanarySampleRate float64
+
+	// Privacy: requests to PrivateRoutes (prefixes matched like
+	// "characters/") and requests with WithPrivate appear in metric labels
+	// and logs per PrivacyMode - IDs redacted to {id} (default), IDs hashed
+	// with PrivacySalt, or the whole endpoint reported as "private".
+	PrivateRoutes []string
+	PrivacyMode   PrivacyMode
+	PrivacySalt   string
+
+	// Write request journal (DoJournaled): failed writes are kept in Redis
+	// this long and replayed every JournalReplayInterval (0 = only by
+	// calling ReplayJournal). Default: journal off.
+	JournalMaxAge         time.Duration
+	JournalReplayInterval time.Duration
+
+	// GetStream: bodies larger than StreamThreshold bytes (default: 10 MiB)
+	// or of unknown size are streamed to the caller instead of buffered, and
+	// cached only up to StreamCacheMaxBytes (0 = streamed bodies are never
+	// cached)
+	StreamThreshold     int64
+	StreamCacheMaxBytes int64
+
+	// Cap on the bytes of ESI response bodies buffered in memory at once,
+	// from reading them for the cache until the caller closes the body;
+	// further responses wait before they are read (0 = no cap). Bounds the
+	// memory of parallel pagination workers on small containers.
+	MaxInFlightBytes int64
+
+	// Log requests still running after this long, and again every further
+	// StuckRequestThreshold, with their endpoint, phase, elapsed time and
+	// context state, to diagnose hangs (0 = off)
+	StuckRequestThreshold time.Duration
+
+	// Close runs the shutdown hooks (see OnShutdown) and stops the
+	// background tasks within ShutdownTimeout (default: 10s)
+	ShutdownTimeout time.Duration
 }
 
 // DefaultConfig returns a safe default configuration.
@@ -115,8 +420,13 @@ func DefaultConfig(redis *redis.Client, userAgent string) Config {
 		MaxConcurrency: 5,
 		MemoryCacheTTL: 60 * time.Second,
 		RespectExpires: true, // MUST be true for ESI compliance
+		MaxStale:       defaultMaxStale,
+		LegacyKeyGrace: cache.DefaultLegacyKeyGrace,
 		MaxRetries:     3,
 		InitialBackoff: 1 * time.Second,
+		RetryBudget:    defaultRetryBudget,
+
+		InteractiveTimeout: defaultInteractiveTimeout,
 	}
 }
 
@@ -134,111 +444,541 @@ func New(cfg Config) (*Client, error) {
 		return nil, fmt.Errorf("respect_expires must be true (ESI requirement)")
 	}
 
+	if cfg.ServeStaleOnError && cfg.MaxStale <= 0 {
+		return nil, fmt.Errorf("serve_stale_on_error requires max_stale > 0")
+	}
+
+	if cfg.JournalReplayInterval > 0 && cfg.JournalMaxAge <= 0 {
+		return nil, fmt.Errorf("journal_replay_interval requires journal_max_age > 0")
+	}
+
+	if cfg.ReadOnly && cfg.JournalMaxAge > 0 {
+		return nil, fmt.Errorf("read_only and journal_max_age are mutually exclusive")
+	}
+
+	if cfg.CacheRedis != nil && cfg.CacheRedisDB != 0 {
+		return nil, fmt.Errorf("cache_redis and cache_redis_db are mutually exclusive")
+	}
+
+	if cfg.CacheReplicaMaxLag < 0 {
+		return nil, fmt.Errorf("cache_replica_max_lag must not be negative (got %s)", cfg.CacheReplicaMaxLag)
+	}
+
 	if cfg.ErrorThreshold < 5 {
 		return nil, fmt.Errorf("error_threshold must be >= 5 (got %d)", cfg.ErrorThreshold)
 	}
 
+	if err := validateTransportConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := validateMinRefreshIntervals(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := validateCapture(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := validateCanary(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := validatePrivacy(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := validateLastModifiedRoutes(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := validateChallengeBackoff(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := validateIncidentMode(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := validateShutdown(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := validateBackoffStrategies(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := validateWarmConnections(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := validateLatestVersions(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := validateRetryBudget(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := validateFallbackTTLs(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := validateAdaptiveTTL(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := validateStream(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := validateInFlightBytes(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := validateStuckRequests(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := validateSLOs(cfg); err != nil {
+		return nil, err
+	}
+
 	// Initialize logger
 	logger := log.With().Str("component", "esi-client").Logger()
 
 	// Create rate limit tracker
 	rateLimiter := ratelimit.NewTracker(cfg.Redis, logger)
 
+	// Create soft quotas
+	quotas := make([]*ratelimit.Quota, 0, len(cfg.Quotas))
+	for _, quotaCfg := range cfg.Quotas {
+		quota, err := ratelimit.NewQuota(cfg.Redis, quotaCfg, logger)
+		if err != nil {
+			return nil, err
+		}
+		quotas = append(quotas, quota)
+	}
+
 	// Create cache manager
-	cacheManager := cache.NewManager(cfg.Redis)
+	cacheRedis, ownsCacheRedis := cacheRedisClient(cfg)
+	cacheManager := cache.NewManagerWithCodec(cacheRedis, cfg.Codec)
+	cacheManager.EnableMemoryLimit(cfg.MaxCacheBytes, cfg.CacheEvictionPolicy)
+	cacheManager.EnableStaleRetention(cfg.MaxStale)
+	cacheManager.EnableLegacyKeys(cfg.LegacyKeyGrace)
+	cacheManager.EnableValidatorIndex(cfg.ValidatorIndexSize)
+	cacheManager.EnableSplitStorage(cfg.SplitBodySize)
+	cacheManager.SetReadReplica(cfg.CacheReplica, cache.ReplicaOptions{
+		MaxLag:       cfg.CacheReplicaMaxLag,
+		MissFallback: cfg.CacheReplicaMissFallback,
+	})
+	cacheManager.SetHeaderFilter(cfg.CacheHeaders)
+	cacheManager.SetFallbackTTL(cfg.DefaultTTL)
 
-	return &Client{
+	c := &Client{
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Transport: newUpstreamTransport(cfg, newTransport(cfg)),
+			Timeout:   30 * time.Second,
 		},
 		redis:       cfg.Redis,
+		cacheRedis:  cacheRedis,
 		rateLimiter: rateLimiter,
+		quotas:      quotas,
 		cache:       cacheManager,
 		config:      cfg,
 		logger:      logger,
-	}, nil
+		stats:       clientStats{since: time.Now()},
+		slos:        newSLOTrackers(cfg.SLOs),
+		inFlight:    newByteBudget(cfg.MaxInFlightBytes),
+
+		ownsCacheRedis: ownsCacheRedis,
+	}
+
+	// Background tasks register their shutdown hooks as they start and stop
+	// in reverse order; the prefetcher starts on demand and stops last
+	c.onShutdown("prefetch", func(context.Context) { c.stopPrefetch() })
+	if cfg.WatchCacheExpirations {
+		c.startExpiryWatch()
+	}
+	if cfg.JournalReplayInterval > 0 {
+		c.startJournalReplay()
+	}
+	if len(c.slos) > 0 {
+		c.startSLOMetrics()
+	}
+	if cfg.CanarySampleRate > 0 {
+		c.startCanaries()
+	}
+	if cfg.WarmConnections > 0 {
+		c.startWarmPool()
+	}
+	if cfg.IncidentStatusURL != "" {
+		c.startIncidentPoller()
+	}
+	if cfg.StuckRequestThreshold > 0 {
+		c.startStuckWatchdog()
+	}
+
+	return c, nil
+}
+
+// cacheRedisClient returns the Redis client for cache data and whether the
+// client created it.
+func cacheRedisClient(cfg Config) (*redis.Client, bool) {
+	if cfg.CacheRedis != nil {
+		return cfg.CacheRedis, false
+	}
+	if cfg.CacheRedisDB == 0 || cfg.CacheRedisDB == cfg.Redis.Options().DB {
+		return cfg.Redis, false
+	}
+
+	opts := *cfg.Redis.Options()
+	opts.DB = cfg.CacheRedisDB
+	return redis.NewClient(&opts), true
+}
+
+// startExpiryWatch runs the cache expiry watcher until Close.
+func (c *Client) startExpiryWatch() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	c.onShutdown("cache expiry watcher", stopAndWait(cancel, done))
+
+	go func() {
+		defer close(done)
+		if err := c.cache.WatchExpirations(ctx); err != nil && ctx.Err() == nil {
+			c.logger.Warn().Err(err).Msg("Cache expiry watcher stopped")
+		}
+	}()
 }
 
 // Do performs an HTTP request with rate limiting, caching, and error handling.
 // This is the core request method that orchestrates all ESI client features.
+// Requests whose context has no deadline get Config.RequestTimeout.
 func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	return c.doWithDeadline(req, func(req *http.Request) (*http.Response, error) {
+		return c.do(req, true)
+	})
+}
+
+// do implements Do. cachePosts enables the body-keyed cache for cacheable
+// POSTs; PostBulk disables it because it caches per element instead.
+func (c *Client) do(req *http.Request, cachePosts bool) (resp *http.Response, err error) {
+	if err := c.checkReadOnly(req); err != nil {
+		return nil, err
+	}
+
 	ctx := req.Context()
 	endpoint := req.URL.Path
+	reported := c.reportedEndpoint(ctx, endpoint) // For metric labels and logs (see PrivateRoutes)
+	logger := requestLogger(ctx, c.logger)
+
+	// Requests running past StuckRequestThreshold are logged with their phase
+	active, untrack := c.trackRequest(ctx, req.Method, reported)
+	defer untrack()
+
+	// The context's access token must be set before the cache key is
+	// computed, so the response is scoped like any authenticated one
+	if token := AccessTokenFromContext(ctx); token != "" && req.Header.Get("Authorization") == "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
 
-	// Start request timing
+	// Start request timing. upstreamStatus is the last ESI status (304 even
+	// when the cached body is returned), 0 if no response was received;
+	// esiRequestID is ESI's ID of that response.
 	startTime := time.Now()
+	upstreamStatus := 0
+	esiRequestID := ""
+	c.stats.requests.Add(1)
 	defer func() {
-		esiRequestDuration.WithLabelValues(endpoint).Observe(time.Since(startTime).Seconds())
+		elapsed := time.Since(startTime)
+		observeWithESIRequestID(ctx, esiRequestDuration.WithLabelValues(reported, statusClass(upstreamStatus)), elapsed.Seconds(), esiRequestID)
+		c.stats.latencyTotal.Add(int64(elapsed))
+		if err != nil {
+			c.stats.errors.Add(1)
+		}
+		c.recordSLO(endpoint, elapsed, err)
 	}()
 
-	// Step 1: Check Rate Limit
+	// Cache key of the request (interactive endpoints are never cached; POSTs
+	// only for endpoints that are deterministic by body)
+	cacheKey, cacheable, err := c.requestCacheKey(req, cachePosts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Identical cacheable GETs in flight share one ESI request
+	force := forceRefresh(ctx)
+	if coalescable(ctx, req, cacheKey, cacheable) && !force {
+		key := cacheKey.String()
+		for {
+			fl, leader := c.flights.join(key)
+			if leader {
+				defer c.flights.finish(key, fl, &resp, &err)
+				break
+			}
+			active.setPhase(phaseCoalesced)
+			if resp, handled, err := fl.wait(ctx, req); handled {
+				return resp, err
+			}
+			active.setPhase(phasePrepare)
+		}
+	}
+
+	// Keep hinted public entries warm for the caller's next request
+	if hint := prefetchHint(ctx); hint > 0 && cacheable && req.Header.Get("Authorization") == "" && cacheKey.NormalizedMethod() == http.MethodGet {
+		defer func() {
+			if err == nil {
+				c.schedulePrefetch(context.WithoutCancel(ctx), req.URL.RequestURI(), cacheKey, hint)
+			}
+		}()
+	}
+
+	// Step 0: Collapse polls within the route's minimum refresh interval
+	if cacheable && !force {
+		if resp := c.suppressRefresh(ctx, req, cacheKey); resp != nil {
+			return resp, nil
+		}
+	}
+
+	// Step 0b: Shed requests the configured LoadShedder rejects
+	if err := c.shed(ctx, endpoint); err != nil {
+		return nil, err
+	}
+	c.load.inFlight.Add(1)
+	defer c.load.inFlight.Add(-1)
+
+	// Step 1: Check Rate Limit (callers opting out of waiting are answered
+	// from the cache or rejected while the error budget is low)
+	if failFast(ctx) {
+		if resp, err, handled := c.backpressure(ctx, cacheKey, cacheable); handled {
+			return resp, err
+		}
+	}
+	phaseStart := time.Now()
+	active.setPhase(phaseRateLimit)
+	c.load.queued.Add(1)
 	allowed, err := c.rateLimiter.ShouldAllowRequest(ctx)
+	c.load.queued.Add(-1)
 	if err != nil {
-		c.logger.Error().Err(err).Msg("Rate limit check failed")
+		logger.Error().Err(err).Msg("Rate limit check failed")
 		return nil, fmt.Errorf("rate limit check: %w", err)
 	}
 	if !allowed {
-		c.logger.Warn().
-			Str("endpoint", endpoint).
+		logger.Warn().
+			Str("endpoint", reported).
 			Msg("Request blocked by rate limiter")
-		esiRequestsTotal.WithLabelValues(endpoint, "rate_limited").Inc()
-		return nil, fmt.Errorf("request blocked: rate limit critical")
+		esiRequestsTotal.WithLabelValues(reported, "rate_limited").Inc()
+		c.stats.blocked.Add(1)
+		return c.staleOnError(ctx, cacheKey, cacheable, staleReasonBlocked, fmt.Errorf("request blocked: %w", ErrRateLimited))
 	}
 
+	// Step 1b: Count against soft quotas
+	for _, quota := range c.quotas {
+		if _, err := quota.Consume(ctx); err != nil {
+			if errors.Is(err, ratelimit.ErrQuotaExceeded) {
+				esiRequestsTotal.WithLabelValues(reported, "quota_exceeded").Inc()
+				c.stats.blocked.Add(1)
+				return c.staleOnError(ctx, cacheKey, cacheable, staleReasonBlocked, fmt.Errorf("request blocked: %w", err))
+			}
+			logger.Warn().Err(err).Msg("Soft quota check failed")
+		}
+	}
+	rateLimitTime := time.Since(phaseStart)
+
 	// Step 2: Check Cache
-	cacheKey := cache.CacheKey{
-		Endpoint:    endpoint,
-		QueryParams: req.URL.Query(),
+	group, interactive := interactiveGroup(endpoint)
+	if interactive {
+		defer func() {
+			observe(ctx, esiInteractiveRequestDuration.WithLabelValues(group), time.Since(startTime).Seconds())
+		}()
+	}
+
+	// Entries with indexed validators are read from Redis only on a 304
+	var cachedEntry *cache.CacheEntry
+	var indexed cache.Validators
+	fromIndex := false
+	if cacheable {
+		phaseStart = time.Now()
+		active.setPhase(phaseCacheLookup)
+		if indexed, fromIndex = c.cache.Validators(cacheKey); fromIndex && indexed.Conditional() && !force {
+			c.stats.cacheHits.Add(1)
+		} else {
+			fromIndex = false
+			cachedEntry, err = c.cache.Get(ctx, cacheKey)
+			if err != nil && err != cache.ErrCacheMiss {
+				logger.Warn().Err(err).Str("endpoint", reported).Msg("Cache get error")
+			}
+			if cachedEntry != nil {
+				c.stats.cacheHits.Add(1)
+			}
+		}
+		observePhase(ctx, phaseCacheLookup, time.Since(phaseStart))
+	}
+
+	// Step 2b: Don't contact ESI during an announced downtime
+	if _, down := c.downtime.active(); down {
+		return c.serveDuringDowntime(ctx, cacheKey, cacheable)
 	}
 
-	cachedEntry, err := c.cache.Get(ctx, cacheKey)
-	if err != nil && err != cache.ErrCacheMiss {
-		c.logger.Warn().Err(err).Str("endpoint", endpoint).Msg("Cache get error")
+	// Step 2c: Nor while backing off from a Cloudflare challenge
+	if until, paused := c.challenge.active(); paused {
+		esiRequestsTotal.WithLabelValues(reported, "challenge_backoff").Inc()
+		return c.staleOnError(ctx, cacheKey, cacheable, staleReasonChallenge, challengeError(0, until))
 	}
 
-	// Step 3: Make Conditional Request if cache hit
-	if cachedEntry != nil && cache.ShouldMakeConditionalRequest(cachedEntry) {
-		cache.AddConditionalHeaders(req, cachedEntry)
+	// Step 3: Make Conditional Request if cache hit (pages without
+	// validators borrow page 1's Last-Modified, as do pages without
+	// Last-Modified on LastModifiedRoutes; forced refreshes always download
+	// the body)
+	seeded := false
+	if force {
+		logger.Debug().Str("endpoint", reported).Msg("Forced refresh - skipping conditional request")
+	} else if fromIndex {
+		cache.AddValidatorHeaders(req, c.conditionalValidators(endpoint, indexed))
+		cache.ConditionalRequestsSent.Inc()
+		logger.Debug().
+			Str("endpoint", reported).
+			Str("etag", indexed.ETag).
+			Msg("Making conditional request from validator index")
+	} else if cacheable && seedConditional(ctx, req, cacheKey, cachedEntry, c.prefersLastModified(endpoint)) {
+		seeded = true
+		logger.Debug().
+			Str("endpoint", reported).
+			Str("if_modified_since", req.Header.Get("If-Modified-Since")).
+			Msg("Making conditional request seeded from page 1")
+	} else if cachedEntry != nil && cache.ShouldMakeConditionalRequest(cachedEntry) {
+		cache.AddValidatorHeaders(req, c.conditionalValidators(endpoint, cache.ValidatorsOf(cachedEntry)))
 		cache.ConditionalRequestsSent.Inc()
-		c.logger.Debug().
-			Str("endpoint", endpoint).
+		logger.Debug().
+			Str("endpoint", reported).
 			Str("etag", cachedEntry.ETag).
 			Msg("Making conditional request")
 	}
 
-	// Step 4: Set User-Agent header
+	// Step 4: Set User-Agent header (and the caller's request ID)
 	req.Header.Set("User-Agent", c.config.UserAgent)
 	req.Header.Set("Accept", "application/json")
+	if id := RequestIDFromContext(ctx); id != "" && req.Header.Get(RequestIDHeader) == "" {
+		req.Header.Set(RequestIDHeader, id)
+	}
+
+	// Step 4b: Wait for the smoothing limiter (retries are spread by backoff)
+	// and in incident mode for a request slot; incidents suppress retries
+	phaseStart = time.Now()
+	active.setPhase(phaseRateLimit)
+	c.load.queued.Add(1)
+	err = c.waitForLimiter(ctx)
+	release := func() {}
+	if err == nil {
+		release, err = c.acquireIncidentSlot(ctx)
+	}
+	c.load.queued.Add(-1)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	retryCtx := ctx
+	if c.incident.active() {
+		retryCtx = withoutRetries(ctx)
+	}
+	observePhase(ctx, phaseRateLimit, rateLimitTime+time.Since(phaseStart))
 
 	// Step 5: Execute HTTP Request with Retry Logic
-	c.logger.Debug().
-		Str("endpoint", endpoint).
+	logger.Debug().
+		Str("endpoint", reported).
 		Str("method", req.Method).
 		Msg("Executing ESI request")
 
-	var resp *http.Response
 	var lastErr error
 	var errClass ErrorClass
 
+	hedge := c.shouldHedge(ctx, req, interactive)
+	capturing := c.shouldCapture(endpoint)
+	attempts := 0
+
+	// Network time excludes retry backoff; it is reported once the body of
+	// a cacheable response has been read as well
+	var networkTime time.Duration
+	defer func() {
+		observePhase(ctx, phaseNetwork, networkTime)
+	}()
+
 	// Wrap the HTTP request in retry logic
-	retryErr := retryWithBackoff(ctx, func() error {
+	retryErr := retryWithBackoff(retryCtx, c.config.BackoffStrategies, func() error {
+		if attempts++; attempts > 1 {
+			c.stats.retries.Add(1)
+
+			// Rewind request bodies (POST) consumed by the previous attempt
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return fmt.Errorf("rewind request body: %w", err)
+				}
+				req.Body = body
+			}
+		}
+
 		// Execute the HTTP request
 		var reqErr error
-		resp, reqErr = c.httpClient.Do(req)
+		active.attempt()
+		attemptStart := time.Now()
+		c.warm.markUsed()
+		if hedge {
+			resp, reqErr = c.doHedged(req, group)
+		} else {
+			resp, reqErr = c.httpClient.Do(req)
+		}
+		networkTime += time.Since(attemptStart)
+		if capturing {
+			c.capture(ctx, req, attempts, resp, reqErr, time.Since(attemptStart))
+		}
+		upstreamStatus, esiRequestID = 0, ""
+		if resp != nil {
+			upstreamStatus = resp.StatusCode
+			esiRequestID = resp.Header.Get(ESIRequestIDHeader)
+		}
 
 		// Handle network errors
 		if reqErr != nil {
-			c.logger.Error().Err(reqErr).Str("endpoint", endpoint).Msg("HTTP request failed")
 			errClass = c.classifyError(nil, reqErr)
+			subclass := classifyNetworkError(reqErr)
+			logger.Error().
+				Err(reqErr).
+				Str("endpoint", reported).
+				Str("network_subclass", string(subclass)).
+				Msg("HTTP request failed")
 			esiErrorsTotal.WithLabelValues(string(errClass)).Inc()
-			esiRequestsTotal.WithLabelValues(endpoint, "network_error").Inc()
-			lastErr = reqErr
-			return reqErr
+			esiNetworkErrorsTotal.WithLabelValues(string(subclass)).Inc()
+			esiRequestsTotal.WithLabelValues(reported, "network_error").Inc()
+			lastErr = &ESIError{
+				ErrorClass: errClass,
+				Subclass:   subclass,
+				Message:    "transport error",
+				Err:        reqErr,
+			}
+			return lastErr
 		}
 
-		// Update Rate Limit from headers
+		// Cloudflare challenge or other HTML instead of ESI JSON: not an ESI
+		// error, never cached nor retried; all requests pause instead
+		if resp.StatusCode != http.StatusNotModified && isChallenge(resp) {
+			errClass = ErrorClassChallenge
+			until := c.beginChallengeBackoff(reported, resp.StatusCode)
+			esiErrorsTotal.WithLabelValues(string(errClass)).Inc()
+			esiChallengeResponsesTotal.WithLabelValues(fmt.Sprintf("%d", resp.StatusCode)).Inc()
+			esiRequestsTotal.WithLabelValues(reported, "challenge").Inc()
+			lastErr = challengeError(resp.StatusCode, until)
+			resp.Body.Close()
+			return lastErr
+		}
+
+		// Update Rate Limit from headers (errors are deducted locally first)
+		c.rateLimiter.RecordErrorResponse(resp.StatusCode, resp.Header)
 		if err := c.rateLimiter.UpdateFromHeaders(ctx, resp.Header); err != nil {
-			c.logger.Warn().Err(err).Msg("Failed to update rate limit from headers")
+			logger.Warn().Err(err).Msg("Failed to update rate limit from headers")
+		}
+		if err := c.rateLimiter.RecordRateLimited(ctx, resp.StatusCode, resp.Header); err != nil {
+			logger.Warn().Err(err).Msg("Failed to share 420 block")
 		}
 
 		// Handle 304 Not Modified (not an error, return success)
@@ -246,16 +986,24 @@ func (c *Client) Do(req *http.Request) (*http.Response, error) {
 			return nil
 		}
 
+		// Announced downtime: honor Retry-After instead of retrying
+		if until, ok := downtimeUntil(resp); ok {
+			c.beginDowntime(reported, until)
+			esiRequestsTotal.WithLabelValues(reported, fmt.Sprintf("%d", resp.StatusCode)).Inc()
+			return nil
+		}
+
 		// Handle HTTP errors
 		if resp.StatusCode >= 400 {
 			errClass = c.classifyError(resp, nil)
 			esiErrorsTotal.WithLabelValues(string(errClass)).Inc()
-			esiRequestsTotal.WithLabelValues(endpoint, fmt.Sprintf("%d", resp.StatusCode)).Inc()
+			esiRequestsTotal.WithLabelValues(reported, fmt.Sprintf("%d", resp.StatusCode)).Inc()
 
-			c.logger.Warn().
-				Str("endpoint", endpoint).
+			logger.Warn().
+				Str("endpoint", reported).
 				Int("status", resp.StatusCode).
 				Str("error_class", string(errClass)).
+				Str("esi_request_id", esiRequestID).
 				Msg("ESI request error")
 
 			// Check if we should retry this error
@@ -265,6 +1013,7 @@ func (c *Client) Do(req *http.Request) (*http.Response, error) {
 					StatusCode: resp.StatusCode,
 					ErrorClass: errClass,
 					Message:    resp.Status,
+					RequestID:  esiRequestID,
 				}
 				resp.Body.Close() // Close the body before retrying
 				return lastErr
@@ -275,61 +1024,186 @@ func (c *Client) Do(req *http.Request) (*http.Response, error) {
 		}
 
 		// Success
-		esiRequestsTotal.WithLabelValues(endpoint, fmt.Sprintf("%d", resp.StatusCode)).Inc()
+		esiRequestsTotal.WithLabelValues(reported, fmt.Sprintf("%d", resp.StatusCode)).Inc()
 		return nil
 	}, func(err error) ErrorClass {
 		// Classify error dynamically for retry logic
+		active.setPhase(phaseRetryBackoff)
 		return errClass
 	})
 
 	// Handle retry exhaustion
 	if retryErr != nil {
+		if interactive {
+			esiInteractiveRequestsTotal.WithLabelValues(group, "error").Inc()
+		}
 		if resp != nil && resp.Body != nil {
 			resp.Body.Close()
 		}
-		return nil, retryErr
+		reason := staleReasonError
+		if errClass == ErrorClassChallenge {
+			reason = staleReasonChallenge
+		}
+		return c.staleOnError(ctx, cacheKey, cacheable, reason, retryErr)
+	}
+
+	// Step 6: Serve from cache if ESI announced a downtime
+	if _, down := downtimeUntil(resp); down {
+		resp.Body.Close()
+		return c.serveDuringDowntime(ctx, cacheKey, cacheable)
+	}
+
+	if interactive {
+		esiInteractiveRequestsTotal.WithLabelValues(group, fmt.Sprintf("%d", resp.StatusCode)).Inc()
+		resp.Header.Set(CacheStatusHeader, CacheStatusBypass)
+		return resp, nil
 	}
 
-	// Step 7: Handle 304 Not Modified
+	// TTL for entries without Expires header
+	fallbackRoute, fallbackTTL := c.fallbackTTL(endpoint)
+
+	// Step 7: Handle 304 Not Modified (with validators from the index, the
+	// entry is read now; if it changed or is gone meanwhile, the body is
+	// fetched again unconditionally)
+	if resp.StatusCode == http.StatusNotModified {
+		active.setPhase(phaseCacheWrite)
+	}
+	if resp.StatusCode == http.StatusNotModified && fromIndex {
+		cachedEntry, _ = c.cache.Get(ctx, cacheKey)
+		if !indexed.Matches(cachedEntry) {
+			resp.Body.Close()
+			logger.Debug().Str("endpoint", reported).Msg("Indexed validators outdated - fetching again")
+			refetch := req.Clone(WithForceRefresh(ctx))
+			refetch.Header.Del("If-None-Match")
+			refetch.Header.Del("If-Modified-Since")
+			return c.do(refetch, cachePosts)
+		}
+	}
 	if resp.StatusCode == http.StatusNotModified {
-		c.logger.Debug().Str("endpoint", endpoint).Msg("304 Not Modified - using cache")
-		esiRequestsTotal.WithLabelValues(endpoint, "304").Inc()
+		logger.Debug().Str("endpoint", reported).Msg("304 Not Modified - using cache")
+		esiRequestsTotal.WithLabelValues(reported, "304").Inc()
 		cache.NotModifiedResponses.Inc()
+		c.stats.notModified.Add(1)
+		recordPageSeed(ctx, cacheKey, resp, cachedEntry)
+		if seeded {
+			esiSeededConditionalRequestsTotal.WithLabelValues("not_modified").Inc()
+		}
+
+		// Without cached entry the 304 answers the caller's own validators:
+		// nothing to promote, extend or compare
+		if cachedEntry == nil {
+			resp.Header.Set(CacheStatusHeader, CacheStatusBypass)
+			return resp, nil
+		}
+
+		// A 304 for an ETag alias makes it the entry's ETag
+		confirmed := resp.Header.Get("ETag")
+		if confirmed != "" && !cache.WeakETagMatch(confirmed, cachedEntry.ETag) {
+			promoted := *cachedEntry
+			if promoted.PromoteETag(confirmed) {
+				cache.ETagAliasConfirmations.Inc()
+				cachedEntry = &promoted
+			}
+		}
 
 		// Update cache TTL from new expires header
 		if expiresStr := resp.Header.Get("Expires"); expiresStr != "" {
 			if newExpires, err := http.ParseTime(expiresStr); err == nil {
-				if err := c.cache.UpdateTTL(ctx, cacheKey, newExpires); err != nil {
-					c.logger.Warn().Err(err).Msg("Failed to update cache TTL")
+				if err := c.cache.UpdateTTLWithETag(ctx, cacheKey, newExpires, confirmed); err != nil {
+					logger.Warn().Err(err).Msg("Failed to update cache TTL")
 				}
 			}
+		} else {
+			newExpires := time.Now().Add(fallbackTTL)
+			if c.config.AdaptiveTTL && fallbackRoute == "" {
+				newExpires = c.adaptiveExpires(cachedEntry.ChangedAt, cachedEntry.CachedAt)
+			}
+			if err := c.cache.UpdateTTLWithETag(ctx, cacheKey, newExpires, confirmed); err != nil {
+				logger.Warn().Err(err).Msg("Failed to update cache TTL")
+			}
 		}
 
 		// Return cached response
 		resp.Body.Close()
-		return c.cacheEntryToResponse(cachedEntry), nil
+		c.maybeCanary(ctx, req, cachedEntry, canaryHitRevalidated)
+		cached := c.cacheEntryToResponse(cachedEntry)
+		cached.Header.Set(CacheStatusHeader, CacheStatusHit)
+		return cached, nil
 	}
 
-	// Step 8: Update Cache on success
-	if resp.StatusCode == http.StatusOK {
-		entry, err := cache.ResponseToEntry(resp)
+	// Step 8: Update Cache on success (large GetStream bodies are cached
+	// once the caller has read them, if at all)
+	if cacheable && resp.StatusCode == http.StatusOK {
+		recordPageSeed(ctx, cacheKey, resp, nil)
+		if seeded {
+			esiSeededConditionalRequestsTotal.WithLabelValues("modified").Inc()
+		}
+	}
+	store := func(entry *cache.CacheEntry) {
+		if !c.cacheAllowed(ctx, cacheKey, resp, entry.Data) {
+			return
+		}
+		if fromIndex && (c.config.AdaptiveTTL || !cache.WeakETagMatch(entry.ETag, indexed.ETag)) {
+			cachedEntry, _ = c.cache.GetStale(ctx, cacheKey) // Previous entry for learnTTL and ETag aliases
+		}
+		active.setPhase(phaseCacheWrite)
+		c.storeEntry(ctx, endpoint, cacheKey, entry, cachedEntry, fallbackRoute)
+	}
+	if cacheable && resp.StatusCode == http.StatusOK && !c.streamResponse(ctx, resp, fallbackTTL, store) {
+		// Buffered bodies count against MaxInFlightBytes until closed
+		active.setPhase(phaseInFlightWait)
+		reserved, err := c.reserveInFlight(ctx, resp)
 		if err != nil {
-			c.logger.Warn().Err(err).Msg("Failed to create cache entry")
-		} else if entry.TTL() > 0 {
-			if err := c.cache.Set(ctx, cacheKey, entry); err != nil {
-				c.logger.Warn().Err(err).Msg("Failed to cache response")
-			} else {
-				c.logger.Debug().
-					Str("endpoint", endpoint).
-					Dur("ttl", entry.TTL()).
-					Msg("Cached response")
-			}
+			resp.Body.Close()
+			return nil, err
+		}
+		active.setPhase(phaseNetwork)
+		phaseStart = time.Now()
+		entry, err := cache.ResponseToEntryWithTTL(resp, fallbackTTL)
+		networkTime += time.Since(phaseStart)
+		if err != nil {
+			c.holdInFlight(resp, reserved, 0)
+			logger.Warn().Err(err).Msg("Failed to create cache entry")
+		} else {
+			c.holdInFlight(resp, reserved, len(entry.Data))
+			store(entry)
 		}
 	}
 
+	if cacheable {
+		resp.Header.Set(CacheStatusHeader, CacheStatusMiss)
+	} else {
+		resp.Header.Set(CacheStatusHeader, CacheStatusBypass)
+	}
 	return resp, nil
 }
 
+// storeEntry writes a fresh entry for endpoint under key, learning its TTL
+// if enabled. prev is the entry it replaces (nil if none). Failures are
+// logged; the response is served either way.
+func (c *Client) storeEntry(ctx context.Context, endpoint string, key cache.CacheKey, entry, prev *cache.CacheEntry, fallbackRoute string) {
+	cache.InheritETagAliases(entry, prev)
+	if c.config.AdaptiveTTL && fallbackRoute == "" && entry.Headers.Get("Expires") == "" {
+		c.learnTTL(entry, prev)
+	}
+	if entry.TTL() <= 0 {
+		return
+	}
+
+	logger := requestLogger(ctx, c.logger)
+	phaseStart := time.Now()
+	err := c.cache.Set(context.WithoutCancel(ctx), key, entry)
+	observePhase(ctx, phaseCacheWrite, time.Since(phaseStart))
+	if err != nil {
+		logger.Warn().Err(err).Msg("Failed to cache response")
+		return
+	}
+	logger.Debug().
+		Str("endpoint", c.reportedEndpoint(ctx, endpoint)).
+		Dur("ttl", entry.TTL()).
+		Msg("Cached response")
+}
+
 // classifyError categorizes an error for observability and handling.
 func (c *Client) classifyError(resp *http.Response, err error) ErrorClass {
 	if err != nil {
@@ -352,14 +1226,55 @@ func (c *Client) classifyError(resp *http.Response, err error) ErrorClass {
 	}
 }
 
+// statusClass maps an upstream status code to a low-cardinality label value.
+// 304 is kept separate from other 3xx because it is a cheap revalidation
+// instead of a full download.
+func statusClass(status int) string {
+	switch {
+	case status == 0:
+		return "error"
+	case status == http.StatusNotModified:
+		return "304"
+	default:
+		return fmt.Sprintf("%dxx", status/100)
+	}
+}
+
 // cacheEntryToResponse converts a cache entry back to an HTTP response.
 func (c *Client) cacheEntryToResponse(entry *cache.CacheEntry) *http.Response {
 	return cache.EntryToResponse(entry)
 }
 
+// requestCacheKey returns the cache key of req and whether the request is
+// cacheable: non-interactive GETs and, if cachePosts is set, POSTs to
+// endpoints that are deterministic by body (keyed by body hash).
+func (c *Client) requestCacheKey(req *http.Request, cachePosts bool) (cache.CacheKey, bool, error) {
+	key := cache.CacheKey{
+		Endpoint:    c.canonicalEndpoint(req.URL.Path),
+		Method:      req.Method,
+		QueryParams: req.URL.Query(),
+	}
+
+	if _, interactive := interactiveGroup(req.URL.Path); interactive {
+		return key, false, nil
+	}
+	if req.Method == "" || req.Method == http.MethodGet {
+		return c.scopeAuthenticated(req, key)
+	}
+	if cachePosts && req.Method == http.MethodPost && c.isCacheablePost(req.URL.Path) {
+		body, err := requestBody(req)
+		if err != nil {
+			return key, false, err
+		}
+		key.BodyHash = cache.HashBody(body)
+		return c.scopeAuthenticated(req, key)
+	}
+	return key, false, nil
+}
+
 // Get performs a GET request to an ESI endpoint.
 func (c *Client) Get(ctx context.Context, endpoint string) (*http.Response, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", "https://esi.evetech.net"+endpoint, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", esiBaseURL+endpoint, nil)
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
@@ -370,17 +1285,64 @@ func (c *Client) Get(ctx context.Context, endpoint string) (*http.Response, erro
 // FetchPage implements pagination.PageFetcher interface for batch fetching
 // Returns the response body data and total page count from X-Pages header
 func (c *Client) FetchPage(ctx context.Context, endpoint string, pageNum int) ([]byte, int, error) {
-	// Add page parameter
+	body, totalPages, err := c.StreamPage(ctx, endpoint, pageNum)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer body.Close()
+
+	// Read body
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	return data, totalPages, nil
+}
+
+// StreamPage fetches a single page like FetchPage but hands out the response
+// body unread, so callers can decode it without buffering the whole page.
+// Implements pagination.PageStreamer. The caller must close the body.
+func (c *Client) StreamPage(ctx context.Context, endpoint string, pageNum int) (io.ReadCloser, int, error) {
+	resp, totalPages, err := c.getPage(ctx, endpoint, pageNum)
+	if err != nil {
+		return nil, 0, err
+	}
+	return resp.Body, totalPages, nil
+}
+
+// FetchPageIfChanged fetches a single page like FetchPage and reports whether
+// it changed since it was cached: false if ESI confirmed the cached copy with
+// 304. Implements pagination.ChangeFetcher.
+func (c *Client) FetchPageIfChanged(ctx context.Context, endpoint string, pageNum int) ([]byte, int, bool, error) {
+	resp, totalPages, err := c.getPage(ctx, endpoint, pageNum)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, false, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	return data, totalPages, resp.Header.Get(CacheStatusHeader) != CacheStatusHit, nil
+}
+
+// getPage fetches a single page and parses its X-Pages header. The caller
+// must close the response body.
+func (c *Client) getPage(ctx context.Context, endpoint string, pageNum int) (*http.Response, int, error) {
+	// Add page parameter; pages get Config.PageTimeout
 	fullEndpoint := fmt.Sprintf("%s?page=%d", endpoint, pageNum)
 
-	resp, err := c.Get(ctx, fullEndpoint)
+	resp, err := c.Get(context.WithValue(ctx, pageKey{}, true), fullEndpoint)
 	if err != nil {
 		return nil, 0, fmt.Errorf("GET request failed: %w", err)
 	}
-	defer resp.Body.Close()
 
 	// Check status
 	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
 		return nil, 0, fmt.Errorf("unexpected status %d", resp.StatusCode)
 	}
 
@@ -395,19 +1357,24 @@ func (c *Client) FetchPage(ctx context.Context, endpoint string, pageNum int) ([
 		}
 	}
 
-	// Read body
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, 0, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	return data, totalPages, nil
+	return resp, totalPages, nil
 }
 
-// Close closes the client and releases resources.
+// Close runs the shutdown hooks (see OnShutdown), stops the background
+// tasks and releases resources. The error wraps ErrShutdownTimeout if hooks
+// did not finish within Config.ShutdownTimeout.
 func (c *Client) Close() error {
-	// TODO: Cleanup resources
-	return nil
+	err := c.runShutdownHooks()
+	if c.ownsCacheRedis {
+		err = errors.Join(err, c.cacheRedis.Close())
+	}
+	return err
+}
+
+// CloseIdleConnections closes idle connections to ESI. Paginated fetches
+// call it when the caller abandons them.
+func (c *Client) CloseIdleConnections() {
+	c.httpClient.CloseIdleConnections()
 }
 
 // SetHTTPClient sets a custom HTTP client.
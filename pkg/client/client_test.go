@@ -4,13 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+	"github.com/Sternrassler/eve-esi-client/pkg/ratelimit"
 	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
 )
@@ -105,6 +108,19 @@ func TestNew_Validation(t *testing.T) {
 			expectError: true,
 			errorMsg:    "error_threshold must be >= 5 (got 3)",
 		},
+		{
+			name: "cache redis and cache db",
+			config: Config{
+				Redis:          redisClient,
+				CacheRedis:     redisClient,
+				CacheRedisDB:   2,
+				UserAgent:      "TestApp/1.0.0",
+				RespectExpires: true,
+				ErrorThreshold: 10,
+			},
+			expectError: true,
+			errorMsg:    "cache_redis and cache_redis_db are mutually exclusive",
+		},
 	}
 
 	for _, tt := range tests {
@@ -232,6 +248,28 @@ func TestClassifyError(t *testing.T) {
 	}
 }
 
+func TestStatusClass(t *testing.T) {
+	tests := []struct {
+		status int
+		want   string
+	}{
+		{0, "error"},
+		{200, "2xx"},
+		{304, "304"},
+		{302, "3xx"},
+		{404, "4xx"},
+		{420, "4xx"},
+		{502, "5xx"},
+		{520, "5xx"},
+	}
+
+	for _, tt := range tests {
+		if got := statusClass(tt.status); got != tt.want {
+			t.Errorf("statusClass(%d) = %q, want %q", tt.status, got, tt.want)
+		}
+	}
+}
+
 func TestDo_UserAgentSet(t *testing.T) {
 	redisClient := setupTestRedis(t)
 
@@ -272,7 +310,7 @@ func TestDo_RateLimitBlock(t *testing.T) {
 	now := time.Now()
 	redisClient.Set(ctx, "esi:rate_limit:errors_remaining", 3, 0)
 	redisClient.Set(ctx, "esi:rate_limit:reset_timestamp", now.Add(60*time.Second).Unix(), 0)
-	// Add last_update to ensure GetState() doesn't return default healthy state
+	// Add last_update to ensure GetState() doesn't return the unknown default state
 	lastUpdateJSON, _ := json.Marshal(now)
 	redisClient.Set(ctx, "esi:rate_limit:last_update", lastUpdateJSON, 0)
 
@@ -388,6 +426,8 @@ func TestDo_Handle304NotModified(t *testing.T) {
 	time.Sleep(100 * time.Millisecond)
 
 	// Second request with conditional headers
+	revalidations := esiRequestDuration.WithLabelValues("/test", "304")
+	before := sampleCount(t, revalidations)
 	req2, _ := http.NewRequest("GET", server.URL+"/test", nil)
 	resp2, err := client.Do(req2)
 	if err != nil {
@@ -395,11 +435,59 @@ func TestDo_Handle304NotModified(t *testing.T) {
 	}
 	resp2.Body.Close()
 
+	// The duration is recorded as a cheap revalidation, not a 200 download
+	if got := sampleCount(t, revalidations) - before; got != 1 {
+		t.Errorf("304 duration observations = %d, want 1", got)
+	}
+
 	// The client should return the cached response
 	if resp2.StatusCode != http.StatusOK && resp2.StatusCode != http.StatusNotModified {
 		t.Errorf("Second response status = %d, want %d or %d",
 			resp2.StatusCode, http.StatusOK, http.StatusNotModified)
 	}
+	if got := resp2.Header.Get(CacheStatusHeader); got != CacheStatusHit {
+		t.Errorf("%s = %q, want %q", CacheStatusHeader, got, CacheStatusHit)
+	}
+}
+
+func TestDo_CallerValidatorsWithoutCacheEntry(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-ESI-Error-Limit-Remain", "100")
+		w.Header().Set("X-ESI-Error-Limit-Reset", "60")
+		if r.Header.Get("If-None-Match") != `"caller"` {
+			t.Errorf("If-None-Match = %q, want the caller's", r.Header.Get("If-None-Match"))
+		}
+
+		// No Expires: the TTL would come from AdaptiveTTL
+		w.Header().Set("ETag", `"caller"`)
+		w.WriteHeader(http.StatusNotModified)
+	}))
+	defer server.Close()
+
+	cfg := DefaultConfig(redisClient, "TestApp/1.0.0")
+	cfg.AdaptiveTTL = true
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	// The caller revalidates a body it holds itself; the client has none
+	req, _ := http.NewRequest("GET", server.URL+"/uncached", nil)
+	req.Header.Set("If-None-Match", `"caller"`)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotModified {
+		t.Errorf("status = %d, want ESI's 304", resp.StatusCode)
+	}
+	if got := resp.Header.Get(CacheStatusHeader); got != CacheStatusBypass {
+		t.Errorf("%s = %q, want %q", CacheStatusHeader, got, CacheStatusBypass)
+	}
 }
 
 func TestDo_ErrorClassification(t *testing.T) {
@@ -726,3 +814,231 @@ func TestDo_RetryExhausted(t *testing.T) {
 		t.Errorf("Expected 3 attempts, got %d", attemptCount)
 	}
 }
+
+func TestDo_QuotaEnforced(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	requestCount := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		w.Header().Set("X-ESI-Error-Limit-Remain", "100")
+		w.Header().Set("X-ESI-Error-Limit-Reset", "60")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := DefaultConfig(redisClient, "TestApp/1.0.0")
+	cfg.Quotas = []ratelimit.QuotaConfig{
+		{Name: "hourly", Window: time.Hour, Limit: 1, Enforce: true},
+	}
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	req1, _ := http.NewRequest("GET", server.URL+"/first", nil)
+	resp, err := client.Do(req1)
+	if err != nil {
+		t.Fatalf("First request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	req2, _ := http.NewRequest("GET", server.URL+"/second", nil)
+	_, err = client.Do(req2)
+	if !errors.Is(err, ratelimit.ErrQuotaExceeded) {
+		t.Errorf("Expected ErrQuotaExceeded, got %v", err)
+	}
+	if requestCount != 1 {
+		t.Errorf("Request count = %d, want 1", requestCount)
+	}
+}
+
+func TestDo_SeparateCacheRedis(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-ESI-Error-Limit-Remain", "100")
+		w.Header().Set("X-ESI-Error-Limit-Reset", "60")
+		w.Header().Set("Expires", time.Now().Add(5*time.Minute).Format(http.TimeFormat))
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"test": "data"}`))
+	}))
+	defer server.Close()
+
+	cfg := DefaultConfig(redisClient, "TestApp/1.0.0")
+	cfg.CacheRedisDB = 14
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	cacheDB := client.cacheRedis
+	if err := cacheDB.FlushDB(ctx).Err(); err != nil {
+		t.Fatalf("flush cache DB: %v", err)
+	}
+	t.Cleanup(func() { cacheDB.FlushDB(context.Background()) })
+
+	req, _ := http.NewRequest("GET", server.URL+"/separate", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	// Cache entry lands in DB 14, rate limit state stays in the main DB
+	cacheKey := cache.CacheKey{Endpoint: "/separate"}.String()
+	if cacheDB.Exists(ctx, cacheKey).Val() != 1 {
+		t.Error("cache entry not stored in cache DB")
+	}
+	if redisClient.Exists(ctx, cacheKey).Val() != 0 {
+		t.Error("cache entry must not be stored in the rate limit DB")
+	}
+	if redisClient.Exists(ctx, ratelimit.RedisKeyErrorsRemaining).Val() != 1 {
+		t.Error("rate limit state not stored in the main DB")
+	}
+	if cacheDB.Exists(ctx, ratelimit.RedisKeyErrorsRemaining).Val() != 0 {
+		t.Error("rate limit state must not be stored in the cache DB")
+	}
+}
+
+func TestDo_HEADDoesNotReplaceGETEntry(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	var hits atomic.Int64
+	server := httptest.NewServer(revalidatingHandler(&hits, `{"players":1}`))
+	defer server.Close()
+
+	client, err := New(DefaultConfig(redisClient, "TestApp/1.0.0"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+
+	ctx := context.Background()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodHead, "https://esi.evetech.net/v1/status/", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("HEAD error = %v", err)
+	}
+	resp.Body.Close()
+
+	// A HEAD has no body to cache: the GET is fetched in full
+	resp, err = client.Get(ctx, "/v1/status/")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != `{"players":1}` {
+		t.Errorf("body = %q, want the full GET response", body)
+	}
+	if got := resp.Header.Get(CacheStatusHeader); got != CacheStatusMiss {
+		t.Errorf("%s = %q, want %q", CacheStatusHeader, got, CacheStatusMiss)
+	}
+}
+
+func TestFetchPageIfChanged(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	var version atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		etag := fmt.Sprintf(`"v%d"`, version.Load())
+		w.Header().Set("X-ESI-Error-Limit-Remain", "100")
+		w.Header().Set("X-ESI-Error-Limit-Reset", "60")
+		w.Header().Set("Expires", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+		w.Header().Set("X-Pages", "4")
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte(etag))
+	}))
+	defer server.Close()
+
+	client, err := New(DefaultConfig(redisClient, "TestApp/1.0.0"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+	client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+
+	tests := []struct {
+		name        string
+		bump        bool
+		wantChanged bool
+		wantData    string
+	}{
+		{"not cached", false, true, `"v0"`},
+		{"revalidated", false, false, `"v0"`},
+		{"modified", true, true, `"v1"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.bump {
+				version.Add(1)
+			}
+			data, totalPages, changed, err := client.FetchPageIfChanged(context.Background(), "/v1/markets/10000002/orders/", 2)
+			if err != nil {
+				t.Fatalf("FetchPageIfChanged() error = %v", err)
+			}
+			if changed != tt.wantChanged || string(data) != tt.wantData || totalPages != 4 {
+				t.Errorf("FetchPageIfChanged() = %s, %d, %v, want %s, 4, %v", data, totalPages, changed, tt.wantData, tt.wantChanged)
+			}
+		})
+	}
+}
+
+func TestDo_ValidatorIndex(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	var notModified, full atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == "" {
+			full.Add(1)
+		}
+		revalidatingHandler(&notModified, `{"players":1}`).ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	cfg := DefaultConfig(redisClient, "TestApp/1.0.0")
+	cfg.ValidatorIndexSize = 100
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+	client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+
+	get := func() string {
+		t.Helper()
+		resp, err := client.Get(context.Background(), "/v1/status/")
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	get() // Cached with ETag "v1", indexed on write
+	if body := get(); body != `{"players":1}` || notModified.Load() != 1 {
+		t.Fatalf("second Get() = %q with %d 304s, want the cached body via a 304", body, notModified.Load())
+	}
+
+	// The entry disappears behind the index's back: the 304 cannot be
+	// served, so the body is fetched again
+	key := cache.CacheKey{Endpoint: "/v1/status/"}
+	if err := redisClient.Del(context.Background(), key.String()).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if body := get(); body != `{"players":1}` {
+		t.Errorf("Get() after eviction = %q", body)
+	}
+	if full.Load() != 2 || notModified.Load() != 2 {
+		t.Errorf("ESI saw %d full and %d conditional (304) requests, want 2 and 2", full.Load(), notModified.Load())
+	}
+}
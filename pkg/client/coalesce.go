@@ -0,0 +1,111 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// esiCoalescedRequestsTotal counts requests answered with the result of an
+// identical request already in flight.
+var esiCoalescedRequestsTotal = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "esi_coalesced_requests_total",
+	Help: "Total requests answered with the result of an identical cacheable GET already in flight",
+})
+
+// flight is a cacheable GET in progress. Requests for the same cache key
+// arriving meanwhile wait for it instead of contacting ESI themselves.
+type flight struct {
+	done chan struct{}
+	resp *http.Response // Snapshot of the leader's response, body in body
+	body []byte
+	err  error
+}
+
+// flights tracks the in-flight cacheable GETs of a client by cache key.
+type flights struct {
+	mu      sync.Mutex
+	pending map[string]*flight
+}
+
+// join returns the flight for key and whether the caller leads it, i.e.
+// must perform the request and call finish.
+func (f *flights) join(key string) (*flight, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if fl, ok := f.pending[key]; ok {
+		return fl, false
+	}
+	if f.pending == nil {
+		f.pending = make(map[string]*flight)
+	}
+	fl := &flight{done: make(chan struct{})}
+	f.pending[key] = fl
+	return fl, true
+}
+
+// finish publishes the leader's result to the waiting requests. The body of
+// resp is read, so it can be handed to every waiter, and replaced for the
+// leader.
+func (f *flights) finish(key string, fl *flight, resp **http.Response, err *error) {
+	if *err == nil && *resp != nil {
+		body, readErr := io.ReadAll((*resp).Body)
+		(*resp).Body.Close()
+		if readErr != nil {
+			*resp, *err = nil, fmt.Errorf("read response body: %w", readErr)
+		} else {
+			(*resp).Body = io.NopCloser(bytes.NewReader(body))
+			snapshot := **resp
+			snapshot.Header = (*resp).Header.Clone()
+			fl.resp, fl.body = &snapshot, body
+		}
+	}
+	fl.err = *err
+
+	f.mu.Lock()
+	delete(f.pending, key)
+	f.mu.Unlock()
+	close(fl.done)
+}
+
+// wait blocks until the flight finished and returns its result for req. It
+// reports false if the leader was cancelled while ctx is still live or was
+// shed (the caller's priority may differ), so the caller must make the
+// request itself.
+func (fl *flight) wait(ctx context.Context, req *http.Request) (*http.Response, bool, error) {
+	select {
+	case <-fl.done:
+	case <-ctx.Done():
+		return nil, true, ctx.Err()
+	}
+
+	if fl.err != nil {
+		if errors.Is(fl.err, context.Canceled) || errors.Is(fl.err, context.DeadlineExceeded) || errors.Is(fl.err, ErrLoadShed) {
+			return nil, false, nil
+		}
+		return nil, true, fl.err
+	}
+
+	resp := *fl.resp
+	resp.Header = fl.resp.Header.Clone()
+	resp.Body = io.NopCloser(bytes.NewReader(fl.body))
+	resp.Request = req
+	esiCoalescedRequestsTotal.Inc()
+	return &resp, true, nil
+}
+
+// coalescable reports whether the request may share the result of an
+// identical request in flight: plain cacheable GETs except GetStream, whose
+// bodies are not buffered.
+func coalescable(ctx context.Context, req *http.Request, key cache.CacheKey, cacheable bool) bool {
+	return cacheable && (req.Method == "" || req.Method == http.MethodGet) &&
+		key.NormalizedMethod() == http.MethodGet && !streaming(ctx)
+}
@@ -0,0 +1,106 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDo_Coalescing(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		time.Sleep(100 * time.Millisecond)
+		w.Header().Set("X-ESI-Error-Limit-Remain", "100")
+		w.Header().Set("X-ESI-Error-Limit-Reset", "60")
+		w.Header().Set("Expires", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer server.Close()
+
+	client, err := New(DefaultConfig(redisClient, "TestApp/1.0.0"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+	client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+
+	tests := []struct {
+		name         string
+		endpoints    []string
+		stream       bool
+		wantRequests int64
+	}{
+		{"same endpoint", []string{"/v1/status/", "/v1/status/", "/v1/status/", "/v1/status/"}, false, 1},
+		{"different endpoints", []string{"/v1/universe/races/", "/v1/universe/bloodlines/"}, false, 2},
+		{"GetStream", []string{"/v1/universe/factions/", "/v1/universe/factions/"}, true, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests.Store(0)
+			var wg sync.WaitGroup
+			for _, endpoint := range tt.endpoints {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					var body io.ReadCloser
+					if tt.stream {
+						b, _, err := client.GetStream(context.Background(), endpoint)
+						if err != nil {
+							t.Errorf("GetStream() error = %v", err)
+							return
+						}
+						body = b
+					} else {
+						resp, err := client.Get(context.Background(), endpoint)
+						if err != nil {
+							t.Errorf("Get() error = %v", err)
+							return
+						}
+						body = resp.Body
+					}
+					defer body.Close()
+					if data, _ := io.ReadAll(body); string(data) != endpoint {
+						t.Errorf("body = %q, want %q", data, endpoint)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if got := requests.Load(); got != tt.wantRequests {
+				t.Errorf("ESI requests = %d, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestFlight_LeaderCancelled(t *testing.T) {
+	var f flights
+	leader, isLeader := f.join("key")
+	if !isLeader {
+		t.Fatal("first join is not the leader")
+	}
+	waiter, isLeader := f.join("key")
+	if isLeader || waiter != leader {
+		t.Fatal("second join did not join the flight")
+	}
+
+	var resp *http.Response
+	err := error(context.Canceled)
+	f.finish("key", leader, &resp, &err)
+
+	if _, handled, err := waiter.wait(context.Background(), nil); handled || err != nil {
+		t.Errorf("wait() = handled %v, %v; want the caller to retry", handled, err)
+	}
+	if _, isLeader := f.join("key"); !isLeader {
+		t.Error("join after finish is not a new leader")
+	}
+}
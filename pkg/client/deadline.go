@@ -0,0 +1,171 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Default deadlines by operation type, used while Config leaves them zero.
+const (
+	defaultRequestTimeout = 15 * time.Second
+	defaultPageTimeout    = time.Minute
+	defaultAdminTimeout   = 10 * time.Minute
+)
+
+// operation is the type of call a default deadline is chosen for.
+type operation int
+
+const (
+	operationRequest operation = iota // Get, Do and friends
+	operationPage                     // One page of a paginated fetch, GetStream up to the headers
+	operationAdmin                    // State export/import, replication, inspection, journal replay
+)
+
+// pageKey is the context key marking page requests.
+type pageKey struct{}
+
+// timeout returns the default deadline of op, 0 for none.
+func (c *Client) timeout(op operation) time.Duration {
+	timeout, fallback := c.config.RequestTimeout, defaultRequestTimeout
+	switch op {
+	case operationPage:
+		timeout, fallback = c.config.PageTimeout, defaultPageTimeout
+	case operationAdmin:
+		timeout, fallback = c.config.AdminTimeout, defaultAdminTimeout
+	}
+	switch {
+	case timeout < 0:
+		return 0
+	case timeout == 0:
+		return fallback
+	}
+	return timeout
+}
+
+// withDefaultDeadline returns ctx with the default deadline of op if ctx
+// has no deadline of its own, and the function releasing it.
+func (c *Client) withDefaultDeadline(ctx context.Context, op operation) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	timeout := c.timeout(op)
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// doWithDeadline runs do under the default deadline of its request's
+// operation type. The deadline is released when the response body is
+// closed, so the caller can still read it. GetStream bodies are read
+// without deadline: theirs ends once the response headers arrived.
+func (c *Client) doWithDeadline(req *http.Request, do func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	if streaming(req.Context()) {
+		return c.doWithHeaderDeadline(req, do)
+	}
+
+	op := operationRequest
+	if _, page := req.Context().Value(pageKey{}).(bool); page {
+		op = operationPage
+	}
+
+	ctx, cancel := c.withDefaultDeadline(req.Context(), op)
+	if ctx == req.Context() {
+		return do(req)
+	}
+
+	resp, err := do(req.WithContext(ctx))
+	if err != nil || resp == nil {
+		cancel()
+		return resp, err
+	}
+	resp.Body = &deadlineBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// doWithHeaderDeadline runs do under the page deadline until the response
+// headers arrived, so streams of any size are not cut off while read.
+func (c *Client) doWithHeaderDeadline(req *http.Request, do func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	timeout := c.timeout(operationPage)
+	if _, ok := req.Context().Deadline(); ok || timeout <= 0 {
+		return do(req)
+	}
+
+	ctx, cancel := withHeaderDeadline(req.Context(), timeout)
+	resp, err := do(req.WithContext(ctx))
+	if err != nil || resp == nil {
+		cancel()
+		return resp, err
+	}
+	ctx.lift()
+	resp.Body = &deadlineBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// headerDeadline is a context whose deadline can be lifted before it
+// expires. It reports context.DeadlineExceeded once the deadline passed.
+type headerDeadline struct {
+	context.Context // Cancellable child of the request context
+
+	deadline time.Time
+	timer    *time.Timer
+	expired  atomic.Bool
+	lifted   atomic.Bool
+}
+
+// withHeaderDeadline returns a headerDeadline expiring after timeout, and
+// the function releasing it.
+func withHeaderDeadline(parent context.Context, timeout time.Duration) (*headerDeadline, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(parent)
+	d := &headerDeadline{Context: ctx, deadline: time.Now().Add(timeout)}
+	d.timer = time.AfterFunc(timeout, func() {
+		d.expired.Store(true)
+		cancel(context.DeadlineExceeded) // Cause seen by net/http
+	})
+	return d, func() {
+		d.timer.Stop()
+		cancel(nil)
+	}
+}
+
+// lift removes the deadline unless it already expired.
+func (d *headerDeadline) lift() {
+	if d.timer.Stop() {
+		d.lifted.Store(true)
+	}
+}
+
+// Deadline returns the deadline until it is lifted, then the parent's.
+func (d *headerDeadline) Deadline() (time.Time, bool) {
+	if d.lifted.Load() {
+		return d.Context.Deadline()
+	}
+	return d.deadline, true
+}
+
+// Err returns context.DeadlineExceeded if the deadline expired.
+func (d *headerDeadline) Err() error {
+	err := d.Context.Err()
+	if err != nil && d.expired.Load() {
+		return context.DeadlineExceeded
+	}
+	return err
+}
+
+// deadlineBody releases a default deadline on the first Close.
+type deadlineBody struct {
+	io.ReadCloser
+	once   sync.Once
+	cancel context.CancelFunc
+}
+
+// Close closes the body and releases the deadline.
+func (b *deadlineBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.cancel)
+	return err
+}
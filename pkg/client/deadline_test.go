@@ -0,0 +1,113 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	c := &Client{config: Config{PageTimeout: 2 * time.Minute, AdminTimeout: -1}}
+
+	tests := []struct {
+		op   operation
+		want time.Duration
+	}{
+		{operationRequest, defaultRequestTimeout},
+		{operationPage, 2 * time.Minute},
+		{operationAdmin, 0},
+	}
+	for _, tt := range tests {
+		if got := c.timeout(tt.op); got != tt.want {
+			t.Errorf("timeout(%d) = %s, want %s", tt.op, got, tt.want)
+		}
+	}
+}
+
+func TestDoWithDeadline(t *testing.T) {
+	c := &Client{config: Config{RequestTimeout: time.Minute}}
+
+	var reqCtx context.Context
+	do := func(req *http.Request) (*http.Response, error) {
+		reqCtx = req.Context()
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, esiBaseURL+"/v1/status/", nil)
+	resp, err := c.doWithDeadline(req, do)
+	if err != nil {
+		t.Fatalf("doWithDeadline() error = %v", err)
+	}
+	deadline, ok := reqCtx.Deadline()
+	if !ok || time.Until(deadline) > time.Minute || time.Until(deadline) < 50*time.Second {
+		t.Errorf("request deadline = %v (set: %t), want RequestTimeout", deadline, ok)
+	}
+
+	// The deadline outlives Do until the body is closed
+	if reqCtx.Err() != nil {
+		t.Fatal("deadline released before the body was closed")
+	}
+	resp.Body.Close()
+	if !errors.Is(reqCtx.Err(), context.Canceled) {
+		t.Errorf("context after Close = %v, want canceled", reqCtx.Err())
+	}
+
+	// Pages get PageTimeout
+	req, _ = http.NewRequestWithContext(context.WithValue(context.Background(), pageKey{}, true), http.MethodGet, esiBaseURL+"/v1/markets/10000002/orders/?page=2", nil)
+	resp, _ = c.doWithDeadline(req, do)
+	resp.Body.Close()
+	if deadline, _ := reqCtx.Deadline(); time.Until(deadline) > defaultPageTimeout || time.Until(deadline) < defaultPageTimeout-10*time.Second {
+		t.Errorf("page deadline in %s, want PageTimeout", time.Until(deadline))
+	}
+
+	// The caller's deadline is kept
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Hour)
+	defer cancel()
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, esiBaseURL+"/v1/status/", nil)
+	resp, _ = c.doWithDeadline(req, do)
+	resp.Body.Close()
+	if reqCtx != ctx {
+		t.Error("doWithDeadline() replaced a context with deadline")
+	}
+}
+
+func TestDoWithDeadline_Stream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/stuck/" {
+			<-r.Context().Done()
+			return
+		}
+		// A body trickling in for longer than PageTimeout
+		for range 5 {
+			_, _ = w.Write([]byte("[]"))
+			w.(http.Flusher).Flush()
+			time.Sleep(30 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	c := &Client{config: Config{PageTimeout: 50 * time.Millisecond}}
+	streamCtx := context.WithValue(context.Background(), streamKey{}, true)
+
+	req, _ := http.NewRequestWithContext(streamCtx, http.MethodGet, server.URL+"/v1/universe/structures/", nil)
+	resp, err := c.doWithDeadline(req, server.Client().Do)
+	if err != nil {
+		t.Fatalf("doWithDeadline() error = %v", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil || len(body) != 10 {
+		t.Errorf("stream read %d bytes, error = %v; want the whole body", len(body), err)
+	}
+
+	// The headers are still bound by PageTimeout
+	req, _ = http.NewRequestWithContext(streamCtx, http.MethodGet, server.URL+"/stuck/", nil)
+	if _, err := c.doWithDeadline(req, server.Client().Do); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("doWithDeadline() error = %v, want deadline exceeded", err)
+	}
+}
@@ -0,0 +1,107 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+)
+
+// defaultMaxStale covers ESI's daily downtime, which usually lasts well
+// under half an hour.
+const defaultMaxStale = 30 * time.Minute
+
+// downtimeState tracks an ESI downtime announced by 503 + Retry-After.
+// Until it ends, requests are answered from the cache (stale if need be)
+// without contacting ESI.
+type downtimeState struct {
+	until atomic.Int64 // Unix nanoseconds
+}
+
+// begin extends the downtime to until. It reports whether a new downtime
+// started, so the notice is logged only once.
+func (d *downtimeState) begin(until time.Time) bool {
+	now := time.Now().UnixNano()
+	for {
+		current := d.until.Load()
+		if until.UnixNano() <= current {
+			return false
+		}
+		if d.until.CompareAndSwap(current, until.UnixNano()) {
+			return current < now
+		}
+	}
+}
+
+// active returns the end of the current downtime, if any.
+func (d *downtimeState) active() (time.Time, bool) {
+	until := time.Unix(0, d.until.Load())
+	return until, time.Now().Before(until)
+}
+
+// downtimeUntil reports whether resp announces a downtime (503 with a
+// Retry-After header) and until when.
+func downtimeUntil(resp *http.Response) (time.Time, bool) {
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		return time.Time{}, false
+	}
+
+	header := resp.Header.Get("Retry-After")
+	if header == "" {
+		return time.Time{}, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Now().Add(time.Duration(seconds) * time.Second), true
+	}
+	if t, err := http.ParseTime(header); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// beginDowntime records a downtime announced by ESI and logs one notice per
+// downtime instead of an error per request.
+func (c *Client) beginDowntime(endpoint string, until time.Time) {
+	if c.downtime.begin(until) {
+		c.logger.Warn().
+			Str("endpoint", endpoint).
+			Time("until", until).
+			Msg("ESI downtime announced - serving cached data without contacting ESI")
+	}
+}
+
+// serveDuringDowntime answers a request while ESI is down: from the cache if
+// an entry exists (marked stale when expired), otherwise with an ESIError
+// wrapping ErrDowntime.
+func (c *Client) serveDuringDowntime(ctx context.Context, key cache.CacheKey, cacheable bool) (*http.Response, error) {
+	until, _ := c.downtime.active()
+
+	if cacheable {
+		if entry, err := c.cache.GetStale(ctx, key); err == nil {
+			resp := c.cacheEntryToResponse(entry)
+			status := CacheStatusHit
+			if entry.IsStale() {
+				status = CacheStatusStale
+				esiStaleResponsesTotal.WithLabelValues(staleReasonDowntime).Inc()
+			}
+			resp.Header.Set(CacheStatusHeader, status)
+			return resp, nil
+		}
+	}
+
+	logger := requestLogger(ctx, c.logger)
+	logger.Debug().
+		Str("endpoint", c.reportedEndpoint(ctx, key.Endpoint)).
+		Time("until", until).
+		Msg("Request rejected during ESI downtime")
+	return nil, &ESIError{
+		StatusCode: http.StatusServiceUnavailable,
+		ErrorClass: ErrorClassServer,
+		Message:    fmt.Sprintf("ESI downtime until %s", until.UTC().Format(time.RFC3339)),
+		Err:        ErrDowntime,
+	}
+}
@@ -0,0 +1,107 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+)
+
+func TestDowntimeUntil(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		retryAfter string
+		want       time.Duration
+		wantOK     bool
+	}{
+		{"seconds", http.StatusServiceUnavailable, "120", 120 * time.Second, true},
+		{"http date", http.StatusServiceUnavailable, time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), time.Hour, true},
+		{"no header", http.StatusServiceUnavailable, "", 0, false},
+		{"invalid header", http.StatusServiceUnavailable, "soon", 0, false},
+		{"other status", http.StatusBadGateway, "120", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+			if tt.retryAfter != "" {
+				resp.Header.Set("Retry-After", tt.retryAfter)
+			}
+
+			until, ok := downtimeUntil(resp)
+			if ok != tt.wantOK {
+				t.Fatalf("downtimeUntil() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok {
+				if diff := time.Until(until) - tt.want; diff > 2*time.Second || diff < -2*time.Second {
+					t.Errorf("downtimeUntil() = %s from now, want %s", time.Until(until), tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDo_DowntimeServesStale(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("X-ESI-Error-Limit-Remain", "100")
+		w.Header().Set("X-ESI-Error-Limit-Reset", "60")
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client, err := New(DefaultConfig(redisClient, "TestApp/1.0.0"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+
+	// An entry that expired just before the downtime
+	ctx := context.Background()
+	key := cache.CacheKey{Endpoint: "/v1/status/"}
+	entry := &cache.CacheEntry{
+		Data:       []byte(`{"players":1}`),
+		Expires:    time.Now().Add(100 * time.Millisecond),
+		StatusCode: http.StatusOK,
+		Headers:    http.Header{},
+	}
+	if err := client.cache.Set(ctx, key, entry); err != nil {
+		t.Fatalf("cache.Set() error = %v", err)
+	}
+	time.Sleep(200 * time.Millisecond)
+
+	for i := range 2 {
+		resp, err := client.Get(ctx, "/v1/status/")
+		if err != nil {
+			t.Fatalf("request %d: Get() error = %v, want stale response", i, err)
+		}
+		resp.Body.Close()
+		if got := resp.Header.Get(CacheStatusHeader); got != CacheStatusStale {
+			t.Errorf("request %d: %s = %q, want %q", i, CacheStatusHeader, got, CacheStatusStale)
+		}
+	}
+
+	// The 503 is not retried and later requests don't contact ESI
+	if got := requests.Load(); got != 1 {
+		t.Errorf("ESI requests = %d, want 1", got)
+	}
+
+	// Without a cached entry the request fails fast
+	_, err = client.Get(ctx, "/v1/uncached/")
+	if !errors.Is(err, ErrDowntime) {
+		t.Errorf("Get() error = %v, want ErrDowntime", err)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("ESI requests = %d after uncached request, want 1", got)
+	}
+}
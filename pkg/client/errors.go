@@ -3,6 +3,11 @@ package client
 import (
 	"errors"
 	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+	"github.com/Sternrassler/eve-esi-client/pkg/ratelimit"
 )
 
 // Common errors returned by the client.
@@ -12,24 +17,173 @@ var (
 
 	// ErrContextCancelled is returned when the context is cancelled during retry.
 	ErrContextCancelled = errors.New("context cancelled")
+
+	// ErrDeadlineBudgetExceeded is returned when the remaining context deadline
+	// is too short to wait for the next retry attempt.
+	ErrDeadlineBudgetExceeded = errors.New("deadline budget exceeded")
+
+	// ErrRetryBudgetExhausted is returned when a request was not retried
+	// because its operation used up the shared retry budget.
+	ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+
+	// ErrBatchBudgetExhausted is returned by GetMany for endpoints that were
+	// not requested because the batch already used up its error budget.
+	ErrBatchBudgetExhausted = errors.New("batch error budget exhausted")
+
+	// ErrDowntime is returned while ESI announced a downtime (503 with
+	// Retry-After) and no cached entry is available.
+	ErrDowntime = errors.New("ESI downtime")
+
+	// ErrRateLimited is returned when the rate limiter blocks a request
+	// because the ESI error limit is critical.
+	ErrRateLimited = errors.New("rate limit critical")
+
+	// ErrJournaled is wrapped by DoJournaled errors when the failed request
+	// was journaled for replay.
+	ErrJournaled = errors.New("request journaled")
+
+	// ErrUnscopedAuthCache is returned in StrictAuthCache mode for cacheable
+	// requests carrying Authorization without a character scope.
+	ErrUnscopedAuthCache = errors.New("authenticated request without character scope")
+
+	// ErrCacheMiss is returned by GetCached if no cached entry exists. It is
+	// cache.ErrCacheMiss, so either works with errors.Is.
+	ErrCacheMiss = cache.ErrCacheMiss
+
+	// ErrReadOnly is returned in ReadOnly mode for requests that could change
+	// in-game state.
+	ErrReadOnly = errors.New("client is read-only")
+
+	// ErrLoadShed is wrapped by the ShedError returned for requests rejected
+	// by the configured LoadShedder.
+	ErrLoadShed = errors.New("request shed")
+
+	// ErrChallenge is wrapped by the ESIError of requests answered with a
+	// Cloudflare challenge or HTML page, or held back during the following
+	// Config.ChallengeBackoff.
+	ErrChallenge = errors.New("cloudflare challenge")
+
+	// ErrShutdownTimeout is wrapped by the error of Close if shutdown hooks
+	// did not finish within Config.ShutdownTimeout.
+	ErrShutdownTimeout = errors.New("shutdown timed out")
+)
+
+// BudgetError reports how much of the caller's time budget a retried request
+// consumed before it gave up. It wraps the terminal retry error, so
+// errors.Is(err, ErrRetryExhausted) and friends keep working.
+type BudgetError struct {
+	// Attempts is the number of requests that were actually sent.
+	Attempts int
+
+	// Consumed is the wall time spent across all attempts and backoffs.
+	Consumed time.Duration
+
+	// Remaining is the time left until the context deadline (0 if none was set).
+	Remaining time.Duration
+
+	Err error
+}
+
+// Error implements the error interface.
+func (e *BudgetError) Error() string {
+	return fmt.Sprintf("%v (attempts: %d, budget consumed: %s, remaining: %s)",
+		e.Err, e.Attempts, e.Consumed.Round(time.Millisecond), e.Remaining.Round(time.Millisecond))
+}
+
+// Unwrap implements error unwrapping for errors.Is/As.
+func (e *BudgetError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorCode is a stable, machine-readable error code, so callers can branch
+// on the kind of failure instead of parsing error messages.
+type ErrorCode string
+
+const (
+	// ErrorCodeRateLimited: the rate limiter, a soft quota, the load shedder
+	// or ESI (420, 429, 520) refused the request.
+	ErrorCodeRateLimited ErrorCode = "RATE_LIMITED"
+
+	// ErrorCodeUpstreamDown: ESI is unreachable, answers 5xx or is in downtime.
+	ErrorCodeUpstreamDown ErrorCode = "UPSTREAM_DOWN"
+
+	// ErrorCodeUnauthorized: missing, expired or insufficient access token
+	// (401, 403).
+	ErrorCodeUnauthorized ErrorCode = "UNAUTHORIZED"
+
+	// ErrorCodeNotFound: the resource does not exist (404).
+	ErrorCodeNotFound ErrorCode = "NOT_FOUND"
+
+	// ErrorCodeRetryExhausted: the request kept failing until the retries or
+	// the caller's deadline ran out.
+	ErrorCodeRetryExhausted ErrorCode = "RETRY_EXHAUSTED"
+
+	// ErrorCodeUnknown: any other failure.
+	ErrorCodeUnknown ErrorCode = "UNKNOWN"
 )
 
+// ErrorCodeOf returns the error code for an error returned by the client.
+func ErrorCodeOf(err error) ErrorCode {
+	var esiErr *ESIError
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrRetryExhausted), errors.Is(err, ErrDeadlineBudgetExceeded), errors.Is(err, ErrRetryBudgetExhausted):
+		return ErrorCodeRetryExhausted
+	case errors.Is(err, ErrRateLimited), errors.Is(err, ratelimit.ErrQuotaExceeded), errors.Is(err, ErrLoadShed):
+		return ErrorCodeRateLimited
+	case errors.Is(err, ErrDowntime), errors.Is(err, ErrBatchBudgetExhausted):
+		return ErrorCodeUpstreamDown
+	case errors.As(err, &esiErr):
+		return esiErr.Code()
+	default:
+		return ErrorCodeUnknown
+	}
+}
+
+// StatusErrorCode returns the error code for an ESI error status. Do returns
+// 4xx responses instead of errors; this maps them to the same codes.
+func StatusErrorCode(status int) ErrorCode {
+	switch {
+	case status == http.StatusUnauthorized, status == http.StatusForbidden:
+		return ErrorCodeUnauthorized
+	case status == http.StatusNotFound:
+		return ErrorCodeNotFound
+	case status == 420, status == http.StatusTooManyRequests, status == 520:
+		return ErrorCodeRateLimited
+	case status >= 500:
+		return ErrorCodeUpstreamDown
+	default:
+		return ErrorCodeUnknown
+	}
+}
+
 // ESIError represents an ESI-specific error with additional context.
 type ESIError struct {
 	StatusCode int
 	ErrorClass ErrorClass
+	Subclass   NetworkSubclass // Only set for ErrorClassNetwork
 	Message    string
+	RequestID  string // X-ESI-Request-ID of the failed response, if ESI sent one
 	Err        error
 }
 
 // Error implements the error interface.
 func (e *ESIError) Error() string {
+	class := string(e.ErrorClass)
+	if e.Subclass != "" {
+		class += "/" + string(e.Subclass)
+	}
+	status := fmt.Sprintf("status %d", e.StatusCode)
+	if e.RequestID != "" {
+		status += ", esi_request_id " + e.RequestID
+	}
 	if e.Err != nil {
-		return fmt.Sprintf("ESI %s error (status %d): %s: %v",
-			e.ErrorClass, e.StatusCode, e.Message, e.Err)
+		return fmt.Sprintf("ESI %s error (%s): %s: %v",
+			class, status, e.Message, e.Err)
 	}
-	return fmt.Sprintf("ESI %s error (status %d): %s",
-		e.ErrorClass, e.StatusCode, e.Message)
+	return fmt.Sprintf("ESI %s error (%s): %s",
+		class, status, e.Message)
 }
 
 // Unwrap implements error unwrapping for errors.Is/As.
@@ -37,6 +191,20 @@ func (e *ESIError) Unwrap() error {
 	return e.Err
 }
 
+// Code returns the stable error code of the error.
+func (e *ESIError) Code() ErrorCode {
+	switch {
+	case errors.Is(e.Err, ErrDowntime), errors.Is(e.Err, ErrChallenge):
+		return ErrorCodeUpstreamDown
+	case e.ErrorClass == ErrorClassNetwork:
+		return ErrorCodeUpstreamDown
+	case e.ErrorClass == ErrorClassRateLimit:
+		return ErrorCodeRateLimited
+	default:
+		return StatusErrorCode(e.StatusCode)
+	}
+}
+
 // shouldRetry determines if an error should be retried based on its classification.
 func shouldRetry(errorClass ErrorClass) bool {
 	switch errorClass {
@@ -52,6 +220,9 @@ func shouldRetry(errorClass ErrorClass) bool {
 	case ErrorClassNetwork:
 		// Network errors should be retried
 		return true
+	case ErrorClassChallenge:
+		// Challenges persist for minutes - all requests back off instead
+		return false
 	default:
 		return false
 	}
@@ -1,8 +1,13 @@
 package client
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"testing"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/ratelimit"
 )
 
 func TestShouldRetry(t *testing.T) {
@@ -31,6 +36,11 @@ func TestShouldRetry(t *testing.T) {
 			errorClass: ErrorClassNetwork,
 			expected:   true,
 		},
+		{
+			name:       "challenge should not retry",
+			errorClass: ErrorClassChallenge,
+			expected:   false,
+		},
 		{
 			name:       "empty error class should not retry",
 			errorClass: "",
@@ -84,6 +94,16 @@ func TestESIError_Error(t *testing.T) {
 			},
 			expected: "ESI rate_limit error (status 520): rate limit exceeded",
 		},
+		{
+			name: "error with ESI request ID",
+			esiError: &ESIError{
+				StatusCode: 502,
+				ErrorClass: ErrorClassServer,
+				Message:    "bad gateway",
+				RequestID:  "9f3c4e1a-8d0b-4c0e-9a7f-2b6d5e8c1f00",
+			},
+			expected: "ESI server error (status 502, esi_request_id 9f3c4e1a-8d0b-4c0e-9a7f-2b6d5e8c1f00): bad gateway",
+		},
 	}
 
 	for _, tt := range tests {
@@ -129,3 +149,70 @@ func TestESIError_UnwrapNil(t *testing.T) {
 		t.Errorf("Unwrap() = %v, want nil", unwrapped)
 	}
 }
+
+func TestESIError_ErrorWithSubclass(t *testing.T) {
+	esiError := &ESIError{
+		ErrorClass: ErrorClassNetwork,
+		Subclass:   NetworkSubclassDNS,
+		Message:    "transport error",
+		Err:        errors.New("no such host"),
+	}
+
+	expected := "ESI network/dns error (status 0): transport error: no such host"
+	if got := esiError.Error(); got != expected {
+		t.Errorf("Error() = %q, want %q", got, expected)
+	}
+}
+
+func TestErrorCodeOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorCode
+	}{
+		{"nil", nil, ""},
+		{"rate limiter block", fmt.Errorf("request blocked: %w", ErrRateLimited), ErrorCodeRateLimited},
+		{"quota exceeded", fmt.Errorf("request blocked: %w", ratelimit.ErrQuotaExceeded), ErrorCodeRateLimited},
+		{"ESI 520", &ESIError{StatusCode: 520, ErrorClass: ErrorClassRateLimit}, ErrorCodeRateLimited},
+		{"downtime", &ESIError{StatusCode: 503, ErrorClass: ErrorClassServer, Err: ErrDowntime}, ErrorCodeUpstreamDown},
+		{"challenge", challengeError(403, time.Now()), ErrorCodeUpstreamDown},
+		{"network", &ESIError{ErrorClass: ErrorClassNetwork, Err: errors.New("dial tcp")}, ErrorCodeUpstreamDown},
+		{"server", &ESIError{StatusCode: 502, ErrorClass: ErrorClassServer}, ErrorCodeUpstreamDown},
+		{"unauthorized", &ESIError{StatusCode: 403, ErrorClass: ErrorClassClient}, ErrorCodeUnauthorized},
+		{"not found", &ESIError{StatusCode: 404, ErrorClass: ErrorClassClient}, ErrorCodeNotFound},
+		{"retry exhausted", &BudgetError{Attempts: 3, Err: fmt.Errorf("%w after 3 attempts: %v", ErrRetryExhausted, &ESIError{StatusCode: 502})}, ErrorCodeRetryExhausted},
+		{"deadline budget", &BudgetError{Attempts: 1, Err: fmt.Errorf("%w: %w", ErrDeadlineBudgetExceeded, &ESIError{StatusCode: 502, ErrorClass: ErrorClassServer})}, ErrorCodeRetryExhausted},
+		{"other", context.Canceled, ErrorCodeUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ErrorCodeOf(tt.err); got != tt.want {
+				t.Errorf("ErrorCodeOf(%v) = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatusErrorCode(t *testing.T) {
+	tests := []struct {
+		status int
+		want   ErrorCode
+	}{
+		{400, ErrorCodeUnknown},
+		{401, ErrorCodeUnauthorized},
+		{403, ErrorCodeUnauthorized},
+		{404, ErrorCodeNotFound},
+		{420, ErrorCodeRateLimited},
+		{429, ErrorCodeRateLimited},
+		{500, ErrorCodeUpstreamDown},
+		{503, ErrorCodeUpstreamDown},
+		{520, ErrorCodeRateLimited},
+	}
+
+	for _, tt := range tests {
+		if got := StatusErrorCode(tt.status); got != tt.want {
+			t.Errorf("StatusErrorCode(%d) = %q, want %q", tt.status, got, tt.want)
+		}
+	}
+}
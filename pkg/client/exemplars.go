@@ -0,0 +1,32 @@
+package client
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// observe records v on h. If ctx carries a sampled OpenTelemetry span, its
+// trace ID is attached as exemplar, so a latency spike in Grafana links
+// straight to the offending trace. Without tracing this is a plain Observe.
+func observe(ctx context.Context, h prometheus.Observer, v float64) {
+	observeWithESIRequestID(ctx, h, v, "")
+}
+
+// observeWithESIRequestID is observe with ESI's X-ESI-Request-ID added to
+// the exemplar, so a slow or failed request can be quoted to CCP.
+func observeWithESIRequestID(ctx context.Context, h prometheus.Observer, v float64, esiRequestID string) {
+	labels := prometheus.Labels{}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsSampled() {
+		labels["trace_id"] = sc.TraceID().String()
+	}
+	if esiRequestID != "" {
+		labels["esi_request_id"] = esiRequestID
+	}
+	if eo, ok := h.(prometheus.ExemplarObserver); ok && len(labels) > 0 {
+		eo.ObserveWithExemplar(v, labels)
+		return
+	}
+	h.Observe(v)
+}
@@ -0,0 +1,62 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestObserve_Exemplar(t *testing.T) {
+	traceID := trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36}
+	spanCtx := func(flags trace.TraceFlags) context.Context {
+		return trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    traceID,
+			SpanID:     trace.SpanID{0, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+			TraceFlags: flags,
+		}))
+	}
+
+	tests := []struct {
+		name         string
+		ctx          context.Context
+		esiRequestID string
+		wantTraceID  string
+	}{
+		{"sampled span", spanCtx(trace.FlagsSampled), "", traceID.String()},
+		{"unsampled span", spanCtx(0), "", ""},
+		{"no tracing", context.Background(), "", ""},
+		{"ESI request ID without tracing", context.Background(), "9f3c4e1a", ""},
+		{"ESI request ID and sampled span", spanCtx(trace.FlagsSampled), "9f3c4e1a", traceID.String()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "test_seconds", Buckets: []float64{1}})
+			observeWithESIRequestID(tt.ctx, h, 0.5, tt.esiRequestID)
+
+			var m dto.Metric
+			if err := h.Write(&m); err != nil {
+				t.Fatalf("write metric: %v", err)
+			}
+			if got := m.GetHistogram().GetSampleCount(); got != 1 {
+				t.Fatalf("sample count = %d, want 1", got)
+			}
+
+			labels := map[string]string{}
+			if exemplar := m.GetHistogram().GetBucket()[0].GetExemplar(); exemplar != nil {
+				for _, label := range exemplar.GetLabel() {
+					labels[label.GetName()] = label.GetValue()
+				}
+			}
+			if got := labels["trace_id"]; got != tt.wantTraceID {
+				t.Errorf("exemplar trace_id = %q, want %q", got, tt.wantTraceID)
+			}
+			if got := labels["esi_request_id"]; got != tt.esiRequestID {
+				t.Errorf("exemplar esi_request_id = %q, want %q", got, tt.esiRequestID)
+			}
+		})
+	}
+}
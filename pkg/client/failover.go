@@ -0,0 +1,228 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// defaultUpstreamCooldown is used when Config.UpstreamCooldown is unset.
+const defaultUpstreamCooldown = 30 * time.Second
+
+// Upstream metrics.
+var (
+	esiUpstreamFailoversTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "esi_upstream_failovers_total",
+		Help: "Total number of requests moved to the next upstream because an upstream failed",
+	}, []string{"upstream"})
+
+	esiUpstreamUp = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "esi_upstream_up",
+		Help: "Whether an upstream is considered healthy (1) or cooling down after a failure (0)",
+	}, []string{"upstream"})
+)
+
+// Upstream is an ESI host or mirror requests can be sent to.
+type Upstream struct {
+	// BaseURL replaces https://esi.evetech.net, e.g. "http://esi-mirror.internal"
+	BaseURL string
+
+	// Routes limits the upstream to routes starting with these prefixes,
+	// matched without version like "universe/" (default: all routes).
+	// A mirror of static data typically serves "universe/" and "dogma/".
+	Routes []string
+}
+
+// serves reports whether the upstream serves route (path without version).
+func (u Upstream) serves(route string) bool {
+	if len(u.Routes) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(u.Routes, func(prefix string) bool {
+		return strings.HasPrefix(route, strings.TrimLeft(prefix, "/"))
+	})
+}
+
+// validateUpstreams checks Config.Upstreams and Config.UpstreamCooldown.
+func validateUpstreams(cfg Config) error {
+	if cfg.UpstreamCooldown < 0 {
+		return fmt.Errorf("upstream_cooldown must not be negative (got %s)", cfg.UpstreamCooldown)
+	}
+	for _, upstream := range cfg.Upstreams {
+		u, err := url.Parse(upstream.BaseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("upstreams must be http(s) URLs with host (got %q)", upstream.BaseURL)
+		}
+	}
+	return nil
+}
+
+// upstreamState is an upstream with its parsed base URL and health.
+type upstreamState struct {
+	Upstream
+	base      *url.URL
+	downUntil time.Time // zero while healthy
+}
+
+// upstreamTransport sends each request to the first healthy upstream serving
+// its route. Idempotent requests (GET, HEAD) that fail with a transport
+// error or 5xx move on to the next upstream; the failed upstream is skipped
+// for the cooldown and then tried again by the next request. If every
+// candidate is cooling down, they are tried in order anyway: a stale health
+// verdict must not make data unavailable.
+type upstreamTransport struct {
+	next     http.RoundTripper
+	cooldown time.Duration
+	now      func() time.Time
+
+	mu        sync.Mutex
+	upstreams []*upstreamState
+}
+
+// newUpstreamTransport wraps next with failover between upstreams, or
+// returns next if none are configured. Upstreams are validated in New().
+func newUpstreamTransport(cfg Config, next http.RoundTripper) http.RoundTripper {
+	if len(cfg.Upstreams) == 0 {
+		return next
+	}
+
+	cooldown := cfg.UpstreamCooldown
+	if cooldown <= 0 {
+		cooldown = defaultUpstreamCooldown
+	}
+
+	t := &upstreamTransport{next: next, cooldown: cooldown, now: time.Now}
+	for _, upstream := range cfg.Upstreams {
+		base, _ := url.Parse(upstream.BaseURL)
+		t.upstreams = append(t.upstreams, &upstreamState{Upstream: upstream, base: base})
+		esiUpstreamUp.WithLabelValues(base.Host).Set(1)
+	}
+	return t
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *upstreamTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	candidates := t.candidates(req.URL.Path)
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no upstream serves %s", req.URL.Path)
+	}
+	idempotent := req.Method == "" || req.Method == http.MethodGet || req.Method == http.MethodHead
+
+	var (
+		resp *http.Response
+		err  error
+	)
+	for i, upstream := range candidates {
+		attempt, rewindErr := rewrite(req, upstream.base, i > 0)
+		if rewindErr != nil {
+			return nil, rewindErr
+		}
+
+		resp, err = t.next.RoundTrip(attempt)
+		failed := err != nil || resp.StatusCode >= http.StatusInternalServerError
+		if !failed {
+			t.markUp(upstream)
+			return resp, nil
+		}
+		if req.Context().Err() != nil {
+			return resp, err
+		}
+
+		t.markDown(upstream)
+		if !idempotent || i == len(candidates)-1 {
+			break
+		}
+		esiUpstreamFailoversTotal.WithLabelValues(upstream.base.Host).Inc()
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+	}
+	return resp, err
+}
+
+// candidates returns the upstreams serving path, healthy ones first, each
+// group in configured order.
+func (t *upstreamTransport) candidates(path string) []*upstreamState {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) > 1 && isVersionSegment(segments[0]) {
+		segments = segments[1:]
+	}
+	route := strings.Join(segments, "/")
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	var healthy, down []*upstreamState
+	for _, upstream := range t.upstreams {
+		switch {
+		case !upstream.serves(route):
+		case now.Before(upstream.downUntil):
+			down = append(down, upstream)
+		default:
+			healthy = append(healthy, upstream)
+		}
+	}
+	return append(healthy, down...)
+}
+
+// markDown starts the cooldown of a failed upstream.
+func (t *upstreamTransport) markDown(upstream *upstreamState) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	upstream.downUntil = t.now().Add(t.cooldown)
+	esiUpstreamUp.WithLabelValues(upstream.base.Host).Set(0)
+}
+
+// markUp ends the cooldown of an upstream that answered.
+func (t *upstreamTransport) markUp(upstream *upstreamState) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !upstream.downUntil.IsZero() {
+		upstream.downUntil = time.Time{}
+		esiUpstreamUp.WithLabelValues(upstream.base.Host).Set(1)
+	}
+}
+
+// down returns how many of the upstreams are cooling down after a failure.
+func (t *upstreamTransport) down() (down, total int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	for _, upstream := range t.upstreams {
+		if now.Before(upstream.downUntil) {
+			down++
+		}
+	}
+	return down, len(t.upstreams)
+}
+
+// rewrite returns a copy of req addressed to base. The body is rewound for
+// every attempt after the first.
+func rewrite(req *http.Request, base *url.URL, rewind bool) (*http.Request, error) {
+	attempt := req.Clone(req.Context())
+	attempt.URL.Scheme = base.Scheme
+	attempt.URL.Host = base.Host
+	attempt.URL.Path = strings.TrimSuffix(base.Path, "/") + req.URL.Path
+	attempt.URL.RawPath = ""
+	attempt.Host = ""
+
+	if rewind && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("rewind request body: %w", err)
+		}
+		attempt.Body = body
+	}
+	return attempt, nil
+}
@@ -0,0 +1,137 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// upstreamServer answers with status and its name, counting requests in hits.
+func upstreamServer(t *testing.T, name string, status *atomic.Int64, hits *atomic.Int64) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(int(status.Load()))
+		w.Write([]byte(name + " " + r.URL.Path))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestUpstreamTransport_Failover(t *testing.T) {
+	var primaryStatus, mirrorStatus, primaryHits, mirrorHits atomic.Int64
+	primaryStatus.Store(http.StatusOK)
+	mirrorStatus.Store(http.StatusOK)
+	primary := upstreamServer(t, "primary", &primaryStatus, &primaryHits)
+	mirror := upstreamServer(t, "mirror", &mirrorStatus, &mirrorHits)
+
+	now := time.Now()
+	transport := newUpstreamTransport(Config{
+		Upstreams: []Upstream{
+			{BaseURL: primary.URL},
+			{BaseURL: mirror.URL + "/esi", Routes: []string{"universe/"}},
+		},
+		UpstreamCooldown: time.Minute,
+	}, http.DefaultTransport).(*upstreamTransport)
+	transport.now = func() time.Time { return now }
+	client := &http.Client{Transport: transport}
+
+	get := func(method, endpoint string) (int, string) {
+		t.Helper()
+		req, _ := http.NewRequest(method, esiBaseURL+endpoint, strings.NewReader("[1]"))
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Do() error = %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	if _, body := get(http.MethodGet, "/v3/universe/types/34/"); body != "primary /v3/universe/types/34/" {
+		t.Errorf("healthy primary: body = %q, want primary", body)
+	}
+
+	// Primary fails: static routes move to the mirror (with its path prefix)
+	primaryStatus.Store(http.StatusServiceUnavailable)
+	if _, body := get(http.MethodGet, "/v3/universe/types/34/"); body != "mirror /esi/v3/universe/types/34/" {
+		t.Errorf("failover: body = %q, want mirror", body)
+	}
+
+	// During the cooldown the mirror is asked first
+	before := primaryHits.Load()
+	get(http.MethodGet, "/v3/universe/types/35/")
+	if primaryHits.Load() != before {
+		t.Error("primary asked during cooldown, want mirror first")
+	}
+
+	// Routes the mirror doesn't serve still go to the primary
+	if status, _ := get(http.MethodGet, "/v1/markets/prices/"); status != http.StatusServiceUnavailable {
+		t.Errorf("unmirrored route: status = %d, want primary's 503", status)
+	}
+
+	// Non-idempotent requests are not repeated on another upstream
+	mirrorStatus.Store(http.StatusInternalServerError)
+	before = primaryHits.Load()
+	if status, _ := get(http.MethodPost, "/v3/universe/names/"); status != http.StatusInternalServerError || primaryHits.Load() != before {
+		t.Errorf("POST: status = %d, primary hits %d -> %d; want mirror's 500 only", status, before, primaryHits.Load())
+	}
+
+	// After the cooldown the recovered primary is used again
+	primaryStatus.Store(http.StatusOK)
+	mirrorStatus.Store(http.StatusOK)
+	now = now.Add(2 * time.Minute)
+	if _, body := get(http.MethodGet, "/v3/universe/types/34/"); !strings.HasPrefix(body, "primary") {
+		t.Errorf("after cooldown: body = %q, want primary", body)
+	}
+}
+
+func TestUpstreamTransport_AllDown(t *testing.T) {
+	var status, hits atomic.Int64
+	status.Store(http.StatusBadGateway)
+	server := upstreamServer(t, "only", &status, &hits)
+
+	transport := newUpstreamTransport(Config{Upstreams: []Upstream{{BaseURL: server.URL}}}, http.DefaultTransport)
+	client := &http.Client{Transport: transport}
+
+	// A failed upstream cooling down is still tried when nothing else serves the route
+	for range 2 {
+		resp, err := client.Get(esiBaseURL + "/v1/status/")
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadGateway {
+			t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusBadGateway)
+		}
+	}
+	if n := hits.Load(); n != 2 {
+		t.Errorf("requests = %d, want 2", n)
+	}
+}
+
+func TestValidateUpstreams(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"none", Config{}, false},
+		{"https and http", Config{Upstreams: []Upstream{{BaseURL: "https://esi.evetech.net"}, {BaseURL: "http://mirror:8080/esi"}}}, false},
+		{"missing scheme", Config{Upstreams: []Upstream{{BaseURL: "mirror.internal"}}}, true},
+		{"unsupported scheme", Config{Upstreams: []Upstream{{BaseURL: "ftp://mirror.internal"}}}, true},
+		{"negative cooldown", Config{UpstreamCooldown: -time.Second}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateUpstreams(tt.cfg); (err != nil) != tt.wantErr {
+				t.Errorf("validateUpstreams() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
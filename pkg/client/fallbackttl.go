@@ -0,0 +1,47 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// validateFallbackTTLs checks Config.DefaultTTL and Config.FallbackTTLs.
+func validateFallbackTTLs(cfg Config) error {
+	if cfg.DefaultTTL < 0 {
+		return fmt.Errorf("default_ttl must not be negative (got %s)", cfg.DefaultTTL)
+	}
+	for route, ttl := range cfg.FallbackTTLs {
+		if strings.Trim(route, "/") == "" {
+			return fmt.Errorf("fallback_ttls: empty route")
+		}
+		if ttl <= 0 {
+			return fmt.Errorf("fallback_ttls: TTL for %q must be > 0 (got %s)", route, ttl)
+		}
+	}
+	return nil
+}
+
+// fallbackTTL returns the TTL for responses from endpoint without Expires
+// header: the TTL of the longest matching Config.FallbackTTLs prefix and
+// that prefix, or the cache manager's fallback TTL and "".
+func (c *Client) fallbackTTL(endpoint string) (route string, ttl time.Duration) {
+	if len(c.config.FallbackTTLs) > 0 {
+		segments := strings.Split(strings.Trim(endpoint, "/"), "/")
+		if len(segments) > 1 && isVersionSegment(segments[0]) {
+			segments = segments[1:]
+		}
+		path := strings.Join(segments, "/") + "/"
+
+		for prefix, d := range c.config.FallbackTTLs {
+			trimmed := strings.TrimLeft(prefix, "/")
+			if strings.HasPrefix(path, trimmed) && len(trimmed) > len(strings.TrimLeft(route, "/")) {
+				route, ttl = prefix, d
+			}
+		}
+	}
+	if route == "" {
+		ttl = c.cache.FallbackTTL()
+	}
+	return route, ttl
+}
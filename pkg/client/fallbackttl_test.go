@@ -0,0 +1,141 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+)
+
+func TestFallbackTTL(t *testing.T) {
+	manager := cache.NewManager(setupTestRedis(t))
+	manager.SetFallbackTTL(10 * time.Minute)
+	c := &Client{cache: manager, config: Config{FallbackTTLs: map[string]time.Duration{
+		"status/":          30 * time.Second,
+		"universe/":        24 * time.Hour,
+		"/universe/names/": time.Hour,
+	}}}
+
+	tests := []struct {
+		endpoint  string
+		wantRoute string
+		wantTTL   time.Duration
+	}{
+		{"/v2/status/", "status/", 30 * time.Second},
+		{"/v1/universe/races/", "universe/", 24 * time.Hour},
+		{"/v3/universe/names/", "/universe/names/", time.Hour},
+		{"/v1/markets/prices/", "", 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.endpoint, func(t *testing.T) {
+			route, ttl := c.fallbackTTL(tt.endpoint)
+			if route != tt.wantRoute || ttl != tt.wantTTL {
+				t.Errorf("fallbackTTL() = %q, %s, want %q, %s", route, ttl, tt.wantRoute, tt.wantTTL)
+			}
+		})
+	}
+}
+
+func TestNew_FallbackTTLsValidation(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	tests := []struct {
+		name       string
+		defaultTTL time.Duration
+		routes     map[string]time.Duration
+		wantErr    bool
+	}{
+		{"valid", time.Minute, map[string]time.Duration{"status/": 30 * time.Second}, false},
+		{"negative default", -time.Minute, nil, true},
+		{"empty route", 0, map[string]time.Duration{"/": time.Minute}, true},
+		{"zero ttl", 0, map[string]time.Duration{"status/": 0}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig(redisClient, "TestApp/1.0.0")
+			cfg.DefaultTTL = tt.defaultTTL
+			cfg.FallbackTTLs = tt.routes
+			client, err := New(cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if client != nil {
+				client.Close()
+			}
+		})
+	}
+}
+
+func TestDo_FallbackTTL(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-ESI-Error-Limit-Remain", "100")
+		w.Header().Set("X-ESI-Error-Limit-Reset", "60")
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	cfg := DefaultConfig(redisClient, "TestApp/1.0.0")
+	cfg.DefaultTTL = 2 * time.Hour
+	cfg.FallbackTTLs = map[string]time.Duration{"status/": 30 * time.Second}
+	cfg.AdaptiveTTL = true
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+	client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+
+	tests := []struct {
+		name     string
+		endpoint string
+		wantTTL  time.Duration
+	}{
+		{"route override wins over adaptive ttl", "/v2/status/", 30 * time.Second},
+		{"other routes learn", "/v1/universe/races/", defaultAdaptiveTTLMin},
+	}
+
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := client.Get(ctx, tt.endpoint)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			resp.Body.Close()
+
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, esiBaseURL+tt.endpoint, nil)
+			key, _, _ := client.requestCacheKey(req, false)
+			entry, err := client.cache.Get(ctx, key)
+			if err != nil {
+				t.Fatalf("cache.Get() error = %v", err)
+			}
+			if ttl := entry.TTL(); ttl < tt.wantTTL-2*time.Second || ttl > tt.wantTTL {
+				t.Errorf("TTL = %s, want %s", ttl, tt.wantTTL)
+			}
+		})
+	}
+
+	// Without adaptive TTLs the default TTL applies
+	client.config.AdaptiveTTL = false
+	resp, err := client.Get(ctx, "/v1/universe/bloodlines/")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, esiBaseURL+"/v1/universe/bloodlines/", nil)
+	key, _, _ := client.requestCacheKey(req, false)
+	entry, err := client.cache.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("cache.Get() error = %v", err)
+	}
+	if ttl := entry.TTL(); ttl < 2*time.Hour-2*time.Second {
+		t.Errorf("TTL = %s, want %s", ttl, 2*time.Hour)
+	}
+}
@@ -0,0 +1,127 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/ratelimit"
+)
+
+// HealthStatus is the overall status reported by Health.
+type HealthStatus string
+
+// Health statuses, from best to worst.
+const (
+	HealthOK       HealthStatus = "OK"
+	HealthDegraded HealthStatus = "DEGRADED" // Requests succeed, but slower, throttled or from stale data
+	HealthCritical HealthStatus = "CRITICAL" // Requests are blocked or cannot reach ESI
+)
+
+// Health signal names as reported in HealthReason.Signal.
+const (
+	HealthSignalRedis         = "redis"
+	HealthSignalErrorBudget   = "error_budget"
+	HealthSignalCacheMissRate = "cache_miss_rate"
+	HealthSignalUpstream      = "upstream"
+	HealthSignalDowntime      = "downtime"
+	HealthSignalIncident      = "incident"
+)
+
+const (
+	// healthMinRequests is the number of requests before the cache miss
+	// rate is judged; a fresh client misses by design.
+	healthMinRequests = 100
+
+	// healthMaxMissRate is the cache miss rate above which the client is
+	// degraded.
+	healthMaxMissRate = 0.5
+)
+
+// healthPenalty is subtracted from the score per reason of a status.
+var healthPenalty = map[HealthStatus]int{
+	HealthDegraded: 25,
+	HealthCritical: 60,
+}
+
+// HealthReason explains why Health is not OK.
+type HealthReason struct {
+	Signal string       `json:"signal"`
+	Status HealthStatus `json:"status"`
+	Detail string       `json:"detail"`
+}
+
+// Health is a composite view of the client's health for readiness probes
+// and dashboards.
+type Health struct {
+	Status    HealthStatus   `json:"status"`
+	Score     int            `json:"score"` // 100 = healthy, 0 = unusable
+	Reasons   []HealthReason `json:"reasons,omitempty"`
+	CheckedAt time.Time      `json:"checked_at"`
+}
+
+// String renders the status and one reason per line.
+func (h Health) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s (score %d)\n", h.Status, h.Score)
+	for _, reason := range h.Reasons {
+		fmt.Fprintf(&b, "[%s] %-15s %s\n", reason.Status, reason.Signal, reason.Detail)
+	}
+	return b.String()
+}
+
+// add records a reason and lowers the score and status accordingly.
+func (h *Health) add(signal string, status HealthStatus, detail string) {
+	h.Reasons = append(h.Reasons, HealthReason{Signal: signal, Status: status, Detail: detail})
+	h.Score = max(h.Score-healthPenalty[status], 0)
+	if status == HealthCritical || h.Status == HealthOK {
+		h.Status = status
+	}
+}
+
+// Health combines the rate limit state, cache effectiveness, upstream
+// failover state, ESI downtime and incident mode into one status with a score. It reads
+// the shared rate limit state from Redis but sends no request to ESI, so
+// it is cheap enough for every readiness probe.
+func (c *Client) Health(ctx context.Context) Health {
+	h := Health{Status: HealthOK, Score: 100, CheckedAt: time.Now()}
+
+	state, err := c.rateLimiter.GetState(ctx)
+	switch {
+	case err != nil:
+		h.add(HealthSignalRedis, HealthCritical, fmt.Sprintf("rate limit state unavailable: %v", err))
+	case state.IsBlocked():
+		h.add(HealthSignalErrorBudget, HealthCritical, fmt.Sprintf("blocked after 420 until %s", state.BlockedUntil.Format(time.RFC3339)))
+	case state.NeedsCriticalBlock():
+		h.add(HealthSignalErrorBudget, HealthCritical, fmt.Sprintf("%d errors remaining (critical below %d)", state.ErrorsRemaining, ratelimit.ErrorThresholdCritical))
+	case state.NeedsThrottling():
+		h.add(HealthSignalErrorBudget, HealthDegraded, fmt.Sprintf("%d errors remaining (throttling below %d)", state.ErrorsRemaining, ratelimit.ErrorThresholdWarning))
+	}
+
+	stats := c.Stats()
+	if stats.Requests >= healthMinRequests {
+		missRate := float64(stats.Requests-stats.CacheHits) / float64(stats.Requests)
+		if missRate > healthMaxMissRate {
+			h.add(HealthSignalCacheMissRate, HealthDegraded, fmt.Sprintf("%.0f%% of %d requests missed the cache", missRate*100, stats.Requests))
+		}
+	}
+
+	if transport, ok := c.httpClient.Transport.(*upstreamTransport); ok {
+		if down, total := transport.down(); down == total {
+			h.add(HealthSignalUpstream, HealthCritical, fmt.Sprintf("all %d upstreams cooling down after failures", total))
+		} else if down > 0 {
+			h.add(HealthSignalUpstream, HealthDegraded, fmt.Sprintf("%d of %d upstreams cooling down after failures", down, total))
+		}
+	}
+
+	if until, down := c.downtime.active(); down {
+		h.add(HealthSignalDowntime, HealthDegraded, fmt.Sprintf("ESI downtime until %s, serving cache", until.Format(time.RFC3339)))
+	}
+
+	if active, detail, since := c.IncidentMode(); active {
+		h.add(HealthSignalIncident, HealthDegraded, fmt.Sprintf("ESI incident since %s (%s), incident mode", since.Format(time.RFC3339), detail))
+	}
+
+	return h
+}
@@ -0,0 +1,89 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestClient_Health(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	tests := []struct {
+		name        string
+		remain      string
+		setup       func(c *Client)
+		wantStatus  HealthStatus
+		wantScore   int
+		wantSignals []string
+	}{
+		{"healthy", "100", nil, HealthOK, 100, nil},
+		{"low error budget", "10", nil, HealthDegraded, 75, []string{HealthSignalErrorBudget}},
+		{"critical error budget", "3", nil, HealthCritical, 40, []string{HealthSignalErrorBudget}},
+		{"high miss rate", "100", func(c *Client) {
+			c.stats.requests.Add(healthMinRequests)
+			c.stats.cacheHits.Add(healthMinRequests / 4)
+		}, HealthDegraded, 75, []string{HealthSignalCacheMissRate}},
+		{"downtime and low error budget", "10", func(c *Client) {
+			c.downtime.begin(time.Now().Add(time.Minute))
+		}, HealthDegraded, 50, []string{HealthSignalErrorBudget, HealthSignalDowntime}},
+		{"all upstreams down", "100", func(c *Client) {
+			transport := newUpstreamTransport(Config{Upstreams: []Upstream{{BaseURL: "http://mirror.invalid"}}}, http.DefaultTransport).(*upstreamTransport)
+			transport.markDown(transport.upstreams[0])
+			c.httpClient = &http.Client{Transport: transport}
+		}, HealthCritical, 40, []string{HealthSignalUpstream}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			redisClient.FlushDB(context.Background())
+			client, err := New(DefaultConfig(redisClient, "TestApp/1.0.0"))
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+			defer client.Close()
+
+			headers := http.Header{}
+			headers.Set("X-ESI-Error-Limit-Remain", tt.remain)
+			headers.Set("X-ESI-Error-Limit-Reset", "60")
+			if err := client.rateLimiter.UpdateFromHeaders(context.Background(), headers); err != nil {
+				t.Fatalf("UpdateFromHeaders() error = %v", err)
+			}
+			if tt.setup != nil {
+				tt.setup(client)
+			}
+
+			health := client.Health(context.Background())
+			if health.Status != tt.wantStatus || health.Score != tt.wantScore {
+				t.Errorf("Health() = %s (score %d), want %s (score %d)\n%s", health.Status, health.Score, tt.wantStatus, tt.wantScore, health)
+			}
+			var signals []string
+			for _, reason := range health.Reasons {
+				signals = append(signals, reason.Signal)
+			}
+			if len(signals) != len(tt.wantSignals) {
+				t.Fatalf("signals = %v, want %v", signals, tt.wantSignals)
+			}
+			for i := range signals {
+				if signals[i] != tt.wantSignals[i] {
+					t.Errorf("signals = %v, want %v", signals, tt.wantSignals)
+				}
+			}
+		})
+	}
+}
+
+func TestClient_HealthRedisDown(t *testing.T) {
+	redisClient := setupTestRedis(t)
+	client, err := New(DefaultConfig(redisClient, "TestApp/1.0.0"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	redisClient.Close()
+
+	health := client.Health(context.Background())
+	if health.Status != HealthCritical || len(health.Reasons) != 1 || health.Reasons[0].Signal != HealthSignalRedis {
+		t.Errorf("Health() = %+v, want CRITICAL because of redis", health)
+	}
+}
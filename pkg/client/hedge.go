@@ -0,0 +1,222 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Hedging tuning.
+const (
+	// defaultHedgeDelay is used until enough latency samples exist to derive a P95.
+	defaultHedgeDelay = 500 * time.Millisecond
+
+	// hedgeMinSamples is the number of observed latencies required before the
+	// P95 is used as hedge delay.
+	hedgeMinSamples = 20
+
+	// hedgeWindowSize is the number of recent latencies kept per endpoint group.
+	hedgeWindowSize = 200
+)
+
+// latencyWindow keeps a ring buffer of recent request latencies.
+type latencyWindow struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+}
+
+// observe records a latency sample.
+func (w *latencyWindow) observe(d time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.samples) < hedgeWindowSize {
+		w.samples = append(w.samples, d)
+		return
+	}
+	w.samples[w.next] = d
+	w.next = (w.next + 1) % hedgeWindowSize
+}
+
+// p95 returns the 95th percentile latency and false if too few samples exist.
+func (w *latencyWindow) p95() (time.Duration, bool) {
+	w.mu.Lock()
+	if len(w.samples) < hedgeMinSamples {
+		w.mu.Unlock()
+		return 0, false
+	}
+	sorted := make([]time.Duration, len(w.samples))
+	copy(sorted, w.samples)
+	w.mu.Unlock()
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[(len(sorted)*95)/100], true
+}
+
+// hedgeTracker holds per-group latency windows for hedging decisions.
+type hedgeTracker struct {
+	mu      sync.Mutex
+	windows map[string]*latencyWindow
+}
+
+// window returns the latency window for an endpoint group.
+func (h *hedgeTracker) window(group string) *latencyWindow {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.windows == nil {
+		h.windows = make(map[string]*latencyWindow)
+	}
+	w, ok := h.windows[group]
+	if !ok {
+		w = &latencyWindow{}
+		h.windows[group] = w
+	}
+	return w
+}
+
+// shouldHedge reports whether a request may be hedged. Only idempotent GETs on
+// interactive endpoints qualify, and never while the error budget is below healthy.
+func (c *Client) shouldHedge(ctx context.Context, req *http.Request, interactive bool) bool {
+	if !c.config.HedgeRequests || !interactive || req.Method != http.MethodGet {
+		return false
+	}
+
+	state, err := c.rateLimiter.GetState(ctx)
+	if err != nil || !state.IsHealthy {
+		return false
+	}
+	return true
+}
+
+// hedgeDelay returns the P95 latency of the group, or the configured fallback.
+func (c *Client) hedgeDelay(group string) time.Duration {
+	if p95, ok := c.hedges.window(group).p95(); ok {
+		return p95
+	}
+	if c.config.HedgeDelay > 0 {
+		return c.config.HedgeDelay
+	}
+	return defaultHedgeDelay
+}
+
+// hedgeResult is the outcome of a single hedged attempt.
+type hedgeResult struct {
+	resp  *http.Response
+	err   error
+	hedge bool
+}
+
+// doHedged sends req and, if no response arrived within the group's hedge
+// delay, a second identical request. The first successful response wins and
+// the other attempt is cancelled right away.
+func (c *Client) doHedged(req *http.Request, group string) (*http.Response, error) {
+	start := time.Now()
+	results := make(chan hedgeResult, 2)
+
+	// Attempt contexts, primary first; kept here so the loser can be
+	// cancelled while it is still waiting for ESI
+	var cancels [2]context.CancelFunc
+	attempt := func(hedge bool) int {
+		if hedge {
+			return 1
+		}
+		return 0
+	}
+	defer func() {
+		for _, cancel := range cancels {
+			if cancel != nil {
+				cancel()
+			}
+		}
+	}()
+
+	send := func(hedge bool) {
+		ctx, cancel := context.WithCancel(req.Context())
+		cancels[attempt(hedge)] = cancel
+		clone := req.Clone(ctx)
+		go func() {
+			resp, err := c.httpClient.Do(clone)
+			results <- hedgeResult{resp: resp, err: err, hedge: hedge}
+		}()
+	}
+
+	send(false)
+	inflight := 1
+	hedged := false
+
+	timer := time.NewTimer(c.hedgeDelay(group))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			if !hedged {
+				hedged = true
+				inflight++
+				send(true)
+				c.logger.Debug().Str("group", group).Msg("Sending hedged request")
+			}
+
+		case r := <-results:
+			inflight--
+
+			// Wait for the other attempt if this one failed
+			if r.err != nil && inflight > 0 {
+				continue
+			}
+
+			// Cancel the loser now and close its body once it returns
+			if inflight > 0 {
+				cancels[attempt(!r.hedge)]()
+				go drainHedgeResults(results, inflight)
+			}
+
+			if r.err != nil {
+				return nil, r.err
+			}
+
+			c.hedges.window(group).observe(time.Since(start))
+			if hedged {
+				winner := "primary"
+				if r.hedge {
+					winner = "hedge"
+				}
+				esiHedgedRequestsTotal.WithLabelValues(group, winner).Inc()
+			}
+
+			// The winner's context lives until its body is closed
+			winner := attempt(r.hedge)
+			r.resp.Body = &cancelOnClose{ReadCloser: r.resp.Body, cancel: cancels[winner]}
+			cancels[winner] = nil
+			return r.resp, nil
+		}
+	}
+}
+
+// drainHedgeResults closes the bodies of outstanding, already cancelled
+// hedged attempts.
+func drainHedgeResults(results <-chan hedgeResult, n int) {
+	for i := 0; i < n; i++ {
+		if r := <-results; r.resp != nil {
+			r.resp.Body.Close()
+		}
+	}
+}
+
+// cancelOnClose releases the attempt context once the winning body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close closes the body and cancels its context.
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
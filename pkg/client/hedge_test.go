@@ -0,0 +1,203 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestLatencyWindow_P95(t *testing.T) {
+	w := &latencyWindow{}
+
+	// Not enough samples yet
+	for i := 0; i < hedgeMinSamples-1; i++ {
+		w.observe(10 * time.Millisecond)
+	}
+	if _, ok := w.p95(); ok {
+		t.Error("p95() should not be available below hedgeMinSamples")
+	}
+
+	// 1..100ms -> P95 is 96ms (index 95)
+	w = &latencyWindow{}
+	for i := 1; i <= 100; i++ {
+		w.observe(time.Duration(i) * time.Millisecond)
+	}
+	got, ok := w.p95()
+	if !ok {
+		t.Fatal("p95() should be available")
+	}
+	if got != 96*time.Millisecond {
+		t.Errorf("p95() = %v, want 96ms", got)
+	}
+}
+
+func TestLatencyWindow_RingBuffer(t *testing.T) {
+	w := &latencyWindow{}
+	for i := 0; i < hedgeWindowSize*2; i++ {
+		w.observe(time.Millisecond)
+	}
+	if len(w.samples) != hedgeWindowSize {
+		t.Errorf("len(samples) = %d, want %d", len(w.samples), hedgeWindowSize)
+	}
+}
+
+func TestDoHedged_HedgeWinsWhenPrimaryIsSlow(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			// Primary hangs until cancelled
+			select {
+			case <-r.Context().Done():
+			case <-time.After(2 * time.Second):
+			}
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("hedge"))
+	}))
+	defer server.Close()
+
+	c := &Client{
+		httpClient: server.Client(),
+		logger:     zerolog.Nop(),
+		config:     Config{HedgeDelay: 20 * time.Millisecond},
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/v1/fleets/1/", nil)
+	start := time.Now()
+	resp, err := c.doHedged(req, InteractiveGroupFleets)
+	if err != nil {
+		t.Fatalf("doHedged() error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "hedge" {
+		t.Errorf("body = %q, want hedge response", body)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("hedged request took %v, primary was not bypassed", elapsed)
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("calls = %d, want 2", got)
+	}
+}
+
+func TestDoHedged_CancelsLoser(t *testing.T) {
+	loserCancelled := make(chan struct{})
+	var calls int32
+	c := &Client{
+		httpClient: &http.Client{Transport: hedgeTransport(func(req *http.Request) (*http.Response, error) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				// Primary blocks until the hedge wins
+				<-req.Context().Done()
+				close(loserCancelled)
+				return nil, req.Context().Err()
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("hedge"))}, nil
+		})},
+		logger: zerolog.Nop(),
+		config: Config{HedgeDelay: 10 * time.Millisecond},
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, esiBaseURL+"/v1/fleets/1/", nil)
+	resp, err := c.doHedged(req, InteractiveGroupFleets)
+	if err != nil {
+		t.Fatalf("doHedged() error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	// The loser is cancelled while the winner's body is still open
+	select {
+	case <-loserCancelled:
+	case <-time.After(time.Second):
+		t.Fatal("losing attempt was not cancelled")
+	}
+}
+
+func TestDoHedged_NoHedgeWhenPrimaryIsFast(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	c := &Client{
+		httpClient: server.Client(),
+		logger:     zerolog.Nop(),
+		config:     Config{HedgeDelay: time.Second},
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/v1/fleets/1/", nil)
+	resp, err := c.doHedged(req, InteractiveGroupFleets)
+	if err != nil {
+		t.Fatalf("doHedged() error = %v", err)
+	}
+	resp.Body.Close()
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("calls = %d, want 1", got)
+	}
+}
+
+func TestShouldHedge(t *testing.T) {
+	c := &Client{config: Config{HedgeRequests: false}}
+	req, _ := http.NewRequest(http.MethodGet, "https://esi.evetech.net/v1/fleets/1/", nil)
+	if c.shouldHedge(req.Context(), req, true) {
+		t.Error("shouldHedge() must be false when hedging is disabled")
+	}
+
+	c.config.HedgeRequests = true
+	post, _ := http.NewRequest(http.MethodPost, "https://esi.evetech.net/v2/ui/autopilot/waypoint/", nil)
+	if c.shouldHedge(post.Context(), post, true) {
+		t.Error("shouldHedge() must be false for non-idempotent methods")
+	}
+	if c.shouldHedge(req.Context(), req, false) {
+		t.Error("shouldHedge() must be false for non-interactive endpoints")
+	}
+}
+
+func TestShouldHedge_UnhealthyErrorBudget(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	// Warning zone: below ErrorThresholdHealthy
+	ctx := context.Background()
+	now := time.Now()
+	redisClient.Set(ctx, "esi:rate_limit:errors_remaining", 30, 0)
+	redisClient.Set(ctx, "esi:rate_limit:reset_timestamp", now.Add(60*time.Second).Unix(), 0)
+	lastUpdateJSON, _ := json.Marshal(now)
+	redisClient.Set(ctx, "esi:rate_limit:last_update", lastUpdateJSON, 0)
+
+	cfg := DefaultConfig(redisClient, "TestApp/1.0.0")
+	cfg.HedgeRequests = true
+	c, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "https://esi.evetech.net/v1/fleets/1/", nil)
+	if c.shouldHedge(ctx, req, true) {
+		t.Error("shouldHedge() must be false while the error budget is below healthy")
+	}
+
+	redisClient.Set(ctx, "esi:rate_limit:errors_remaining", 100, 0)
+	if !c.shouldHedge(ctx, req, true) {
+		t.Error("shouldHedge() should be true with a healthy error budget")
+	}
+}
+
+// hedgeTransport adapts a function to http.RoundTripper.
+type hedgeTransport func(*http.Request) (*http.Response, error)
+
+func (f hedgeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,271 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// ESIStatusURL is ESI's own route status feed, usable as
+// Config.IncidentStatusURL.
+const ESIStatusURL = "https://esi.evetech.net/status.json?version=latest"
+
+const (
+	// defaultIncidentPollInterval is how often the status feed is polled
+	// when Config.IncidentPollInterval is unset.
+	defaultIncidentPollInterval = time.Minute
+
+	// defaultIncidentConcurrency is the number of requests sent to ESI at
+	// once in incident mode when Config.IncidentMaxConcurrency is unset.
+	defaultIncidentConcurrency = 2
+
+	// incidentPollTimeout bounds one poll of the status feed.
+	incidentPollTimeout = 10 * time.Second
+
+	// incidentMaxFeedSize bounds the status feed read per poll.
+	incidentMaxFeedSize = 4 << 20
+
+	// incidentRedShare is the share of red routes in ESI's status feed from
+	// which an incident is declared; a few red routes are normal.
+	incidentRedShare = 0.25
+)
+
+// incidentIndicators are the Statuspage indicators that declare an incident.
+var incidentIndicators = map[string]bool{"major": true, "critical": true}
+
+var (
+	esiIncidentMode = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "esi_incident_mode",
+		Help: "Whether the client is in incident mode (1) because the status feed declares an incident",
+	})
+
+	esiIncidentPollsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "esi_incident_polls_total",
+		Help: "Total number of status feed polls by result (ok, incident, error)",
+	}, []string{"result"})
+)
+
+// incidentState tracks incident mode. While active, requests to ESI share
+// slots, are not retried and fall back to cached entries on errors.
+type incidentState struct {
+	enabled atomic.Bool
+	slots   chan struct{}
+
+	mu     sync.Mutex
+	detail string
+	since  time.Time
+}
+
+// active reports whether incident mode is on.
+func (s *incidentState) active() bool {
+	return s.enabled.Load()
+}
+
+// validateIncidentMode checks the incident mode settings.
+func validateIncidentMode(cfg Config) error {
+	if cfg.IncidentStatusURL == "" {
+		return nil
+	}
+	u, err := url.Parse(cfg.IncidentStatusURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("incident_status_url must be an http(s) URL (got %q)", cfg.IncidentStatusURL)
+	}
+	if cfg.IncidentPollInterval < 0 {
+		return fmt.Errorf("incident_poll_interval must not be negative (got %s)", cfg.IncidentPollInterval)
+	}
+	if cfg.IncidentMaxConcurrency < 0 {
+		return fmt.Errorf("incident_max_concurrency must not be negative (got %d)", cfg.IncidentMaxConcurrency)
+	}
+	return nil
+}
+
+// IncidentMode reports whether the client is in incident mode, with the
+// incident as described by the status feed and since when.
+func (c *Client) IncidentMode() (active bool, detail string, since time.Time) {
+	c.incident.mu.Lock()
+	defer c.incident.mu.Unlock()
+	return c.incident.active(), c.incident.detail, c.incident.since
+}
+
+// startIncidentPoller polls Config.IncidentStatusURL until Close, starting
+// immediately.
+func (c *Client) startIncidentPoller() {
+	slots := c.config.IncidentMaxConcurrency
+	if slots == 0 {
+		slots = defaultIncidentConcurrency
+	}
+	c.incident.slots = make(chan struct{}, slots)
+
+	interval := c.config.IncidentPollInterval
+	if interval == 0 {
+		interval = defaultIncidentPollInterval
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	c.onShutdown("incident poller", stopAndWait(cancel, done))
+
+	go func() {
+		defer close(done)
+		httpClient := &http.Client{Timeout: incidentPollTimeout}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			incident, detail, err := pollIncidentStatus(ctx, httpClient, c.config.IncidentStatusURL)
+			switch {
+			case err != nil && ctx.Err() == nil:
+				// Keep the current mode until the feed answers again
+				esiIncidentPollsTotal.WithLabelValues("error").Inc()
+				c.logger.Warn().Err(err).Msg("Incident status poll failed")
+			case err == nil && incident:
+				esiIncidentPollsTotal.WithLabelValues("incident").Inc()
+				c.setIncidentMode(true, detail)
+			case err == nil:
+				esiIncidentPollsTotal.WithLabelValues("ok").Inc()
+				c.setIncidentMode(false, detail)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// setIncidentMode enters or leaves incident mode, logging the change.
+func (c *Client) setIncidentMode(active bool, detail string) {
+	c.incident.mu.Lock()
+	defer c.incident.mu.Unlock()
+
+	if c.incident.enabled.Swap(active) == active {
+		if active {
+			c.incident.detail = detail
+		}
+		return
+	}
+	if active {
+		c.incident.detail = detail
+		c.incident.since = time.Now()
+		esiIncidentMode.Set(1)
+		c.logger.Warn().
+			Str("incident", detail).
+			Msg("ESI incident declared - entering incident mode (reduced concurrency, no retries, serving stale)")
+		return
+	}
+
+	c.logger.Info().
+		Dur("duration", time.Since(c.incident.since)).
+		Str("status", detail).
+		Msg("ESI incident resolved - leaving incident mode")
+	c.incident.detail = ""
+	c.incident.since = time.Time{}
+	esiIncidentMode.Set(0)
+}
+
+// acquireIncidentSlot waits for one of the incident mode request slots. It
+// returns immediately outside incident mode.
+func (c *Client) acquireIncidentSlot(ctx context.Context) (release func(), err error) {
+	if !c.incident.active() || c.incident.slots == nil {
+		return func() {}, nil
+	}
+	select {
+	case c.incident.slots <- struct{}{}:
+		return func() { <-c.incident.slots }, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("incident mode: %w", ctx.Err())
+	}
+}
+
+// pollIncidentStatus fetches a status feed and reports whether it declares
+// an incident. Both Statuspage status.json documents and ESI's route status
+// list (ESIStatusURL) are understood.
+func pollIncidentStatus(ctx context.Context, httpClient *http.Client, feedURL string) (incident bool, detail string, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feedURL, nil)
+	if err != nil {
+		return false, "", err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return false, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, "", fmt.Errorf("status feed answered %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, incidentMaxFeedSize))
+	if err != nil {
+		return false, "", fmt.Errorf("read status feed: %w", err)
+	}
+	return parseIncidentStatus(data)
+}
+
+// parseIncidentStatus interprets a status feed document.
+func parseIncidentStatus(data []byte) (incident bool, detail string, err error) {
+	// ESI route status: [{"route": "...", "status": "green|yellow|red"}, ...]
+	var routes []struct {
+		Route  string `json:"route"`
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal(data, &routes); err == nil {
+		if len(routes) == 0 {
+			return false, "", fmt.Errorf("status feed lists no routes")
+		}
+		red := 0
+		for _, route := range routes {
+			if route.Status == "red" {
+				red++
+			}
+		}
+		detail = fmt.Sprintf("%d of %d ESI routes red", red, len(routes))
+		return float64(red) >= incidentRedShare*float64(len(routes)), detail, nil
+	}
+
+	// Statuspage: {"status": {"indicator": "none|minor|major|critical", ...}}
+	var page struct {
+		Status struct {
+			Indicator   string `json:"indicator"`
+			Description string `json:"description"`
+		} `json:"status"`
+	}
+	if err := json.Unmarshal(data, &page); err != nil {
+		return false, "", fmt.Errorf("decode status feed: %w", err)
+	}
+	if page.Status.Indicator == "" {
+		return false, "", fmt.Errorf("status feed has no status indicator")
+	}
+	detail = page.Status.Description
+	if detail == "" {
+		detail = page.Status.Indicator
+	}
+	return incidentIndicators[page.Status.Indicator], detail, nil
+}
+
+// noRetriesKey is the context key of requests sent without retries.
+type noRetriesKey struct{}
+
+// withoutRetries returns a context whose requests retryWithBackoff sends
+// only once.
+func withoutRetries(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noRetriesKey{}, true)
+}
+
+// retriesSuppressed reports whether ctx was created by withoutRetries.
+func retriesSuppressed(ctx context.Context) bool {
+	v, _ := ctx.Value(noRetriesKey{}).(bool)
+	return v
+}
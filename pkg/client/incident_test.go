@@ -0,0 +1,153 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseIncidentStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		incident bool
+		detail   string
+		wantErr  bool
+	}{
+		{"statuspage operational", `{"status":{"indicator":"none","description":"All Systems Operational"}}`, false, "All Systems Operational", false},
+		{"statuspage minor", `{"status":{"indicator":"minor","description":"Minor Service Outage"}}`, false, "Minor Service Outage", false},
+		{"statuspage major", `{"status":{"indicator":"major","description":"Partial System Outage"}}`, true, "Partial System Outage", false},
+		{"statuspage critical without description", `{"status":{"indicator":"critical"}}`, true, "critical", false},
+		{"esi routes mostly green", `[{"route":"/status/","status":"green"},{"route":"/markets/prices/","status":"green"},{"route":"/markets/{region_id}/orders/","status":"yellow"},{"route":"/universe/types/{type_id}/","status":"red"},{"route":"/alliances/","status":"green"}]`, false, "1 of 5 ESI routes red", false},
+		{"esi routes red", `[{"route":"/status/","status":"red"},{"route":"/markets/prices/","status":"red"},{"route":"/alliances/","status":"green"}]`, true, "2 of 3 ESI routes red", false},
+		{"no routes", `[]`, false, "", true},
+		{"no indicator", `{"page":{}}`, false, "", true},
+		{"not json", `<html></html>`, false, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			incident, detail, err := parseIncidentStatus([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseIncidentStatus() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if incident != tt.incident || detail != tt.detail {
+				t.Errorf("parseIncidentStatus() = %v, %q, want %v, %q", incident, detail, tt.incident, tt.detail)
+			}
+		})
+	}
+}
+
+func TestValidateIncidentMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"off", Config{}, false},
+		{"esi status", Config{IncidentStatusURL: ESIStatusURL}, false},
+		{"relative url", Config{IncidentStatusURL: "/status.json"}, true},
+		{"negative interval", Config{IncidentStatusURL: ESIStatusURL, IncidentPollInterval: -time.Second}, true},
+		{"negative concurrency", Config{IncidentStatusURL: ESIStatusURL, IncidentMaxConcurrency: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateIncidentMode(tt.cfg); (err != nil) != tt.wantErr {
+				t.Errorf("validateIncidentMode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIncidentSlots(t *testing.T) {
+	c := &Client{}
+	c.incident.slots = make(chan struct{}, 1)
+
+	// Outside incident mode slots are not taken
+	for i := 0; i < 3; i++ {
+		if _, err := c.acquireIncidentSlot(context.Background()); err != nil {
+			t.Fatalf("acquireIncidentSlot() outside incident mode error = %v", err)
+		}
+	}
+
+	c.setIncidentMode(true, "Partial System Outage")
+	if active, detail, since := c.IncidentMode(); !active || detail != "Partial System Outage" || since.IsZero() {
+		t.Errorf("IncidentMode() = %v, %q, %v", active, detail, since)
+	}
+
+	release, err := c.acquireIncidentSlot(context.Background())
+	if err != nil {
+		t.Fatalf("acquireIncidentSlot() error = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.acquireIncidentSlot(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("second acquireIncidentSlot() error = %v, want deadline exceeded", err)
+	}
+	release()
+	if release, err := c.acquireIncidentSlot(context.Background()); err != nil {
+		t.Errorf("acquireIncidentSlot() after release error = %v", err)
+	} else {
+		release()
+	}
+
+	c.setIncidentMode(false, "All Systems Operational")
+	if active, detail, _ := c.IncidentMode(); active || detail != "" {
+		t.Errorf("IncidentMode() after resolution = %v, %q", active, detail)
+	}
+}
+
+func TestRetryWithBackoff_SuppressedRetries(t *testing.T) {
+	attempts := 0
+	fn := func() error {
+		attempts++
+		return errors.New("server error")
+	}
+
+	err := retryWithBackoff(withoutRetries(context.Background()), nil, fn, func(error) ErrorClass { return ErrorClassServer })
+	if err == nil || attempts != 1 {
+		t.Errorf("retryWithBackoff() = %v after %d attempts, want the error after 1", err, attempts)
+	}
+}
+
+func TestIncidentPoller(t *testing.T) {
+	var incident atomic.Bool
+	incident.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if incident.Load() {
+			_, _ = w.Write([]byte(`{"status":{"indicator":"major","description":"Partial System Outage"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":{"indicator":"none","description":"All Systems Operational"}}`))
+	}))
+	defer server.Close()
+
+	c := &Client{config: Config{IncidentStatusURL: server.URL, IncidentPollInterval: 10 * time.Millisecond}}
+	c.startIncidentPoller()
+	defer func() { _ = c.runShutdownHooks() }()
+
+	waitFor := func(want bool) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			if active, _, _ := c.IncidentMode(); active == want {
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		t.Fatalf("incident mode did not become %v", want)
+	}
+
+	waitFor(true)
+	if cap(c.incident.slots) != defaultIncidentConcurrency {
+		t.Errorf("incident slots = %d, want %d", cap(c.incident.slots), defaultIncidentConcurrency)
+	}
+	incident.Store(false)
+	waitFor(false)
+}
@@ -0,0 +1,147 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// inFlightUnknownSize is reserved for response bodies without
+// Content-Length (e.g. transparently decompressed gzip) until they are read.
+const inFlightUnknownSize = 256 << 10
+
+var (
+	esiInFlightBytes = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "esi_inflight_bytes",
+		Help: "Bytes of ESI response bodies currently buffered in memory (requires MaxInFlightBytes)",
+	})
+
+	esiInFlightWaitSeconds = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "esi_inflight_wait_seconds",
+		Help:    "Time responses waited for in-flight bytes below MaxInFlightBytes before being read",
+		Buckets: []float64{0.01, 0.05, 0.1, 0.5, 1, 2, 5, 10},
+	})
+)
+
+// byteBudget caps the bytes of response bodies buffered at once. Bodies wait
+// before they are read; a body larger than the limit is admitted alone.
+type byteBudget struct {
+	limit int64
+
+	mu       sync.Mutex
+	used     int64
+	released chan struct{} // Closed and replaced on every release
+}
+
+// newByteBudget returns a budget of limit bytes, nil if limit is 0.
+func newByteBudget(limit int64) *byteBudget {
+	if limit <= 0 {
+		return nil
+	}
+	return &byteBudget{limit: limit, released: make(chan struct{})}
+}
+
+// acquire waits until n more bytes fit the limit, or nothing else is
+// buffered, and reserves them.
+func (b *byteBudget) acquire(ctx context.Context, n int64) error {
+	for {
+		b.mu.Lock()
+		if b.used == 0 || b.used+n <= b.limit {
+			b.used += n
+			esiInFlightBytes.Set(float64(b.used))
+			b.mu.Unlock()
+			return nil
+		}
+		released := b.released
+		b.mu.Unlock()
+
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// adjust corrects a reservation by delta once the real size is known.
+// Growth never waits, the bytes are already in memory.
+func (b *byteBudget) adjust(delta int64) {
+	if delta == 0 {
+		return
+	}
+	b.mu.Lock()
+	b.used += delta
+	esiInFlightBytes.Set(float64(b.used))
+	if delta < 0 {
+		close(b.released)
+		b.released = make(chan struct{})
+	}
+	b.mu.Unlock()
+}
+
+// release returns n reserved bytes.
+func (b *byteBudget) release(n int64) {
+	b.adjust(-n)
+}
+
+// validateInFlightBytes checks Config.MaxInFlightBytes.
+func validateInFlightBytes(cfg Config) error {
+	if cfg.MaxInFlightBytes < 0 {
+		return fmt.Errorf("max_inflight_bytes must not be negative (got %d)", cfg.MaxInFlightBytes)
+	}
+	return nil
+}
+
+// reserveInFlight waits until the body of resp may be buffered under
+// Config.MaxInFlightBytes and returns the bytes reserved for it.
+func (c *Client) reserveInFlight(ctx context.Context, resp *http.Response) (int64, error) {
+	if c.inFlight == nil {
+		return 0, nil
+	}
+	n := resp.ContentLength
+	if n < 0 {
+		n = inFlightUnknownSize
+	}
+
+	start := time.Now()
+	err := c.inFlight.acquire(ctx, n)
+	observe(ctx, esiInFlightWaitSeconds, time.Since(start).Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("in-flight bytes: %w", err)
+	}
+	return n, nil
+}
+
+// holdInFlight corrects the reservation of resp to the size of its buffered
+// body and keeps it until the caller closes the body. Without a body the
+// reservation is returned right away.
+func (c *Client) holdInFlight(resp *http.Response, reserved int64, size int) {
+	if c.inFlight == nil {
+		return
+	}
+	if resp.Body == nil {
+		c.inFlight.release(reserved)
+		return
+	}
+	c.inFlight.adjust(int64(size) - reserved)
+	resp.Body = &inFlightBody{ReadCloser: resp.Body, release: func() { c.inFlight.release(int64(size)) }}
+}
+
+// inFlightBody returns its bytes to the budget on the first Close.
+type inFlightBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+// Close closes the body and releases its bytes.
+func (b *inFlightBody) Close() error {
+	b.once.Do(b.release)
+	return b.ReadCloser.Close()
+}
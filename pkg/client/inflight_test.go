@@ -0,0 +1,89 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestByteBudget(t *testing.T) {
+	b := newByteBudget(100)
+	ctx := context.Background()
+
+	if err := b.acquire(ctx, 60); err != nil {
+		t.Fatalf("acquire(60) error = %v", err)
+	}
+
+	// 60 + 50 exceed the limit: waits for a release
+	acquired := make(chan error, 1)
+	go func() { acquired <- b.acquire(ctx, 50) }()
+	select {
+	case err := <-acquired:
+		t.Fatalf("acquire(50) returned %v while over the limit", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	b.release(60)
+	select {
+	case err := <-acquired:
+		if err != nil {
+			t.Fatalf("acquire(50) error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("acquire(50) still waiting after release")
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := b.acquire(waitCtx, 60); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("acquire(60) over the limit error = %v, want deadline exceeded", err)
+	}
+
+	// Bodies larger than the limit are admitted alone
+	b.release(50)
+	if err := b.acquire(ctx, 500); err != nil {
+		t.Errorf("acquire(500) with nothing buffered error = %v", err)
+	}
+	if b.used != 500 {
+		t.Errorf("used = %d, want 500", b.used)
+	}
+
+	if newByteBudget(0) != nil {
+		t.Error("newByteBudget(0) != nil")
+	}
+}
+
+func TestHoldInFlight(t *testing.T) {
+	c := &Client{inFlight: newByteBudget(1 << 20)}
+	resp := &http.Response{ContentLength: -1, Body: io.NopCloser(strings.NewReader("{}"))}
+
+	reserved, err := c.reserveInFlight(context.Background(), resp)
+	if err != nil || reserved != inFlightUnknownSize {
+		t.Fatalf("reserveInFlight() = %d, %v, want %d", reserved, err, inFlightUnknownSize)
+	}
+
+	c.holdInFlight(resp, reserved, 2)
+	if c.inFlight.used != 2 {
+		t.Errorf("used after holdInFlight = %d, want the body size 2", c.inFlight.used)
+	}
+	if body, _ := io.ReadAll(resp.Body); string(body) != "{}" {
+		t.Errorf("body = %q", body)
+	}
+	resp.Body.Close()
+	resp.Body.Close()
+	if c.inFlight.used != 0 {
+		t.Errorf("used after Close = %d, want 0", c.inFlight.used)
+	}
+}
+
+func TestValidateInFlightBytes(t *testing.T) {
+	if err := validateInFlightBytes(Config{MaxInFlightBytes: 64 << 20}); err != nil {
+		t.Errorf("validateInFlightBytes() error = %v", err)
+	}
+	if err := validateInFlightBytes(Config{MaxInFlightBytes: -1}); err == nil {
+		t.Error("negative MaxInFlightBytes accepted")
+	}
+}
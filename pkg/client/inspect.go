@@ -0,0 +1,129 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+)
+
+// forceRefreshKey is the context key of WithForceRefresh.
+type forceRefreshKey struct{}
+
+// WithForceRefresh returns a context whose cacheable requests download the
+// body from ESI even if a cached entry exists: no conditional request, no
+// minimum refresh interval, no coalescing with requests in flight. The
+// response replaces the cached entry. Meant for debugging stale data; every
+// forced request costs a full download.
+func WithForceRefresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forceRefreshKey{}, true)
+}
+
+// forceRefresh reports whether ctx was created by WithForceRefresh.
+func forceRefresh(ctx context.Context) bool {
+	v, _ := ctx.Value(forceRefreshKey{}).(bool)
+	return v
+}
+
+// InspectCache returns the cache key and the stored entry of a GET to an
+// ESI endpoint without contacting ESI, including expired entries kept for
+// MaxStale and preloaded ones. The key is scoped like GetCached's. Without
+// an entry it returns the key and ErrCacheMiss.
+func (c *Client) InspectCache(ctx context.Context, endpoint string) (string, *cache.CacheEntry, error) {
+	ctx, cancel := c.withDefaultDeadline(ctx, operationAdmin)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, esiBaseURL+endpoint, nil)
+	if err != nil {
+		return "", nil, fmt.Errorf("create request: %w", err)
+	}
+	if token := AccessTokenFromContext(ctx); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	key, cacheable, err := c.requestCacheKey(req, false)
+	if err != nil {
+		return "", nil, err
+	}
+	if !cacheable {
+		return "", nil, ErrCacheMiss
+	}
+
+	entry, err := c.cache.GetStale(ctx, key)
+	if errors.Is(err, cache.ErrCacheMiss) || errors.Is(err, cache.ErrInvalidEntry) {
+		return key.String(), nil, ErrCacheMiss
+	}
+	if err != nil {
+		return key.String(), nil, fmt.Errorf("cache lookup: %w", err)
+	}
+	return key.String(), entry, nil
+}
+
+// CacheDiff compares the cached entry of an endpoint with the live ESI
+// response.
+type CacheDiff struct {
+	Key    string
+	Cached *cache.CacheEntry // nil if nothing was cached
+
+	LiveStatus int
+	LiveHeader http.Header
+	LiveBody   []byte
+
+	// Equal reports whether the cached and the live body carry the same
+	// data (JSON bodies are compared without insignificant whitespace).
+	Equal bool
+}
+
+// DiffCache fetches endpoint from ESI with WithForceRefresh and compares the
+// response with the entry cached before, e.g. to debug stale-data
+// complaints. The live response replaces the cached entry.
+func (c *Client) DiffCache(ctx context.Context, endpoint string) (*CacheDiff, error) {
+	ctx, cancel := c.withDefaultDeadline(ctx, operationAdmin)
+	defer cancel()
+
+	key, cached, err := c.InspectCache(ctx, endpoint)
+	if err != nil && !errors.Is(err, ErrCacheMiss) {
+		return nil, err
+	}
+
+	resp, err := c.Get(WithForceRefresh(ctx), endpoint)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response body: %w", err)
+	}
+
+	diff := &CacheDiff{
+		Key:        key,
+		Cached:     cached,
+		LiveStatus: resp.StatusCode,
+		LiveHeader: resp.Header,
+		LiveBody:   body,
+	}
+	if cached != nil {
+		diff.Equal = sameBody(cached.Data, body)
+	}
+	return diff, nil
+}
+
+// sameBody reports whether two response bodies are equal, ignoring
+// insignificant whitespace in JSON.
+func sameBody(a, b []byte) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	var compactA, compactB bytes.Buffer
+	if json.Compact(&compactA, a) != nil || json.Compact(&compactB, b) != nil {
+		return false
+	}
+	return bytes.Equal(compactA.Bytes(), compactB.Bytes())
+}
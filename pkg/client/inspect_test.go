@@ -0,0 +1,88 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSameBody(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{`{"a":1}`, `{"a":1}`, true},
+		{`{"a":1}`, "{\n  \"a\": 1\n}", true},
+		{`{"a":1}`, `{"a":2}`, false},
+		{"plain", "plain", true},
+		{"plain", "plain ", false},
+	}
+	for _, tt := range tests {
+		if got := sameBody([]byte(tt.a), []byte(tt.b)); got != tt.want {
+			t.Errorf("sameBody(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestDiffCache(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	var players atomic.Int64
+	var conditional atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != "" {
+			conditional.Add(1)
+		}
+		w.Header().Set("ETag", `"v1"`) // ESI keeps the ETag although the data changed
+		w.Header().Set("Expires", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+		fmt.Fprintf(w, `{"players":%d}`, players.Load())
+	}))
+	defer server.Close()
+
+	client, err := New(DefaultConfig(redisClient, "TestApp/1.0.0"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+	ctx := context.Background()
+
+	if _, _, err := client.InspectCache(ctx, "/v1/status/"); !errors.Is(err, ErrCacheMiss) {
+		t.Fatalf("InspectCache() before fetch error = %v, want ErrCacheMiss", err)
+	}
+
+	resp, err := client.Get(ctx, "/v1/status/")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+
+	key, entry, err := client.InspectCache(ctx, "/v1/status/")
+	if err != nil {
+		t.Fatalf("InspectCache() error = %v", err)
+	}
+	if key != "esi:v1/status" || entry.ETag != `"v1"` || string(entry.Data) != `{"players":0}` {
+		t.Errorf("InspectCache() = %q, %+v", key, entry)
+	}
+
+	players.Store(1)
+	diff, err := client.DiffCache(ctx, "/v1/status/")
+	if err != nil {
+		t.Fatalf("DiffCache() error = %v", err)
+	}
+	if diff.Equal || string(diff.Cached.Data) != `{"players":0}` || string(diff.LiveBody) != `{"players":1}` {
+		t.Errorf("DiffCache() = equal %v, cached %s, live %s", diff.Equal, diff.Cached.Data, diff.LiveBody)
+	}
+	if conditional.Load() != 0 {
+		t.Error("forced refresh sent a conditional request")
+	}
+
+	// The live response replaced the entry
+	if _, entry, _ := client.InspectCache(ctx, "/v1/status/"); entry == nil || string(entry.Data) != `{"players":1}` {
+		t.Errorf("entry after DiffCache = %+v, want live body", entry)
+	}
+}
@@ -0,0 +1,145 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Interactive endpoint groups. Responses from these endpoints reflect live
+// in-game state (fleet composition, client windows) and MUST NOT be cached.
+const (
+	// InteractiveGroupFleets covers /fleets/ endpoints.
+	InteractiveGroupFleets = "fleets"
+
+	// InteractiveGroupUI covers /ui/ endpoints.
+	InteractiveGroupUI = "ui"
+)
+
+// defaultInteractiveTimeout is used when Config.InteractiveTimeout is unset.
+const defaultInteractiveTimeout = 5 * time.Second
+
+// interactiveGroup reports whether an endpoint path belongs to an interactive
+// endpoint group and returns the group name. The version segment
+// (/v1/, /latest/, ...) is ignored.
+func interactiveGroup(endpoint string) (string, bool) {
+	segments := strings.Split(strings.Trim(endpoint, "/"), "/")
+	if len(segments) == 0 {
+		return "", false
+	}
+
+	// Skip the version prefix if present
+	first := segments[0]
+	if len(segments) > 1 && isVersionSegment(first) {
+		first = segments[1]
+	}
+
+	switch first {
+	case InteractiveGroupFleets, InteractiveGroupUI:
+		return first, true
+	default:
+		return "", false
+	}
+}
+
+// isVersionSegment returns true for ESI route version segments
+// like "v1", "v5", "latest", "dev" or "legacy".
+func isVersionSegment(segment string) bool {
+	switch segment {
+	case "latest", "dev", "legacy":
+		return true
+	}
+	if len(segment) < 2 || segment[0] != 'v' {
+		return false
+	}
+	_, err := strconv.Atoi(segment[1:])
+	return err == nil
+}
+
+// Interactive performs an uncached request against a /fleets/ or /ui/ endpoint
+// with the configured InteractiveTimeout. The response body is read completely
+// before the deadline is released, so callers can consume it without racing
+// the timeout.
+//
+// accessToken is an ESI SSO access token sent as Bearer authorization.
+// It is never logged or cached.
+func (c *Client) Interactive(ctx context.Context, method, endpoint, accessToken string) (*http.Response, error) {
+	if _, ok := interactiveGroup(endpoint); !ok {
+		return nil, fmt.Errorf("endpoint %q is not an interactive endpoint", endpoint)
+	}
+
+	timeout := c.config.InteractiveTimeout
+	if timeout <= 0 {
+		timeout = defaultInteractiveTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, esiBaseURL+endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	return resp, nil
+}
+
+// GetFleet fetches fleet information (GET /v1/fleets/{fleet_id}/).
+func (c *Client) GetFleet(ctx context.Context, fleetID int64, accessToken string) (*http.Response, error) {
+	return c.Interactive(ctx, http.MethodGet, fmt.Sprintf("/v1/fleets/%d/", fleetID), accessToken)
+}
+
+// GetFleetMembers fetches the member list of a fleet (GET /v1/fleets/{fleet_id}/members/).
+func (c *Client) GetFleetMembers(ctx context.Context, fleetID int64, accessToken string) (*http.Response, error) {
+	return c.Interactive(ctx, http.MethodGet, fmt.Sprintf("/v1/fleets/%d/members/", fleetID), accessToken)
+}
+
+// GetFleetWings fetches the wings and squads of a fleet (GET /v1/fleets/{fleet_id}/wings/).
+func (c *Client) GetFleetWings(ctx context.Context, fleetID int64, accessToken string) (*http.Response, error) {
+	return c.Interactive(ctx, http.MethodGet, fmt.Sprintf("/v1/fleets/%d/wings/", fleetID), accessToken)
+}
+
+// SetAutopilotWaypoint sets an autopilot waypoint in the character's client
+// (POST /v2/ui/autopilot/waypoint/).
+func (c *Client) SetAutopilotWaypoint(ctx context.Context, destinationID int64, addToBeginning, clearOther bool, accessToken string) (*http.Response, error) {
+	query := url.Values{}
+	query.Set("destination_id", strconv.FormatInt(destinationID, 10))
+	query.Set("add_to_beginning", strconv.FormatBool(addToBeginning))
+	query.Set("clear_other_waypoints", strconv.FormatBool(clearOther))
+	return c.Interactive(ctx, http.MethodPost, "/v2/ui/autopilot/waypoint/?"+query.Encode(), accessToken)
+}
+
+// OpenMarketDetails opens the market details window for a type
+// (POST /v1/ui/openwindow/marketdetails/).
+func (c *Client) OpenMarketDetails(ctx context.Context, typeID int64, accessToken string) (*http.Response, error) {
+	endpoint := fmt.Sprintf("/v1/ui/openwindow/marketdetails/?type_id=%d", typeID)
+	return c.Interactive(ctx, http.MethodPost, endpoint, accessToken)
+}
+
+// OpenInformation opens the information window for a character, corporation,
+// alliance, solar system or type (POST /v1/ui/openwindow/information/).
+func (c *Client) OpenInformation(ctx context.Context, targetID int64, accessToken string) (*http.Response, error) {
+	endpoint := fmt.Sprintf("/v1/ui/openwindow/information/?target_id=%d", targetID)
+	return c.Interactive(ctx, http.MethodPost, endpoint, accessToken)
+}
@@ -0,0 +1,89 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+)
+
+func TestInteractiveGroup(t *testing.T) {
+	tests := []struct {
+		name      string
+		endpoint  string
+		wantGroup string
+		wantOK    bool
+	}{
+		{"fleet info", "/v1/fleets/123/", InteractiveGroupFleets, true},
+		{"fleet members latest", "/latest/fleets/123/members/", InteractiveGroupFleets, true},
+		{"ui waypoint", "/v2/ui/autopilot/waypoint/", InteractiveGroupUI, true},
+		{"unversioned ui", "/ui/openwindow/information/", InteractiveGroupUI, true},
+		{"market orders", "/v1/markets/10000002/orders/", "", false},
+		{"character fleet is not a fleets route", "/v2/characters/1/fleet/", "", false},
+		{"empty", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			group, ok := interactiveGroup(tt.endpoint)
+			if group != tt.wantGroup || ok != tt.wantOK {
+				t.Errorf("interactiveGroup(%q) = (%q, %v), want (%q, %v)",
+					tt.endpoint, group, ok, tt.wantGroup, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestInteractive_RejectsNonInteractiveEndpoint(t *testing.T) {
+	c := &Client{config: Config{}}
+
+	_, err := c.Interactive(context.Background(), http.MethodGet, "/v1/markets/10000002/orders/", "")
+	if err == nil {
+		t.Error("Expected error for non-interactive endpoint")
+	}
+}
+
+func TestDo_InteractiveBypassesCache(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	requestCount := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		if r.Header.Get("If-None-Match") != "" {
+			t.Error("Interactive request must not be conditional")
+		}
+		w.Header().Set("X-ESI-Error-Limit-Remain", "100")
+		w.Header().Set("X-ESI-Error-Limit-Reset", "60")
+		w.Header().Set("Expires", time.Now().Add(5*time.Minute).Format(http.TimeFormat))
+		w.Header().Set("ETag", `"fleet-etag"`)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"fleet_id": 123}`))
+	}))
+	defer server.Close()
+
+	client, err := New(DefaultConfig(redisClient, "TestApp/1.0.0"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest("GET", server.URL+"/v1/fleets/123/", nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Request %d failed: %v", i+1, err)
+		}
+		resp.Body.Close()
+	}
+
+	if requestCount != 2 {
+		t.Errorf("Request count = %d, want 2 (no cache)", requestCount)
+	}
+
+	_, err = client.GetCache().Get(context.Background(), cache.CacheKey{Endpoint: "/v1/fleets/123/"})
+	if err != cache.ErrCacheMiss {
+		t.Errorf("Expected ErrCacheMiss for interactive endpoint, got %v", err)
+	}
+}
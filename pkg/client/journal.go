@@ -0,0 +1,276 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// journalKey is the Redis hash holding journaled write requests by dedup key.
+// It lives on Config.Redis (with the rate limit state), not the cache Redis,
+// so it survives cache evictions.
+const journalKey = "esi:journal"
+
+// esiJournalRequestsTotal counts journal operations by result.
+var esiJournalRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "esi_journal_requests_total",
+	Help: "Total number of journaled write requests by result (journaled, replayed, rejected, expired)",
+}, []string{"result"})
+
+// Results of journal operations (esi_journal_requests_total label).
+const (
+	journalResultJournaled = "journaled" // Failed request stored for replay
+	journalResultReplayed  = "replayed"  // Delivered on replay
+	journalResultRejected  = "rejected"  // ESI answered the replay with 4xx, dropped
+	journalResultExpired   = "expired"   // Older than JournalMaxAge, dropped
+)
+
+// JournalEntry is a write request stored for replay.
+type JournalEntry struct {
+	Key       string      `json:"key"`
+	Method    string      `json:"method"`
+	Endpoint  string      `json:"endpoint"` // Path and query
+	Header    http.Header `json:"header,omitempty"`
+	Body      []byte      `json:"body,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// ReplayResult summarizes one ReplayJournal run.
+type ReplayResult struct {
+	Replayed int // Delivered to ESI
+	Rejected int // Answered with 4xx (e.g. expired access token) and dropped
+	Expired  int // Older than JournalMaxAge and dropped
+	Pending  int // Still journaled because ESI is unavailable
+}
+
+// DoJournaled performs a write request (POST, PUT, DELETE) like Do. If it
+// fails because ESI is unavailable (network errors, 5xx after retries,
+// downtime, rate limit blocks), the request is journaled in Redis and the
+// returned error wraps both ErrJournaled and the original error. Journaled
+// requests are sent again by ReplayJournal, in the order they were recorded.
+//
+// dedupKey identifies the user action: journaling the same key again
+// replaces the pending entry instead of adding a second one, so a bot that
+// retries a command does not replay it twice. An empty key is derived from
+// method, endpoint and body.
+//
+// The journal stores request headers including Authorization. ESI access
+// tokens are short-lived, so replays after long outages are rejected (401)
+// and dropped; JournalMaxAge should stay below the token lifetime.
+func (c *Client) DoJournaled(req *http.Request, dedupKey string) (*http.Response, error) {
+	if c.config.JournalMaxAge <= 0 {
+		return nil, fmt.Errorf("request journal is disabled (journal_max_age is 0)")
+	}
+
+	body, err := requestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	entry := JournalEntry{
+		Key:       dedupKey,
+		Method:    req.Method,
+		Endpoint:  req.URL.RequestURI(),
+		Header:    req.Header.Clone(),
+		Body:      body,
+		CreatedAt: time.Now(),
+	}
+	if entry.Key == "" {
+		entry.Key = entry.Method + " " + entry.Endpoint + " " + cache.HashBody(body)
+	}
+
+	resp, err := c.Do(req)
+	if err == nil || !journalable(err) {
+		return resp, err
+	}
+
+	// The caller's context may be what failed; the action still needs saving
+	ctx := context.WithoutCancel(req.Context())
+	if jErr := c.journal(ctx, entry); jErr != nil {
+		c.logger.Error().Err(jErr).Str("endpoint", c.reportedEndpoint(ctx, req.URL.Path)).Msg("Failed to journal write request")
+		return nil, err
+	}
+	return nil, fmt.Errorf("%w as %q: %w", ErrJournaled, entry.Key, err)
+}
+
+// journalable reports whether a failed write request is worth replaying.
+// Client errors (4xx) are final and not journaled.
+func journalable(err error) bool {
+	var esiErr *ESIError
+	if errors.As(err, &esiErr) && esiErr.ErrorClass == ErrorClassClient {
+		return false
+	}
+	return true
+}
+
+// journal stores entry, replacing a pending entry with the same key.
+func (c *Client) journal(ctx context.Context, entry JournalEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("marshal journal entry: %w", err)
+	}
+	if err := c.redis.HSet(ctx, journalKey, entry.Key, data).Err(); err != nil {
+		return fmt.Errorf("write journal entry: %w", err)
+	}
+
+	esiJournalRequestsTotal.WithLabelValues(journalResultJournaled).Inc()
+	c.logger.Warn().
+		Str("endpoint", c.reportedEndpoint(ctx, entry.Endpoint)).
+		Str("key", entry.Key).
+		Msg("Write request journaled for replay")
+	return nil
+}
+
+// JournalEntries returns the pending journal entries, oldest first.
+func (c *Client) JournalEntries(ctx context.Context) ([]JournalEntry, error) {
+	values, err := c.redis.HGetAll(ctx, journalKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("read journal: %w", err)
+	}
+
+	entries := make([]JournalEntry, 0, len(values))
+	for key, value := range values {
+		var entry JournalEntry
+		if err := json.Unmarshal([]byte(value), &entry); err != nil {
+			c.logger.Warn().Err(err).Str("key", key).Msg("Dropping corrupted journal entry")
+			c.redis.HDel(ctx, journalKey, key)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+	})
+	return entries, nil
+}
+
+// ReplayJournal sends the journaled requests to ESI, oldest first. Delivered
+// and rejected (4xx) requests are removed; the run stops at the first
+// request that fails again, keeping it and all newer ones for the next run
+// so their order is preserved. Nothing is sent during an announced downtime.
+//
+// Each entry is claimed by removing it from Redis before it is sent, so
+// several instances sharing the journal never replay the same request.
+func (c *Client) ReplayJournal(ctx context.Context) (ReplayResult, error) {
+	var result ReplayResult
+	ctx, cancel := c.withDefaultDeadline(ctx, operationAdmin)
+	defer cancel()
+
+	entries, err := c.JournalEntries(ctx)
+	if err != nil {
+		return result, err
+	}
+	if _, down := c.downtime.active(); down {
+		result.Pending = len(entries)
+		return result, nil
+	}
+
+	for i, entry := range entries {
+		claimed, err := c.redis.HDel(ctx, journalKey, entry.Key).Result()
+		if err != nil {
+			result.Pending = len(entries) - i
+			return result, fmt.Errorf("claim journal entry: %w", err)
+		}
+		if claimed == 0 {
+			continue // Replayed by another instance
+		}
+
+		if time.Since(entry.CreatedAt) > c.config.JournalMaxAge {
+			result.Expired++
+			esiJournalRequestsTotal.WithLabelValues(journalResultExpired).Inc()
+			c.logger.Warn().
+				Str("endpoint", c.reportedEndpoint(ctx, entry.Endpoint)).
+				Str("key", entry.Key).
+				Time("created_at", entry.CreatedAt).
+				Msg("Dropping expired journal entry")
+			continue
+		}
+
+		status, err := c.replay(ctx, entry)
+		if err != nil {
+			// Put it back unless a newer request with the same key arrived
+			data, _ := json.Marshal(entry)
+			c.redis.HSetNX(context.WithoutCancel(ctx), journalKey, entry.Key, data)
+			result.Pending = len(entries) - i
+			return result, nil
+		}
+
+		if status >= 400 {
+			result.Rejected++
+			esiJournalRequestsTotal.WithLabelValues(journalResultRejected).Inc()
+			c.logger.Warn().
+				Str("endpoint", c.reportedEndpoint(ctx, entry.Endpoint)).
+				Str("key", entry.Key).
+				Int("status", status).
+				Msg("Journaled request rejected by ESI")
+			continue
+		}
+
+		result.Replayed++
+		esiJournalRequestsTotal.WithLabelValues(journalResultReplayed).Inc()
+	}
+	return result, nil
+}
+
+// replay sends a journaled request and returns the response status.
+func (c *Client) replay(ctx context.Context, entry JournalEntry) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, entry.Method, esiBaseURL+entry.Endpoint, bytes.NewReader(entry.Body))
+	if err != nil {
+		return 0, fmt.Errorf("create request: %w", err)
+	}
+	for name, values := range entry.Header {
+		req.Header[name] = values
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// startJournalReplay replays the journal every JournalReplayInterval until
+// Close, so journaled requests are delivered once ESI recovers.
+func (c *Client) startJournalReplay() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	c.onShutdown("journal replay", stopAndWait(cancel, done))
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(c.config.JournalReplayInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			result, err := c.ReplayJournal(ctx)
+			if err != nil && ctx.Err() == nil {
+				c.logger.Warn().Err(err).Msg("Journal replay failed")
+				continue
+			}
+			if result.Replayed > 0 || result.Rejected > 0 || result.Expired > 0 {
+				c.logger.Info().
+					Int("replayed", result.Replayed).
+					Int("rejected", result.Rejected).
+					Int("expired", result.Expired).
+					Int("pending", result.Pending).
+					Msg("Journal replayed")
+			}
+		}
+	}()
+}
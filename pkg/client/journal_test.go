@@ -0,0 +1,185 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newJournalTestClient returns a client with the journal enabled that sends
+// requests to server.
+func newJournalTestClient(t *testing.T, server *httptest.Server) *Client {
+	t.Helper()
+
+	cfg := DefaultConfig(setupTestRedis(t), "TestApp/1.0.0")
+	cfg.JournalMaxAge = 10 * time.Minute
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+	return client
+}
+
+func newWaypointRequest(t *testing.T, ctx context.Context, body string) *http.Request {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, esiBaseURL+"/v2/ui/autopilot/waypoint/?destination_id=30000142", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("NewRequest() error = %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer token")
+	return req
+}
+
+func TestDoJournaled_JournalsAndReplays(t *testing.T) {
+	var down atomic.Bool
+	down.Store(true)
+
+	var mu sync.Mutex
+	var delivered []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-ESI-Error-Limit-Remain", "100")
+		w.Header().Set("X-ESI-Error-Limit-Reset", "60")
+		if down.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		delivered = append(delivered, r.Header.Get("Authorization")+" "+string(body))
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := newJournalTestClient(t, server)
+
+	// The same action twice (bot retrying a command) is journaled once
+	for i := 0; i < 2; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		_, err := client.DoJournaled(newWaypointRequest(t, ctx, "a"), "waypoint-1")
+		cancel()
+		if !errors.Is(err, ErrJournaled) {
+			t.Fatalf("DoJournaled() error = %v, want ErrJournaled", err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	_, err := client.DoJournaled(newWaypointRequest(t, ctx, "b"), "")
+	cancel()
+	if !errors.Is(err, ErrJournaled) {
+		t.Fatalf("DoJournaled() error = %v, want ErrJournaled", err)
+	}
+
+	entries, err := client.JournalEntries(context.Background())
+	if err != nil {
+		t.Fatalf("JournalEntries() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("JournalEntries() = %d entries, want 2", len(entries))
+	}
+
+	down.Store(false)
+	result, err := client.ReplayJournal(context.Background())
+	if err != nil {
+		t.Fatalf("ReplayJournal() error = %v", err)
+	}
+	if result != (ReplayResult{Replayed: 2}) {
+		t.Errorf("ReplayJournal() = %+v, want 2 replayed", result)
+	}
+
+	want := []string{"Bearer token a", "Bearer token b"}
+	if strings.Join(delivered, ",") != strings.Join(want, ",") {
+		t.Errorf("delivered = %q, want %q (in journal order)", delivered, want)
+	}
+
+	entries, err = client.JournalEntries(context.Background())
+	if err != nil {
+		t.Fatalf("JournalEntries() error = %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("JournalEntries() after replay = %d entries, want 0", len(entries))
+	}
+}
+
+func TestReplayJournal_Outcomes(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		age     time.Duration
+		want    ReplayResult
+		pending int
+	}{
+		{"delivered", http.StatusNoContent, 0, ReplayResult{Replayed: 1}, 0},
+		{"rejected", http.StatusUnauthorized, 0, ReplayResult{Rejected: 1}, 0},
+		{"still down", http.StatusBadGateway, 0, ReplayResult{Pending: 1}, 1},
+		{"expired", http.StatusNoContent, time.Hour, ReplayResult{Expired: 1}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-ESI-Error-Limit-Remain", "100")
+				w.Header().Set("X-ESI-Error-Limit-Reset", "60")
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			client := newJournalTestClient(t, server)
+			entry := JournalEntry{
+				Key:       "contacts-1",
+				Method:    http.MethodPost,
+				Endpoint:  "/v2/characters/1/contacts/?standing=10",
+				Body:      []byte("[2]"),
+				CreatedAt: time.Now().Add(-tt.age),
+			}
+			if err := client.journal(context.Background(), entry); err != nil {
+				t.Fatalf("journal() error = %v", err)
+			}
+
+			// The deadline skips the retry backoff
+			ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+			defer cancel()
+			result, err := client.ReplayJournal(ctx)
+			if err != nil {
+				t.Fatalf("ReplayJournal() error = %v", err)
+			}
+			if result != tt.want {
+				t.Errorf("ReplayJournal() = %+v, want %+v", result, tt.want)
+			}
+
+			entries, err := client.JournalEntries(context.Background())
+			if err != nil {
+				t.Fatalf("JournalEntries() error = %v", err)
+			}
+			if len(entries) != tt.pending {
+				t.Errorf("JournalEntries() = %d entries, want %d", len(entries), tt.pending)
+			}
+		})
+	}
+}
+
+func TestDoJournaled_Disabled(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	client, err := New(DefaultConfig(redisClient, "TestApp/1.0.0"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if _, err := client.DoJournaled(newWaypointRequest(t, context.Background(), ""), "x"); err == nil {
+		t.Error("DoJournaled() succeeded with the journal disabled, want error")
+	}
+
+	cfg := DefaultConfig(redisClient, "TestApp/1.0.0")
+	cfg.JournalReplayInterval = time.Minute
+	if _, err := New(cfg); err == nil {
+		t.Error("New() accepted JournalReplayInterval without JournalMaxAge")
+	}
+}
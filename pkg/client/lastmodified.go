@@ -0,0 +1,48 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+)
+
+// validateLastModifiedRoutes checks Config.LastModifiedRoutes.
+func validateLastModifiedRoutes(cfg Config) error {
+	for _, route := range cfg.LastModifiedRoutes {
+		if strings.Trim(route, "/") == "" {
+			return fmt.Errorf("last_modified_routes: empty route")
+		}
+	}
+	return nil
+}
+
+// prefersLastModified reports whether endpoint belongs to one of the
+// LastModifiedRoutes, matched without version like "markets/".
+func (c *Client) prefersLastModified(endpoint string) bool {
+	if len(c.config.LastModifiedRoutes) == 0 {
+		return false
+	}
+	segments := strings.Split(strings.Trim(endpoint, "/"), "/")
+	if len(segments) > 1 && isVersionSegment(segments[0]) {
+		segments = segments[1:]
+	}
+	route := strings.Join(segments, "/") + "/"
+
+	for _, prefix := range c.config.LastModifiedRoutes {
+		if strings.HasPrefix(route, strings.TrimLeft(prefix, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// conditionalValidators returns the validators to send for a cached entry
+// of endpoint: on LastModifiedRoutes the ETag is dropped if Last-Modified is
+// known, so the request carries If-Modified-Since instead of If-None-Match.
+func (c *Client) conditionalValidators(endpoint string, v cache.Validators) cache.Validators {
+	if !v.LastModified.IsZero() && c.prefersLastModified(endpoint) {
+		v.ETag = ""
+	}
+	return v
+}
@@ -0,0 +1,81 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+)
+
+func TestValidateLastModifiedRoutes(t *testing.T) {
+	if err := validateLastModifiedRoutes(Config{LastModifiedRoutes: []string{"markets/"}}); err != nil {
+		t.Errorf("validateLastModifiedRoutes() error = %v", err)
+	}
+	if err := validateLastModifiedRoutes(Config{LastModifiedRoutes: []string{"/"}}); err == nil {
+		t.Error("validateLastModifiedRoutes() accepted an empty route")
+	}
+}
+
+func TestConditionalValidators(t *testing.T) {
+	c := &Client{config: Config{LastModifiedRoutes: []string{"markets/"}}}
+	lastModified := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	both := cache.Validators{ETag: `"page7"`, LastModified: lastModified}
+
+	tests := []struct {
+		name     string
+		endpoint string
+		v        cache.Validators
+		wantETag string
+	}{
+		{"listed route", "/v1/markets/10000002/orders/", both, ""},
+		{"other route", "/v1/universe/types/", both, `"page7"`},
+		{"no last modified", "/v1/markets/10000002/orders/", cache.Validators{ETag: `"page7"`}, `"page7"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := c.conditionalValidators(tt.endpoint, tt.v)
+			if got.ETag != tt.wantETag || !got.LastModified.Equal(tt.v.LastModified) {
+				t.Errorf("conditionalValidators() = %+v, want ETag %q", got, tt.wantETag)
+			}
+		})
+	}
+}
+
+func TestSeedConditional_PreferLastModified(t *testing.T) {
+	pageOne := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	ctx := withPageSeeds(context.Background())
+	collection := cache.CacheKey{Endpoint: "/v1/markets/10000002/orders/"}
+	seeds := pageSeedsFromContext(ctx)
+	seeds.lastModified[collection.String()] = pageOne
+
+	key := collection
+	key.QueryParams = url.Values{"page": {"2"}}
+	withETag := &cache.CacheEntry{ETag: `"page2"`, CachedAt: time.Now()}
+	withLastModified := &cache.CacheEntry{ETag: `"page2"`, LastModified: pageOne, CachedAt: time.Now()}
+
+	tests := []struct {
+		name   string
+		entry  *cache.CacheEntry
+		prefer bool
+		want   bool
+	}{
+		{"own ETag", withETag, false, false},
+		{"own ETag preferring Last-Modified", withETag, true, true},
+		{"own Last-Modified preferring it", withLastModified, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/markets/10000002/orders/?page=2", nil)
+			if got := seedConditional(ctx, req, key, tt.entry, tt.prefer); got != tt.want {
+				t.Errorf("seedConditional() = %v, want %v", got, tt.want)
+			}
+			if seeded := req.Header.Get("If-Modified-Since") != ""; seeded != tt.want {
+				t.Errorf("If-Modified-Since set = %v, want %v", seeded, tt.want)
+			}
+		})
+	}
+}
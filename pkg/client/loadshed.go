@@ -0,0 +1,175 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/ratelimit"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// esiShedRequestsTotal counts requests rejected by the load shedder.
+var esiShedRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "esi_shed_requests_total",
+	Help: "Total requests rejected by the load shedder by priority (low, normal, high)",
+}, []string{"priority"})
+
+// Priority ranks requests for load shedding. Requests without WithPriority
+// have PriorityNormal.
+type Priority int
+
+// Request priorities.
+const (
+	PriorityLow    Priority = -1 // Background work shed first, e.g. crawls
+	PriorityNormal Priority = 0  // Default
+	PriorityHigh   Priority = 1  // User-facing requests that should be shed last
+)
+
+// String returns the priority name used in metrics and logs.
+func (p Priority) String() string {
+	switch {
+	case p < PriorityNormal:
+		return "low"
+	case p > PriorityNormal:
+		return "high"
+	default:
+		return "normal"
+	}
+}
+
+// priorityKey is the context key of WithPriority.
+type priorityKey struct{}
+
+// WithPriority returns a context whose requests have priority p for the
+// configured LoadShedder.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityFromContext returns the priority set by WithPriority,
+// PriorityNormal if none.
+func PriorityFromContext(ctx context.Context) Priority {
+	p, _ := ctx.Value(priorityKey{}).(Priority)
+	return p
+}
+
+// Load describes a request and the client's load when Do decides whether to
+// shed it.
+type Load struct {
+	Endpoint string
+	Priority Priority
+
+	// RateLimit is the shared error limit state, nil if it could not be read.
+	RateLimit *ratelimit.RateLimitState
+
+	// InFlight is the number of admitted requests of this client that have
+	// not returned yet, including queued ones.
+	InFlight int
+
+	// Queued is the number of admitted requests waiting for the rate limiter
+	// or the smoothing limiter.
+	Queued int
+}
+
+// LoadShedder decides which requests Do rejects before they consume rate
+// limit, smoothing or network capacity. Shed is called for every request
+// that cannot be answered from the cache without contacting ESI, and must be
+// safe for concurrent use.
+type LoadShedder interface {
+	// Shed reports whether the request described by load is rejected.
+	Shed(load Load) bool
+}
+
+// LoadShedderFunc adapts a function to the LoadShedder interface.
+type LoadShedderFunc func(load Load) bool
+
+// Shed implements LoadShedder.
+func (f LoadShedderFunc) Shed(load Load) bool {
+	return f(load)
+}
+
+// PriorityShedder is a LoadShedder that rejects requests below MinPriority
+// while the client is overloaded: MaxInFlight requests already in flight,
+// MaxQueued already waiting, or (with ShedWhileThrottled) the error budget
+// in warning or critical state. Zero limits are not checked.
+type PriorityShedder struct {
+	MinPriority        Priority // Requests below it may be shed (default: PriorityNormal, i.e. only PriorityLow)
+	MaxInFlight        int
+	MaxQueued          int
+	ShedWhileThrottled bool
+}
+
+// Shed implements LoadShedder.
+func (s PriorityShedder) Shed(load Load) bool {
+	if load.Priority >= s.MinPriority {
+		return false
+	}
+	switch {
+	case s.MaxInFlight > 0 && load.InFlight >= s.MaxInFlight:
+		return true
+	case s.MaxQueued > 0 && load.Queued >= s.MaxQueued:
+		return true
+	case s.ShedWhileThrottled && load.RateLimit != nil:
+		return load.RateLimit.NeedsThrottling() || load.RateLimit.NeedsCriticalBlock()
+	default:
+		return false
+	}
+}
+
+// ShedError is returned for requests rejected by the configured
+// LoadShedder. It wraps ErrLoadShed.
+type ShedError struct {
+	Endpoint string
+	Priority Priority
+}
+
+// Error implements the error interface.
+func (e *ShedError) Error() string {
+	return fmt.Sprintf("%s (priority %s): %v", e.Endpoint, e.Priority, ErrLoadShed)
+}
+
+// Unwrap implements error unwrapping for errors.Is/As.
+func (e *ShedError) Unwrap() error {
+	return ErrLoadShed
+}
+
+// loadCounters track the requests Do admitted and has not returned yet.
+type loadCounters struct {
+	inFlight atomic.Int64
+	queued   atomic.Int64
+}
+
+// shed consults the configured LoadShedder and returns a ShedError if it
+// rejects the request. Without a LoadShedder it returns nil.
+func (c *Client) shed(ctx context.Context, endpoint string) error {
+	if c.config.LoadShedder == nil {
+		return nil
+	}
+
+	load := Load{
+		Endpoint: endpoint,
+		Priority: PriorityFromContext(ctx),
+		InFlight: int(c.load.inFlight.Load()),
+		Queued:   int(c.load.queued.Load()),
+	}
+	if state, err := c.rateLimiter.GetState(ctx); err == nil {
+		load.RateLimit = state
+	}
+	if !c.config.LoadShedder.Shed(load) {
+		return nil
+	}
+
+	logger := requestLogger(ctx, c.logger)
+	logger.Debug().
+		Str("endpoint", c.reportedEndpoint(ctx, endpoint)).
+		Str("priority", load.Priority.String()).
+		Int("in_flight", load.InFlight).
+		Int("queued", load.Queued).
+		Msg("Request shed")
+	esiRequestsTotal.WithLabelValues(c.reportedEndpoint(ctx, endpoint), "shed").Inc()
+	esiShedRequestsTotal.WithLabelValues(load.Priority.String()).Inc()
+	c.stats.blocked.Add(1)
+	return &ShedError{Endpoint: endpoint, Priority: load.Priority}
+}
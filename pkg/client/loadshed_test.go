@@ -0,0 +1,103 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/ratelimit"
+)
+
+func TestPriorityShedder(t *testing.T) {
+	throttled := &ratelimit.RateLimitState{ErrorsRemaining: 10, ResetAt: time.Now().Add(30 * time.Second)}
+	healthy := &ratelimit.RateLimitState{ErrorsRemaining: 100, ResetAt: time.Now().Add(30 * time.Second)}
+
+	tests := []struct {
+		name    string
+		shedder PriorityShedder
+		load    Load
+		want    bool
+	}{
+		{"idle", PriorityShedder{MaxInFlight: 10}, Load{Priority: PriorityLow, InFlight: 2}, false},
+		{"in flight limit", PriorityShedder{MaxInFlight: 10}, Load{Priority: PriorityLow, InFlight: 10}, true},
+		{"normal priority kept", PriorityShedder{MaxInFlight: 10}, Load{Priority: PriorityNormal, InFlight: 50}, false},
+		{"min priority high", PriorityShedder{MinPriority: PriorityHigh, MaxInFlight: 10}, Load{Priority: PriorityNormal, InFlight: 50}, true},
+		{"queue limit", PriorityShedder{MaxQueued: 3}, Load{Priority: PriorityLow, Queued: 3}, true},
+		{"throttled", PriorityShedder{ShedWhileThrottled: true}, Load{Priority: PriorityLow, RateLimit: throttled}, true},
+		{"healthy", PriorityShedder{ShedWhileThrottled: true}, Load{Priority: PriorityLow, RateLimit: healthy}, false},
+		{"unknown state", PriorityShedder{ShedWhileThrottled: true}, Load{Priority: PriorityLow}, false},
+		{"no limits", PriorityShedder{}, Load{Priority: PriorityLow, InFlight: 1000, RateLimit: throttled}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.shedder.Shed(tt.load); got != tt.want {
+				t.Errorf("Shed(%+v) = %v, want %v", tt.load, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPriorityFromContext(t *testing.T) {
+	if got := PriorityFromContext(context.Background()); got != PriorityNormal {
+		t.Errorf("PriorityFromContext() = %s, want normal", got)
+	}
+	if got := PriorityFromContext(WithPriority(context.Background(), PriorityLow)); got != PriorityLow {
+		t.Errorf("PriorityFromContext() = %s, want low", got)
+	}
+}
+
+func TestDo_LoadShedder(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	var calls atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte(`{"players":1}`))
+	}))
+	defer server.Close()
+
+	var seen atomic.Value
+	cfg := DefaultConfig(redisClient, "TestApp/1.0.0")
+	cfg.LoadShedder = LoadShedderFunc(func(load Load) bool {
+		seen.Store(load)
+		return load.Priority == PriorityLow
+	})
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+
+	// Low priority requests are rejected without contacting ESI
+	_, err = client.Get(WithPriority(context.Background(), PriorityLow), "/v1/status/")
+	var shedErr *ShedError
+	if !errors.As(err, &shedErr) {
+		t.Fatalf("Get() error = %v, want ShedError", err)
+	}
+	if !errors.Is(err, ErrLoadShed) || ErrorCodeOf(err) != ErrorCodeRateLimited {
+		t.Errorf("Get() error = %v (code %s), want ErrLoadShed with RATE_LIMITED", err, ErrorCodeOf(err))
+	}
+	if shedErr.Priority != PriorityLow || shedErr.Endpoint != "/v1/status/" {
+		t.Errorf("ShedError = %+v, want low priority for /v1/status/", shedErr)
+	}
+	if calls.Load() != 0 {
+		t.Error("shed request sent to ESI")
+	}
+	if load := seen.Load().(Load); load.RateLimit == nil || load.InFlight != 0 {
+		t.Errorf("Load = %+v, want rate limit state and nothing in flight", load)
+	}
+
+	// Other priorities pass
+	resp, err := client.Get(context.Background(), "/v1/status/")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+	if calls.Load() != 1 {
+		t.Errorf("ESI calls = %d, want 1", calls.Load())
+	}
+}
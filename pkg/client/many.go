@@ -0,0 +1,96 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/pool"
+)
+
+// ManyResult is the outcome of one endpoint requested by GetMany.
+type ManyResult struct {
+	Endpoint   string
+	StatusCode int         // 0 if the request failed without a response
+	Header     http.Header // Response headers (nil without a response)
+	Body       []byte      // Complete response body
+	Err        error       // Transport, rate limit or budget error
+}
+
+// GetMany fetches several endpoints in parallel with at most
+// Config.MaxConcurrency requests in flight. Results are returned in the order
+// of endpoints, with bodies read completely.
+//
+// The endpoints share one error budget of Config.ErrorThreshold failures
+// (errors and 4xx/5xx responses): once it is used up, the remaining endpoints
+// are not requested and fail with ErrBatchBudgetExhausted, so a single batch
+// of bad paths cannot burn through the ESI error limit. They also share one
+// retry budget (see Config.RetryBudget).
+func (c *Client) GetMany(ctx context.Context, endpoints []string) []ManyResult {
+	ctx = c.ScopeOperation(ctx)
+	cfg := pool.Config{
+		Workers:     c.config.MaxConcurrency,
+		MaxFailures: max(c.config.ErrorThreshold, 1),
+	}
+	out := pool.Run(ctx, len(endpoints), cfg, func(ctx context.Context, i int) (ManyResult, error) {
+		result := c.getOne(ctx, endpoints[i])
+		if result.Err != nil {
+			return result, result.Err
+		}
+		if result.StatusCode >= http.StatusBadRequest {
+			return result, errFailedStatus
+		}
+		return result, nil
+	})
+
+	results := make([]ManyResult, len(endpoints))
+	failures := 0
+	for i, r := range out {
+		results[i] = r.Value
+		results[i].Endpoint = endpoints[i]
+		switch {
+		case errors.Is(r.Err, pool.ErrSkipped):
+			results[i].Err = ErrBatchBudgetExhausted
+		case r.Err != nil:
+			failures++
+			if r.Attempts == 0 {
+				results[i].Err = r.Err // Not started, context done
+			}
+		}
+	}
+
+	if failures > 0 {
+		c.logger.Debug().
+			Int("endpoints", len(endpoints)).
+			Int("failures", failures).
+			Msg("GetMany finished with failures")
+	}
+
+	return results
+}
+
+// errFailedStatus marks 4xx/5xx responses as failures for the GetMany error
+// budget; the response itself is still returned.
+var errFailedStatus = errors.New("error status")
+
+// getOne performs one GetMany request and reads the response body.
+func (c *Client) getOne(ctx context.Context, endpoint string) ManyResult {
+	result := ManyResult{Endpoint: endpoint}
+
+	resp, err := c.Get(ctx, endpoint)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer resp.Body.Close()
+
+	result.StatusCode = resp.StatusCode
+	result.Header = resp.Header
+	result.Body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		result.Err = fmt.Errorf("read response body: %w", err)
+	}
+	return result
+}
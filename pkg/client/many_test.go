@@ -0,0 +1,110 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetMany(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		w.Header().Set("X-ESI-Error-Limit-Remain", "100")
+		w.Header().Set("X-ESI-Error-Limit-Reset", "60")
+		if strings.HasPrefix(r.URL.Path, "/bad/") {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":"not found"}`)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, `{"path":%q}`, r.URL.Path)
+	}))
+	defer server.Close()
+
+	cfg := DefaultConfig(redisClient, "TestApp/1.0.0")
+	cfg.MaxConcurrency = 2
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+
+	endpoints := []string{"/a/", "/bad/1/", "/b/", "/c/", "/d/"}
+	results := client.GetMany(context.Background(), endpoints)
+
+	if len(results) != len(endpoints) {
+		t.Fatalf("GetMany() returned %d results, want %d", len(results), len(endpoints))
+	}
+	for i, result := range results {
+		if result.Endpoint != endpoints[i] {
+			t.Errorf("results[%d].Endpoint = %q, want %q", i, result.Endpoint, endpoints[i])
+		}
+		if result.Err != nil {
+			t.Errorf("results[%d].Err = %v", i, result.Err)
+		}
+	}
+	if results[1].StatusCode != http.StatusNotFound {
+		t.Errorf("bad endpoint status = %d, want %d", results[1].StatusCode, http.StatusNotFound)
+	}
+	if want := `{"path":"/c/"}`; string(results[3].Body) != want {
+		t.Errorf("results[3].Body = %s, want %s", results[3].Body, want)
+	}
+	if got := maxInFlight.Load(); got > 2 {
+		t.Errorf("max requests in flight = %d, want <= MaxConcurrency (2)", got)
+	}
+}
+
+func TestGetMany_SharedErrorBudget(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("X-ESI-Error-Limit-Remain", "100")
+		w.Header().Set("X-ESI-Error-Limit-Reset", "60")
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	cfg := DefaultConfig(redisClient, "TestApp/1.0.0")
+	cfg.MaxConcurrency = 1
+	cfg.ErrorThreshold = 5
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+
+	endpoints := make([]string, 8)
+	for i := range endpoints {
+		endpoints[i] = fmt.Sprintf("/bad/%d/", i)
+	}
+	results := client.GetMany(context.Background(), endpoints)
+
+	if got := requests.Load(); got != 5 {
+		t.Errorf("requests sent = %d, want 5 (error budget)", got)
+	}
+	for _, result := range results[5:] {
+		if !errors.Is(result.Err, ErrBatchBudgetExhausted) {
+			t.Errorf("%s: Err = %v, want ErrBatchBudgetExhausted", result.Endpoint, result.Err)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"strings"
+)
+
+// NetworkSubclass refines ErrorClassNetwork. The subclasses need different
+// remediation: DNS failures are usually local, connect timeouts point at ESI
+// or the path to it, TLS failures at proxies or certificates.
+type NetworkSubclass string
+
+const (
+	// NetworkSubclassDNS represents name resolution failures.
+	NetworkSubclassDNS NetworkSubclass = "dns"
+
+	// NetworkSubclassConnectTimeout represents timeouts while establishing the TCP connection.
+	NetworkSubclassConnectTimeout NetworkSubclass = "connect_timeout"
+
+	// NetworkSubclassConnect represents non-timeout dial failures (e.g. connection refused).
+	NetworkSubclassConnect NetworkSubclass = "connect"
+
+	// NetworkSubclassTLS represents TLS handshake and certificate failures.
+	NetworkSubclassTLS NetworkSubclass = "tls"
+
+	// NetworkSubclassReadTimeout represents timeouts after the connection was established.
+	NetworkSubclassReadTimeout NetworkSubclass = "read_timeout"
+
+	// NetworkSubclassOther represents all remaining transport errors.
+	NetworkSubclassOther NetworkSubclass = "other"
+)
+
+// classifyNetworkError determines the NetworkSubclass of a transport error.
+func classifyNetworkError(err error) NetworkSubclass {
+	if err == nil {
+		return ""
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return NetworkSubclassDNS
+	}
+
+	if isTLSError(err) {
+		return NetworkSubclassTLS
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		if opErr.Timeout() {
+			return NetworkSubclassConnectTimeout
+		}
+		return NetworkSubclassConnect
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return NetworkSubclassReadTimeout
+	}
+
+	return NetworkSubclassOther
+}
+
+// isTLSError reports whether err originates from the TLS layer.
+func isTLSError(err error) bool {
+	var recordErr tls.RecordHeaderError
+	var verifyErr *tls.CertificateVerificationError
+	var unknownAuthErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+
+	switch {
+	case errors.As(err, &recordErr),
+		errors.As(err, &verifyErr),
+		errors.As(err, &unknownAuthErr),
+		errors.As(err, &hostnameErr),
+		errors.As(err, &invalidErr):
+		return true
+	}
+
+	// Handshake alerts are not exported as types
+	return strings.Contains(err.Error(), "tls: ")
+}
@@ -0,0 +1,81 @@
+package client
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"testing"
+)
+
+func TestClassifyNetworkError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected NetworkSubclass
+	}{
+		{
+			name:     "nil error",
+			err:      nil,
+			expected: "",
+		},
+		{
+			name: "dns failure",
+			err: &url.Error{Op: "Get", URL: "https://esi.evetech.net", Err: &net.OpError{
+				Op:  "dial",
+				Err: &net.DNSError{Err: "no such host", Name: "esi.evetech.net", IsNotFound: true},
+			}},
+			expected: NetworkSubclassDNS,
+		},
+		{
+			name: "connect timeout",
+			err: &url.Error{Op: "Get", URL: "https://esi.evetech.net", Err: &net.OpError{
+				Op:  "dial",
+				Err: os.ErrDeadlineExceeded,
+			}},
+			expected: NetworkSubclassConnectTimeout,
+		},
+		{
+			name: "connection refused",
+			err: &url.Error{Op: "Get", URL: "https://esi.evetech.net", Err: &net.OpError{
+				Op:  "dial",
+				Err: errors.New("connect: connection refused"),
+			}},
+			expected: NetworkSubclassConnect,
+		},
+		{
+			name:     "tls certificate error",
+			err:      &url.Error{Op: "Get", URL: "https://esi.evetech.net", Err: x509.UnknownAuthorityError{}},
+			expected: NetworkSubclassTLS,
+		},
+		{
+			name:     "tls handshake alert",
+			err:      fmt.Errorf("remote error: tls: handshake failure"),
+			expected: NetworkSubclassTLS,
+		},
+		{
+			name: "read timeout",
+			err: &url.Error{Op: "Get", URL: "https://esi.evetech.net", Err: &net.OpError{
+				Op:  "read",
+				Err: os.ErrDeadlineExceeded,
+			}},
+			expected: NetworkSubclassReadTimeout,
+		},
+		{
+			name:     "unexpected eof",
+			err:      io.ErrUnexpectedEOF,
+			expected: NetworkSubclassOther,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyNetworkError(tt.err); got != tt.expected {
+				t.Errorf("classifyNetworkError() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package client
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// esiSeededConditionalRequestsTotal counts page requests made conditional
+// with the Last-Modified of page 1 and whether ESI confirmed the cached page.
+var esiSeededConditionalRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "esi_seeded_conditional_requests_total",
+	Help: "Total page requests made conditional with the Last-Modified of page 1 by result (not_modified, modified)",
+}, []string{"result"})
+
+// pageSeeds holds the Last-Modified of page 1 of each paginated endpoint
+// fetched within one operation (see ScopeOperation). All pages of an ESI
+// collection are generated together and share Last-Modified, so page 1's
+// value can validate cached pages that carry no validator of their own.
+type pageSeeds struct {
+	mu           sync.Mutex
+	lastModified map[string]time.Time // By seedKey
+}
+
+// pageSeedsKey is the context key of the operation's pageSeeds.
+type pageSeedsKey struct{}
+
+// withPageSeeds returns ctx with an empty pageSeeds, unless it has one.
+func withPageSeeds(ctx context.Context) context.Context {
+	if pageSeedsFromContext(ctx) != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, pageSeedsKey{}, &pageSeeds{lastModified: make(map[string]time.Time)})
+}
+
+// pageSeedsFromContext returns the operation's pageSeeds, nil if none.
+func pageSeedsFromContext(ctx context.Context) *pageSeeds {
+	seeds, _ := ctx.Value(pageSeedsKey{}).(*pageSeeds)
+	return seeds
+}
+
+// seedKey returns the cache key of key's collection (the key without its
+// page parameter) and the page number (1 if absent, 0 if invalid).
+func seedKey(key cache.CacheKey) (string, int) {
+	page := 1
+	if p := key.QueryParams.Get("page"); p != "" {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 1 {
+			return "", 0
+		}
+		page = n
+	}
+	key.QueryParams = maps.Clone(key.QueryParams)
+	key.QueryParams.Del("page")
+	return key.String(), page
+}
+
+// recordPageSeed remembers page 1's Last-Modified for the other pages of
+// the operation. resp is ESI's 200 or 304 response, entry the cached page
+// it confirmed (nil for a 200).
+func recordPageSeed(ctx context.Context, key cache.CacheKey, resp *http.Response, entry *cache.CacheEntry) {
+	seeds := pageSeedsFromContext(ctx)
+	if seeds == nil {
+		return
+	}
+	collection, page := seedKey(key)
+	if page != 1 {
+		return
+	}
+
+	lastModified, err := http.ParseTime(resp.Header.Get("Last-Modified"))
+	if err != nil {
+		if entry == nil || entry.LastModified.IsZero() {
+			return
+		}
+		lastModified = entry.LastModified
+	}
+
+	seeds.mu.Lock()
+	defer seeds.mu.Unlock()
+	seeds.lastModified[collection] = lastModified
+}
+
+// seedConditional makes the request for a later page conditional with
+// page 1's Last-Modified if the cached page has no validator of its own, or
+// with preferLastModified no Last-Modified of its own (its ETag is ignored).
+// The cached page must have been fetched from ESI after that time, so a 304
+// proves it current. It reports whether the request was made conditional.
+func seedConditional(ctx context.Context, req *http.Request, key cache.CacheKey, entry *cache.CacheEntry, preferLastModified bool) bool {
+	seeds := pageSeedsFromContext(ctx)
+	if seeds == nil || entry == nil || entry.Preloaded {
+		return false
+	}
+	if preferLastModified && !entry.LastModified.IsZero() {
+		return false
+	}
+	if !preferLastModified && cache.ShouldMakeConditionalRequest(entry) {
+		return false
+	}
+	collection, page := seedKey(key)
+	if page < 2 {
+		return false
+	}
+
+	seeds.mu.Lock()
+	lastModified, ok := seeds.lastModified[collection]
+	seeds.mu.Unlock()
+	if !ok || entry.CachedAt.Before(lastModified) {
+		return false
+	}
+
+	req.Header.Set("If-Modified-Since", lastModified.UTC().Format(http.TimeFormat))
+	return true
+}
@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+)
+
+func TestSeedKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    url.Values
+		wantPage int
+	}{
+		{"no page", url.Values{"order_type": {"all"}}, 1},
+		{"page 1", url.Values{"order_type": {"all"}, "page": {"1"}}, 1},
+		{"page 7", url.Values{"order_type": {"all"}, "page": {"7"}}, 7},
+		{"invalid page", url.Values{"page": {"x"}}, 0},
+	}
+
+	collection := cache.CacheKey{Endpoint: "/v1/markets/10000002/orders/", QueryParams: url.Values{"order_type": {"all"}}}.String()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := tt.query.Encode()
+			key, page := seedKey(cache.CacheKey{Endpoint: "/v1/markets/10000002/orders/", QueryParams: tt.query})
+			if page != tt.wantPage {
+				t.Errorf("page = %d, want %d", page, tt.wantPage)
+			}
+			if page != 0 && key != collection {
+				t.Errorf("key = %q, want %q", key, collection)
+			}
+			if after := tt.query.Encode(); after != before {
+				t.Errorf("query changed to %q, want %q", after, before)
+			}
+		})
+	}
+}
+
+func TestFetchPage_SeededConditional(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	lastModified := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	var mu sync.Mutex
+	ifModifiedSince := map[string]string{} // By page
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		mu.Lock()
+		ifModifiedSince[page] = r.Header.Get("If-Modified-Since")
+		mu.Unlock()
+
+		w.Header().Set("X-ESI-Error-Limit-Remain", "100")
+		w.Header().Set("X-ESI-Error-Limit-Reset", "60")
+		w.Header().Set("Expires", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+		w.Header().Set("X-Pages", "2")
+		if page == "1" {
+			// Only page 1 carries validators
+			w.Header().Set("ETag", `"page1"`)
+			w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+			if r.Header.Get("If-None-Match") == `"page1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		} else if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && !lastModified.After(since) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte(`[` + page + `]`))
+	}))
+	defer server.Close()
+
+	client, err := New(DefaultConfig(redisClient, "TestApp/1.0.0"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+	client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+
+	crawl := func(ctx context.Context) {
+		t.Helper()
+		for page := 1; page <= 2; page++ {
+			data, _, err := client.FetchPage(ctx, "/v1/markets/10000002/orders/", page)
+			if err != nil {
+				t.Fatalf("FetchPage(%d) error = %v", page, err)
+			}
+			if want := fmt.Sprintf("[%d]", page); string(data) != want {
+				t.Errorf("page %d = %s, want %s", page, data, want)
+			}
+		}
+	}
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		wantSeed bool
+	}{
+		{"first crawl", client.ScopeOperation(context.Background()), false},
+		{"repeat crawl", client.ScopeOperation(context.Background()), true},
+		{"no operation scope", context.Background(), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			crawl(tt.ctx)
+			mu.Lock()
+			defer mu.Unlock()
+			if seeded := ifModifiedSince["2"] != ""; seeded != tt.wantSeed {
+				t.Errorf("page 2 If-Modified-Since = %q, want seeded %v", ifModifiedSince["2"], tt.wantSeed)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Request phases reported by esi_request_phase_duration_seconds.
+const (
+	// phaseRateLimit covers the error limit check (including throttling),
+	// soft quotas and the smoothing limiter.
+	phaseRateLimit = "rate_limit"
+
+	// phaseCacheLookup is the Redis cache read.
+	phaseCacheLookup = "cache_lookup"
+
+	// phaseNetwork covers all attempts up to the response headers and, for
+	// cached responses, the body download.
+	phaseNetwork = "network"
+
+	// phaseCacheWrite is the Redis cache write of a fresh response.
+	phaseCacheWrite = "cache_write"
+)
+
+var esiRequestPhaseDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "esi_request_phase_duration_seconds",
+	Help:    "ESI request duration by phase (rate_limit, cache_lookup, network, cache_write)",
+	Buckets: []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 2, 5, 10},
+}, []string{"phase"})
+
+// observePhase records the duration of one request phase.
+func observePhase(ctx context.Context, phase string, d time.Duration) {
+	observe(ctx, esiRequestPhaseDuration.WithLabelValues(phase), d.Seconds())
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// sampleCount returns the number of observations of a histogram.
+func sampleCount(t *testing.T, o prometheus.Observer) uint64 {
+	t.Helper()
+	var m dto.Metric
+	if err := o.(prometheus.Histogram).Write(&m); err != nil {
+		t.Fatalf("write metric: %v", err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
+
+// phaseCount returns the number of observations of a request phase.
+func phaseCount(t *testing.T, phase string) uint64 {
+	t.Helper()
+	return sampleCount(t, esiRequestPhaseDuration.WithLabelValues(phase))
+}
+
+func TestDo_PhaseDurations(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-ESI-Error-Limit-Remain", "100")
+		w.Header().Set("X-ESI-Error-Limit-Reset", "60")
+		w.Header().Set("Expires", time.Now().Add(time.Minute).Format(http.TimeFormat))
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"players": 30000}`))
+	}))
+	defer server.Close()
+
+	client, err := New(DefaultConfig(redisClient, "TestApp/1.0.0"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+
+	phases := []string{phaseRateLimit, phaseCacheLookup, phaseNetwork, phaseCacheWrite}
+	before := make(map[string]uint64)
+	for _, phase := range phases {
+		before[phase] = phaseCount(t, phase)
+	}
+
+	resp, err := client.Get(context.Background(), "/v2/status/")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+
+	for _, phase := range phases {
+		if got := phaseCount(t, phase) - before[phase]; got != 1 {
+			t.Errorf("phase %s observed %d times, want 1", phase, got)
+		}
+	}
+}
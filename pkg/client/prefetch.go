@@ -0,0 +1,161 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// esiPrefetchesTotal counts prefetch scheduler operations by result.
+var esiPrefetchesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "esi_prefetches_total",
+	Help: "Total number of prefetches by result (scheduled, fetched, failed, dropped)",
+}, []string{"result"})
+
+// Results of prefetch operations (esi_prefetches_total label).
+const (
+	prefetchResultScheduled = "scheduled" // Refresh planned from a hint
+	prefetchResultFetched   = "fetched"   // Entry refreshed before it was needed
+	prefetchResultFailed    = "failed"    // Refresh failed or was rejected by backpressure
+	prefetchResultDropped   = "dropped"   // Not planned because too many are pending
+)
+
+const (
+	// prefetchTick is how often the scheduler looks for due refreshes.
+	prefetchTick = time.Second
+
+	// prefetchMaxPending caps the endpoints waiting for a refresh.
+	prefetchMaxPending = 1000
+)
+
+// prefetchHintKey is the context key of WithPrefetchHint.
+type prefetchHintKey struct{}
+
+// WithPrefetchHint returns a context telling the client that the caller will
+// request the same endpoint again within d. If the cache entry expires
+// before then, the client refreshes it in the background right after it
+// expires, so the next request is a cache hit. Each hint plans one refresh:
+// endpoints stay warm only as long as callers keep sending hints.
+//
+// Hints apply to cacheable GETs without Authorization; private data is not
+// prefetched because access tokens expire.
+func WithPrefetchHint(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, prefetchHintKey{}, d)
+}
+
+// prefetchHint returns the hint set by WithPrefetchHint, 0 if none.
+func prefetchHint(ctx context.Context) time.Duration {
+	d, _ := ctx.Value(prefetchHintKey{}).(time.Duration)
+	return d
+}
+
+// prefetcher refreshes hinted endpoints when their entries expire.
+type prefetcher struct {
+	mu      sync.Mutex
+	due     map[string]time.Time // Endpoint (path and query) -> refresh time
+	stop    context.CancelFunc   // Stops the loop, nil until the first hint
+	stopped bool
+}
+
+// schedulePrefetch plans a refresh of endpoint if its cache entry expires
+// before the caller needs it again (now + hint).
+func (c *Client) schedulePrefetch(ctx context.Context, endpoint string, key cache.CacheKey, hint time.Duration) {
+	entry, err := c.cache.Get(ctx, key)
+	if err != nil || !entry.Expires.Before(time.Now().Add(hint)) {
+		return
+	}
+
+	p := &c.prefetch
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.stopped {
+		return
+	}
+	if _, pending := p.due[endpoint]; !pending && len(p.due) >= prefetchMaxPending {
+		esiPrefetchesTotal.WithLabelValues(prefetchResultDropped).Inc()
+		return
+	}
+	if p.due == nil {
+		p.due = make(map[string]time.Time)
+	}
+	p.due[endpoint] = entry.Expires
+	esiPrefetchesTotal.WithLabelValues(prefetchResultScheduled).Inc()
+
+	if p.stop == nil {
+		loopCtx, cancel := context.WithCancel(context.Background())
+		p.stop = cancel
+		go c.prefetchLoop(loopCtx)
+	}
+}
+
+// prefetchLoop refreshes due endpoints until ctx is cancelled.
+func (c *Client) prefetchLoop(ctx context.Context) {
+	ticker := time.NewTicker(prefetchTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			for _, endpoint := range c.takeDuePrefetches(now) {
+				c.runPrefetch(ctx, endpoint)
+			}
+		}
+	}
+}
+
+// takeDuePrefetches removes and returns the endpoints due at now.
+func (c *Client) takeDuePrefetches(now time.Time) []string {
+	p := &c.prefetch
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var due []string
+	for endpoint, at := range p.due {
+		if !at.After(now) {
+			due = append(due, endpoint)
+			delete(p.due, endpoint)
+		}
+	}
+	return due
+}
+
+// runPrefetch refreshes one endpoint. Prefetches never wait for the rate
+// limiter: while the error budget is low they are dropped.
+func (c *Client) runPrefetch(ctx context.Context, endpoint string) {
+	resp, err := c.Get(WithFailFast(ctx), endpoint)
+	if err != nil {
+		esiPrefetchesTotal.WithLabelValues(prefetchResultFailed).Inc()
+		c.logger.Debug().Err(err).Str("endpoint", c.reportedEndpoint(ctx, endpoint)).Msg("Prefetch failed")
+		return
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		esiPrefetchesTotal.WithLabelValues(prefetchResultFailed).Inc()
+		return
+	}
+	esiPrefetchesTotal.WithLabelValues(prefetchResultFetched).Inc()
+}
+
+// stopPrefetch stops the scheduler and discards pending refreshes.
+func (c *Client) stopPrefetch() {
+	p := &c.prefetch
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.stopped = true
+	p.due = nil
+	if p.stop != nil {
+		p.stop()
+	}
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDo_PrefetchHint(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("X-ESI-Error-Limit-Remain", "100")
+		w.Header().Set("X-ESI-Error-Limit-Reset", "60")
+		w.Header().Set("Expires", time.Now().Add(2*time.Second).UTC().Format(http.TimeFormat))
+		w.Write([]byte(`{"players":1}`))
+	}))
+	defer server.Close()
+
+	cfg := DefaultConfig(redisClient, "TestApp/1.0.0")
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+	client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+
+	pending := func() int {
+		client.prefetch.mu.Lock()
+		defer client.prefetch.mu.Unlock()
+		return len(client.prefetch.due)
+	}
+
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		endpoint    string
+		wantPending int
+	}{
+		{"no hint", context.Background(), "/v1/status/", 0},
+		{"entry outlives hint", WithPrefetchHint(context.Background(), time.Second), "/v1/status/", 0},
+		{"authenticated", WithPrefetchHint(WithAccessToken(context.Background(), "token"), time.Minute), "/v1/characters/1/", 0},
+		{"entry expires before next use", WithPrefetchHint(context.Background(), time.Minute), "/v1/status/", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := client.Get(tt.ctx, tt.endpoint)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			resp.Body.Close()
+			if got := pending(); got != tt.wantPending {
+				t.Errorf("pending prefetches = %d, want %d", got, tt.wantPending)
+			}
+		})
+	}
+
+	// The scheduled prefetch refreshes the entry once it expires
+	before := requests.Load()
+	deadline := time.Now().Add(5 * time.Second)
+	for requests.Load() == before && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if requests.Load() == before {
+		t.Fatal("prefetch did not refresh the expired entry")
+	}
+	if got := pending(); got != 0 {
+		t.Errorf("pending prefetches after refresh = %d, want 0", got)
+	}
+}
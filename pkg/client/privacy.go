@@ -0,0 +1,124 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+	"github.com/cespare/xxhash/v2"
+)
+
+// PrivacyMode controls how endpoints of private requests appear in metric
+// labels and logs (see Config.PrivateRoutes and WithPrivate).
+type PrivacyMode int
+
+const (
+	// PrivacyRedact replaces IDs in the path with {id}, e.g.
+	// /v5/characters/{id}/assets/ (default).
+	PrivacyRedact PrivacyMode = iota
+
+	// PrivacyHash replaces IDs with a salted hash, e.g.
+	// /v5/characters/#1a2b3c4d/assets/, so requests of one character can be
+	// told apart without revealing who it is. Requires PrivacySalt.
+	PrivacyHash
+
+	// PrivacyOmit reports the endpoint as "private".
+	PrivacyOmit
+)
+
+// privateEndpoint is the endpoint reported for private requests with
+// PrivacyOmit.
+const privateEndpoint = "private"
+
+// String returns the name of the mode as used in configuration files.
+func (m PrivacyMode) String() string {
+	switch m {
+	case PrivacyRedact:
+		return "redact"
+	case PrivacyHash:
+		return "hash"
+	case PrivacyOmit:
+		return "omit"
+	default:
+		return fmt.Sprintf("PrivacyMode(%d)", int(m))
+	}
+}
+
+// privateKey is the context key of WithPrivate.
+type privateKey struct{}
+
+// WithPrivate returns a context whose requests are reported like requests
+// to Config.PrivateRoutes, e.g. for a single character-identifying call of
+// an otherwise public route.
+func WithPrivate(ctx context.Context) context.Context {
+	return context.WithValue(ctx, privateKey{}, true)
+}
+
+// private reports whether ctx was created by WithPrivate.
+func private(ctx context.Context) bool {
+	v, _ := ctx.Value(privateKey{}).(bool)
+	return v
+}
+
+// validatePrivacy checks the privacy configuration.
+func validatePrivacy(cfg Config) error {
+	for _, route := range cfg.PrivateRoutes {
+		if strings.Trim(route, "/") == "" {
+			return fmt.Errorf("private_routes: empty route")
+		}
+	}
+	switch cfg.PrivacyMode {
+	case PrivacyRedact, PrivacyOmit:
+	case PrivacyHash:
+		if cfg.PrivacySalt == "" {
+			return fmt.Errorf("privacy_mode hash requires privacy_salt")
+		}
+	default:
+		return fmt.Errorf("invalid privacy_mode %d", int(cfg.PrivacyMode))
+	}
+	return nil
+}
+
+// isPrivate reports whether endpoint (path, optionally with query) belongs
+// to one of the PrivateRoutes, matched without version like "characters/".
+func (c *Client) isPrivate(endpoint string) bool {
+	path, _, _ := strings.Cut(endpoint, "?")
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) > 1 && isVersionSegment(segments[0]) {
+		segments = segments[1:]
+	}
+	route := strings.Join(segments, "/") + "/"
+
+	for _, prefix := range c.config.PrivateRoutes {
+		if strings.HasPrefix(route, strings.TrimLeft(prefix, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// reportedEndpoint returns endpoint as it may appear in metric labels and
+// logs: unchanged for public requests, with IDs redacted or hashed (and the
+// query dropped) per PrivacyMode for private ones.
+func (c *Client) reportedEndpoint(ctx context.Context, endpoint string) string {
+	if !private(ctx) && !c.isPrivate(endpoint) {
+		return endpoint
+	}
+
+	path, _, _ := strings.Cut(endpoint, "?")
+	switch c.config.PrivacyMode {
+	case PrivacyOmit:
+		return privateEndpoint
+	case PrivacyHash:
+		segments := strings.Split(strings.Trim(path, "/"), "/")
+		for i, segment := range segments {
+			if segment != "" && strings.Trim(segment, "0123456789") == "" {
+				segments[i] = fmt.Sprintf("#%08x", uint32(xxhash.Sum64String(c.config.PrivacySalt+":"+segment)))
+			}
+		}
+		return "/" + strings.Join(segments, "/") + "/"
+	default:
+		return cache.EndpointPattern(path)
+	}
+}
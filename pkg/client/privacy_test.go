@@ -0,0 +1,130 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestValidatePrivacy(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"default", Config{}, false},
+		{"routes", Config{PrivateRoutes: []string{"characters/", "corporations/"}}, false},
+		{"empty route", Config{PrivateRoutes: []string{"/"}}, true},
+		{"omit", Config{PrivacyMode: PrivacyOmit}, false},
+		{"hash with salt", Config{PrivacyMode: PrivacyHash, PrivacySalt: "s3cret"}, false},
+		{"hash without salt", Config{PrivacyMode: PrivacyHash}, true},
+		{"invalid mode", Config{PrivacyMode: PrivacyMode(7)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validatePrivacy(tt.cfg); (err != nil) != tt.wantErr {
+				t.Errorf("validatePrivacy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReportedEndpoint(t *testing.T) {
+	routes := []string{"characters/", "corporations/98000001/wallets/"}
+	tests := []struct {
+		name     string
+		mode     PrivacyMode
+		ctx      context.Context
+		endpoint string
+		want     string
+	}{
+		{"public route unchanged", PrivacyRedact, context.Background(), "/v1/markets/10000002/orders/", "/v1/markets/10000002/orders/"},
+		{"redact", PrivacyRedact, context.Background(), "/v5/characters/90000001/assets/", "/v5/characters/{id}/assets/"},
+		{"redact drops query", PrivacyRedact, context.Background(), "/v5/characters/90000001/assets/?page=2", "/v5/characters/{id}/assets/"},
+		{"nested prefix", PrivacyRedact, context.Background(), "/v1/corporations/98000001/wallets/1/journal/", "/v1/corporations/{id}/wallets/{id}/journal/"},
+		{"other corporation public", PrivacyRedact, context.Background(), "/v1/corporations/98000002/wallets/", "/v1/corporations/98000002/wallets/"},
+		{"omit", PrivacyOmit, context.Background(), "/v5/characters/90000001/assets/", "private"},
+		{"WithPrivate", PrivacyRedact, WithPrivate(context.Background()), "/v1/universe/structures/1035466617946/", "/v1/universe/structures/{id}/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{config: Config{PrivateRoutes: routes, PrivacyMode: tt.mode}}
+			if got := c.reportedEndpoint(tt.ctx, tt.endpoint); got != tt.want {
+				t.Errorf("reportedEndpoint(%q) = %q, want %q", tt.endpoint, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReportedEndpoint_Hash(t *testing.T) {
+	ctx := context.Background()
+	c := &Client{config: Config{PrivateRoutes: []string{"characters/"}, PrivacyMode: PrivacyHash, PrivacySalt: "s3cret"}}
+
+	first := c.reportedEndpoint(ctx, "/v5/characters/90000001/assets/")
+	if strings.Contains(first, "90000001") || !strings.HasPrefix(first, "/v5/characters/#") || !strings.HasSuffix(first, "/assets/") {
+		t.Fatalf("reportedEndpoint() = %q, want the ID hashed", first)
+	}
+	if again := c.reportedEndpoint(ctx, "/v5/characters/90000001/assets/"); again != first {
+		t.Errorf("hash not stable: %q, then %q", first, again)
+	}
+	if other := c.reportedEndpoint(ctx, "/v5/characters/90000002/assets/"); other == first {
+		t.Errorf("different IDs hash to %q", other)
+	}
+
+	salted := &Client{config: Config{PrivateRoutes: []string{"characters/"}, PrivacyMode: PrivacyHash, PrivacySalt: "other"}}
+	if got := salted.reportedEndpoint(ctx, "/v5/characters/90000001/assets/"); got == first {
+		t.Errorf("hash does not depend on the salt: %q", got)
+	}
+}
+
+func TestDo_PrivateRouteMetricLabels(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-ESI-Error-Limit-Remain", "100")
+		w.Header().Set("X-ESI-Error-Limit-Reset", "60")
+		w.Header().Set("Expires", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	cfg := DefaultConfig(redisClient, "TestApp/1.0.0")
+	cfg.PrivateRoutes = []string{"characters/"}
+	cfg.PrivacyMode = PrivacyOmit
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+	client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+
+	count := func(endpoint string) float64 {
+		t.Helper()
+		var m dto.Metric
+		if err := esiRequestsTotal.WithLabelValues(endpoint, "200").Write(&m); err != nil {
+			t.Fatalf("read counter: %v", err)
+		}
+		return m.GetCounter().GetValue()
+	}
+	before := count("private")
+
+	resp, err := client.Get(context.Background(), "/v5/characters/90000001/assets/")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	if got := count("private") - before; got != 1 {
+		t.Errorf("esi_requests_total{endpoint=\"private\"} increased by %v, want 1", got)
+	}
+	if got := count("/v5/characters/90000001/assets/"); got != 0 {
+		t.Errorf("esi_requests_total with character ID = %v, want 0", got)
+	}
+}
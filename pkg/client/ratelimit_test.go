@@ -0,0 +1,68 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/ratelimit"
+)
+
+func TestDo_Shared420Block(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	var limited atomic.Bool
+	var calls atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("X-ESI-Error-Limit-Reset", "1")
+		if limited.Load() {
+			// Without X-ESI-Error-Limit-Remain only the shared block can stop other instances
+			w.WriteHeader(ratelimit.StatusErrorLimited)
+			return
+		}
+		w.Header().Set("X-ESI-Error-Limit-Remain", "100")
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	newClient := func() *Client {
+		t.Helper()
+		client, err := New(DefaultConfig(redisClient, "TestApp/1.0.0"))
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+		return client
+	}
+	seen, other := newClient(), newClient()
+
+	limited.Store(true)
+	resp, err := seen.Get(context.Background(), "/v1/status/")
+	if err != nil {
+		t.Fatalf("Get() error = %v, want the 420 response", err)
+	}
+	resp.Body.Close()
+	limited.Store(false)
+
+	// The other instance is blocked without asking ESI
+	before := calls.Load()
+	if _, err := other.Get(context.Background(), "/v1/status/"); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Get() error = %v, want ErrRateLimited", err)
+	}
+	if calls.Load() != before {
+		t.Error("request sent to ESI during shared 420 block")
+	}
+
+	// The block clears with the error limit window
+	time.Sleep(1100 * time.Millisecond)
+	resp, err = other.Get(context.Background(), "/v1/status/")
+	if err != nil {
+		t.Fatalf("Get() after block error = %v", err)
+	}
+	resp.Body.Close()
+}
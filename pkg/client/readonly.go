@@ -0,0 +1,41 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// allowedReadOnly reports whether req may be sent in ReadOnly mode: GET and
+// HEAD, plus POSTs to the built-in lookup endpoints (universe/names,
+// universe/ids, characters/affiliation), which ESI serves by POST only
+// because of their request bodies. Config.CacheablePosts does not widen the
+// set; it is not vetted for side effects.
+func allowedReadOnly(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead:
+		return true
+	case http.MethodPost:
+		segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+		if len(segments) > 1 && isVersionSegment(segments[0]) {
+			segments = segments[1:]
+		}
+		return slices.Contains(defaultCacheablePosts, strings.Join(segments, "/"))
+	default:
+		return false
+	}
+}
+
+// checkReadOnly rejects requests that could change in-game state when the
+// client runs in ReadOnly mode.
+func (c *Client) checkReadOnly(req *http.Request) error {
+	if !c.config.ReadOnly || allowedReadOnly(req) {
+		return nil
+	}
+	c.logger.Warn().
+		Str("method", req.Method).
+		Str("endpoint", c.reportedEndpoint(req.Context(), req.URL.Path)).
+		Msg("Request rejected in read-only mode")
+	return fmt.Errorf("%s %s: %w", req.Method, req.URL.Path, ErrReadOnly)
+}
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDo_ReadOnly(t *testing.T) {
+	tests := []struct {
+		method   string
+		endpoint string
+		allowed  bool
+	}{
+		{http.MethodGet, "/v1/markets/prices/", true},
+		{http.MethodHead, "/v1/status/", true},
+		{http.MethodGet, "/v1/fleets/1/", true},
+		{http.MethodPost, "/v3/universe/names/", true},
+		{http.MethodPost, "/latest/universe/ids/", true},
+		{http.MethodPost, "/v2/characters/affiliation/", true},
+		{http.MethodPost, "/v2/ui/autopilot/waypoint/", false},
+		{http.MethodPost, "/v2/characters/90000001/contacts/", false},
+		{http.MethodPut, "/v1/fleets/1/", false},
+		{http.MethodDelete, "/v2/characters/90000001/contacts/", false},
+	}
+
+	redisClient := setupTestRedis(t)
+
+	var calls atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("X-ESI-Error-Limit-Remain", "100")
+		w.Header().Set("X-ESI-Error-Limit-Reset", "60")
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	cfg := DefaultConfig(redisClient, "TestApp/1.0.0")
+	cfg.ReadOnly = true
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.endpoint, func(t *testing.T) {
+			before := calls.Load()
+			req, _ := http.NewRequestWithContext(context.Background(), tt.method, esiBaseURL+tt.endpoint, strings.NewReader(`[1]`))
+			resp, err := client.Do(req)
+			if err == nil {
+				resp.Body.Close()
+			}
+
+			sent := calls.Load() > before
+			if tt.allowed && (err != nil || !sent) {
+				t.Errorf("Do() error = %v, sent = %v; want request sent", err, sent)
+			}
+			if !tt.allowed && (!errors.Is(err, ErrReadOnly) || sent) {
+				t.Errorf("Do() error = %v, sent = %v; want ErrReadOnly before sending", err, sent)
+			}
+		})
+	}
+}
+
+func TestNew_ReadOnlyRejectsJournal(t *testing.T) {
+	cfg := DefaultConfig(setupTestRedis(t), "TestApp/1.0.0")
+	cfg.ReadOnly = true
+	cfg.JournalMaxAge = time.Minute
+
+	if _, err := New(cfg); err == nil {
+		t.Error("New() with read_only and journal_max_age succeeded, want error")
+	}
+}
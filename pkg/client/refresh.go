@@ -0,0 +1,85 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// esiRefreshesSuppressedTotal counts requests answered from the cache because
+// the entry was refreshed less than the route's minimum interval ago.
+var esiRefreshesSuppressedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "esi_refreshes_suppressed_total",
+	Help: "Total number of requests served from cache without revalidation because of a minimum refresh interval",
+}, []string{"route"})
+
+// validateMinRefreshIntervals checks Config.MinRefreshIntervals.
+func validateMinRefreshIntervals(cfg Config) error {
+	for route, interval := range cfg.MinRefreshIntervals {
+		if strings.Trim(route, "/") == "" {
+			return fmt.Errorf("min_refresh_intervals: empty route")
+		}
+		if interval <= 0 {
+			return fmt.Errorf("min_refresh_intervals: interval for %q must be > 0 (got %s)", route, interval)
+		}
+	}
+	return nil
+}
+
+// minRefreshInterval returns the minimum refresh interval of the longest
+// configured route prefix matching endpoint, and that prefix.
+func (c *Client) minRefreshInterval(endpoint string) (route string, interval time.Duration) {
+	if len(c.config.MinRefreshIntervals) == 0 {
+		return "", 0
+	}
+
+	segments := strings.Split(strings.Trim(endpoint, "/"), "/")
+	if len(segments) > 1 && isVersionSegment(segments[0]) {
+		segments = segments[1:]
+	}
+	path := strings.Join(segments, "/") + "/"
+
+	for prefix, d := range c.config.MinRefreshIntervals {
+		trimmed := strings.TrimLeft(prefix, "/")
+		if strings.HasPrefix(path, trimmed) && len(trimmed) > len(strings.TrimLeft(route, "/")) {
+			route, interval = prefix, d
+		}
+	}
+	return route, interval
+}
+
+// suppressRefresh answers a request from the cache without contacting ESI
+// if its entry was fetched or revalidated less than the route's minimum
+// refresh interval ago. It returns nil if the request must go to ESI.
+func (c *Client) suppressRefresh(ctx context.Context, req *http.Request, key cache.CacheKey) *http.Response {
+	endpoint := req.URL.Path
+	route, interval := c.minRefreshInterval(endpoint)
+	if interval <= 0 {
+		return nil
+	}
+
+	entry, err := c.cache.Get(ctx, key)
+	if err != nil || entry.Preloaded || time.Since(entry.RefreshedAt()) >= interval {
+		return nil
+	}
+
+	logger := requestLogger(ctx, c.logger)
+	logger.Debug().
+		Str("endpoint", c.reportedEndpoint(ctx, endpoint)).
+		Str("route", route).
+		Time("refreshed_at", entry.RefreshedAt()).
+		Msg("Minimum refresh interval not reached - serving cache")
+	esiRefreshesSuppressedTotal.WithLabelValues(route).Inc()
+	c.stats.cacheHits.Add(1)
+	c.maybeCanary(ctx, req, entry, canaryHitSuppressed)
+
+	resp := c.cacheEntryToResponse(entry)
+	resp.Header.Set(CacheStatusHeader, CacheStatusHit)
+	return resp
+}
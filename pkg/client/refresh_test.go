@@ -0,0 +1,116 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMinRefreshInterval(t *testing.T) {
+	c := &Client{config: Config{MinRefreshIntervals: map[string]time.Duration{
+		"markets/":            time.Minute,
+		"/markets/prices/":    time.Hour,
+		"universe/types":      2 * time.Minute,
+		"characters/1/orders": 3 * time.Minute,
+	}}}
+
+	tests := []struct {
+		endpoint     string
+		wantRoute    string
+		wantInterval time.Duration
+	}{
+		{"/v1/markets/10000002/orders/", "markets/", time.Minute},
+		{"/v1/markets/prices/", "/markets/prices/", time.Hour},
+		{"/v3/universe/types/34/", "universe/types", 2 * time.Minute},
+		{"/v1/status/", "", 0},
+		{"/v2/characters/12/orders/", "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.endpoint, func(t *testing.T) {
+			route, interval := c.minRefreshInterval(tt.endpoint)
+			if route != tt.wantRoute || interval != tt.wantInterval {
+				t.Errorf("minRefreshInterval() = %q, %s, want %q, %s", route, interval, tt.wantRoute, tt.wantInterval)
+			}
+		})
+	}
+}
+
+func TestNew_MinRefreshIntervalsValidation(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	tests := []struct {
+		name      string
+		intervals map[string]time.Duration
+		wantErr   bool
+	}{
+		{"valid", map[string]time.Duration{"markets/": time.Minute}, false},
+		{"empty route", map[string]time.Duration{"/": time.Minute}, true},
+		{"zero interval", map[string]time.Duration{"markets/": 0}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig(redisClient, "TestApp/1.0.0")
+			cfg.MinRefreshIntervals = tt.intervals
+			client, err := New(cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if client != nil {
+				client.Close()
+			}
+		})
+	}
+}
+
+func TestDo_MinRefreshInterval(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	var requests, hits atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		revalidatingHandler(&hits, `{"players":1}`).ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	cfg := DefaultConfig(redisClient, "TestApp/1.0.0")
+	cfg.MinRefreshIntervals = map[string]time.Duration{"status/": time.Hour}
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+	client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+
+	get := func(endpoint string) string {
+		t.Helper()
+		resp, err := client.Get(context.Background(), endpoint)
+		if err != nil {
+			t.Fatalf("Get(%s) error = %v", endpoint, err)
+		}
+		resp.Body.Close()
+		return resp.Header.Get(CacheStatusHeader)
+	}
+
+	// Polls within the interval are collapsed onto the cached value
+	get("/v1/status/")
+	for range 3 {
+		if status := get("/v1/status/"); status != CacheStatusHit {
+			t.Errorf("%s = %q, want %q", CacheStatusHeader, status, CacheStatusHit)
+		}
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("ESI requests = %d, want 1", got)
+	}
+
+	// Routes without an interval keep revalidating
+	get("/v1/universe/types/")
+	get("/v1/universe/types/")
+	if got := hits.Load(); got != 1 {
+		t.Errorf("revalidations = %d, want 1", got)
+	}
+}
@@ -0,0 +1,48 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/rs/zerolog"
+)
+
+// RequestIDHeader carries a request ID for correlating a downstream request
+// with the ESI calls and log lines it caused.
+const RequestIDHeader = "X-Request-ID"
+
+// ESIRequestIDHeader is ESI's own ID of a response. It is logged as
+// esi_request_id and set on ESIError, so failures can be escalated to CCP
+// with the identifier they can look up.
+const ESIRequestIDHeader = "X-ESI-Request-ID"
+
+// requestIDKey is the context key of the request ID.
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying id. Requests made with it send id
+// to ESI as X-Request-ID and log it as request_id.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID of ctx, or "" if none is set.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewRequestID returns a random request ID (32 hex characters).
+func NewRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// requestLogger returns logger with the request ID of ctx, if any.
+func requestLogger(ctx context.Context, logger zerolog.Logger) zerolog.Logger {
+	if id := RequestIDFromContext(ctx); id != "" {
+		return logger.With().Str("request_id", id).Logger()
+	}
+	return logger
+}
@@ -0,0 +1,99 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestDo_RequestID(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get(RequestIDHeader)
+		w.Header().Set("X-ESI-Error-Limit-Remain", "100")
+		w.Header().Set("X-ESI-Error-Limit-Reset", "60")
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client, err := New(DefaultConfig(redisClient, "TestApp/1.0.0"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+
+	var logs bytes.Buffer
+	client.logger = zerolog.New(&logs)
+
+	ctx := WithRequestID(context.Background(), "req-123")
+	resp, err := client.Get(ctx, "/v1/request-id/")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+
+	if got != "req-123" {
+		t.Errorf("%s sent to ESI = %q, want %q", RequestIDHeader, got, "req-123")
+	}
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		if strings.Contains(line, `"endpoint"`) && !strings.Contains(line, `"request_id":"req-123"`) {
+			t.Errorf("log line without request_id: %s", line)
+		}
+	}
+	if !strings.Contains(logs.String(), `"request_id":"req-123"`) {
+		t.Errorf("no log line with request_id:\n%s", logs.String())
+	}
+}
+
+func TestDo_ESIRequestID(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-ESI-Error-Limit-Remain", "100")
+		w.Header().Set("X-ESI-Error-Limit-Reset", "60")
+		w.Header().Set(ESIRequestIDHeader, "esi-456")
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client, err := New(DefaultConfig(redisClient, "TestApp/1.0.0"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+
+	var logs bytes.Buffer
+	client.logger = zerolog.New(&logs)
+
+	// The deadline skips the retry backoff
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	_, err = client.Get(ctx, "/v1/esi-request-id/")
+
+	var esiErr *ESIError
+	if !errors.As(err, &esiErr) || esiErr.RequestID != "esi-456" {
+		t.Errorf("Get() error = %v, want ESIError with RequestID esi-456", err)
+	}
+	if !strings.Contains(logs.String(), `"esi_request_id":"esi-456"`) {
+		t.Errorf("no log line with esi_request_id:\n%s", logs.String())
+	}
+}
+
+func TestNewRequestID(t *testing.T) {
+	a, b := NewRequestID(), NewRequestID()
+	if len(a) != 32 || a == b {
+		t.Errorf("NewRequestID() = %q, %q, want distinct 32 character IDs", a, b)
+	}
+	if got := RequestIDFromContext(context.Background()); got != "" {
+		t.Errorf("RequestIDFromContext(empty) = %q, want empty", got)
+	}
+}
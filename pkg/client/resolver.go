@@ -0,0 +1,143 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// esiDNSStaleAnswersTotal counts dials that used an expired cached DNS answer
+// because the lookup failed.
+var esiDNSStaleAnswersTotal = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "esi_dns_stale_answers_total",
+	Help: "Total number of connections dialed with a stale cached DNS answer after a failed lookup",
+})
+
+// resolvedHost is a cached DNS answer.
+type resolvedHost struct {
+	addrs   []string
+	expires time.Time
+}
+
+// hostResolver resolves host names for the transport dialer. Static
+// addresses pin a host to fixed IPs without any lookup. Other hosts are
+// looked up and, with a TTL, cached; when a lookup fails the last answer is
+// reused regardless of its age, so a resolver outage does not turn into
+// network errors (and retries) for hosts that were already known.
+type hostResolver struct {
+	static map[string][]string
+	ttl    time.Duration
+	lookup func(ctx context.Context, host string) ([]string, error)
+
+	mu    sync.Mutex
+	cache map[string]resolvedHost
+}
+
+// newHostResolver returns the resolver for cfg, or nil if neither
+// StaticAddresses nor DNSCacheTTL is set.
+func newHostResolver(cfg Config) *hostResolver {
+	if len(cfg.StaticAddresses) == 0 && cfg.DNSCacheTTL <= 0 {
+		return nil
+	}
+
+	r := &hostResolver{
+		static: make(map[string][]string),
+		ttl:    cfg.DNSCacheTTL,
+		lookup: net.DefaultResolver.LookupHost,
+		cache:  make(map[string]resolvedHost),
+	}
+	if len(cfg.StaticAddresses) > 0 {
+		r.static[esiHost()] = cfg.StaticAddresses
+	}
+	return r
+}
+
+// resolve returns the addresses to dial for host.
+func (r *hostResolver) resolve(ctx context.Context, host string) ([]string, error) {
+	if addrs, ok := r.static[host]; ok {
+		return addrs, nil
+	}
+	if r.ttl <= 0 {
+		return r.lookup(ctx, host)
+	}
+
+	r.mu.Lock()
+	cached, ok := r.cache[host]
+	r.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.addrs, nil
+	}
+
+	addrs, err := r.lookup(ctx, host)
+	if err != nil {
+		if ok {
+			esiDNSStaleAnswersTotal.Inc()
+			return cached.addrs, nil
+		}
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.cache[host] = resolvedHost{addrs: addrs, expires: time.Now().Add(r.ttl)}
+	r.mu.Unlock()
+	return addrs, nil
+}
+
+// dial resolves the host of addr and dials the addresses in order until one
+// connects. The address stays a host name for the transport, so TLS SNI and
+// certificate verification still use the host name.
+func (r *hostResolver) dial(ctx context.Context, dialer *net.Dialer, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if net.ParseIP(host) != nil {
+		return dialer.DialContext(ctx, network, addr)
+	}
+
+	addrs, err := r.resolve(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for _, ip := range addrs {
+		if !matchesNetwork(ip, network) {
+			continue
+		}
+		conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip, port))
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no %s address for %s", network, host)
+	}
+	return nil, lastErr
+}
+
+// matchesNetwork reports whether ip can be dialed on network (tcp, tcp4, tcp6).
+func matchesNetwork(ip, network string) bool {
+	parsed := net.ParseIP(ip)
+	switch network {
+	case "tcp4":
+		return parsed != nil && parsed.To4() != nil
+	case "tcp6":
+		return parsed != nil && parsed.To4() == nil
+	default:
+		return true
+	}
+}
+
+// esiHost returns the host name of the ESI API.
+func esiHost() string {
+	u, _ := url.Parse(esiBaseURL)
+	return u.Hostname()
+}
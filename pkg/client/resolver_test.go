@@ -0,0 +1,100 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHostResolver_ReusesLastAnswerOnFailure(t *testing.T) {
+	lookups := 0
+	failing := false
+	r := newHostResolver(Config{DNSCacheTTL: time.Minute})
+	r.lookup = func(ctx context.Context, host string) ([]string, error) {
+		lookups++
+		if failing {
+			return nil, &net.DNSError{Err: "server misbehaving", Name: host}
+		}
+		return []string{"192.0.2.1"}, nil
+	}
+
+	ctx := context.Background()
+	for range 2 {
+		if _, err := r.resolve(ctx, "esi.evetech.net"); err != nil {
+			t.Fatalf("resolve() error = %v", err)
+		}
+	}
+	if lookups != 1 {
+		t.Errorf("lookups = %d, want 1 (second answer from cache)", lookups)
+	}
+
+	// Expire the entry and let the resolver fail
+	r.cache["esi.evetech.net"] = resolvedHost{addrs: []string{"192.0.2.1"}, expires: time.Now().Add(-time.Second)}
+	failing = true
+	addrs, err := r.resolve(ctx, "esi.evetech.net")
+	if err != nil {
+		t.Fatalf("resolve() with failing lookup error = %v, want stale answer", err)
+	}
+	if !reflect.DeepEqual(addrs, []string{"192.0.2.1"}) {
+		t.Errorf("resolve() = %v, want stale answer", addrs)
+	}
+
+	// Unknown hosts still fail
+	var dnsErr *net.DNSError
+	if _, err := r.resolve(ctx, "unknown.example"); !errors.As(err, &dnsErr) {
+		t.Errorf("resolve() for unknown host error = %v, want *net.DNSError", err)
+	}
+}
+
+func TestNewTransport_StaticAddresses(t *testing.T) {
+	var host string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host = r.Host
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	client := &http.Client{Transport: newTransport(Config{StaticAddresses: []string{"127.0.0.1"}})}
+
+	// esi.evetech.net is pinned to the test server without any DNS lookup
+	target := "http://esi.evetech.net:" + serverURL.Port() + "/v1/status/"
+	resp, err := client.Get(target)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if want := "esi.evetech.net:" + serverURL.Port(); host != want {
+		t.Errorf("Host = %q, want %q", host, want)
+	}
+}
+
+func TestValidateTransportConfig_DNS(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         Config
+		expectError bool
+	}{
+		{"static addresses", Config{StaticAddresses: []string{"192.0.2.1", "2001:db8::1"}}, false},
+		{"dns cache", Config{DNSCacheTTL: time.Minute}, false},
+		{"invalid static address", Config{StaticAddresses: []string{"esi.evetech.net"}}, true},
+		{"no address for ip version", Config{IPVersion: IPVersion6, StaticAddresses: []string{"192.0.2.1"}}, true},
+		{"negative ttl", Config{DNSCacheTTL: -time.Second}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateTransportConfig(tt.cfg)
+			if (err != nil) != tt.expectError {
+				t.Errorf("validateTransportConfig() error = %v, expectError %v", err, tt.expectError)
+			}
+		})
+	}
+}
@@ -3,7 +3,6 @@ package client
 import (
 	"context"
 	"fmt"
-	"math/rand"
 	"time"
 
 	"github.com/rs/zerolog/log"
@@ -69,14 +68,39 @@ func RetryConfigForErrorClass(errorClass ErrorClass) RetryConfig {
 	}
 }
 
-// retryWithBackoff executes a function with exponential backoff retry logic.
-// It respects context cancellation and adds jitter to prevent thundering herd.
+// retryWithBackoff executes a function with backoff retry logic. The wait
+// before each retry comes from the error class's strategy in strategies
+// (default: ExponentialJitter). It respects context cancellation.
 // The classifyFn callback is called after each error to determine the error class dynamically.
-func retryWithBackoff(ctx context.Context, fn func() error, classifyFn func(error) ErrorClass) error {
+//
+// Retries whose backoff would outlast the context deadline, or that the
+// context's retry budget (WithRetryBudget) cannot pay for, are skipped.
+// Terminal errors are returned as *BudgetError carrying the consumed time
+// budget.
+func retryWithBackoff(ctx context.Context, strategies map[ErrorClass]BackoffStrategy, fn func() error, classifyFn func(error) ErrorClass) error {
+	start := time.Now()
+	logger := requestLogger(ctx, log.Logger)
+	retryBudget := retryBudgetFromContext(ctx)
+	if retryBudget != nil {
+		retryBudget.deposit()
+	}
+	budgetErr := func(attempts int, err error) error {
+		var remaining time.Duration
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) > 0 {
+			remaining = time.Until(deadline)
+		}
+		return &BudgetError{
+			Attempts:  attempts,
+			Consumed:  time.Since(start),
+			Remaining: remaining,
+			Err:       err,
+		}
+	}
+
 	var lastErr error
 	var currentClass ErrorClass
 	var config RetryConfig
-	var backoff time.Duration
+	var prevWait time.Duration
 
 	for attempt := 1; ; attempt++ {
 		// Execute the function
@@ -85,7 +109,7 @@ func retryWithBackoff(ctx context.Context, fn func() error, classifyFn func(erro
 			// Success
 			if attempt > 1 {
 				// Log successful retry
-				log.Info().
+				logger.Info().
 					Str("error_class", string(currentClass)).
 					Int("attempt", attempt).
 					Msg("Request succeeded after retry")
@@ -105,54 +129,71 @@ func retryWithBackoff(ctx context.Context, fn func() error, classifyFn func(erro
 			return lastErr
 		}
 
+		// Incident mode sends every request once
+		if retriesSuppressed(ctx) {
+			return lastErr
+		}
+
 		// If this was the last attempt, don't wait
 		if attempt >= config.MaxAttempts {
 			break
 		}
 
-		// Initialize backoff on first retry
-		if attempt == 1 {
-			backoff = config.InitialBackoff
+		// Wait per the error class's backoff strategy
+		wait := backoffStrategy(strategies, currentClass).Backoff(config, attempt, prevWait)
+
+		// Skip retries that cannot complete within the caller's deadline
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= wait {
+			esiRetryDeadlineSkipsTotal.WithLabelValues(string(currentClass)).Inc()
+			logger.Warn().
+				Str("error_class", string(currentClass)).
+				Int("attempt", attempt).
+				Dur("backoff", wait).
+				Dur("remaining", time.Until(deadline)).
+				Msg("Skipping retry - backoff exceeds remaining deadline")
+			return budgetErr(attempt, fmt.Errorf("%w: %w", ErrDeadlineBudgetExceeded, lastErr))
+		}
+
+		// Skip retries the operation's shared retry budget cannot pay for
+		if retryBudget != nil && !retryBudget.withdraw() {
+			esiRetryBudgetExhaustedTotal.WithLabelValues(string(currentClass)).Inc()
+			logger.Warn().
+				Str("error_class", string(currentClass)).
+				Int("attempt", attempt).
+				Msg("Skipping retry - retry budget exhausted")
+			return budgetErr(attempt, fmt.Errorf("%w: %w", ErrRetryBudgetExhausted, lastErr))
 		}
 
 		// Record retry metrics
 		esiRetriesTotal.WithLabelValues(string(currentClass)).Inc()
+		observe(ctx, esiRetryBackoffSeconds.WithLabelValues(string(currentClass)), wait.Seconds())
 
-		// Add jitter (±20% randomness)
-		jitter := time.Duration(float64(backoff) * (0.8 + rand.Float64()*0.4))
-		esiRetryBackoffSeconds.WithLabelValues(string(currentClass)).Observe(jitter.Seconds())
-
-		log.Debug().
+		logger.Debug().
 			Str("error_class", string(currentClass)).
 			Int("attempt", attempt).
-			Dur("backoff", jitter).
+			Dur("backoff", wait).
 			Msg("Retrying request after backoff")
 
 		// Wait with context cancellation support
 		select {
 		case <-ctx.Done():
-			log.Warn().
+			logger.Warn().
 				Str("error_class", string(currentClass)).
 				Int("attempt", attempt).
 				Msg("Context cancelled during retry backoff")
-			return fmt.Errorf("%w: %v", ErrContextCancelled, ctx.Err())
-		case <-time.After(jitter):
+			return budgetErr(attempt, fmt.Errorf("%w: %v", ErrContextCancelled, ctx.Err()))
+		case <-time.After(wait):
 			// Continue to next attempt
 		}
-
-		// Calculate next backoff (exponential)
-		backoff = time.Duration(float64(backoff) * config.BackoffMultiplier)
-		if backoff > config.MaxBackoff {
-			backoff = config.MaxBackoff
-		}
+		prevWait = wait
 	}
 
 	// All retries exhausted
 	esiRetryExhaustedTotal.WithLabelValues(string(currentClass)).Inc()
-	log.Warn().
+	logger.Warn().
 		Str("error_class", string(currentClass)).
 		Int("max_attempts", config.MaxAttempts).
 		Msg("Retry attempts exhausted")
 
-	return fmt.Errorf("%w after %d attempts: %v", ErrRetryExhausted, config.MaxAttempts, lastErr)
+	return budgetErr(config.MaxAttempts, fmt.Errorf("%w after %d attempts: %v", ErrRetryExhausted, config.MaxAttempts, lastErr))
 }
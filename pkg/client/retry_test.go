@@ -89,7 +89,7 @@ func TestRetryWithBackoff_Success(t *testing.T) {
 		return nil
 	}
 
-	err := retryWithBackoff(ctx, fn, func(error) ErrorClass {
+	err := retryWithBackoff(ctx, nil, fn, func(error) ErrorClass {
 		return ErrorClassServer
 	})
 
@@ -115,7 +115,7 @@ func TestRetryWithBackoff_SuccessAfterRetry(t *testing.T) {
 	}
 
 	start := time.Now()
-	err := retryWithBackoff(ctx, fn, func(error) ErrorClass {
+	err := retryWithBackoff(ctx, nil, fn, func(error) ErrorClass {
 		return ErrorClassServer
 	})
 	duration := time.Since(start)
@@ -145,7 +145,7 @@ func TestRetryWithBackoff_MaxAttemptsExhausted(t *testing.T) {
 		return testErr
 	}
 
-	err := retryWithBackoff(ctx, fn, func(error) ErrorClass { return ErrorClassServer })
+	err := retryWithBackoff(ctx, nil, fn, func(error) ErrorClass { return ErrorClassServer })
 
 	if err == nil {
 		t.Error("Expected error, got nil")
@@ -169,7 +169,7 @@ func TestRetryWithBackoff_ClientErrorNoRetry(t *testing.T) {
 		return testErr
 	}
 
-	err := retryWithBackoff(ctx, fn, func(error) ErrorClass { return ErrorClassClient })
+	err := retryWithBackoff(ctx, nil, fn, func(error) ErrorClass { return ErrorClassClient })
 
 	if err == nil {
 		t.Error("Expected error, got nil")
@@ -201,7 +201,7 @@ func TestRetryWithBackoff_ContextCancelled(t *testing.T) {
 		return errors.New("error")
 	}
 
-	err := retryWithBackoff(ctx, fn, func(error) ErrorClass { return ErrorClassServer })
+	err := retryWithBackoff(ctx, nil, fn, func(error) ErrorClass { return ErrorClassServer })
 
 	if err == nil {
 		t.Error("Expected error, got nil")
@@ -226,7 +226,7 @@ func TestRetryWithBackoff_ContextCancelledImmediately(t *testing.T) {
 		return errors.New("error")
 	}
 
-	err := retryWithBackoff(ctx, fn, func(error) ErrorClass { return ErrorClassServer })
+	err := retryWithBackoff(ctx, nil, fn, func(error) ErrorClass { return ErrorClassServer })
 
 	// First attempt should still happen even if context is cancelled
 	if callCount < 1 {
@@ -249,7 +249,7 @@ func TestRetryWithBackoff_ExponentialBackoff(t *testing.T) {
 		return errors.New("error")
 	}
 
-	_ = retryWithBackoff(ctx, fn, func(error) ErrorClass { return ErrorClassServer })
+	_ = retryWithBackoff(ctx, nil, fn, func(error) ErrorClass { return ErrorClassServer })
 
 	if len(timestamps) != 3 {
 		t.Fatalf("Expected 3 timestamps, got %d", len(timestamps))
@@ -286,7 +286,7 @@ func TestRetryWithBackoff_RateLimitLongerBackoff(t *testing.T) {
 		return errors.New("rate limit error")
 	}
 
-	_ = retryWithBackoff(ctx, fn, func(error) ErrorClass { return ErrorClassRateLimit })
+	_ = retryWithBackoff(ctx, nil, fn, func(error) ErrorClass { return ErrorClassRateLimit })
 
 	if len(timestamps) != 3 {
 		t.Fatalf("Expected 3 timestamps, got %d", len(timestamps))
@@ -316,7 +316,7 @@ func TestRetryWithBackoff_Jitter(t *testing.T) {
 			return nil // Succeed on second attempt
 		}
 
-		_ = retryWithBackoff(ctx, fn, func(error) ErrorClass { return ErrorClassServer })
+		_ = retryWithBackoff(ctx, nil, fn, func(error) ErrorClass { return ErrorClassServer })
 
 		if len(timestamps) >= 2 {
 			delays = append(delays, timestamps[1].Sub(timestamps[0]))
@@ -364,3 +364,67 @@ func TestRetryWithBackoff_MaxBackoffCap(t *testing.T) {
 		t.Errorf("Expected backoff to cap at %v, got %v", config.MaxBackoff, backoff)
 	}
 }
+
+func TestRetryWithBackoff_SkipsRetryBeyondDeadline(t *testing.T) {
+	// Server error backoff starts at ~1s, deadline leaves far less
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	callCount := 0
+	testErr := errors.New("server error")
+	fn := func() error {
+		callCount++
+		return testErr
+	}
+
+	start := time.Now()
+	err := retryWithBackoff(ctx, nil, fn, func(error) ErrorClass { return ErrorClassServer })
+
+	if !errors.Is(err, ErrDeadlineBudgetExceeded) {
+		t.Fatalf("Expected ErrDeadlineBudgetExceeded, got %v", err)
+	}
+	if !errors.Is(err, testErr) {
+		t.Errorf("Expected last error to be wrapped, got %v", err)
+	}
+	if callCount != 1 {
+		t.Errorf("Expected 1 call (retry skipped), got %d", callCount)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("Retry skip should return immediately, took %v", elapsed)
+	}
+
+	var budgetErr *BudgetError
+	if !errors.As(err, &budgetErr) {
+		t.Fatalf("Expected *BudgetError, got %T", err)
+	}
+	if budgetErr.Attempts != 1 {
+		t.Errorf("Attempts = %d, want 1", budgetErr.Attempts)
+	}
+	if budgetErr.Remaining <= 0 || budgetErr.Remaining > 200*time.Millisecond {
+		t.Errorf("Remaining = %v, want between 0 and 200ms", budgetErr.Remaining)
+	}
+}
+
+func TestRetryWithBackoff_ExhaustedReportsBudget(t *testing.T) {
+	callCount := 0
+	fn := func() error {
+		callCount++
+		return errors.New("server error")
+	}
+
+	err := retryWithBackoff(context.Background(), nil, fn, func(error) ErrorClass { return ErrorClassServer })
+
+	var budgetErr *BudgetError
+	if !errors.As(err, &budgetErr) {
+		t.Fatalf("Expected *BudgetError, got %T", err)
+	}
+	if budgetErr.Attempts != callCount {
+		t.Errorf("Attempts = %d, want %d", budgetErr.Attempts, callCount)
+	}
+	if budgetErr.Consumed <= 0 {
+		t.Errorf("Consumed = %v, want > 0", budgetErr.Consumed)
+	}
+	if budgetErr.Remaining != 0 {
+		t.Errorf("Remaining = %v, want 0 without deadline", budgetErr.Remaining)
+	}
+}
@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// esiRetryBudgetExhaustedTotal counts retries not attempted because the
+// operation's retry budget was used up.
+var esiRetryBudgetExhaustedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "esi_retry_budget_exhausted_total",
+	Help: "Total number of retries skipped because the operation's shared retry budget was exhausted",
+}, []string{"error_class"})
+
+// defaultRetryBudget is Config.RetryBudget in DefaultConfig.
+const defaultRetryBudget = 20
+
+// retryBudgetScale is the number of requests that pay for one retry: an
+// operation retries at most its initial tokens plus 10% of its requests.
+// Tokens are counted in units of 1/retryBudgetScale retries.
+const retryBudgetScale = 10
+
+// RetryBudget is a token bucket of retries shared by all requests of one
+// operation, e.g. the hundreds of page requests of a paginated fetch. Each
+// retry takes a token and each request adds a tenth of one, so a flaky
+// upstream cannot multiply the operation's requests by the retry count.
+// A RetryBudget is safe for concurrent use.
+type RetryBudget struct {
+	mu       sync.Mutex
+	tokens   int // In 1/retryBudgetScale retries
+	capacity int
+}
+
+// NewRetryBudget returns a budget holding tokens retries.
+func NewRetryBudget(tokens int) *RetryBudget {
+	return &RetryBudget{tokens: tokens * retryBudgetScale, capacity: tokens * retryBudgetScale}
+}
+
+// Remaining returns the number of retries currently available.
+func (b *RetryBudget) Remaining() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens / retryBudgetScale
+}
+
+// deposit credits a request to the budget, up to its capacity.
+func (b *RetryBudget) deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = min(b.tokens+1, b.capacity)
+}
+
+// withdraw takes a token for one retry. It reports false if none is left.
+func (b *RetryBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens < retryBudgetScale {
+		return false
+	}
+	b.tokens -= retryBudgetScale
+	return true
+}
+
+// retryBudgetKey is the context key of WithRetryBudget.
+type retryBudgetKey struct{}
+
+// WithRetryBudget returns a context whose requests share budget for retries,
+// including requests of nested operations (GetMany, paginated fetches) that
+// would otherwise get a budget of their own.
+func WithRetryBudget(ctx context.Context, budget *RetryBudget) context.Context {
+	return context.WithValue(ctx, retryBudgetKey{}, budget)
+}
+
+// retryBudgetFromContext returns the budget set by WithRetryBudget, nil if none.
+func retryBudgetFromContext(ctx context.Context) *RetryBudget {
+	budget, _ := ctx.Value(retryBudgetKey{}).(*RetryBudget)
+	return budget
+}
+
+// validateRetryBudget checks Config.RetryBudget.
+func validateRetryBudget(cfg Config) error {
+	if cfg.RetryBudget < 0 {
+		return fmt.Errorf("retry_budget must not be negative (got %d)", cfg.RetryBudget)
+	}
+	return nil
+}
+
+// ScopeOperation implements pagination.OperationScoper: all pages of a
+// paginated fetch share one retry budget of Config.RetryBudget tokens,
+// unless ctx already carries one, and the Last-Modified of page 1 (see
+// seedConditional).
+func (c *Client) ScopeOperation(ctx context.Context) context.Context {
+	ctx = withPageSeeds(ctx)
+	if c.config.RetryBudget == 0 || retryBudgetFromContext(ctx) != nil {
+		return ctx
+	}
+	return WithRetryBudget(ctx, NewRetryBudget(c.config.RetryBudget))
+}
@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryBudget(t *testing.T) {
+	budget := NewRetryBudget(2)
+
+	for i := range 2 {
+		if !budget.withdraw() {
+			t.Fatalf("withdraw %d = false, want true", i+1)
+		}
+	}
+	if budget.withdraw() {
+		t.Error("withdraw from empty budget = true, want false")
+	}
+
+	// Ten requests pay for one more retry
+	for range 10 {
+		budget.deposit()
+	}
+	if got := budget.Remaining(); got != 1 {
+		t.Errorf("Remaining() after 10 deposits = %d, want 1", got)
+	}
+
+	// Deposits never exceed the initial tokens
+	for range 100 {
+		budget.deposit()
+	}
+	if got := budget.Remaining(); got != 2 {
+		t.Errorf("Remaining() after 100 deposits = %d, want 2", got)
+	}
+}
+
+func TestRetryWithBackoff_RetryBudget(t *testing.T) {
+	ctx := WithRetryBudget(context.Background(), NewRetryBudget(1))
+
+	calls := 0
+	err := retryWithBackoff(ctx, nil, func() error {
+		calls++
+		return errors.New("server error")
+	}, func(error) ErrorClass { return ErrorClassServer })
+
+	if !errors.Is(err, ErrRetryBudgetExhausted) {
+		t.Fatalf("error = %v, want ErrRetryBudgetExhausted", err)
+	}
+	if calls != 2 {
+		t.Errorf("attempts = %d, want 2 (one retry paid by the budget)", calls)
+	}
+	if code := ErrorCodeOf(err); code != ErrorCodeRetryExhausted {
+		t.Errorf("ErrorCodeOf() = %s, want %s", code, ErrorCodeRetryExhausted)
+	}
+}
+
+func TestClient_ScopeOperation(t *testing.T) {
+	shared := NewRetryBudget(5)
+
+	tests := []struct {
+		name        string
+		retryBudget int
+		ctx         context.Context
+		wantBudget  func(*RetryBudget) bool
+	}{
+		{"new budget per operation", 20, context.Background(), func(b *RetryBudget) bool { return b != nil && b.Remaining() == 20 }},
+		{"caller budget is kept", 20, WithRetryBudget(context.Background(), shared), func(b *RetryBudget) bool { return b == shared }},
+		{"disabled", 0, context.Background(), func(b *RetryBudget) bool { return b == nil }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{config: Config{RetryBudget: tt.retryBudget}}
+			if budget := retryBudgetFromContext(c.ScopeOperation(tt.ctx)); !tt.wantBudget(budget) {
+				t.Errorf("unexpected retry budget %v", budget)
+			}
+		})
+	}
+}
+
+func TestGetMany_SharedRetryBudget(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("X-ESI-Error-Limit-Remain", "100")
+		w.Header().Set("X-ESI-Error-Limit-Reset", "60")
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	cfg := DefaultConfig(redisClient, "TestApp/1.0.0")
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+	client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+
+	// An empty budget leaves every endpoint a single attempt
+	ctx := WithRetryBudget(context.Background(), NewRetryBudget(0))
+	results := client.GetMany(ctx, []string{"/v1/status/", "/v1/universe/races/", "/v1/universe/bloodlines/"})
+
+	if got := requests.Load(); got != 3 {
+		t.Errorf("ESI requests = %d, want 3", got)
+	}
+	for _, result := range results {
+		if !errors.Is(result.Err, ErrRetryBudgetExhausted) {
+			t.Errorf("%s: error = %v, want ErrRetryBudgetExhausted", result.Endpoint, result.Err)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+)
+
+// validateLatestVersions checks Config.LatestVersions.
+func validateLatestVersions(cfg Config) error {
+	for route, version := range cfg.LatestVersions {
+		if strings.Trim(route, "/") == "" {
+			return fmt.Errorf("latest_versions: empty route")
+		}
+		if !isVersionSegment(version) || version == "latest" || version == "dev" || version == "legacy" {
+			return fmt.Errorf("latest_versions: %q must map to a pinned version like v1 (got %q)", route, version)
+		}
+	}
+	return nil
+}
+
+// canonicalEndpoint returns the endpoint used for the cache key: /latest/
+// routes listed in Config.LatestVersions are rewritten to the version
+// /latest/ resolves to, so /latest/ and the pinned version share entries.
+// Other endpoints are returned unchanged.
+func (c *Client) canonicalEndpoint(endpoint string) string {
+	if len(c.config.LatestVersions) == 0 {
+		return endpoint
+	}
+
+	segments := strings.Split(strings.Trim(endpoint, "/"), "/")
+	if len(segments) < 2 || segments[0] != "latest" {
+		return endpoint
+	}
+	route := strings.Join(segments[1:], "/") + "/"
+
+	var matched, version string
+	for prefix, v := range c.config.LatestVersions {
+		trimmed := strings.TrimLeft(prefix, "/")
+		if strings.HasPrefix(route, trimmed) && len(trimmed) > len(matched) {
+			matched, version = trimmed, v
+		}
+	}
+	if version == "" {
+		return endpoint
+	}
+	return "/" + version + "/" + route
+}
@@ -0,0 +1,106 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCanonicalEndpoint(t *testing.T) {
+	c := &Client{config: Config{LatestVersions: map[string]string{
+		"markets/":         "v1",
+		"markets/history/": "v2",
+		"/status/":         "v2",
+	}}}
+
+	tests := []struct {
+		endpoint string
+		want     string
+	}{
+		{"/latest/markets/10000002/orders/", "/v1/markets/10000002/orders/"},
+		{"/latest/markets/history/", "/v2/markets/history/"},
+		{"/latest/status/", "/v2/status/"},
+		{"/v1/markets/10000002/orders/", "/v1/markets/10000002/orders/"},
+		{"/latest/universe/types/", "/latest/universe/types/"},
+		{"/dev/status/", "/dev/status/"},
+		{"/latest/", "/latest/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.endpoint, func(t *testing.T) {
+			if got := c.canonicalEndpoint(tt.endpoint); got != tt.want {
+				t.Errorf("canonicalEndpoint() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew_LatestVersionsValidation(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	tests := []struct {
+		name     string
+		versions map[string]string
+		wantErr  bool
+	}{
+		{"valid", map[string]string{"markets/": "v1"}, false},
+		{"empty route", map[string]string{"": "v1"}, true},
+		{"alias version", map[string]string{"markets/": "latest"}, true},
+		{"invalid version", map[string]string{"markets/": "1"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig(redisClient, "TestApp/1.0.0")
+			cfg.LatestVersions = tt.versions
+			client, err := New(cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if client != nil {
+				client.Close()
+			}
+		})
+	}
+}
+
+func TestDo_LatestSharesPinnedEntry(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	var hits atomic.Int64
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		revalidatingHandler(&hits, `{"players":1}`).ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	cfg := DefaultConfig(redisClient, "TestApp/1.0.0")
+	cfg.LatestVersions = map[string]string{"status/": "v2"}
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+	client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+
+	for _, endpoint := range []string{"/latest/status/", "/v2/status/"} {
+		resp, err := client.Get(context.Background(), endpoint)
+		if err != nil {
+			t.Fatalf("Get(%s) error = %v", endpoint, err)
+		}
+		resp.Body.Close()
+	}
+
+	// The pinned request revalidated the entry fetched via /latest/
+	if hits.Load() != 1 {
+		t.Errorf("revalidations = %d, want 1", hits.Load())
+	}
+	// Requests still go to the path the caller used
+	if len(paths) != 2 || paths[0] != "/latest/status/" || paths[1] != "/v2/status/" {
+		t.Errorf("ESI paths = %v, want [/latest/status/ /v2/status/]", paths)
+	}
+}
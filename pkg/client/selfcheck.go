@@ -0,0 +1,159 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Self-check names as reported in CheckResult.Name.
+const (
+	CheckRedis      = "redis"
+	CheckCacheRedis = "cache_redis" // only reported if the cache uses a separate Redis
+	CheckUserAgent  = "user_agent"
+	CheckStatus     = "esi_status"
+	CheckClock      = "clock"
+)
+
+// maxClockSkew is the largest accepted difference between the local clock
+// and the ESI Date header. Expires handling is second-granular, so larger
+// skew makes the client serve stale data or refetch too early.
+const maxClockSkew = 5 * time.Second
+
+// statusEndpoint is the cheap, unauthenticated endpoint used for reachability.
+const statusEndpoint = "/latest/status/"
+
+// userAgentPattern matches "AppName/Version" followed by contact information.
+var userAgentPattern = regexp.MustCompile(`^\S+/\S+ .+$`)
+
+// CheckResult is the outcome of a single self-check.
+type CheckResult struct {
+	Name     string
+	OK       bool
+	Detail   string
+	Duration time.Duration
+}
+
+// SelfCheckReport is the outcome of SelfCheck.
+type SelfCheckReport struct {
+	OK     bool // All checks passed
+	Checks []CheckResult
+}
+
+// String renders the report one check per line, suitable for CLI output.
+func (r SelfCheckReport) String() string {
+	var b strings.Builder
+	for _, check := range r.Checks {
+		status := "OK  "
+		if !check.OK {
+			status = "FAIL"
+		}
+		fmt.Fprintf(&b, "[%s] %-10s %s (%s)\n", status, check.Name, check.Detail, check.Duration.Round(time.Millisecond))
+	}
+	return b.String()
+}
+
+// SelfCheck verifies the client setup: Redis connectivity, User-Agent
+// format, reachability of ESI /status and local clock skew against the ESI
+// Date header. All checks run even if an earlier one fails.
+func (c *Client) SelfCheck(ctx context.Context) SelfCheckReport {
+	report := SelfCheckReport{OK: true}
+	add := func(name string, start time.Time, err error, detail string) {
+		result := CheckResult{Name: name, OK: err == nil, Detail: detail, Duration: time.Since(start)}
+		if err != nil {
+			result.Detail = err.Error()
+			report.OK = false
+		}
+		report.Checks = append(report.Checks, result)
+	}
+
+	start := time.Now()
+	err := c.redis.Ping(ctx).Err()
+	add(CheckRedis, start, err, "connected")
+
+	if c.cacheRedis != c.redis {
+		start = time.Now()
+		err = c.cacheRedis.Ping(ctx).Err()
+		add(CheckCacheRedis, start, err, "connected")
+	}
+
+	start = time.Now()
+	add(CheckUserAgent, start, validateUserAgent(c.config.UserAgent), c.config.UserAgent)
+
+	start = time.Now()
+	serverDate, err := c.checkStatus(ctx)
+	add(CheckStatus, start, err, "reachable")
+
+	start = time.Now()
+	if err != nil {
+		add(CheckClock, start, fmt.Errorf("skipped: ESI status unavailable"), "")
+	} else {
+		skew, err := checkClockSkew(serverDate, time.Now())
+		add(CheckClock, start, err, fmt.Sprintf("skew %s", skew))
+	}
+
+	return report
+}
+
+// validateUserAgent checks the ESI recommended "AppName/Version (contact)" format.
+func validateUserAgent(userAgent string) error {
+	if !userAgentPattern.MatchString(userAgent) {
+		return fmt.Errorf("user-agent %q should look like \"AppName/Version (contact@example.com)\"", userAgent)
+	}
+	if !strings.Contains(userAgent, "@") && !strings.Contains(userAgent, "://") {
+		return fmt.Errorf("user-agent %q has no contact (email or URL)", userAgent)
+	}
+	return nil
+}
+
+// checkStatus requests ESI /status directly (bypassing the cache so the Date
+// header is fresh) and returns the server date.
+func (c *Client) checkStatus(ctx context.Context) (time.Time, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, esiBaseURL+statusEndpoint, nil)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("User-Agent", c.config.UserAgent)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("GET %s: %w", statusEndpoint, err)
+	}
+	defer resp.Body.Close()
+
+	c.rateLimiter.RecordErrorResponse(resp.StatusCode, resp.Header)
+	if err := c.rateLimiter.UpdateFromHeaders(ctx, resp.Header); err != nil {
+		c.logger.Warn().Err(err).Msg("Failed to update rate limit from headers")
+	}
+	if err := c.rateLimiter.RecordRateLimited(ctx, resp.StatusCode, resp.Header); err != nil {
+		c.logger.Warn().Err(err).Msg("Failed to share 420 block")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return time.Time{}, fmt.Errorf("GET %s: unexpected status %d", statusEndpoint, resp.StatusCode)
+	}
+
+	date, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("GET %s: invalid Date header: %w", statusEndpoint, err)
+	}
+	return date, nil
+}
+
+// checkClockSkew compares the local clock with the server date.
+func checkClockSkew(serverDate, now time.Time) (time.Duration, error) {
+	skew := now.Sub(serverDate)
+	if skew < 0 {
+		skew = -skew
+	}
+	// Date has second resolution
+	skew = skew.Truncate(time.Second)
+	if skew > maxClockSkew {
+		return skew, fmt.Errorf("local clock is off by %s (max %s)", skew, maxClockSkew)
+	}
+	return skew, nil
+}
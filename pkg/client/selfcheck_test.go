@@ -0,0 +1,134 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateUserAgent(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		wantErr   bool
+	}{
+		{"email contact", "MyApp/1.0.0 (admin@example.com)", false},
+		{"url contact", "MyApp/1.0.0 (+https://example.com)", false},
+		{"no contact", "MyApp/1.0.0 (hello)", true},
+		{"no version", "MyApp (admin@example.com)", true},
+		{"name only", "MyApp", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateUserAgent(tt.userAgent); (err != nil) != tt.wantErr {
+				t.Errorf("validateUserAgent(%q) error = %v, wantErr %v", tt.userAgent, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckClockSkew(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		server   time.Time
+		wantSkew time.Duration
+		wantErr  bool
+	}{
+		{"in sync", now, 0, false},
+		{"sub-second difference", now.Add(-900 * time.Millisecond), 0, false},
+		{"local clock ahead", now.Add(-3 * time.Second), 3 * time.Second, false},
+		{"local clock behind too far", now.Add(30 * time.Second), 30 * time.Second, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			skew, err := checkClockSkew(tt.server, now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkClockSkew() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if skew != tt.wantSkew {
+				t.Errorf("checkClockSkew() skew = %v, want %v", skew, tt.wantSkew)
+			}
+		})
+	}
+}
+
+func TestSelfCheck(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		status    int
+		date      time.Time
+		wantOK    bool
+		wantFail  []string
+	}{
+		{
+			name:      "all checks pass",
+			userAgent: "TestApp/1.0.0 (test@example.com)",
+			status:    http.StatusOK,
+			date:      time.Now(),
+			wantOK:    true,
+		},
+		{
+			name:      "clock skew and bad user agent",
+			userAgent: "TestApp",
+			status:    http.StatusOK,
+			date:      time.Now().Add(-time.Hour),
+			wantFail:  []string{CheckUserAgent, CheckClock},
+		},
+		{
+			name:      "status unavailable",
+			userAgent: "TestApp/1.0.0 (test@example.com)",
+			status:    http.StatusServiceUnavailable,
+			date:      time.Now(),
+			wantFail:  []string{CheckStatus, CheckClock},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			redisClient := setupTestRedis(t)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != statusEndpoint {
+					t.Errorf("unexpected path %s", r.URL.Path)
+				}
+				w.Header().Set("Date", tt.date.UTC().Format(http.TimeFormat))
+				w.Header().Set("X-ESI-Error-Limit-Remain", "100")
+				w.Header().Set("X-ESI-Error-Limit-Reset", "60")
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			client, err := New(DefaultConfig(redisClient, tt.userAgent))
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+			client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}})
+
+			report := client.SelfCheck(context.Background())
+			if report.OK != tt.wantOK {
+				t.Errorf("SelfCheck() OK = %v, want %v\n%s", report.OK, tt.wantOK, report)
+			}
+			if len(report.Checks) != 4 {
+				t.Fatalf("SelfCheck() returned %d checks, want 4", len(report.Checks))
+			}
+
+			var failed []string
+			for _, check := range report.Checks {
+				if !check.OK {
+					failed = append(failed, check.Name)
+				}
+			}
+			if strings.Join(failed, ",") != strings.Join(tt.wantFail, ",") {
+				t.Errorf("failed checks = %v, want %v\n%s", failed, tt.wantFail, report)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultShutdownTimeout bounds Close if Config.ShutdownTimeout is 0.
+const defaultShutdownTimeout = 10 * time.Second
+
+// shutdownHook is a function run by Close.
+type shutdownHook struct {
+	name string
+	fn   func(ctx context.Context)
+}
+
+// shutdownState holds the hooks run by Close.
+type shutdownState struct {
+	mu    sync.Mutex
+	hooks []shutdownHook
+	done  bool // Close ran the hooks
+}
+
+// validateShutdown checks Config.ShutdownTimeout.
+func validateShutdown(cfg Config) error {
+	if cfg.ShutdownTimeout < 0 {
+		return fmt.Errorf("shutdown_timeout must not be negative (got %s)", cfg.ShutdownTimeout)
+	}
+	return nil
+}
+
+// OnShutdown registers fn to run during Close. Hooks run one after another
+// in reverse order of registration, so hooks registered after New run
+// before the client's own background tasks (prefetcher, journal replay,
+// expiry watcher) stop and may still use the client. ctx expires with
+// Config.ShutdownTimeout, which all hooks share: Close stops waiting for a
+// hook once it expired and calls the remaining ones with the expired ctx, so
+// hooks must return promptly when ctx is done. Hooks registered after Close
+// never run.
+func (c *Client) OnShutdown(fn func(ctx context.Context)) {
+	c.onShutdown("OnShutdown", fn)
+}
+
+// onShutdown registers a named hook (see OnShutdown).
+func (c *Client) onShutdown(name string, fn func(ctx context.Context)) {
+	c.shutdown.mu.Lock()
+	defer c.shutdown.mu.Unlock()
+	if !c.shutdown.done {
+		c.shutdown.hooks = append(c.shutdown.hooks, shutdownHook{name: name, fn: fn})
+	}
+}
+
+// stopAndWait returns a hook that cancels a background loop and waits until
+// it returned (done closed) or ctx expired.
+func stopAndWait(cancel context.CancelFunc, done <-chan struct{}) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		cancel()
+		select {
+		case <-done:
+		case <-ctx.Done():
+		}
+	}
+}
+
+// runShutdownHooks runs the registered hooks once (see OnShutdown). It
+// returns an error wrapping ErrShutdownTimeout naming the hooks that did
+// not finish in time.
+func (c *Client) runShutdownHooks() error {
+	c.shutdown.mu.Lock()
+	hooks := c.shutdown.hooks
+	c.shutdown.hooks, c.shutdown.done = nil, true
+	c.shutdown.mu.Unlock()
+
+	timeout := c.config.ShutdownTimeout
+	if timeout == 0 {
+		timeout = defaultShutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var late []string
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hook := hooks[i]
+		if ctx.Err() != nil {
+			hook.fn(ctx) // Past the deadline: hooks must return promptly
+			continue
+		}
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			hook.fn(ctx)
+		}()
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+			late = append(late, hook.name)
+			c.logger.Warn().
+				Str("hook", hook.name).
+				Dur("timeout", timeout).
+				Msg("Shutdown hook did not finish in time")
+		}
+	}
+
+	if len(late) > 0 {
+		return fmt.Errorf("%w after %s: %s", ErrShutdownTimeout, timeout, strings.Join(late, ", "))
+	}
+	return nil
+}
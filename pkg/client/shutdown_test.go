@@ -0,0 +1,88 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestValidateShutdown(t *testing.T) {
+	for timeout, wantErr := range map[time.Duration]bool{0: false, 5 * time.Second: false, -time.Second: true} {
+		if err := validateShutdown(Config{ShutdownTimeout: timeout}); (err != nil) != wantErr {
+			t.Errorf("validateShutdown(%s) error = %v, wantErr %v", timeout, err, wantErr)
+		}
+	}
+}
+
+func TestOnShutdown_ReverseOrder(t *testing.T) {
+	c := &Client{logger: zerolog.Nop()}
+
+	var order []string
+	c.onShutdown("prefetch", func(context.Context) { order = append(order, "prefetch") })
+	c.onShutdown("journal replay", func(context.Context) { order = append(order, "journal replay") })
+	c.OnShutdown(func(ctx context.Context) {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("hook context has no deadline")
+		}
+		order = append(order, "user")
+	})
+
+	if err := c.runShutdownHooks(); err != nil {
+		t.Fatalf("runShutdownHooks() error = %v", err)
+	}
+	if want := []string{"user", "journal replay", "prefetch"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("hooks ran in order %v, want %v", order, want)
+	}
+
+	// Hooks run once; later registrations are ignored
+	c.OnShutdown(func(context.Context) { order = append(order, "late") })
+	if err := c.runShutdownHooks(); err != nil {
+		t.Fatalf("second runShutdownHooks() error = %v", err)
+	}
+	if len(order) != 3 {
+		t.Errorf("hooks ran again: %v", order)
+	}
+}
+
+func TestOnShutdown_Timeout(t *testing.T) {
+	c := &Client{logger: zerolog.Nop(), config: Config{ShutdownTimeout: 50 * time.Millisecond}}
+
+	release := make(chan struct{})
+	defer close(release)
+	ranAfter := false
+	c.onShutdown("prefetch", func(context.Context) { ranAfter = true })
+	c.OnShutdown(func(context.Context) { <-release }) // ignores ctx
+
+	start := time.Now()
+	err := c.runShutdownHooks()
+	if !errors.Is(err, ErrShutdownTimeout) {
+		t.Fatalf("runShutdownHooks() error = %v, want ErrShutdownTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("shutdown took %s despite a 50ms timeout", elapsed)
+	}
+	if !ranAfter {
+		t.Error("hooks after the timed-out one did not run")
+	}
+}
+
+func TestStopAndWait(t *testing.T) {
+	loopCtx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	stopped := false
+	go func() {
+		defer close(done)
+		<-loopCtx.Done()
+		time.Sleep(10 * time.Millisecond)
+		stopped = true
+	}()
+
+	stopAndWait(cancel, done)(context.Background())
+	if !stopped {
+		t.Error("stopAndWait returned before the loop stopped")
+	}
+}
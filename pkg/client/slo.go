@@ -0,0 +1,302 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	esiSLORequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "esi_slo_requests_total",
+		Help: "Total requests covered by an SLO by route and result (good, slow, failed)",
+	}, []string{"route", "result"})
+
+	esiSLOBurnRate = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "esi_slo_burn_rate",
+		Help: "Rate at which an SLO's error budget is spent by route and window (5m, 1h); 1 spends it exactly over the SLO window",
+	}, []string{"route", "window"})
+
+	esiSLOCompliance = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "esi_slo_compliance",
+		Help: "Fraction of good requests within the SLO window by route",
+	}, []string{"route"})
+)
+
+// Results of requests covered by an SLO (esi_slo_requests_total label).
+const (
+	sloResultGood   = "good"   // Succeeded within the latency objective
+	sloResultSlow   = "slow"   // Succeeded slower than the latency objective
+	sloResultFailed = "failed" // Returned an error
+)
+
+const (
+	// defaultSLOWindow is the compliance window of SLOs without Window.
+	defaultSLOWindow = time.Hour
+
+	// maxSLOWindow bounds SLO windows, which are kept in memory per minute.
+	maxSLOWindow = 24 * time.Hour
+
+	// sloBucket is the resolution of SLO windows.
+	sloBucket = time.Minute
+
+	// sloRefreshInterval is how often the SLO gauges are recomputed, so burn
+	// rates decay while no requests arrive.
+	sloRefreshInterval = 15 * time.Second
+)
+
+// sloBurnWindows are the windows burn rates are exported for: a fast burn
+// shows in both, a slow one only in the longer window.
+var sloBurnWindows = []struct {
+	label  string
+	window time.Duration
+}{
+	{"5m", 5 * time.Minute},
+	{"1h", time.Hour},
+}
+
+// SLO is a latency and availability objective for a route prefix.
+type SLO struct {
+	// Latency is the duration after which a successful request counts as
+	// bad (0 = availability only).
+	Latency time.Duration
+
+	// Target is the fraction of requests that must be good, e.g. 0.95 for
+	// "95% of market fetches within 2s" (0 < Target < 1).
+	Target float64
+
+	// Window is the period compliance is measured over (default: 1h,
+	// max: 24h).
+	Window time.Duration
+}
+
+// SLOStatus is the compliance of one configured SLO.
+type SLOStatus struct {
+	Route string // Route prefix the SLO is configured for
+	SLO   SLO
+
+	Requests int64 // Requests within the SLO window
+	Bad      int64 // Requests within the SLO window that were slow or failed
+
+	// Compliance is the fraction of good requests within the SLO window
+	// (1 without requests).
+	Compliance float64
+
+	// BurnRates are the error budget burn rates by window ("5m", "1h"): the
+	// bad fraction divided by the allowed one (1 - Target).
+	BurnRates map[string]float64
+}
+
+// validateSLOs checks Config.SLOs.
+func validateSLOs(cfg Config) error {
+	for route, slo := range cfg.SLOs {
+		if strings.Trim(route, "/") == "" {
+			return fmt.Errorf("slos: empty route")
+		}
+		if slo.Target <= 0 || slo.Target >= 1 {
+			return fmt.Errorf("slos: target for %q must be between 0 and 1 (got %g)", route, slo.Target)
+		}
+		if slo.Latency < 0 {
+			return fmt.Errorf("slos: latency for %q must not be negative (got %s)", route, slo.Latency)
+		}
+		if slo.Window < 0 || slo.Window > maxSLOWindow {
+			return fmt.Errorf("slos: window for %q must be between 0 and %s (got %s)", route, maxSLOWindow, slo.Window)
+		}
+	}
+	return nil
+}
+
+// sloBucketCounts are the requests of one minute.
+type sloBucketCounts struct {
+	minute    int64 // Unix minute the counts belong to
+	good, bad int64
+}
+
+// sloTracker keeps per-minute request counts of one SLO.
+type sloTracker struct {
+	route string
+	slo   SLO
+
+	mu      sync.Mutex
+	buckets []sloBucketCounts // Ring indexed by Unix minute
+}
+
+// newSLOTracker returns a tracker holding enough minutes for the SLO window
+// and every burn rate window.
+func newSLOTracker(route string, slo SLO) *sloTracker {
+	if slo.Window == 0 {
+		slo.Window = defaultSLOWindow
+	}
+	span := slo.Window
+	for _, w := range sloBurnWindows {
+		span = max(span, w.window)
+	}
+	return &sloTracker{
+		route:   route,
+		slo:     slo,
+		buckets: make([]sloBucketCounts, span/sloBucket),
+	}
+}
+
+// record counts a request finished at now.
+func (t *sloTracker) record(now time.Time, good bool) {
+	minute := now.Unix() / int64(sloBucket/time.Second)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	b := &t.buckets[minute%int64(len(t.buckets))]
+	if b.minute != minute {
+		*b = sloBucketCounts{minute: minute}
+	}
+	if good {
+		b.good++
+	} else {
+		b.bad++
+	}
+}
+
+// counts returns the requests finished within window before now. The
+// current minute counts fully.
+func (t *sloTracker) counts(now time.Time, window time.Duration) (good, bad int64) {
+	minute := now.Unix() / int64(sloBucket/time.Second)
+	oldest := minute - int64(window/sloBucket) + 1
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, b := range t.buckets {
+		if b.minute >= oldest && b.minute <= minute {
+			good += b.good
+			bad += b.bad
+		}
+	}
+	return good, bad
+}
+
+// status returns the compliance and burn rates of the SLO at now.
+func (t *sloTracker) status(now time.Time) SLOStatus {
+	good, bad := t.counts(now, t.slo.Window)
+	s := SLOStatus{
+		Route:      t.route,
+		SLO:        t.slo,
+		Requests:   good + bad,
+		Bad:        bad,
+		Compliance: 1,
+		BurnRates:  make(map[string]float64, len(sloBurnWindows)),
+	}
+	if s.Requests > 0 {
+		s.Compliance = float64(good) / float64(s.Requests)
+	}
+	for _, w := range sloBurnWindows {
+		good, bad := t.counts(now, w.window)
+		if total := good + bad; total > 0 {
+			s.BurnRates[w.label] = float64(bad) / float64(total) / (1 - t.slo.Target)
+		} else {
+			s.BurnRates[w.label] = 0
+		}
+	}
+	return s
+}
+
+// newSLOTrackers returns a tracker per configured SLO, sorted by route.
+func newSLOTrackers(slos map[string]SLO) []*sloTracker {
+	trackers := make([]*sloTracker, 0, len(slos))
+	for route, slo := range slos {
+		trackers = append(trackers, newSLOTracker(route, slo))
+	}
+	sort.Slice(trackers, func(i, j int) bool { return trackers[i].route < trackers[j].route })
+	return trackers
+}
+
+// sloTracker returns the tracker of the longest configured route prefix
+// matching endpoint, nil if no SLO covers it.
+func (c *Client) sloTracker(endpoint string) *sloTracker {
+	if len(c.slos) == 0 {
+		return nil
+	}
+
+	segments := strings.Split(strings.Trim(endpoint, "/"), "/")
+	if len(segments) > 1 && isVersionSegment(segments[0]) {
+		segments = segments[1:]
+	}
+	path := strings.Join(segments, "/") + "/"
+
+	var matched *sloTracker
+	for _, t := range c.slos {
+		trimmed := strings.TrimLeft(t.route, "/")
+		if strings.HasPrefix(path, trimmed) && (matched == nil || len(trimmed) > len(strings.TrimLeft(matched.route, "/"))) {
+			matched = t
+		}
+	}
+	return matched
+}
+
+// recordSLO counts a finished request against the SLO covering endpoint.
+// Requests the caller cancelled are not counted; stale entries served while
+// ESI fails count as successful, since the caller got an answer.
+func (c *Client) recordSLO(endpoint string, elapsed time.Duration, err error) {
+	t := c.sloTracker(endpoint)
+	if t == nil || errors.Is(err, context.Canceled) {
+		return
+	}
+
+	result := sloResultGood
+	switch {
+	case err != nil:
+		result = sloResultFailed
+	case t.slo.Latency > 0 && elapsed > t.slo.Latency:
+		result = sloResultSlow
+	}
+	t.record(time.Now(), result == sloResultGood)
+	esiSLORequestsTotal.WithLabelValues(t.route, result).Inc()
+}
+
+// SLOStatus returns the compliance of every configured SLO, sorted by route.
+func (c *Client) SLOStatus() []SLOStatus {
+	now := time.Now()
+	statuses := make([]SLOStatus, len(c.slos))
+	for i, t := range c.slos {
+		statuses[i] = t.status(now)
+	}
+	return statuses
+}
+
+// updateSLOMetrics sets the SLO gauges from the current status.
+func (c *Client) updateSLOMetrics() {
+	for _, s := range c.SLOStatus() {
+		esiSLOCompliance.WithLabelValues(s.Route).Set(s.Compliance)
+		for window, rate := range s.BurnRates {
+			esiSLOBurnRate.WithLabelValues(s.Route, window).Set(rate)
+		}
+	}
+}
+
+// startSLOMetrics refreshes the SLO gauges every sloRefreshInterval until
+// Close.
+func (c *Client) startSLOMetrics() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	c.onShutdown("SLO metrics", stopAndWait(cancel, done))
+
+	c.updateSLOMetrics()
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(sloRefreshInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.updateSLOMetrics()
+			}
+		}
+	}()
+}
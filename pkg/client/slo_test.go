@@ -0,0 +1,114 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestValidateSLOs(t *testing.T) {
+	tests := []struct {
+		name    string
+		slos    map[string]SLO
+		wantErr bool
+	}{
+		{"none", nil, false},
+		{"valid", map[string]SLO{"markets/": {Latency: 2 * time.Second, Target: 0.95}}, false},
+		{"availability only", map[string]SLO{"status/": {Target: 0.999, Window: 24 * time.Hour}}, false},
+		{"empty route", map[string]SLO{"/": {Target: 0.95}}, true},
+		{"missing target", map[string]SLO{"markets/": {Latency: time.Second}}, true},
+		{"target of 1", map[string]SLO{"markets/": {Target: 1}}, true},
+		{"negative latency", map[string]SLO{"markets/": {Target: 0.9, Latency: -time.Second}}, true},
+		{"window too long", map[string]SLO{"markets/": {Target: 0.9, Window: 48 * time.Hour}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSLOs(Config{SLOs: tt.slos})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateSLOs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSLOTracker_Status(t *testing.T) {
+	tracker := newSLOTracker("markets/", SLO{Latency: 2 * time.Second, Target: 0.9})
+	now := time.Date(2026, 1, 1, 12, 0, 30, 0, time.UTC)
+
+	// 30 minutes ago: 10 requests, all bad
+	for range 10 {
+		tracker.record(now.Add(-30*time.Minute), false)
+	}
+	// Now: 18 good, 2 bad
+	for i := range 20 {
+		tracker.record(now, i >= 2)
+	}
+
+	s := tracker.status(now)
+	if s.Requests != 30 || s.Bad != 12 {
+		t.Errorf("Requests, Bad = %d, %d, want 30, 12", s.Requests, s.Bad)
+	}
+	if want := 18.0 / 30; math.Abs(s.Compliance-want) > 1e-9 {
+		t.Errorf("Compliance = %v, want %v", s.Compliance, want)
+	}
+	// 5m: 10% bad of an allowed 10% burns at exactly 1
+	if got := s.BurnRates["5m"]; math.Abs(got-1) > 1e-9 {
+		t.Errorf("BurnRates[5m] = %v, want 1", got)
+	}
+	if got := s.BurnRates["1h"]; math.Abs(got-4) > 1e-9 {
+		t.Errorf("BurnRates[1h] = %v, want 4", got)
+	}
+
+	// Two hours later everything has left the window
+	s = tracker.status(now.Add(2 * time.Hour))
+	if s.Requests != 0 || s.Compliance != 1 || s.BurnRates["5m"] != 0 || s.BurnRates["1h"] != 0 {
+		t.Errorf("status after window = %+v, want empty", s)
+	}
+}
+
+func TestSLOTracker_RingReuse(t *testing.T) {
+	tracker := newSLOTracker("status/", SLO{Target: 0.99})
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// Same ring slot one window apart: the old counts are dropped
+	tracker.record(now, false)
+	tracker.record(now.Add(time.Hour), true)
+
+	good, bad := tracker.counts(now.Add(time.Hour), time.Hour)
+	if good != 1 || bad != 0 {
+		t.Errorf("counts() = %d, %d, want 1, 0", good, bad)
+	}
+}
+
+func TestClient_RecordSLO(t *testing.T) {
+	c := &Client{slos: newSLOTrackers(map[string]SLO{
+		"markets/":        {Latency: 2 * time.Second, Target: 0.95},
+		"markets/prices/": {Target: 0.99},
+		"universe/types/": {Latency: time.Second, Target: 0.9},
+	})}
+
+	c.recordSLO("/v1/markets/10000002/orders/", 500*time.Millisecond, nil)
+	c.recordSLO("/v1/markets/10000002/orders/", 3*time.Second, nil)
+	c.recordSLO("/v1/markets/prices/", 3*time.Second, nil) // Availability only
+	c.recordSLO("/v1/markets/prices/", time.Second, fmt.Errorf("get: %w", ErrRetryExhausted))
+	c.recordSLO("/v3/universe/types/34/", time.Second, context.Canceled) // Not counted
+	c.recordSLO("/v1/status/", time.Minute, errors.New("boom"))          // No SLO
+
+	want := map[string][2]int64{ // Route -> requests, bad
+		"markets/":        {2, 1},
+		"markets/prices/": {2, 1},
+		"universe/types/": {0, 0},
+	}
+	statuses := c.SLOStatus()
+	if len(statuses) != len(want) {
+		t.Fatalf("SLOStatus() returned %d SLOs, want %d", len(statuses), len(want))
+	}
+	for _, s := range statuses {
+		if got := [2]int64{s.Requests, s.Bad}; got != want[s.Route] {
+			t.Errorf("%s: requests, bad = %v, want %v", s.Route, got, want[s.Route])
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var esiSmoothingWaitSeconds = promauto.NewHistogram(prometheus.HistogramOpts{
+	Name:    "esi_smoothing_wait_seconds",
+	Help:    "Time requests waited for the request smoothing limiter",
+	Buckets: []float64{0.01, 0.05, 0.1, 0.5, 1, 2, 5, 10},
+})
+
+// Limiter smooths request emission. *rate.Limiter from golang.org/x/time/rate
+// implements it, e.g. 150 requests/minute sustained with bursts of 20:
+//
+//	cfg.Limiter = rate.NewLimiter(rate.Limit(150.0/60), 20)
+type Limiter interface {
+	// Wait blocks until a request may be sent or ctx is done.
+	Wait(ctx context.Context) error
+}
+
+// waitForLimiter blocks until the configured Limiter admits the request.
+// Without a Limiter it returns immediately.
+func (c *Client) waitForLimiter(ctx context.Context) error {
+	if c.config.Limiter == nil {
+		return nil
+	}
+
+	start := time.Now()
+	err := c.config.Limiter.Wait(ctx)
+	observe(ctx, esiSmoothingWaitSeconds, time.Since(start).Seconds())
+	if err != nil {
+		return fmt.Errorf("request smoothing: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// countingLimiter admits requests until err is set.
+type countingLimiter struct {
+	waits int
+	err   error
+}
+
+func (l *countingLimiter) Wait(ctx context.Context) error {
+	l.waits++
+	return l.err
+}
+
+func TestDo_Limiter(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("X-ESI-Error-Limit-Remain", "100")
+		w.Header().Set("X-ESI-Error-Limit-Reset", "60")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	limiter := &countingLimiter{}
+	cfg := DefaultConfig(redisClient, "TestApp/1.0.0")
+	cfg.Limiter = limiter
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+
+	resp, err := client.Get(context.Background(), "/v1/status/")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+	if limiter.waits != 1 || requests != 1 {
+		t.Errorf("waits = %d, requests = %d, want 1 each", limiter.waits, requests)
+	}
+
+	// A limiter error (e.g. deadline shorter than the wait) fails the request
+	// before anything is sent
+	limiter.err = errors.New("would exceed context deadline")
+	if _, err := client.Get(context.Background(), "/v1/status/"); !errors.Is(err, limiter.err) {
+		t.Errorf("Get() error = %v, want limiter error", err)
+	}
+	if requests != 1 {
+		t.Errorf("requests = %d, want 1", requests)
+	}
+}
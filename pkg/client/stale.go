@@ -0,0 +1,57 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// esiStaleResponsesTotal counts expired cache entries served instead of an
+// ESI response.
+var esiStaleResponsesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "esi_stale_responses_total",
+	Help: "Total number of expired cache entries served because ESI was unavailable",
+}, []string{"reason"})
+
+// Reasons for serving stale entries (esi_stale_responses_total label).
+const (
+	staleReasonDowntime  = "downtime"  // ESI announced a downtime (503 + Retry-After)
+	staleReasonError     = "error"     // Request failed after retries
+	staleReasonBlocked   = "blocked"   // Rate limiter or quota blocked the request
+	staleReasonChallenge = "challenge" // Cloudflare challenge instead of ESI (see ChallengeBackoff)
+)
+
+// staleOnError returns the cached entry for key instead of err if
+// Config.ServeStaleOnError is set or the client is in incident mode, and an
+// entry is still retained (see MaxStale). Expired and unconfirmed preloaded
+// entries are marked with CacheStatusStale. Without an entry, err is
+// returned unchanged.
+func (c *Client) staleOnError(ctx context.Context, key cache.CacheKey, cacheable bool, reason string, err error) (*http.Response, error) {
+	if (!c.config.ServeStaleOnError && !c.incident.active()) || !cacheable {
+		return nil, err
+	}
+
+	entry, getErr := c.cache.GetStale(ctx, key)
+	if getErr != nil {
+		return nil, err
+	}
+
+	status := CacheStatusHit
+	if entry.IsStale() {
+		status = CacheStatusStale
+		esiStaleResponsesTotal.WithLabelValues(reason).Inc()
+	}
+	logger := requestLogger(ctx, c.logger)
+	logger.Warn().
+		Err(err).
+		Str("endpoint", c.reportedEndpoint(ctx, key.Endpoint)).
+		Str("cache_status", status).
+		Msg("Serving cached entry instead of failing")
+
+	resp := c.cacheEntryToResponse(entry)
+	resp.Header.Set(CacheStatusHeader, status)
+	return resp, nil
+}
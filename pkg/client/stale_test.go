@@ -0,0 +1,123 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+)
+
+func TestDo_ServeStaleOnError(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-ESI-Error-Limit-Remain", "100")
+		w.Header().Set("X-ESI-Error-Limit-Reset", "60")
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		serveStale bool
+		wantStale  bool
+	}{
+		{"enabled", true, true},
+		{"disabled", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig(redisClient, "TestApp/1.0.0")
+			cfg.ServeStaleOnError = tt.serveStale
+			client, err := New(cfg)
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+			client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+
+			key := cache.CacheKey{Endpoint: "/v1/stale-on-error/"}
+			entry := &cache.CacheEntry{
+				Data:       []byte(`{"players":1}`),
+				Expires:    time.Now().Add(100 * time.Millisecond),
+				StatusCode: http.StatusOK,
+				Headers:    http.Header{},
+			}
+			if err := client.cache.Set(context.Background(), key, entry); err != nil {
+				t.Fatalf("cache.Set() error = %v", err)
+			}
+			time.Sleep(200 * time.Millisecond)
+
+			// The deadline skips the retry backoff
+			ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+			defer cancel()
+			resp, err := client.Get(ctx, "/v1/stale-on-error/")
+
+			if !tt.wantStale {
+				if err == nil {
+					resp.Body.Close()
+					t.Fatal("Get() succeeded, want upstream error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Get() error = %v, want stale response", err)
+			}
+			resp.Body.Close()
+			if got := resp.Header.Get(CacheStatusHeader); got != CacheStatusStale {
+				t.Errorf("%s = %q, want %q", CacheStatusHeader, got, CacheStatusStale)
+			}
+		})
+	}
+}
+
+func TestDo_PreloadedEntryServedAsStale(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-ESI-Error-Limit-Remain", "100")
+		w.Header().Set("X-ESI-Error-Limit-Reset", "60")
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	cfg := DefaultConfig(redisClient, "TestApp/1.0.0")
+	cfg.ServeStaleOnError = true
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+
+	// Not expired, but never confirmed by ESI
+	dump := `{"key":"esi:v1/preloaded","body":{"players":1},"expires":"2024-01-01T00:00:00Z"}`
+	if _, err := client.cache.Preload(context.Background(), strings.NewReader(dump), cache.PreloadOptions{}); err != nil {
+		t.Fatalf("Preload() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	resp, err := client.Get(ctx, "/v1/preloaded/")
+	if err != nil {
+		t.Fatalf("Get() error = %v, want preloaded response", err)
+	}
+	resp.Body.Close()
+	if got := resp.Header.Get(CacheStatusHeader); got != CacheStatusStale {
+		t.Errorf("%s = %q, want %q", CacheStatusHeader, got, CacheStatusStale)
+	}
+}
+
+func TestNew_ServeStaleOnErrorRequiresMaxStale(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	cfg := DefaultConfig(redisClient, "TestApp/1.0.0")
+	cfg.ServeStaleOnError = true
+	cfg.MaxStale = 0
+	if _, err := New(cfg); err == nil {
+		t.Error("New() accepted ServeStaleOnError without MaxStale")
+	}
+}
@@ -0,0 +1,49 @@
+package client
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a point-in-time snapshot of client activity since New.
+// It mirrors the most important Prometheus metrics for applications
+// that want to show client health without running Prometheus.
+type Stats struct {
+	Since          time.Time     // When the client was created
+	Requests       int64         // Requests passed to Do
+	CacheHits      int64         // Requests that found an entry in the cache
+	NotModified    int64         // 304 responses served from cache
+	Retries        int64         // Additional attempts made by the retry logic
+	Blocked        int64         // Requests blocked by the rate limiter or a quota
+	Errors         int64         // Requests that returned an error
+	AverageLatency time.Duration // Mean duration of Do calls
+}
+
+// clientStats holds the counters behind Stats.
+type clientStats struct {
+	since        time.Time
+	requests     atomic.Int64
+	cacheHits    atomic.Int64
+	notModified  atomic.Int64
+	retries      atomic.Int64
+	blocked      atomic.Int64
+	errors       atomic.Int64
+	latencyTotal atomic.Int64 // nanoseconds
+}
+
+// Stats returns a snapshot of the client counters since start.
+func (c *Client) Stats() Stats {
+	s := Stats{
+		Since:       c.stats.since,
+		Requests:    c.stats.requests.Load(),
+		CacheHits:   c.stats.cacheHits.Load(),
+		NotModified: c.stats.notModified.Load(),
+		Retries:     c.stats.retries.Load(),
+		Blocked:     c.stats.blocked.Load(),
+		Errors:      c.stats.errors.Load(),
+	}
+	if s.Requests > 0 {
+		s.AverageLatency = time.Duration(c.stats.latencyTotal.Load() / s.Requests)
+	}
+	return s
+}
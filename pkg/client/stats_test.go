@@ -0,0 +1,74 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/ratelimit"
+)
+
+func TestStats(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	failures := 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-ESI-Error-Limit-Remain", "100")
+		w.Header().Set("X-ESI-Error-Limit-Reset", "60")
+
+		if r.URL.Path == "/flaky" && failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("If-None-Match") != "" {
+			w.Header().Set("Expires", time.Now().Add(10*time.Minute).Format(http.TimeFormat))
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Expires", time.Now().Add(5*time.Minute).Format(http.TimeFormat))
+		w.Header().Set("ETag", `"abc123"`)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"test": "data"}`))
+	}))
+	defer server.Close()
+
+	cfg := DefaultConfig(redisClient, "TestApp/1.0.0")
+	cfg.Quotas = []ratelimit.QuotaConfig{
+		{Name: "stats", Window: time.Hour, Limit: 3, Enforce: true},
+	}
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	// 200, then 304 from cache, then 503 + retry, then quota block
+	for _, path := range []string{"/test", "/test", "/flaky", "/blocked"} {
+		req, _ := http.NewRequest("GET", server.URL+path, nil)
+		if resp, err := client.Do(req); err == nil {
+			resp.Body.Close()
+		}
+	}
+
+	stats := client.Stats()
+	want := Stats{
+		Requests:    4,
+		CacheHits:   1,
+		NotModified: 1,
+		Retries:     1,
+		Blocked:     1,
+		Errors:      1,
+	}
+	got := stats
+	got.Since, got.AverageLatency = time.Time{}, 0
+	if got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+	if stats.AverageLatency <= 0 {
+		t.Errorf("AverageLatency = %v, want > 0", stats.AverageLatency)
+	}
+	if stats.Since.IsZero() || stats.Since.After(time.Now()) {
+		t.Errorf("Since = %v, want client creation time", stats.Since)
+	}
+}
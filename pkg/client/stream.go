@@ -0,0 +1,162 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+)
+
+// defaultStreamThreshold is Config.StreamThreshold if 0.
+const defaultStreamThreshold = 10 << 20
+
+// Meta describes a response returned by GetStream.
+type Meta struct {
+	StatusCode  int
+	Header      http.Header
+	CacheStatus string // X-ESI-Client-Cache: HIT, STALE, MISS or BYPASS
+	Pages       int    // X-Pages (0 if absent)
+	Size        int64  // Body size in bytes, -1 if unknown
+}
+
+// streamKey is the context key marking GetStream requests.
+type streamKey struct{}
+
+// streaming reports whether the request was made through GetStream.
+func streaming(ctx context.Context) bool {
+	_, ok := ctx.Value(streamKey{}).(bool)
+	return ok
+}
+
+// validateStream checks the streaming settings.
+func validateStream(cfg Config) error {
+	if cfg.StreamThreshold < 0 {
+		return fmt.Errorf("stream_threshold must not be negative (got %d)", cfg.StreamThreshold)
+	}
+	if cfg.StreamCacheMaxBytes < 0 {
+		return fmt.Errorf("stream_cache_max_bytes must not be negative (got %d)", cfg.StreamCacheMaxBytes)
+	}
+	return nil
+}
+
+// GetStream performs a GET request like Get, but hands out large response
+// bodies (above Config.StreamThreshold or of unknown size) as they arrive
+// instead of buffering them for the cache entry first, so responses of
+// hundreds of megabytes never live in memory as a whole. The caller must
+// close the body.
+//
+// Streamed bodies are written to the cache only if they are at most
+// Config.StreamCacheMaxBytes large (tee-to-cache): they are copied while the
+// caller reads and stored once the body was read completely. Smaller
+// responses and cache hits behave exactly like Get.
+func (c *Client) GetStream(ctx context.Context, endpoint string) (io.ReadCloser, Meta, error) {
+	ctx = context.WithValue(ctx, streamKey{}, true)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, esiBaseURL+endpoint, nil)
+	if err != nil {
+		return nil, Meta{}, fmt.Errorf("create request: %w", err)
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, Meta{}, err
+	}
+
+	meta := Meta{
+		StatusCode:  resp.StatusCode,
+		Header:      resp.Header,
+		CacheStatus: resp.Header.Get(CacheStatusHeader),
+		Size:        resp.ContentLength,
+	}
+	if pages, err := strconv.Atoi(resp.Header.Get("X-Pages")); err == nil {
+		meta.Pages = pages
+	}
+	return resp.Body, meta, nil
+}
+
+// streamThreshold returns the body size above which GetStream responses
+// are streamed.
+func (c *Client) streamThreshold() int64 {
+	if c.config.StreamThreshold == 0 {
+		return defaultStreamThreshold
+	}
+	return c.config.StreamThreshold
+}
+
+// streamResponse replaces the body of a large GetStream response with a
+// stream that passes the cache entry to store once the body was read, if it
+// fits Config.StreamCacheMaxBytes. It reports false if the response is small
+// enough to be cached as usual.
+func (c *Client) streamResponse(ctx context.Context, resp *http.Response, fallbackTTL time.Duration, store func(*cache.CacheEntry)) bool {
+	if !streaming(ctx) || (resp.ContentLength >= 0 && resp.ContentLength <= c.streamThreshold()) {
+		return false
+	}
+
+	limit := c.config.StreamCacheMaxBytes
+	if limit == 0 || resp.ContentLength > limit {
+		return true // Streamed without caching
+	}
+
+	resp.Body = &teeBody{
+		ReadCloser: resp.Body,
+		limit:      limit,
+		done: func(data []byte) {
+			entry, err := cache.ResponseToEntryWithTTL(&http.Response{
+				StatusCode: resp.StatusCode,
+				Header:     resp.Header.Clone(),
+				Body:       io.NopCloser(bytes.NewReader(data)),
+			}, fallbackTTL)
+			if err == nil {
+				store(entry)
+			}
+		},
+	}
+	return true
+}
+
+// teeBody copies a streamed body while it is read and passes the copy to
+// done once the body was read completely. The copy is dropped as soon as it
+// exceeds limit, or if the body is closed early or fails.
+type teeBody struct {
+	io.ReadCloser
+	buf      bytes.Buffer
+	limit    int64
+	done     func(data []byte)
+	finished bool // done was called or the copy was dropped
+}
+
+// Read implements io.Reader.
+func (t *teeBody) Read(p []byte) (int, error) {
+	n, err := t.ReadCloser.Read(p)
+	if t.finished {
+		return n, err
+	}
+
+	if int64(t.buf.Len()+n) > t.limit {
+		t.drop()
+	} else {
+		t.buf.Write(p[:n])
+	}
+
+	switch {
+	case errors.Is(err, io.EOF):
+		if !t.finished {
+			t.finished = true
+			t.done(t.buf.Bytes())
+		}
+	case err != nil:
+		t.drop()
+	}
+	return n, err
+}
+
+// drop discards the copy; the body is not cached.
+func (t *teeBody) drop() {
+	t.finished = true
+	t.buf = bytes.Buffer{}
+}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+)
+
+func TestGetStream(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-ESI-Error-Limit-Remain", "100")
+		w.Header().Set("X-ESI-Error-Limit-Reset", "60")
+		w.Header().Set("Expires", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+		w.Header().Set("X-Pages", "3")
+		size := 50
+		if strings.Contains(r.URL.Path, "large") {
+			size = 500
+		}
+		w.Write([]byte(strings.Repeat("x", size)))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name          string
+		endpoint      string
+		cacheMaxBytes int64
+		readAll       bool
+		wantCached    bool
+	}{
+		{"small body cached as usual", "/v1/small/", 0, true, true},
+		{"large body not cached", "/v1/large/a/", 0, true, false},
+		{"large body teed to cache", "/v1/large/b/", 1000, true, true},
+		{"large body above cache limit", "/v1/large/c/", 200, true, false},
+		{"large body closed early", "/v1/large/d/", 1000, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig(redisClient, "TestApp/1.0.0")
+			cfg.StreamThreshold = 100
+			cfg.StreamCacheMaxBytes = tt.cacheMaxBytes
+			client, err := New(cfg)
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+			defer client.Close()
+			client.SetHTTPClient(&http.Client{Transport: &testTransport{server: server}, Timeout: 5 * time.Second})
+
+			body, meta, err := client.GetStream(context.Background(), tt.endpoint)
+			if err != nil {
+				t.Fatalf("GetStream() error = %v", err)
+			}
+			if meta.StatusCode != http.StatusOK || meta.CacheStatus != CacheStatusMiss || meta.Pages != 3 {
+				t.Errorf("Meta = %+v, want 200/MISS/3 pages", meta)
+			}
+			if tt.readAll {
+				if _, err := io.Copy(io.Discard, body); err != nil {
+					t.Fatalf("read body: %v", err)
+				}
+			} else {
+				buf := make([]byte, 10)
+				body.Read(buf)
+			}
+			body.Close()
+
+			_, err = client.GetCache().Get(context.Background(), cache.CacheKey{Endpoint: tt.endpoint})
+			if cached := err == nil; cached != tt.wantCached {
+				t.Errorf("cached = %v, want %v", cached, tt.wantCached)
+			}
+		})
+	}
+}
+
+func TestValidateStream(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"defaults", Config{}, false},
+		{"custom", Config{StreamThreshold: 1 << 20, StreamCacheMaxBytes: 50 << 20}, false},
+		{"negative threshold", Config{StreamThreshold: -1}, true},
+		{"negative cache limit", Config{StreamCacheMaxBytes: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateStream(tt.cfg); (err != nil) != tt.wantErr {
+				t.Errorf("validateStream() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Request phases reported only by the stuck request watchdog, in addition
+// to the phases of esi_request_phase_duration_seconds.
+const (
+	// phasePrepare is everything before the first timed phase: cache key,
+	// refresh suppression and load shedding.
+	phasePrepare = "prepare"
+
+	// phaseCoalesced is waiting for an identical request in flight.
+	phaseCoalesced = "coalesced"
+
+	// phaseRetryBackoff is waiting between attempts.
+	phaseRetryBackoff = "retry_backoff"
+
+	// phaseInFlightWait is waiting for MaxInFlightBytes.
+	phaseInFlightWait = "inflight_wait"
+)
+
+// Bounds of the interval in which the watchdog looks for stuck requests.
+const (
+	minStuckCheckInterval = 100 * time.Millisecond
+	maxStuckCheckInterval = 10 * time.Second
+)
+
+var esiStuckRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "esi_stuck_requests_total",
+	Help: "Total number of requests still running after StuckRequestThreshold by phase",
+}, []string{"phase"})
+
+// activeRequest is a Do call tracked by the stuck request watchdog.
+type activeRequest struct {
+	ctx      context.Context
+	method   string
+	endpoint string // As reported in logs (see PrivateRoutes)
+	start    time.Time
+
+	phase    atomic.Value // string
+	attempts atomic.Int32
+	reports  int // Guarded by requestWatch.mu
+}
+
+// setPhase records the phase the request is in. It is a no-op on nil, so
+// requests are instrumented whether or not the watchdog runs.
+func (r *activeRequest) setPhase(phase string) {
+	if r != nil {
+		r.phase.Store(phase)
+	}
+}
+
+// attempt counts an attempt and moves the request to the network phase.
+func (r *activeRequest) attempt() {
+	if r != nil {
+		r.attempts.Add(1)
+		r.phase.Store(phaseNetwork)
+	}
+}
+
+// requestWatch holds the requests in flight.
+type requestWatch struct {
+	mu     sync.Mutex
+	next   uint64
+	active map[uint64]*activeRequest
+}
+
+// validateStuckRequests checks Config.StuckRequestThreshold.
+func validateStuckRequests(cfg Config) error {
+	if cfg.StuckRequestThreshold < 0 {
+		return fmt.Errorf("stuck_request_threshold must not be negative (got %s)", cfg.StuckRequestThreshold)
+	}
+	return nil
+}
+
+// trackRequest registers a request with the watchdog and returns it with
+// the function that unregisters it. Without the watchdog it returns nil.
+func (c *Client) trackRequest(ctx context.Context, method, endpoint string) (*activeRequest, func()) {
+	if c.config.StuckRequestThreshold <= 0 {
+		return nil, func() {}
+	}
+
+	r := &activeRequest{ctx: ctx, method: method, endpoint: endpoint, start: time.Now()}
+	r.phase.Store(phasePrepare)
+
+	c.watch.mu.Lock()
+	defer c.watch.mu.Unlock()
+	if c.watch.active == nil {
+		c.watch.active = make(map[uint64]*activeRequest)
+	}
+	c.watch.next++
+	id := c.watch.next
+	c.watch.active[id] = r
+	return r, func() {
+		c.watch.mu.Lock()
+		delete(c.watch.active, id)
+		c.watch.mu.Unlock()
+	}
+}
+
+// startStuckWatchdog checks the requests in flight until Close.
+func (c *Client) startStuckWatchdog() {
+	interval := min(max(c.config.StuckRequestThreshold/4, minStuckCheckInterval), maxStuckCheckInterval)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	c.onShutdown("stuck request watchdog", stopAndWait(cancel, done))
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.reportStuckRequests()
+			}
+		}
+	}()
+}
+
+// reportStuckRequests logs every request running longer than
+// Config.StuckRequestThreshold, and again each further threshold it keeps
+// running, with the phase it hangs in and the state of its context.
+func (c *Client) reportStuckRequests() {
+	threshold := c.config.StuckRequestThreshold
+	now := time.Now()
+
+	type report struct {
+		r     *activeRequest
+		first bool
+	}
+	c.watch.mu.Lock()
+	var stuck []report
+	for _, r := range c.watch.active {
+		if now.Sub(r.start) >= threshold*time.Duration(r.reports+1) {
+			r.reports++
+			stuck = append(stuck, report{r: r, first: r.reports == 1})
+		}
+	}
+	c.watch.mu.Unlock()
+
+	for _, s := range stuck {
+		r := s.r
+		phase, _ := r.phase.Load().(string)
+		if s.first {
+			esiStuckRequestsTotal.WithLabelValues(phase).Inc()
+		}
+
+		logger := requestLogger(r.ctx, c.logger)
+		event := logger.Warn().
+			Str("method", r.method).
+			Str("endpoint", r.endpoint).
+			Str("phase", phase).
+			Dur("elapsed", now.Sub(r.start)).
+			Int32("attempts", r.attempts.Load())
+		if err := r.ctx.Err(); err != nil {
+			// Cancelled but still running: something ignores the context
+			event = event.Str("context", err.Error())
+		} else if deadline, ok := r.ctx.Deadline(); ok {
+			event = event.Dur("deadline_in", deadline.Sub(now))
+		} else {
+			event = event.Str("context", "no deadline")
+		}
+		event.Msg("Request still running")
+	}
+}
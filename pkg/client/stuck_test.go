@@ -0,0 +1,64 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestReportStuckRequests(t *testing.T) {
+	var logs bytes.Buffer
+	c := &Client{config: Config{StuckRequestThreshold: 20 * time.Millisecond}, logger: zerolog.New(&logs)}
+
+	ctx, cancel := context.WithCancel(WithRequestID(context.Background(), "req-1"))
+	active, untrack := c.trackRequest(ctx, "GET", "/v1/markets/10000002/orders/")
+	active.attempt()
+
+	c.reportStuckRequests()
+	if logs.Len() != 0 {
+		t.Fatalf("request reported before the threshold: %s", logs.String())
+	}
+
+	time.Sleep(25 * time.Millisecond)
+	cancel()
+	c.reportStuckRequests()
+	out := logs.String()
+	for _, want := range []string{`"phase":"network"`, `"endpoint":"/v1/markets/10000002/orders/"`, `"attempts":1`, `"context":"context canceled"`, `"request_id":"req-1"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("stuck request log lacks %s: %s", want, out)
+		}
+	}
+
+	// Reported again only after another threshold
+	logs.Reset()
+	c.reportStuckRequests()
+	if logs.Len() != 0 {
+		t.Errorf("request reported twice within one threshold: %s", logs.String())
+	}
+
+	untrack()
+	time.Sleep(25 * time.Millisecond)
+	c.reportStuckRequests()
+	if logs.Len() != 0 {
+		t.Errorf("finished request reported: %s", logs.String())
+	}
+}
+
+func TestTrackRequest_Off(t *testing.T) {
+	c := &Client{}
+	active, untrack := c.trackRequest(context.Background(), "GET", "/v1/status/")
+	if active != nil {
+		t.Error("trackRequest() tracked a request without StuckRequestThreshold")
+	}
+	active.setPhase(phaseNetwork) // No-op on nil
+	active.attempt()
+	untrack()
+
+	if err := validateStuckRequests(Config{StuckRequestThreshold: -time.Second}); err == nil {
+		t.Error("negative StuckRequestThreshold accepted")
+	}
+}
@@ -0,0 +1,150 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// IPVersion selects the IP protocol used for outgoing ESI connections.
+type IPVersion string
+
+const (
+	// IPVersionAny lets the resolver choose (dual stack, default).
+	IPVersionAny IPVersion = ""
+
+	// IPVersion4 forces IPv4 connections.
+	IPVersion4 IPVersion = "ipv4"
+
+	// IPVersion6 forces IPv6 connections.
+	IPVersion6 IPVersion = "ipv6"
+)
+
+// network returns the dial network for the IP version.
+func (v IPVersion) network() string {
+	switch v {
+	case IPVersion4:
+		return "tcp4"
+	case IPVersion6:
+		return "tcp6"
+	default:
+		return "tcp"
+	}
+}
+
+// validateTransportConfig checks the IP version and local address settings.
+func validateTransportConfig(cfg Config) error {
+	switch cfg.IPVersion {
+	case IPVersionAny, IPVersion4, IPVersion6:
+	default:
+		return fmt.Errorf("ip_version must be %q, %q or empty (got %q)", IPVersion4, IPVersion6, cfg.IPVersion)
+	}
+
+	if _, err := proxyURL(cfg); err != nil {
+		return err
+	}
+
+	if err := validateUpstreams(cfg); err != nil {
+		return err
+	}
+
+	if cfg.DNSCacheTTL < 0 {
+		return fmt.Errorf("dns_cache_ttl must not be negative (got %s)", cfg.DNSCacheTTL)
+	}
+	usable := false
+	for _, addr := range cfg.StaticAddresses {
+		if net.ParseIP(addr) == nil {
+			return fmt.Errorf("static_addresses must be IP addresses (got %q)", addr)
+		}
+		usable = usable || matchesNetwork(addr, cfg.IPVersion.network())
+	}
+	if len(cfg.StaticAddresses) > 0 && !usable {
+		return fmt.Errorf("static_addresses contain no %s address", cfg.IPVersion)
+	}
+
+	if cfg.LocalAddress == "" {
+		return nil
+	}
+
+	ip := net.ParseIP(cfg.LocalAddress)
+	if ip == nil {
+		return fmt.Errorf("local_address must be an IP address (got %q)", cfg.LocalAddress)
+	}
+	if cfg.IPVersion == IPVersion4 && ip.To4() == nil {
+		return fmt.Errorf("local_address %s is not an IPv4 address", cfg.LocalAddress)
+	}
+	if cfg.IPVersion == IPVersion6 && ip.To4() != nil {
+		return fmt.Errorf("local_address %s is not an IPv6 address", cfg.LocalAddress)
+	}
+
+	return nil
+}
+
+// proxyURL parses Config.ProxyURL and applies ProxyUsername/ProxyPassword.
+// Returns nil if no explicit proxy is configured.
+func proxyURL(cfg Config) (*url.URL, error) {
+	if cfg.ProxyURL == "" {
+		return nil, nil
+	}
+
+	u, err := url.Parse(cfg.ProxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse proxy_url: %w", err)
+	}
+
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("proxy_url scheme must be http, https, socks5 or socks5h (got %q)", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("proxy_url must include a host")
+	}
+
+	if cfg.ProxyUsername != "" {
+		u.User = url.UserPassword(cfg.ProxyUsername, cfg.ProxyPassword)
+	}
+
+	return u, nil
+}
+
+// newTransport builds the HTTP transport for ESI requests. It starts from
+// http.DefaultTransport (keeping its pooling defaults and HTTP_PROXY,
+// HTTPS_PROXY and NO_PROXY handling) and applies the proxy, IP version,
+// egress address and DNS settings. An explicit ProxyURL takes precedence over
+// the environment.
+func newTransport(cfg Config) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	// Validated in New()
+	if proxy, _ := proxyURL(cfg); proxy != nil {
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	// Keep the warm pool's connections idle instead of closing all but two
+	if cfg.WarmConnections > http.DefaultMaxIdleConnsPerHost {
+		transport.MaxIdleConnsPerHost = cfg.WarmConnections
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	if cfg.LocalAddress != "" {
+		dialer.LocalAddr = &net.TCPAddr{IP: net.ParseIP(cfg.LocalAddress)}
+	}
+
+	network := cfg.IPVersion.network()
+	resolver := newHostResolver(cfg)
+	transport.DialContext = func(ctx context.Context, _, addr string) (net.Conn, error) {
+		if resolver != nil {
+			return resolver.dial(ctx, dialer, network, addr)
+		}
+		return dialer.DialContext(ctx, network, addr)
+	}
+
+	return transport
+}
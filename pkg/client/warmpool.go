@@ -0,0 +1,134 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultWarmInterval is how long the client may be idle before the warm
+// connections are re-established, below the transport's 90s idle timeout.
+const defaultWarmInterval = 60 * time.Second
+
+// warmTimeout bounds one warm-up request.
+const warmTimeout = 10 * time.Second
+
+// warmPool tracks when connections to ESI were last used.
+type warmPool struct {
+	lastUsed atomic.Int64 // Unix nanoseconds of the last request sent to ESI
+}
+
+// markUsed records a request sent to ESI.
+func (p *warmPool) markUsed() {
+	p.lastUsed.Store(time.Now().UnixNano())
+}
+
+// idleFor returns how long no request was sent to ESI.
+func (p *warmPool) idleFor() time.Duration {
+	return time.Since(time.Unix(0, p.lastUsed.Load()))
+}
+
+// validateWarmConnections checks the warm pool settings.
+func validateWarmConnections(cfg Config) error {
+	if cfg.WarmConnections < 0 {
+		return fmt.Errorf("warm_connections must not be negative (got %d)", cfg.WarmConnections)
+	}
+	if cfg.WarmInterval < 0 {
+		return fmt.Errorf("warm_interval must not be negative (got %s)", cfg.WarmInterval)
+	}
+	return nil
+}
+
+// startWarmPool establishes WarmConnections connections to ESI and
+// re-establishes them whenever the client was idle for WarmInterval, until
+// Close.
+func (c *Client) startWarmPool() {
+	interval := c.config.WarmInterval
+	if interval == 0 {
+		interval = defaultWarmInterval
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	c.onShutdown("connection warm-up", stopAndWait(cancel, done))
+
+	go func() {
+		defer close(done)
+		c.warmConnections(ctx)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if c.warm.idleFor() >= interval {
+					c.warmConnections(ctx)
+				}
+			}
+		}
+	}()
+}
+
+// warmConnections sends WarmConnections concurrent HEAD requests for the
+// status endpoint, so the transport keeps that many TLS connections in its
+// idle pool. Nothing is sent during an ESI downtime or challenge backoff.
+// It returns the number of successful requests.
+func (c *Client) warmConnections(ctx context.Context) int {
+	if _, down := c.downtime.active(); down {
+		return 0
+	}
+	if _, paused := c.challenge.active(); paused {
+		return 0
+	}
+
+	var (
+		wg     sync.WaitGroup
+		warmed atomic.Int32
+	)
+	for range c.config.WarmConnections {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.warmConnection(ctx); err != nil {
+				if ctx.Err() == nil {
+					c.logger.Debug().Err(err).Msg("Connection warm-up failed")
+				}
+				return
+			}
+			warmed.Add(1)
+		}()
+	}
+	wg.Wait()
+
+	c.warm.markUsed()
+	return int(warmed.Load())
+}
+
+// warmConnection sends one warm-up request.
+func (c *Client) warmConnection(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, warmTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, esiBaseURL+statusEndpoint, nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("User-Agent", c.config.UserAgent)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("HEAD %s: %w", statusEndpoint, err)
+	}
+	resp.Body.Close()
+
+	c.rateLimiter.RecordErrorResponse(resp.StatusCode, resp.Header)
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("HEAD %s: status %d", statusEndpoint, resp.StatusCode)
+	}
+	return nil
+}
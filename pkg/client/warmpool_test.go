@@ -0,0 +1,104 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestValidateWarmConnections(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"off", Config{}, false},
+		{"warm pool", Config{WarmConnections: 8, WarmInterval: 30 * time.Second}, false},
+		{"negative connections", Config{WarmConnections: -1}, true},
+		{"negative interval", Config{WarmConnections: 4, WarmInterval: -time.Second}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateWarmConnections(tt.cfg); (err != nil) != tt.wantErr {
+				t.Errorf("validateWarmConnections() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewTransport_WarmConnectionsIdlePool(t *testing.T) {
+	if got := newTransport(Config{}).MaxIdleConnsPerHost; got != 0 {
+		t.Errorf("MaxIdleConnsPerHost without warm pool = %d, want 0 (default 2)", got)
+	}
+	if got := newTransport(Config{WarmConnections: 8}).MaxIdleConnsPerHost; got != 8 {
+		t.Errorf("MaxIdleConnsPerHost with 8 warm connections = %d, want 8", got)
+	}
+}
+
+func TestWarmPool_IdleFor(t *testing.T) {
+	var p warmPool
+	if p.idleFor() < time.Hour {
+		t.Errorf("idleFor() before any request = %v, want idle", p.idleFor())
+	}
+	p.markUsed()
+	if p.idleFor() > time.Second {
+		t.Errorf("idleFor() after markUsed = %v, want ~0", p.idleFor())
+	}
+}
+
+func TestWarmConnections(t *testing.T) {
+	redisClient := setupTestRedis(t)
+
+	var (
+		mu      sync.Mutex
+		heads   int
+		remotes = map[string]bool{}
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond) // keep requests overlapping
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method == http.MethodHead && r.URL.Path == statusEndpoint && r.Header.Get("User-Agent") != "" {
+			heads++
+			remotes[r.RemoteAddr] = true
+		}
+	}))
+	defer server.Close()
+
+	// Warm up by hand instead of in the background against the real ESI
+	client, err := New(DefaultConfig(redisClient, "TestApp/1.0.0"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+	client.config.WarmConnections = 3
+	transport := &http.Transport{MaxIdleConnsPerHost: 3}
+	client.SetHTTPClient(&http.Client{Transport: &testTransportVia{server: server, next: transport}, Timeout: 5 * time.Second})
+
+	if warmed := client.warmConnections(t.Context()); warmed != 3 {
+		t.Errorf("warmConnections() = %d, want 3", warmed)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if heads != 3 || len(remotes) != 3 {
+		t.Errorf("server saw %d HEAD requests on %d connections, want 3 on 3", heads, len(remotes))
+	}
+	if client.warm.idleFor() > time.Second {
+		t.Error("warm-up did not reset the idle timer")
+	}
+}
+
+// testTransportVia redirects requests to server like testTransport, through
+// its own transport.
+type testTransportVia struct {
+	server *httptest.Server
+	next   *http.Transport
+}
+
+func (t *testTransportVia) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.URL.Scheme = "http"
+	req.URL.Host = t.server.URL[7:]
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,60 @@
+// Package codec defines the JSON encoding interfaces used by the typed APIs
+// and the cache envelope, so a faster implementation (sonic, jsoniter, ...)
+// can replace encoding/json without touching call sites.
+//
+// Example adapter for jsoniter:
+//
+//	type jsoniterCodec struct{ api jsoniter.API }
+//
+//	func (c jsoniterCodec) Marshal(v any) ([]byte, error)      { return c.api.Marshal(v) }
+//	func (c jsoniterCodec) Unmarshal(data []byte, v any) error { return c.api.Unmarshal(data, v) }
+//	func (c jsoniterCodec) NewStreamDecoder(r io.Reader) codec.StreamDecoder {
+//		return c.api.NewDecoder(r)
+//	}
+package codec
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Encoder serializes values to JSON.
+type Encoder interface {
+	Marshal(v any) ([]byte, error)
+}
+
+// StreamDecoder decodes JSON values from a stream.
+type StreamDecoder interface {
+	Decode(v any) error
+}
+
+// Decoder deserializes JSON, either from a buffer or from a stream.
+type Decoder interface {
+	Unmarshal(data []byte, v any) error
+	NewStreamDecoder(r io.Reader) StreamDecoder
+}
+
+// Codec combines Encoder and Decoder.
+type Codec interface {
+	Encoder
+	Decoder
+}
+
+// Std is the encoding/json implementation and the default everywhere.
+var Std Codec = stdCodec{}
+
+type stdCodec struct{}
+
+func (stdCodec) Marshal(v any) ([]byte, error) { return json.Marshal(v) }
+
+func (stdCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+func (stdCodec) NewStreamDecoder(r io.Reader) StreamDecoder { return json.NewDecoder(r) }
+
+// OrStd returns c, or Std if c is nil.
+func OrStd(c Codec) Codec {
+	if c == nil {
+		return Std
+	}
+	return c
+}
@@ -0,0 +1,45 @@
+package codec
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStd_RoundTrip(t *testing.T) {
+	type payload struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	}
+
+	data, err := Std.Marshal(payload{ID: 34, Name: "Tritanium"})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	var got payload
+	if err := Std.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if got.ID != 34 || got.Name != "Tritanium" {
+		t.Errorf("Unmarshal() = %+v", got)
+	}
+
+	var streamed payload
+	if err := Std.NewStreamDecoder(strings.NewReader(string(data))).Decode(&streamed); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if streamed != got {
+		t.Errorf("Decode() = %+v, want %+v", streamed, got)
+	}
+}
+
+func TestOrStd(t *testing.T) {
+	if OrStd(nil) != Std {
+		t.Error("OrStd(nil) should return Std")
+	}
+
+	custom := stdCodec{}
+	if OrStd(custom) != custom {
+		t.Error("OrStd() should keep a non-nil codec")
+	}
+}
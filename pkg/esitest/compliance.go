@@ -0,0 +1,248 @@
+// Package esitest provides test helpers for applications built on the ESI
+// client.
+//
+// Compliance checks that custom cache policies (Manager.Set with own
+// expiries, MinRefreshIntervals, CacheFilter, ...) keep to ESI's caching
+// rules. It watches the cache's Redis writes, the HTTP traffic to ESI and
+// the responses served, and fails the test when an entry is stored to
+// expire before the upstream Expires, or when a cached response is served
+// as fresh after it:
+//
+//	check := esitest.NewCompliance(t)
+//	check.Instrument(redisClient) // The Redis of Config.Redis or Config.CacheRedis
+//
+//	esi, _ := client.New(cfg)
+//	esi.SetHTTPClient(&http.Client{Transport: check.Transport(nil)})
+//	api := check.Getter(esi)
+//
+//	resp, err := api.Get(ctx, "/v1/markets/prices/")
+package esitest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+	"github.com/Sternrassler/eve-esi-client/pkg/client"
+	"github.com/Sternrassler/eve-esi-client/pkg/codec"
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultTolerance is the clock slack of Compliance checks. Expires headers
+// have a resolution of one second.
+const DefaultTolerance = time.Second
+
+// Getter is the part of *client.Client checked by Compliance.Getter.
+type Getter interface {
+	Get(ctx context.Context, endpoint string) (*http.Response, error)
+}
+
+// upstreamResponse is the last response ESI sent for a request URI.
+type upstreamResponse struct {
+	at      time.Time
+	expires time.Time // Zero without Expires header
+}
+
+// Compliance fails a test whose cache violates ESI's caching rules. Create
+// it with NewCompliance; it is safe for concurrent use.
+type Compliance struct {
+	t testing.TB
+
+	// Codec decodes cache entries (default codec.Std). Set it to the codec of
+	// Config.Codec before the first write.
+	Codec codec.Codec
+
+	// Tolerance is the clock slack allowed by the checks (default
+	// DefaultTolerance).
+	Tolerance time.Duration
+
+	mu       sync.Mutex
+	upstream map[string]upstreamResponse // By request URI
+}
+
+// NewCompliance returns a compliance check reporting to t.
+func NewCompliance(t testing.TB) *Compliance {
+	return &Compliance{t: t, Codec: codec.Std, Tolerance: DefaultTolerance, upstream: make(map[string]upstreamResponse)}
+}
+
+// Instrument checks every cache entry written to rdb: an entry must not
+// expire, in the entry or in Redis, before the Expires header it was
+// stored with.
+func (c *Compliance) Instrument(rdb *redis.Client) {
+	rdb.AddHook(complianceHook{c})
+}
+
+// Transport returns a RoundTripper recording the Expires of every ESI
+// response, for the checks of Getter. A nil next uses
+// http.DefaultTransport.
+func (c *Compliance) Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		resp, err := next.RoundTrip(req)
+		if err == nil {
+			c.recordUpstream(req.URL.RequestURI(), resp)
+		}
+		return resp, err
+	})
+}
+
+// Getter wraps g so every cache hit it returns is checked: a response
+// served from cache (client.CacheStatusHit) without contacting ESI must
+// not be past the Expires ESI last sent for the endpoint. Endpoints ESI was
+// not asked for through Transport are not checked.
+func (c *Compliance) Getter(g Getter) Getter {
+	return getterFunc(func(ctx context.Context, endpoint string) (*http.Response, error) {
+		start := time.Now()
+		resp, err := g.Get(ctx, endpoint)
+		if err == nil {
+			c.checkServed(endpoint, resp, start)
+		}
+		return resp, err
+	})
+}
+
+// recordUpstream notes the Expires of an ESI response to uri.
+func (c *Compliance) recordUpstream(uri string, resp *http.Response) {
+	record := upstreamResponse{at: time.Now()}
+	if expires, err := http.ParseTime(resp.Header.Get("Expires")); err == nil {
+		record.expires = expires
+	}
+
+	c.mu.Lock()
+	c.upstream[uri] = record
+	c.mu.Unlock()
+}
+
+// checkServed fails the test if resp, returned for endpoint by a call
+// started at start, is a cache hit served after the upstream Expires.
+func (c *Compliance) checkServed(endpoint string, resp *http.Response, start time.Time) {
+	if resp.Header.Get(client.CacheStatusHeader) != client.CacheStatusHit {
+		return
+	}
+
+	c.mu.Lock()
+	record, ok := c.upstream[endpoint]
+	c.mu.Unlock()
+	if !ok || record.expires.IsZero() || !record.at.Before(start) {
+		return // Unknown, or revalidated by this call
+	}
+
+	if late := time.Since(record.expires); late > c.Tolerance {
+		c.t.Helper()
+		c.t.Errorf("esitest: %s served from cache as fresh %s after its Expires (%s)", endpoint, late.Round(time.Millisecond), record.expires.Format(http.TimeFormat))
+	}
+}
+
+// checkWrite fails the test if the cache entry data, written to key with a
+// Redis TTL of ttl (0 for none), expires before its Expires header.
+func (c *Compliance) checkWrite(key string, data []byte, ttl time.Duration) {
+	if !strings.HasPrefix(key, "esi:") || strings.HasPrefix(key, cache.RedisKeyBodyPrefix) {
+		return
+	}
+	var entry cache.CacheEntry
+	if err := c.Codec.Unmarshal(data, &entry); err != nil || entry.Version == 0 {
+		return // Not a cache entry (rate limit state, captures, ...)
+	}
+	upstream, err := http.ParseTime(entry.Headers.Get("Expires"))
+	if err != nil {
+		return // Fallback or adaptive TTL, nothing to comply with
+	}
+
+	c.t.Helper()
+	if short := upstream.Sub(entry.Expires); short > c.Tolerance {
+		c.t.Errorf("esitest: %s stored to expire %s before its Expires (%s)", key, short.Round(time.Millisecond), upstream.Format(http.TimeFormat))
+	}
+	if ttl > 0 {
+		if short := time.Until(upstream) - ttl; short > c.Tolerance {
+			c.t.Errorf("esitest: %s stored with Redis TTL %s, %s before its Expires (%s)", key, ttl, short.Round(time.Millisecond), upstream.Format(http.TimeFormat))
+		}
+	}
+}
+
+// complianceHook passes the SET commands of a Redis client to checkWrite.
+type complianceHook struct {
+	c *Compliance
+}
+
+func (h complianceHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h complianceHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		err := next(ctx, cmd)
+		if err == nil {
+			h.check(cmd)
+		}
+		return err
+	}
+}
+
+func (h complianceHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		err := next(ctx, cmds)
+		for _, cmd := range cmds {
+			if cmd.Err() == nil {
+				h.check(cmd)
+			}
+		}
+		return err
+	}
+}
+
+// check inspects SET key value [EX s | PX ms] [NX] commands.
+func (h complianceHook) check(cmd redis.Cmder) {
+	args := cmd.Args()
+	if len(args) < 3 || !strings.EqualFold(fmt.Sprint(args[0]), "set") {
+		return
+	}
+	key, ok := args[1].(string)
+	if !ok {
+		return
+	}
+	var data []byte
+	switch v := args[2].(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return
+	}
+
+	var ttl time.Duration
+	for i := 3; i+1 < len(args); i++ {
+		n, ok := args[i+1].(int64)
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(fmt.Sprint(args[i])) {
+		case "ex":
+			ttl = time.Duration(n) * time.Second
+		case "px":
+			ttl = time.Duration(n) * time.Millisecond
+		}
+	}
+	h.c.checkWrite(key, data, ttl)
+}
+
+// roundTripperFunc adapts a function to http.RoundTripper.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// getterFunc adapts a function to Getter.
+type getterFunc func(ctx context.Context, endpoint string) (*http.Response, error)
+
+func (f getterFunc) Get(ctx context.Context, endpoint string) (*http.Response, error) {
+	return f(ctx, endpoint)
+}
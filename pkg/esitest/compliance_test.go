@@ -0,0 +1,134 @@
+package esitest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+	"github.com/Sternrassler/eve-esi-client/pkg/client"
+	"github.com/redis/go-redis/v9"
+)
+
+// recorder collects the failures a Compliance reports.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+// setCmd returns the SET command Manager.Set issues for entry.
+func setCmd(t *testing.T, key string, entry *cache.CacheEntry, ttl time.Duration) redis.Cmder {
+	t.Helper()
+	entry.Version = cache.EntryVersion
+	data, err := json.Marshal(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return redis.NewStatusCmd(context.Background(), "set", key, data, "px", ttl.Milliseconds())
+}
+
+func TestCompliance_Writes(t *testing.T) {
+	upstream := time.Now().Add(5 * time.Minute)
+	headers := http.Header{"Expires": {upstream.UTC().Format(http.TimeFormat)}}
+
+	tests := []struct {
+		name       string
+		key        string
+		expires    time.Time
+		ttl        time.Duration
+		wantErrors int
+	}{
+		{"until Expires", "esi:/v1/markets/prices/", upstream, 5 * time.Minute, 0},
+		{"past Expires", "esi:/v1/markets/prices/", upstream.Add(time.Hour), 2 * time.Hour, 0},
+		{"entry expires early", "esi:/v1/markets/prices/", upstream.Add(-time.Minute), 5 * time.Minute, 1},
+		{"Redis TTL too short", "esi:/v1/markets/prices/", upstream, time.Minute, 1},
+		{"both", "esi:/v1/markets/prices/", upstream.Add(-time.Minute), time.Minute, 2},
+		{"split body", cache.RedisKeyBodyPrefix + "esi:/v1/markets/prices/", upstream, time.Minute, 0},
+		{"other key", "ratelimit:state", upstream, time.Minute, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &recorder{TB: t}
+			hook := complianceHook{NewCompliance(rec)}
+			process := hook.ProcessHook(func(ctx context.Context, cmd redis.Cmder) error { return nil })
+
+			entry := &cache.CacheEntry{Data: []byte(`[]`), Expires: tt.expires, Headers: headers}
+			if err := process(context.Background(), setCmd(t, tt.key, entry, tt.ttl)); err != nil {
+				t.Fatal(err)
+			}
+			if len(rec.errors) != tt.wantErrors {
+				t.Errorf("failures = %q, want %d", rec.errors, tt.wantErrors)
+			}
+		})
+	}
+}
+
+// fakeCache serves the body from "cache" after the first request.
+type fakeCache struct {
+	http   *http.Client
+	server string
+	cached bool
+}
+
+func (f *fakeCache) Get(ctx context.Context, endpoint string) (*http.Response, error) {
+	if f.cached {
+		resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader("[]"))}
+		resp.Header.Set(client.CacheStatusHeader, client.CacheStatusHit)
+		return resp, nil
+	}
+	f.cached = true
+	resp, err := f.http.Get(f.server + endpoint)
+	if err == nil {
+		resp.Header.Set(client.CacheStatusHeader, client.CacheStatusMiss)
+	}
+	return resp, err
+}
+
+func TestCompliance_Served(t *testing.T) {
+	expires := time.Now().Add(-time.Minute)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Expires", expires.UTC().Format(http.TimeFormat))
+		_, _ = w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+
+	rec := &recorder{TB: t}
+	check := NewCompliance(rec)
+	esi := check.Getter(&fakeCache{http: &http.Client{Transport: check.Transport(nil)}, server: server.URL})
+
+	for range 2 {
+		resp, err := esi.Get(context.Background(), "/v1/markets/prices/")
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		resp.Body.Close()
+	}
+	if len(rec.errors) != 1 || !strings.Contains(rec.errors[0], "/v1/markets/prices/ served from cache as fresh") {
+		t.Errorf("failures = %q, want the expired hit", rec.errors)
+	}
+
+	// Fresh hits pass
+	rec.errors = nil
+	expires = time.Now().Add(time.Minute)
+	fresh := check.Getter(&fakeCache{http: &http.Client{Transport: check.Transport(nil)}, server: server.URL})
+	for range 2 {
+		resp, _ := fresh.Get(context.Background(), "/v1/status/")
+		resp.Body.Close()
+	}
+	if len(rec.errors) != 0 {
+		t.Errorf("failures = %q for a fresh hit", rec.errors)
+	}
+}
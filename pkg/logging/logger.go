@@ -117,6 +117,7 @@ func NewLogger(component string) zerolog.Logger {
 //
 // Context Fields:
 //   - endpoint: ESI endpoint path
+//   - request_id: Caller's request ID (client.WithRequestID), also sent to ESI as X-Request-ID
 //   - status_code: HTTP status code
 //   - duration: Request duration
 //   - error_class: Error classification (client, server, rate_limit, network)
@@ -0,0 +1,271 @@
+package market
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/pagination"
+	"github.com/Sternrassler/eve-esi-client/pkg/pool"
+	"github.com/rs/zerolog/log"
+)
+
+// CrawlOptions configures a multi-region crawl.
+type CrawlOptions struct {
+	// Fetcher fetches single pages (typically *client.Client). Required.
+	Fetcher pagination.PageFetcher
+
+	// MaxConcurrency is the size of the shared worker pool (default: 10).
+	MaxConcurrency int
+
+	// Timeout per page fetch (default: 15s).
+	Timeout time.Duration
+
+	// Priority orders regions; higher values are crawled first.
+	// Regions without an entry have priority 0 and keep their input order.
+	Priority map[int]int
+
+	// OnProgress is called after every page of a region and once as soon as
+	// the region is done. Calls are serialized.
+	OnProgress func(RegionProgress)
+}
+
+// RegionProgress reports the crawl progress of a single region.
+type RegionProgress struct {
+	RegionID     int
+	PagesFetched int
+	TotalPages   int
+	Done         bool
+	Err          error
+}
+
+// RegionSnapshot is the consolidated order book of a region.
+type RegionSnapshot struct {
+	RegionID  int
+	Orders    []Order
+	Pages     int
+	FetchedAt time.Time
+	Duration  time.Duration
+
+	// Err is set if any page of the region failed. Orders then contain
+	// only the pages fetched successfully.
+	Err error
+}
+
+// ordersEndpoint returns the market orders endpoint of a region.
+func ordersEndpoint(regionID int) string {
+	return fmt.Sprintf("/v1/markets/%d/orders/", regionID)
+}
+
+// pageJob is a single page fetch of a region.
+type pageJob struct {
+	region int // index into the crawl's region state
+	page   int
+}
+
+// pageDone is the outcome of a pageJob.
+type pageDone struct {
+	job        pageJob
+	orders     []Order
+	totalPages int
+	err        error
+}
+
+// regionState tracks an in-progress region.
+type regionState struct {
+	snapshot  RegionSnapshot
+	start     time.Time
+	fetched   int
+	attempted int  // Pages fetched or failed
+	done      bool // Final progress reported
+}
+
+// CrawlRegions fetches the market orders of all regions through one shared
+// worker pool. Regions are processed in priority order, and their remaining
+// pages are interleaved round-robin so every region progresses fairly.
+//
+// Snapshots are returned in priority order. The returned error is only set if
+// the options are invalid or ctx was cancelled; per-region failures are
+// reported in RegionSnapshot.Err.
+func CrawlRegions(ctx context.Context, regionIDs []int, opts CrawlOptions) ([]RegionSnapshot, error) {
+	if opts.Fetcher == nil {
+		return nil, fmt.Errorf("crawl options: fetcher is required")
+	}
+	if opts.MaxConcurrency <= 0 {
+		opts.MaxConcurrency = 10
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 15 * time.Second
+	}
+
+	// All regions are one operation for fetchers with per-operation state
+	ctx = pagination.ScopeOperation(ctx, opts.Fetcher)
+
+	regions := prioritize(regionIDs, opts.Priority)
+	states := make([]*regionState, len(regions))
+	for i, regionID := range regions {
+		states[i] = &regionState{
+			snapshot: RegionSnapshot{RegionID: regionID},
+			start:    time.Now(),
+		}
+	}
+
+	log.Info().
+		Ints("regions", regions).
+		Int("workers", opts.MaxConcurrency).
+		Msg("Starting multi-region crawl")
+
+	// Phase 1: first page of every region to learn the page counts
+	firstPages := make([]pageJob, len(states))
+	for i := range states {
+		firstPages[i] = pageJob{region: i, page: 1}
+	}
+	runJobs(ctx, firstPages, opts, states, func(done pageDone) {
+		state := states[done.job.region]
+		state.snapshot.Pages = done.totalPages
+		recordPage(state, done, opts.OnProgress)
+	})
+
+	// Phase 2: remaining pages, interleaved round-robin in priority order
+	runJobs(ctx, interleave(states), opts, states, func(done pageDone) {
+		recordPage(states[done.job.region], done, opts.OnProgress)
+	})
+
+	// Regions cut short by cancellation are done now
+	snapshots := make([]RegionSnapshot, len(states))
+	for i, state := range states {
+		finishRegion(state, opts.OnProgress)
+		snapshots[i] = state.snapshot
+	}
+
+	if err := ctx.Err(); err != nil {
+		return snapshots, fmt.Errorf("crawl cancelled: %w", err)
+	}
+
+	log.Info().
+		Int("regions", len(snapshots)).
+		Msg("Multi-region crawl complete")
+
+	return snapshots, nil
+}
+
+// prioritize returns the region IDs ordered by descending priority.
+// Equal priorities keep their input order.
+func prioritize(regionIDs []int, priority map[int]int) []int {
+	regions := make([]int, len(regionIDs))
+	copy(regions, regionIDs)
+	sort.SliceStable(regions, func(i, j int) bool {
+		return priority[regions[i]] > priority[regions[j]]
+	})
+	return regions
+}
+
+// interleave builds the page 2..N jobs of all regions round-robin:
+// page 2 of every region, then page 3 of every region, and so on.
+func interleave(states []*regionState) []pageJob {
+	maxPages := 0
+	for _, state := range states {
+		if state.snapshot.Err == nil && state.snapshot.Pages > maxPages {
+			maxPages = state.snapshot.Pages
+		}
+	}
+
+	var jobs []pageJob
+	for page := 2; page <= maxPages; page++ {
+		for i, state := range states {
+			if state.snapshot.Err == nil && page <= state.snapshot.Pages {
+				jobs = append(jobs, pageJob{region: i, page: page})
+			}
+		}
+	}
+	return jobs
+}
+
+// recordPage merges a page result into its region and reports progress.
+func recordPage(state *regionState, done pageDone, onProgress func(RegionProgress)) {
+	if done.err != nil {
+		if state.snapshot.Err == nil {
+			state.snapshot.Err = fmt.Errorf("page %d: %w", done.job.page, done.err)
+		}
+		log.Warn().
+			Err(done.err).
+			Int("region_id", state.snapshot.RegionID).
+			Int("page", done.job.page).
+			Msg("Region page fetch failed")
+	} else {
+		state.snapshot.Orders = append(state.snapshot.Orders, done.orders...)
+		state.fetched++
+	}
+	state.attempted++
+
+	if onProgress != nil {
+		onProgress(RegionProgress{
+			RegionID:     state.snapshot.RegionID,
+			PagesFetched: state.fetched,
+			TotalPages:   state.snapshot.Pages,
+			Err:          done.err,
+		})
+	}
+
+	// A failed first page leaves no pages to fetch
+	if state.attempted >= state.snapshot.Pages {
+		finishRegion(state, onProgress)
+	}
+}
+
+// finishRegion completes the snapshot of a region and reports its final
+// progress, once.
+func finishRegion(state *regionState, onProgress func(RegionProgress)) {
+	if state.done {
+		return
+	}
+	state.done = true
+	state.snapshot.Duration = time.Since(state.start)
+	state.snapshot.FetchedAt = time.Now()
+
+	if onProgress != nil {
+		onProgress(RegionProgress{
+			RegionID:     state.snapshot.RegionID,
+			PagesFetched: state.fetched,
+			TotalPages:   state.snapshot.Pages,
+			Done:         true,
+			Err:          state.snapshot.Err,
+		})
+	}
+}
+
+// runJobs executes jobs in order through a worker pool and hands each result
+// to handle. handle calls are serialized.
+func runJobs(ctx context.Context, jobs []pageJob, opts CrawlOptions, states []*regionState, handle func(pageDone)) {
+	var mu sync.Mutex
+	pool.Run(ctx, len(jobs), pool.Config{Workers: opts.MaxConcurrency}, func(ctx context.Context, i int) (struct{}, error) {
+		job := jobs[i]
+		done := fetchPage(ctx, opts, states[job.region].snapshot.RegionID, job)
+
+		mu.Lock()
+		defer mu.Unlock()
+		handle(done)
+		return struct{}{}, nil
+	})
+}
+
+// fetchPage fetches and decodes a single orders page.
+func fetchPage(ctx context.Context, opts CrawlOptions, regionID int, job pageJob) pageDone {
+	pageCtx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	data, totalPages, err := opts.Fetcher.FetchPage(pageCtx, ordersEndpoint(regionID), job.page)
+	if err != nil {
+		return pageDone{job: job, err: err}
+	}
+
+	var orders []Order
+	if err := json.Unmarshal(data, &orders); err != nil {
+		return pageDone{job: job, err: fmt.Errorf("decode orders: %w", err)}
+	}
+
+	return pageDone{job: job, orders: orders, totalPages: totalPages}
+}
@@ -0,0 +1,155 @@
+package market
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeFetcher serves generated order pages per region.
+type fakeFetcher struct {
+	mu    sync.Mutex
+	pages map[int]int // region -> total pages
+	fail  map[int]int // region -> failing page
+	calls []string
+}
+
+func (f *fakeFetcher) FetchPage(ctx context.Context, endpoint string, pageNum int) ([]byte, int, error) {
+	var regionID int
+	if _, err := fmt.Sscanf(endpoint, "/v1/markets/%d/orders/", &regionID); err != nil {
+		return nil, 0, err
+	}
+
+	f.mu.Lock()
+	f.calls = append(f.calls, fmt.Sprintf("%d:%d", regionID, pageNum))
+	f.mu.Unlock()
+
+	if f.fail[regionID] == pageNum {
+		return nil, 0, errors.New("boom")
+	}
+
+	orderID := int64(regionID)*1000 + int64(pageNum)
+	return []byte(fmt.Sprintf(`[{"order_id": %d, "type_id": 34, "price": 5.5}]`, orderID)), f.pages[regionID], nil
+}
+
+func TestCrawlRegions_ConsolidatesOrders(t *testing.T) {
+	fetcher := &fakeFetcher{pages: map[int]int{1: 3, 2: 1}}
+
+	snapshots, err := CrawlRegions(context.Background(), []int{1, 2}, CrawlOptions{Fetcher: fetcher})
+	if err != nil {
+		t.Fatalf("CrawlRegions() error = %v", err)
+	}
+
+	if len(snapshots) != 2 {
+		t.Fatalf("len(snapshots) = %d, want 2", len(snapshots))
+	}
+	if got := len(snapshots[0].Orders); got != 3 {
+		t.Errorf("region 1 orders = %d, want 3", got)
+	}
+	if got := len(snapshots[1].Orders); got != 1 {
+		t.Errorf("region 2 orders = %d, want 1", got)
+	}
+	for _, s := range snapshots {
+		if s.Err != nil {
+			t.Errorf("region %d error = %v", s.RegionID, s.Err)
+		}
+	}
+}
+
+func TestCrawlRegions_PriorityAndInterleaving(t *testing.T) {
+	fetcher := &fakeFetcher{pages: map[int]int{1: 3, 2: 3}}
+
+	// Single worker makes the fetch order deterministic
+	snapshots, err := CrawlRegions(context.Background(), []int{1, 2}, CrawlOptions{
+		Fetcher:        fetcher,
+		MaxConcurrency: 1,
+		Priority:       map[int]int{2: 10},
+	})
+	if err != nil {
+		t.Fatalf("CrawlRegions() error = %v", err)
+	}
+
+	if snapshots[0].RegionID != 2 {
+		t.Errorf("first snapshot region = %d, want 2 (highest priority)", snapshots[0].RegionID)
+	}
+
+	want := "2:1,1:1,2:2,1:2,2:3,1:3"
+	if got := strings.Join(fetcher.calls, ","); got != want {
+		t.Errorf("fetch order = %s, want %s", got, want)
+	}
+}
+
+func TestCrawlRegions_RegionFailureIsIsolated(t *testing.T) {
+	fetcher := &fakeFetcher{
+		pages: map[int]int{1: 2, 2: 2},
+		fail:  map[int]int{1: 2},
+	}
+
+	var mu sync.Mutex
+	done := map[int]RegionProgress{}
+	snapshots, err := CrawlRegions(context.Background(), []int{1, 2}, CrawlOptions{
+		Fetcher: fetcher,
+		OnProgress: func(p RegionProgress) {
+			mu.Lock()
+			defer mu.Unlock()
+			if p.Done {
+				done[p.RegionID] = p
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("CrawlRegions() error = %v", err)
+	}
+
+	if snapshots[0].Err == nil {
+		t.Error("region 1 should report the failed page")
+	}
+	if len(snapshots[0].Orders) != 1 {
+		t.Errorf("region 1 orders = %d, want 1 (partial)", len(snapshots[0].Orders))
+	}
+	if snapshots[1].Err != nil || len(snapshots[1].Orders) != 2 {
+		t.Errorf("region 2 = %d orders, err %v; want 2 orders, no error", len(snapshots[1].Orders), snapshots[1].Err)
+	}
+
+	if p := done[2]; p.PagesFetched != 2 || p.TotalPages != 2 {
+		t.Errorf("region 2 final progress = %+v, want 2/2", p)
+	}
+	if p := done[1]; p.Err == nil {
+		t.Error("region 1 final progress should carry the error")
+	}
+}
+
+func TestCrawlRegions_ReportsRegionsAsTheyFinish(t *testing.T) {
+	fetcher := &fakeFetcher{pages: map[int]int{1: 3, 2: 1}}
+
+	var events []string
+	_, err := CrawlRegions(context.Background(), []int{1, 2}, CrawlOptions{
+		Fetcher:        fetcher,
+		MaxConcurrency: 1,
+		OnProgress: func(p RegionProgress) {
+			event := fmt.Sprintf("%d:%d/%d", p.RegionID, p.PagesFetched, p.TotalPages)
+			if p.Done {
+				event += " done"
+			}
+			events = append(events, event)
+		},
+	})
+	if err != nil {
+		t.Fatalf("CrawlRegions() error = %v", err)
+	}
+
+	// Region 2 is done before the remaining pages of region 1 are fetched
+	want := "1:1/3,2:1/1,2:1/1 done,1:2/3,1:3/3,1:3/3 done"
+	if got := strings.Join(events, ","); got != want {
+		t.Errorf("progress = %s, want %s", got, want)
+	}
+}
+
+func TestCrawlRegions_RequiresFetcher(t *testing.T) {
+	if _, err := CrawlRegions(context.Background(), []int{1}, CrawlOptions{}); err == nil {
+		t.Error("Expected error without fetcher")
+	}
+}
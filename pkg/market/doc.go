@@ -0,0 +1,28 @@
+// Package market provides high-level market data crawling on top of the ESI
+// client.
+//
+// CrawlRegions fetches the order books of several regions through a single
+// worker pool. Regions are ordered by priority, and their pages are
+// interleaved round-robin so a large region (The Forge) cannot starve small
+// ones:
+//
+//	snapshots, err := market.CrawlRegions(ctx, []int{10000002, 10000043}, market.CrawlOptions{
+//		Fetcher:  esiClient,
+//		Priority: map[int]int{10000002: 10},
+//		OnProgress: func(p market.RegionProgress) {
+//			fmt.Printf("region %d: %d/%d\n", p.RegionID, p.PagesFetched, p.TotalPages)
+//		},
+//	})
+//
+// Each region yields a RegionSnapshot with all orders consolidated. A failing
+// region does not abort the others; its error is reported in the snapshot.
+//
+// Snapshots can be handed to other pipelines through a Serializer: JSON, or
+// Protobuf, which emits esi.market.v1.RegionSnapshot messages as defined in
+// market.proto. Consumers generate code from that file;
+// UnmarshalSnapshotProto decodes messages in Go without generated code:
+//
+//	data, err := market.Protobuf.MarshalSnapshot(snapshots[0])
+//
+// See ADR-008 for pagination architecture decisions.
+package market
@@ -0,0 +1,19 @@
+package market
+
+import "time"
+
+// Order represents an ESI market order (GET /v1/markets/{region_id}/orders/).
+type Order struct {
+	OrderID      int64     `json:"order_id"`
+	TypeID       int       `json:"type_id"`
+	LocationID   int64     `json:"location_id"`
+	SystemID     int       `json:"system_id"`
+	VolumeTotal  int       `json:"volume_total"`
+	VolumeRemain int       `json:"volume_remain"`
+	MinVolume    int       `json:"min_volume"`
+	Price        float64   `json:"price"`
+	IsBuyOrder   bool      `json:"is_buy_order"`
+	Duration     int       `json:"duration"`
+	Issued       time.Time `json:"issued"`
+	Range        string    `json:"range"`
+}
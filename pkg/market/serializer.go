@@ -0,0 +1,324 @@
+package market
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/codec"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Serializer encodes crawl results for downstream pipelines.
+type Serializer interface {
+	// ContentType is the media type of the encoded snapshots.
+	ContentType() string
+
+	// MarshalSnapshot encodes a region snapshot.
+	MarshalSnapshot(s RegionSnapshot) ([]byte, error)
+}
+
+var (
+	// JSON encodes snapshots as JSON with encoding/json.
+	JSON Serializer = JSONSerializer{}
+
+	// Protobuf encodes snapshots as esi.market.v1.RegionSnapshot messages
+	// (see market.proto).
+	Protobuf Serializer = protobufSerializer{}
+)
+
+// JSONSerializer encodes snapshots as JSON with the snake_case field names
+// of ESI.
+type JSONSerializer struct {
+	// Codec is the JSON implementation (default: encoding/json).
+	Codec codec.Codec
+}
+
+// snapshotJSON is the JSON form of RegionSnapshot.
+type snapshotJSON struct {
+	RegionID   int     `json:"region_id"`
+	Orders     []Order `json:"orders"`
+	Pages      int     `json:"pages"`
+	FetchedAt  string  `json:"fetched_at"`
+	DurationMS int64   `json:"duration_ms"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// ContentType implements Serializer.
+func (JSONSerializer) ContentType() string { return "application/json" }
+
+// MarshalSnapshot implements Serializer.
+func (j JSONSerializer) MarshalSnapshot(s RegionSnapshot) ([]byte, error) {
+	v := snapshotJSON{
+		RegionID:   s.RegionID,
+		Orders:     s.Orders,
+		Pages:      s.Pages,
+		FetchedAt:  s.FetchedAt.UTC().Format(time.RFC3339),
+		DurationMS: s.Duration.Milliseconds(),
+	}
+	if v.Orders == nil {
+		v.Orders = []Order{}
+	}
+	if s.Err != nil {
+		v.Error = s.Err.Error()
+	}
+	return codec.OrStd(j.Codec).Marshal(v)
+}
+
+// Field numbers of market.proto.
+const (
+	orderFieldOrderID      protowire.Number = 1
+	orderFieldTypeID       protowire.Number = 2
+	orderFieldLocationID   protowire.Number = 3
+	orderFieldSystemID     protowire.Number = 4
+	orderFieldVolumeTotal  protowire.Number = 5
+	orderFieldVolumeRemain protowire.Number = 6
+	orderFieldMinVolume    protowire.Number = 7
+	orderFieldPrice        protowire.Number = 8
+	orderFieldIsBuyOrder   protowire.Number = 9
+	orderFieldDuration     protowire.Number = 10
+	orderFieldIssued       protowire.Number = 11
+	orderFieldRange        protowire.Number = 12
+
+	snapshotFieldRegionID  protowire.Number = 1
+	snapshotFieldOrders    protowire.Number = 2
+	snapshotFieldPages     protowire.Number = 3
+	snapshotFieldFetchedAt protowire.Number = 4
+	snapshotFieldDuration  protowire.Number = 5
+	snapshotFieldError     protowire.Number = 6
+
+	// google.protobuf.Timestamp and google.protobuf.Duration
+	wktFieldSeconds protowire.Number = 1
+	wktFieldNanos   protowire.Number = 2
+)
+
+// protobufSerializer encodes the messages of market.proto with protowire,
+// so the library needs no generated code.
+type protobufSerializer struct{}
+
+// ContentType implements Serializer.
+func (protobufSerializer) ContentType() string { return "application/x-protobuf" }
+
+// MarshalSnapshot implements Serializer.
+func (protobufSerializer) MarshalSnapshot(s RegionSnapshot) ([]byte, error) {
+	var b []byte
+	b = appendVarint(b, snapshotFieldRegionID, int64(s.RegionID))
+	for i := range s.Orders {
+		b = protowire.AppendTag(b, snapshotFieldOrders, protowire.BytesType)
+		b = protowire.AppendBytes(b, appendOrder(nil, &s.Orders[i]))
+	}
+	b = appendVarint(b, snapshotFieldPages, int64(s.Pages))
+	b = appendTimestamp(b, snapshotFieldFetchedAt, s.FetchedAt)
+	if s.Duration != 0 {
+		b = appendSecondsNanos(b, snapshotFieldDuration, int64(s.Duration/time.Second), int64(s.Duration%time.Second))
+	}
+	if s.Err != nil {
+		b = appendString(b, snapshotFieldError, s.Err.Error())
+	}
+	return b, nil
+}
+
+// appendOrder appends the esi.market.v1.Order encoding of o.
+func appendOrder(b []byte, o *Order) []byte {
+	b = appendVarint(b, orderFieldOrderID, o.OrderID)
+	b = appendVarint(b, orderFieldTypeID, int64(o.TypeID))
+	b = appendVarint(b, orderFieldLocationID, o.LocationID)
+	b = appendVarint(b, orderFieldSystemID, int64(o.SystemID))
+	b = appendVarint(b, orderFieldVolumeTotal, int64(o.VolumeTotal))
+	b = appendVarint(b, orderFieldVolumeRemain, int64(o.VolumeRemain))
+	b = appendVarint(b, orderFieldMinVolume, int64(o.MinVolume))
+	if o.Price != 0 {
+		b = protowire.AppendTag(b, orderFieldPrice, protowire.Fixed64Type)
+		b = protowire.AppendFixed64(b, math.Float64bits(o.Price))
+	}
+	if o.IsBuyOrder {
+		b = appendVarint(b, orderFieldIsBuyOrder, 1)
+	}
+	b = appendVarint(b, orderFieldDuration, int64(o.Duration))
+	b = appendTimestamp(b, orderFieldIssued, o.Issued)
+	b = appendString(b, orderFieldRange, o.Range)
+	return b
+}
+
+// appendVarint appends an int32, int64 or bool field, omitting the proto3
+// default 0. Negative values are sign-extended to 64 bits, as for int32.
+func appendVarint(b []byte, num protowire.Number, v int64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(v))
+}
+
+// appendString appends a string field, omitting the empty string.
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+// appendTimestamp appends a google.protobuf.Timestamp field, omitting the
+// zero time.
+func appendTimestamp(b []byte, num protowire.Number, t time.Time) []byte {
+	if t.IsZero() {
+		return b
+	}
+	return appendSecondsNanos(b, num, t.Unix(), int64(t.Nanosecond()))
+}
+
+// appendSecondsNanos appends a google.protobuf.Timestamp or Duration field.
+func appendSecondsNanos(b []byte, num protowire.Number, seconds, nanos int64) []byte {
+	var msg []byte
+	msg = appendVarint(msg, wktFieldSeconds, seconds)
+	msg = appendVarint(msg, wktFieldNanos, nanos)
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, msg)
+}
+
+// UnmarshalSnapshotProto decodes an esi.market.v1.RegionSnapshot message, for
+// Go consumers that don't generate code from market.proto. Unknown fields are
+// skipped, so messages of newer schema versions decode.
+func UnmarshalSnapshotProto(data []byte) (RegionSnapshot, error) {
+	var s RegionSnapshot
+	err := consumeFields(data, func(num protowire.Number, typ protowire.Type, v uint64, raw []byte) error {
+		switch {
+		case num == snapshotFieldRegionID && typ == protowire.VarintType:
+			s.RegionID = int(int32(v))
+		case num == snapshotFieldOrders && typ == protowire.BytesType:
+			o, err := unmarshalOrder(raw)
+			if err != nil {
+				return err
+			}
+			s.Orders = append(s.Orders, o)
+		case num == snapshotFieldPages && typ == protowire.VarintType:
+			s.Pages = int(int32(v))
+		case num == snapshotFieldFetchedAt && typ == protowire.BytesType:
+			seconds, nanos, err := unmarshalSecondsNanos(raw)
+			if err != nil {
+				return err
+			}
+			s.FetchedAt = time.Unix(seconds, nanos).UTC()
+		case num == snapshotFieldDuration && typ == protowire.BytesType:
+			seconds, nanos, err := unmarshalSecondsNanos(raw)
+			if err != nil {
+				return err
+			}
+			s.Duration = time.Duration(seconds)*time.Second + time.Duration(nanos)
+		case num == snapshotFieldError && typ == protowire.BytesType:
+			s.Err = errors.New(string(raw))
+		}
+		return nil
+	})
+	if err != nil {
+		return RegionSnapshot{}, fmt.Errorf("unmarshal region snapshot: %w", err)
+	}
+	return s, nil
+}
+
+// unmarshalOrder decodes an esi.market.v1.Order message.
+func unmarshalOrder(data []byte) (Order, error) {
+	var o Order
+	err := consumeFields(data, func(num protowire.Number, typ protowire.Type, v uint64, raw []byte) error {
+		switch typ {
+		case protowire.VarintType:
+			switch num {
+			case orderFieldOrderID:
+				o.OrderID = int64(v)
+			case orderFieldTypeID:
+				o.TypeID = int(int32(v))
+			case orderFieldLocationID:
+				o.LocationID = int64(v)
+			case orderFieldSystemID:
+				o.SystemID = int(int32(v))
+			case orderFieldVolumeTotal:
+				o.VolumeTotal = int(int32(v))
+			case orderFieldVolumeRemain:
+				o.VolumeRemain = int(int32(v))
+			case orderFieldMinVolume:
+				o.MinVolume = int(int32(v))
+			case orderFieldIsBuyOrder:
+				o.IsBuyOrder = v != 0
+			case orderFieldDuration:
+				o.Duration = int(int32(v))
+			}
+		case protowire.Fixed64Type:
+			if num == orderFieldPrice {
+				o.Price = math.Float64frombits(v)
+			}
+		case protowire.BytesType:
+			switch num {
+			case orderFieldIssued:
+				seconds, nanos, err := unmarshalSecondsNanos(raw)
+				if err != nil {
+					return err
+				}
+				o.Issued = time.Unix(seconds, nanos).UTC()
+			case orderFieldRange:
+				o.Range = string(raw)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return Order{}, fmt.Errorf("order: %w", err)
+	}
+	return o, nil
+}
+
+// unmarshalSecondsNanos decodes a google.protobuf.Timestamp or Duration.
+func unmarshalSecondsNanos(data []byte) (seconds, nanos int64, err error) {
+	err = consumeFields(data, func(num protowire.Number, typ protowire.Type, v uint64, _ []byte) error {
+		if typ != protowire.VarintType {
+			return nil
+		}
+		switch num {
+		case wktFieldSeconds:
+			seconds = int64(v)
+		case wktFieldNanos:
+			nanos = int64(int32(v))
+		}
+		return nil
+	})
+	return seconds, nanos, err
+}
+
+// consumeFields calls fn for every field of a message: v holds varint and
+// fixed values, raw the content of length-delimited ones.
+func consumeFields(data []byte, fn func(num protowire.Number, typ protowire.Type, v uint64, raw []byte) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		var v uint64
+		var raw []byte
+		switch typ {
+		case protowire.VarintType:
+			v, n = protowire.ConsumeVarint(data)
+		case protowire.Fixed64Type:
+			v, n = protowire.ConsumeFixed64(data)
+		case protowire.Fixed32Type:
+			var v32 uint32
+			v32, n = protowire.ConsumeFixed32(data)
+			v = uint64(v32)
+		case protowire.BytesType:
+			raw, n = protowire.ConsumeBytes(data)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		if err := fn(num, typ, v, raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package market
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func testSnapshot() RegionSnapshot {
+	return RegionSnapshot{
+		RegionID: 10000002,
+		Orders: []Order{
+			{
+				OrderID: 6000000001, TypeID: 34, LocationID: 60003760, SystemID: 30000142,
+				VolumeTotal: 1000, VolumeRemain: 250, MinVolume: 1, Price: 5.37, IsBuyOrder: true,
+				Duration: 90, Issued: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), Range: "region",
+			},
+			{OrderID: 6000000002, TypeID: 35, Price: -1, Range: "station"},
+		},
+		Pages:     3,
+		FetchedAt: time.Date(2026, 1, 2, 12, 0, 0, 500, time.UTC),
+		Duration:  1500 * time.Millisecond,
+		Err:       errors.New("page 3: boom"),
+	}
+}
+
+func TestProtobuf_RoundTrip(t *testing.T) {
+	want := testSnapshot()
+	data, err := Protobuf.MarshalSnapshot(want)
+	if err != nil {
+		t.Fatalf("MarshalSnapshot() error = %v", err)
+	}
+
+	got, err := UnmarshalSnapshotProto(data)
+	if err != nil {
+		t.Fatalf("UnmarshalSnapshotProto() error = %v", err)
+	}
+	if got.Err == nil || got.Err.Error() != want.Err.Error() {
+		t.Errorf("Err = %v, want %v", got.Err, want.Err)
+	}
+	got.Err, want.Err = nil, nil
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip mismatch:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestProtobuf_WireFormat(t *testing.T) {
+	data, err := Protobuf.MarshalSnapshot(RegionSnapshot{
+		RegionID: 1,
+		Orders:   []Order{{OrderID: 1, TypeID: 34}},
+	})
+	if err != nil {
+		t.Fatalf("MarshalSnapshot() error = %v", err)
+	}
+
+	// region_id = 1; orders { order_id = 1; type_id = 34 }; defaults omitted
+	want := []byte{0x08, 0x01, 0x12, 0x04, 0x08, 0x01, 0x10, 0x22}
+	if !bytes.Equal(data, want) {
+		t.Errorf("MarshalSnapshot() = % x, want % x", data, want)
+	}
+}
+
+func TestUnmarshalSnapshotProto_SkipsUnknownFields(t *testing.T) {
+	data, _ := Protobuf.MarshalSnapshot(RegionSnapshot{RegionID: 7})
+	data = protowire.AppendTag(data, 99, protowire.BytesType)
+	data = protowire.AppendString(data, "from a newer schema")
+
+	got, err := UnmarshalSnapshotProto(data)
+	if err != nil || got.RegionID != 7 {
+		t.Errorf("UnmarshalSnapshotProto() = %+v, %v, want region 7", got, err)
+	}
+
+	if _, err := UnmarshalSnapshotProto([]byte{0x12, 0x05, 0x08}); err == nil {
+		t.Error("UnmarshalSnapshotProto(truncated) error = nil, want error")
+	}
+}
+
+func TestProtoSchemaInSync(t *testing.T) {
+	schema, err := os.ReadFile("market.proto")
+	if err != nil {
+		t.Fatalf("read market.proto: %v", err)
+	}
+
+	want := map[string]map[string]protowire.Number{
+		"Order": {
+			"order_id": orderFieldOrderID, "type_id": orderFieldTypeID, "location_id": orderFieldLocationID,
+			"system_id": orderFieldSystemID, "volume_total": orderFieldVolumeTotal, "volume_remain": orderFieldVolumeRemain,
+			"min_volume": orderFieldMinVolume, "price": orderFieldPrice, "is_buy_order": orderFieldIsBuyOrder,
+			"duration": orderFieldDuration, "issued": orderFieldIssued, "range": orderFieldRange,
+		},
+		"RegionSnapshot": {
+			"region_id": snapshotFieldRegionID, "orders": snapshotFieldOrders, "pages": snapshotFieldPages,
+			"fetched_at": snapshotFieldFetchedAt, "duration": snapshotFieldDuration, "error": snapshotFieldError,
+		},
+	}
+
+	messages := regexp.MustCompile(`(?s)message (\w+) \{(.*?)\n\}`).FindAllSubmatch(schema, -1)
+	field := regexp.MustCompile(`(\w+) = (\d+);`)
+	got := make(map[string]map[string]protowire.Number)
+	for _, m := range messages {
+		fields := make(map[string]protowire.Number)
+		for _, f := range field.FindAllSubmatch(m[2], -1) {
+			num, _ := strconv.Atoi(string(f[2]))
+			fields[string(f[1])] = protowire.Number(num)
+		}
+		got[string(m[1])] = fields
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("market.proto fields = %v, encoder uses %v", got, want)
+	}
+}
+
+func TestJSONSerializer(t *testing.T) {
+	data, err := JSON.MarshalSnapshot(testSnapshot())
+	if err != nil {
+		t.Fatalf("MarshalSnapshot() error = %v", err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	for key, want := range map[string]any{
+		"region_id":   float64(10000002),
+		"pages":       float64(3),
+		"fetched_at":  "2026-01-02T12:00:00Z",
+		"duration_ms": float64(1500),
+		"error":       "page 3: boom",
+	} {
+		if got[key] != want {
+			t.Errorf("%s = %v, want %v", key, got[key], want)
+		}
+	}
+	if orders, _ := got["orders"].([]any); len(orders) != 2 {
+		t.Errorf("orders = %v, want 2 orders", got["orders"])
+	}
+	if JSON.ContentType() != "application/json" || Protobuf.ContentType() != "application/x-protobuf" {
+		t.Error("unexpected content types")
+	}
+}
@@ -3,8 +3,12 @@
 // to maintain modularity and avoid circular dependencies.
 //
 // This package provides documentation and reference for all available metrics.
+// The Grafana dashboard and Prometheus alert rules in docs/monitoring are
+// generated from the metric definitions (go generate ./pkg/metrics).
 package metrics
 
+//go:generate go run ../../internal/tools/dashgen -root ../..
+
 import (
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -20,24 +24,75 @@ var Registry = prometheus.DefaultRegisterer
 //   - esi_rate_limit_blocks_total (Counter): Requests blocked due to critical error limit
 //   - esi_rate_limit_throttles_total (Counter): Requests throttled due to warning error limit
 //   - esi_rate_limit_resets_total (Counter): Number of error limit resets detected
+//   - esi_error_budget_minutes_to_critical (Gauge): Forecast minutes until the critical threshold (+Inf if the window resets first)
+//   - esi_rate_limit_unconfirmed_errors (Gauge): Error responses deducted locally since the last header update
+//   - esi_rate_limit_stale_headers_total (Counter): Out-of-order header updates that would have raised the estimate
+//   - esi_rate_limit_shared_blocks_total (Counter): 420 responses published as a block shared by all instances
+//   - esi_quota_used{quota} (Gauge): Requests counted against a soft quota in the current window
+//   - esi_quota_limit{quota} (Gauge): Configured soft quota limit
+//   - esi_quota_exceeded_total{quota, enforced} (Counter): Requests beyond a soft quota
 //
 // Cache Metrics (pkg/cache):
-//   - esi_cache_hits_total{layer="redis"} (Counter): Cache hits by layer
+//   - esi_cache_hits_total{layer="redis"|"memory"} (Counter): Cache hits by layer
 //   - esi_cache_misses_total (Counter): Cache misses
 //   - esi_cache_size_bytes{layer="redis"} (Gauge): Current cache size in bytes
+//   - esi_cache_entries{layer="redis"} (Gauge): Live cache entries written by this instance (with expiry watch)
+//   - esi_cache_expirations_total{endpoint} (Counter): Cache entries expired by Redis per endpoint pattern
+//   - esi_cache_tracked_bytes (Gauge): Approximate cache size tracked for the memory limit
+//   - esi_cache_evictions_total{policy} (Counter): Cache entries evicted to stay under the memory limit
 //   - esi_304_responses_total (Counter): 304 Not Modified responses
 //   - esi_conditional_requests_total (Counter): Conditional requests sent with If-None-Match
+//   - esi_etag_alias_confirmations_total (Counter): 304 responses confirming an earlier ETag of the cached body (ETag alias)
+//   - esi_seeded_conditional_requests_total{result} (Counter): Page requests made conditional with page 1's Last-Modified by result (not_modified, modified)
 //   - esi_cache_errors_total{operation} (Counter): Cache operation errors
+//   - esi_cache_corruption_total (Counter): Corrupted cache entries detected and deleted
+//   - esi_cache_migrations_total{from_version} (Counter): Cache entries migrated from an older envelope version
+//   - esi_cache_key_migrations_total{migration, mode} (Counter): Cache entries moved from a legacy key format by migration and mode (read, scan)
+//   - esi_cache_replica_reads_total{result} (Counter): Cache reads with a read replica by result (replica, recent_write, miss_fallback, error_fallback)
 //
 // Request Metrics (pkg/client):
 //   - esi_requests_total{endpoint, status} (Counter): Total requests by endpoint and HTTP status
-//   - esi_request_duration_seconds{endpoint} (Histogram): Request duration by endpoint
-//   - esi_errors_total{class} (Counter): Errors by class (client, server, rate_limit, network)
+//   - esi_request_duration_seconds{endpoint, status_class} (Histogram): Request duration by endpoint and upstream status class (2xx, 304, 4xx, 5xx, error)
+//   - esi_request_phase_duration_seconds{phase} (Histogram): Request duration by phase (rate_limit, cache_lookup, network, cache_write)
+//   - esi_errors_total{class} (Counter): Errors by class (client, server, rate_limit, network, challenge)
+//   - esi_challenge_responses_total{status} (Counter): Cloudflare challenge or HTML responses received instead of ESI JSON
+//   - esi_coalesced_requests_total (Counter): Requests answered with the result of an identical cacheable GET already in flight
+//   - esi_canary_checks_total{endpoint, hit, result} (Counter): Sampled cache hits compared with a forced live fetch by hit type (revalidated, suppressed) and result (match, mismatch, error)
+//   - esi_shed_requests_total{priority} (Counter): Requests rejected by the load shedder by priority (low, normal, high)
+//   - esi_slo_requests_total{route, result} (Counter): Requests covered by an SLO by result (good, slow, failed)
+//   - esi_slo_compliance{route} (Gauge): Fraction of good requests within the SLO window
+//   - esi_slo_burn_rate{route, window} (Gauge): SLO error budget burn rate over 5m and 1h
+//   - esi_network_errors_total{subclass} (Counter): Network errors by subclass (dns, connect_timeout, connect, tls, read_timeout, other)
+//   - esi_interactive_requests_total{group, status} (Counter): Uncached /fleets/ and /ui/ requests
+//   - esi_interactive_request_duration_seconds{group} (Histogram): Interactive request duration
+//   - esi_hedged_requests_total{group, winner} (Counter): Hedged interactive GETs by winning attempt
+//   - esi_smoothing_wait_seconds (Histogram): Time requests waited for the smoothing limiter
+//   - esi_inflight_bytes (Gauge): Bytes of ESI response bodies currently buffered in memory
+//   - esi_inflight_wait_seconds (Histogram): Time responses waited for MaxInFlightBytes before being read
+//   - esi_stuck_requests_total{phase} (Counter): Requests still running after StuckRequestThreshold by phase
+//   - esi_stale_responses_total{reason} (Counter): Expired cache entries served because ESI was unavailable (downtime, error, blocked, challenge)
+//   - esi_refreshes_suppressed_total{route} (Counter): Requests served from cache without revalidation because of a minimum refresh interval
+//   - esi_cache_filtered_total{endpoint} (Counter): Responses Config.CacheFilter excluded from the cache
+//   - esi_dns_stale_answers_total (Counter): Connections dialed with a stale cached DNS answer after a failed lookup
+//   - esi_upstream_failovers_total{upstream} (Counter): Requests moved to the next upstream because an upstream failed
+//   - esi_upstream_up{upstream} (Gauge): Whether an upstream is considered healthy (1) or cooling down after a failure (0)
+//   - esi_journal_requests_total{result} (Counter): Journaled write requests by result (journaled, replayed, rejected, expired)
+//   - esi_prefetches_total{result} (Counter): Prefetches by result (scheduled, fetched, failed, dropped)
+//   - esi_incident_mode (Gauge): Whether the client is in incident mode (1) because the status feed declares an incident
+//   - esi_incident_polls_total{result} (Counter): Status feed polls by result (ok, incident, error)
 //
 // Retry Metrics (pkg/client):
 //   - esi_retries_total{error_class} (Counter): Retry attempts by error class
 //   - esi_retry_backoff_seconds{error_class} (Histogram): Backoff duration by error class
 //   - esi_retry_exhausted_total{error_class} (Counter): Requests that exhausted max retries
+//   - esi_retry_deadline_skips_total{error_class} (Counter): Retries skipped due to insufficient deadline
+//   - esi_retry_budget_exhausted_total{error_class} (Counter): Retries skipped because the operation's retry budget was used up
+//
+// Proxy Metrics (cmd/esi-proxy, not registered by the library):
+//   - esi_proxy_requests_total{tenant, route, status} (Counter): Downstream proxy requests by tenant, route pattern and status
+//   - esi_proxy_cache_responses_total{tenant, route, cache} (Counter): Proxy responses by cache status (hit, stale, miss, bypass)
+//   - esi_proxy_response_bytes_total{tenant, route, source} (Counter): Response bytes served from cache vs. upstream
+//   - esi_proxy_coalesced_requests_total{tenant, route} (Counter): Downstream requests answered by an identical upstream request in flight
 //
 // Example Prometheus Queries:
 //
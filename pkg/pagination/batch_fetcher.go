@@ -4,21 +4,38 @@ package pagination
 import (
 "context"
 "fmt"
-"sync"
 "time"
 
+"github.com/Sternrassler/eve-esi-client/pkg/pool"
 "github.com/rs/zerolog/log"
 )
 
+// ProgressFunc is called after each fetched page with the number of completed
+// pages, the total page count and the estimated time until completion.
+type ProgressFunc func(completed, total int, eta time.Duration)
+
 // Config holds batch fetcher configuration
 type Config struct {
 // MaxConcurrency is the maximum number of parallel requests
 // Recommendation: 10 workers for ESI (300 req/min = 5 req/s)
 MaxConcurrency int
-// Timeout per page fetch
+// Timeout per page fetch (upper bound with AdaptiveTimeout)
 Timeout time.Duration
-// Buffer size for channels (default: estimated total pages)
+// AdaptiveTimeout derives the page timeout from recent page latencies of
+// the endpoint (P95 x 3, between MinTimeout and Timeout), so a stuck page
+// is abandoned long before the flat Timeout when ESI is fast
+AdaptiveTimeout bool
+// Lower bound of adaptive page timeouts (default: 2s)
+MinTimeout time.Duration
+// Deadline for the whole fetch (0 = none). When exceeded, remaining
+// workers stop and partial data is returned with ErrJobDeadlineExceeded.
+JobDeadline time.Duration
+// Deprecated: BufferSize is ignored; pages are dispatched by pkg/pool
+// without buffering.
 BufferSize int
+// Progress is called after every fetched page (optional).
+// Calls are serialized and must not block for long.
+Progress ProgressFunc
 }
 
 // DefaultConfig returns safe default configuration for ESI
@@ -26,7 +43,6 @@ func DefaultConfig() Config {
 return Config{
 MaxConcurrency: 10,
 Timeout:        15 * time.Second,
-BufferSize:     400,
 }
 }
 
@@ -36,7 +52,9 @@ type PageFetcher interface {
 FetchPage(ctx context.Context, endpoint string, pageNum int) (data []byte, totalPages int, err error)
 }
 
-// PageResult represents the result of fetching a single page
+// PageResult represents the result of fetching a single page.
+//
+// Deprecated: BatchFetcher no longer passes page results over channels.
 type PageResult struct {
 PageNumber int
 Data       []byte
@@ -57,8 +75,11 @@ config.MaxConcurrency = 10
 if config.Timeout <= 0 {
 config.Timeout = 15 * time.Second
 }
-if config.BufferSize <= 0 {
-config.BufferSize = 400
+if config.MinTimeout <= 0 {
+config.MinTimeout = defaultMinTimeout
+}
+if config.MinTimeout > config.Timeout {
+config.MinTimeout = config.Timeout
 }
 
 return &BatchFetcher{
@@ -72,10 +93,16 @@ config:  config,
 func (bf *BatchFetcher) FetchAllPages(ctx context.Context, endpoint string) (map[int][]byte, error) {
 start := time.Now()
 
+ctx = ScopeOperation(ctx, bf.fetcher)
+parent := ctx
+ctx, cancel := bf.config.withJobDeadline(ctx)
+defer cancel()
+
 // Fetch first page to get total page count
-firstPageData, totalPages, err := bf.fetcher.FetchPage(ctx, endpoint, 1)
+firstPageData, totalPages, err := fetchPage(ctx, bf.fetcher, endpoint, 1, bf.config)
 if err != nil {
-return nil, fmt.Errorf("failed to fetch first page: %w", err)
+releaseAbandoned(parent, bf.fetcher)
+return nil, fmt.Errorf("failed to fetch first page: %w", jobError(parent, ctx, err))
 }
 
 log.Info().
@@ -83,6 +110,8 @@ Str("endpoint", endpoint).
 Int("total_pages", totalPages).
 Msg("Starting parallel page fetch")
 
+bf.reportProgress(1, totalPages, start)
+
 // Single page optimization
 if totalPages == 1 {
 result := map[int][]byte{1: firstPageData}
@@ -97,78 +126,59 @@ return result, nil
 // Create result map with first page
 results := make(map[int][]byte)
 results[1] = firstPageData
-resultsMutex := sync.Mutex{}
-
-// Create channels
-pageQueue := make(chan int, bf.config.BufferSize)
-pageResults := make(chan PageResult, bf.config.BufferSize)
-errors := make(chan error, bf.config.MaxConcurrency)
 
-// Fill page queue (skip page 1, already fetched)
-go func() {
-for page := 2; page <= totalPages; page++ {
-pageQueue <- page
-}
-close(pageQueue)
-}()
-
-// Start worker pool
-var wg sync.WaitGroup
-for i := 0; i < bf.config.MaxConcurrency; i++ {
-wg.Add(1)
-go bf.worker(ctx, endpoint, pageQueue, pageResults, errors, &wg, i)
+// Fetch pages 2..N with the worker pool. A failed page used to stop its
+// worker, so the pool stops dispatching after MaxConcurrency failures.
+cfg := pool.Config{
+Workers:     bf.config.MaxConcurrency,
+MaxFailures: bf.config.MaxConcurrency,
+Progress: func(completed, total int) {
+bf.reportProgress(completed+1, totalPages, start)
+},
+}
+pages := pool.Run(ctx, totalPages-1, cfg, func(ctx context.Context, i int) ([]byte, error) {
+data, _, err := fetchPage(ctx, bf.fetcher, endpoint, i+2, bf.config)
+if err != nil {
+log.Warn().
+Err(err).
+Int("page", i+2).
+Msg("Page fetch failed")
 }
-
-// Close results channel when all workers done
-go func() {
-wg.Wait()
-close(pageResults)
-close(errors)
-}()
+return data, err
+})
 
 // Collect results
-fetchedPages := 1 // First page already fetched
-for result := range pageResults {
-if result.Error != nil {
-log.Warn().
-Err(result.Error).
-Int("page", result.PageNumber).
-Msg("Page fetch failed")
+completed := []int{1} // First page already fetched
+var firstErr error
+for i, page := range pages {
+if page.Err != nil {
+if firstErr == nil {
+firstErr = fmt.Errorf("page %d: %w", i+2, page.Err)
+}
 continue
 }
-
-resultsMutex.Lock()
-results[result.PageNumber] = result.Data
-fetchedPages++
-resultsMutex.Unlock()
-
-// Progress logging every 50 pages
-if fetchedPages%50 == 0 {
-log.Info().
-Int("fetched", fetchedPages).
-Int("total", totalPages).
-Float64("progress_pct", float64(fetchedPages)/float64(totalPages)*100).
-Msg("Fetch progress")
+results[i+2] = page.Value
+completed = append(completed, i+2)
 }
+
+// Workers stopped by cancellation or the job deadline
+if len(completed) < totalPages && ctx.Err() != nil {
+releaseAbandoned(parent, bf.fetcher)
+return results, &PartialError{Endpoint: endpoint, Completed: completed, Total: totalPages, Err: jobError(parent, ctx, ctx.Err())}
 }
 
-// Check for errors
-select {
-case err := <-errors:
-if err != nil {
+if firstErr != nil {
 log.Warn().
-Err(err).
-Int("fetched_pages", fetchedPages).
+Err(firstErr).
+Int("fetched_pages", len(completed)).
 Int("total_pages", totalPages).
 Msg("Worker error - returning partial results")
-return results, fmt.Errorf("worker error (partial data: %d/%d pages): %w", fetchedPages, totalPages, err)
-}
-default:
+return results, &PartialError{Endpoint: endpoint, Completed: completed, Total: totalPages, Err: firstErr}
 }
 
 log.Info().
 Str("endpoint", endpoint).
-Int("pages", fetchedPages).
+Int("pages", len(completed)).
 Int("total", totalPages).
 Dur("duration", time.Since(start)).
 Msg("Fetch complete")
@@ -176,65 +186,19 @@ Msg("Fetch complete")
 return results, nil
 }
 
-// worker processes pages from the queue
-func (bf *BatchFetcher) worker(ctx context.Context, endpoint string, pageQueue <-chan int, results chan<- PageResult, errors chan<- error, wg *sync.WaitGroup, workerID int) {
-defer wg.Done()
-pagesProcessed := 0
-
-for pageNum := range pageQueue {
-// Check context cancellation
-select {
-case <-ctx.Done():
-log.Debug().
-Int("worker_id", workerID).
-Int("pages_processed", pagesProcessed).
-Msg("Worker stopping (context cancelled)")
+// reportProgress invokes the progress callback with a linear ETA estimate
+func (bf *BatchFetcher) reportProgress(completed, total int, start time.Time) {
+if bf.config.Progress == nil {
 return
-default:
 }
-
-// Fetch page with timeout
-pageCtx, cancel := context.WithTimeout(ctx, bf.config.Timeout)
-data, _, err := bf.fetcher.FetchPage(pageCtx, endpoint, pageNum)
-cancel()
-
-if err != nil {
-log.Warn().
-Err(err).
-Int("worker_id", workerID).
-Int("page", pageNum).
-Msg("Page fetch failed")
-
-// Non-blocking error send
-select {
-case errors <- err:
-default:
-}
-return
-}
-
-// Send result
-select {
-case results <- PageResult{
-PageNumber: pageNum,
-Data:       data,
-Error:      nil,
-}:
-case <-ctx.Done():
-log.Debug().
-Int("worker_id", workerID).
-Int("pages_processed", pagesProcessed).
-Msg("Worker stopping (context cancelled after fetch)")
-return
-}
-
-pagesProcessed++
+bf.config.Progress(completed, total, estimateETA(completed, total, time.Since(start)))
 }
 
-if pagesProcessed > 0 {
-log.Debug().
-Int("worker_id", workerID).
-Int("pages_processed", pagesProcessed).
-Msg("Worker completed")
+// estimateETA extrapolates the remaining duration from the average page time
+func estimateETA(completed, total int, elapsed time.Duration) time.Duration {
+if completed <= 0 || completed >= total {
+return 0
 }
+perPage := elapsed / time.Duration(completed)
+return perPage * time.Duration(total-completed)
 }
@@ -0,0 +1,80 @@
+package pagination
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// staticFetcher returns a fixed number of pages.
+type staticFetcher struct {
+	totalPages int
+}
+
+func (f *staticFetcher) FetchPage(ctx context.Context, endpoint string, pageNum int) ([]byte, int, error) {
+	return []byte(fmt.Sprintf(`[%d]`, pageNum)), f.totalPages, nil
+}
+
+func TestEstimateETA(t *testing.T) {
+	tests := []struct {
+		name      string
+		completed int
+		total     int
+		elapsed   time.Duration
+		want      time.Duration
+	}{
+		{"nothing completed", 0, 10, time.Second, 0},
+		{"half done", 5, 10, 5 * time.Second, 5 * time.Second},
+		{"one of four", 1, 4, 2 * time.Second, 6 * time.Second},
+		{"all done", 10, 10, 10 * time.Second, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := estimateETA(tt.completed, tt.total, tt.elapsed); got != tt.want {
+				t.Errorf("estimateETA() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchAllPages_Progress(t *testing.T) {
+	var mu sync.Mutex
+	var completed []int
+	totals := map[int]bool{}
+
+	config := DefaultConfig()
+	config.MaxConcurrency = 3
+	config.Progress = func(done, total int, eta time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		completed = append(completed, done)
+		totals[total] = true
+		if eta < 0 {
+			t.Errorf("eta = %v, must not be negative", eta)
+		}
+	}
+
+	fetcher := NewBatchFetcher(&staticFetcher{totalPages: 5}, config)
+	results, err := fetcher.FetchAllPages(context.Background(), "/v1/markets/10000002/orders/")
+	if err != nil {
+		t.Fatalf("FetchAllPages() error = %v", err)
+	}
+	if len(results) != 5 {
+		t.Errorf("len(results) = %d, want 5", len(results))
+	}
+
+	if len(completed) != 5 {
+		t.Fatalf("progress calls = %d, want 5", len(completed))
+	}
+	for i, done := range completed {
+		if done != i+1 {
+			t.Errorf("progress call %d completed = %d, want %d", i, done, i+1)
+		}
+	}
+	if len(totals) != 1 || !totals[5] {
+		t.Errorf("progress totals = %v, want only 5", totals)
+	}
+}
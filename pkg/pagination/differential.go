@@ -0,0 +1,132 @@
+package pagination
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ChangeFetcher is optionally implemented by fetchers that revalidate cached
+// pages with conditional requests and can tell whether a page changed since
+// the cached copy (ESI answered 200) or not (304). FetchChangedPages uses it;
+// other fetchers report every page as changed.
+type ChangeFetcher interface {
+	// FetchPageIfChanged fetches a single page like FetchPage and reports
+	// whether its content differs from the cached copy.
+	FetchPageIfChanged(ctx context.Context, endpoint string, pageNum int) (data []byte, totalPages int, changed bool, err error)
+}
+
+// DiffResult is the result of a differential crawl.
+type DiffResult struct {
+	Changed   map[int][]byte // Pages that changed or were not cached, by page number
+	Unchanged []int          // Pages ESI confirmed unchanged, ascending
+	Total     int            // Total page count of the endpoint
+}
+
+// FetchChangedPages crawls an endpoint like FetchAllPages, but returns only
+// the pages that changed since the fetcher cached them. With a caching
+// fetcher every cached page is revalidated with a conditional request and
+// only pages answered with 200 are downloaded, so a repeat crawl of a stable
+// region costs little more than the request count.
+//
+// If pages fail, the result holds the pages fetched so far together with a
+// *PartialError, like FetchAllPages.
+func (bf *BatchFetcher) FetchChangedPages(ctx context.Context, endpoint string) (*DiffResult, error) {
+	start := time.Now()
+	tracker := &changeTracker{PageFetcher: bf.fetcher}
+	pages, err := (&BatchFetcher{fetcher: tracker, config: bf.config}).FetchAllPages(ctx, endpoint)
+	if pages == nil {
+		return nil, err
+	}
+
+	result := &DiffResult{Changed: make(map[int][]byte, len(pages))}
+	for page, data := range pages {
+		if tracker.isUnchanged(page) {
+			result.Unchanged = append(result.Unchanged, page)
+		} else {
+			result.Changed[page] = data
+		}
+	}
+	slices.Sort(result.Unchanged)
+	result.Total = tracker.totalPages()
+
+	log.Info().
+		Str("endpoint", endpoint).
+		Int("changed", len(result.Changed)).
+		Int("unchanged", len(result.Unchanged)).
+		Int("total", result.Total).
+		Dur("duration", time.Since(start)).
+		Msg("Differential fetch complete")
+
+	return result, err
+}
+
+// changeTracker wraps the fetcher of a differential crawl and records which
+// pages were unchanged. It forwards the optional fetcher interfaces.
+type changeTracker struct {
+	PageFetcher
+
+	mu        sync.Mutex
+	unchanged map[int]bool
+	total     int
+}
+
+// FetchPage implements PageFetcher.
+func (t *changeTracker) FetchPage(ctx context.Context, endpoint string, pageNum int) ([]byte, int, error) {
+	fetcher, ok := t.PageFetcher.(ChangeFetcher)
+	if !ok {
+		data, totalPages, err := t.PageFetcher.FetchPage(ctx, endpoint, pageNum)
+		t.record(pageNum, totalPages, true, err)
+		return data, totalPages, err
+	}
+	data, totalPages, changed, err := fetcher.FetchPageIfChanged(ctx, endpoint, pageNum)
+	t.record(pageNum, totalPages, changed, err)
+	return data, totalPages, err
+}
+
+// record notes the outcome of a page fetch.
+func (t *changeTracker) record(pageNum, totalPages int, changed bool, err error) {
+	if err != nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if pageNum == 1 {
+		t.total = totalPages
+	}
+	if !changed {
+		if t.unchanged == nil {
+			t.unchanged = make(map[int]bool)
+		}
+		t.unchanged[pageNum] = true
+	}
+}
+
+// isUnchanged reports whether pageNum was confirmed unchanged.
+func (t *changeTracker) isUnchanged(pageNum int) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.unchanged[pageNum]
+}
+
+// totalPages returns the page count reported with page 1.
+func (t *changeTracker) totalPages() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.total
+}
+
+// ScopeOperation implements OperationScoper for the wrapped fetcher.
+func (t *changeTracker) ScopeOperation(ctx context.Context) context.Context {
+	return ScopeOperation(ctx, t.PageFetcher)
+}
+
+// CloseIdleConnections implements idleConnectionCloser for the wrapped fetcher.
+func (t *changeTracker) CloseIdleConnections() {
+	if closer, ok := t.PageFetcher.(idleConnectionCloser); ok {
+		closer.CloseIdleConnections()
+	}
+}
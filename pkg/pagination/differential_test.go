@@ -0,0 +1,83 @@
+package pagination
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync/atomic"
+	"testing"
+)
+
+// diffFetcher reports the pages in changed as changed and all others as
+// unchanged, and counts scoped operations.
+type diffFetcher struct {
+	staticFetcher
+	changed map[int]bool
+	scoped  atomic.Int64
+}
+
+func (f *diffFetcher) FetchPageIfChanged(ctx context.Context, endpoint string, pageNum int) ([]byte, int, bool, error) {
+	data, total, err := f.FetchPage(ctx, endpoint, pageNum)
+	return data, total, f.changed[pageNum], err
+}
+
+func (f *diffFetcher) ScopeOperation(ctx context.Context) context.Context {
+	f.scoped.Add(1)
+	return ctx
+}
+
+func TestFetchChangedPages(t *testing.T) {
+	tests := []struct {
+		name          string
+		fetcher       PageFetcher
+		wantChanged   []int
+		wantUnchanged []int
+	}{
+		{"change fetcher", &diffFetcher{staticFetcher: staticFetcher{totalPages: 5}, changed: map[int]bool{2: true, 5: true}}, []int{2, 5}, []int{1, 3, 4}},
+		{"nothing changed", &diffFetcher{staticFetcher: staticFetcher{totalPages: 3}}, nil, []int{1, 2, 3}},
+		{"plain fetcher", &staticFetcher{totalPages: 3}, []int{1, 2, 3}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewBatchFetcher(tt.fetcher, DefaultConfig()).FetchChangedPages(context.Background(), "/v1/markets/10000002/orders/")
+			if err != nil {
+				t.Fatalf("FetchChangedPages() error = %v", err)
+			}
+
+			var changed []int
+			for page, data := range result.Changed {
+				if want := fmt.Sprintf("[%d]", page); string(data) != want {
+					t.Errorf("page %d = %s, want %s", page, data, want)
+				}
+				changed = append(changed, page)
+			}
+			slices.Sort(changed)
+			if !slices.Equal(changed, tt.wantChanged) {
+				t.Errorf("Changed = %v, want %v", changed, tt.wantChanged)
+			}
+			if !slices.Equal(result.Unchanged, tt.wantUnchanged) {
+				t.Errorf("Unchanged = %v, want %v", result.Unchanged, tt.wantUnchanged)
+			}
+			if result.Total != len(tt.wantChanged)+len(tt.wantUnchanged) {
+				t.Errorf("Total = %d, want %d", result.Total, len(tt.wantChanged)+len(tt.wantUnchanged))
+			}
+			if f, ok := tt.fetcher.(*diffFetcher); ok && f.scoped.Load() != 1 {
+				t.Errorf("ScopeOperation calls = %d, want 1", f.scoped.Load())
+			}
+		})
+	}
+}
+
+func TestFetchChangedPages_FirstPageFails(t *testing.T) {
+	f := &pagedFetcher{pages: []string{`[1]`, `[2]`}, fail: 1}
+
+	result, err := NewBatchFetcher(f, DefaultConfig()).FetchChangedPages(context.Background(), "/v1/test/")
+	if err == nil || result != nil {
+		t.Fatalf("FetchChangedPages() = %v, %v, want nil result and error", result, err)
+	}
+	if errors.As(err, new(*PartialError)) {
+		t.Errorf("error = %v, want no *PartialError", err)
+	}
+}
@@ -14,8 +14,49 @@
 //   - Fetches first page to determine total pages
 //   - Spawns worker pool (default 10 workers)
 //   - Distributes remaining pages across workers
-//   - Collects results with progress logging
+//   - Collects results and reports progress via Config.Progress
 //   - Handles errors gracefully (returns partial data)
 //
+// GetPaginated is the typed variant: each page is decoded into []T as soon as it
+// arrives, page buffers are recycled through a sync.Pool, and fetchers that
+// implement PageStreamer can decode straight from the response body:
+//
+//	orders, err := pagination.GetPaginated(ctx, esiClient, endpoint, pagination.TypedConfig[market.Order]{
+//		Config: pagination.DefaultConfig(),
+//		Stream: true,
+//		Key:    func(o market.Order) int64 { return o.OrderID },
+//	})
+//
+// Pages use a flat Timeout by default. With AdaptiveTimeout the page timeout
+// follows the recent P95 latency of the endpoint (times three, between
+// MinTimeout and Timeout), and JobDeadline bounds the whole fetch:
+//
+//	config := pagination.DefaultConfig()
+//	config.AdaptiveTimeout = true
+//	config.JobDeadline = 2 * time.Minute
+//
+// When the job deadline passes, remaining workers stop and the pages fetched
+// so far are returned with an error wrapping ErrJobDeadlineExceeded.
+//
+// Whenever a fetch stops early (cancelled context, job deadline, failed
+// pages) the data fetched so far comes with a *PartialError listing the
+// completed pages. On cancellation all workers stop before the call
+// returns, in-flight requests are cancelled, and fetchers with a
+// CloseIdleConnections method (like the client) drop their idle connections:
+//
+//	orders, err := pagination.GetPaginated(ctx, esiClient, endpoint, cfg)
+//	var partial *pagination.PartialError
+//	if errors.As(err, &partial) {
+//		log.Printf("got %d of %d pages", len(partial.Completed), partial.Total)
+//	}
+//
+// FetchChangedPages is a differential crawl for repeat fetches: fetchers that
+// implement ChangeFetcher (like the client) revalidate every cached page with
+// a conditional request, and only pages ESI answers with 200 are downloaded
+// and returned. Unchanged pages are listed, so callers can skip them:
+//
+//	diff, err := fetcher.FetchChangedPages(ctx, "/v1/markets/10000002/orders/")
+//	log.Printf("%d of %d pages unchanged", len(diff.Unchanged), diff.Total)
+//
 // See ADR-008 for architecture decisions.
 package pagination
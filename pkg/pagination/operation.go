@@ -0,0 +1,22 @@
+package pagination
+
+import "context"
+
+// OperationScoper is optionally implemented by fetchers that keep state per
+// paginated fetch, such as a retry budget shared by all of its pages.
+// FetchAllPages and GetPaginated call it once per fetch.
+type OperationScoper interface {
+	// ScopeOperation returns the context used for all pages of one fetch.
+	ScopeOperation(ctx context.Context) context.Context
+}
+
+// ScopeOperation returns ctx scoped by fetcher if it implements
+// OperationScoper, ctx otherwise. Callers fetching pages of several
+// endpoints as one operation (e.g. a multi-region crawl) use it once for
+// all of them.
+func ScopeOperation(ctx context.Context, fetcher PageFetcher) context.Context {
+	if scoper, ok := fetcher.(OperationScoper); ok {
+		return scoper.ScopeOperation(ctx)
+	}
+	return ctx
+}
@@ -0,0 +1,67 @@
+package pagination
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+)
+
+type operationKey struct{}
+
+// scopingFetcher implements OperationScoper and checks that every page is
+// fetched with the scoped context.
+type scopingFetcher struct {
+	pagedFetcher
+	scopes   atomic.Int64
+	unscoped atomic.Int64
+}
+
+func (f *scopingFetcher) ScopeOperation(ctx context.Context) context.Context {
+	return context.WithValue(ctx, operationKey{}, f.scopes.Add(1))
+}
+
+func (f *scopingFetcher) FetchPage(ctx context.Context, endpoint string, pageNum int) ([]byte, int, error) {
+	if ctx.Value(operationKey{}) == nil {
+		f.unscoped.Add(1)
+	}
+	return f.pagedFetcher.FetchPage(ctx, endpoint, pageNum)
+}
+
+func TestScopeOperation(t *testing.T) {
+	pages := []string{`[{"id":1}]`, `[{"id":2}]`, `[{"id":3}]`}
+
+	tests := []struct {
+		name  string
+		fetch func(ctx context.Context, f PageFetcher) error
+	}{
+		{"GetPaginated", func(ctx context.Context, f PageFetcher) error {
+			_, err := GetPaginated(ctx, f, "/v1/markets/10000002/orders/", TypedConfig[item]{Config: DefaultConfig()})
+			return err
+		}},
+		{"FetchAllPages", func(ctx context.Context, f PageFetcher) error {
+			_, err := NewBatchFetcher(f, DefaultConfig()).FetchAllPages(ctx, "/v1/markets/10000002/orders/")
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &scopingFetcher{pagedFetcher: pagedFetcher{pages: pages}}
+			if err := tt.fetch(context.Background(), f); err != nil {
+				t.Fatalf("fetch error = %v", err)
+			}
+			if got := f.scopes.Load(); got != 1 {
+				t.Errorf("ScopeOperation calls = %d, want 1", got)
+			}
+			if got := f.unscoped.Load(); got != 0 {
+				t.Errorf("pages fetched without scoped context = %d, want 0", got)
+			}
+		})
+	}
+
+	// Fetchers without OperationScoper keep the context
+	ctx := context.WithValue(context.Background(), operationKey{}, "caller")
+	if got := ScopeOperation(ctx, &pagedFetcher{pages: pages}); got != ctx {
+		t.Error("ScopeOperation() changed the context of a plain fetcher")
+	}
+}
@@ -0,0 +1,46 @@
+package pagination
+
+import (
+	"context"
+	"fmt"
+)
+
+// PartialError is returned together with the data fetched so far when a
+// paginated fetch stops early: the caller cancelled, the job deadline passed
+// or pages failed. Err is the cause, so errors.Is(err, context.Canceled) and
+// errors.Is(err, ErrJobDeadlineExceeded) keep working.
+type PartialError struct {
+	Endpoint  string
+	Completed []int // Page numbers fetched successfully, ascending
+	Total     int   // Total page count of the endpoint
+	Err       error
+}
+
+// Error implements the error interface.
+func (e *PartialError) Error() string {
+	return fmt.Sprintf("%s: partial data (%d/%d pages): %v", e.Endpoint, len(e.Completed), e.Total, e.Err)
+}
+
+// Unwrap implements error unwrapping for errors.Is/As.
+func (e *PartialError) Unwrap() error {
+	return e.Err
+}
+
+// idleConnectionCloser is optionally implemented by fetchers that keep
+// connections open, like http.Client.
+type idleConnectionCloser interface {
+	CloseIdleConnections()
+}
+
+// releaseAbandoned closes the fetcher's idle connections once the caller has
+// given up on a fetch (parent is done). Requests still in flight are
+// cancelled through their context; their connections would otherwise stay
+// pooled for a crawl that no longer runs.
+func releaseAbandoned(parent context.Context, fetcher PageFetcher) {
+	if parent.Err() == nil {
+		return
+	}
+	if closer, ok := fetcher.(idleConnectionCloser); ok {
+		closer.CloseIdleConnections()
+	}
+}
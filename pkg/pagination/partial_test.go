@@ -0,0 +1,109 @@
+package pagination
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockingFetcher serves the first pages immediately and blocks on the rest
+// until their context is done, like requests hanging on a slow upstream.
+type blockingFetcher struct {
+	totalPages int
+	fastPages  int
+	blocked    chan struct{} // Receives one value per blocking request
+	inFlight   atomic.Int64
+	idleClosed atomic.Int64
+}
+
+func (f *blockingFetcher) FetchPage(ctx context.Context, endpoint string, pageNum int) ([]byte, int, error) {
+	if pageNum <= f.fastPages {
+		return []byte(fmt.Sprintf(`[%d]`, pageNum)), f.totalPages, nil
+	}
+	f.inFlight.Add(1)
+	defer f.inFlight.Add(-1)
+	f.blocked <- struct{}{}
+	<-ctx.Done()
+	return nil, 0, ctx.Err()
+}
+
+func (f *blockingFetcher) CloseIdleConnections() {
+	f.idleClosed.Add(1)
+}
+
+func TestCancelMidCrawl(t *testing.T) {
+	config := DefaultConfig()
+	config.MaxConcurrency = 2
+
+	tests := []struct {
+		name  string
+		fetch func(ctx context.Context, f PageFetcher) (int, error)
+	}{
+		{"FetchAllPages", func(ctx context.Context, f PageFetcher) (int, error) {
+			results, err := NewBatchFetcher(f, config).FetchAllPages(ctx, "/v1/markets/10000002/orders/")
+			return len(results), err
+		}},
+		{"GetPaginated", func(ctx context.Context, f PageFetcher) (int, error) {
+			items, err := GetPaginated(ctx, f, "/v1/markets/10000002/orders/", TypedConfig[int]{Config: config})
+			return len(items), err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &blockingFetcher{totalPages: 50, fastPages: 3, blocked: make(chan struct{}, 50)}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// Cancel once both workers hang on a page
+			go func() {
+				<-f.blocked
+				<-f.blocked
+				cancel()
+			}()
+
+			start := time.Now()
+			got, err := tt.fetch(ctx, f)
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("fetch returned after %v, want prompt return on cancel", elapsed)
+			}
+
+			var partial *PartialError
+			if !errors.As(err, &partial) {
+				t.Fatalf("error = %v, want *PartialError", err)
+			}
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("error = %v, want context.Canceled", err)
+			}
+			if !slices.Equal(partial.Completed, []int{1, 2, 3}) || partial.Total != 50 {
+				t.Errorf("PartialError = %v/%d, want [1 2 3]/50", partial.Completed, partial.Total)
+			}
+			if got != 3 {
+				t.Errorf("partial results = %d, want 3", got)
+			}
+			if n := f.inFlight.Load(); n != 0 {
+				t.Errorf("requests in flight after return = %d, want 0", n)
+			}
+			if n := f.idleClosed.Load(); n != 1 {
+				t.Errorf("CloseIdleConnections calls = %d, want 1", n)
+			}
+		})
+	}
+}
+
+func TestPartialError_NotAbandoned(t *testing.T) {
+	f := &pagedFetcher{pages: []string{`[1]`, `[2]`, `[3]`}, fail: 2}
+
+	_, err := NewBatchFetcher(f, DefaultConfig()).FetchAllPages(context.Background(), "/v1/test/")
+	var partial *PartialError
+	if !errors.As(err, &partial) {
+		t.Fatalf("error = %v, want *PartialError", err)
+	}
+	if !slices.Equal(partial.Completed, []int{1, 3}) {
+		t.Errorf("Completed = %v, want [1 3]", partial.Completed)
+	}
+}
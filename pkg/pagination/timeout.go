@@ -0,0 +1,148 @@
+package pagination
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/cache"
+)
+
+// ErrJobDeadlineExceeded is returned (wrapped, with partial data) when a fetch
+// runs longer than Config.JobDeadline.
+var ErrJobDeadlineExceeded = errors.New("pagination job deadline exceeded")
+
+// Adaptive timeout tuning.
+const (
+	// defaultMinTimeout is the lower bound of adaptive page timeouts.
+	defaultMinTimeout = 2 * time.Second
+
+	// adaptiveTimeoutFactor multiplies the P95 page latency; a page taking
+	// three times as long as 95% of recent pages is considered stuck.
+	adaptiveTimeoutFactor = 3
+
+	// adaptiveMinSamples is the number of page latencies required before the
+	// P95 replaces the flat Timeout.
+	adaptiveMinSamples = 20
+
+	// adaptiveWindowSize is the number of recent page latencies kept per
+	// endpoint pattern.
+	adaptiveWindowSize = 200
+)
+
+// latencyWindow keeps a ring buffer of recent page latencies.
+type latencyWindow struct {
+	samples []time.Duration
+	next    int
+}
+
+// pageLatencies holds recent page latencies per endpoint pattern, shared by
+// all fetchers of the process so short-lived fetchers profit from earlier runs.
+type pageLatencies struct {
+	mu      sync.Mutex
+	windows map[string]*latencyWindow
+}
+
+// recentLatencies feeds adaptive page timeouts.
+var recentLatencies = &pageLatencies{windows: make(map[string]*latencyWindow)}
+
+// latencyKey groups endpoints by pattern (region and type IDs replaced), so
+// all regions of a market endpoint share one window.
+func latencyKey(endpoint string) string {
+	path, _, _ := strings.Cut(endpoint, "?")
+	return cache.EndpointPattern(path)
+}
+
+// observe records the latency of a successful page fetch.
+func (l *pageLatencies) observe(endpoint string, d time.Duration) {
+	key := latencyKey(endpoint)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	w, ok := l.windows[key]
+	if !ok {
+		w = &latencyWindow{}
+		l.windows[key] = w
+	}
+	if len(w.samples) < adaptiveWindowSize {
+		w.samples = append(w.samples, d)
+		return
+	}
+	w.samples[w.next] = d
+	w.next = (w.next + 1) % adaptiveWindowSize
+}
+
+// p95 returns the 95th percentile page latency of endpoint and false if too
+// few samples exist.
+func (l *pageLatencies) p95(endpoint string) (time.Duration, bool) {
+	l.mu.Lock()
+	w, ok := l.windows[latencyKey(endpoint)]
+	if !ok || len(w.samples) < adaptiveMinSamples {
+		l.mu.Unlock()
+		return 0, false
+	}
+	sorted := make([]time.Duration, len(w.samples))
+	copy(sorted, w.samples)
+	l.mu.Unlock()
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[(len(sorted)*95)/100], true
+}
+
+// pageTimeout returns the timeout for one page fetch of endpoint. With
+// AdaptiveTimeout it is the recent P95 latency times adaptiveTimeoutFactor,
+// kept between MinTimeout and Timeout; otherwise the flat Timeout.
+func (c Config) pageTimeout(endpoint string) time.Duration {
+	if !c.AdaptiveTimeout {
+		return c.Timeout
+	}
+	p95, ok := recentLatencies.p95(endpoint)
+	if !ok {
+		return c.Timeout
+	}
+
+	timeout := p95 * adaptiveTimeoutFactor
+	if timeout < c.MinTimeout {
+		timeout = c.MinTimeout
+	}
+	if timeout > c.Timeout {
+		timeout = c.Timeout
+	}
+	return timeout
+}
+
+// fetchPage fetches a single page with the page timeout and records its
+// latency for adaptive timeouts.
+func fetchPage(ctx context.Context, fetcher PageFetcher, endpoint string, pageNum int, config Config) ([]byte, int, error) {
+	pageCtx, cancel := context.WithTimeout(ctx, config.pageTimeout(endpoint))
+	defer cancel()
+
+	start := time.Now()
+	data, totalPages, err := fetcher.FetchPage(pageCtx, endpoint, pageNum)
+	if err == nil {
+		recentLatencies.observe(endpoint, time.Since(start))
+	}
+	return data, totalPages, err
+}
+
+// withJobDeadline applies Config.JobDeadline to ctx.
+func (c Config) withJobDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.JobDeadline <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.JobDeadline)
+}
+
+// jobError wraps err with ErrJobDeadlineExceeded if the job context ran out
+// while the caller's context is still live.
+func jobError(parent, job context.Context, err error) error {
+	if job.Err() != nil && parent.Err() == nil {
+		return fmt.Errorf("%w: %w", ErrJobDeadlineExceeded, err)
+	}
+	return err
+}
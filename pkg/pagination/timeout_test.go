@@ -0,0 +1,105 @@
+package pagination
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// slowFetcher takes delay per page and honors context cancellation.
+type slowFetcher struct {
+	totalPages int
+	delay      time.Duration
+}
+
+func (f *slowFetcher) FetchPage(ctx context.Context, endpoint string, pageNum int) ([]byte, int, error) {
+	select {
+	case <-time.After(f.delay):
+		return []byte(fmt.Sprintf(`[%d]`, pageNum)), f.totalPages, nil
+	case <-ctx.Done():
+		return nil, 0, ctx.Err()
+	}
+}
+
+func TestConfig_PageTimeout(t *testing.T) {
+	tests := []struct {
+		name     string
+		adaptive bool
+		latency  time.Duration
+		samples  int
+		want     time.Duration
+	}{
+		{"flat", false, time.Second, adaptiveMinSamples, 15 * time.Second},
+		{"too few samples", true, time.Second, adaptiveMinSamples - 1, 15 * time.Second},
+		{"P95 x 3", true, time.Second, adaptiveMinSamples, 3 * time.Second},
+		{"at least MinTimeout", true, 100 * time.Millisecond, adaptiveMinSamples, 2 * time.Second},
+		{"at most Timeout", true, 10 * time.Second, adaptiveMinSamples, 15 * time.Second},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint := fmt.Sprintf("/v1/test/page-timeout-%d/", i)
+			for n := 0; n < tt.samples; n++ {
+				recentLatencies.observe(endpoint, tt.latency)
+			}
+
+			config := DefaultConfig()
+			config.AdaptiveTimeout = tt.adaptive
+			config = NewBatchFetcher(nil, config).config
+			if got := config.pageTimeout(endpoint); got != tt.want {
+				t.Errorf("pageTimeout() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLatencyKey_GroupsByPattern(t *testing.T) {
+	for n := 0; n < adaptiveMinSamples; n++ {
+		recentLatencies.observe("/v1/markets/10000002/orders/?order_type=all", time.Second)
+	}
+	if _, ok := recentLatencies.p95("/v1/markets/10000043/orders/"); !ok {
+		t.Error("p95() found no samples for another region of the same endpoint")
+	}
+}
+
+func TestJobDeadline(t *testing.T) {
+	fetcher := &slowFetcher{totalPages: 50, delay: 20 * time.Millisecond}
+	config := DefaultConfig()
+	config.MaxConcurrency = 2
+	config.JobDeadline = 150 * time.Millisecond
+
+	t.Run("FetchAllPages", func(t *testing.T) {
+		start := time.Now()
+		results, err := NewBatchFetcher(fetcher, config).FetchAllPages(context.Background(), "/v1/test/job-deadline/")
+		if !errors.Is(err, ErrJobDeadlineExceeded) {
+			t.Fatalf("FetchAllPages() error = %v, want ErrJobDeadlineExceeded", err)
+		}
+		if len(results) == 0 || len(results) >= fetcher.totalPages {
+			t.Errorf("len(results) = %d, want partial data", len(results))
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("FetchAllPages() took %v, workers were not stopped at the deadline", elapsed)
+		}
+	})
+
+	t.Run("GetPaginated", func(t *testing.T) {
+		items, err := GetPaginated(context.Background(), fetcher, "/v1/test/job-deadline/", TypedConfig[int]{Config: config})
+		if !errors.Is(err, ErrJobDeadlineExceeded) {
+			t.Fatalf("GetPaginated() error = %v, want ErrJobDeadlineExceeded", err)
+		}
+		if len(items) == 0 || len(items) >= fetcher.totalPages {
+			t.Errorf("len(items) = %d, want partial data", len(items))
+		}
+	})
+
+	t.Run("caller cancellation is not a job deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := NewBatchFetcher(fetcher, config).FetchAllPages(ctx, "/v1/test/job-deadline/")
+		if err == nil || errors.Is(err, ErrJobDeadlineExceeded) {
+			t.Errorf("FetchAllPages() error = %v, want caller's context error", err)
+		}
+	})
+}
@@ -0,0 +1,224 @@
+package pagination
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/codec"
+	"github.com/Sternrassler/eve-esi-client/pkg/pool"
+	"github.com/rs/zerolog/log"
+)
+
+// PageStreamer is optionally implemented by fetchers that can hand out the raw
+// response body of a page instead of a fully buffered []byte. GetPaginated uses
+// it to decode pages from pooled buffers or directly from the stream.
+type PageStreamer interface {
+	// StreamPage fetches a single page and returns its body and the total
+	// page count. The caller must close the body.
+	StreamPage(ctx context.Context, endpoint string, pageNum int) (body io.ReadCloser, totalPages int, err error)
+}
+
+// TypedConfig configures GetPaginated.
+type TypedConfig[T any] struct {
+	Config
+
+	// Stream decodes pages straight from the response body with a
+	// json.Decoder instead of buffering them first. Only effective if the
+	// fetcher implements PageStreamer.
+	Stream bool
+
+	// Key returns the identity of an item (e.g. order_id). Items with a key
+	// already seen on an earlier page are dropped; ESI pages can shift while
+	// a crawl is running. Nil disables deduplication.
+	Key func(T) int64
+
+	// Decoder replaces encoding/json for page decoding (default: codec.Std).
+	Decoder codec.Decoder
+}
+
+// bufferPool recycles page buffers between decodes.
+var bufferPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// maxPooledBuffer caps the size of buffers returned to the pool so a single
+// oversized page does not pin memory.
+const maxPooledBuffer = 16 << 20
+
+// GetPaginated fetches all pages of endpoint in parallel and decodes each page
+// into []T as soon as it arrives, so raw page bytes do not accumulate. Items
+// are returned in page order.
+//
+// On a page error the items of all successfully fetched pages are returned
+// together with the error (partial data).
+func GetPaginated[T any](ctx context.Context, fetcher PageFetcher, endpoint string, cfg TypedConfig[T]) ([]T, error) {
+	config := NewBatchFetcher(fetcher, cfg.Config).config
+	dec := cfg.Decoder
+	if dec == nil {
+		dec = codec.Std
+	}
+	start := time.Now()
+
+	ctx = ScopeOperation(ctx, fetcher)
+	parent := ctx
+	ctx, cancel := config.withJobDeadline(ctx)
+	defer cancel()
+
+	first, totalPages, err := decodePage[T](ctx, fetcher, endpoint, 1, cfg.Stream, dec, config)
+	if err != nil {
+		releaseAbandoned(parent, fetcher)
+		return nil, fmt.Errorf("failed to fetch first page: %w", jobError(parent, ctx, err))
+	}
+	if totalPages < 1 {
+		totalPages = 1
+	}
+
+	pages := make([][]T, totalPages)
+	pages[0] = first
+	progress := &BatchFetcher{config: config}
+	progress.reportProgress(1, totalPages, start)
+
+	completed := []int{1}
+	var firstErr error
+	if totalPages > 1 {
+		var rest []int
+		rest, firstErr = fetchTypedPages(ctx, fetcher, endpoint, pages, cfg.Stream, dec, config, func(completed int) {
+			progress.reportProgress(completed, totalPages, start)
+		})
+		completed = append(completed, rest...)
+	}
+
+	items := consolidate(pages, cfg.Key)
+
+	log.Debug().
+		Str("endpoint", endpoint).
+		Int("pages", totalPages).
+		Int("items", len(items)).
+		Dur("duration", time.Since(start)).
+		Msg("Typed paginated fetch complete")
+
+	if firstErr != nil {
+		releaseAbandoned(parent, fetcher)
+		return items, &PartialError{Endpoint: endpoint, Completed: completed, Total: totalPages, Err: jobError(parent, ctx, firstErr)}
+	}
+	return items, nil
+}
+
+// fetchTypedPages fetches pages 2..N into pages using the worker pool and
+// returns the page numbers fetched successfully. onPage is called serialized
+// with the number of completed pages.
+func fetchTypedPages[T any](ctx context.Context, fetcher PageFetcher, endpoint string, pages [][]T, stream bool, dec codec.Decoder, config Config, onPage func(completed int)) ([]int, error) {
+	cfg := pool.Config{
+		Workers: config.MaxConcurrency,
+		Progress: func(completed, total int) {
+			onPage(completed + 1)
+		},
+	}
+	results := pool.Run(ctx, len(pages)-1, cfg, func(ctx context.Context, i int) ([]T, error) {
+		items, _, err := decodePage[T](ctx, fetcher, endpoint, i+2, stream, dec, config)
+		return items, err
+	})
+
+	var completed []int
+	var firstErr error
+	for i, result := range results {
+		if result.Err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("page %d: %w", i+2, result.Err)
+			}
+			continue
+		}
+		pages[i+1] = result.Value
+		completed = append(completed, i+2)
+	}
+	if firstErr != nil && ctx.Err() != nil {
+		return completed, ctx.Err()
+	}
+	return completed, firstErr
+}
+
+// decodePage fetches and decodes a single page with the (adaptive) page
+// timeout, preferring the streaming path when the fetcher supports it.
+func decodePage[T any](ctx context.Context, fetcher PageFetcher, endpoint string, page int, stream bool, dec codec.Decoder, config Config) ([]T, int, error) {
+	var items []T
+
+	streamer, ok := fetcher.(PageStreamer)
+	if !ok {
+		data, totalPages, err := fetchPage(ctx, fetcher, endpoint, page, config)
+		if err != nil {
+			return nil, 0, err
+		}
+		if err := dec.Unmarshal(data, &items); err != nil {
+			return nil, 0, fmt.Errorf("decode page %d: %w", page, err)
+		}
+		return items, totalPages, nil
+	}
+
+	pageCtx, cancel := context.WithTimeout(ctx, config.pageTimeout(endpoint))
+	defer cancel()
+
+	start := time.Now()
+	body, totalPages, err := streamer.StreamPage(pageCtx, endpoint, page)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer body.Close()
+
+	if stream {
+		if err := dec.NewStreamDecoder(body).Decode(&items); err != nil {
+			return nil, 0, fmt.Errorf("decode page %d: %w", page, err)
+		}
+		recentLatencies.observe(endpoint, time.Since(start))
+		return items, totalPages, nil
+	}
+
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer func() {
+		if buf.Cap() <= maxPooledBuffer {
+			bufferPool.Put(buf)
+		}
+	}()
+
+	if _, err := buf.ReadFrom(body); err != nil {
+		return nil, 0, fmt.Errorf("read page %d: %w", page, err)
+	}
+	recentLatencies.observe(endpoint, time.Since(start))
+	if err := dec.Unmarshal(buf.Bytes(), &items); err != nil {
+		return nil, 0, fmt.Errorf("decode page %d: %w", page, err)
+	}
+	return items, totalPages, nil
+}
+
+// consolidate concatenates pages in order, dropping duplicate keys.
+func consolidate[T any](pages [][]T, key func(T) int64) []T {
+	total := 0
+	for _, page := range pages {
+		total += len(page)
+	}
+
+	items := make([]T, 0, total)
+	if key == nil {
+		for _, page := range pages {
+			items = append(items, page...)
+		}
+		return items
+	}
+
+	seen := make(map[int64]struct{}, total)
+	for _, page := range pages {
+		for _, item := range page {
+			k := key(item)
+			if _, dup := seen[k]; dup {
+				continue
+			}
+			seen[k] = struct{}{}
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,137 @@
+package pagination
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/Sternrassler/eve-esi-client/pkg/codec"
+)
+
+type item struct {
+	ID int64 `json:"id"`
+}
+
+// pagedFetcher serves JSON pages from a fixed slice of page bodies.
+type pagedFetcher struct {
+	pages []string
+	fail  int
+}
+
+func (f *pagedFetcher) FetchPage(ctx context.Context, endpoint string, pageNum int) ([]byte, int, error) {
+	if pageNum == f.fail {
+		return nil, 0, errors.New("boom")
+	}
+	return []byte(f.pages[pageNum-1]), len(f.pages), nil
+}
+
+// streamingFetcher additionally implements PageStreamer.
+type streamingFetcher struct {
+	pagedFetcher
+	streamed int
+}
+
+func (f *streamingFetcher) StreamPage(ctx context.Context, endpoint string, pageNum int) (io.ReadCloser, int, error) {
+	f.streamed++
+	return io.NopCloser(strings.NewReader(f.pages[pageNum-1])), len(f.pages), nil
+}
+
+func TestGetPaginated(t *testing.T) {
+	pages := []string{
+		`[{"id":1},{"id":2}]`,
+		`[{"id":2},{"id":3}]`,
+		`[{"id":4}]`,
+	}
+
+	tests := []struct {
+		name    string
+		fetcher PageFetcher
+		stream  bool
+		dedupe  bool
+		want    []int64
+	}{
+		{"plain fetcher", &pagedFetcher{pages: pages}, false, false, []int64{1, 2, 2, 3, 4}},
+		{"plain fetcher dedupe", &pagedFetcher{pages: pages}, false, true, []int64{1, 2, 3, 4}},
+		{"pooled buffers", &streamingFetcher{pagedFetcher: pagedFetcher{pages: pages}}, false, true, []int64{1, 2, 3, 4}},
+		{"streaming decode", &streamingFetcher{pagedFetcher: pagedFetcher{pages: pages}}, true, true, []int64{1, 2, 3, 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := TypedConfig[item]{Config: DefaultConfig(), Stream: tt.stream}
+			cfg.MaxConcurrency = 1
+			if tt.dedupe {
+				cfg.Key = func(i item) int64 { return i.ID }
+			}
+
+			got, err := GetPaginated(context.Background(), tt.fetcher, "/test", cfg)
+			if err != nil {
+				t.Fatalf("GetPaginated() error = %v", err)
+			}
+			if ids := idsOf(got); fmt.Sprint(ids) != fmt.Sprint(tt.want) {
+				t.Errorf("GetPaginated() = %v, want %v", ids, tt.want)
+			}
+			if sf, ok := tt.fetcher.(*streamingFetcher); ok && sf.streamed != len(pages) {
+				t.Errorf("streamed %d pages, want %d", sf.streamed, len(pages))
+			}
+		})
+	}
+}
+
+func TestGetPaginated_PartialData(t *testing.T) {
+	fetcher := &pagedFetcher{
+		pages: []string{`[{"id":1}]`, `[{"id":2}]`, `[{"id":3}]`},
+		fail:  2,
+	}
+
+	got, err := GetPaginated(context.Background(), fetcher, "/test", TypedConfig[item]{})
+	if err == nil {
+		t.Fatal("GetPaginated() expected error")
+	}
+	if ids := idsOf(got); fmt.Sprint(ids) != "[1 3]" {
+		t.Errorf("partial items = %v, want [1 3]", ids)
+	}
+}
+
+// countingDecoder wraps codec.Std and counts Unmarshal calls.
+type countingDecoder struct {
+	codec.Decoder
+	calls int
+}
+
+func (d *countingDecoder) Unmarshal(data []byte, v any) error {
+	d.calls++
+	return d.Decoder.Unmarshal(data, v)
+}
+
+func TestGetPaginated_CustomDecoder(t *testing.T) {
+	fetcher := &pagedFetcher{pages: []string{`[{"id":1}]`, `[{"id":2}]`}}
+	dec := &countingDecoder{Decoder: codec.Std}
+
+	cfg := TypedConfig[item]{Decoder: dec}
+	if _, err := GetPaginated(context.Background(), fetcher, "/test", cfg); err != nil {
+		t.Fatalf("GetPaginated() error = %v", err)
+	}
+	if dec.calls != 2 {
+		t.Errorf("decoder calls = %d, want 2", dec.calls)
+	}
+}
+
+func TestGetPaginated_DecodeError(t *testing.T) {
+	fetcher := &pagedFetcher{pages: []string{`not json`}}
+
+	if _, err := GetPaginated(context.Background(), fetcher, "/test", TypedConfig[item]{}); err == nil {
+		t.Fatal("GetPaginated() expected decode error")
+	}
+}
+
+func idsOf(items []item) []int64 {
+	ids := make([]int64, len(items))
+	for i, it := range items {
+		ids[i] = it.ID
+	}
+	return ids
+}
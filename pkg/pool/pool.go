@@ -0,0 +1,145 @@
+// Package pool runs indexed tasks with bounded concurrency.
+//
+// It is the worker pool behind pagination, the client's GetMany, market
+// crawls and the esi-proxy cache warm-up: results are returned in task
+// order, failed tasks can be retried, a failure budget stops dispatching
+// further tasks, and progress is reported serialized.
+//
+//	results := pool.Run(ctx, len(ids), pool.Config{Workers: 5}, func(ctx context.Context, i int) ([]byte, error) {
+//		return fetch(ctx, ids[i])
+//	})
+//	for i, r := range results {
+//		if r.Err != nil {
+//			log.Printf("%d failed: %v", ids[i], r.Err)
+//		}
+//	}
+package pool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrSkipped is the error of tasks that were not started because the pool's
+// failure budget (Config.MaxFailures) was used up.
+var ErrSkipped = errors.New("task skipped: failure budget exhausted")
+
+// Config controls a Run.
+type Config struct {
+	// Workers is the maximum number of tasks running at once (default: 1)
+	Workers int
+
+	// Retries is the number of extra attempts of a failed task (default: 0)
+	Retries int
+
+	// RetryDelay is the wait before the first retry, doubled for each
+	// further retry
+	RetryDelay time.Duration
+
+	// Retryable reports whether a task error is worth retrying (default:
+	// every error except context cancellation and deadline)
+	Retryable func(error) bool
+
+	// MaxFailures stops dispatching once this many tasks have failed; the
+	// remaining tasks fail with ErrSkipped (0 = unlimited)
+	MaxFailures int
+
+	// Progress is called after every successful task with the number of
+	// successful tasks so far and the task count (optional). Calls are
+	// serialized and must not block for long.
+	Progress func(completed, total int)
+}
+
+// Result is the outcome of one task.
+type Result[T any] struct {
+	Value    T     // Value returned by the last attempt (also set on error)
+	Err      error // Error of the last attempt, ErrSkipped or the context error
+	Attempts int   // Attempts made (0 if the task was not started)
+}
+
+// Run executes task for the indices 0..n-1 with at most Config.Workers tasks
+// in flight and returns the results in index order, successful or not
+// (partial results). Tasks not started because ctx is done fail with the
+// context error; Run itself always waits for all started tasks.
+func Run[T any](ctx context.Context, n int, cfg Config, task func(ctx context.Context, i int) (T, error)) []Result[T] {
+	results := make([]Result[T], n)
+	if n == 0 {
+		return results
+	}
+
+	var (
+		failures  atomic.Int64
+		mu        sync.Mutex
+		completed int
+		wg        sync.WaitGroup
+	)
+
+	jobs := make(chan int)
+	for range min(max(cfg.Workers, 1), n) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if cfg.MaxFailures > 0 && failures.Load() >= int64(cfg.MaxFailures) {
+					results[i].Err = ErrSkipped
+					continue
+				}
+				if err := ctx.Err(); err != nil {
+					results[i].Err = err
+					continue
+				}
+
+				results[i] = runTask(ctx, cfg, i, task)
+				if results[i].Err != nil {
+					failures.Add(1)
+					continue
+				}
+
+				if cfg.Progress != nil {
+					mu.Lock()
+					completed++
+					cfg.Progress(completed, n)
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	// Workers drain the queue even after cancellation, so this never blocks
+	for i := range n {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// runTask runs one task with the configured retries.
+func runTask[T any](ctx context.Context, cfg Config, i int, task func(ctx context.Context, i int) (T, error)) Result[T] {
+	delay := cfg.RetryDelay
+	for attempt := 1; ; attempt++ {
+		value, err := task(ctx, i)
+		if err == nil || attempt > cfg.Retries || !retryable(cfg, err) {
+			return Result[T]{Value: value, Err: err, Attempts: attempt}
+		}
+
+		select {
+		case <-ctx.Done():
+			return Result[T]{Value: value, Err: err, Attempts: attempt}
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// retryable applies Config.Retryable or the default policy.
+func retryable(cfg Config, err error) bool {
+	if cfg.Retryable != nil {
+		return cfg.Retryable(err)
+	}
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
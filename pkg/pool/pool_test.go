@@ -0,0 +1,157 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRun_OrderAndConcurrency(t *testing.T) {
+	var running, peak atomic.Int64
+
+	results := Run(context.Background(), 20, Config{Workers: 3}, func(ctx context.Context, i int) (int, error) {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		running.Add(-1)
+		return i * i, nil
+	})
+
+	if len(results) != 20 {
+		t.Fatalf("len(results) = %d, want 20", len(results))
+	}
+	for i, r := range results {
+		if r.Err != nil || r.Value != i*i || r.Attempts != 1 {
+			t.Errorf("results[%d] = %+v, want value %d after 1 attempt", i, r, i*i)
+		}
+	}
+	if p := peak.Load(); p > 3 {
+		t.Errorf("peak concurrency = %d, want <= 3", p)
+	}
+}
+
+func TestRun_Retries(t *testing.T) {
+	errTemporary := errors.New("temporary")
+	errPermanent := errors.New("permanent")
+
+	tests := []struct {
+		name         string
+		failures     int // Attempts failing before success
+		err          error
+		wantErr      error
+		wantAttempts int
+	}{
+		{"succeeds after retries", 2, errTemporary, nil, 3},
+		{"retries exhausted", 5, errTemporary, errTemporary, 3},
+		{"not retryable", 5, errPermanent, errPermanent, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int64
+			cfg := Config{
+				Retries:    2,
+				RetryDelay: time.Millisecond,
+				Retryable:  func(err error) bool { return errors.Is(err, errTemporary) },
+			}
+			results := Run(context.Background(), 1, cfg, func(ctx context.Context, i int) (string, error) {
+				if calls.Add(1) <= int64(tt.failures) {
+					return "", tt.err
+				}
+				return "ok", nil
+			})
+
+			r := results[0]
+			if !errors.Is(r.Err, tt.wantErr) || r.Attempts != tt.wantAttempts {
+				t.Errorf("result = %+v, want error %v after %d attempts", r, tt.wantErr, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestRun_MaxFailures(t *testing.T) {
+	var calls atomic.Int64
+	results := Run(context.Background(), 10, Config{Workers: 1, MaxFailures: 2}, func(ctx context.Context, i int) (int, error) {
+		calls.Add(1)
+		return 0, errors.New("boom")
+	})
+
+	if n := calls.Load(); n != 2 {
+		t.Errorf("tasks run = %d, want 2", n)
+	}
+	for i, r := range results[2:] {
+		if !errors.Is(r.Err, ErrSkipped) || r.Attempts != 0 {
+			t.Errorf("results[%d] = %+v, want ErrSkipped", i+2, r)
+		}
+	}
+}
+
+func TestRun_Cancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	results := Run(ctx, 10, Config{Workers: 1}, func(ctx context.Context, i int) (int, error) {
+		if i == 2 {
+			cancel()
+		}
+		return i, nil
+	})
+
+	for i, r := range results {
+		if i <= 2 && r.Err != nil {
+			t.Errorf("results[%d].Err = %v, want nil (started before cancel)", i, r.Err)
+		}
+		if i > 2 && !errors.Is(r.Err, context.Canceled) {
+			t.Errorf("results[%d].Err = %v, want context.Canceled", i, r.Err)
+		}
+	}
+}
+
+func TestRun_Progress(t *testing.T) {
+	var mu sync.Mutex
+	var calls []int
+
+	cfg := Config{
+		Workers: 4,
+		Progress: func(completed, total int) {
+			mu.Lock()
+			defer mu.Unlock()
+			if total != 8 {
+				t.Errorf("total = %d, want 8", total)
+			}
+			calls = append(calls, completed)
+		},
+	}
+	Run(context.Background(), 8, cfg, func(ctx context.Context, i int) (int, error) {
+		if i == 5 {
+			return 0, errors.New("boom")
+		}
+		return i, nil
+	})
+
+	if len(calls) != 7 {
+		t.Fatalf("progress calls = %d, want 7 (successful tasks only)", len(calls))
+	}
+	for i, completed := range calls {
+		if completed != i+1 {
+			t.Errorf("progress call %d completed = %d, want %d", i, completed, i+1)
+		}
+	}
+}
+
+func TestRun_Empty(t *testing.T) {
+	results := Run(context.Background(), 0, Config{}, func(ctx context.Context, i int) (int, error) {
+		t.Fatal("task called for empty run")
+		return 0, nil
+	})
+	if len(results) != 0 {
+		t.Errorf("len(results) = %d, want 0", len(results))
+	}
+}
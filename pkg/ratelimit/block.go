@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/redis/go-redis/v9"
+)
+
+// StatusErrorLimited is ESI's status for requests sent after the error limit
+// was exhausted.
+const StatusErrorLimited = 420
+
+// esiRateLimitSharedBlocksTotal counts 420 responses published as a shared block.
+var esiRateLimitSharedBlocksTotal = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "esi_rate_limit_shared_blocks_total",
+	Help: "Total number of 420 responses published as a block shared by all instances",
+})
+
+// extendBlockScript sets the block end (ARGV[1], Unix ms) with TTL ARGV[2]
+// (ms) unless a later end is already stored, so concurrent 420s can only
+// extend a block.
+var extendBlockScript = redis.NewScript(`
+local current = tonumber(redis.call('GET', KEYS[1]) or '0')
+if current >= tonumber(ARGV[1]) then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+return 1
+`)
+
+// RecordRateLimited publishes a block in Redis after ESI answered 420, so
+// every instance stops sending requests until the error limit window resets,
+// not only the one that saw the response. The block lasts for
+// X-ESI-Error-Limit-Reset (or Retry-After) seconds, ErrorLimitWindow if
+// neither is present, and clears itself when its key expires. Other status
+// codes are ignored.
+func (t *Tracker) RecordRateLimited(ctx context.Context, statusCode int, headers http.Header) error {
+	if statusCode != StatusErrorLimited {
+		return nil
+	}
+
+	wait := ErrorLimitWindow
+	for _, name := range []string{"X-ESI-Error-Limit-Reset", "Retry-After"} {
+		if seconds, err := strconv.Atoi(headers.Get(name)); err == nil && seconds > 0 {
+			wait = time.Duration(seconds) * time.Second
+			break
+		}
+	}
+	until := time.Now().Add(wait)
+
+	if err := extendBlockScript.Run(ctx, t.redis, []string{RedisKeyBlockedUntil}, until.UnixMilli(), wait.Milliseconds()).Err(); err != nil {
+		return fmt.Errorf("store rate limit block: %w", err)
+	}
+	esiRateLimitSharedBlocksTotal.Inc()
+
+	t.logger.Error().
+		Time("blocked_until", until).
+		Msg("ESI answered 420 - blocking requests on all instances")
+	return nil
+}
+
+// blockedUntil returns the end of the shared 420 block, zero if none.
+func (t *Tracker) blockedUntil(ctx context.Context) (time.Time, error) {
+	ms, err := t.redis.Get(ctx, RedisKeyBlockedUntil).Int64()
+	if err == redis.Nil {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("get rate limit block: %w", err)
+	}
+	return time.UnixMilli(ms), nil
+}
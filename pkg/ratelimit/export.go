@@ -0,0 +1,41 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ExportState returns the rate limit state as stored in Redis, without local
+// adjustments, for migrating it to another Redis instance (blue-green
+// deploys). Returns nil if no state has been stored yet.
+func (t *Tracker) ExportState(ctx context.Context) (*RateLimitState, error) {
+	state, found, err := t.loadState(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("export rate limit state: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+	return state, nil
+}
+
+// ImportState stores a previously exported state. States whose window has
+// already reset carry no information and are skipped; imported reports
+// whether the state was written.
+func (t *Tracker) ImportState(ctx context.Context, state *RateLimitState) (imported bool, err error) {
+	if state == nil || !state.ResetAt.After(time.Now()) {
+		return false, nil
+	}
+
+	if err := t.storeState(ctx, state); err != nil {
+		return false, fmt.Errorf("import rate limit state: %w", err)
+	}
+	esiErrorsRemaining.Set(float64(state.ErrorsRemaining))
+
+	t.logger.Info().
+		Int("errors_remaining", state.ErrorsRemaining).
+		Time("reset_at", state.ResetAt).
+		Msg("Imported ESI error limit state")
+	return true, nil
+}
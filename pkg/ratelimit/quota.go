@@ -0,0 +1,186 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+)
+
+// RedisKeyQuotaPrefix is the prefix for soft quota counters.
+// Full key format: esi:quota:<name>:<window start unix>
+const RedisKeyQuotaPrefix = "esi:quota"
+
+// DefaultQuotaWarnRatio is the usage ratio at which a quota warning is logged.
+const DefaultQuotaWarnRatio = 0.8
+
+// ErrQuotaExceeded is returned when an enforced soft quota is used up.
+var ErrQuotaExceeded = errors.New("soft request quota exceeded")
+
+// Prometheus metrics for soft quotas.
+var (
+	esiQuotaUsed = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "esi_quota_used",
+		Help: "Requests counted against the soft quota in the current window",
+	}, []string{"quota"})
+
+	esiQuotaLimit = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "esi_quota_limit",
+		Help: "Configured soft quota limit per window",
+	}, []string{"quota"})
+
+	esiQuotaExceededTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "esi_quota_exceeded_total",
+		Help: "Total requests issued (or rejected, if enforced) beyond the soft quota",
+	}, []string{"quota", "enforced"})
+)
+
+// QuotaConfig defines a soft request quota shared by all instances of a
+// deployment via Redis. Quotas cap the ESI footprint independently of the
+// error limit.
+type QuotaConfig struct {
+	// Name identifies the quota in Redis keys and metrics (e.g. "hourly", "daily").
+	Name string
+
+	// Window is the quota period. Windows are aligned to the Unix epoch (UTC),
+	// so a 24h window resets at 00:00 UTC.
+	Window time.Duration
+
+	// Limit is the number of requests allowed per window.
+	Limit int64
+
+	// WarnRatio logs a warning once usage reaches this fraction of Limit
+	// (default: DefaultQuotaWarnRatio).
+	WarnRatio float64
+
+	// Enforce rejects requests beyond Limit with ErrQuotaExceeded.
+	// When false, excess requests are only logged and counted.
+	Enforce bool
+}
+
+// QuotaUsage is the result of consuming one request from a quota.
+type QuotaUsage struct {
+	Name     string
+	Used     int64
+	Limit    int64
+	ResetsAt time.Time
+}
+
+// Exceeded returns true if the quota is used beyond its limit.
+func (u QuotaUsage) Exceeded() bool {
+	return u.Used > u.Limit
+}
+
+// Quota tracks a soft request quota in Redis.
+type Quota struct {
+	redis  *redis.Client
+	config QuotaConfig
+	logger zerolog.Logger
+}
+
+// NewQuota creates a soft quota tracker.
+func NewQuota(redisClient *redis.Client, cfg QuotaConfig, logger zerolog.Logger) (*Quota, error) {
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("quota name is required")
+	}
+	if cfg.Window <= 0 {
+		return nil, fmt.Errorf("quota %s: window must be > 0", cfg.Name)
+	}
+	if cfg.Limit <= 0 {
+		return nil, fmt.Errorf("quota %s: limit must be > 0", cfg.Name)
+	}
+	if cfg.WarnRatio <= 0 || cfg.WarnRatio > 1 {
+		cfg.WarnRatio = DefaultQuotaWarnRatio
+	}
+
+	esiQuotaLimit.WithLabelValues(cfg.Name).Set(float64(cfg.Limit))
+
+	return &Quota{
+		redis:  redisClient,
+		config: cfg,
+		logger: logger.With().Str("quota", cfg.Name).Logger(),
+	}, nil
+}
+
+// windowStart returns the start of the quota window containing t.
+func (q *Quota) windowStart(t time.Time) time.Time {
+	return t.UTC().Truncate(q.config.Window)
+}
+
+// key returns the Redis key for the window starting at start.
+func (q *Quota) key(start time.Time) string {
+	return fmt.Sprintf("%s:%s:%d", RedisKeyQuotaPrefix, q.config.Name, start.Unix())
+}
+
+// Consume counts one request against the quota. It returns ErrQuotaExceeded
+// if the quota is enforced and already used up.
+func (q *Quota) Consume(ctx context.Context) (QuotaUsage, error) {
+	start := q.windowStart(time.Now())
+	key := q.key(start)
+
+	pipe := q.redis.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	// Keep the counter slightly longer than the window for late readers
+	pipe.Expire(ctx, key, q.config.Window+time.Minute)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return QuotaUsage{}, fmt.Errorf("increment quota %s: %w", q.config.Name, err)
+	}
+
+	usage := QuotaUsage{
+		Name:     q.config.Name,
+		Used:     incr.Val(),
+		Limit:    q.config.Limit,
+		ResetsAt: start.Add(q.config.Window),
+	}
+	esiQuotaUsed.WithLabelValues(q.config.Name).Set(float64(usage.Used))
+
+	// Warn exactly once per window when crossing the warning threshold
+	warnAt := int64(math.Ceil(float64(q.config.Limit) * q.config.WarnRatio))
+	if usage.Used == warnAt {
+		q.logger.Warn().
+			Int64("used", usage.Used).
+			Int64("limit", usage.Limit).
+			Time("resets_at", usage.ResetsAt).
+			Msg("Soft request quota warning threshold reached")
+	}
+
+	if usage.Exceeded() {
+		esiQuotaExceededTotal.WithLabelValues(q.config.Name, fmt.Sprintf("%t", q.config.Enforce)).Inc()
+		if usage.Used == usage.Limit+1 {
+			q.logger.Error().
+				Int64("limit", usage.Limit).
+				Bool("enforced", q.config.Enforce).
+				Time("resets_at", usage.ResetsAt).
+				Msg("Soft request quota exceeded")
+		}
+		if q.config.Enforce {
+			return usage, fmt.Errorf("%w: %s (%d/%d, resets at %s)",
+				ErrQuotaExceeded, q.config.Name, usage.Used, usage.Limit, usage.ResetsAt.Format(time.RFC3339))
+		}
+	}
+
+	return usage, nil
+}
+
+// Usage returns the current usage without consuming a request.
+func (q *Quota) Usage(ctx context.Context) (QuotaUsage, error) {
+	start := q.windowStart(time.Now())
+
+	used, err := q.redis.Get(ctx, q.key(start)).Int64()
+	if err != nil && err != redis.Nil {
+		return QuotaUsage{}, fmt.Errorf("get quota %s: %w", q.config.Name, err)
+	}
+
+	return QuotaUsage{
+		Name:     q.config.Name,
+		Used:     used,
+		Limit:    q.config.Limit,
+		ResetsAt: start.Add(q.config.Window),
+	}, nil
+}
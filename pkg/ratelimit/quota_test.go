@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestNewQuota_Validation(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         QuotaConfig
+		expectError bool
+	}{
+		{"valid hourly", QuotaConfig{Name: "hourly", Window: time.Hour, Limit: 1000}, false},
+		{"missing name", QuotaConfig{Window: time.Hour, Limit: 1000}, true},
+		{"zero window", QuotaConfig{Name: "hourly", Limit: 1000}, true},
+		{"zero limit", QuotaConfig{Name: "hourly", Window: time.Hour}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewQuota(nil, tt.cfg, zerolog.Nop())
+			if (err != nil) != tt.expectError {
+				t.Errorf("NewQuota() error = %v, expectError %v", err, tt.expectError)
+			}
+		})
+	}
+}
+
+func TestNewQuota_DefaultWarnRatio(t *testing.T) {
+	q, err := NewQuota(nil, QuotaConfig{Name: "daily", Window: 24 * time.Hour, Limit: 10}, zerolog.Nop())
+	if err != nil {
+		t.Fatalf("NewQuota() error = %v", err)
+	}
+	if q.config.WarnRatio != DefaultQuotaWarnRatio {
+		t.Errorf("WarnRatio = %v, want %v", q.config.WarnRatio, DefaultQuotaWarnRatio)
+	}
+}
+
+func TestQuota_WindowAlignment(t *testing.T) {
+	q, _ := NewQuota(nil, QuotaConfig{Name: "daily", Window: 24 * time.Hour, Limit: 10}, zerolog.Nop())
+
+	now := time.Date(2025, 10, 27, 15, 30, 0, 0, time.UTC)
+	start := q.windowStart(now)
+
+	want := time.Date(2025, 10, 27, 0, 0, 0, 0, time.UTC)
+	if !start.Equal(want) {
+		t.Errorf("windowStart() = %v, want %v", start, want)
+	}
+	if key := q.key(start); key != "esi:quota:daily:1761523200" {
+		t.Errorf("key() = %q, want esi:quota:daily:1761523200", key)
+	}
+}
+
+func TestQuotaUsage_Exceeded(t *testing.T) {
+	if (QuotaUsage{Used: 10, Limit: 10}).Exceeded() {
+		t.Error("Exceeded() should be false at the limit")
+	}
+	if !(QuotaUsage{Used: 11, Limit: 10}).Exceeded() {
+		t.Error("Exceeded() should be true beyond the limit")
+	}
+}
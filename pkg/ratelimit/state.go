@@ -12,6 +12,10 @@ const (
 	RedisKeyErrorsRemaining = "esi:rate_limit:errors_remaining"
 	RedisKeyResetTimestamp  = "esi:rate_limit:reset_timestamp"
 	RedisKeyLastUpdate      = "esi:rate_limit:last_update"
+
+	// RedisKeyBlockedUntil holds the end of a shared block (Unix ms) after
+	// ESI answered 420. It expires with the block.
+	RedisKeyBlockedUntil = "esi:rate_limit:blocked_until"
 )
 
 // Thresholds for rate limit decisions.
@@ -27,8 +31,25 @@ const (
 	// ErrorThresholdHealthy indicates normal operation.
 	// When errors remaining is at or above this value, no restrictions apply.
 	ErrorThresholdHealthy = 50
+
+	// UnknownStateErrorsRemaining is assumed while no current state exists
+	// (fresh Redis, or keys expired after downtime). Requests are allowed
+	// without throttling, but the state is not healthy, so optional extra
+	// load such as request hedging stays off until real headers arrive.
+	UnknownStateErrorsRemaining = ErrorThresholdWarning
+)
+
+// ESI error limit window: ErrorLimitBudget errors per ErrorLimitWindow.
+const (
+	ErrorLimitBudget = 100
+	ErrorLimitWindow = 60 * time.Second
 )
 
+// StateKeyGrace is how long rate limit keys outlive the reset of the window
+// they describe. After that the state is gone and treated as unknown instead
+// of driving decisions with ancient data.
+const StateKeyGrace = 30 * time.Second
+
 // RateLimitState represents the current ESI error rate limit state.
 // This state is shared across all client instances via Redis.
 type RateLimitState struct {
@@ -47,6 +68,14 @@ type RateLimitState struct {
 	// IsHealthy indicates whether the error limit is in a healthy state.
 	// True when ErrorsRemaining >= ErrorThresholdHealthy.
 	IsHealthy bool `json:"is_healthy"`
+
+	// Unknown is true if no current state was found and the cautious
+	// default (UnknownStateErrorsRemaining) is used.
+	Unknown bool `json:"unknown,omitempty"`
+
+	// BlockedUntil is set while a 420 seen by any instance blocks all
+	// requests (see Tracker.RecordRateLimited).
+	BlockedUntil time.Time `json:"blocked_until,omitempty"`
 }
 
 // IsStale returns true if the state data is older than the given duration.
@@ -55,9 +84,15 @@ func (s *RateLimitState) IsStale(maxAge time.Duration) bool {
 	return time.Since(s.LastUpdate) > maxAge
 }
 
-// NeedsCriticalBlock returns true if requests should be blocked due to critical error limit.
+// NeedsCriticalBlock returns true if requests should be blocked due to
+// critical error limit or a shared 420 block.
 func (s *RateLimitState) NeedsCriticalBlock() bool {
-	return s.ErrorsRemaining < ErrorThresholdCritical
+	return s.ErrorsRemaining < ErrorThresholdCritical || s.IsBlocked()
+}
+
+// IsBlocked reports whether a shared 420 block is in effect.
+func (s *RateLimitState) IsBlocked() bool {
+	return time.Now().Before(s.BlockedUntil)
 }
 
 // NeedsThrottling returns true if requests should be throttled due to warning threshold.
@@ -65,17 +100,46 @@ func (s *RateLimitState) NeedsThrottling() bool {
 	return s.ErrorsRemaining < ErrorThresholdWarning && !s.NeedsCriticalBlock()
 }
 
-// TimeUntilReset returns the duration until the error limit resets.
-// Returns 0 if the reset time has already passed.
+// TimeUntilReset returns the duration until the error limit resets (or a
+// longer shared 420 block ends). Returns 0 if the reset time has already
+// passed.
 func (s *RateLimitState) TimeUntilReset() time.Duration {
 	duration := time.Until(s.ResetAt)
+	if s.IsBlocked() {
+		duration = max(duration, time.Until(s.BlockedUntil))
+	}
 	if duration < 0 {
 		return 0
 	}
 	return duration
 }
 
-// UpdateHealth updates the IsHealthy field based on current ErrorsRemaining.
+// UpdateHealth updates the IsHealthy field based on current ErrorsRemaining
+// and a shared 420 block.
 func (s *RateLimitState) UpdateHealth() {
-	s.IsHealthy = s.ErrorsRemaining >= ErrorThresholdHealthy
+	s.IsHealthy = s.ErrorsRemaining >= ErrorThresholdHealthy && !s.IsBlocked()
+}
+
+// TimeToCritical forecasts when ErrorsRemaining falls below
+// ErrorThresholdCritical if errors keep arriving at the rate observed so far
+// in the current window, measured from LastUpdate. ok is false if the budget
+// is projected to last until the window resets (or nothing was consumed).
+func (s *RateLimitState) TimeToCritical() (d time.Duration, ok bool) {
+	if s.NeedsCriticalBlock() {
+		return 0, true
+	}
+
+	consumed := ErrorLimitBudget - s.ErrorsRemaining
+	untilReset := s.ResetAt.Sub(s.LastUpdate)
+	elapsed := ErrorLimitWindow - untilReset
+	if consumed <= 0 || elapsed <= 0 {
+		return 0, false
+	}
+
+	perError := elapsed / time.Duration(consumed)
+	d = perError * time.Duration(s.ErrorsRemaining-ErrorThresholdCritical+1)
+	if d >= untilReset {
+		return 0, false
+	}
+	return d, true
 }
@@ -52,6 +52,7 @@ func TestRateLimitState_NeedsCriticalBlock(t *testing.T) {
 	tests := []struct {
 		name            string
 		errorsRemaining int
+		blockedUntil    time.Time
 		expected        bool
 	}{
 		{
@@ -59,6 +60,18 @@ func TestRateLimitState_NeedsCriticalBlock(t *testing.T) {
 			errorsRemaining: 50,
 			expected:        false,
 		},
+		{
+			name:            "shared 420 block",
+			errorsRemaining: 50,
+			blockedUntil:    time.Now().Add(time.Minute),
+			expected:        true,
+		},
+		{
+			name:            "shared 420 block ended",
+			errorsRemaining: 50,
+			blockedUntil:    time.Now().Add(-time.Second),
+			expected:        false,
+		},
 		{
 			name:            "at critical threshold",
 			errorsRemaining: ErrorThresholdCritical,
@@ -80,6 +93,7 @@ func TestRateLimitState_NeedsCriticalBlock(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			state := &RateLimitState{
 				ErrorsRemaining: tt.errorsRemaining,
+				BlockedUntil:    tt.blockedUntil,
 			}
 			result := state.NeedsCriticalBlock()
 			if result != tt.expected {
@@ -241,3 +255,39 @@ func TestThresholdConstants(t *testing.T) {
 			ErrorThresholdWarning, ErrorThresholdHealthy)
 	}
 }
+
+func TestRateLimitState_TimeToCritical(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name       string
+		remaining  int
+		resetIn    time.Duration
+		wantOK     bool
+		wantApprox time.Duration
+	}{
+		// 60 errors in 30s (2/s): 36 more errors reach 4 remaining in 18s
+		{"fast consumption", 40, 30 * time.Second, true, 18 * time.Second},
+		// 10 errors in 50s: budget outlasts the remaining 10s
+		{"slow consumption", 90, 10 * time.Second, false, 0},
+		{"nothing consumed", ErrorLimitBudget, 30 * time.Second, false, 0},
+		{"already critical", ErrorThresholdCritical - 1, 30 * time.Second, true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := &RateLimitState{
+				ErrorsRemaining: tt.remaining,
+				ResetAt:         now.Add(tt.resetIn),
+				LastUpdate:      now,
+			}
+			d, ok := state.TimeToCritical()
+			if ok != tt.wantOK {
+				t.Fatalf("TimeToCritical() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if diff := d - tt.wantApprox; diff < -time.Second || diff > time.Second {
+				t.Errorf("TimeToCritical() = %v, want about %v", d, tt.wantApprox)
+			}
+		})
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -35,12 +37,37 @@ var (
 		Name: "esi_rate_limit_resets_total",
 		Help: "Total number of error limit resets",
 	})
+
+	esiRateLimitUnconfirmedErrors = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "esi_rate_limit_unconfirmed_errors",
+		Help: "Error responses counted locally since the last header update (pessimistically deducted)",
+	})
+
+	esiErrorBudgetMinutesToCritical = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "esi_error_budget_minutes_to_critical",
+		Help: "Forecast minutes until errors remaining falls below the critical threshold at the current error rate (+Inf if the budget lasts until the window resets)",
+	})
+
+	esiRateLimitStaleHeadersTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "esi_rate_limit_stale_headers_total",
+		Help: "Total error limit header updates ignored because a later response already reported fewer errors remaining",
+	})
 )
 
+// windowTolerance is the maximum reset time difference of two header updates
+// that belong to the same error limit window.
+const windowTolerance = 2 * time.Second
+
 // Tracker monitors ESI error rate limits and gates requests.
 type Tracker struct {
 	redis  *redis.Client
 	logger zerolog.Logger
+
+	// unconfirmedErrors counts error responses observed by this process since
+	// the last header update. They are deducted from the stored estimate so
+	// the tracker never overestimates the remaining budget.
+	mu                sync.Mutex
+	unconfirmedErrors int
 }
 
 // NewTracker creates a new rate limit tracker.
@@ -52,50 +79,134 @@ func NewTracker(redisClient *redis.Client, logger zerolog.Logger) *Tracker {
 }
 
 // GetState retrieves the current rate limit state from Redis.
-// Returns a default healthy state if no data exists in Redis.
+// Returns a cautious default state (Unknown, not healthy) if no current data
+// exists in Redis or the stored window has ended. Errors counted locally
+// since the last header update are deducted, and a shared 420 block is
+// applied.
 func (t *Tracker) GetState(ctx context.Context) (*RateLimitState, error) {
-	// Fetch all state fields from Redis
+	state, found, err := t.loadState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// ESI refills the budget when the window ends (ADR-006): the stored
+	// state stays for reset detection but no longer blocks requests
+	if found && !state.ResetAt.After(time.Now()) {
+		found = false
+	}
+
+	// If no current state exists in Redis, be cautious until headers arrive
+	if !found {
+		t.logger.Debug().Msg("No rate limit state in Redis, returning cautious default state")
+		state = &RateLimitState{
+			ErrorsRemaining: UnknownStateErrorsRemaining,
+			ResetAt:         time.Now().Add(60 * time.Second),
+			LastUpdate:      time.Now(),
+			Unknown:         true,
+		}
+		state.UpdateHealth()
+	}
+
+	state.BlockedUntil, err = t.blockedUntil(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	t.applyUnconfirmedErrors(state)
+	return state, nil
+}
+
+// loadState reads the stored rate limit state from Redis without local
+// adjustments. found is false if no current state exists: never stored, a
+// key expired, or the state is older than its window plus StateKeyGrace
+// (keys written without TTL by older versions).
+func (t *Tracker) loadState(ctx context.Context) (state *RateLimitState, found bool, err error) {
+	// Fetch all state fields from Redis; a partially expired state is unknown
 	errorsRemaining, err := t.redis.Get(ctx, RedisKeyErrorsRemaining).Int()
-	if err != nil && err != redis.Nil {
-		return nil, fmt.Errorf("get errors remaining: %w", err)
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("get errors remaining: %w", err)
 	}
 
 	resetTimestamp, err := t.redis.Get(ctx, RedisKeyResetTimestamp).Int64()
-	if err != nil && err != redis.Nil {
-		return nil, fmt.Errorf("get reset timestamp: %w", err)
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("get reset timestamp: %w", err)
 	}
 
 	lastUpdateStr, err := t.redis.Get(ctx, RedisKeyLastUpdate).Result()
-	if err != nil && err != redis.Nil {
-		return nil, fmt.Errorf("get last update: %w", err)
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("get last update: %w", err)
 	}
 
-	// If no state exists in Redis, return default healthy state
-	if err == redis.Nil {
-		t.logger.Debug().Msg("No rate limit state in Redis, returning default healthy state")
-		return &RateLimitState{
-			ErrorsRemaining: 100, // Assume healthy until we get real data
-			ResetAt:         time.Now().Add(60 * time.Second),
-			LastUpdate:      time.Now(),
-			IsHealthy:       true,
-		}, nil
+	resetAt := time.Unix(resetTimestamp, 0)
+	if time.Since(resetAt) > StateKeyGrace {
+		return nil, false, nil
 	}
 
 	var lastUpdate time.Time
 	if lastUpdateStr != "" {
 		if err := json.Unmarshal([]byte(lastUpdateStr), &lastUpdate); err != nil {
-			return nil, fmt.Errorf("parse last update: %w", err)
+			return nil, false, fmt.Errorf("parse last update: %w", err)
 		}
 	}
 
-	state := &RateLimitState{
+	state = &RateLimitState{
 		ErrorsRemaining: errorsRemaining,
-		ResetAt:         time.Unix(resetTimestamp, 0),
+		ResetAt:         resetAt,
 		LastUpdate:      lastUpdate,
 	}
 	state.UpdateHealth()
 
-	return state, nil
+	return state, true, nil
+}
+
+// applyUnconfirmedErrors deducts unconfirmed local errors from the state and
+// refreshes its health flag.
+func (t *Tracker) applyUnconfirmedErrors(state *RateLimitState) {
+	t.mu.Lock()
+	unconfirmed := t.unconfirmedErrors
+	t.mu.Unlock()
+
+	state.ErrorsRemaining -= unconfirmed
+	if state.ErrorsRemaining < 0 {
+		state.ErrorsRemaining = 0
+	}
+	state.UpdateHealth()
+}
+
+// RecordErrorResponse pessimistically deducts an error response
+// (status >= 400) from the local estimate as soon as it is observed, before
+// its headers (if any) reach Redis. Error responses without
+// X-ESI-Error-Limit-Remain (e.g. Cloudflare 520 HTML pages) stay deducted
+// until the next header update reconciles the estimate.
+//
+// Call RecordErrorResponse before UpdateFromHeaders for the same response.
+func (t *Tracker) RecordErrorResponse(statusCode int, headers http.Header) {
+	if statusCode < 400 {
+		return
+	}
+
+	t.mu.Lock()
+	t.unconfirmedErrors++
+	unconfirmed := t.unconfirmedErrors
+	t.mu.Unlock()
+
+	esiRateLimitUnconfirmedErrors.Set(float64(unconfirmed))
+
+	if headers.Get("X-ESI-Error-Limit-Remain") == "" {
+		t.logger.Warn().
+			Int("status", statusCode).
+			Int("unconfirmed_errors", unconfirmed).
+			Msg("Error response without error limit headers - deducting locally")
+	}
 }
 
 // UpdateFromHeaders parses ESI rate limit headers and updates Redis state.
@@ -124,7 +235,7 @@ func (t *Tracker) UpdateFromHeaders(ctx context.Context, headers http.Header) er
 	}
 
 	// Get previous state to detect resets
-	previousState, _ := t.GetState(ctx)
+	previousState, found, _ := t.loadState(ctx)
 
 	// Create updated state
 	now := time.Now()
@@ -133,10 +244,23 @@ func (t *Tracker) UpdateFromHeaders(ctx context.Context, headers http.Header) er
 		ResetAt:         now.Add(time.Duration(resetSeconds) * time.Second),
 		LastUpdate:      now,
 	}
+
+	// Within one window the error budget only shrinks. A higher value comes
+	// from a response that was overtaken by later ones (concurrent burst)
+	// and must not raise the estimate again.
+	if found && sameWindow(previousState, state) && remain > previousState.ErrorsRemaining {
+		esiRateLimitStaleHeadersTotal.Inc()
+		t.logger.Debug().
+			Int("stale", remain).
+			Int("current", previousState.ErrorsRemaining).
+			Msg("Ignoring stale error limit header from overtaken response")
+		remain = previousState.ErrorsRemaining
+		state.ErrorsRemaining = remain
+	}
 	state.UpdateHealth()
 
 	// Detect rate limit reset (errors remaining increased significantly)
-	if previousState != nil && remain > previousState.ErrorsRemaining+50 {
+	if found && remain > previousState.ErrorsRemaining+50 {
 		esiRateLimitResetsTotal.Inc()
 		t.logger.Info().
 			Int("previous", previousState.ErrorsRemaining).
@@ -145,23 +269,23 @@ func (t *Tracker) UpdateFromHeaders(ctx context.Context, headers http.Header) er
 	}
 
 	// Store in Redis atomically
-	pipe := t.redis.Pipeline()
-	pipe.Set(ctx, RedisKeyErrorsRemaining, remain, 0)
-	pipe.Set(ctx, RedisKeyResetTimestamp, state.ResetAt.Unix(), 0)
-
-	lastUpdateJSON, err := json.Marshal(state.LastUpdate)
-	if err != nil {
-		return fmt.Errorf("marshal last update: %w", err)
+	if err := t.storeState(ctx, state); err != nil {
+		return err
 	}
-	pipe.Set(ctx, RedisKeyLastUpdate, lastUpdateJSON, 0)
 
-	_, err = pipe.Exec(ctx)
-	if err != nil {
-		return fmt.Errorf("store rate limit state in redis: %w", err)
-	}
+	// Headers are authoritative - reconcile the local estimate
+	t.mu.Lock()
+	t.unconfirmedErrors = 0
+	t.mu.Unlock()
+	esiRateLimitUnconfirmedErrors.Set(0)
 
 	// Update Prometheus metrics
 	esiErrorsRemaining.Set(float64(remain))
+	if d, ok := state.TimeToCritical(); ok {
+		esiErrorBudgetMinutesToCritical.Set(d.Minutes())
+	} else {
+		esiErrorBudgetMinutesToCritical.Set(math.Inf(1))
+	}
 
 	// Log state update
 	logEvent := t.logger.Info().
@@ -182,9 +306,45 @@ func (t *Tracker) UpdateFromHeaders(ctx context.Context, headers http.Header) er
 	return nil
 }
 
+// storeState writes the state to Redis in one pipeline. The keys expire
+// StateKeyGrace after the window resets.
+func (t *Tracker) storeState(ctx context.Context, state *RateLimitState) error {
+	ttl := time.Until(state.ResetAt) + StateKeyGrace
+	if ttl < StateKeyGrace {
+		ttl = StateKeyGrace
+	}
+
+	pipe := t.redis.Pipeline()
+	pipe.Set(ctx, RedisKeyErrorsRemaining, state.ErrorsRemaining, ttl)
+	pipe.Set(ctx, RedisKeyResetTimestamp, state.ResetAt.Unix(), ttl)
+
+	lastUpdateJSON, err := json.Marshal(state.LastUpdate)
+	if err != nil {
+		return fmt.Errorf("marshal last update: %w", err)
+	}
+	pipe.Set(ctx, RedisKeyLastUpdate, lastUpdateJSON, ttl)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("store rate limit state in redis: %w", err)
+	}
+	return nil
+}
+
+// sameWindow reports whether two states belong to the same error limit window.
+// ESI reports the reset as seconds until the window ends, so states from one
+// window share their reset time up to rounding.
+func sameWindow(previous, current *RateLimitState) bool {
+	if !previous.ResetAt.After(current.LastUpdate) {
+		return false
+	}
+	diff := current.ResetAt.Sub(previous.ResetAt)
+	return diff >= -windowTolerance && diff <= windowTolerance
+}
+
 // ShouldAllowRequest checks if a request should be allowed based on current rate limit state.
 // Returns false if the request should be blocked due to critical error limit.
-// Returns true but may sleep for throttling if in warning state.
+// Returns true but may sleep for throttling if in warning state; the sleep
+// ends early with the context error if ctx is done.
 func (t *Tracker) ShouldAllowRequest(ctx context.Context) (bool, error) {
 	state, err := t.GetState(ctx)
 	if err != nil {
@@ -197,6 +357,7 @@ func (t *Tracker) ShouldAllowRequest(ctx context.Context) (bool, error) {
 
 		t.logger.Error().
 			Int("errors_remaining", state.ErrorsRemaining).
+			Bool("shared_block", state.IsBlocked()).
 			Dur("wait_duration", waitDuration).
 			Msg("ESI error limit critical - blocking request")
 
@@ -211,7 +372,11 @@ func (t *Tracker) ShouldAllowRequest(ctx context.Context) (bool, error) {
 			Msg("ESI error limit warning - throttling request")
 
 		esiRateLimitThrottlesTotal.Inc()
-		time.Sleep(1 * time.Second)
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-time.After(1 * time.Second):
+		}
 	}
 
 	// Healthy: Allow request
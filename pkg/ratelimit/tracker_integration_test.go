@@ -4,6 +4,7 @@ package ratelimit
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"os"
 	"testing"
@@ -69,12 +70,17 @@ func TestTracker_Integration_GetState(t *testing.T) {
 		t.Fatalf("GetState() error = %v", err)
 	}
 
-	if state.ErrorsRemaining != 100 {
-		t.Errorf("Default ErrorsRemaining = %d, want 100", state.ErrorsRemaining)
+	if state.ErrorsRemaining != UnknownStateErrorsRemaining {
+		t.Errorf("Default ErrorsRemaining = %d, want %d", state.ErrorsRemaining, UnknownStateErrorsRemaining)
 	}
 
-	if !state.IsHealthy {
-		t.Error("Default state should be healthy")
+	if state.IsHealthy || !state.Unknown {
+		t.Error("Default state should be unknown and not healthy")
+	}
+
+	allowed, err := tracker.ShouldAllowRequest(ctx)
+	if err != nil || !allowed {
+		t.Errorf("ShouldAllowRequest() with unknown state = %v, %v, want allowed", allowed, err)
 	}
 
 	// Test 2: Update state and retrieve it
@@ -201,6 +207,47 @@ func TestTracker_Integration_ShouldAllowRequest_Critical(t *testing.T) {
 	}
 }
 
+func TestTracker_Integration_SharedBlock(t *testing.T) {
+	redisClient, cleanup := setupRedis(t)
+	defer cleanup()
+
+	logger := zerolog.New(os.Stderr).Level(zerolog.Disabled)
+	seen, other := NewTracker(redisClient, logger), NewTracker(redisClient, logger)
+	ctx := context.Background()
+
+	headers := http.Header{}
+	headers.Set("X-ESI-Error-Limit-Reset", "2")
+	if err := seen.RecordRateLimited(ctx, StatusErrorLimited, headers); err != nil {
+		t.Fatalf("RecordRateLimited() error = %v", err)
+	}
+
+	// A shorter block must not cut the first one short
+	headers.Set("X-ESI-Error-Limit-Reset", "1")
+	if err := other.RecordRateLimited(ctx, StatusErrorLimited, headers); err != nil {
+		t.Fatalf("RecordRateLimited() error = %v", err)
+	}
+
+	// Another instance honors the block without having seen the 420
+	allowed, err := other.ShouldAllowRequest(ctx)
+	if err != nil {
+		t.Fatalf("ShouldAllowRequest() error = %v", err)
+	}
+	if allowed {
+		t.Error("ShouldAllowRequest() = true, want false during shared block")
+	}
+
+	time.Sleep(1500 * time.Millisecond)
+	if allowed, _ := other.ShouldAllowRequest(ctx); allowed {
+		t.Error("ShouldAllowRequest() = true after 1.5s, want the longer block to hold")
+	}
+
+	// The block clears itself
+	time.Sleep(time.Second)
+	if allowed, _ := other.ShouldAllowRequest(ctx); !allowed {
+		t.Error("ShouldAllowRequest() = false, want allowed after the block expired")
+	}
+}
+
 func TestTracker_Integration_ShouldAllowRequest_Warning(t *testing.T) {
 	redisClient, cleanup := setupRedis(t)
 	defer cleanup()
@@ -237,6 +284,37 @@ func TestTracker_Integration_ShouldAllowRequest_Warning(t *testing.T) {
 	}
 }
 
+func TestTracker_Integration_ShouldAllowRequest_WarningCancelled(t *testing.T) {
+	redisClient, cleanup := setupRedis(t)
+	defer cleanup()
+
+	logger := zerolog.New(os.Stderr).Level(zerolog.Disabled)
+	tracker := NewTracker(redisClient, logger)
+
+	headers := http.Header{}
+	headers.Set("X-ESI-Error-Limit-Remain", "15")
+	headers.Set("X-ESI-Error-Limit-Reset", "60")
+	if err := tracker.UpdateFromHeaders(context.Background(), headers); err != nil {
+		t.Fatalf("UpdateFromHeaders() error = %v", err)
+	}
+
+	// The throttle sleep ends when the caller gives up
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	allowed, err := tracker.ShouldAllowRequest(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ShouldAllowRequest() error = %v, want context.DeadlineExceeded", err)
+	}
+	if allowed {
+		t.Error("ShouldAllowRequest() = true, want false after cancellation")
+	}
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Errorf("ShouldAllowRequest() returned after %v, want prompt return", d)
+	}
+}
+
 func TestTracker_Integration_ShouldAllowRequest_Healthy(t *testing.T) {
 	redisClient, cleanup := setupRedis(t)
 	defer cleanup()
@@ -307,9 +385,215 @@ func TestTracker_Integration_StateReset(t *testing.T) {
 		t.Fatalf("GetState() error = %v", err)
 	}
 
-	// Reset time should have passed
-	if state.TimeUntilReset() > 0 {
-		// This is expected - the state still shows old data until ESI sends new headers
-		t.Logf("TimeUntilReset = %v (expected 0 but state not updated from ESI)", state.TimeUntilReset())
+	// The ended window no longer blocks: the state is unknown until ESI
+	// sends new headers
+	if !state.Unknown || state.NeedsCriticalBlock() {
+		t.Errorf("state after reset = %+v, want unknown and not blocked", state)
+	}
+	allowed, err := tracker.ShouldAllowRequest(ctx)
+	if err != nil || !allowed {
+		t.Errorf("ShouldAllowRequest() after reset = %v, %v, want allowed", allowed, err)
+	}
+}
+
+func TestTracker_Integration_UnconfirmedErrorsReconciled(t *testing.T) {
+	redisClient, cleanup := setupRedis(t)
+	defer cleanup()
+
+	logger := zerolog.New(os.Stderr).Level(zerolog.Disabled)
+	tracker := NewTracker(redisClient, logger)
+	ctx := context.Background()
+
+	headers := http.Header{}
+	headers.Set("X-ESI-Error-Limit-Remain", "60")
+	headers.Set("X-ESI-Error-Limit-Reset", "60")
+	if err := tracker.UpdateFromHeaders(ctx, headers); err != nil {
+		t.Fatalf("UpdateFromHeaders() error = %v", err)
+	}
+
+	// Cloudflare-style 520 HTML page without ESI headers
+	for i := 0; i < 3; i++ {
+		tracker.RecordErrorResponse(520, http.Header{})
+	}
+
+	state, err := tracker.GetState(ctx)
+	if err != nil {
+		t.Fatalf("GetState() error = %v", err)
+	}
+	if state.ErrorsRemaining != 57 {
+		t.Errorf("ErrorsRemaining = %d, want 57 (pessimistic)", state.ErrorsRemaining)
+	}
+
+	// Next header update is authoritative
+	headers.Set("X-ESI-Error-Limit-Remain", "58")
+	if err := tracker.UpdateFromHeaders(ctx, headers); err != nil {
+		t.Fatalf("UpdateFromHeaders() error = %v", err)
+	}
+
+	state, err = tracker.GetState(ctx)
+	if err != nil {
+		t.Fatalf("GetState() error = %v", err)
+	}
+	if state.ErrorsRemaining != 58 {
+		t.Errorf("ErrorsRemaining = %d, want 58 (reconciled)", state.ErrorsRemaining)
+	}
+}
+
+func TestTracker_Integration_StaleHeaderIgnoredWithinWindow(t *testing.T) {
+	redisClient, cleanup := setupRedis(t)
+	defer cleanup()
+
+	logger := zerolog.New(os.Stderr).Level(zerolog.Disabled)
+	tracker := NewTracker(redisClient, logger)
+	ctx := context.Background()
+
+	// Burst of concurrent failures: the response reporting 40 is processed
+	// after the one reporting 35
+	for _, remain := range []string{"35", "40"} {
+		headers := http.Header{}
+		headers.Set("X-ESI-Error-Limit-Remain", remain)
+		headers.Set("X-ESI-Error-Limit-Reset", "50")
+		tracker.RecordErrorResponse(http.StatusInternalServerError, headers)
+		if err := tracker.UpdateFromHeaders(ctx, headers); err != nil {
+			t.Fatalf("UpdateFromHeaders() error = %v", err)
+		}
+	}
+
+	state, err := tracker.GetState(ctx)
+	if err != nil {
+		t.Fatalf("GetState() error = %v", err)
+	}
+	if state.ErrorsRemaining != 35 {
+		t.Errorf("ErrorsRemaining = %d, want 35 (stale header ignored)", state.ErrorsRemaining)
+	}
+}
+
+func TestQuota_Integration_Consume(t *testing.T) {
+	redisClient, cleanup := setupRedis(t)
+	defer cleanup()
+
+	logger := zerolog.New(os.Stderr).Level(zerolog.Disabled)
+	ctx := context.Background()
+
+	soft, err := NewQuota(redisClient, QuotaConfig{Name: "soft", Window: time.Hour, Limit: 2}, logger)
+	if err != nil {
+		t.Fatalf("NewQuota() error = %v", err)
+	}
+	enforced, err := NewQuota(redisClient, QuotaConfig{Name: "hard", Window: time.Hour, Limit: 2, Enforce: true}, logger)
+	if err != nil {
+		t.Fatalf("NewQuota() error = %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		usage, err := soft.Consume(ctx)
+		if err != nil {
+			t.Fatalf("Consume() on unenforced quota error = %v", err)
+		}
+		if usage.Used != int64(i+1) {
+			t.Errorf("Used = %d, want %d", usage.Used, i+1)
+		}
+
+		_, err = enforced.Consume(ctx)
+		if i < 2 && err != nil {
+			t.Fatalf("Consume() within limit error = %v", err)
+		}
+		if i == 2 && !errors.Is(err, ErrQuotaExceeded) {
+			t.Errorf("Consume() beyond limit error = %v, want ErrQuotaExceeded", err)
+		}
+	}
+
+	usage, err := soft.Usage(ctx)
+	if err != nil {
+		t.Fatalf("Usage() error = %v", err)
+	}
+	if usage.Used != 3 {
+		t.Errorf("Usage().Used = %d, want 3", usage.Used)
+	}
+}
+
+func TestTracker_Integration_ExportImportState(t *testing.T) {
+	source, cleanupSource := setupRedis(t)
+	defer cleanupSource()
+	target, cleanupTarget := setupRedis(t)
+	defer cleanupTarget()
+
+	logger := zerolog.New(os.Stderr).Level(zerolog.Disabled)
+	ctx := context.Background()
+
+	headers := http.Header{}
+	headers.Set("X-ESI-Error-Limit-Remain", "42")
+	headers.Set("X-ESI-Error-Limit-Reset", "50")
+	if err := NewTracker(source, logger).UpdateFromHeaders(ctx, headers); err != nil {
+		t.Fatalf("UpdateFromHeaders() error = %v", err)
+	}
+
+	exported, err := NewTracker(source, logger).ExportState(ctx)
+	if err != nil || exported == nil {
+		t.Fatalf("ExportState() = %v, %v", exported, err)
+	}
+
+	tracker := NewTracker(target, logger)
+	imported, err := tracker.ImportState(ctx, exported)
+	if err != nil || !imported {
+		t.Fatalf("ImportState() = %v, %v", imported, err)
+	}
+
+	state, err := tracker.GetState(ctx)
+	if err != nil {
+		t.Fatalf("GetState() error = %v", err)
+	}
+	if state.ErrorsRemaining != 42 {
+		t.Errorf("ErrorsRemaining = %d, want 42", state.ErrorsRemaining)
+	}
+	if !state.ResetAt.Equal(exported.ResetAt) {
+		t.Errorf("ResetAt = %v, want %v", state.ResetAt, exported.ResetAt)
+	}
+}
+
+func TestTracker_Integration_StateKeysExpire(t *testing.T) {
+	redisClient, cleanup := setupRedis(t)
+	defer cleanup()
+
+	logger := zerolog.New(os.Stderr).Level(zerolog.Disabled)
+	tracker := NewTracker(redisClient, logger)
+	ctx := context.Background()
+
+	headers := http.Header{}
+	headers.Set("X-ESI-Error-Limit-Remain", "75")
+	headers.Set("X-ESI-Error-Limit-Reset", "40")
+	if err := tracker.UpdateFromHeaders(ctx, headers); err != nil {
+		t.Fatalf("UpdateFromHeaders() error = %v", err)
+	}
+
+	for _, key := range []string{RedisKeyErrorsRemaining, RedisKeyResetTimestamp, RedisKeyLastUpdate} {
+		ttl := redisClient.TTL(ctx, key).Val()
+		if ttl <= 40*time.Second || ttl > 40*time.Second+StateKeyGrace {
+			t.Errorf("TTL(%s) = %v, want reset window plus grace", key, ttl)
+		}
+	}
+
+	// Ancient state written without TTL (older versions) is treated as unknown
+	old := time.Now().Add(-2 * time.Hour)
+	redisClient.Set(ctx, RedisKeyErrorsRemaining, 3, 0)
+	redisClient.Set(ctx, RedisKeyResetTimestamp, old.Unix(), 0)
+	redisClient.Set(ctx, RedisKeyLastUpdate, `"`+old.Format(time.RFC3339Nano)+`"`, 0)
+
+	state, err := tracker.GetState(ctx)
+	if err != nil {
+		t.Fatalf("GetState() error = %v", err)
+	}
+	if !state.Unknown || state.ErrorsRemaining != UnknownStateErrorsRemaining {
+		t.Errorf("GetState() = %+v, want unknown default for ancient state", state)
+	}
+
+	// A partially expired state is unknown as well, never "0 errors remaining"
+	redisClient.Set(ctx, RedisKeyResetTimestamp, time.Now().Add(time.Minute).Unix(), 0)
+	redisClient.Del(ctx, RedisKeyErrorsRemaining)
+	state, err = tracker.GetState(ctx)
+	if err != nil {
+		t.Fatalf("GetState() error = %v", err)
+	}
+	if !state.Unknown {
+		t.Errorf("GetState() = %+v, want unknown default for partial state", state)
 	}
 }
@@ -237,3 +237,101 @@ func parseIntOrZero(val string) int {
 	}
 	return result
 }
+
+func TestRecordErrorResponse_UnconfirmedErrors(t *testing.T) {
+	tracker := NewTracker(nil, zerolog.Nop())
+
+	withHeaders := http.Header{}
+	withHeaders.Set("X-ESI-Error-Limit-Remain", "80")
+	withHeaders.Set("X-ESI-Error-Limit-Reset", "60")
+
+	// Every error response counts until the next header update
+	tracker.RecordErrorResponse(http.StatusOK, http.Header{})
+	tracker.RecordErrorResponse(http.StatusNotModified, http.Header{})
+	tracker.RecordErrorResponse(http.StatusInternalServerError, withHeaders)
+	tracker.RecordErrorResponse(520, http.Header{})
+	tracker.RecordErrorResponse(http.StatusNotFound, http.Header{})
+
+	state := &RateLimitState{ErrorsRemaining: 52}
+	tracker.applyUnconfirmedErrors(state)
+
+	if state.ErrorsRemaining != 49 {
+		t.Errorf("ErrorsRemaining = %d, want 49", state.ErrorsRemaining)
+	}
+	if state.IsHealthy {
+		t.Error("State should no longer be healthy after deduction")
+	}
+
+	// Never below zero
+	state = &RateLimitState{ErrorsRemaining: 1}
+	tracker.applyUnconfirmedErrors(state)
+	if state.ErrorsRemaining != 0 {
+		t.Errorf("ErrorsRemaining = %d, want 0", state.ErrorsRemaining)
+	}
+}
+
+func TestSameWindow(t *testing.T) {
+	now := time.Now()
+	resetAt := now.Add(40 * time.Second)
+
+	tests := []struct {
+		name     string
+		previous *RateLimitState
+		current  *RateLimitState
+		want     bool
+	}{
+		{
+			name:     "same reset time",
+			previous: &RateLimitState{ResetAt: resetAt},
+			current:  &RateLimitState{ResetAt: resetAt, LastUpdate: now},
+			want:     true,
+		},
+		{
+			name:     "reset time within rounding tolerance",
+			previous: &RateLimitState{ResetAt: resetAt},
+			current:  &RateLimitState{ResetAt: resetAt.Add(time.Second), LastUpdate: now},
+			want:     true,
+		},
+		{
+			name:     "next window",
+			previous: &RateLimitState{ResetAt: resetAt},
+			current:  &RateLimitState{ResetAt: resetAt.Add(60 * time.Second), LastUpdate: now},
+			want:     false,
+		},
+		{
+			name:     "previous window already over",
+			previous: &RateLimitState{ResetAt: now.Add(-time.Second)},
+			current:  &RateLimitState{ResetAt: now.Add(-time.Second), LastUpdate: now},
+			want:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sameWindow(tt.previous, tt.current); got != tt.want {
+				t.Errorf("sameWindow() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImportState_SkipsStale(t *testing.T) {
+	tracker := NewTracker(nil, zerolog.Nop())
+
+	tests := []struct {
+		name  string
+		state *RateLimitState
+	}{
+		{"nil state", nil},
+		{"window already reset", &RateLimitState{ErrorsRemaining: 10, ResetAt: time.Now().Add(-time.Second)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			imported, err := tracker.ImportState(context.Background(), tt.state)
+			if err != nil || imported {
+				t.Errorf("ImportState() = %v, %v, want false, nil", imported, err)
+			}
+		})
+	}
+}
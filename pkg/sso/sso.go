@@ -0,0 +1,367 @@
+// Package sso verifies EVE SSO access tokens.
+//
+// EVE SSO access tokens are JWTs signed with the keys published at
+// DefaultJWKSURL. A Verifier checks the signature, issuer, audience and
+// expiry of a token and returns the character it was issued for, so the
+// character scope of cached private responses comes from the token instead
+// of what a caller claims:
+//
+//	verifier := sso.NewVerifier()
+//	claims, err := verifier.Verify(ctx, accessToken)
+//	if err != nil {
+//		return err // Forged, expired or not an EVE SSO token
+//	}
+//	ctx = client.WithAccessToken(ctx, accessToken)
+//	ctx = client.WithCharacterID(ctx, claims.CharacterID)
+//	ctx = client.WithOwnerHash(ctx, claims.OwnerHash)
+package sso
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultJWKSURL is where EVE SSO publishes its token signing keys.
+const DefaultJWKSURL = "https://login.eveonline.com/oauth/jwks"
+
+// DefaultIssuers are the issuers of EVE SSO access tokens.
+var DefaultIssuers = []string{"https://login.eveonline.com", "login.eveonline.com"}
+
+// Audience every EVE SSO access token is issued for, next to the client ID.
+const Audience = "EVE Online"
+
+// keyRefreshInterval limits how often unknown key IDs trigger a JWKS fetch.
+const keyRefreshInterval = time.Minute
+
+// ErrInvalidToken is wrapped by every error of Verify.
+var ErrInvalidToken = errors.New("invalid access token")
+
+// Claims are the verified claims of an access token.
+type Claims struct {
+	CharacterID   int64
+	CharacterName string
+	OwnerHash     string
+	Scopes        []string
+	ExpiresAt     time.Time
+}
+
+// Verifier checks EVE SSO access tokens against the published signing keys.
+// Create it with NewVerifier; it is safe for concurrent use.
+type Verifier struct {
+	// JWKSURL is the signing key set (default: DefaultJWKSURL)
+	JWKSURL string
+
+	// Issuers accepted in the iss claim (default: DefaultIssuers)
+	Issuers []string
+
+	// ClientID is required in the aud claim if set, so tokens issued to
+	// other applications are rejected (optional)
+	ClientID string
+
+	// HTTPClient fetches the key set (default: 10s timeout)
+	HTTPClient *http.Client
+
+	now func() time.Time
+
+	mu        sync.Mutex
+	keys      map[string]crypto.PublicKey // By key ID
+	fetchedAt time.Time
+}
+
+// NewVerifier returns a verifier for tokens of the EVE SSO.
+func NewVerifier() *Verifier {
+	return &Verifier{
+		JWKSURL:    DefaultJWKSURL,
+		Issuers:    DefaultIssuers,
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+		now:        time.Now,
+	}
+}
+
+// tokenHeader is the JOSE header of a token.
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// tokenClaims are the claims of an EVE SSO access token.
+type tokenClaims struct {
+	Subject   string          `json:"sub"`
+	Name      string          `json:"name"`
+	Owner     string          `json:"owner"`
+	Issuer    string          `json:"iss"`
+	Audience  json.RawMessage `json:"aud"` // String or array
+	Scopes    json.RawMessage `json:"scp"` // String or array
+	ExpiresAt int64           `json:"exp"`
+}
+
+// Verify checks the signature, issuer, audience and expiry of token and
+// returns its claims. Errors wrap ErrInvalidToken, or the error of fetching
+// the signing keys.
+func (v *Verifier) Verify(ctx context.Context, token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Claims{}, fmt.Errorf("%w: not a JWT", ErrInvalidToken)
+	}
+
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Claims{}, fmt.Errorf("%w: header: %v", ErrInvalidToken, err)
+	}
+	key, err := v.key(ctx, header.Kid)
+	if err != nil {
+		return Claims{}, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: signature: %v", ErrInvalidToken, err)
+	}
+	if err := verifySignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return Claims{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	var raw tokenClaims
+	if err := decodeSegment(parts[1], &raw); err != nil {
+		return Claims{}, fmt.Errorf("%w: claims: %v", ErrInvalidToken, err)
+	}
+	return v.checkClaims(raw)
+}
+
+// checkClaims validates the claims of a token with a valid signature.
+func (v *Verifier) checkClaims(raw tokenClaims) (Claims, error) {
+	issuers := v.Issuers
+	if issuers == nil {
+		issuers = DefaultIssuers
+	}
+	if !slices.Contains(issuers, raw.Issuer) {
+		return Claims{}, fmt.Errorf("%w: issuer %q", ErrInvalidToken, raw.Issuer)
+	}
+
+	expires := time.Unix(raw.ExpiresAt, 0)
+	if raw.ExpiresAt == 0 || !v.clock().Before(expires) {
+		return Claims{}, fmt.Errorf("%w: expired at %s", ErrInvalidToken, expires.UTC().Format(time.RFC3339))
+	}
+
+	audience, err := stringOrList(raw.Audience)
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: aud: %v", ErrInvalidToken, err)
+	}
+	if !slices.Contains(audience, Audience) || (v.ClientID != "" && !slices.Contains(audience, v.ClientID)) {
+		return Claims{}, fmt.Errorf("%w: audience %q", ErrInvalidToken, audience)
+	}
+
+	id, ok := strings.CutPrefix(raw.Subject, "CHARACTER:EVE:")
+	characterID, err := strconv.ParseInt(id, 10, 64)
+	if !ok || err != nil || characterID <= 0 {
+		return Claims{}, fmt.Errorf("%w: subject %q", ErrInvalidToken, raw.Subject)
+	}
+	if raw.Owner == "" {
+		return Claims{}, fmt.Errorf("%w: no owner hash", ErrInvalidToken)
+	}
+
+	scopes, err := stringOrList(raw.Scopes)
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: scp: %v", ErrInvalidToken, err)
+	}
+
+	return Claims{
+		CharacterID:   characterID,
+		CharacterName: raw.Name,
+		OwnerHash:     raw.Owner,
+		Scopes:        scopes,
+		ExpiresAt:     expires,
+	}, nil
+}
+
+// clock returns the current time.
+func (v *Verifier) clock() time.Time {
+	if v.now != nil {
+		return v.now()
+	}
+	return time.Now()
+}
+
+// key returns the signing key kid, fetching the key set if it is unknown
+// and was not fetched within keyRefreshInterval.
+func (v *Verifier) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if key, ok := v.keys[kid]; ok {
+		return key, nil
+	}
+	if v.keys != nil && v.clock().Sub(v.fetchedAt) < keyRefreshInterval {
+		return nil, fmt.Errorf("%w: unknown key %q", ErrInvalidToken, kid)
+	}
+
+	keys, err := v.fetchKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	v.keys, v.fetchedAt = keys, v.clock()
+
+	if key, ok := v.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: unknown key %q", ErrInvalidToken, kid)
+}
+
+// jwk is one key of a JWKS document.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// fetchKeys downloads the key set. Keys of unsupported types are skipped.
+func (v *Verifier) fetchKeys(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	url := v.JWKSURL
+	if url == "" {
+		url = DefaultJWKSURL
+	}
+	httpClient := v.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create JWKS request: %w", err)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch JWKS: status %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if key, err := k.publicKey(); err == nil {
+			keys[k.Kid] = key
+		}
+	}
+	return keys, nil
+}
+
+// publicKey converts an RSA or P-256 key.
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeInt(k.E)
+		if err != nil || !e.IsInt64() {
+			return nil, fmt.Errorf("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+// verifySignature checks an RS256 or ES256 signature of signed.
+func verifySignature(alg string, key crypto.PublicKey, signed string, signature []byte) error {
+	digest := sha256.Sum256([]byte(signed))
+	switch alg {
+	case "RS256":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("RS256 token signed with a %T", key)
+		}
+		if err := rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, digest[:], signature); err != nil {
+			return fmt.Errorf("signature: %w", err)
+		}
+		return nil
+	case "ES256":
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("ES256 token signed with a %T", key)
+		}
+		if len(signature) != 64 {
+			return fmt.Errorf("signature: invalid length %d", len(signature))
+		}
+		r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(ecKey, digest[:], r, s) {
+			return errors.New("signature: verification failed")
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported algorithm %q", alg)
+}
+
+// decodeSegment decodes a base64url JSON segment of a token.
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// decodeInt decodes a base64url big-endian integer of a JWK.
+func decodeInt(s string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(data) == 0 {
+		return nil, fmt.Errorf("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
+
+// stringOrList decodes a claim that is a string or a list of strings.
+func stringOrList(raw json.RawMessage) ([]string, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var list []string
+	if err := json.Unmarshal(raw, &list); err == nil {
+		return list, nil
+	}
+	var single string
+	if err := json.Unmarshal(raw, &single); err != nil {
+		return nil, err
+	}
+	return []string{single}, nil
+}
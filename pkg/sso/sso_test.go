@@ -0,0 +1,158 @@
+package sso
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testIssuer serves a JWKS with one RSA key and signs tokens with it.
+type testIssuer struct {
+	key     *rsa.PrivateKey
+	server  *httptest.Server
+	fetches atomic.Int32
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer := &testIssuer{key: key}
+	issuer.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		issuer.fetches.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "JWT-Signature-Key",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+// sign returns an RS256 token with claims, signed by key ID kid.
+func (i *testIssuer) sign(t *testing.T, kid string, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, i.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// validClaims returns the claims of a valid token of character 90000001.
+func validClaims() map[string]any {
+	return map[string]any{
+		"sub":   "CHARACTER:EVE:90000001",
+		"name":  "Test Pilot",
+		"owner": "owner-hash",
+		"iss":   "https://login.eveonline.com",
+		"aud":   []string{"client-id", "EVE Online"},
+		"scp":   []string{"esi-wallet.read_character_wallet.v1", "esi-skills.read_skills.v1"},
+		"exp":   time.Now().Add(20 * time.Minute).Unix(),
+	}
+}
+
+func TestVerify(t *testing.T) {
+	issuer := newTestIssuer(t)
+	verifier := NewVerifier()
+	verifier.JWKSURL = issuer.server.URL
+	verifier.ClientID = "client-id"
+
+	claims, err := verifier.Verify(context.Background(), issuer.sign(t, "JWT-Signature-Key", validClaims()))
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if claims.CharacterID != 90000001 || claims.OwnerHash != "owner-hash" || claims.CharacterName != "Test Pilot" || len(claims.Scopes) != 2 {
+		t.Errorf("Verify() = %+v", claims)
+	}
+
+	// A single scope is a string
+	single := validClaims()
+	single["scp"] = "esi-wallet.read_character_wallet.v1"
+	if claims, err := verifier.Verify(context.Background(), issuer.sign(t, "JWT-Signature-Key", single)); err != nil || len(claims.Scopes) != 1 {
+		t.Errorf("Verify() = %+v, %v for a single scope", claims, err)
+	}
+}
+
+func TestVerify_Rejects(t *testing.T) {
+	issuer := newTestIssuer(t)
+	other := newTestIssuer(t)
+
+	with := func(name string, value any) map[string]any {
+		claims := validClaims()
+		claims[name] = value
+		return claims
+	}
+	valid := issuer.sign(t, "JWT-Signature-Key", validClaims())
+	parts := strings.Split(valid, ".")
+	forged, _ := json.Marshal(with("sub", "CHARACTER:EVE:90000002"))
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"not a JWT", "token"},
+		{"expired", issuer.sign(t, "JWT-Signature-Key", with("exp", time.Now().Add(-time.Minute).Unix()))},
+		{"no expiry", issuer.sign(t, "JWT-Signature-Key", with("exp", 0))},
+		{"other issuer", issuer.sign(t, "JWT-Signature-Key", with("iss", "https://evil.example"))},
+		{"other application", issuer.sign(t, "JWT-Signature-Key", with("aud", []string{"other-client", "EVE Online"}))},
+		{"no character", issuer.sign(t, "JWT-Signature-Key", with("sub", "CORPORATION:EVE:98000001"))},
+		{"no owner hash", issuer.sign(t, "JWT-Signature-Key", with("owner", ""))},
+		{"foreign key", other.sign(t, "JWT-Signature-Key", validClaims())},
+		{"unknown key", issuer.sign(t, "other-key", validClaims())},
+		{"forged claims", parts[0] + "." + base64.RawURLEncoding.EncodeToString(forged) + "." + parts[2]},
+		{"unsigned", base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","kid":"JWT-Signature-Key"}`)) + "." + parts[1] + "."},
+	}
+
+	verifier := NewVerifier()
+	verifier.JWKSURL = issuer.server.URL
+	verifier.ClientID = "client-id"
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := verifier.Verify(context.Background(), tt.token); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Verify() error = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+
+	// Unknown key IDs do not fetch the key set on every token
+	if got := issuer.fetches.Load(); got != 1 {
+		t.Errorf("JWKS fetched %d times, want 1", got)
+	}
+}
+
+func TestVerify_JWKSUnavailable(t *testing.T) {
+	issuer := newTestIssuer(t)
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+
+	verifier := NewVerifier()
+	verifier.JWKSURL = down.URL
+	_, err := verifier.Verify(context.Background(), issuer.sign(t, "JWT-Signature-Key", validClaims()))
+	if err == nil || errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Verify() error = %v, want a key set error", err)
+	}
+}
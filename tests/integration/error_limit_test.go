@@ -0,0 +1,114 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/internal/testutil"
+	"github.com/Sternrassler/eve-esi-client/pkg/client"
+	"github.com/Sternrassler/eve-esi-client/pkg/ratelimit"
+	"github.com/redis/go-redis/v9"
+)
+
+// TestErrorLimit_NearBan drives the client through ESI's error limit per
+// ADR-006: requests pass while the budget is healthy, are throttled below
+// ErrorThresholdWarning, are blocked without reaching ESI below
+// ErrorThresholdCritical, and resume as soon as the window resets.
+func TestErrorLimit_NearBan(t *testing.T) {
+	redisClient, cleanup := setupRedis(t)
+	defer cleanup()
+
+	testNearBan(t, redisClient)
+}
+
+// testNearBan runs the near-ban scenario against redisClient.
+func testNearBan(t *testing.T, redisClient *redis.Client) {
+	mockESI := testutil.NewMockESI()
+	defer mockESI.Close()
+
+	budget := testutil.NewErrorBudget(100, 6*time.Second)
+	mockESI.SetErrorBudget(budget)
+	mockESI.SetResponse("/v1/status/", testutil.MockESIResponse{StatusCode: http.StatusOK, Body: `{"players":1}`})
+	mockESI.SetResponse("/v1/universe/types/0/", testutil.MockESIResponse{StatusCode: http.StatusNotFound, Body: `{"error":"Type not found"}`})
+
+	cfg := client.DefaultConfig(redisClient, "TestApp/1.0.0 (test@example.com)")
+	esiClient, err := client.New(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer esiClient.Close()
+	esiClient.SetHTTPClient(&http.Client{Transport: &testTransport{mockServer: mockESI}, Timeout: 5 * time.Second})
+
+	ctx := context.Background()
+	get := func(endpoint string) (time.Duration, int, error) {
+		t.Helper()
+		start := time.Now()
+		resp, err := esiClient.Get(ctx, endpoint)
+		if err != nil {
+			return time.Since(start), 0, err
+		}
+		defer resp.Body.Close()
+		_, _ = io.ReadAll(resp.Body)
+		return time.Since(start), resp.StatusCode, nil
+	}
+
+	// Healthy: requests pass without delay
+	if d, status, err := get("/v1/status/"); err != nil || status != http.StatusOK || d > 500*time.Millisecond {
+		t.Fatalf("healthy request = %d, %v after %v, want 200 without delay", status, err, d)
+	}
+
+	// Errors of other applications on the same IP, plus one of our own,
+	// leave fewer than ErrorThresholdWarning
+	budget.Spend(100 - ratelimit.ErrorThresholdWarning)
+	if _, status, err := get("/v1/universe/types/0/"); err != nil || status != http.StatusNotFound {
+		t.Fatalf("error request = %d, %v, want 404", status, err)
+	}
+
+	// Warning: requests are throttled but still sent
+	before := mockESI.GetRequestCount()
+	if d, status, err := get("/v1/status/"); err != nil || status != http.StatusOK || d < time.Second {
+		t.Errorf("warning request = %d, %v after %v, want 200 after >= 1s throttle", status, err, d)
+	}
+	if got := mockESI.GetRequestCount() - before; got != 1 {
+		t.Errorf("ESI requests while throttled = %d, want 1", got)
+	}
+
+	// Critical: the next error leaves fewer than ErrorThresholdCritical
+	budget.Spend(budget.Remaining() - ratelimit.ErrorThresholdCritical)
+	if _, status, err := get("/v1/universe/types/0/"); err != nil || status != http.StatusNotFound {
+		t.Fatalf("error request = %d, %v, want 404", status, err)
+	}
+	if got := budget.Remaining(); got >= ratelimit.ErrorThresholdCritical {
+		t.Fatalf("budget = %d, want < %d", got, ratelimit.ErrorThresholdCritical)
+	}
+
+	// Blocked: requests fail fast and never reach ESI until the reset
+	before = mockESI.GetRequestCount()
+	for time.Until(budget.ResetAt()) > time.Second {
+		d, _, err := get("/v1/status/")
+		if !errors.Is(err, client.ErrRateLimited) {
+			t.Fatalf("request %v before reset: error = %v, want ErrRateLimited", time.Until(budget.ResetAt()), err)
+		}
+		if d > 100*time.Millisecond {
+			t.Errorf("blocked request took %v, want fail fast", d)
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+	if got := mockESI.GetRequestCount() - before; got != 0 {
+		t.Errorf("ESI requests while blocked = %d, want 0", got)
+	}
+	if got := budget.Discarded(); got != 0 {
+		t.Errorf("requests discarded by ESI = %d, want 0", got)
+	}
+
+	// Resumed: the reset header is rounded up to whole seconds, so the
+	// client resumes within a second after ESI refilled the budget
+	time.Sleep(time.Until(budget.ResetAt()) + time.Second + 100*time.Millisecond)
+	if d, status, err := get("/v1/status/"); err != nil || status != http.StatusOK || d > 500*time.Millisecond {
+		t.Errorf("request after reset = %d, %v after %v, want 200 without delay", status, err, d)
+	}
+}
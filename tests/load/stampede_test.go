@@ -0,0 +1,339 @@
+//go:build load
+
+// Package load stress-tests the client's behavior under many concurrent
+// consumers. Run with:
+//
+//	go test -tags load -v ./tests/load/...
+//
+// It needs a Redis at REDIS_URL (default: localhost:6379, DB 14 is
+// flushed) and is skipped without one. LOAD_CONSUMERS sets the number of
+// concurrent consumers per scenario (default: 500).
+package load
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Sternrassler/eve-esi-client/internal/testutil"
+	"github.com/Sternrassler/eve-esi-client/pkg/client"
+	"github.com/redis/go-redis/v9"
+)
+
+// upstreamDelay keeps every ESI request in flight long enough for all
+// consumers of a scenario to pile up behind it.
+const upstreamDelay = 200 * time.Millisecond
+
+// setupRedis connects to the Redis at REDIS_URL and flushes the test DB.
+func setupRedis(t *testing.T) *redis.Client {
+	t.Helper()
+
+	addr := os.Getenv("REDIS_URL")
+	if addr == "" {
+		addr = "localhost:6379"
+	}
+	redisClient := redis.NewClient(&redis.Options{Addr: addr, DB: 14})
+	if err := redisClient.Ping(context.Background()).Err(); err != nil {
+		t.Skipf("Redis not available at %s: %v", addr, err)
+	}
+	if err := redisClient.FlushDB(context.Background()).Err(); err != nil {
+		t.Fatalf("Failed to flush Redis: %v", err)
+	}
+	t.Cleanup(func() {
+		redisClient.FlushDB(context.Background())
+		redisClient.Close()
+	})
+	return redisClient
+}
+
+// consumers returns the number of concurrent consumers per scenario.
+func consumers(t *testing.T) int {
+	t.Helper()
+
+	n, err := strconv.Atoi(os.Getenv("LOAD_CONSUMERS"))
+	if err != nil || n <= 0 {
+		return 500
+	}
+	return n
+}
+
+// testTransport redirects ESI requests to the mock server.
+type testTransport struct {
+	mockESI *testutil.MockESI
+}
+
+func (t *testTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.URL.Scheme = "http"
+	req.URL.Host = strings.TrimPrefix(t.mockESI.URL(), "http://")
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// newClient returns a client talking to mockESI.
+func newClient(t *testing.T, redisClient *redis.Client, mockESI *testutil.MockESI, configure func(*client.Config)) *client.Client {
+	t.Helper()
+
+	cfg := client.DefaultConfig(redisClient, "LoadTest/1.0.0 (test@example.com)")
+	if configure != nil {
+		configure(&cfg)
+	}
+	c, err := client.New(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	c.SetHTTPClient(&http.Client{Transport: &testTransport{mockESI: mockESI}, Timeout: 10 * time.Second})
+	return c
+}
+
+// esiHandler answers like ESI after upstreamDelay: 304 if the request
+// carries the current ETag, the body otherwise. status overrides the
+// response with an error status if set.
+func esiHandler(etag, body string, expires time.Duration, status *atomic.Int64) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(upstreamDelay)
+		w.Header().Set("X-ESI-Error-Limit-Remain", "100")
+		w.Header().Set("X-ESI-Error-Limit-Reset", "60")
+		if code := status.Load(); code != 0 {
+			w.WriteHeader(int(code))
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Expires", time.Now().Add(expires).UTC().Format(http.TimeFormat))
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte(body))
+	}
+}
+
+// result is what one consumer got.
+type result struct {
+	status      int
+	cacheStatus string
+	body        string
+	err         error
+}
+
+// stampede runs n consumers against endpoint at once and returns their
+// results.
+func stampede(c *client.Client, endpoint string, n int) []result {
+	results := make([]result, n)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			resp, err := c.Get(context.Background(), endpoint)
+			if err != nil {
+				results[i].err = err
+				return
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			results[i] = result{
+				status:      resp.StatusCode,
+				cacheStatus: resp.Header.Get(client.CacheStatusHeader),
+				body:        string(body),
+				err:         err,
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+	return results
+}
+
+// checkResults fails the test unless every consumer got body with one of
+// the cache statuses.
+func checkResults(t *testing.T, results []result, body string, cacheStatuses ...string) {
+	t.Helper()
+
+	failed := 0
+	for _, r := range results {
+		ok := r.err == nil && r.status == http.StatusOK && r.body == body
+		if ok {
+			ok = false
+			for _, s := range cacheStatuses {
+				ok = ok || r.cacheStatus == s
+			}
+		}
+		if !ok {
+			if failed < 5 {
+				t.Errorf("consumer got status=%d cache=%s body=%q err=%v, want 200 %v %q", r.status, r.cacheStatus, r.body, r.err, cacheStatuses, body)
+			}
+			failed++
+		}
+	}
+	if failed > 0 {
+		t.Errorf("%d of %d consumers got a wrong result", failed, len(results))
+	}
+}
+
+// TestStampede_ColdCache: consumers of one client requesting an uncached
+// endpoint at once share a single ESI request.
+func TestStampede_ColdCache(t *testing.T) {
+	redisClient := setupRedis(t)
+	mockESI := testutil.NewMockESI()
+	defer mockESI.Close()
+	mockESI.SetHandler("/v1/status/", esiHandler(`"cold"`, `{"players":1}`, time.Minute, new(atomic.Int64)))
+
+	c := newClient(t, redisClient, mockESI, nil)
+	results := stampede(c, "/v1/status/", consumers(t))
+
+	checkResults(t, results, `{"players":1}`, client.CacheStatusMiss)
+	if got := mockESI.GetRequestCount(); got != 1 {
+		t.Errorf("ESI requests = %d, want 1", got)
+	}
+}
+
+// TestStampede_Revalidation: consumers of a cached endpoint share a single
+// conditional request and all get the cached body.
+func TestStampede_Revalidation(t *testing.T) {
+	redisClient := setupRedis(t)
+	mockESI := testutil.NewMockESI()
+	defer mockESI.Close()
+	mockESI.SetHandler("/v1/status/", esiHandler(`"warm"`, `{"players":2}`, time.Minute, new(atomic.Int64)))
+
+	c := newClient(t, redisClient, mockESI, nil)
+	checkResults(t, stampede(c, "/v1/status/", 1), `{"players":2}`, client.CacheStatusMiss)
+	mockESI.Reset()
+
+	results := stampede(c, "/v1/status/", consumers(t))
+
+	checkResults(t, results, `{"players":2}`, client.CacheStatusHit)
+	if got := mockESI.GetRequestCount(); got != 1 {
+		t.Errorf("ESI requests = %d, want 1", got)
+	}
+	if got := mockESI.GetConditionalCount(); got != 1 {
+		t.Errorf("conditional ESI requests = %d, want 1", got)
+	}
+}
+
+// TestStampede_ManyKeys: coalescing is per cache key; each endpoint costs
+// one ESI request however many consumers ask for it.
+func TestStampede_ManyKeys(t *testing.T) {
+	redisClient := setupRedis(t)
+	mockESI := testutil.NewMockESI()
+	defer mockESI.Close()
+
+	const endpoints = 20
+	for i := range endpoints {
+		body := fmt.Sprintf(`{"type_id":%d}`, i)
+		mockESI.SetHandler(fmt.Sprintf("/v3/universe/types/%d/", i), esiHandler(fmt.Sprintf(`"t%d"`, i), body, time.Minute, new(atomic.Int64)))
+	}
+
+	c := newClient(t, redisClient, mockESI, nil)
+	n := consumers(t)
+	var wg sync.WaitGroup
+	for i := range endpoints {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results := stampede(c, fmt.Sprintf("/v3/universe/types/%d/", i), n/endpoints)
+			checkResults(t, results, fmt.Sprintf(`{"type_id":%d}`, i), client.CacheStatusMiss)
+		}()
+	}
+	wg.Wait()
+
+	if got := mockESI.GetRequestCount(); got != endpoints {
+		t.Errorf("ESI requests = %d, want %d", got, endpoints)
+	}
+}
+
+// TestStampede_Instances: clients sharing Redis (several esi-proxy replicas)
+// coalesce only their own consumers; there is no lock across processes, so
+// each instance sends at most one request per endpoint.
+func TestStampede_Instances(t *testing.T) {
+	redisClient := setupRedis(t)
+	mockESI := testutil.NewMockESI()
+	defer mockESI.Close()
+	mockESI.SetHandler("/v1/status/", esiHandler(`"shared"`, `{"players":3}`, time.Minute, new(atomic.Int64)))
+
+	const instances = 4
+	n := consumers(t)
+	var wg sync.WaitGroup
+	for range instances {
+		c := newClient(t, redisClient, mockESI, nil)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results := stampede(c, "/v1/status/", n/instances)
+			checkResults(t, results, `{"players":3}`, client.CacheStatusMiss, client.CacheStatusHit)
+		}()
+	}
+	wg.Wait()
+
+	if got := mockESI.GetRequestCount(); got < 1 || got > instances {
+		t.Errorf("ESI requests = %d, want 1..%d", got, instances)
+	}
+}
+
+// TestStampede_StaleOnError: while ESI fails, consumers of an expired entry
+// share one failing request (with its retries) and all get the stale body.
+func TestStampede_StaleOnError(t *testing.T) {
+	redisClient := setupRedis(t)
+	mockESI := testutil.NewMockESI()
+	defer mockESI.Close()
+	var status atomic.Int64
+	mockESI.SetHandler("/v1/status/", esiHandler(`"stale"`, `{"players":4}`, time.Second, &status))
+
+	c := newClient(t, redisClient, mockESI, func(cfg *client.Config) {
+		cfg.ServeStaleOnError = true
+	})
+	checkResults(t, stampede(c, "/v1/status/", 1), `{"players":4}`, client.CacheStatusMiss)
+
+	// Let the entry expire, then break ESI
+	time.Sleep(2 * time.Second)
+	status.Store(http.StatusServiceUnavailable)
+	mockESI.Reset()
+
+	results := stampede(c, "/v1/status/", consumers(t))
+
+	checkResults(t, results, `{"players":4}`, client.CacheStatusStale)
+	attempts := client.RetryConfigForErrorClass(client.ErrorClassServer).MaxAttempts
+	if got := mockESI.GetRequestCount(); got != attempts {
+		t.Errorf("ESI requests = %d, want %d (one request and its retries)", got, attempts)
+	}
+}
+
+// TestStampede_LeaderCancelled: when the consumer whose request is in flight
+// gives up, the waiting consumers make the request again instead of failing.
+func TestStampede_LeaderCancelled(t *testing.T) {
+	redisClient := setupRedis(t)
+	mockESI := testutil.NewMockESI()
+	defer mockESI.Close()
+	mockESI.SetHandler("/v1/status/", esiHandler(`"cancel"`, `{"players":5}`, time.Minute, new(atomic.Int64)))
+
+	c := newClient(t, redisClient, mockESI, nil)
+
+	// The impatient consumer starts first and leads the request
+	impatient, cancel := context.WithTimeout(context.Background(), upstreamDelay/2)
+	defer cancel()
+	impatientDone := make(chan error, 1)
+	go func() {
+		_, err := c.Get(impatient, "/v1/status/")
+		impatientDone <- err
+	}()
+	time.Sleep(upstreamDelay / 4)
+
+	results := stampede(c, "/v1/status/", consumers(t))
+
+	if err := <-impatientDone; err == nil {
+		t.Error("impatient consumer succeeded, want deadline error")
+	}
+	checkResults(t, results, `{"players":5}`, client.CacheStatusMiss)
+	if got := mockESI.GetRequestCount(); got != 2 {
+		t.Errorf("ESI requests = %d, want 2 (cancelled one, repeated one)", got)
+	}
+}